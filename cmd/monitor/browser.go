@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/config"
+	"log"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// browserReadyTimeout 等待Web服务器开始监听的最长时间，超时（例如端口被占用）则不打开浏览器
+const browserReadyTimeout = 10 * time.Second
+
+// browserOpener 打开浏览器的方式（默认调用系统浏览器）
+type browserOpener interface {
+	Open(url string) error
+}
+
+// systemBrowser 根据操作系统调用默认浏览器
+type systemBrowser struct{}
+
+// Open 根据操作系统打开默认浏览器
+func (systemBrowser) Open(url string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default: // linux, freebsd, openbsd, netbsd
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// shouldOpenBrowser 判断是否自动打开浏览器
+// NO_BROWSER=true / --no-browser、CI 环境、或无图形界面（见 headlessReason）时跳过
+func shouldOpenBrowser(cfg *config.Config, goos string, getenv func(string) string) bool {
+	if cfg.NoBrowser {
+		return false
+	}
+	if reason := headlessReason(goos, getenv); reason != "" {
+		log.Printf("[Browser] %s, not opening browser", reason)
+		return false
+	}
+	return true
+}
+
+// headlessReason 检测无图形界面的运行环境，返回原因，有图形界面时返回空字符串
+// Linux/BSD 下 DISPLAY/WAYLAND_DISPLAY 均为空视为无图形界面；SSH 会话（即使转发了X11）打开的浏览器也不在用户面前
+func headlessReason(goos string, getenv func(string) string) string {
+	if getenv("CI") != "" {
+		return "CI environment detected"
+	}
+	if getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != "" {
+		return "SSH session detected"
+	}
+	if goos != "windows" && goos != "darwin" &&
+		getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == "" {
+		return "No display detected (headless)"
+	}
+	return ""
+}
+
+// openBrowserWhenReady 等待Web服务器开始监听后打开浏览器，超时则放弃
+func openBrowserWhenReady(opener browserOpener, ready <-chan struct{}, url string, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+	case <-timer.C:
+		log.Printf("[Browser] Web server not ready after %v, not opening browser", timeout)
+		return
+	}
+
+	if err := opener.Open(url); err != nil {
+		log.Printf("[Browser] Failed to open browser: %v", err)
+	} else {
+		log.Printf("[Browser] Opening %s in default browser", url)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/config"
+	"testing"
+	"time"
+)
+
+// envFrom 由map构造 getenv
+func envFrom(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestShouldOpenBrowser(t *testing.T) {
+	desktop := map[string]string{"DISPLAY": ":0"}
+	tests := []struct {
+		name      string
+		noBrowser bool
+		goos      string
+		env       map[string]string
+		want      bool
+	}{
+		{"linux desktop", false, "linux", desktop, true},
+		{"wayland desktop", false, "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, true},
+		{"flag set on desktop", true, "linux", desktop, false},
+		{"flag set on windows", true, "windows", nil, false},
+		{"linux headless", false, "linux", nil, false},
+		{"windows without DISPLAY", false, "windows", nil, true},
+		{"darwin without DISPLAY", false, "darwin", nil, true},
+		{"CI", false, "windows", map[string]string{"CI": "true"}, false},
+		{"SSH with X11 forwarding", false, "linux", map[string]string{"DISPLAY": "localhost:10.0", "SSH_CONNECTION": "1.2.3.4 22"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{NoBrowser: tt.noBrowser}
+			if got := shouldOpenBrowser(cfg, tt.goos, envFrom(tt.env)); got != tt.want {
+				t.Fatalf("shouldOpenBrowser = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingBrowser 记录打开的URL
+type recordingBrowser struct {
+	opened chan string
+}
+
+func (b *recordingBrowser) Open(url string) error {
+	b.opened <- url
+	return nil
+}
+
+func TestOpenBrowserWaitsForServer(t *testing.T) {
+	browser := &recordingBrowser{opened: make(chan string, 1)}
+	ready := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		openBrowserWhenReady(browser, ready, "http://localhost:8080/", time.Second)
+	}()
+
+	select {
+	case url := <-browser.opened:
+		t.Fatalf("browser opened %s before the server was ready", url)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(ready)
+	<-done
+	if url := <-browser.opened; url != "http://localhost:8080/" {
+		t.Fatalf("opened %q", url)
+	}
+}
+
+func TestOpenBrowserGivesUpWhenServerNeverReady(t *testing.T) {
+	browser := &recordingBrowser{opened: make(chan string, 1)}
+	openBrowserWhenReady(browser, make(chan struct{}), "http://localhost:8080/", 10*time.Millisecond)
+	select {
+	case url := <-browser.opened:
+		t.Fatalf("browser opened %s although the server never became ready", url)
+	default:
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/exchange/lighter"
+	"crypto-arbitrage-monitor/internal/web"
+	"crypto-arbitrage-monitor/pkg/common"
+	"sync/atomic"
+)
+
+// lighterDepth Lighter 订单簿深度：读取连接池维护的本地订单簿
+type lighterDepth struct {
+	pool atomic.Pointer[lighter.WSPool] // 连接池启动成功后设置
+}
+
+// Depth 实现 web.DepthProvider
+func (d *lighterDepth) Depth(marketType common.MarketType, symbol string, levels int) ([]web.DepthLevel, []web.DepthLevel, bool) {
+	pool := d.pool.Load()
+	if pool == nil {
+		return nil, nil, false
+	}
+	bids, asks, ok := pool.OrderBookDepth(symbol, marketType, levels)
+	if !ok {
+		return nil, nil, false
+	}
+	return toDepthLevels(bids), toDepthLevels(asks), true
+}
+
+// toDepthLevels 转换为 web 的盘口档位
+func toDepthLevels(orders []lighter.Order) []web.DepthLevel {
+	levels := make([]web.DepthLevel, 0, len(orders))
+	for _, order := range orders {
+		levels = append(levels, web.DepthLevel{Price: order.Price, Size: order.Amount})
+	}
+	return levels
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/config"
+	"crypto-arbitrage-monitor/pkg/common"
+	"log"
+	"strings"
+)
+
+// sourceExchanges 数据源所属的交易所
+var sourceExchanges = map[string]common.Exchange{
+	sourceAsterWS:          common.ExchangeAster,
+	sourceAsterSpotWS:      common.ExchangeAster,
+	sourceAsterREST:        common.ExchangeAster,
+	sourceLighterWS:        common.ExchangeLighter,
+	sourceLighterREST:      common.ExchangeLighter,
+	sourceBinanceSpotWS:    common.ExchangeBinance,
+	sourceBinanceFuturesWS: common.ExchangeBinance,
+	sourceBinanceREST:      common.ExchangeBinance,
+}
+
+// exchangeFlags 交易所开关和对应的环境变量（日志使用），按启动顺序
+var exchangeFlags = []struct {
+	exchange common.Exchange
+	name     string
+	env      string
+}{
+	{common.ExchangeAster, "Aster", "ASTER_ENABLED"},
+	{common.ExchangeLighter, "Lighter", "LIGHTER_ENABLED"},
+	{common.ExchangeBinance, "Binance", "BINANCE_ENABLED"},
+}
+
+// exchangeEnabled 交易所是否启用（ASTER_ENABLED / LIGHTER_ENABLED / BINANCE_ENABLED）
+func exchangeEnabled(cfg *config.Config, exchange common.Exchange) bool {
+	switch exchange {
+	case common.ExchangeAster:
+		return cfg.AsterEnabled
+	case common.ExchangeLighter:
+		return cfg.LighterEnabled
+	case common.ExchangeBinance:
+		return cfg.BinanceEnabled
+	}
+	return false
+}
+
+// sourceEnabled 数据源是否需要启动：所属交易所已启用，Aster 现货 WebSocket 另外需要 ASTER_SPOT_WS_ENABLED
+func sourceEnabled(cfg *config.Config, source string) bool {
+	exchange, exists := sourceExchanges[source]
+	if !exists || !exchangeEnabled(cfg, exchange) {
+		return false
+	}
+	if source == sourceAsterSpotWS {
+		return cfg.AsterSpotWSEnabled
+	}
+	return true
+}
+
+// logExchangeFlags 输出各交易所的启用状态，全部未启用时提示不会采集任何价格
+func logExchangeFlags(cfg *config.Config) {
+	anyEnabled := false
+	for _, flag := range exchangeFlags {
+		if exchangeEnabled(cfg, flag.exchange) {
+			anyEnabled = true
+			log.Printf("[%s] Enabled", flag.name)
+		} else {
+			log.Printf("[%s] Disabled (%s=false)", flag.name, flag.env)
+		}
+	}
+	if !anyEnabled {
+		log.Println("[Exchanges] All exchanges are disabled (ASTER_ENABLED / LIGHTER_ENABLED / BINANCE_ENABLED), no prices will be collected")
+	}
+}
+
+// sourceLauncher 启动数据源（sourceSupervisor）
+type sourceLauncher interface {
+	Start(name string, start sourceStartFunc)
+}
+
+// taskLauncher 运行后台任务（taskRunner）
+type taskLauncher interface {
+	supervise(name string, task func())
+}
+
+// startSources 按 allSources 的顺序启动已启用的 WebSocket 数据源，未启用交易所的数据源不调用其启动函数
+func startSources(cfg *config.Config, launcher sourceLauncher, starts map[string]sourceStartFunc) {
+	for _, source := range allSources {
+		start, exists := starts[source]
+		if !exists || !sourceEnabled(cfg, source) {
+			continue
+		}
+		launcher.Start(source, start)
+	}
+}
+
+// startRESTUpdaters 为已启用的交易所启动 REST 刷新任务（key 为数据源名称，任务名为 aster-rest 等），
+// 未启用交易所的刷新goroutine不会启动
+func startRESTUpdaters(cfg *config.Config, launcher taskLauncher, updaters map[string]func()) {
+	for _, source := range allSources {
+		updater, exists := updaters[source]
+		if !exists || !sourceEnabled(cfg, source) {
+			continue
+		}
+		launcher.supervise(strings.ReplaceAll(source, "_", "-"), updater)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/config"
+	"reflect"
+	"testing"
+)
+
+// recordingLauncher 记录启动的数据源和任务，不实际启动
+type recordingLauncher struct {
+	started []string
+}
+
+func (l *recordingLauncher) Start(name string, start sourceStartFunc) {
+	l.started = append(l.started, name)
+}
+
+func (l *recordingLauncher) supervise(name string, task func()) {
+	l.started = append(l.started, name)
+}
+
+func TestDisabledExchangesAreNotStarted(t *testing.T) {
+	// 与 main 一样为每个数据源都提供启动函数，由开关决定哪些交给 launcher
+	starts := make(map[string]sourceStartFunc)
+	for _, source := range []string{sourceAsterWS, sourceAsterSpotWS, sourceLighterWS, sourceBinanceSpotWS, sourceBinanceFuturesWS} {
+		starts[source] = func() (func(), error) { return nil, nil }
+	}
+	updaters := make(map[string]func())
+	for _, source := range []string{sourceAsterREST, sourceLighterREST, sourceBinanceREST} {
+		updaters[source] = func() {}
+	}
+
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		wantSources []string
+		wantTasks   []string
+	}{
+		{
+			name:        "all enabled",
+			cfg:         config.Config{AsterEnabled: true, AsterSpotWSEnabled: true, LighterEnabled: true, BinanceEnabled: true},
+			wantSources: []string{sourceAsterWS, sourceAsterSpotWS, sourceLighterWS, sourceBinanceSpotWS, sourceBinanceFuturesWS},
+			wantTasks:   []string{"aster-rest", "lighter-rest", "binance-rest"},
+		},
+		{
+			name:        "lighter only",
+			cfg:         config.Config{AsterSpotWSEnabled: true, LighterEnabled: true},
+			wantSources: []string{sourceLighterWS},
+			wantTasks:   []string{"lighter-rest"},
+		},
+		{
+			name:        "aster without spot websocket",
+			cfg:         config.Config{AsterEnabled: true},
+			wantSources: []string{sourceAsterWS},
+			wantTasks:   []string{"aster-rest"},
+		},
+		{
+			name:        "all disabled",
+			cfg:         config.Config{AsterSpotWSEnabled: true},
+			wantSources: []string{},
+			wantTasks:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources, tasks := &recordingLauncher{started: []string{}}, &recordingLauncher{started: []string{}}
+			startSources(&tt.cfg, sources, starts)
+			startRESTUpdaters(&tt.cfg, tasks, updaters)
+
+			if !reflect.DeepEqual(sources.started, tt.wantSources) {
+				t.Errorf("sources started = %v, want %v", sources.started, tt.wantSources)
+			}
+			if !reflect.DeepEqual(tasks.started, tt.wantTasks) {
+				t.Errorf("REST updaters started = %v, want %v", tasks.started, tt.wantTasks)
+			}
+		})
+	}
+}
+
+func TestSourceExchangesCoverAllSources(t *testing.T) {
+	for _, source := range allSources {
+		if _, exists := sourceExchanges[source]; !exists {
+			t.Errorf("source %s has no exchange, it would never start", source)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"log"
+	"strings"
+	"time"
+)
+
+// 数据源名称（SECONDARY_SOURCES 使用）
+const (
+	sourceAsterWS          = "aster_ws"
+	sourceAsterSpotWS      = "aster_spot_ws"
+	sourceAsterREST        = "aster_rest"
+	sourceLighterWS        = "lighter_ws"
+	sourceLighterREST      = "lighter_rest"
+	sourceBinanceSpotWS    = "binance_spot_ws"
+	sourceBinanceFuturesWS = "binance_futures_ws"
+	sourceBinanceREST      = "binance_rest"
+)
+
+// allSources 所有数据源
+var allSources = []string{
+	sourceAsterWS,
+	sourceAsterSpotWS,
+	sourceAsterREST,
+	sourceLighterWS,
+	sourceLighterREST,
+	sourceBinanceSpotWS,
+	sourceBinanceFuturesWS,
+	sourceBinanceREST,
+}
+
+// priceSink 数据源写入价格的目标
+type priceSink interface {
+	UpdatePrice(price *common.Price) bool
+	RecordFetchLatency(exchange common.Exchange, duration time.Duration)
+	RecordFetchError(exchange common.Exchange, endpoint string, err error)
+	FetchBackoff(exchange common.Exchange, endpoint string) time.Duration
+}
+
+// teeSink 同时写入默认存储和副存储
+type teeSink struct {
+	primary   *pricestore.PriceStore
+	secondary *pricestore.PriceStore
+}
+
+// UpdatePrice 依次写入副存储和默认存储（两个存储各自保存副本），返回默认存储是否实际更新
+func (t *teeSink) UpdatePrice(price *common.Price) bool {
+	t.secondary.UpdatePrice(price)
+	return t.primary.UpdatePrice(price)
+}
+
+// RecordFetchLatency 拉取耗时只记录在默认存储
+func (t *teeSink) RecordFetchLatency(exchange common.Exchange, duration time.Duration) {
+	t.primary.RecordFetchLatency(exchange, duration)
+}
+
+// RecordFetchError 拉取错误只记录在默认存储
+func (t *teeSink) RecordFetchError(exchange common.Exchange, endpoint string, err error) {
+	t.primary.RecordFetchError(exchange, endpoint, err)
+}
+
+// FetchBackoff 限频退避以默认存储为准
+func (t *teeSink) FetchBackoff(exchange common.Exchange, endpoint string) time.Duration {
+	return t.primary.FetchBackoff(exchange, endpoint)
+}
+
+// feedRouter 按数据源决定价格写入哪些存储
+type feedRouter struct {
+	primary   *pricestore.PriceStore
+	secondary *pricestore.PriceStore // 为nil时不启用副存储
+	sources   map[string]bool        // 同时写入副存储的数据源
+}
+
+// newFeedRouter 创建数据源路由，sources 为空表示所有数据源都写入副存储
+func newFeedRouter(primary, secondary *pricestore.PriceStore, sources []string) *feedRouter {
+	router := &feedRouter{
+		primary:   primary,
+		secondary: secondary,
+		sources:   make(map[string]bool),
+	}
+	if secondary == nil {
+		return router
+	}
+
+	if len(sources) == 0 {
+		sources = allSources
+	}
+	for _, source := range sources {
+		source = strings.ToLower(strings.TrimSpace(source))
+		if !isKnownSource(source) {
+			log.Printf("[Namespace] Unknown source %q ignored (valid: %s)", source, strings.Join(allSources, ", "))
+			continue
+		}
+		router.sources[source] = true
+	}
+
+	log.Printf("[Namespace] Secondary store %q fed by: %v", secondary.Name(), router.enabledSources())
+	return router
+}
+
+// sink 返回数据源对应的写入目标
+func (r *feedRouter) sink(source string) priceSink {
+	if r.secondary != nil && r.sources[source] {
+		return &teeSink{primary: r.primary, secondary: r.secondary}
+	}
+	return r.primary
+}
+
+// enabledSources 按固定顺序返回写入副存储的数据源
+func (r *feedRouter) enabledSources() []string {
+	enabled := make([]string, 0, len(r.sources))
+	for _, source := range allSources {
+		if r.sources[source] {
+			enabled = append(enabled, source)
+		}
+	}
+	return enabled
+}
+
+// isKnownSource 判断数据源名称是否合法
+func isKnownSource(source string) bool {
+	for _, known := range allSources {
+		if source == known {
+			return true
+		}
+	}
+	return false
+}
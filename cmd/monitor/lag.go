@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/exchange/binance"
+	"sync/atomic"
+)
+
+// binanceLag Binance 合约 WebSocket 的处理延迟状态（/api/stats 的 ws_lag 和定期统计日志）
+type binanceLag struct {
+	client atomic.Pointer[binance.WSClient] // WebSocket 启动成功后设置，重启后替换
+}
+
+// lagStatus 当前连接的延迟状态，WebSocket 未启动时返回 false
+func (l *binanceLag) lagStatus() (binance.LagStatus, bool) {
+	client := l.client.Load()
+	if client == nil {
+		return binance.LagStatus{}, false
+	}
+	return client.Status(), true
+}
+
+// Status 用于 web.Server.SetWSLagFunc，WebSocket 未启动时返回 nil
+func (l *binanceLag) Status() interface{} {
+	status, ok := l.lagStatus()
+	if !ok {
+		return nil
+	}
+	return status
+}
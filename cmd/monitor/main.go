@@ -3,29 +3,61 @@ package main
 import (
 	"context"
 	"crypto-arbitrage-monitor/config"
+	"crypto-arbitrage-monitor/internal/buildinfo"
 	"crypto-arbitrage-monitor/internal/exchange/aster"
 	"crypto-arbitrage-monitor/internal/exchange/binance"
 	"crypto-arbitrage-monitor/internal/exchange/lighter"
+	"crypto-arbitrage-monitor/internal/failover"
+	"crypto-arbitrage-monitor/internal/logging"
+	"crypto-arbitrage-monitor/internal/netutil"
+	"crypto-arbitrage-monitor/internal/opportunitysink"
 	"crypto-arbitrage-monitor/internal/pricestore"
 	"crypto-arbitrage-monitor/internal/web"
+	"crypto-arbitrage-monitor/internal/wsutil"
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
 	"runtime"
-	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 )
 
 func main() {
-	// 加载配置
+	showVersion := flag.Bool("version", false, "打印版本信息和脱敏后的有效配置后退出")
+	noBrowser := flag.Bool("no-browser", false, "启动时不自动打开浏览器（同 NO_BROWSER=true）")
+	quiet := flag.Bool("quiet", false, "不向标准输出打印启动横幅和调试信息，日志文件只跳过拒绝报价的样本（同 QUIET=true）")
+	exportConfig := flag.String("export-config", "", "将运行时配置（阈值、黑名单、symbol映射、比值策略、交易所能力）和脱敏后的启动配置导出到该文件后退出，格式同 /api/config/export")
+	flag.Parse()
+
+	// 加载配置（命令行参数只能开启，不能关闭环境变量中的设置）
 	cfg := config.LoadConfig()
+	cfg.NoBrowser = cfg.NoBrowser || *noBrowser
+	cfg.Quiet = cfg.Quiet || *quiet
+	pricestore.SetQuiet(cfg.Quiet)
+
+	// 存储、API序列化和日志的时间为UTC（序列化的时间在生成时显式调用 .UTC()），终端输出按 DISPLAY_TIMEZONE 转换
+	log.SetFlags(log.LstdFlags | log.LUTC)
+	displayLoc, err := common.LoadDisplayLocation(cfg.DisplayTimezone)
+	if err != nil {
+		log.Printf("[Config] Invalid DISPLAY_TIMEZONE %q, using Local: %v", cfg.DisplayTimezone, err)
+		cfg.DisplayTimezone = "Local"
+		displayLoc = time.Local
+	}
 
-	// 创建日志文件
-	logFile, err := os.OpenFile("arbitrage.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if *showVersion {
+		printVersion(cfg)
+		return
+	}
+
+	// 创建日志文件（外部轮转或删除后自动重新打开，超过大小上限时轮转）
+	logFile, err := logging.NewFileWriter(cfg.LogFile, int64(cfg.LogMaxSizeMB)*1024*1024, cfg.LogMaxBackups)
 	if err == nil {
 		log.SetOutput(logFile)
 		defer logFile.Close()
@@ -35,104 +67,357 @@ func main() {
 
 	// 创建价格存储器（双索引结构）
 	store := pricestore.NewPriceStore()
+	configureStore(store, cfg)
 
-	// 启动Aster WebSocket
-	asterWS := startAsterWebSocket(store)
-	if asterWS != nil {
-		defer asterWS.Close()
+	// 加载按symbol配置的套利阈值
+	if err := store.LoadThresholdOverrides(cfg.ThresholdsFile); err != nil {
+		log.Printf("[Thresholds] Failed to load %s: %v", cfg.ThresholdsFile, err)
+	}
+	if err := store.LoadBlacklist(cfg.BlacklistFile, cfg.Blacklist); err != nil {
+		log.Printf("[Blacklist] Failed to load %s: %v", cfg.BlacklistFile, err)
+	}
+	for _, symbol := range cfg.UpdateDebugSymbols {
+		log.Printf("[Debug] Recording price update decisions for %s", store.EnableUpdateDebug(symbol, cfg.UpdateDebugSize))
+	}
+	if n, err := store.LoadSymbolMappings(cfg.SymbolMappingsFile); err != nil {
+		log.Printf("[Normalizer] Failed to load %s: %v", cfg.SymbolMappingsFile, err)
+	} else if n > 0 {
+		log.Printf("[Normalizer] Loaded %d symbol mappings from %s", n, cfg.SymbolMappingsFile)
+	}
+	if err := store.LoadVenueCapabilities(cfg.VenueCapabilitiesFile); err != nil {
+		log.Printf("[Venues] Failed to load %s: %v", cfg.VenueCapabilitiesFile, err)
+	}
+	if err := store.LoadRatioStrategies(cfg.StrategiesFile); err != nil {
+		log.Printf("[Strategies] Failed to load %s: %v", cfg.StrategiesFile, err)
 	}
 
-	// 启动Aster REST初始化和定期更新
-	asterSpotClient := aster.NewSpotClient(cfg.AsterSpotBaseURL, cfg.AsterAPIKey, cfg.AsterSecretKey)
-	asterFuturesClient := aster.NewFuturesClient(cfg.AsterFutureBaseURL, cfg.AsterAPIKey, cfg.AsterSecretKey)
-
-	// 启动Lighter WebSocket连接池和REST
-	lighterMarkets := lighter.GetCommonMarkets()
-	lighterAPIBaseURL := lighter.LighterAPIBaseURL
-	marketIDs := lighter.GetMarketIDs(lighterMarkets)
-	lighterWSPool := startLighterWSPool(store, lighterMarkets, lighterAPIBaseURL, marketIDs)
-	if lighterWSPool != nil {
-		defer lighterWSPool.Close()
+	if *exportConfig != "" {
+		if err := exportConfigBundle(store, cfg, *exportConfig); err != nil {
+			log.Fatalf("[Config] Failed to export config bundle: %v", err)
+		}
+		fmt.Printf("Config bundle written to %s\n", *exportConfig)
+		return
 	}
 
-	// Binance（可选，需要代理）
-	var binanceSpotWSPool *binance.SpotWSPool
-	var binanceFuturesWS *binance.WSClient
+	// 每个交易所REST并发限制，避免触发限频
+	aster.SetMaxConcurrentRequests(cfg.RESTMaxConcurrency)
+	binance.SetMaxConcurrentRequests(cfg.RESTMaxConcurrency)
+	lighter.SetMaxConcurrentRequests(cfg.RESTMaxConcurrency)
 
-	log.Println("[Binance] Enabled")
-	// 配置Binance代理
+	// 所有WebSocket客户端共享的Dialer配置
+	wsutil.SetHandshakeTimeout(time.Duration(cfg.WSHandshakeTimeout) * time.Second)
+
+	// 交易所REST/WebSocket共享的拨号配置（DNS缓存、IPv4/IPv6切换、失败主机降级）
+	dialCfg := netutil.DefaultConfig()
+	dialCfg.ForceIPv4 = cfg.ForceIPv4
+	dialCfg.DNSCacheTTL = time.Duration(cfg.DNSCacheTTL) * time.Second
+	dialCfg.DNSStaleFor = time.Duration(cfg.DNSStaleMax) * time.Second
+	dialCfg.AttemptTimeout = time.Duration(cfg.DialAttemptTimeout) * time.Second
+	dialCfg.DemoteAfter = cfg.DialDemoteAfter
+	netutil.Configure(dialCfg)
+
+	// BookTicker调试日志采样（高频tick下避免刷屏）
+	common.TickerLogSampler.SetInterval(time.Duration(cfg.TickerLogInterval) * time.Second)
+
+	// 配置Binance代理（REST和WebSocket共用，需要在建立连接前设置）
 	if cfg.HTTPSProxy != "" {
 		binance.SetProxyURL(cfg.HTTPSProxy)
 	} else if cfg.HTTPProxy != "" {
 		binance.SetProxyURL(cfg.HTTPProxy)
 	}
 
-	// 启动Binance现货 WebSocket 连接池（分片模式）
-	binanceSpotWSPool = startBinanceSpotWSPool(store)
-	if binanceSpotWSPool != nil {
-		defer binanceSpotWSPool.Close()
+	// 币本位合约（BTCUSD_PERP）按张计价，默认不入库
+	binance.SetExcludeInverse(cfg.BinanceExcludeInverse)
+
+	// 副存储（命名空间）：只接收 SECONDARY_SOURCES 中的数据源，用于和默认存储对比
+	// 阈值/黑名单/策略只保存在内存中，不写回默认存储的配置文件
+	var secondaryStore *pricestore.PriceStore
+	if cfg.SecondaryNamespace != "" {
+		secondaryStore = pricestore.NewPriceStore()
+		secondaryStore.SetName(cfg.SecondaryNamespace)
+		configureStore(secondaryStore, cfg)
+		if err := secondaryStore.LoadBlacklist("", cfg.Blacklist); err != nil {
+			log.Printf("[Namespace] Failed to load blacklist for %s: %v", cfg.SecondaryNamespace, err)
+		}
 	}
+	feeds := newFeedRouter(store, secondaryStore, cfg.SecondarySources)
+
+	logExchangeFlags(cfg)
+
+	// 数据源启动失败（交易所短暂不可用）时在后台重试，启动成功后再交给订阅器和刷新任务
+	stopChan := make(chan struct{})
+	tasks := newTaskRunner(stopChan)
+	sources := newSourceSupervisor(stopChan)
+	binanceSub := &binanceSubscriber{store: feeds.sink(sourceBinanceSpotWS)}
+	asterSub := &asterSubscriber{spotWSEnabled: cfg.AsterSpotWSEnabled}
+	lighterAPIBaseURL := lighter.LighterAPIBaseURL
+	lighterSub := &lighterSubscriber{apiBaseURL: lighterAPIBaseURL, store: feeds.sink(sourceLighterWS)}
+	lighterBook := &lighterDepth{}
+	binanceFuturesLag := &binanceLag{}
+	// 连接池的订阅登记（/api/connections），连接池重试启动时沿用同一个登记
+	binanceSubscriptions := wsutil.NewSubscriptionRegistry(string(common.ExchangeBinance))
+	lighterSubscriptions := wsutil.NewSubscriptionRegistry(string(common.ExchangeLighter))
 
-	// 启动Binance合约 WebSocket
-	binanceFuturesWS = startBinanceFuturesWebSocket(store)
-	if binanceFuturesWS != nil {
-		defer binanceFuturesWS.Close()
+	asterSpotClient := aster.NewSpotClient(cfg.AsterSpotBaseURL, cfg.AsterAPIKey, cfg.AsterSecretKey)
+	asterFuturesClient := aster.NewFuturesClient(cfg.AsterFutureBaseURL, cfg.AsterAPIKey, cfg.AsterSecretKey)
+
+	// Lighter 报价货币需在获取市场列表前设置，symbol后缀由此决定；市场列表只在启用 Lighter 时获取
+	lighter.SetDefaultPerpQuote(cfg.LighterPerpQuote)
+	lighter.SetRESTFanout(cfg.LighterRESTParallelRequests, time.Duration(cfg.LighterRESTTimeoutMs)*time.Millisecond)
+	var lighterMarkets []*lighter.Market
+	var marketIDs []int
+	if cfg.LighterEnabled {
+		lighterMarkets = lighter.GetCommonMarkets()
+		marketIDs = lighter.GetMarketIDs(lighterMarkets)
 	}
 
+	// 启动各交易所的 WebSocket 数据源（未启用的交易所不启动，见 startSources）
+	startSources(cfg, sources, map[string]sourceStartFunc{
+		// Aster WebSocket
+		sourceAsterWS: func() (func(), error) {
+			asterWS, err := startAsterWebSocket(feeds.sink(sourceAsterWS))
+			if err != nil {
+				return nil, err
+			}
+			return asterWS.Close, nil
+		},
+		// Aster现货 WebSocket（交易对来自 exchangeInfo，定期刷新发现新上线的交易对）
+		sourceAsterSpotWS: func() (func(), error) {
+			asterSpotWS, discovery, err := startAsterSpotWebSocket(feeds.sink(sourceAsterSpotWS), asterSpotClient, cfg)
+			if err != nil {
+				return nil, err
+			}
+			asterSub.spotDiscovery.Store(discovery)
+			return func() {
+				discovery.Close()
+				asterSpotWS.Close()
+			}, nil
+		},
+		// Lighter WebSocket连接池
+		sourceLighterWS: func() (func(), error) {
+			pool, err := startLighterWSPool(feeds.sink(sourceLighterWS), lighterMarkets, lighterAPIBaseURL, marketIDs, lighterSubscriptions, cfg)
+			if err != nil {
+				return nil, err
+			}
+			lighterSub.pool.Store(pool)
+			lighterBook.pool.Store(pool)
+			// 任务16（Lighter）: 市场列表刷新，新上线的市场追加订阅，下线的退订
+			if cfg.LighterMarketRefreshInterval > 0 {
+				sources.Go("lighter-market-refresher", func() {
+					runLighterMarketRefresher(pool, lighterAPIBaseURL, time.Duration(cfg.LighterMarketRefreshInterval)*time.Minute, stopChan)
+				})
+			}
+			return func() { pool.Close() }, nil
+		},
+		// Binance现货 WebSocket 连接池（分片模式，需要代理）
+		sourceBinanceSpotWS: func() (func(), error) {
+			pool, err := startBinanceSpotWSPool(feeds.sink(sourceBinanceSpotWS), binanceSubscriptions, cfg)
+			if err != nil {
+				return nil, err
+			}
+			binanceSub.spotPool.Store(pool)
+			// 任务16（Binance现货）: 交易对列表刷新
+			if cfg.BinanceSpotSymbolRefreshInterval > 0 {
+				sources.Go("binance-spot-symbol-refresher", func() {
+					runBinanceSpotSymbolRefresher(pool, time.Duration(cfg.BinanceSpotSymbolRefreshInterval)*time.Minute, stopChan)
+				})
+			}
+			return pool.Close, nil
+		},
+		// Binance合约 WebSocket
+		sourceBinanceFuturesWS: func() (func(), error) {
+			binanceFuturesWS, err := startBinanceFuturesWebSocket(feeds.sink(sourceBinanceFuturesWS), cfg)
+			if err != nil {
+				return nil, err
+			}
+			binanceFuturesLag.client.Store(binanceFuturesWS)
+			return func() { binanceFuturesWS.Close() }, nil
+		},
+	})
+
 	// 启动Web服务器
 	webServer := web.NewServer(store, ":8080")
+	if logFile != nil {
+		webServer.SetLogSizeFunc(logFile.Size)
+	}
+	webServer.SetSnapshotInterval(time.Duration(cfg.WebSnapshotRefreshMs) * time.Millisecond)
+	webServer.SetEffectiveConfig(cfg.Redacted())
+	webServer.SetUnredactedConfig(cfg.Values())
+	webServer.SetAPIToken(cfg.APIToken)
+	webServer.SetMaxOpportunities(cfg.MaxOpportunitiesReturned)
+	// 按需订阅（POST /api/subscribe），未启用的交易所返回 unsupported
+	if cfg.BinanceEnabled {
+		webServer.SetSubscriber(common.ExchangeBinance, binanceSub)
+		webServer.SetSubscriptionRegistry(binanceSubscriptions)
+		webServer.SetWSLagFunc("binance_futures", binanceFuturesLag.Status)
+	}
+	if cfg.LighterEnabled {
+		webServer.SetSubscriber(common.ExchangeLighter, lighterSub)
+		webServer.SetDepthProvider(common.ExchangeLighter, lighterBook)
+		webServer.SetSubscriptionRegistry(lighterSubscriptions)
+	}
+	if cfg.AsterEnabled {
+		webServer.SetSubscriber(common.ExchangeAster, asterSub)
+	}
+	// 主备模式（/api/health 返回角色，standby 时API响应带 role）
+	elector := buildElector(cfg)
+	if elector != nil {
+		webServer.SetElector(elector)
+	}
+	if secondaryStore != nil {
+		if err := webServer.AddNamespace(secondaryStore); err != nil {
+			log.Printf("[Namespace] Failed to register %s: %v", cfg.SecondaryNamespace, err)
+		} else {
+			log.Printf("[Web Server] Namespace %s at http://localhost:8080/api/%s/", cfg.SecondaryNamespace, cfg.SecondaryNamespace)
+		}
+	}
+	// 退出时取消，停止快照刷新并关闭Web服务器
+	webCtx, stopWebServer := context.WithCancel(context.Background())
+	defer stopWebServer()
 	go func() {
-		if err := webServer.Start(); err != nil {
+		if err := webServer.Start(webCtx); err != nil {
 			log.Printf("[Web Server] Error: %v", err)
 		}
 	}()
 	log.Println("[Web Server] Access at http://localhost:8080")
-	println("[Web Server] Access at http://localhost:8080")
+	if !cfg.Quiet {
+		println("[Web Server] Access at http://localhost:8080")
+	}
 
-	// 等待一小段时间确保服务器启动，然后自动打开浏览器
-	go func() {
-		time.Sleep(500 * time.Millisecond)
-		openBrowser("http://localhost:8080/")
-	}()
+	// 服务器开始监听后自动打开浏览器（无桌面环境、SSH会话或 --no-browser 时跳过）
+	if shouldOpenBrowser(cfg, runtime.GOOS, os.Getenv) {
+		go openBrowserWhenReady(systemBrowser{}, webServer.Ready(), "http://localhost:8080/", browserReadyTimeout)
+	}
 
 	// 启动后台任务
-	var wg sync.WaitGroup
-	stopChan := make(chan struct{})
-
-	// 任务1: Aster REST数据获取
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		runAsterRESTUpdater(asterSpotClient, asterFuturesClient, store, stopChan)
-	}()
 
-	// 任务2: Lighter REST数据获取
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		runLighterRESTUpdater(lighterAPIBaseURL, marketIDs, store, stopChan)
-	}()
-
-	// 任务3: Binance REST数据获取（可选）
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		runBinanceRESTUpdater(store, stopChan)
-	}()
+	// 成交量分层刷新（Aster REST 使用），分层状态记录在默认存储
+	store.SetRefreshTiers(&pricestore.RefreshTierConfig{
+		FastInterval: time.Duration(cfg.VolumeFastRefreshSeconds) * time.Second,
+		FullInterval: time.Duration(cfg.VolumeFullRefreshMinutes) * time.Minute,
+		PromoteFor:   time.Duration(cfg.VolumePromoteMinutes) * time.Minute,
+		MaxPromoted:  cfg.VolumeMaxPromoted,
+	}, cfg.MonitorSymbols)
+
+	// 任务1-3: 各交易所 REST 数据获取（未启用的交易所不启动刷新goroutine，见 startRESTUpdaters）
+	asterSpotVolumes := newVolumeCache(tierSourceAsterSpot24h, common.ExchangeAster, store)
+	asterFuturesVolumes := newVolumeCache(tierSourceAsterFutures24h, common.ExchangeAster, store)
+	startRESTUpdaters(cfg, tasks, map[string]func(){
+		sourceAsterREST: func() {
+			runAsterRESTUpdater(asterSpotClient, asterFuturesClient, asterSpotVolumes, asterFuturesVolumes, feeds.sink(sourceAsterREST), stopChan)
+		},
+		sourceLighterREST: func() {
+			runLighterRESTUpdater(lighterAPIBaseURL, marketIDs, feeds.sink(sourceLighterREST), stopChan)
+		},
+		sourceBinanceREST: func() {
+			runBinanceRESTUpdater(feeds.sink(sourceBinanceREST), stopChan)
+		},
+	})
 
 	// 任务4: 统计信息打印
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		runStatsReporter(store, stopChan)
-	}()
+	tasks.supervise("stats-reporter", func() {
+		runStatsReporter(store, sources, binanceFuturesLag, cfg.CoverageGapMinVolume, stopChan)
+	})
 
 	// 任务5: 定期清理过期数据
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	tasks.supervise("data-cleaner", func() {
 		runDataCleaner(store, stopChan)
-	}()
+	})
+
+	// 任务6: 交易所能力配置热加载
+	tasks.supervise("venue-capability-reloader", func() {
+		store.RunVenueCapabilityReloader(cfg.VenueCapabilitiesFile, 10*time.Second, stopChan)
+	})
+
+	// 任务7: 刷新只读行情快照（/api/tickers 无锁读取）
+	tasks.supervise("snapshot-refresher", func() {
+		store.RunSnapshotRefresher(time.Duration(cfg.SnapshotRefreshMs)*time.Millisecond, stopChan)
+	})
+
+	// 任务8: 数量级倍数检测（1000PEPE 等）
+	tasks.supervise("multiplier-detector", func() {
+		store.RunMultiplierDetector(30*time.Second, stopChan)
+	})
+
+	// 任务9: 模拟交易（确认的套利机会开仓、盯市和平仓）
+	if cfg.PaperTradingEnabled {
+		store.SetPaperConfig(&pricestore.PaperConfig{
+			Enabled:           true,
+			Notional:          cfg.PaperNotional,
+			ExitSpreadPercent: cfg.PaperExitSpread,
+			MaxHold:           time.Duration(cfg.PaperMaxHoldMinutes) * time.Minute,
+			MaxOpen:           cfg.PaperMaxOpenPositions,
+		})
+		tasks.supervise("paper-trader", func() {
+			store.RunPaperTrader(2*time.Second, stopChan)
+		})
+	}
+
+	// 任务10: 副存储的快照刷新和过期数据清理
+	if secondaryStore != nil {
+		tasks.supervise("secondary-snapshot-refresher", func() {
+			secondaryStore.RunSnapshotRefresher(time.Duration(cfg.SnapshotRefreshMs)*time.Millisecond, stopChan)
+		})
+		tasks.supervise("secondary-data-cleaner", func() {
+			runDataCleaner(secondaryStore, stopChan)
+		})
+	}
+
+	// 任务11: 日志文件被外部轮转或删除后重新打开
+	if logFile != nil {
+		tasks.supervise("log-reopen-watcher", func() {
+			logFile.RunReopenWatcher(10*time.Second, stopChan)
+		})
+	}
+
+	// 任务12: 套利机会输出（stdout表格 / NDJSON文件 / webhook），每轮只评估一次再分发
+	if sinks := buildOpportunitySinks(cfg, displayLoc); len(sinks) > 0 {
+		fanout := opportunitysink.NewFanout(store, sinks...)
+		if elector != nil {
+			fanout.SetGate(elector.IsLeader)
+		}
+		tasks.supervise("opportunity-sinks", func() {
+			fanout.Run(time.Duration(cfg.OpportunitySinkIntervalSec)*time.Second, stopChan)
+		})
+	}
+
+	// 任务13: 主备心跳（standby 继续采集但不发送套利机会输出，leader 失联后提升）
+	if elector != nil {
+		tasks.supervise("failover-heartbeat", func() {
+			elector.Run(time.Duration(cfg.FailoverHeartbeatSec)*time.Second, stopChan)
+		})
+	}
+
+	// 任务14: 价差异常标注（最优价差明显高于滚动窗口常态时记录日志）
+	if cfg.AnomalyZThreshold > 0 {
+		store.SetAnomalyConfig(&pricestore.AnomalyConfig{
+			ZThreshold: cfg.AnomalyZThreshold,
+			Window:     time.Duration(cfg.AnomalyWindowMinutes) * time.Minute,
+			Cooldown:   time.Duration(cfg.AnomalyCooldownMinutes) * time.Minute,
+		})
+		tasks.supervise("anomaly-annotator", func() {
+			store.RunAnomalyAnnotator(time.Duration(cfg.AnomalySampleSeconds)*time.Second, stopChan)
+		})
+	}
+
+	// 任务15: 稳定币三角一致性检查（场所隐含汇率或标准化汇率偏离共识时告警）
+	if cfg.StableBasisIntervalSec > 0 {
+		quotes := make([]common.QuoteCurrency, 0, len(cfg.StableBasisQuotes))
+		for _, quote := range cfg.StableBasisQuotes {
+			quotes = append(quotes, common.QuoteCurrency(strings.ToUpper(strings.TrimSpace(quote))))
+		}
+		store.SetStableBasisConfig(&pricestore.StableBasisConfig{
+			Quotes:       quotes,
+			Venues:       cfg.StableBasisVenues,
+			ThresholdBps: cfg.StableBasisThresholdBps,
+		})
+		tasks.supervise("stable-basis-checker", func() {
+			store.RunStableBasisChecker(time.Duration(cfg.StableBasisIntervalSec)*time.Second, stopChan)
+		})
+	}
+
+	// 任务16: WebSocket连接池订阅列表刷新（新上线的交易对追加订阅，下线的退订，其余连接不受影响）
+	// 在连接池启动成功后开始，见上面的 sources.Start
 
 	// 等待退出信号
 	sigChan := make(chan os.Signal, 1)
@@ -145,15 +430,188 @@ func main() {
 
 	// 通知所有goroutine停止
 	close(stopChan)
+	stopWebServer()
 
-	// 等待所有goroutine完成
-	wg.Wait()
+	// 等待所有后台任务完成，取消尚未成功的数据源启动重试，然后关闭已启动的连接
+	tasks.Wait()
+	sources.Close()
 
 	log.Println("Shutdown complete.")
 }
 
+// printVersion 输出与 /api/version 相同的构建信息和脱敏后的有效配置（--version）
+func printVersion(cfg *config.Config) {
+	info := buildinfo.Get()
+	fmt.Printf("crypto-arbitrage-monitor %s\n", info)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string]interface{}{
+		"version": info,
+		"config":  cfg.Redacted(),
+	})
+}
+
+// exportConfigBundle 将运行时配置和脱敏后的启动配置写入文件（--export-config）
+func exportConfigBundle(store *pricestore.PriceStore, cfg *config.Config, path string) error {
+	bundle := store.ExportConfigBundle()
+	bundle.Environment = cfg.Redacted()
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// buildElector 按 FAILOVER_MODE 创建主备选举器，未启用或配置不完整时返回nil（单实例运行）
+func buildElector(cfg *config.Config) *failover.Elector {
+	var transport failover.Transport
+	switch strings.ToLower(cfg.FailoverMode) {
+	case "", "off":
+		return nil
+	case "http":
+		if cfg.FailoverPeerURL == "" {
+			log.Println("[Failover] http mode enabled but FAILOVER_PEER_URL is empty, running standalone")
+			return nil
+		}
+		httpTransport := failover.NewHTTPTransport(cfg.FailoverPeerURL, time.Duration(cfg.FailoverHeartbeatSec)*time.Second)
+		httpTransport.SetAuthToken(cfg.APIToken)
+		transport = httpTransport
+	case "file":
+		if cfg.FailoverDir == "" {
+			log.Println("[Failover] file mode enabled but FAILOVER_DIR is empty, running standalone")
+			return nil
+		}
+		if err := os.MkdirAll(cfg.FailoverDir, 0755); err != nil {
+			log.Printf("[Failover] Failed to create %s: %v, running standalone", cfg.FailoverDir, err)
+			return nil
+		}
+		transport = failover.NewFileTransport(cfg.FailoverDir)
+	default:
+		log.Printf("[Failover] Unknown FAILOVER_MODE %q, running standalone", cfg.FailoverMode)
+		return nil
+	}
+
+	instanceID := cfg.FailoverInstanceID
+	if instanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "monitor"
+		}
+		instanceID = hostname
+	}
+
+	timeout := time.Duration(cfg.FailoverTimeoutSec) * time.Second
+	log.Printf("[Failover] %s mode, instance %s (priority %d), starting as standby, peer timeout %v",
+		cfg.FailoverMode, instanceID, cfg.FailoverPriority, timeout)
+	return failover.NewElector(instanceID, cfg.FailoverPriority, transport, timeout, time.Now().UTC())
+}
+
+// buildOpportunitySinks 按 OPPORTUNITY_SINKS 创建启用的套利机会输出目标
+func buildOpportunitySinks(cfg *config.Config, displayLoc *time.Location) []opportunitysink.Sink {
+	sinks := make([]opportunitysink.Sink, 0, len(cfg.OpportunitySinks))
+	for _, name := range cfg.OpportunitySinks {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "stdout":
+			table := opportunitysink.NewTableSink(nil)
+			table.SetLocation(displayLoc)
+			sinks = append(sinks, table)
+		case "file":
+			sinks = append(sinks, opportunitysink.NewFileSink(cfg.OpportunityFile))
+		case "webhook":
+			if cfg.OpportunityWebhookURL == "" {
+				log.Println("[OpportunitySink] webhook sink enabled but OPPORTUNITY_WEBHOOK_URL is empty, skipping")
+				continue
+			}
+			sinks = append(sinks, opportunitysink.NewWebhookSink(cfg.OpportunityWebhookURL, 10*time.Second))
+		case "":
+		default:
+			log.Printf("[OpportunitySink] Unknown sink %q, skipping", name)
+			continue
+		}
+	}
+	for _, sink := range sinks {
+		log.Printf("[OpportunitySink] Enabled %s sink", sink.Name())
+	}
+	return sinks
+}
+
+// configureStore 按配置设置存储的校验、手续费和评分参数（默认存储和副存储共用）
+func configureStore(store *pricestore.PriceStore, cfg *config.Config) {
+	// 配置价格合法性校验
+	validation := pricestore.DefaultValidationConfig()
+	validation.MinAskBidRatio = cfg.PriceMinAskBidRatio
+	validation.MaxAskBidRatio = cfg.PriceMaxAskBidRatio
+	validation.MaxMarkDeviationPercent = cfg.PriceMaxMarkDeviation
+	for symbol, bound := range cfg.PriceBounds {
+		validation.SymbolBounds[symbol] = pricestore.PriceBounds{Min: bound.Min, Max: bound.Max}
+	}
+	store.SetValidationConfig(validation)
+
+	// 配置成交模拟使用的taker手续费率
+	takerFees := make(map[common.Exchange]float64, len(cfg.TakerFees))
+	for exchange, fee := range cfg.TakerFees {
+		takerFees[common.Exchange(exchange)] = fee
+	}
+	store.SetTakerFees(takerFees)
+
+	// 配置价差置信度评分
+	confidence := pricestore.DefaultConfidenceWeights()
+	confidence.AgeHalfLife = time.Duration(cfg.ConfidenceAgeHalfLifeMs) * time.Millisecond
+	confidence.RESTPenalty = cfg.ConfidenceRESTPenalty
+	confidence.ThinBookNotional = cfg.ConfidenceThinBookUSDT
+	confidence.ThinBookPenalty = cfg.ConfidenceThinBookPenalty
+	confidence.AgeGapHalfLife = time.Duration(cfg.ConfidenceAgeGapHalfLifeMs) * time.Millisecond
+	store.SetConfidenceWeights(confidence)
+	store.SetMinExchangeCount(cfg.MinExchangeCount)
+	store.SetMinOpportunityVolume(cfg.MinOpportunityVolume)
+	pairings, err := pricestore.ParseAllowedPairings(cfg.OpportunityPairings)
+	if err != nil {
+		log.Printf("[Config] %v, allowing all market pairings", err)
+		pairings = nil
+	}
+	store.SetAllowedPairings(pairings)
+	store.SetMaxTrackedOpportunities(cfg.MaxTrackedOpportunities)
+	store.SetSpreadGrace(time.Duration(cfg.SpreadGraceMs) * time.Millisecond)
+
+	// 价差计算使用的买卖价格，深度加权时由 Lighter 连接池按本地订单簿计算
+	priceMode, err := pricestore.ParsePriceMode(cfg.PriceMode)
+	if err != nil {
+		log.Printf("[Config] %v, using %s", err, pricestore.PriceModeTopOfBook)
+		priceMode = pricestore.PriceModeTopOfBook
+	}
+	store.SetPriceMode(priceMode)
+	if priceMode == pricestore.PriceModeDepthWeighted {
+		lighter.SetDepthVWAPNotional(cfg.DepthVWAPNotional)
+	}
+
+	// 延迟补偿的价差投影
+	if cfg.LatencyProjection {
+		projection := pricestore.DefaultLatencyProjection()
+		projection.MinGap = time.Duration(cfg.LatencyProjectionMinGapMs) * time.Millisecond
+		projection.MaxBps = cfg.LatencyProjectionMaxBps
+		projection.DriftMinSpacing = time.Duration(cfg.LatencyProjectionDriftSpacingMs) * time.Millisecond
+		projection.DriftWindow = time.Duration(cfg.LatencyProjectionDriftWindowMs) * time.Millisecond
+		projection.ForOpportunities = cfg.LatencyProjectionForOpportunities
+		store.SetLatencyProjection(projection)
+	}
+
+	// 配置数量级倍数检测（1000PEPE 等）
+	multiplier := pricestore.DefaultMultiplierConfig()
+	multiplier.AutoApply = cfg.MultiplierAutoApply
+	multiplier.StableFor = time.Duration(cfg.MultiplierStableMinutes) * time.Minute
+	store.SetMultiplierConfig(multiplier)
+
+	// 配置价格历史（按时间点查询价格和价差）
+	store.SetHistoryConfig(&pricestore.HistoryConfig{
+		Retention:      time.Duration(cfg.PriceHistoryRetentionMinutes) * time.Minute,
+		SampleInterval: time.Duration(cfg.PriceHistorySampleMs) * time.Millisecond,
+	})
+}
+
 // startAsterWebSocket 启动Aster WebSocket连接
-func startAsterWebSocket(store *pricestore.PriceStore) *aster.WSClient {
+func startAsterWebSocket(store priceSink) (*aster.WSClient, error) {
 	log.Println("[Aster] Connecting to WebSocket...")
 
 	asterWS := aster.NewWSClient("wss://fstream.asterdex.com/ws", common.MarketTypeFuture)
@@ -165,22 +623,60 @@ func startAsterWebSocket(store *pricestore.PriceStore) *aster.WSClient {
 	})
 
 	if err := asterWS.Connect(); err != nil {
-		log.Printf("[Aster] Failed to connect WebSocket: %v", err)
-		return nil
+		return nil, fmt.Errorf("connect WebSocket: %w", err)
 	}
 
 	// 订阅全市场最优挂单信息（实时bid/ask）
 	if err := asterWS.Subscribe([]string{"!bookTicker"}); err != nil {
-		log.Printf("[Aster] Failed to subscribe: %v", err)
-		return nil
+		asterWS.Close()
+		return nil, fmt.Errorf("subscribe: %w", err)
 	}
 
 	log.Println("[Aster] WebSocket connected and subscribed to bookTicker")
-	return asterWS
+	return asterWS, nil
+}
+
+// startAsterSpotWebSocket 启动Aster现货WebSocket，订阅 exchangeInfo 中所有可交易交易对的 bookTicker
+// 现货没有全市场 !bookTicker 流，新上线的交易对由 SymbolDiscovery 定期发现后追加订阅
+func startAsterSpotWebSocket(store priceSink, spotClient *aster.SpotClient, cfg *config.Config) (*aster.WSClient, *aster.SymbolDiscovery, error) {
+	log.Println("[Aster Spot] Connecting to WebSocket...")
+
+	asterSpotWS := aster.NewWSClient(cfg.AsterWSSpotURL+"/ws", common.MarketTypeSpot)
+	asterSpotWS.SetBookTickerHandler(func(ticker *aster.WSBookTickerData) {
+		price := aster.ConvertWSBookTickerToPrice(ticker, common.ExchangeAster, common.MarketTypeSpot)
+		store.UpdatePrice(price)
+	})
+
+	if err := asterSpotWS.Connect(); err != nil {
+		return nil, nil, fmt.Errorf("connect WebSocket: %w", err)
+	}
+
+	discovery := aster.NewSpotSymbolDiscovery(spotClient, time.Duration(cfg.AsterSymbolRefreshInterval)*time.Minute)
+	discovery.SetNewSymbolsHandler(func(symbols []string) {
+		subscribeAsterBookTickers(asterSpotWS, symbols)
+	})
+	if err := discovery.Start(); err != nil {
+		asterSpotWS.Close()
+		return nil, nil, err
+	}
+
+	log.Printf("[Aster Spot] WebSocket connected and subscribed to %d symbols", len(discovery.Symbols()))
+	return asterSpotWS, discovery, nil
+}
+
+// subscribeAsterBookTickers 订阅交易对的 bookTicker 流（WSClient.Subscribe 内部按每条消息最多100个分批）
+func subscribeAsterBookTickers(ws *aster.WSClient, symbols []string) {
+	streams := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		streams = append(streams, strings.ToLower(symbol)+"@bookTicker")
+	}
+	if err := ws.Subscribe(streams); err != nil {
+		log.Printf("[Aster Spot] Failed to subscribe: %v", err)
+	}
 }
 
 // startLighterWSPool 启动Lighter WebSocket连接池（分片模式）
-func startLighterWSPool(store *pricestore.PriceStore, markets []*lighter.Market, apiBaseURL string, marketIDs []int) *lighter.WSPool {
+func startLighterWSPool(store priceSink, markets []*lighter.Market, apiBaseURL string, marketIDs []int, registry *wsutil.SubscriptionRegistry, cfg *config.Config) (*lighter.WSPool, error) {
 	log.Println("[Lighter] Initializing WebSocket pool...")
 
 	// 步骤1：冷启动 - 使用 REST API 获取所有市场的快照数据
@@ -199,6 +695,13 @@ func startLighterWSPool(store *pricestore.PriceStore, markets []*lighter.Market,
 
 	// 步骤2：创建 WebSocket 连接池（每个连接 60 个市场）
 	pool := lighter.NewWSPool(markets, 60)
+	pool.SetSubscriptionRegistry(registry)
+	pool.SetMaxConnections(cfg.WSMaxConnections)
+	pool.SetSubscribePacing(
+		time.Duration(cfg.LighterSubscribeDelayMs)*time.Millisecond,
+		time.Duration(cfg.LighterConnStaggerMs)*time.Millisecond,
+	)
+	pool.SetReconnectRate(cfg.WSReconnectRate, cfg.WSReconnectBurst)
 
 	// 设置价格处理器
 	pool.SetPriceHandler(func(price *common.Price) {
@@ -207,24 +710,23 @@ func startLighterWSPool(store *pricestore.PriceStore, markets []*lighter.Market,
 
 	// 步骤3：启动连接池
 	if err := pool.Start(); err != nil {
-		log.Printf("[Lighter] Failed to start WebSocket pool: %v", err)
-		return nil
+		pool.Close()
+		return nil, fmt.Errorf("start WebSocket pool: %w", err)
 	}
 
 	log.Println("[Lighter] WebSocket pool started successfully")
-	return pool
+	return pool, nil
 }
 
 // startBinanceSpotWSPool 启动Binance现货WebSocket连接池（分片模式）
-func startBinanceSpotWSPool(store *pricestore.PriceStore) *binance.SpotWSPool {
+func startBinanceSpotWSPool(store priceSink, registry *wsutil.SubscriptionRegistry, cfg *config.Config) (*binance.SpotWSPool, error) {
 	log.Println("[Binance Spot] Initializing WebSocket pool...")
 
 	// 步骤1：冷启动 - 使用 REST API 获取所有交易对的快照数据
 	log.Println("[Binance Spot] Fetching initial snapshot via REST API...")
 	prices, err := binance.FetchSpotPrices()
 	if err != nil {
-		log.Printf("[Binance Spot] Failed to fetch initial snapshot: %v", err)
-		return nil
+		return nil, fmt.Errorf("fetch initial snapshot: %w", err)
 	}
 
 	// 更新到 store（冷启动数据）
@@ -234,24 +736,13 @@ func startBinanceSpotWSPool(store *pricestore.PriceStore) *binance.SpotWSPool {
 		symbols = append(symbols, price.Symbol)
 	}
 	log.Printf("[Binance Spot] Loaded %d symbols from REST snapshot", len(symbols))
-	// 确保汇率交易对被订阅（用于Quote Normalization）
-	ratePairs := []string{"USDCUSDT", "USDEUSDT", "FDUSDUSDT"}
-	for _, pair := range ratePairs {
-		found := false
-		for _, symbol := range symbols {
-			if symbol == pair {
-				found = true
-				break
-			}
-		}
-		if !found {
-			symbols = append(symbols, pair)
-			log.Printf("[Binance Spot] Added exchange rate pair: %s", pair)
-		}
-	}
+	symbols = withExchangeRatePairs(symbols)
 
 	// 步骤2：创建 WebSocket 连接池（每个连接 50 个 symbol）
 	pool := binance.NewSpotWSPool(symbols, 50)
+	pool.SetSubscriptionRegistry(registry)
+	pool.SetMaxConnections(cfg.WSMaxConnections)
+	pool.SetReconnectRate(cfg.WSReconnectRate, cfg.WSReconnectBurst)
 
 	// 设置 BookTicker 处理器
 	pool.SetBookTickerHandler(func(ticker *binance.WSBookTickerData) {
@@ -261,16 +752,35 @@ func startBinanceSpotWSPool(store *pricestore.PriceStore) *binance.SpotWSPool {
 
 	// 步骤3：启动连接池
 	if err := pool.Start(); err != nil {
-		log.Printf("[Binance Spot] Failed to start WebSocket pool: %v", err)
-		return nil
+		pool.Close()
+		return nil, fmt.Errorf("start WebSocket pool: %w", err)
 	}
 
 	log.Println("[Binance Spot] WebSocket pool started successfully")
-	return pool
+	return pool, nil
+}
+
+// withExchangeRatePairs 确保汇率交易对被订阅（用于Quote Normalization）
+func withExchangeRatePairs(symbols []string) []string {
+	ratePairs := []string{"USDCUSDT", "USDEUSDT", "FDUSDUSDT"}
+	for _, pair := range ratePairs {
+		found := false
+		for _, symbol := range symbols {
+			if symbol == pair {
+				found = true
+				break
+			}
+		}
+		if !found {
+			symbols = append(symbols, pair)
+			log.Printf("[Binance Spot] Added exchange rate pair: %s", pair)
+		}
+	}
+	return symbols
 }
 
 // startBinanceFuturesWebSocket 启动Binance合约WebSocket（使用BookTicker获取真实bid/ask）
-func startBinanceFuturesWebSocket(store *pricestore.PriceStore) *binance.WSClient {
+func startBinanceFuturesWebSocket(store priceSink, cfg *config.Config) (*binance.WSClient, error) {
 	log.Println("[Binance Futures] Connecting to WebSocket...")
 
 	// 使用bookTicker获取真实的bid/ask价格
@@ -282,17 +792,21 @@ func startBinanceFuturesWebSocket(store *pricestore.PriceStore) *binance.WSClien
 		store.UpdatePrice(price)
 	})
 
+	// 丢弃模式：!bookTicker 全量流处理不过来时，只保留关注的symbol
+	if cfg.BinanceLagShed {
+		binanceFuturesWS.SetShedWatchlist(cfg.MonitorSymbols)
+	}
+
 	if err := binanceFuturesWS.Connect(); err != nil {
-		log.Printf("[Binance Futures] Failed to connect WebSocket: %v", err)
-		return nil
+		return nil, fmt.Errorf("connect WebSocket: %w", err)
 	}
 
 	log.Println("[Binance Futures] WebSocket connected (BookTicker)")
-	return binanceFuturesWS
+	return binanceFuturesWS, nil
 }
 
 // runAsterRESTUpdater 运行Aster REST API更新任务（状态机模式，带context和timeout）
-func runAsterRESTUpdater(spotClient *aster.SpotClient, futuresClient *aster.FuturesClient, store *pricestore.PriceStore, stopChan <-chan struct{}) {
+func runAsterRESTUpdater(spotClient *aster.SpotClient, futuresClient *aster.FuturesClient, spotVolumes, futuresVolumes *volumeCache, store priceSink, stopChan <-chan struct{}) {
 	const (
 		stateColdStart = iota
 		stateNormal
@@ -300,7 +814,7 @@ func runAsterRESTUpdater(spotClient *aster.SpotClient, futuresClient *aster.Futu
 
 	// 立即执行一次初始化（带timeout）
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	fetchAsterPrices(ctx, spotClient, futuresClient, store)
+	fetchAsterPrices(ctx, spotClient, futuresClient, spotVolumes, futuresVolumes, store)
 	cancel()
 
 	state := stateColdStart
@@ -331,7 +845,7 @@ func runAsterRESTUpdater(spotClient *aster.SpotClient, futuresClient *aster.Futu
 			// 在goroutine中执行，允许被stopChan中断
 			done := make(chan struct{})
 			go func() {
-				fetchAsterPrices(ctx, spotClient, futuresClient, store)
+				fetchAsterPrices(ctx, spotClient, futuresClient, spotVolumes, futuresVolumes, store)
 				close(done)
 			}()
 
@@ -350,7 +864,7 @@ func runAsterRESTUpdater(spotClient *aster.SpotClient, futuresClient *aster.Futu
 }
 
 // runLighterRESTUpdater 运行Lighter REST API更新任务（状态机模式）
-func runLighterRESTUpdater(apiBaseURL string, marketIDs []int, store *pricestore.PriceStore, stopChan <-chan struct{}) {
+func runLighterRESTUpdater(apiBaseURL string, marketIDs []int, store priceSink, stopChan <-chan struct{}) {
 	const (
 		stateColdStart = iota
 		stateNormal
@@ -407,7 +921,7 @@ func runLighterRESTUpdater(apiBaseURL string, marketIDs []int, store *pricestore
 }
 
 // runBinanceRESTUpdater 运行Binance REST API更新任务（状态机模式）
-func runBinanceRESTUpdater(store *pricestore.PriceStore, stopChan <-chan struct{}) {
+func runBinanceRESTUpdater(store priceSink, stopChan <-chan struct{}) {
 	const (
 		stateColdStart = iota
 		stateNormal
@@ -463,8 +977,8 @@ func runBinanceRESTUpdater(store *pricestore.PriceStore, stopChan <-chan struct{
 	}
 }
 
-// runStatsReporter 定期打印统计信息
-func runStatsReporter(store *pricestore.PriceStore, stopChan <-chan struct{}) {
+// runStatsReporter 定期打印统计信息、尚未启动成功的数据源，以及成交量不低于 coverageMinVolume 的覆盖缺口
+func runStatsReporter(store *pricestore.PriceStore, sources *sourceSupervisor, binanceFuturesLag *binanceLag, coverageMinVolume float64, stopChan <-chan struct{}) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -480,7 +994,30 @@ func runStatsReporter(store *pricestore.PriceStore, stopChan <-chan struct{}) {
 				stats.TotalPrices, activePrices, stats.TotalSymbols, stats.TotalExchanges)
 
 			for exchange, count := range stats.ByExchange {
-				log.Printf("  - %s: %d prices", exchange, count)
+				log.Printf("  - %s: %d prices (%d rejected)", exchange, count, stats.RejectedByExchange[exchange])
+			}
+
+			for _, st := range sources.Statuses() {
+				if !st.Ready {
+					log.Printf("[Sources] %s: %s, last error: %s", st.Name, st, st.LastError)
+				}
+			}
+
+			if lag, ok := binanceFuturesLag.lagStatus(); ok && (lag.Lagging || lag.ShedMessages > 0) {
+				log.Printf("[Binance Lag] futures: lagging=%v event_lag=%dms processing_p95=%dus lag_events=%d shed=%d",
+					lag.Lagging, lag.LastEventLag.Milliseconds(), lag.ProcessingP95.Microseconds(), lag.LagEvents, lag.ShedMessages)
+			}
+
+			gaps := store.GetCoverageGaps(coverageMinVolume)
+			if len(gaps) > 0 {
+				log.Printf("[Coverage] %d symbols with 24h volume >= %.0f missing on some exchanges", len(gaps), coverageMinVolume)
+				for i, gap := range gaps {
+					if i == 5 {
+						log.Printf("  ... see /api/coverage-gaps for the full list")
+						break
+					}
+					log.Printf("  - %s (vol %.0f): on %v, missing %v, stale %v", gap.Symbol, gap.Volume24h, gap.PresentOn, gap.MissingOn, gap.StaleOn)
+				}
 			}
 		}
 	}
@@ -504,8 +1041,26 @@ func runDataCleaner(store *pricestore.PriceStore, stopChan <-chan struct{}) {
 	}
 }
 
+// REST拉取的接口名称：限频退避按接口记录（/api/stats fetch_backoff_until 的key为 "交易所/接口"），
+// 一个接口被限频不影响同一交易所的其他接口
+const (
+	endpointSpotBookTicker    = "spot_book_ticker"
+	endpointFuturesBookTicker = "futures_book_ticker"
+	endpointMarketData        = "market_data"
+)
+
+// inFetchBackoff 接口被限频、仍在退避期内时返回true（跳过本轮拉取）
+func inFetchBackoff(store priceSink, exchange common.Exchange, endpoint, tag string) bool {
+	wait := store.FetchBackoff(exchange, endpoint)
+	if wait <= 0 {
+		return false
+	}
+	common.DedupLog.Printf(string(exchange)+"/"+endpoint+"-backoff", "%s Rate limited, skipping REST fetch (%v left)", tag, wait.Round(time.Second))
+	return true
+}
+
 // fetchAsterPrices 获取Aster价格数据（支持context取消）
-func fetchAsterPrices(ctx context.Context, spotClient *aster.SpotClient, futuresClient *aster.FuturesClient, store *pricestore.PriceStore) {
+func fetchAsterPrices(ctx context.Context, spotClient *aster.SpotClient, futuresClient *aster.FuturesClient, spotVolumes, futuresVolumes *volumeCache, store priceSink) {
 	var wg sync.WaitGroup
 	doneChan := make(chan struct{})
 
@@ -513,26 +1068,47 @@ func fetchAsterPrices(ctx context.Context, spotClient *aster.SpotClient, futures
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tickers, err := spotClient.GetAllBookTickers()
-		if err != nil {
-			log.Printf("[Aster Spot] Failed to fetch prices: %v", err)
+		if inFetchBackoff(store, common.ExchangeAster, endpointSpotBookTicker, "[Aster Spot]") {
 			return
 		}
-
-		tickers24h, err := spotClient.GetAll24hrTickers()
+		fetchStart := time.Now()
+		tickers, err := spotClient.GetAllBookTickers()
 		if err != nil {
-			log.Printf("[Aster Spot] Failed to fetch 24h data: %v", err)
+			store.RecordFetchError(common.ExchangeAster, endpointSpotBookTicker, err)
+			log.Printf("[Aster Spot] Failed to fetch prices (%s): %v", common.ErrorClass(err), err)
 			return
 		}
+		store.RecordFetchLatency(common.ExchangeAster, time.Since(fetchStart))
 
-		volumeMap := make(map[string]float64)
-		for _, t := range tickers24h {
-			volumeMap[t.Symbol] = parseFloat(t.QuoteVolume)
+		// 成交量分层刷新：全量24hr低频，快速层symbol逐个高频（后台进行，报价使用已缓存的成交量）
+		symbols := make([]string, 0, len(tickers))
+		for _, ticker := range tickers {
+			symbols = append(symbols, ticker.Symbol)
 		}
+		spotVolumes.refreshAsync(symbols, func() (map[string]float64, error) {
+			tickers24h, err := spotClient.GetAll24hrTickers()
+			if err != nil {
+				return nil, err
+			}
+			volumeMap := make(map[string]float64, len(tickers24h))
+			for _, t := range tickers24h {
+				volumeMap[t.Symbol] = numutil.ParseFloat(t.QuoteVolume)
+			}
+			return volumeMap, nil
+		}, func(symbol string) (float64, error) {
+			t, err := spotClient.Get24hrTicker(symbol)
+			if err != nil {
+				return 0, err
+			}
+			return numutil.ParseFloat(t.QuoteVolume), nil
+		})
+
+		spotVolumes.reconcile(symbols)
 
 		for _, ticker := range tickers {
-			volume := volumeMap[ticker.Symbol]
+			volume, known := spotVolumes.get(ticker.Symbol)
 			price := spotClient.ConvertToCommonPrice(&ticker, volume)
+			price.VolumeKnown = known
 			store.UpdatePrice(price)
 		}
 
@@ -543,26 +1119,61 @@ func fetchAsterPrices(ctx context.Context, spotClient *aster.SpotClient, futures
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		tickers, err := futuresClient.GetAllBookTickers()
-		if err != nil {
-			log.Printf("[Aster Futures] Failed to fetch prices: %v", err)
+		if inFetchBackoff(store, common.ExchangeAster, endpointFuturesBookTicker, "[Aster Futures]") {
 			return
 		}
-
-		tickers24h, err := futuresClient.GetAll24hrTickers()
+		fetchStart := time.Now()
+		tickers, err := futuresClient.GetAllBookTickers()
 		if err != nil {
-			log.Printf("[Aster Futures] Failed to fetch 24h data: %v", err)
+			store.RecordFetchError(common.ExchangeAster, endpointFuturesBookTicker, err)
+			log.Printf("[Aster Futures] Failed to fetch prices (%s): %v", common.ErrorClass(err), err)
 			return
 		}
+		store.RecordFetchLatency(common.ExchangeAster, time.Since(fetchStart))
 
-		volumeMap := make(map[string]float64)
-		for _, t := range tickers24h {
-			volumeMap[t.Symbol] = parseFloat(t.QuoteVolume)
+		// 成交量分层刷新：全量24hr低频，快速层symbol逐个高频（后台进行，报价使用已缓存的成交量）
+		symbols := make([]string, 0, len(tickers))
+		for _, ticker := range tickers {
+			symbols = append(symbols, ticker.Symbol)
+		}
+		futuresVolumes.refreshAsync(symbols, func() (map[string]float64, error) {
+			tickers24h, err := futuresClient.GetAll24hrTickers()
+			if err != nil {
+				return nil, err
+			}
+			volumeMap := make(map[string]float64, len(tickers24h))
+			for _, t := range tickers24h {
+				volumeMap[t.Symbol] = numutil.ParseFloat(t.QuoteVolume)
+			}
+			return volumeMap, nil
+		}, func(symbol string) (float64, error) {
+			t, err := futuresClient.Get24hrTicker(symbol)
+			if err != nil {
+				return 0, err
+			}
+			return numutil.ParseFloat(t.QuoteVolume), nil
+		})
+
+		futuresVolumes.reconcile(symbols)
+
+		// 标记/指数价格用于过滤偏离过多的盘口（获取失败时不附带，不影响报价入库）
+		markPrices := make(map[string]aster.MarkPrice)
+		if marks, err := futuresClient.GetAllMarkPrices(); err != nil {
+			common.DedupLog.Printf("aster-mark", "[Aster Futures] Failed to fetch mark prices (%s): %v", common.ErrorClass(err), err)
+		} else {
+			for _, mark := range marks {
+				markPrices[mark.Symbol] = mark
+			}
 		}
 
 		for _, ticker := range tickers {
-			volume := volumeMap[ticker.Symbol]
+			volume, known := futuresVolumes.get(ticker.Symbol)
 			price := futuresClient.ConvertToCommonPrice(&ticker, volume)
+			price.VolumeKnown = known
+			if mark, ok := markPrices[ticker.Symbol]; ok {
+				price.MarkPrice = numutil.ParseFloat(mark.MarkPrice)
+				price.IndexPrice = numutil.ParseFloat(mark.IndexPrice)
+			}
 			store.UpdatePrice(price)
 		}
 
@@ -585,16 +1196,23 @@ func fetchAsterPrices(ctx context.Context, spotClient *aster.SpotClient, futures
 }
 
 // fetchLighterPrices 获取Lighter价格数据（支持context取消）
-func fetchLighterPrices(ctx context.Context, apiBaseURL string, marketIDs []int, store *pricestore.PriceStore) {
+func fetchLighterPrices(ctx context.Context, apiBaseURL string, marketIDs []int, store priceSink) {
+	if inFetchBackoff(store, common.ExchangeLighter, endpointMarketData, "[Lighter]") {
+		return
+	}
+
 	done := make(chan struct{})
 
 	go func() {
+		fetchStart := time.Now()
 		prices, err := lighter.FetchMarketData(apiBaseURL, marketIDs)
 		if err != nil {
-			log.Printf("[Lighter] Failed to fetch prices: %v", err)
+			store.RecordFetchError(common.ExchangeLighter, endpointMarketData, err)
+			log.Printf("[Lighter] Failed to fetch prices (%s): %v", common.ErrorClass(err), err)
 			close(done)
 			return
 		}
+		store.RecordFetchLatency(common.ExchangeLighter, time.Since(fetchStart))
 
 		for _, price := range prices {
 			store.UpdatePrice(price)
@@ -613,7 +1231,7 @@ func fetchLighterPrices(ctx context.Context, apiBaseURL string, marketIDs []int,
 }
 
 // fetchBinancePrices 获取Binance价格数据（支持context取消）
-func fetchBinancePrices(ctx context.Context, store *pricestore.PriceStore) {
+func fetchBinancePrices(ctx context.Context, store priceSink) {
 	var wg sync.WaitGroup
 	doneChan := make(chan struct{})
 
@@ -621,11 +1239,17 @@ func fetchBinancePrices(ctx context.Context, store *pricestore.PriceStore) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		if inFetchBackoff(store, common.ExchangeBinance, endpointSpotBookTicker, "[Binance Spot]") {
+			return
+		}
+		fetchStart := time.Now()
 		prices, err := binance.FetchSpotPrices()
 		if err != nil {
-			log.Printf("[Binance Spot] Failed to fetch prices: %v", err)
+			store.RecordFetchError(common.ExchangeBinance, endpointSpotBookTicker, err)
+			log.Printf("[Binance Spot] Failed to fetch prices (%s): %v", common.ErrorClass(err), err)
 			return
 		}
+		store.RecordFetchLatency(common.ExchangeBinance, time.Since(fetchStart))
 
 		for _, price := range prices {
 			store.UpdatePrice(price)
@@ -638,11 +1262,17 @@ func fetchBinancePrices(ctx context.Context, store *pricestore.PriceStore) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		if inFetchBackoff(store, common.ExchangeBinance, endpointFuturesBookTicker, "[Binance Futures]") {
+			return
+		}
+		fetchStart := time.Now()
 		prices, err := binance.FetchFuturesPrices()
 		if err != nil {
-			log.Printf("[Binance Futures] Failed to fetch prices: %v", err)
+			store.RecordFetchError(common.ExchangeBinance, endpointFuturesBookTicker, err)
+			log.Printf("[Binance Futures] Failed to fetch prices (%s): %v", common.ErrorClass(err), err)
 			return
 		}
+		store.RecordFetchLatency(common.ExchangeBinance, time.Since(fetchStart))
 
 		for _, price := range prices {
 			store.UpdatePrice(price)
@@ -664,33 +1294,3 @@ func fetchBinancePrices(ctx context.Context, store *pricestore.PriceStore) {
 		log.Println("[Binance] Fetch cancelled by context")
 	}
 }
-
-// parseFloat 解析字符串为float64
-func parseFloat(s string) float64 {
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0
-	}
-	return f
-}
-
-// openBrowser 根据操作系统打开默认浏览器
-func openBrowser(url string) {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", url)
-	case "darwin":
-		cmd = exec.Command("open", url)
-	default: // linux, freebsd, openbsd, netbsd
-		cmd = exec.Command("xdg-open", url)
-	}
-
-	err := cmd.Start()
-	if err != nil {
-		log.Printf("[Browser] Failed to open browser: %v", err)
-	} else {
-		log.Printf("[Browser] Opening %s in default browser", url)
-	}
-}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/exchange/binance"
+	"crypto-arbitrage-monitor/internal/exchange/lighter"
+	"log"
+	"time"
+)
+
+// reloadMinKeepRatio 刷新得到的列表少于当前订阅数的该比例时跳过本轮，
+// 避免接口返回不完整的列表时退订大部分正常的订阅
+const reloadMinKeepRatio = 0.5
+
+// runLighterMarketRefresher 定期从 orderBookDetails 刷新市场列表，就地更新连接池的订阅
+func runLighterMarketRefresher(pool *lighter.WSPool, apiBaseURL string, interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			// 不使用 GetCommonMarkets：接口失败时它会返回内置的少量市场
+			markets, err := lighter.FetchMarketsFromAPI(apiBaseURL + "/api/v1/orderBookDetails")
+			if err != nil {
+				log.Printf("[Lighter] Market refresh failed: %v", err)
+				continue
+			}
+			current := pool.GetStats().Markets
+			if float64(len(markets)) < float64(current)*reloadMinKeepRatio {
+				log.Printf("[Lighter] Market refresh returned %d markets (subscribed %d), skipping reload", len(markets), current)
+				continue
+			}
+			if _, _, err := pool.Reload(markets); err != nil {
+				log.Printf("[Lighter] Market reload incomplete: %v", err)
+			}
+		}
+	}
+}
+
+// runBinanceSpotSymbolRefresher 定期从现货 bookTicker 快照刷新交易对列表，就地更新连接池的订阅
+func runBinanceSpotSymbolRefresher(pool *binance.SpotWSPool, interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			prices, err := binance.FetchSpotPrices()
+			if err != nil {
+				log.Printf("[Binance Spot] Symbol refresh failed: %v", err)
+				continue
+			}
+			symbols := make([]string, 0, len(prices))
+			for _, price := range prices {
+				symbols = append(symbols, price.Symbol)
+			}
+			current := pool.SymbolCount()
+			if float64(len(symbols)) < float64(current)*reloadMinKeepRatio {
+				log.Printf("[Binance Spot] Symbol refresh returned %d symbols (subscribed %d), skipping reload", len(symbols), current)
+				continue
+			}
+			if _, _, err := pool.Reload(withExchangeRatePairs(symbols)); err != nil {
+				log.Printf("[Binance Spot] Symbol reload incomplete: %v", err)
+			}
+		}
+	}
+}
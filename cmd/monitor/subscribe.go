@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/exchange/aster"
+	"crypto-arbitrage-monitor/internal/exchange/binance"
+	"crypto-arbitrage-monitor/internal/exchange/lighter"
+	"crypto-arbitrage-monitor/internal/web"
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// errSourceStarting 连接池还在后台重试启动（见 sourceSupervisor）
+var errSourceStarting = errors.New("WebSocket pool is still starting")
+
+// binanceSubscriber Binance 按需订阅：现货追加到连接池，合约已由 !bookTicker 全量流覆盖
+type binanceSubscriber struct {
+	spotPool atomic.Pointer[binance.SpotWSPool] // 连接池启动成功后设置
+	store    priceSink
+}
+
+// Subscribe 实现 web.Subscriber
+func (s *binanceSubscriber) Subscribe(marketType common.MarketType, symbol string) (web.SubscribeStatus, error) {
+	if marketType == common.MarketTypeFuture {
+		return web.SubscribeExisting, nil
+	}
+	spotPool := s.spotPool.Load()
+	if spotPool == nil {
+		return "", common.NewTemporaryError(common.ExchangeBinance, errSourceStarting)
+	}
+
+	// 先用 REST 校验 symbol（不存在时返回 -1121），同时写入冷启动数据
+	price, err := binance.FetchSpotBookTicker(symbol)
+	if err != nil {
+		return "", err
+	}
+	s.store.UpdatePrice(price)
+
+	// 已订阅时返回 *wsutil.SubscriptionConflictError，由 /api/subscribe 作为 existing 返回
+	if err := spotPool.AddSymbol(price.Symbol); err != nil {
+		return "", err
+	}
+	log.Printf("[Subscribe] BINANCE SPOT %s subscribed on demand", price.Symbol)
+	return web.SubscribeAdded, nil
+}
+
+// lighterSubscriber Lighter 按需订阅：从市场列表查找 market id 后追加到连接池
+type lighterSubscriber struct {
+	pool       atomic.Pointer[lighter.WSPool] // 连接池启动成功后设置
+	apiBaseURL string
+	store      priceSink
+}
+
+// Subscribe 实现 web.Subscriber
+func (s *lighterSubscriber) Subscribe(marketType common.MarketType, symbol string) (web.SubscribeStatus, error) {
+	pool := s.pool.Load()
+	if pool == nil {
+		return "", common.NewTemporaryError(common.ExchangeLighter, errSourceStarting)
+	}
+
+	marketKind := "spot"
+	if marketType == common.MarketTypeFuture {
+		marketKind = "perp"
+	}
+
+	markets, err := lighter.FetchMarketsFromAPI(s.apiBaseURL + "/api/v1/orderBookDetails")
+	if err != nil {
+		return "", common.NewTemporaryError(common.ExchangeLighter, err)
+	}
+	var market *lighter.Market
+	for _, m := range markets {
+		if m.Symbol == symbol && m.Type == marketKind {
+			market = m
+			break
+		}
+	}
+	if market == nil {
+		return "", &common.APIError{
+			Exchange: common.ExchangeLighter,
+			Kind:     common.ErrBadRequest,
+			Message:  fmt.Sprintf("no active %s market %s", marketKind, symbol),
+		}
+	}
+
+	if err := pool.AddMarket(market); err != nil {
+		return "", err
+	}
+
+	// 冷启动数据，WebSocket 快照到达前先有报价
+	if prices, err := lighter.FetchMarketData(s.apiBaseURL, []int{market.MarketID}); err == nil {
+		for _, price := range prices {
+			s.store.UpdatePrice(price)
+		}
+	}
+	log.Printf("[Subscribe] LIGHTER %s %s (market %d) subscribed on demand", marketType, symbol, market.MarketID)
+	return web.SubscribeAdded, nil
+}
+
+// asterSubscriber Aster 按需订阅：合约已由 !bookTicker 全量流覆盖，现货立即刷新 exchangeInfo 发现新交易对
+type asterSubscriber struct {
+	spotWSEnabled bool
+	spotDiscovery atomic.Pointer[aster.SymbolDiscovery] // 现货 WebSocket 启动成功后设置
+}
+
+// Subscribe 实现 web.Subscriber
+func (s *asterSubscriber) Subscribe(marketType common.MarketType, symbol string) (web.SubscribeStatus, error) {
+	if marketType == common.MarketTypeFuture {
+		return web.SubscribeExisting, nil
+	}
+	if !s.spotWSEnabled {
+		return web.SubscribeUnsupported, nil
+	}
+	spotDiscovery := s.spotDiscovery.Load()
+	if spotDiscovery == nil {
+		return "", common.NewTemporaryError(common.ExchangeAster, errSourceStarting)
+	}
+	if spotDiscovery.Has(symbol) {
+		return web.SubscribeExisting, nil
+	}
+
+	// 新发现的交易对由发现回调订阅
+	if _, err := spotDiscovery.Refresh(); err != nil {
+		return "", err
+	}
+	if !spotDiscovery.Has(symbol) {
+		return "", &common.APIError{
+			Exchange: common.ExchangeAster,
+			Kind:     common.ErrBadRequest,
+			Message:  fmt.Sprintf("%s is not a trading spot symbol", symbol),
+		}
+	}
+	log.Printf("[Subscribe] ASTER SPOT %s subscribed on demand", symbol)
+	return web.SubscribeAdded, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 数据源启动失败后的重试间隔（指数退避）
+const (
+	sourceRetryMinBackoff = 5 * time.Second
+	sourceRetryMaxBackoff = 2 * time.Minute
+)
+
+// sourceStartFunc 启动一个数据源，成功时返回关闭函数
+type sourceStartFunc func() (closeFn func(), err error)
+
+// sourceStatus 数据源的启动状态
+type sourceStatus struct {
+	Name      string
+	Ready     bool
+	Attempt   int       // 已尝试启动的次数
+	LastError string    // 最近一次启动失败的原因
+	Since     time.Time // 进入当前状态的时间
+}
+
+// String 状态描述：ready 或 starting (attempt N)
+func (st sourceStatus) String() string {
+	if st.Ready {
+		return "ready"
+	}
+	return fmt.Sprintf("starting (attempt %d)", st.Attempt+1)
+}
+
+// sourceSupervisor 数据源启动监督：启动失败（交易所REST/WebSocket短暂不可用）时在后台按退避无限重试，
+// 直到启动成功或 stopChan 关闭，而不是放弃该数据源
+type sourceSupervisor struct {
+	stopChan   <-chan struct{}
+	wg         sync.WaitGroup // 重试goroutine和数据源启动后的后台任务
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	tasks      *taskRunner // 后台任务 panic 后的重启策略（等待由 wg 负责）
+
+	mu       sync.Mutex
+	statuses map[string]*sourceStatus
+	closers  []func() // 已启动的数据源，按启动顺序
+}
+
+// newSourceSupervisor 创建数据源启动监督，stopChan 关闭后停止重试
+func newSourceSupervisor(stopChan <-chan struct{}) *sourceSupervisor {
+	return &sourceSupervisor{
+		stopChan:   stopChan,
+		minBackoff: sourceRetryMinBackoff,
+		maxBackoff: sourceRetryMaxBackoff,
+		tasks:      newTaskRunner(stopChan),
+		statuses:   make(map[string]*sourceStatus),
+	}
+}
+
+// Start 启动数据源：第一次在当前goroutine中尝试，失败后在后台按退避重试
+// start 在启动成功时负责把数据源交给使用方（订阅器、刷新任务等），返回的关闭函数由 Close 调用
+func (s *sourceSupervisor) Start(name string, start sourceStartFunc) {
+	s.mu.Lock()
+	s.statuses[name] = &sourceStatus{Name: name, Since: time.Now()}
+	s.mu.Unlock()
+
+	if s.attempt(name, start) {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.retry(name, start)
+	}()
+}
+
+// retry 按退避重试启动，直到成功或停止
+func (s *sourceSupervisor) retry(name string, start sourceStartFunc) {
+	backoff := s.minBackoff
+	for {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-s.stopChan:
+			timer.Stop()
+			log.Printf("[Sources] %s: startup retry cancelled", name)
+			return
+		case <-timer.C:
+		}
+
+		if s.attempt(name, start) {
+			return
+		}
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// attempt 尝试启动一次，记录状态变化
+func (s *sourceSupervisor) attempt(name string, start sourceStartFunc) bool {
+	closeFn, err := start()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.statuses[name]
+	st.Attempt++
+	if err != nil {
+		st.LastError = err.Error()
+		log.Printf("[Sources] %s: startup attempt %d failed, retrying in background: %v", name, st.Attempt, err)
+		return false
+	}
+
+	if st.Attempt > 1 {
+		log.Printf("[Sources] %s: starting -> ready after %d attempts (down %v)", name, st.Attempt, time.Since(st.Since).Round(time.Second))
+	}
+	st.Ready = true
+	st.LastError = ""
+	st.Since = time.Now()
+	if closeFn != nil {
+		s.closers = append(s.closers, closeFn)
+	}
+	return true
+}
+
+// Go 运行数据源启动后的后台任务（需要在 stopChan 关闭后退出，panic 后按 taskRunner 的策略重启），Close 会等待其结束
+func (s *sourceSupervisor) Go(name string, task func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.tasks.run(name, task)
+	}()
+}
+
+// Statuses 所有数据源的启动状态（按名称排序）
+func (s *sourceSupervisor) Statuses() []sourceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]sourceStatus, 0, len(s.statuses))
+	for _, st := range s.statuses {
+		statuses = append(statuses, *st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Close 等待重试和后台任务退出（调用前需关闭 stopChan），然后按启动的逆序关闭已启动的数据源
+func (s *sourceSupervisor) Close() {
+	s.wg.Wait()
+
+	s.mu.Lock()
+	closers := s.closers
+	s.closers = nil
+	s.mu.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		closers[i]()
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestSourceSupervisor 极短退避的数据源启动监督
+func newTestSourceSupervisor(stopChan <-chan struct{}) *sourceSupervisor {
+	s := newSourceSupervisor(stopChan)
+	s.minBackoff = time.Millisecond
+	s.maxBackoff = 4 * time.Millisecond
+	s.tasks = newTestTaskRunner(stopChan)
+	return s
+}
+
+// sourceStatusOf 某个数据源的启动状态
+func sourceStatusOf(t *testing.T, s *sourceSupervisor, name string) sourceStatus {
+	t.Helper()
+	for _, st := range s.Statuses() {
+		if st.Name == name {
+			return st
+		}
+	}
+	t.Fatalf("no status for %s", name)
+	return sourceStatus{}
+}
+
+func TestSourceSupervisorRetriesUntilReady(t *testing.T) {
+	stop := make(chan struct{})
+	s := newTestSourceSupervisor(stop)
+
+	var attempts, closed atomic.Int32
+	s.Start("flaky", func() (func(), error) {
+		// 前两次启动失败，第三次成功
+		if attempts.Add(1) <= 2 {
+			return nil, errors.New("exchange unavailable")
+		}
+		return func() { closed.Add(1) }, nil
+	})
+
+	st := sourceStatusOf(t, s, "flaky")
+	if st.Ready || st.Attempt != 1 || st.LastError != "exchange unavailable" || st.String() != "starting (attempt 2)" {
+		t.Fatalf("status after the first attempt = %+v (%s)", st, st)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !sourceStatusOf(t, s, "flaky").Ready {
+		if time.Now().After(deadline) {
+			t.Fatalf("source not ready after retries: %+v", sourceStatusOf(t, s, "flaky"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	st = sourceStatusOf(t, s, "flaky")
+	if st.Attempt != 3 || st.LastError != "" || st.String() != "ready" {
+		t.Fatalf("status when ready = %+v", st)
+	}
+
+	close(stop)
+	s.Close()
+	if n := attempts.Load(); n != 3 {
+		t.Fatalf("start called %d times, want 3 (no retries after success)", n)
+	}
+	if n := closed.Load(); n != 1 {
+		t.Fatalf("close function called %d times, want 1", n)
+	}
+}
+
+func TestSourceSupervisorCleanShutdown(t *testing.T) {
+	stop := make(chan struct{})
+	s := newTestSourceSupervisor(stop)
+	s.minBackoff = time.Hour // 停止时仍在等待下一次重试
+
+	var mu sync.Mutex
+	var order []string
+	closer := func(name string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+	s.Start("first", func() (func(), error) { return closer("first"), nil })
+	s.Start("second", func() (func(), error) { return closer("second"), nil })
+	s.Start("down", func() (func(), error) { return nil, errors.New("exchange unavailable") })
+
+	// 数据源启动后的后台任务在 stopChan 关闭后退出，Close 等待其结束
+	var taskDone atomic.Bool
+	s.Go("refresher", func() {
+		<-stop
+		time.Sleep(10 * time.Millisecond)
+		taskDone.Store(true)
+	})
+
+	close(stop)
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return after stop (retry not cancelled)")
+	}
+
+	if !taskDone.Load() {
+		t.Fatal("Close returned before the background task exited")
+	}
+	if want := []string{"second", "first"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("sources closed in order %v, want %v (reverse start order)", order, want)
+	}
+	if st := sourceStatusOf(t, s, "down"); st.Ready || st.Attempt != 1 {
+		t.Fatalf("failed source status = %+v, want one attempt and not ready", st)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// 后台任务 panic 后的重启策略
+const (
+	taskRestartMinBackoff = 1 * time.Second
+	taskRestartMaxBackoff = 1 * time.Minute
+	taskMaxRestarts       = 10              // 连续重启次数上限，超过后放弃该任务
+	taskHealthyRun        = 5 * time.Minute // 运行超过该时长后再 panic 时重新计数
+)
+
+// taskRunner 后台任务（任务1-15）的运行和 panic 恢复：任务 panic 后记录堆栈并按退避重启，
+// 而不是让该任务的goroutine静默退出、数据停止更新。任务正常返回（stopChan 关闭）时不重启
+type taskRunner struct {
+	stopChan    <-chan struct{}
+	wg          sync.WaitGroup
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	maxRestarts int
+}
+
+// newTaskRunner 创建后台任务运行器，stopChan 关闭后不再重启
+func newTaskRunner(stopChan <-chan struct{}) *taskRunner {
+	return &taskRunner{
+		stopChan:    stopChan,
+		minBackoff:  taskRestartMinBackoff,
+		maxBackoff:  taskRestartMaxBackoff,
+		maxRestarts: taskMaxRestarts,
+	}
+}
+
+// supervise 在新的goroutine中运行任务，panic 后按退避重启（最多 maxRestarts 次），Wait 等待其结束
+func (t *taskRunner) supervise(name string, task func()) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.run(name, task)
+	}()
+}
+
+// run 运行任务直到正常返回、stopChan 关闭或连续重启次数超过上限
+func (t *taskRunner) run(name string, task func()) {
+	backoff := t.minBackoff
+	restarts := 0
+	for {
+		started := time.Now()
+		if !runRecovered(name, task) {
+			return
+		}
+
+		// 运行了足够长时间后再 panic，视为新的故障，重新计数
+		if time.Since(started) >= taskHealthyRun {
+			backoff = t.minBackoff
+			restarts = 0
+		}
+		if restarts >= t.maxRestarts {
+			log.Printf("[Tasks] %s: panicked %d times in a row, giving up", name, restarts+1)
+			return
+		}
+		restarts++
+
+		log.Printf("[Tasks] %s: restarting in %v (restart %d/%d)", name, backoff, restarts, t.maxRestarts)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-t.stopChan:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, t.maxBackoff)
+	}
+}
+
+// runRecovered 运行任务，捕获 panic 并记录堆栈，返回是否发生了 panic
+func runRecovered(name string, task func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Tasks] %s: panic: %v\n%s", name, r, debug.Stack())
+			panicked = true
+		}
+	}()
+	task()
+	return false
+}
+
+// Wait 等待所有任务结束（调用前需关闭 stopChan）
+func (t *taskRunner) Wait() {
+	t.wg.Wait()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestTaskRunner 极短退避的任务运行器
+func newTestTaskRunner(stopChan <-chan struct{}) *taskRunner {
+	runner := newTaskRunner(stopChan)
+	runner.minBackoff = time.Millisecond
+	runner.maxBackoff = 5 * time.Millisecond
+	return runner
+}
+
+func TestPanickingTaskIsRestartedAndKeepsRunning(t *testing.T) {
+	stop := make(chan struct{})
+	runner := newTestTaskRunner(stop)
+
+	var starts, ticks atomic.Int32
+	runner.supervise("flaky", func() {
+		// 第一次运行时 panic，重启后正常运行直到 stop
+		if starts.Add(1) == 1 {
+			panic("boom")
+		}
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ticks.Add(1)
+			}
+		}
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for ticks.Load() < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("task not running after panic (starts %d, ticks %d)", starts.Load(), ticks.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(stop)
+	runner.Wait()
+	if n := starts.Load(); n != 2 {
+		t.Fatalf("task started %d times, want 2 (one restart)", n)
+	}
+}
+
+func TestTaskGivesUpAfterMaxRestarts(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	runner := newTestTaskRunner(stop)
+	runner.maxRestarts = 3
+
+	var starts atomic.Int32
+	runner.supervise("broken", func() {
+		starts.Add(1)
+		panic("always")
+	})
+
+	done := make(chan struct{})
+	go func() {
+		runner.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("runner kept restarting a task that always panics")
+	}
+	if n := starts.Load(); n != 4 {
+		t.Fatalf("task started %d times, want 1 + 3 restarts", n)
+	}
+}
+
+func TestTaskReturningNormallyIsNotRestarted(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	runner := newTestTaskRunner(stop)
+
+	var starts atomic.Int32
+	runner.supervise("oneshot", func() { starts.Add(1) })
+	runner.Wait()
+	if n := starts.Load(); n != 1 {
+		t.Fatalf("task started %d times, want 1", n)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 分层刷新的数据源名称（/api/stats refresh_tiers 的key）
+const (
+	tierSourceAsterSpot24h    = "aster_spot_24hr"
+	tierSourceAsterFutures24h = "aster_futures_24hr"
+)
+
+// volumeCache 按刷新分层维护某个数据源的24小时成交量
+// 全量24hr接口低频刷新所有symbol，快速层symbol（关注列表和出现机会的symbol）用单symbol接口高频刷新
+// 刷新在后台进行，报价入库只读取缓存，不等待成交量请求
+type volumeCache struct {
+	source   string
+	exchange common.Exchange
+	tiers    *pricestore.PriceStore // 分层状态、拉取错误和限频退避记录在默认存储中（退避按数据源 source 记录）
+	now      func() time.Time       // 当前时间（测试中替换为假时钟）
+
+	mu         sync.Mutex
+	volumes    map[string]float64 // key: 交易所原始symbol
+	dropped    map[string]bool    // 单symbol请求返回永久错误（例如symbol不存在）的symbol，下次全量刷新前不再请求
+	refreshing bool               // 后台刷新进行中
+}
+
+// newVolumeCache 创建成交量缓存
+func newVolumeCache(source string, exchange common.Exchange, tiers *pricestore.PriceStore) *volumeCache {
+	return &volumeCache{
+		source:   source,
+		exchange: exchange,
+		tiers:    tiers,
+		now:      time.Now,
+		volumes:  make(map[string]float64),
+		dropped:  make(map[string]bool),
+	}
+}
+
+// refreshAsync 在后台执行 refresh，立即返回；上一次刷新尚未完成时跳过本轮
+// 返回的通道在刷新结束（或跳过）时关闭
+func (vc *volumeCache) refreshAsync(symbols []string, fetchAll func() (map[string]float64, error), fetchOne func(symbol string) (float64, error)) <-chan struct{} {
+	done := make(chan struct{})
+
+	vc.mu.Lock()
+	if vc.refreshing {
+		vc.mu.Unlock()
+		close(done)
+		return done
+	}
+	vc.refreshing = true
+	vc.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer func() {
+			vc.mu.Lock()
+			vc.refreshing = false
+			vc.mu.Unlock()
+		}()
+		vc.refresh(symbols, fetchAll, fetchOne)
+	}()
+	return done
+}
+
+// refresh 按到期的刷新层更新成交量
+// symbols 为本轮报价涉及的交易所symbol，fetchAll 拉取全量成交量，fetchOne 拉取单个symbol的成交量
+func (vc *volumeCache) refresh(symbols []string, fetchAll func() (map[string]float64, error), fetchOne func(symbol string) (float64, error)) {
+	if vc.tiers.FetchBackoff(vc.exchange, vc.source) > 0 {
+		return
+	}
+	now := vc.now()
+
+	switch vc.tiers.DueRefreshTier(vc.source, now) {
+	case pricestore.RefreshTierFull:
+		volumes, err := fetchAll()
+		if err != nil {
+			vc.tiers.RecordFetchError(vc.exchange, vc.source, err)
+			log.Printf("[Volumes] %s full refresh failed (%s): %v", vc.source, common.ErrorClass(err), err)
+			return
+		}
+		vc.mu.Lock()
+		vc.volumes = volumes
+		vc.dropped = make(map[string]bool)
+		vc.mu.Unlock()
+		vc.tiers.MarkTierRefreshed(vc.source, pricestore.RefreshTierFull, now, len(volumes))
+
+	case pricestore.RefreshTierFast:
+		refreshed := 0
+		for _, symbol := range symbols {
+			if !vc.tiers.IsFastTierSymbol(symbol, now) || vc.isDropped(symbol) {
+				continue
+			}
+			volume, err := fetchOne(symbol)
+			if err != nil {
+				vc.tiers.RecordFetchError(vc.exchange, vc.source, err)
+				log.Printf("[Volumes] %s refresh of %s failed (%s): %v", vc.source, symbol, common.ErrorClass(err), err)
+				if errors.Is(err, common.ErrRateLimited) {
+					break
+				}
+				if common.IsPermanentError(err) {
+					vc.mu.Lock()
+					vc.dropped[symbol] = true
+					vc.mu.Unlock()
+				}
+				continue
+			}
+			vc.mu.Lock()
+			vc.volumes[symbol] = volume
+			vc.mu.Unlock()
+			refreshed++
+		}
+		vc.tiers.MarkTierRefreshed(vc.source, pricestore.RefreshTierFast, now, refreshed)
+	}
+}
+
+// isDropped 判断symbol是否因永久错误暂停单独刷新
+func (vc *volumeCache) isDropped(symbol string) bool {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return vc.dropped[symbol]
+}
+
+// get 获取symbol的24小时成交量，没有数据时返回 0, false
+func (vc *volumeCache) get(symbol string) (float64, bool) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	volume, known := vc.volumes[symbol]
+	return volume, known
+}
+
+// maxLoggedSymbols 对账日志中最多列出的symbol数
+const maxLoggedSymbols = 10
+
+// reconcile 对账本轮报价的symbol和成交量缓存：两边不一致时记录日志（按数据源合并，60秒内只输出一次）和计数
+// 成交量缓存为空（尚未完成第一次全量刷新）时只记录计数
+func (vc *volumeCache) reconcile(symbols []string) {
+	quoted := make(map[string]bool, len(symbols))
+	missingVolume := make([]string, 0)
+
+	vc.mu.Lock()
+	cached := len(vc.volumes)
+	for _, symbol := range symbols {
+		quoted[symbol] = true
+		if _, exists := vc.volumes[symbol]; !exists {
+			missingVolume = append(missingVolume, symbol)
+		}
+	}
+	missingQuote := make([]string, 0)
+	for symbol := range vc.volumes {
+		if !quoted[symbol] {
+			missingQuote = append(missingQuote, symbol)
+		}
+	}
+	vc.mu.Unlock()
+
+	vc.tiers.RecordVolumeCoverage(vc.source, len(missingVolume), len(missingQuote))
+	if cached == 0 {
+		return
+	}
+	if len(missingVolume) > 0 {
+		common.DedupLog.Printf("volumes-"+vc.source, "[Volumes] %s: %d quoted symbols have no 24hr volume (treated as unknown): %s",
+			vc.source, len(missingVolume), summarizeSymbols(missingVolume))
+	}
+	if len(missingQuote) > 0 {
+		common.DedupLog.Printf("volumes-"+vc.source, "[Volumes] %s: %d symbols have 24hr volume but no quote: %s",
+			vc.source, len(missingQuote), summarizeSymbols(missingQuote))
+	}
+}
+
+// summarizeSymbols 排序后列出前 maxLoggedSymbols 个symbol
+func summarizeSymbols(symbols []string) string {
+	sort.Strings(symbols)
+	if len(symbols) <= maxLoggedSymbols {
+		return strings.Join(symbols, ",")
+	}
+	return fmt.Sprintf("%s ... (+%d more)", strings.Join(symbols[:maxLoggedSymbols], ","), len(symbols)-maxLoggedSymbols)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVolumeSource 记录全量和单symbol请求
+type fakeVolumeSource struct {
+	mu       sync.Mutex
+	fullCall int
+	oneCalls []string
+	block    chan struct{} // 非nil时单symbol请求等待该通道关闭
+}
+
+func (f *fakeVolumeSource) fetchAll() (map[string]float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fullCall++
+	return map[string]float64{"BTCUSDT": 100, "ETHUSDT": 50, "XYZUSDT": 1}, nil
+}
+
+func (f *fakeVolumeSource) fetchOne(symbol string) (float64, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.oneCalls = append(f.oneCalls, symbol)
+	return 1000, nil
+}
+
+// takeCalls 返回并清空记录
+func (f *fakeVolumeSource) takeCalls() (int, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	full, one := f.fullCall, f.oneCalls
+	f.fullCall, f.oneCalls = 0, nil
+	sort.Strings(one)
+	return full, one
+}
+
+func newTestVolumeCache(watchlist ...string) (*volumeCache, *time.Time) {
+	store := pricestore.NewPriceStore()
+	store.SetRefreshTiers(&pricestore.RefreshTierConfig{
+		FastInterval: time.Minute,
+		FullInterval: 15 * time.Minute,
+		PromoteFor:   10 * time.Minute,
+	}, watchlist)
+
+	clock := time.Unix(1700000000, 0)
+	vc := newVolumeCache("test_24hr", common.ExchangeAster, store)
+	vc.now = func() time.Time { return clock }
+	return vc, &clock
+}
+
+func TestVolumeCacheTierCadence(t *testing.T) {
+	vc, clock := newTestVolumeCache("BTCUSDT", "ETHUSDT")
+	src := &fakeVolumeSource{}
+	symbols := []string{"BTCUSDT", "ETHUSDT", "XYZUSDT"}
+
+	steps := []struct {
+		name     string
+		offset   time.Duration
+		wantFull int
+		wantOne  []string
+	}{
+		{"first full", 0, 1, nil},
+		{"fast interval not reached", 30 * time.Second, 0, nil},
+		{"fast tier requests only the watchlist", time.Minute, 0, []string{"BTCUSDT", "ETHUSDT"}},
+		{"fast tier just refreshed", 90 * time.Second, 0, nil},
+		{"next fast", 2 * time.Minute, 0, []string{"BTCUSDT", "ETHUSDT"}},
+		{"full interval reached", 15 * time.Minute, 1, nil},
+	}
+
+	start := *clock
+	for _, step := range steps {
+		*clock = start.Add(step.offset)
+		vc.refresh(symbols, src.fetchAll, src.fetchOne)
+		full, one := src.takeCalls()
+		if full != step.wantFull || !reflect.DeepEqual(one, step.wantOne) {
+			t.Fatalf("%s: full=%d one=%v, want full=%d one=%v", step.name, full, one, step.wantFull, step.wantOne)
+		}
+	}
+
+	if volume, known := vc.get("BTCUSDT"); !known || volume != 100 {
+		t.Fatalf("BTCUSDT volume = %v (known %v), want 100 from the last full refresh", volume, known)
+	}
+	if _, known := vc.get("NOPEUSDT"); known {
+		t.Fatal("unknown symbol reported as known")
+	}
+}
+
+func TestVolumeCacheRefreshAsyncDoesNotBlock(t *testing.T) {
+	vc, clock := newTestVolumeCache("BTCUSDT")
+	src := &fakeVolumeSource{}
+	symbols := []string{"BTCUSDT", "ETHUSDT"}
+
+	<-vc.refreshAsync(symbols, src.fetchAll, src.fetchOne)
+
+	// 快速层请求阻塞时 refreshAsync 立即返回，报价仍可读取缓存，重叠的刷新被跳过
+	src.block = make(chan struct{})
+	*clock = clock.Add(time.Minute)
+	returned := make(chan (<-chan struct{}), 1)
+	go func() { returned <- vc.refreshAsync(symbols, src.fetchAll, src.fetchOne) }()
+
+	var running <-chan struct{}
+	select {
+	case running = <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("refreshAsync blocked on a slow per-symbol request")
+	}
+	if _, known := vc.get("ETHUSDT"); !known {
+		t.Fatal("cached volume unavailable during refresh")
+	}
+
+	select {
+	case <-vc.refreshAsync(symbols, src.fetchAll, src.fetchOne):
+	case <-time.After(time.Second):
+		t.Fatal("overlapping refresh was not skipped")
+	}
+
+	close(src.block)
+	<-running
+	if full, one := src.takeCalls(); full != 1 || !reflect.DeepEqual(one, []string{"BTCUSDT"}) {
+		t.Fatalf("full=%d one=%v, want one full and one BTCUSDT request", full, one)
+	}
+	if volume, _ := vc.get("BTCUSDT"); volume != 1000 {
+		t.Fatalf("BTCUSDT volume = %v after fast refresh, want 1000", volume)
+	}
+}
+
+func TestVolumeCacheRateLimitBacksOffOnlyItsEndpoint(t *testing.T) {
+	vc, clock := newTestVolumeCache("BTCUSDT", "ETHUSDT")
+	symbols := []string{"BTCUSDT", "ETHUSDT"}
+	src := &fakeVolumeSource{}
+	vc.refresh(symbols, src.fetchAll, src.fetchOne)
+	src.takeCalls()
+
+	// 快速层第一个请求返回 403（WAF 限制）：停止本轮，退避只作用于该数据源
+	forbidden := common.NewHTTPError(common.ExchangeAster, http.StatusForbidden, nil, nil)
+	oneCalls := 0
+	*clock = clock.Add(time.Minute)
+	vc.refresh(symbols, src.fetchAll, func(symbol string) (float64, error) {
+		oneCalls++
+		return 0, forbidden
+	})
+	if oneCalls != 1 {
+		t.Fatalf("per-symbol requests after 403 = %d, want 1", oneCalls)
+	}
+	if vc.tiers.FetchBackoff(common.ExchangeAster, vc.source) <= 0 {
+		t.Fatal("403 did not start a backoff for the volume endpoint")
+	}
+	if wait := vc.tiers.FetchBackoff(common.ExchangeAster, endpointSpotBookTicker); wait != 0 {
+		t.Fatalf("book ticker endpoint backing off for %v after a volume 403", wait)
+	}
+	if vc.isDropped("BTCUSDT") {
+		t.Fatal("rate-limited symbol dropped as permanently failing")
+	}
+
+	// 退避期内不再请求
+	*clock = clock.Add(time.Minute)
+	vc.refresh(symbols, src.fetchAll, src.fetchOne)
+	if full, one := src.takeCalls(); full != 0 || len(one) != 0 {
+		t.Fatalf("requests during backoff: full=%d one=%v", full, one)
+	}
+}
+
+func TestVolumeCacheReconcile(t *testing.T) {
+	vc, _ := newTestVolumeCache()
+	coverage := func() (int, int) {
+		t.Helper()
+		refresh, ok := vc.tiers.GetStats().RefreshTiers[vc.source]
+		if !ok {
+			t.Fatal("no refresh_tiers entry for the source")
+		}
+		return refresh.SymbolsMissingVolume, refresh.SymbolsMissingQuote
+	}
+
+	// 第一次全量刷新之前：所有报价都没有成交量，只计数
+	vc.reconcile([]string{"BTCUSDT", "NEWUSDT"})
+	if missingVolume, missingQuote := coverage(); missingVolume != 2 || missingQuote != 0 {
+		t.Fatalf("before the first full refresh: missing volume %d, missing quote %d", missingVolume, missingQuote)
+	}
+
+	src := &fakeVolumeSource{}
+	vc.refresh(nil, src.fetchAll, src.fetchOne)
+
+	// NEWUSDT 有报价没有成交量（按未知处理），ETHUSDT、XYZUSDT 有成交量没有报价
+	vc.reconcile([]string{"BTCUSDT", "NEWUSDT"})
+	if missingVolume, missingQuote := coverage(); missingVolume != 1 || missingQuote != 2 {
+		t.Fatalf("missing volume %d, missing quote %d; want 1 and 2", missingVolume, missingQuote)
+	}
+	if volume, known := vc.get("NEWUSDT"); known || volume != 0 {
+		t.Fatalf("NEWUSDT volume = %v, known %v; want unknown", volume, known)
+	}
+	if volume, known := vc.get("BTCUSDT"); !known || volume != 100 {
+		t.Fatalf("BTCUSDT volume = %v, known %v", volume, known)
+	}
+
+	// 两边一致后计数归零
+	vc.reconcile([]string{"BTCUSDT", "ETHUSDT", "XYZUSDT"})
+	if missingVolume, missingQuote := coverage(); missingVolume != 0 || missingQuote != 0 {
+		t.Fatalf("after the symbols match: missing volume %d, missing quote %d", missingVolume, missingQuote)
+	}
+}
+
+func TestSummarizeSymbols(t *testing.T) {
+	if got := summarizeSymbols([]string{"ETHUSDT", "BTCUSDT"}); got != "BTCUSDT,ETHUSDT" {
+		t.Fatalf("summary = %q", got)
+	}
+	symbols := make([]string, 0, 12)
+	for c := 'L'; c >= 'A'; c-- {
+		symbols = append(symbols, string(c)+"USDT")
+	}
+	want := "AUSDT,BUSDT,CUSDT,DUSDT,EUSDT,FUSDT,GUSDT,HUSDT,IUSDT,JUSDT ... (+2 more)"
+	if got := summarizeSymbols(symbols); got != want {
+		t.Fatalf("summary = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -29,6 +31,20 @@ type APIPrice struct {
 	Timestamp   time.Time `json:"timestamp"`
 	LastUpdated time.Time `json:"last_updated"`
 	Source      string    `json:"source"`
+	Depth       *APIDepth `json:"depth,omitempty"`
+}
+
+// APIDepth API 返回的盘口深度（只有维护本地订单簿的交易所有）
+type APIDepth struct {
+	Bids []APIDepthLevel `json:"bids"`
+	Asks []APIDepthLevel `json:"asks"`
+}
+
+// APIDepthLevel 单档盘口，CumNotional 为从最优价到本档的累计名义价值
+type APIDepthLevel struct {
+	Price       float64 `json:"price"`
+	Size        float64 `json:"size"`
+	CumNotional float64 `json:"cum_notional"`
 }
 
 // PriceDisplay 价格显示
@@ -43,6 +59,7 @@ type PriceDisplay struct {
 	Volume24h  float64
 	Age        time.Duration
 	Available  bool
+	Depth      *APIDepth
 }
 
 func clearScreen() {
@@ -58,32 +75,9 @@ func clearScreen() {
 	}
 }
 
-func formatPrice(num float64) string {
-	if num == 0 {
-		return "-"
-	}
-	// 价格统一显示 8 位小数，确保能看出差异
-	return fmt.Sprintf("%.8f", num)
-}
-
-func formatQty(num float64) string {
-	if num == 0 {
-		return "-"
-	}
-	if num < 0.01 {
-		return fmt.Sprintf("%.8f", num)
-	} else if num < 1 {
-		return fmt.Sprintf("%.6f", num)
-	} else if num < 100 {
-		return fmt.Sprintf("%.4f", num)
-	} else {
-		return fmt.Sprintf("%.2f", num)
-	}
-}
-
-// fetchPricesFromAPI 从 HTTP API 获取价格数据
-func fetchPricesFromAPI(symbol, apiURL string) (map[string]*APIPrice, error) {
-	url := fmt.Sprintf("%s/api/prices/%s", apiURL, symbol)
+// fetchPricesFromAPI 从 HTTP API 获取价格数据，depthLevels 为0时不请求盘口深度
+func fetchPricesFromAPI(symbol, apiURL string, depthLevels int) (map[string]*APIPrice, error) {
+	url := fmt.Sprintf("%s/api/prices/%s?depth_levels=%d", apiURL, symbol, depthLevels)
 
 	resp, err := http.Get(url)
 	if err != nil {
@@ -127,26 +121,8 @@ func fetchPricesFromAPI(symbol, apiURL string) (map[string]*APIPrice, error) {
 	return result, nil
 }
 
-func displayPrices(symbol, apiURL string) {
-	clearScreen()
-
-	fmt.Printf("\n")
-	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
-	fmt.Printf("                              实时价格监控（本地缓存） - %s\n", symbol)
-	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
-	fmt.Printf("\n")
-
-	// 从 API 获取数据
-	pricesMap, err := fetchPricesFromAPI(symbol, apiURL)
-	if err != nil {
-		fmt.Printf("  ⚠️  无法获取价格数据: %v\n", err)
-		fmt.Printf("\n")
-		fmt.Printf("  提示：请确保主监控程序正在运行并监听 %s\n", apiURL)
-		fmt.Printf("\n")
-		fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
-		return
-	}
-
+// buildDisplays 按固定的交易所/市场顺序整理API报价，缺失的场所标记为不可用
+func buildDisplays(pricesMap map[string]*APIPrice, now time.Time) []*PriceDisplay {
 	// 定义要显示的交易所和市场类型
 	displayConfigs := []struct {
 		key     string
@@ -178,7 +154,7 @@ func displayPrices(symbol, apiURL string) {
 			spread = ((price.AskPrice - price.BidPrice) / price.BidPrice) * 100
 		}
 
-		age := time.Since(price.LastUpdated)
+		age := now.Sub(price.LastUpdated)
 
 		displays = append(displays, &PriceDisplay{
 			Exchange:   cfg.name,
@@ -191,9 +167,89 @@ func displayPrices(symbol, apiURL string) {
 			Volume24h:  price.Volume24h,
 			Age:        age,
 			Available:  true,
+			Depth:      price.Depth,
 		})
 	}
 
+	return displays
+}
+
+// arbitrageCandidates 筛选参与套利计算的报价：有完整bid/ask且数据年龄不超过 maxAge
+// 返回参与计算的报价和因过期被排除的报价（maxAge <= 0 时不限制年龄）
+func arbitrageCandidates(displays []*PriceDisplay, maxAge time.Duration) ([]*PriceDisplay, []*PriceDisplay) {
+	var valid, stale []*PriceDisplay
+	for _, d := range displays {
+		if !d.Available || d.BidPrice <= 0 || d.AskPrice <= 0 {
+			continue
+		}
+		if maxAge > 0 && d.Age > maxAge {
+			stale = append(stale, d)
+			continue
+		}
+		valid = append(valid, d)
+	}
+	return valid, stale
+}
+
+// displayDepth 显示各场所的盘口阶梯（卖盘价格从高到低在上，买盘在下）
+func displayDepth(displays []*PriceDisplay) {
+	for _, d := range displays {
+		if !d.Available || d.Depth == nil {
+			continue
+		}
+
+		fmt.Printf("\n")
+		fmt.Printf("─────────────────────── %s %s 盘口 ───────────────────────\n", d.Exchange, d.MarketType)
+		fmt.Printf("%-6s %20s %13s %16s\n", "", "价格", "数量", "累计金额")
+		for i := len(d.Depth.Asks) - 1; i >= 0; i-- {
+			level := d.Depth.Asks[i]
+			fmt.Printf("%-6s %20s %13s %16.2f\n", fmt.Sprintf("卖%d", i+1), numutil.FormatPrice(level.Price), numutil.FormatQty(level.Size), level.CumNotional)
+		}
+		fmt.Printf("%-6s\n", "------")
+		for i, level := range d.Depth.Bids {
+			fmt.Printf("%-6s %20s %13s %16.2f\n", fmt.Sprintf("买%d", i+1), numutil.FormatPrice(level.Price), numutil.FormatQty(level.Size), level.CumNotional)
+		}
+	}
+}
+
+// bestArbitrage 在参与计算的报价中找出最低 ask 和最高 bid，最高 bid 高于最低 ask 时存在套利机会
+func bestArbitrage(prices []*PriceDisplay) (minAsk, maxBid *PriceDisplay, ok bool) {
+	for _, p := range prices {
+		if maxBid == nil || p.BidPrice > maxBid.BidPrice {
+			maxBid = p
+		}
+		if minAsk == nil || p.AskPrice < minAsk.AskPrice {
+			minAsk = p
+		}
+	}
+	if maxBid == nil || minAsk == nil || maxBid.BidPrice <= minAsk.AskPrice {
+		return nil, nil, false
+	}
+	return minAsk, maxBid, true
+}
+
+func displayPrices(symbol, apiURL string, maxAge time.Duration, depthLevels int, loc *time.Location) {
+	clearScreen()
+
+	fmt.Printf("\n")
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
+	fmt.Printf("                              实时价格监控（本地缓存） - %s\n", symbol)
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
+	fmt.Printf("\n")
+
+	// 从 API 获取数据
+	pricesMap, err := fetchPricesFromAPI(symbol, apiURL, depthLevels)
+	if err != nil {
+		fmt.Printf("  ⚠️  无法获取价格数据: %v\n", err)
+		fmt.Printf("\n")
+		fmt.Printf("  提示：请确保主监控程序正在运行并监听 %s\n", apiURL)
+		fmt.Printf("\n")
+		fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
+		return
+	}
+
+	displays := buildDisplays(pricesMap, time.Now())
+
 	// 检查是否有任何数据
 	hasData := false
 	for _, d := range displays {
@@ -239,46 +295,42 @@ func displayPrices(symbol, apiURL string) {
 		fmt.Printf("%-15s %-10s %20s %20s %13s %13s %9.3f%% %10s\n",
 			d.Exchange,
 			d.MarketType,
-			formatPrice(d.BidPrice),
-			formatPrice(d.AskPrice),
-			formatQty(d.BidQty),
-			formatQty(d.AskQty),
+			numutil.FormatPrice(d.BidPrice),
+			numutil.FormatPrice(d.AskPrice),
+			numutil.FormatQty(d.BidQty),
+			numutil.FormatQty(d.AskQty),
 			d.Spread,
 			ageStr,
 		)
 	}
 
+	// 盘口深度（-depth 大于0时）
+	if depthLevels > 0 {
+		displayDepth(displays)
+	}
+
 	// 计算套利机会
 	fmt.Printf("\n")
 	fmt.Printf("─────────────────────── 套利机会分析 ───────────────────────────────────\n")
 
-	var validPrices []*PriceDisplay
-	for _, d := range displays {
-		if d.Available && d.BidPrice > 0 && d.AskPrice > 0 {
-			validPrices = append(validPrices, d)
+	// 过期的报价仍然显示，但不参与计算，避免陈旧数据产生虚假机会
+	validPrices, stalePrices := arbitrageCandidates(displays, maxAge)
+	if len(stalePrices) > 0 {
+		fmt.Printf("\n")
+		for _, d := range stalePrices {
+			fmt.Printf("  ⏸  %s %s 数据已 %.0fs 未更新（超过 %v），不参与计算\n", d.Exchange, d.MarketType, d.Age.Seconds(), maxAge)
 		}
 	}
 
 	if len(validPrices) >= 2 {
-		// 找出最高 bid 和最低 ask
-		var maxBid, minAsk *PriceDisplay
-		for _, p := range validPrices {
-			if maxBid == nil || p.BidPrice > maxBid.BidPrice {
-				maxBid = p
-			}
-			if minAsk == nil || p.AskPrice < minAsk.AskPrice {
-				minAsk = p
-			}
-		}
-
-		if maxBid != nil && minAsk != nil && maxBid.BidPrice > minAsk.AskPrice {
+		if minAsk, maxBid, ok := bestArbitrage(validPrices); ok {
 			profit := ((maxBid.BidPrice - minAsk.AskPrice) / minAsk.AskPrice) * 100
 			priceDiff := maxBid.BidPrice - minAsk.AskPrice
 			fmt.Printf("\n")
 			fmt.Printf("  🔥 发现套利机会！\n")
-			fmt.Printf("     在 %s %s 买入: %s\n", minAsk.Exchange, minAsk.MarketType, formatPrice(minAsk.AskPrice))
-			fmt.Printf("     在 %s %s 卖出: %s\n", maxBid.Exchange, maxBid.MarketType, formatPrice(maxBid.BidPrice))
-			fmt.Printf("     价格差: %s (%.6f%%)\n", formatPrice(priceDiff), profit)
+			fmt.Printf("     在 %s %s 买入: %s\n", minAsk.Exchange, minAsk.MarketType, numutil.FormatPrice(minAsk.AskPrice))
+			fmt.Printf("     在 %s %s 卖出: %s\n", maxBid.Exchange, maxBid.MarketType, numutil.FormatPrice(maxBid.BidPrice))
+			fmt.Printf("     价格差: %s (%.6f%%)\n", numutil.FormatPrice(priceDiff), profit)
 			fmt.Printf("\n")
 		} else {
 			fmt.Printf("\n  暂无明显套利机会\n\n")
@@ -290,19 +342,36 @@ func displayPrices(symbol, apiURL string) {
 	// 统计信息
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
 	fmt.Printf("数据新鲜度: ● <10s  ◐ 10-30s  ○ >30s  |  刷新时间: %s\n",
-		time.Now().Format("2006-01-02 15:04:05"))
+		time.Now().In(loc).Format("2006-01-02 15:04:05 MST"))
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
 	fmt.Printf("按 Ctrl+C 退出\n")
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════════════════════════\n")
 }
 
+// envOr 读取环境变量，未设置时返回默认值
+func envOr(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 func main() {
 	// 解析命令行参数
 	symbol := flag.String("symbol", "ETHUSDT", "要查询的币种符号，如 BTCUSDT, ETHUSDT")
 	refresh := flag.Int("refresh", 500, "刷新间隔(毫秒)")
 	apiURL := flag.String("api", "http://localhost:8080", "API 服务器地址")
+	maxAge := flag.Duration("max-age", 10*time.Second, "参与套利计算的报价最大数据年龄（如 10s），0 表示不限制")
+	depth := flag.Int("depth", 0, "显示有本地订单簿的场所（Lighter）的前N档盘口（最大20），0 表示不显示")
+	tz := flag.String("tz", envOr("DISPLAY_TIMEZONE", "Local"), "显示时间的时区（如 Asia/Shanghai、UTC），Local 为本机时区")
 	flag.Parse()
 
+	loc, err := common.LoadDisplayLocation(*tz)
+	if err != nil {
+		fmt.Printf("无效的时区 %q: %v\n", *tz, err)
+		os.Exit(1)
+	}
+
 	// 标准化符号（转大写）
 	*symbol = strings.ToUpper(*symbol)
 
@@ -313,6 +382,10 @@ func main() {
 	fmt.Printf("  查询币种: %s\n", *symbol)
 	fmt.Printf("  刷新间隔: %d ms\n", *refresh)
 	fmt.Printf("  API 地址: %s\n", *apiURL)
+	fmt.Printf("  最大数据年龄: %v\n", *maxAge)
+	if *depth > 0 {
+		fmt.Printf("  盘口档数: %d\n", *depth)
+	}
 	fmt.Printf("\n")
 	fmt.Printf("  💡 提示：请确保主监控程序正在运行\n")
 	fmt.Printf("     运行: run_with_proxy.bat\n")
@@ -323,7 +396,7 @@ func main() {
 
 	// 测试 API 连接
 	testURL := fmt.Sprintf("%s/api/prices/%s", *apiURL, *symbol)
-	_, err := http.Get(testURL)
+	_, err = http.Get(testURL)
 	if err != nil {
 		fmt.Printf("\n")
 		fmt.Printf("⚠️  无法连接到 API 服务器: %v\n", err)
@@ -348,7 +421,7 @@ func main() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	// 先显示一次
-	displayPrices(*symbol, *apiURL)
+	displayPrices(*symbol, *apiURL, *maxAge, *depth, loc)
 
 	// 主循环
 	for {
@@ -357,7 +430,7 @@ func main() {
 			fmt.Printf("\n正在退出...\n")
 			return
 		case <-ticker.C:
-			displayPrices(*symbol, *apiURL)
+			displayPrices(*symbol, *apiURL, *maxAge, *depth, loc)
 		}
 	}
 }
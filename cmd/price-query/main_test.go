@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mixedAgeServer 返回不同数据年龄的预置报价：Lighter 已40秒未更新，且它的 bid 高于其他场所的 ask
+func mixedAgeServer(t *testing.T, now time.Time) *httptest.Server {
+	t.Helper()
+	quote := func(exchange, market string, bid, ask float64, age time.Duration) string {
+		return fmt.Sprintf(`{"symbol":"BTCUSDT","exchange":%q,"market_type":%q,"bid_price":%v,"ask_price":%v,"last_updated":%q}`,
+			exchange, market, bid, ask, now.Add(-age).Format(time.RFC3339Nano))
+	}
+	body := "[" +
+		quote("BINANCE", "SPOT", 100.00, 100.10, time.Second) + "," +
+		quote("BINANCE", "FUTURE", 100.05, 100.15, 2*time.Second) + "," +
+		quote("LIGHTER", "FUTURE", 105.00, 105.10, 40*time.Second) + "]"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/prices/BTCUSDT" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStaleLegsExcludedFromArbitrage(t *testing.T) {
+	now := time.Now()
+	server := mixedAgeServer(t, now)
+
+	prices, err := fetchPricesFromAPI("BTCUSDT", server.URL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	displays := buildDisplays(prices, now)
+	if len(displays) != 4 || displays[2].Available {
+		t.Fatalf("displays = %+v, want 4 rows with Aster unavailable", displays)
+	}
+
+	valid, stale := arbitrageCandidates(displays, 10*time.Second)
+	if len(valid) != 2 || len(stale) != 1 || stale[0].Exchange != "Lighter" {
+		t.Fatalf("valid %d, stale %+v; want 2 fresh Binance legs and stale Lighter", len(valid), stale)
+	}
+	// 过期的 Lighter 不参与计算，两个新鲜场所之间没有套利机会
+	if minAsk, maxBid, ok := bestArbitrage(valid); ok {
+		t.Fatalf("opportunity buy %s %s sell %s %s from fresh legs only", minAsk.Exchange, minAsk.MarketType, maxBid.Exchange, maxBid.MarketType)
+	}
+
+	// 不限制年龄时过期报价会产生（虚假的）套利机会
+	valid, stale = arbitrageCandidates(displays, 0)
+	if len(valid) != 3 || len(stale) != 0 {
+		t.Fatalf("max-age 0: valid %d stale %d, want all 3 legs", len(valid), len(stale))
+	}
+	minAsk, maxBid, ok := bestArbitrage(valid)
+	if !ok || minAsk.Exchange != "Binance" || minAsk.MarketType != "现货" || maxBid.Exchange != "Lighter" {
+		t.Fatalf("max-age 0: opportunity = %+v -> %+v (%v)", minAsk, maxBid, ok)
+	}
+}
+
+func TestArbitrageCandidatesSkipsOneSidedLegs(t *testing.T) {
+	displays := []*PriceDisplay{
+		{Exchange: "Binance", BidPrice: 100, AskPrice: 100.1, Available: true},
+		{Exchange: "Aster", BidPrice: 100, AskPrice: 0, Available: true},
+		{Exchange: "Lighter", Available: false},
+	}
+	valid, stale := arbitrageCandidates(displays, 10*time.Second)
+	if len(valid) != 1 || valid[0].Exchange != "Binance" || len(stale) != 0 {
+		t.Fatalf("valid %+v stale %+v", valid, stale)
+	}
+}
@@ -8,6 +8,11 @@ import (
 
 // Config 应用配置
 type Config struct {
+	// 交易所开关（关闭后不启动对应的 WebSocket 连接和 REST 更新任务）
+	AsterEnabled   bool
+	LighterEnabled bool
+	BinanceEnabled bool
+
 	// Aster API配置
 	AsterAPIKey        string
 	AsterSecretKey     string
@@ -16,6 +21,10 @@ type Config struct {
 	AsterWSSpotURL     string
 	AsterWSFutureURL   string
 
+	// Aster现货交易对发现
+	AsterSpotWSEnabled         bool // 通过 exchangeInfo 发现现货交易对并订阅 bookTicker
+	AsterSymbolRefreshInterval int  // 交易对刷新间隔（分钟），0表示禁用自动刷新
+
 	// Telegram配置
 	TelegramBotToken string
 	TelegramChatID   string
@@ -25,21 +34,186 @@ type Config struct {
 	UpdateInterval     int      // 更新间隔(秒)
 	MonitorSymbols     []string // 监控的交易对
 	EnableNotification bool     // 是否启用Telegram通知
+	NoBrowser          bool     // 启动时不自动打开浏览器
+	Quiet              bool     // 不向标准输出打印启动横幅和调试信息（日志文件只跳过拒绝报价的样本）
+	DisplayTimezone    string   // 终端和页面显示时间使用的时区（IANA名称），Local 为主机/浏览器时区；存储和API始终为UTC
 
 	// Lighter配置
-	LighterMarketRefreshInterval int // Lighter市场刷新间隔（分钟），0表示禁用自动刷新
+	LighterMarketRefreshInterval int    // Lighter市场刷新间隔（分钟），连接池就地追加/退订变化的市场，0表示禁用自动刷新
+	LighterPerpQuote             string // Lighter永续合约的报价货币（USDT / USDC）
+	LighterSubscribeDelayMs      int    // Lighter连接池相邻订阅消息间隔（毫秒）
+	LighterConnStaggerMs         int    // Lighter连接池相邻连接启动间隔（毫秒）
+	LighterRESTParallelRequests  int    // Lighter REST每轮同时发起的请求数（1-5），取数据最多的结果
+	LighterRESTTimeoutMs         int    // Lighter REST每轮等待结果的超时（毫秒，1000-15000）
+
+	// Binance WebSocket配置
+	BinanceLagShed        bool // 持续滞后时丢弃非 MonitorSymbols 的消息
+	BinanceExcludeInverse bool // 丢弃币本位合约（如 BTCUSD_PERP），关闭时以 BTCUSD_INVERSE 独立入库
+
+	BinanceSpotSymbolRefreshInterval int // 现货交易对列表刷新间隔（分钟），新交易对追加订阅、下线的退订，0表示禁用
+
+	// 套利阈值配置
+	ThresholdsFile string // 按symbol配置的阈值持久化文件（JSON）
+
+	// 比值策略配置
+	StrategiesFile string // 配对比值策略（A - 系数 * B）持久化文件（JSON），不存在时只注册 STG-ZRO
+
+	// Symbol黑名单配置
+	Blacklist     []string // 黑名单规则（精确 / 通配符 BTC*USDT / 正则 re:...），黑名单文件不存在时使用
+	BlacklistFile string   // 黑名单持久化文件（JSON）
+
+	// Symbol映射配置
+	SymbolMappingsFile string // symbol映射文件（JSON {"原symbol": "标准symbol"}），修改后 POST /api/normalizer/reload 生效
+
+	// WebSocket连接池配置
+	WSMaxConnections   int     // 每个连接池的最大连接数（0表示不限制）
+	WSHandshakeTimeout int     // WebSocket握手超时（秒）
+	WSReconnectRate    float64 // 每个连接池每秒最多重连次数（0表示不限速）
+	WSReconnectBurst   int     // 每个连接池可以立即重连的连接数
+	TickerLogInterval  int     // BookTicker调试日志每个symbol的最小输出间隔（秒），0表示关闭
+
+	// 交易所端点拨号配置（REST和WebSocket共用）
+	ForceIPv4          bool // 只使用IPv4地址（IPv6路由不通的机器）
+	DNSCacheTTL        int  // DNS解析结果缓存时长上限（秒），按记录TTL缓存，0表示不缓存
+	DNSStaleMax        int  // 解析失败时过期的解析结果最多继续使用多久（秒），0表示不使用
+	DialAttemptTimeout int  // 单个地址的连接超时（秒），超时后尝试下一个地址
+	DialDemoteAfter    int  // 主机连续拨号失败N次后暂时从URL轮换中降级，0表示不降级
+
+	// 日志文件配置
+	LogFile       string // 日志文件路径（追加写入），被外部轮转或删除后自动重新打开
+	LogMaxSizeMB  int    // 单个日志文件的最大大小（MB），超过后轮转，0表示不限制
+	LogMaxBackups int    // 轮转后保留的备份数（arbitrage.log.1 ...）
+
+	// 只读快照配置
+	SnapshotRefreshMs    int // /api/tickers 使用的行情快照刷新间隔（毫秒）
+	WebSnapshotRefreshMs int // /api/spreads、/api/stats 使用的存储快照刷新间隔（毫秒），0表示每个请求直接读取存储
+
+	// Web API 响应配置
+	MaxOpportunitiesReturned int // /api/arbitrage-opportunities 最多返回的套利机会数（按价差排名，请求的 limit 不能超过该值），0表示不限制
+
+	// Web API 认证配置
+	APIToken string // 非空时 /api/* 请求需要 Authorization: Bearer <token>（/api/health 和静态页面除外）
 
 	// 代理配置
 	HTTPProxy  string // HTTP 代理地址，例如: http://127.0.0.1:7890
 	HTTPSProxy string // HTTPS 代理地址，例如: http://127.0.0.1:7890
 
 	// 性能配置
-	MaxGoroutines int // 最大并发数
+	MaxGoroutines      int // 最大并发数
+	RESTMaxConcurrency int // 每个交易所REST同时进行中的最大请求数
+
+	// 价格校验配置
+	PriceMinAskBidRatio   float64               // ask < bid*该值 时拒绝
+	PriceMaxAskBidRatio   float64               // ask > bid*该值 时拒绝
+	PriceMaxMarkDeviation float64               // 合约中间价偏离标记/指数价格超过该百分比时拒绝，0表示不校验
+	PriceBounds           map[string]PriceBound // 按symbol配置的价格上下限
+
+	// 成交模拟配置
+	TakerFees map[string]float64 // 各交易所taker手续费率（百分比）
+
+	// 交易所执行能力配置
+	VenueCapabilitiesFile string // 交易所充提币/永续能力配置文件（JSON），修改后自动重新加载
+
+	// 价差计算配置
+	MinExchangeCount        int      // 只计算至少在N个场所（交易所+市场类型）有活跃报价的symbol
+	MinOpportunityVolume    float64  // 套利机会两腿24小时成交量（计价货币）的最小值，0表示不过滤
+	OpportunityPairings     []string // 允许生成套利机会的市场类型组合（买入腿-卖出腿，如 spot-spot,future-future），为空时全部允许
+	MaxTrackedOpportunities int      // 同时跟踪的套利机会上限，超过时价差最小的未确认机会先被丢弃，0表示不限制
+	SpreadGraceMs           int      // /api/spreads 中短暂缺腿的价差继续返回上次值的时长（毫秒），0表示不保留
+	PriceMode               string   // 价差计算使用的买卖价格：top_of_book（买一/卖一，默认）或 depth_weighted（有本地订单簿的场所按 DepthVWAPNotional 深度加权）
+	DepthVWAPNotional       float64  // depth_weighted 模式下深度加权价格的名义金额（USDT）
+	CoverageGapMinVolume    float64  // 统计日志中报告覆盖缺口（部分交易所缺失的symbol）的24小时成交量下限
+
+	// 延迟补偿的价差投影配置
+	LatencyProjection                 bool    // 两腿交易所时间相差较大时按较旧一腿的中间价漂移投影，价差额外返回投影后的值
+	LatencyProjectionMinGapMs         int     // 两腿交易所时间差超过该值（毫秒）才投影
+	LatencyProjectionMaxBps           float64 // 单次投影的最大幅度（基点）
+	LatencyProjectionDriftSpacingMs   int     // 计算漂移的两次报价最小间隔（毫秒），间隔更短的报价沿用上次的漂移
+	LatencyProjectionDriftWindowMs    int     // 计算漂移的两次报价最大间隔（毫秒）
+	LatencyProjectionForOpportunities bool    // 套利机会按投影后的价差判断阈值
+
+	// 价格更新调试配置
+	UpdateDebugSymbols []string // 启动时开启更新调试的symbol（记录每次更新被哪条新鲜度规则接受/拒绝，见 /api/debug/updates/{symbol}）
+	UpdateDebugSize    int      // 每个调试symbol保留的最近更新记录数
+
+	// 数量级倍数检测配置（1000PEPEUSDT 与 PEPEUSDT 等）
+	MultiplierAutoApply     bool // 嫌疑稳定后自动应用倍数修正（默认只记录日志和 /api/suspects）
+	MultiplierStableMinutes int  // 自动应用前需要持续检测到的时长（分钟）
+
+	// 价格历史配置（/api/prices/{symbol}?at=... 和 /api/spreads?at=... 按时间点查询）
+	PriceHistoryRetentionMinutes int // 历史保留时长，同时是最大回溯范围（分钟），0表示不记录
+	PriceHistorySampleMs         int // 每个场所两次采样的最小间隔（毫秒）
+
+	// 模拟交易（paper trading）配置
+	PaperTradingEnabled   bool    // 确认的套利机会开模拟仓位，结果见 /api/paper/positions 和 /api/paper/summary
+	PaperNotional         float64 // 每笔开仓金额（USDT）
+	PaperExitSpread       float64 // 价差收敛到该值（百分比）以下时平仓
+	PaperMaxHoldMinutes   int     // 最长持仓时间（分钟）
+	PaperMaxOpenPositions int     // 最多同时持有的仓位数
+
+	// 成交量分层刷新配置（快速层 = MONITOR_SYMBOLS + 出现套利机会的symbol）
+	VolumeFastRefreshSeconds int // 快速层逐个symbol刷新间隔（秒）
+	VolumeFullRefreshMinutes int // 全量24hr刷新间隔（分钟）
+	VolumePromoteMinutes     int // 出现机会的symbol提升到快速层的时长（分钟）
+	VolumeMaxPromoted        int // 同时提升到快速层的symbol上限（不含 MONITOR_SYMBOLS），0表示不限制
+
+	// 套利机会输出配置（除 Web API 外的输出目标）
+	OpportunitySinks           []string // 启用的输出目标：stdout（定期表格）、file（NDJSON）、webhook，为空表示不启用
+	OpportunitySinkIntervalSec int      // 评估已确认机会并分发的间隔（秒）
+	OpportunityFile            string   // file 输出的 NDJSON 文件路径（只写入新确认的机会）
+	OpportunityWebhookURL      string   // webhook 输出的地址（POST 新确认的机会）
+
+	// 主备模式配置（两个实例都采集数据，只有 leader 发送套利机会输出/webhook）
+	FailoverMode         string // off（默认，不启用）、http（轮询对端 Web 服务的心跳）、file（共享磁盘目录中的心跳文件）
+	FailoverInstanceID   string // 本实例ID，为空时使用主机名
+	FailoverPriority     int    // 优先级，两个实例都在线时高者为 leader（相同时ID较小者）
+	FailoverPeerURL      string // http 模式下对端 Web 服务地址，例如 http://10.0.0.2:8080
+	FailoverDir          string // file 模式下共享的心跳目录
+	FailoverHeartbeatSec int    // 心跳间隔（秒）
+	FailoverTimeoutSec   int    // 对端心跳超过该时长未更新视为失联，standby 提升为 leader（秒）
+
+	// 价差异常标注配置（最优价差明显高于滚动窗口常态时记录日志和 /api/anomalies）
+	AnomalyZThreshold      float64 // 价差相对滚动中位数的稳健z分数（(价差-中位数)/(1.4826*MAD)）达到该值时标注，0表示不启用
+	AnomalyWindowMinutes   int     // 滚动统计窗口（分钟）
+	AnomalyCooldownMinutes int     // 同一交易对两次标注的最小间隔（分钟）
+	AnomalySampleSeconds   int     // 采样间隔（秒）
+
+	// 稳定币三角一致性检查（同一场所 XUSDT / XUSDC 推出的隐含汇率与跨场所共识比较，/api/stable-basis）
+	StableBasisIntervalSec  int      // 检查间隔（秒），0表示不启用
+	StableBasisQuotes       []string // 检查的稳定币（相对USDT）
+	StableBasisVenues       []string // 只检查这些场所（EXCHANGE 或 EXCHANGE_MARKETTYPE），为空表示全部
+	StableBasisThresholdBps float64  // 隐含汇率偏离共识超过该值（基点）时告警
+
+	// 置信度评分配置
+	ConfidenceAgeHalfLifeMs    int     // 超过1秒后数据年龄每增加该值得分减半（毫秒）
+	ConfidenceRESTPenalty      float64 // REST数据源扣分比例（0-1）
+	ConfidenceThinBookUSDT     float64 // 盘口金额低于该值视为薄盘口
+	ConfidenceThinBookPenalty  float64 // 薄盘口扣分比例（0-1）
+	ConfidenceAgeGapHalfLifeMs int     // 两腿年龄差每增加该值得分减半（毫秒）
+
+	// 副存储（命名空间）配置，用于对比实验数据源，不影响默认存储
+	SecondaryNamespace string   // 副存储名称，API挂载在 /api/{namespace}/...，为空时不启用
+	SecondarySources   []string // 同时写入副存储的数据源（aster_ws, aster_spot_ws, aster_rest, lighter_ws, lighter_rest, binance_spot_ws, binance_futures_ws, binance_rest），为空表示全部
 }
 
+// PriceBound 单个symbol的价格上下限（0表示不限制）
+type PriceBound struct {
+	Min float64
+	Max float64
+}
+
+// DefaultLeveragedTokenBlacklist 默认过滤的币安杠杆代币（BTCUPUSDT、ETHDOWNUSDT 等）
+// 按币种精确列出，不使用 *UPUSDT 这样的通配符，避免误伤 JUPUSDT 等正常币种
+const DefaultLeveragedTokenBlacklist = "re:^(BTC|ETH|BNB|ADA|DOT|XRP|LINK|TRX|XTZ|EOS|LTC|SXP|FIL|YFI|BCH|AAVE|SUSHI|UNI|XLM|1INCH)(UP|DOWN)USDT$"
+
 // LoadConfig 加载配置
 func LoadConfig() *Config {
 	cfg := &Config{
+		// 交易所开关
+		AsterEnabled:   getEnvBool("ASTER_ENABLED", true),
+		LighterEnabled: getEnvBool("LIGHTER_ENABLED", true),
+		BinanceEnabled: getEnvBool("BINANCE_ENABLED", true),
+
 		// Aster 默认配置
 		AsterSpotBaseURL:   getEnv("ASTER_SPOT_BASE_URL", "https://sapi.asterdex.com"),
 		AsterFutureBaseURL: getEnv("ASTER_FUTURE_BASE_URL", "https://fapi.asterdex.com"),
@@ -48,6 +222,10 @@ func LoadConfig() *Config {
 		AsterAPIKey:        getEnv("ASTER_API_KEY", ""),
 		AsterSecretKey:     getEnv("ASTER_SECRET_KEY", ""),
 
+		// Aster现货交易对发现
+		AsterSpotWSEnabled:         getEnvBool("ASTER_SPOT_WS_ENABLED", true),
+		AsterSymbolRefreshInterval: getEnvInt("ASTER_SYMBOL_REFRESH_INTERVAL", 10), // 默认10分钟刷新一次
+
 		// Telegram 配置
 		TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
 		TelegramChatID:   getEnv("TELEGRAM_CHAT_ID", ""),
@@ -57,16 +235,170 @@ func LoadConfig() *Config {
 		UpdateInterval:     getEnvInt("UPDATE_INTERVAL", 1),
 		MonitorSymbols:     getEnvArray("MONITOR_SYMBOLS", []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}),
 		EnableNotification: getEnvBool("ENABLE_NOTIFICATION", false), // 默认关闭通知避免误发
+		NoBrowser:          getEnvBool("NO_BROWSER", false),          // 无图形界面或SSH会话时会自动跳过
+		Quiet:              getEnvBool("QUIET", false),               // 在 systemd 等环境下运行时可开启
+		DisplayTimezone:    getEnv("DISPLAY_TIMEZONE", "Local"),
 
 		// Lighter配置
 		LighterMarketRefreshInterval: getEnvInt("LIGHTER_MARKET_REFRESH_INTERVAL", 10), // 默认10分钟刷新一次
+		LighterPerpQuote:             getEnv("LIGHTER_PERP_QUOTE", "USDT"),
+		LighterSubscribeDelayMs:      getEnvInt("LIGHTER_SUBSCRIBE_DELAY_MS", 50),
+		LighterConnStaggerMs:         getEnvInt("LIGHTER_CONN_STAGGER_MS", 500),
+		LighterRESTParallelRequests:  getEnvInt("LIGHTER_REST_PARALLEL_REQUESTS", 3),
+		LighterRESTTimeoutMs:         getEnvInt("LIGHTER_REST_TIMEOUT_MS", 5000),
+
+		// Binance WebSocket配置
+		BinanceLagShed:        getEnvBool("BINANCE_LAG_SHED", false),
+		BinanceExcludeInverse: getEnvBool("BINANCE_EXCLUDE_INVERSE", true),
+
+		BinanceSpotSymbolRefreshInterval: getEnvInt("BINANCE_SPOT_SYMBOL_REFRESH_INTERVAL", 0),
+
+		// 套利阈值配置
+		ThresholdsFile: getEnv("THRESHOLDS_FILE", "thresholds.json"),
+
+		// 比值策略配置
+		StrategiesFile: getEnv("STRATEGIES_FILE", "strategies.json"),
+
+		// Symbol黑名单配置（默认过滤杠杆代币和稳定币对）
+		Blacklist:     getEnvArray("BLACKLIST", []string{DefaultLeveragedTokenBlacklist, "USDCUSDT", "FDUSDUSDT"}),
+		BlacklistFile: getEnv("BLACKLIST_FILE", "blacklist.json"),
+
+		// Symbol映射配置
+		SymbolMappingsFile: getEnv("SYMBOL_MAPPINGS_FILE", "symbol_mappings.json"),
+
+		// WebSocket连接池配置
+		WSMaxConnections:   getEnvInt("WS_MAX_CONNECTIONS", 10),
+		WSHandshakeTimeout: getEnvInt("WS_HANDSHAKE_TIMEOUT", 10),
+		WSReconnectRate:    getEnvFloat("WS_RECONNECT_RATE", 1),
+		WSReconnectBurst:   getEnvInt("WS_RECONNECT_BURST", 2),
+		TickerLogInterval:  getEnvInt("TICKER_LOG_INTERVAL", 5),
+
+		// 交易所端点拨号配置
+		ForceIPv4:          getEnvBool("FORCE_IPV4", false),
+		DNSCacheTTL:        getEnvInt("DNS_CACHE_TTL", 60),
+		DNSStaleMax:        getEnvInt("DNS_STALE_MAX", 300),
+		DialAttemptTimeout: getEnvInt("DIAL_ATTEMPT_TIMEOUT", 5),
+		DialDemoteAfter:    getEnvInt("DIAL_DEMOTE_AFTER", 3),
+
+		// 日志文件配置
+		LogFile:       getEnv("LOG_FILE", "arbitrage.log"),
+		LogMaxSizeMB:  getEnvInt("LOG_MAX_SIZE_MB", 100),
+		LogMaxBackups: getEnvInt("LOG_MAX_BACKUPS", 3),
+
+		// 只读快照配置
+		SnapshotRefreshMs:    getEnvInt("SNAPSHOT_REFRESH_MS", 100),
+		WebSnapshotRefreshMs: getEnvInt("WEB_SNAPSHOT_REFRESH_MS", 1000),
+
+		// Web API 响应配置
+		MaxOpportunitiesReturned: getEnvInt("MAX_OPPORTUNITIES_RETURNED", 200),
+
+		// Web API 认证配置
+		APIToken: getEnv("API_TOKEN", ""),
 
 		// 代理配置（默认为空，不使用代理）
 		HTTPProxy:  getEnv("HTTP_PROXY", ""),
 		HTTPSProxy: getEnv("HTTPS_PROXY", ""),
 
 		// 性能配置
-		MaxGoroutines: getEnvInt("MAX_GOROUTINES", 100),
+		MaxGoroutines:      getEnvInt("MAX_GOROUTINES", 100),
+		RESTMaxConcurrency: getEnvInt("REST_MAX_CONCURRENCY", 2),
+
+		// 价格校验配置
+		PriceMinAskBidRatio:   getEnvFloat("PRICE_MIN_ASK_BID_RATIO", 0.5),
+		PriceMaxAskBidRatio:   getEnvFloat("PRICE_MAX_ASK_BID_RATIO", 2.0),
+		PriceMaxMarkDeviation: getEnvFloat("PRICE_MAX_MARK_DEVIATION", 5),
+		PriceBounds:           getEnvPriceBounds("PRICE_BOUNDS"),
+
+		// 成交模拟配置（默认taker费率，百分比）
+		TakerFees: getEnvFloatMap("TAKER_FEES", map[string]float64{
+			"BINANCE": 0.1,
+			"ASTER":   0.05,
+			"LIGHTER": 0,
+		}),
+
+		// 交易所执行能力配置
+		VenueCapabilitiesFile: getEnv("VENUE_CAPABILITIES_FILE", "venues.json"),
+
+		// 价差计算配置
+		MinExchangeCount:        getEnvInt("MIN_EXCHANGE_COUNT", 2),
+		MinOpportunityVolume:    getEnvFloat("MIN_OPPORTUNITY_VOLUME", 0),
+		OpportunityPairings:     getEnvArray("OPPORTUNITY_PAIRINGS", []string{}),
+		MaxTrackedOpportunities: getEnvInt("MAX_TRACKED_OPPORTUNITIES", 500),
+		SpreadGraceMs:           getEnvInt("SPREAD_GRACE_MS", 0),
+		PriceMode:               getEnv("PRICE_MODE", "top_of_book"),
+		DepthVWAPNotional:       getEnvFloat("DEPTH_VWAP_NOTIONAL", 1000),
+		CoverageGapMinVolume:    getEnvFloat("COVERAGE_GAP_MIN_VOLUME", 1000000),
+
+		// 延迟补偿的价差投影配置
+		LatencyProjection:                 getEnvBool("LATENCY_PROJECTION", false),
+		LatencyProjectionMinGapMs:         getEnvInt("LATENCY_PROJECTION_MIN_GAP_MS", 150),
+		LatencyProjectionMaxBps:           getEnvFloat("LATENCY_PROJECTION_MAX_BPS", 20),
+		LatencyProjectionDriftSpacingMs:   getEnvInt("LATENCY_PROJECTION_DRIFT_SPACING_MS", 250),
+		LatencyProjectionDriftWindowMs:    getEnvInt("LATENCY_PROJECTION_DRIFT_WINDOW_MS", 2000),
+		LatencyProjectionForOpportunities: getEnvBool("LATENCY_PROJECTION_FOR_OPPORTUNITIES", false),
+
+		// 价格更新调试配置（默认关闭）
+		UpdateDebugSymbols: getEnvArray("UPDATE_DEBUG_SYMBOLS", []string{}),
+		UpdateDebugSize:    getEnvInt("UPDATE_DEBUG_SIZE", 100),
+
+		// 数量级倍数检测配置
+		MultiplierAutoApply:     getEnvBool("MULTIPLIER_AUTO_APPLY", false),
+		MultiplierStableMinutes: getEnvInt("MULTIPLIER_STABLE_MINUTES", 10),
+
+		// 价格历史配置
+		PriceHistoryRetentionMinutes: getEnvInt("PRICE_HISTORY_RETENTION_MINUTES", 5),
+		PriceHistorySampleMs:         getEnvInt("PRICE_HISTORY_SAMPLE_MS", 1000),
+
+		// 模拟交易配置
+		PaperTradingEnabled:   getEnvBool("PAPER_TRADING_ENABLED", true),
+		PaperNotional:         getEnvFloat("PAPER_NOTIONAL", 1000),
+		PaperExitSpread:       getEnvFloat("PAPER_EXIT_SPREAD", 0.05),
+		PaperMaxHoldMinutes:   getEnvInt("PAPER_MAX_HOLD_MINUTES", 30),
+		PaperMaxOpenPositions: getEnvInt("PAPER_MAX_OPEN_POSITIONS", 20),
+
+		// 成交量分层刷新配置
+		VolumeFastRefreshSeconds: getEnvInt("VOLUME_FAST_REFRESH_SECONDS", 60),
+		VolumeFullRefreshMinutes: getEnvInt("VOLUME_FULL_REFRESH_MINUTES", 15),
+		VolumePromoteMinutes:     getEnvInt("VOLUME_PROMOTE_MINUTES", 10),
+		VolumeMaxPromoted:        getEnvInt("VOLUME_MAX_PROMOTED", 20),
+
+		// 套利机会输出配置
+		OpportunitySinks:           getEnvArray("OPPORTUNITY_SINKS", []string{}),
+		OpportunitySinkIntervalSec: getEnvInt("OPPORTUNITY_SINK_INTERVAL_SECONDS", 10),
+		OpportunityFile:            getEnv("OPPORTUNITY_FILE", "opportunities.ndjson"),
+		OpportunityWebhookURL:      getEnv("OPPORTUNITY_WEBHOOK_URL", ""),
+
+		// 主备模式配置
+		FailoverMode:         getEnv("FAILOVER_MODE", "off"),
+		FailoverInstanceID:   getEnv("FAILOVER_INSTANCE_ID", ""),
+		FailoverPriority:     getEnvInt("FAILOVER_PRIORITY", 0),
+		FailoverPeerURL:      getEnv("FAILOVER_PEER_URL", ""),
+		FailoverDir:          getEnv("FAILOVER_DIR", ""),
+		FailoverHeartbeatSec: getEnvInt("FAILOVER_HEARTBEAT_SECONDS", 2),
+		FailoverTimeoutSec:   getEnvInt("FAILOVER_TIMEOUT_SECONDS", 10),
+
+		// 价差异常标注配置（默认关闭）
+		AnomalyZThreshold:      getEnvFloat("ANOMALY_Z_THRESHOLD", 0),
+		AnomalyWindowMinutes:   getEnvInt("ANOMALY_WINDOW_MINUTES", 60),
+		AnomalyCooldownMinutes: getEnvInt("ANOMALY_COOLDOWN_MINUTES", 10),
+		AnomalySampleSeconds:   getEnvInt("ANOMALY_SAMPLE_SECONDS", 10),
+
+		// 稳定币三角一致性检查
+		StableBasisIntervalSec:  getEnvInt("STABLE_BASIS_INTERVAL_SECONDS", 30),
+		StableBasisQuotes:       getEnvArray("STABLE_BASIS_QUOTES", []string{"USDC", "FDUSD"}),
+		StableBasisVenues:       getEnvArray("STABLE_BASIS_VENUES", []string{}),
+		StableBasisThresholdBps: getEnvFloat("STABLE_BASIS_THRESHOLD_BPS", 20),
+
+		// 置信度评分配置
+		ConfidenceAgeHalfLifeMs:    getEnvInt("CONFIDENCE_AGE_HALF_LIFE_MS", 5000),
+		ConfidenceRESTPenalty:      getEnvFloat("CONFIDENCE_REST_PENALTY", 0.3),
+		ConfidenceThinBookUSDT:     getEnvFloat("CONFIDENCE_THIN_BOOK_USDT", 1000),
+		ConfidenceThinBookPenalty:  getEnvFloat("CONFIDENCE_THIN_BOOK_PENALTY", 0.2),
+		ConfidenceAgeGapHalfLifeMs: getEnvInt("CONFIDENCE_AGE_GAP_HALF_LIFE_MS", 5000),
+
+		// 副存储（命名空间）配置
+		SecondaryNamespace: getEnv("SECONDARY_NAMESPACE", ""),
+		SecondarySources:   getEnvArray("SECONDARY_SOURCES", []string{}),
 	}
 
 	return cfg
@@ -112,3 +444,50 @@ func getEnvArray(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getEnvFloatMap 解析 KEY:VALUE 形式的浮点数配置
+// 格式: BINANCE:0.1,ASTER:0.05
+func getEnvFloatMap(key string, defaultValue map[string]float64) map[string]float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]float64)
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 2 {
+			continue
+		}
+		f, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+		result[strings.ToUpper(parts[0])] = f
+	}
+	return result
+}
+
+// getEnvPriceBounds 解析价格上下限配置
+// 格式: SYMBOL:MIN:MAX,SYMBOL:MIN:MAX（例如 BTCUSDT:1000:1000000）
+func getEnvPriceBounds(key string) map[string]PriceBound {
+	bounds := make(map[string]PriceBound)
+	value := os.Getenv(key)
+	if value == "" {
+		return bounds
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 3 {
+			continue
+		}
+		minVal, err1 := strconv.ParseFloat(parts[1], 64)
+		maxVal, err2 := strconv.ParseFloat(parts[2], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		bounds[strings.ToUpper(parts[0])] = PriceBound{Min: minVal, Max: maxVal}
+	}
+	return bounds
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redactedValue 替换敏感配置值的占位符（未配置的字段保持为空，便于看出是否设置）
+const redactedValue = "******"
+
+// secretFieldMarkers 字段名包含这些片段时整个值视为敏感（API密钥、机器人token、webhook地址等）
+// 按字段名匹配而不是逐个列出字段，新增的 XxxAPIKey / XxxToken 等字段不会因为遗漏而泄露
+var secretFieldMarkers = []string{"Key", "Secret", "Token", "Password", "ChatID", "Webhook"}
+
+// urlFieldMarkers 字段名包含这些片段时按URL处理：保留地址，隐藏其中的用户名密码和查询参数
+var urlFieldMarkers = []string{"URL", "Proxy"}
+
+// Redacted 返回脱敏后的有效配置（key 为字段名），用于 /api/version 等诊断输出
+// 敏感字段替换为占位符，URL/代理地址去掉其中的凭证和查询参数，其余字段原样返回
+func (c *Config) Redacted() map[string]interface{} {
+	value := reflect.ValueOf(c).Elem()
+	typ := value.Type()
+
+	result := make(map[string]interface{}, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldValue := value.Field(i)
+
+		switch {
+		case containsAny(field.Name, secretFieldMarkers):
+			if fieldValue.IsZero() {
+				result[field.Name] = fieldValue.Interface()
+			} else {
+				result[field.Name] = redactedValue
+			}
+		case containsAny(field.Name, urlFieldMarkers) && fieldValue.Kind() == reflect.String:
+			result[field.Name] = redactURL(fieldValue.String())
+		default:
+			result[field.Name] = fieldValue.Interface()
+		}
+	}
+	return result
+}
+
+// Values 返回未脱敏的有效配置（key 为字段名），包含API密钥等敏感值，只用于带管理令牌的配置导出
+func (c *Config) Values() map[string]interface{} {
+	value := reflect.ValueOf(c).Elem()
+	typ := value.Type()
+
+	result := make(map[string]interface{}, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		if field := typ.Field(i); field.IsExported() {
+			result[field.Name] = value.Field(i).Interface()
+		}
+	}
+	return result
+}
+
+// redactURL 去掉URL中的用户名密码和查询参数，无法解析时整个替换
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return redactedValue
+	}
+	hasUser := u.User != nil
+	u.User = nil
+	if u.RawQuery != "" {
+		u.RawQuery = redactedValue
+	}
+	u.Fragment = ""
+
+	// url.User 会转义占位符，凭证部分直接拼接
+	result := u.String()
+	if hasUser {
+		result = strings.Replace(result, "//", "//"+redactedValue+"@", 1)
+	}
+	return result
+}
+
+// containsAny 判断字段名是否包含任一片段
+func containsAny(name string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// filledConfig 所有字符串字段都设置了值：URL/代理字段为带凭证和查询参数的地址，其余为 v-字段名
+func filledConfig() *Config {
+	cfg := &Config{MinSpreadPercent: 0.3, BinanceEnabled: true}
+	value := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.String {
+			continue
+		}
+		if containsAny(field.Name, urlFieldMarkers) && !containsAny(field.Name, secretFieldMarkers) {
+			value.Field(i).SetString("https://user:pass@" + strings.ToLower(field.Name) + ".example/path?key=abc#frag")
+		} else {
+			value.Field(i).SetString("v-" + field.Name)
+		}
+	}
+	return cfg
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := filledConfig()
+	redacted := cfg.Redacted()
+	values := cfg.Values()
+	if len(redacted) != len(values) {
+		t.Fatalf("redacted config has %d fields, want %d", len(redacted), len(values))
+	}
+
+	secrets := 0
+	for name, original := range values {
+		got := redacted[name]
+		switch {
+		case containsAny(name, secretFieldMarkers):
+			secrets++
+			if got != redactedValue {
+				t.Errorf("%s = %v, want masked", name, got)
+			}
+		case containsAny(name, urlFieldMarkers) && reflect.TypeOf(original).Kind() == reflect.String:
+			want := "https://" + redactedValue + "@" + strings.ToLower(name) + ".example/path?" + redactedValue
+			if got != want {
+				t.Errorf("%s = %v, want %s", name, got, want)
+			}
+		default:
+			if !reflect.DeepEqual(got, original) {
+				t.Errorf("%s = %v, want unchanged %v", name, got, original)
+			}
+		}
+	}
+	for _, name := range []string{"AsterAPIKey", "AsterSecretKey", "TelegramBotToken", "TelegramChatID", "APIToken", "OpportunityWebhookURL"} {
+		if redacted[name] != redactedValue {
+			t.Errorf("%s = %v, want masked", name, redacted[name])
+		}
+	}
+	if secrets < 6 {
+		t.Fatalf("only %d secret fields found", secrets)
+	}
+
+	// 序列化后的输出中不出现任何敏感值或URL凭证
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"v-AsterAPIKey", "v-AsterSecretKey", "v-TelegramBotToken", "v-APIToken", "pass", "key=abc"} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("redacted output contains %q", leaked)
+		}
+	}
+}
+
+func TestRedactedKeepsEmptySecrets(t *testing.T) {
+	redacted := (&Config{HTTPProxy: "http://proxy.local:3128"}).Redacted()
+	if redacted["AsterAPIKey"] != "" || redacted["APIToken"] != "" {
+		t.Fatalf("unset secrets = %q / %q, want empty", redacted["AsterAPIKey"], redacted["APIToken"])
+	}
+	if redacted["HTTPProxy"] != "http://proxy.local:3128" {
+		t.Fatalf("proxy without credentials = %v, want unchanged", redacted["HTTPProxy"])
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct{ raw, want string }{
+		{"", ""},
+		{"wss://fstream.asterdex.com/ws", "wss://fstream.asterdex.com/ws"},
+		{"http://user:pw@10.0.0.2:8080", "http://******@10.0.0.2:8080"},
+		{"not a url", redactedValue},
+	}
+	for _, tt := range tests {
+		if got := redactURL(tt.raw); got != tt.want {
+			t.Errorf("redactURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
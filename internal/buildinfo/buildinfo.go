@@ -0,0 +1,82 @@
+// Package buildinfo 构建版本信息和进程启动时间（/api/version 和 --version）
+//
+// 版本号、提交和构建时间通过 -ldflags 注入，例如:
+//
+//	go build -ldflags "-X crypto-arbitrage-monitor/internal/buildinfo.Version=v1.2.0 \
+//	  -X crypto-arbitrage-monitor/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X crypto-arbitrage-monitor/internal/buildinfo.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/monitor
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// 通过 -ldflags -X 注入，未注入时为开发构建的默认值
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = "unknown"
+)
+
+// startTime 进程启动时间（包初始化时记录）
+var startTime = time.Now().UTC()
+
+// Info 构建和运行信息
+type Info struct {
+	Version       string    `json:"version"`
+	Commit        string    `json:"commit"`
+	BuildDate     string    `json:"build_date"`
+	GoVersion     string    `json:"go_version"`
+	StartTime     time.Time `json:"start_time"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+}
+
+// Get 获取构建信息，未注入 Commit 时使用 go build 记录的 VCS 修订号（没有时为 unknown）
+func Get() Info {
+	return Info{
+		Version:       Version,
+		Commit:        commit(),
+		BuildDate:     BuildDate,
+		GoVersion:     runtime.Version(),
+		StartTime:     startTime,
+		UptimeSeconds: time.Since(startTime).Seconds(),
+	}
+}
+
+// String 单行版本信息（--version 输出）
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, i.Commit, i.BuildDate, i.GoVersion)
+}
+
+// commit 注入的提交，其次是 VCS 修订号（工作区有未提交修改时加 -dirty）
+func commit() string {
+	if Commit != "" {
+		return Commit
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "unknown"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified {
+		revision += "-dirty"
+	}
+	return revision
+}
@@ -0,0 +1,167 @@
+package aster
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// symbolStatusTrading exchangeInfo 中可交易的状态
+const symbolStatusTrading = "TRADING"
+
+// SymbolDiscovery 基于 exchangeInfo 的交易对发现
+// 启动时获取全部可交易的交易对，之后定期刷新，发现新上线的交易对时回调
+type SymbolDiscovery struct {
+	name     string                   // 日志标识，例如 "Spot"
+	fetch    func() ([]string, error) // 获取当前可交易的交易对
+	interval time.Duration            // 刷新间隔，0表示只在启动时获取一次
+
+	mu           sync.RWMutex
+	symbols      map[string]bool
+	onNewSymbols func([]string)
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSpotSymbolDiscovery 创建现货交易对发现（使用 /api/v1/exchangeInfo）
+func NewSpotSymbolDiscovery(client *SpotClient, interval time.Duration) *SymbolDiscovery {
+	return newSymbolDiscovery("Spot", interval, func() ([]string, error) {
+		info, err := client.GetExchangeInfo()
+		if err != nil {
+			return nil, err
+		}
+		symbols := make([]string, 0, len(info.Symbols))
+		for _, s := range info.Symbols {
+			if s.Status == symbolStatusTrading {
+				symbols = append(symbols, s.Symbol)
+			}
+		}
+		return symbols, nil
+	})
+}
+
+// newSymbolDiscovery 创建交易对发现
+func newSymbolDiscovery(name string, interval time.Duration, fetch func() ([]string, error)) *SymbolDiscovery {
+	return &SymbolDiscovery{
+		name:     name,
+		fetch:    fetch,
+		interval: interval,
+		symbols:  make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetNewSymbolsHandler 设置新交易对回调（首次获取的交易对也会通过该回调返回）
+func (d *SymbolDiscovery) SetNewSymbolsHandler(handler func([]string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onNewSymbols = handler
+}
+
+// Start 获取初始交易对列表，并在 interval > 0 时启动定期刷新
+func (d *SymbolDiscovery) Start() error {
+	if _, err := d.updateSymbols(); err != nil {
+		return fmt.Errorf("failed to fetch initial %s symbols: %w", d.name, err)
+	}
+
+	if d.interval > 0 {
+		log.Printf("[Aster %s] Symbol auto-refresh enabled (interval: %v)", d.name, d.interval)
+		go d.refreshSymbols()
+	}
+	return nil
+}
+
+// Symbols 获取当前已知的全部交易对（按字母排序）
+func (d *SymbolDiscovery) Symbols() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	symbols := make([]string, 0, len(d.symbols))
+	for symbol := range d.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Has 判断交易对是否在当前已知的列表中
+func (d *SymbolDiscovery) Has(symbol string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.symbols[symbol]
+}
+
+// Refresh 立即刷新一次交易对列表（不等待定期刷新），返回新增的交易对（同样会触发新交易对回调）
+func (d *SymbolDiscovery) Refresh() ([]string, error) {
+	return d.updateSymbols()
+}
+
+// Close 停止定期刷新
+func (d *SymbolDiscovery) Close() {
+	d.closeOnce.Do(func() {
+		close(d.done)
+	})
+}
+
+// refreshSymbols 定期刷新交易对列表
+func (d *SymbolDiscovery) refreshSymbols() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			if _, err := d.updateSymbols(); err != nil {
+				log.Printf("[Aster %s] Failed to refresh symbols: %v", d.name, err)
+			}
+		}
+	}
+}
+
+// updateSymbols 获取交易对列表，返回新增的交易对并回调
+// 下架的交易对只从列表中移除，已有订阅保持不变（没有数据推送，由过期清理处理）
+func (d *SymbolDiscovery) updateSymbols() ([]string, error) {
+	current, err := d.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	initial := len(d.symbols) == 0
+	seen := make(map[string]bool, len(current))
+	newlyAdded := make([]string, 0)
+	for _, symbol := range current {
+		seen[symbol] = true
+		if !d.symbols[symbol] {
+			d.symbols[symbol] = true
+			newlyAdded = append(newlyAdded, symbol)
+		}
+	}
+	removed := 0
+	for symbol := range d.symbols {
+		if !seen[symbol] {
+			delete(d.symbols, symbol)
+			removed++
+		}
+	}
+	total := len(d.symbols)
+	handler := d.onNewSymbols
+	d.mu.Unlock()
+
+	sort.Strings(newlyAdded)
+	if initial {
+		log.Printf("[Aster %s] Discovered %d symbols from exchange info", d.name, total)
+	} else if len(newlyAdded) > 0 || removed > 0 {
+		log.Printf("[Aster %s] Symbol refresh: %d new %v, %d removed, now %d symbols", d.name, len(newlyAdded), newlyAdded, removed, total)
+	}
+
+	if handler != nil && len(newlyAdded) > 0 {
+		handler(newlyAdded)
+	}
+	return newlyAdded, nil
+}
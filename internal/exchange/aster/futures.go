@@ -2,6 +2,7 @@ package aster
 
 import (
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,13 +19,12 @@ type FuturesClient struct {
 }
 
 // NewFuturesClient 创建合约客户端
-func NewFuturesClient(baseURL, apiKey, secretKey string) *FuturesClient {
+func NewFuturesClient(baseURL, apiKey, secretKey string, opts ...ClientOption) *FuturesClient {
+	o := applyClientOptions(opts)
 	return &FuturesClient{
-		BaseURL: baseURL,
-		Auth:    NewAuth(apiKey, secretKey),
-		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		BaseURL:    baseURL,
+		Auth:       NewAuth(apiKey, secretKey),
+		HTTPClient: o.httpClient,
 	}
 }
 
@@ -259,8 +259,8 @@ func (c *FuturesClient) GetAllMarkPrices() ([]MarkPrice, error) {
 
 // ConvertToCommonPrice 转换为通用价格格式
 func (c *FuturesClient) ConvertToCommonPrice(ticker *FuturesBookTicker, volume24h float64) *common.Price {
-	bidPrice := parseFloat(ticker.BidPrice)
-	askPrice := parseFloat(ticker.AskPrice)
+	bidPrice := numutil.ParseFloat(ticker.BidPrice)
+	askPrice := numutil.ParseFloat(ticker.AskPrice)
 
 	return &common.Price{
 		Symbol:      ticker.Symbol,
@@ -269,10 +269,10 @@ func (c *FuturesClient) ConvertToCommonPrice(ticker *FuturesBookTicker, volume24
 		Price:       (bidPrice + askPrice) / 2,
 		BidPrice:    bidPrice,
 		AskPrice:    askPrice,
-		BidQty:      parseFloat(ticker.BidQty),
-		AskQty:      parseFloat(ticker.AskQty),
+		BidQty:      numutil.ParseFloat(ticker.BidQty),
+		AskQty:      numutil.ParseFloat(ticker.AskQty),
 		Volume24h:   volume24h,
-		Timestamp:   time.UnixMilli(ticker.Time),
+		Timestamp:   common.UnixMilliUTC(ticker.Time),
 		LastUpdated: time.Now(),
 	}
 }
@@ -309,10 +309,13 @@ func (c *FuturesClient) doRequest(method, endpoint string, params map[string]str
 		req.Header.Set(k, v)
 	}
 
-	// 发送请求
+	// 发送请求（受并发限制，读取完响应后释放）
+	release := restLimiter.Acquire()
+	defer release()
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", common.NewTemporaryError(common.ExchangeAster, err))
 	}
 	defer resp.Body.Close()
 
@@ -322,9 +325,9 @@ func (c *FuturesClient) doRequest(method, endpoint string, params map[string]str
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// 检查HTTP状态码
+	// 检查HTTP状态码（按状态码和错误码分类，调用者可区分限频、临时错误和请求错误）
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: status=%d, body=%s", resp.StatusCode, string(body))
+		return nil, common.NewHTTPError(common.ExchangeAster, resp.StatusCode, resp.Header, body)
 	}
 
 	return body, nil
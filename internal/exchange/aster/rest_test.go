@@ -0,0 +1,227 @@
+package aster
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// cannedTransport 按请求路径（带查询参数时为 路径?查询）返回预置的JSON，不访问网络
+type cannedTransport map[string]string
+
+func (c cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.Path
+	if req.URL.RawQuery != "" {
+		key += "?" + req.URL.RawQuery
+	}
+	body, ok := c[key]
+	status := http.StatusOK
+	if !ok {
+		status, body = http.StatusNotFound, `{"code":-1,"msg":"no canned response"}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// cannedClient 基础地址无法解析，只有注入的 http.Client 生效时请求才会成功
+func cannedClient(responses cannedTransport) ClientOption {
+	return WithHTTPClient(&http.Client{Transport: responses})
+}
+
+const unreachableBaseURL = "https://aster.invalid"
+
+func TestSpotClientParsesCannedResponses(t *testing.T) {
+	client := NewSpotClient(unreachableBaseURL, "", "", cannedClient(cannedTransport{
+		"/api/v1/exchangeInfo": `{"timezone":"UTC","serverTime":1700000000000,"symbols":[
+			{"symbol":"BTCUSDT","status":"TRADING","baseAsset":"BTC","quoteAsset":"USDT"}]}`,
+		"/api/v1/ticker/price":                `[{"symbol":"BTCUSDT","price":"50000.5","time":1700000000001}]`,
+		"/api/v1/ticker/price?symbol=BTCUSDT": `{"symbol":"BTCUSDT","price":"50000.5","time":1700000000001}`,
+		"/api/v1/ticker/bookTicker": `[{"symbol":"BTCUSDT","bidPrice":"49999.9","bidQty":"1.5",
+			"askPrice":"50000.1","askQty":"2","time":1700000000002}]`,
+		"/api/v1/ticker/bookTicker?symbol=BTCUSDT": `{"symbol":"BTCUSDT","bidPrice":"49999.9","bidQty":"1.5",
+			"askPrice":"50000.1","askQty":"2","time":1700000000002}`,
+		"/api/v1/ticker/24hr": `[{"symbol":"BTCUSDT","priceChange":"100","priceChangePercent":"0.2",
+			"lastPrice":"50000","bidPrice":"49999.9","askPrice":"50000.1","volume":"12.5","quoteVolume":"625000",
+			"openTime":1699913600000,"closeTime":1700000000000}]`,
+		"/api/v1/ticker/24hr?symbol=BTCUSDT": `{"symbol":"BTCUSDT","lastPrice":"50000","quoteVolume":"625000"}`,
+	}))
+
+	info, err := client.GetExchangeInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ServerTime != 1700000000000 || len(info.Symbols) != 1 ||
+		info.Symbols[0] != (Symbol{Symbol: "BTCUSDT", Status: "TRADING", BaseAsset: "BTC", QuoteAsset: "USDT"}) {
+		t.Fatalf("exchange info = %+v", info)
+	}
+
+	prices, err := client.GetAllTickerPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || prices[0].Price != "50000.5" || prices[0].Time != 1700000000001 {
+		t.Fatalf("ticker prices = %+v", prices)
+	}
+	price, err := client.GetTickerPrice("BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Symbol != "BTCUSDT" || price.Price != "50000.5" {
+		t.Fatalf("ticker price = %+v", price)
+	}
+
+	books, err := client.GetAllBookTickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BookTicker{Symbol: "BTCUSDT", BidPrice: "49999.9", BidQty: "1.5", AskPrice: "50000.1", AskQty: "2", Time: 1700000000002}
+	if len(books) != 1 || books[0] != want {
+		t.Fatalf("book tickers = %+v, want %+v", books, want)
+	}
+	book, err := client.GetBookTicker("BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *book != want {
+		t.Fatalf("book ticker = %+v, want %+v", book, want)
+	}
+
+	converted := client.ConvertToCommonPrice(book, 625000)
+	if converted.BidPrice != 49999.9 || converted.AskPrice != 50000.1 || converted.BidQty != 1.5 || converted.AskQty != 2 ||
+		converted.Price != 50000 || converted.Timestamp.UnixMilli() != 1700000000002 {
+		t.Fatalf("converted price = %+v", converted)
+	}
+
+	tickers, err := client.GetAll24hrTickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].QuoteVolume != "625000" || tickers[0].PriceChangePercent != "0.2" ||
+		tickers[0].OpenTime != 1699913600000 || tickers[0].CloseTime != 1700000000000 {
+		t.Fatalf("24hr tickers = %+v", tickers)
+	}
+	ticker, err := client.Get24hrTicker("BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.LastPrice != "50000" || ticker.QuoteVolume != "625000" {
+		t.Fatalf("24hr ticker = %+v", ticker)
+	}
+}
+
+func TestFuturesClientParsesCannedResponses(t *testing.T) {
+	client := NewFuturesClient(unreachableBaseURL, "", "", cannedClient(cannedTransport{
+		"/fapi/v1/exchangeInfo": `{"timezone":"UTC","serverTime":1700000000000,"symbols":[
+			{"symbol":"ETHUSDT","status":"TRADING","baseAsset":"ETH","quoteAsset":"USDT","contractType":"PERPETUAL",
+			"deliveryDate":4133404800000,"onboardDate":1690000000000,"contractStatus":"TRADING","contractSize":1,
+			"marginAsset":"USDT","maintMarginPercent":"2.5","requiredMarginPercent":"5"}]}`,
+		"/fapi/v1/ticker/price":                `[{"symbol":"ETHUSDT","price":"3000.25","time":1700000000001}]`,
+		"/fapi/v1/ticker/price?symbol=ETHUSDT": `{"symbol":"ETHUSDT","price":"3000.25","time":1700000000001}`,
+		"/fapi/v1/ticker/bookTicker": `[{"symbol":"ETHUSDT","bidPrice":"3000.1","bidQty":"10",
+			"askPrice":"3000.3","askQty":"8.5","time":1700000000002}]`,
+		"/fapi/v1/ticker/bookTicker?symbol=ETHUSDT": `{"symbol":"ETHUSDT","bidPrice":"3000.1","bidQty":"10",
+			"askPrice":"3000.3","askQty":"8.5","time":1700000000002}`,
+		"/fapi/v1/ticker/24hr": `[{"symbol":"ETHUSDT","priceChange":"-15","priceChangePercent":"-0.5",
+			"lastPrice":"3000.2","volume":"400","quoteVolume":"1200080","openTime":1699913600000,
+			"closeTime":1700000000000,"count":9876}]`,
+		"/fapi/v1/ticker/24hr?symbol=ETHUSDT": `{"symbol":"ETHUSDT","lastPrice":"3000.2","count":9876}`,
+		"/fapi/v1/premiumIndex": `[{"symbol":"ETHUSDT","markPrice":"3000.18","indexPrice":"3000.05",
+			"lastFundingRate":"0.0001","nextFundingTime":1700006400000,"time":1700000000003}]`,
+		"/fapi/v1/premiumIndex?symbol=ETHUSDT": `{"symbol":"ETHUSDT","markPrice":"3000.18","indexPrice":"3000.05",
+			"lastFundingRate":"0.0001","nextFundingTime":1700006400000,"time":1700000000003}`,
+	}))
+
+	info, err := client.GetExchangeInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Symbols) != 1 {
+		t.Fatalf("exchange info = %+v", info)
+	}
+	sym := info.Symbols[0]
+	if sym.ContractType != "PERPETUAL" || sym.DeliveryDate != 4133404800000 || sym.ContractSize != 1 ||
+		sym.MarginAsset != "USDT" || sym.RequiredMarginPercent != "5" {
+		t.Fatalf("futures symbol = %+v", sym)
+	}
+
+	prices, err := client.GetAllTickerPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || prices[0].Price != "3000.25" {
+		t.Fatalf("ticker prices = %+v", prices)
+	}
+	price, err := client.GetTickerPrice("ETHUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Price != "3000.25" || price.Time != 1700000000001 {
+		t.Fatalf("ticker price = %+v", price)
+	}
+
+	books, err := client.GetAllBookTickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FuturesBookTicker{Symbol: "ETHUSDT", BidPrice: "3000.1", BidQty: "10", AskPrice: "3000.3", AskQty: "8.5", Time: 1700000000002}
+	if len(books) != 1 || books[0] != want {
+		t.Fatalf("book tickers = %+v, want %+v", books, want)
+	}
+	book, err := client.GetBookTicker("ETHUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *book != want {
+		t.Fatalf("book ticker = %+v, want %+v", book, want)
+	}
+
+	converted := client.ConvertToCommonPrice(book, 1200080)
+	if converted.BidQty != 10 || converted.AskQty != 8.5 || converted.Volume24h != 1200080 ||
+		converted.Timestamp.UnixMilli() != 1700000000002 {
+		t.Fatalf("converted price = %+v", converted)
+	}
+
+	tickers, err := client.GetAll24hrTickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].Count != 9876 || tickers[0].PriceChange != "-15" || tickers[0].QuoteVolume != "1200080" {
+		t.Fatalf("24hr tickers = %+v", tickers)
+	}
+	ticker, err := client.Get24hrTicker("ETHUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.LastPrice != "3000.2" || ticker.Count != 9876 {
+		t.Fatalf("24hr ticker = %+v", ticker)
+	}
+
+	wantMark := MarkPrice{Symbol: "ETHUSDT", MarkPrice: "3000.18", IndexPrice: "3000.05",
+		LastFundingRate: "0.0001", NextFundingTime: 1700006400000, Time: 1700000000003}
+	marks, err := client.GetAllMarkPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 1 || marks[0] != wantMark {
+		t.Fatalf("mark prices = %+v, want %+v", marks, wantMark)
+	}
+	mark, err := client.GetMarkPrice("ETHUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *mark != wantMark {
+		t.Fatalf("mark price = %+v, want %+v", mark, wantMark)
+	}
+}
+
+func TestClientSurfacesErrorStatus(t *testing.T) {
+	client := NewSpotClient(unreachableBaseURL, "", "", cannedClient(cannedTransport{}))
+	if _, err := client.GetAllBookTickers(); err == nil {
+		t.Fatal("404 response parsed without error")
+	}
+}
@@ -2,6 +2,7 @@ package aster
 
 import (
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,13 +19,12 @@ type SpotClient struct {
 }
 
 // NewSpotClient 创建现货客户端
-func NewSpotClient(baseURL, apiKey, secretKey string) *SpotClient {
+func NewSpotClient(baseURL, apiKey, secretKey string, opts ...ClientOption) *SpotClient {
+	o := applyClientOptions(opts)
 	return &SpotClient{
-		BaseURL: baseURL,
-		Auth:    NewAuth(apiKey, secretKey),
-		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		BaseURL:    baseURL,
+		Auth:       NewAuth(apiKey, secretKey),
+		HTTPClient: o.httpClient,
 	}
 }
 
@@ -203,8 +203,8 @@ func (c *SpotClient) GetAll24hrTickers() ([]Ticker24hr, error) {
 
 // ConvertToCommonPrice 转换为通用价格格式（REST API）
 func (c *SpotClient) ConvertToCommonPrice(ticker *BookTicker, volume24h float64) *common.Price {
-	bidPrice := parseFloat(ticker.BidPrice)
-	askPrice := parseFloat(ticker.AskPrice)
+	bidPrice := numutil.ParseFloat(ticker.BidPrice)
+	askPrice := numutil.ParseFloat(ticker.AskPrice)
 
 	return &common.Price{
 		Symbol:      ticker.Symbol,
@@ -213,12 +213,12 @@ func (c *SpotClient) ConvertToCommonPrice(ticker *BookTicker, volume24h float64)
 		Price:       (bidPrice + askPrice) / 2,
 		BidPrice:    bidPrice,
 		AskPrice:    askPrice,
-		BidQty:      parseFloat(ticker.BidQty),
-		AskQty:      parseFloat(ticker.AskQty),
+		BidQty:      numutil.ParseFloat(ticker.BidQty),
+		AskQty:      numutil.ParseFloat(ticker.AskQty),
 		Volume24h:   volume24h,
-		Timestamp:   time.UnixMilli(ticker.Time), // 使用交易所时间
-		LastUpdated: time.Now(),                  // 本地接收时间
-		Source:      common.PriceSourceREST,      // 标记为REST数据源
+		Timestamp:   common.UnixMilliUTC(ticker.Time), // 使用交易所时间
+		LastUpdated: time.Now(),                       // 本地接收时间
+		Source:      common.PriceSourceREST,           // 标记为REST数据源
 	}
 }
 
@@ -254,10 +254,13 @@ func (c *SpotClient) doRequest(method, endpoint string, params map[string]string
 		req.Header.Set(k, v)
 	}
 
-	// 发送请求
+	// 发送请求（受并发限制，读取完响应后释放）
+	release := restLimiter.Acquire()
+	defer release()
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", common.NewTemporaryError(common.ExchangeAster, err))
 	}
 	defer resp.Body.Close()
 
@@ -267,9 +270,9 @@ func (c *SpotClient) doRequest(method, endpoint string, params map[string]string
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// 检查HTTP状态码
+	// 检查HTTP状态码（按状态码和错误码分类，调用者可区分限频、临时错误和请求错误）
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: status=%d, body=%s", resp.StatusCode, string(body))
+		return nil, common.NewHTTPError(common.ExchangeAster, resp.StatusCode, resp.Header, body)
 	}
 
 	return body, nil
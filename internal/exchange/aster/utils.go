@@ -1,16 +1,45 @@
 package aster
 
 import (
+	"crypto-arbitrage-monitor/internal/netutil"
+	"crypto-arbitrage-monitor/pkg/common"
+	"net/http"
 	"strconv"
+	"time"
 )
 
-// parseFloat 解析字符串为float64
-func parseFloat(s string) float64 {
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0
+// restLimiter 限制Aster REST同时进行中的请求数（现货和合约共用）
+var restLimiter = common.NewConcurrencyLimiter(2)
+
+// SetMaxConcurrentRequests 设置Aster REST最大并发请求数
+func SetMaxConcurrentRequests(limit int) {
+	restLimiter.SetLimit(limit)
+}
+
+// ClientOption 现货/合约客户端的可选配置
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	httpClient *http.Client
+}
+
+// WithHTTPClient 使用自定义 http.Client（例如指向 httptest.Server 或共享连接池的客户端）
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(o *clientOptions) {
+		o.httpClient = client
+	}
+}
+
+// applyClientOptions 应用可选配置，未指定 http.Client 时使用默认的10秒超时客户端
+func applyClientOptions(opts []ClientOption) clientOptions {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.httpClient == nil {
+		o.httpClient = netutil.NewHTTPClient(10 * time.Second)
 	}
-	return f
+	return o
 }
 
 // parseInt 解析字符串为int64
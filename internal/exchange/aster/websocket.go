@@ -1,7 +1,10 @@
 package aster
 
 import (
+	"crypto-arbitrage-monitor/internal/faults"
+	"crypto-arbitrage-monitor/internal/wsutil"
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -25,6 +28,9 @@ type WSClient struct {
 	done              chan struct{}
 	connectedAt       time.Time
 	lastPongTime      time.Time
+	nextRequestID     int64         // 订阅请求ID（递增，分批发送时每条消息不同）
+	reconnectDelay    time.Duration // 断线后重连前的等待时间
+	faultPoint        *faults.Point // 故障注入点（仅 -tags faults 构建生效）
 }
 
 // WSMessage WebSocket消息 (Combined Stream 格式)
@@ -73,21 +79,30 @@ type WSMiniTickerData struct {
 
 // NewWSClient 创建WebSocket客户端
 func NewWSClient(url string, marketType common.MarketType) *WSClient {
-	return &WSClient{
-		URL:           url,
-		MarketType:    marketType,
-		subscriptions: make(map[string]bool),
-		reconnect:     true,
-		done:          make(chan struct{}),
+	w := &WSClient{
+		URL:            url,
+		MarketType:     marketType,
+		subscriptions:  make(map[string]bool),
+		reconnect:      true,
+		reconnectDelay: 5 * time.Second,
+		done:           make(chan struct{}),
+	}
+	w.faultPoint = faults.NewPoint("aster-ws-"+string(marketType), w.closeConn)
+	return w
+}
+
+// closeConn 关闭当前底层连接（不关闭 WSClient，读循环退出后正常重连）
+func (w *WSClient) closeConn() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Conn != nil {
+		w.Conn.Close()
 	}
 }
 
 // Connect 连接WebSocket
 func (w *WSClient) Connect() error {
-	dialer := websocket.DefaultDialer
-	dialer.HandshakeTimeout = 10 * time.Second
-
-	conn, _, err := dialer.Dial(w.URL, nil)
+	conn, _, err := wsutil.NewDialer("").Dial(w.URL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to websocket: %w", err)
 	}
@@ -118,7 +133,10 @@ func (w *WSClient) Connect() error {
 	return nil
 }
 
-// Subscribe 订阅流
+// maxStreamsPerMessage 每条 SUBSCRIBE/UNSUBSCRIBE 消息最多携带的流数量
+const maxStreamsPerMessage = 100
+
+// Subscribe 订阅流（超过 maxStreamsPerMessage 时分多条消息发送，重连后的重新订阅同样分批）
 func (w *WSClient) Subscribe(streams []string) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -127,20 +145,21 @@ func (w *WSClient) Subscribe(streams []string) error {
 		return fmt.Errorf("websocket not connected")
 	}
 
-	// 构建订阅消息
-	msg := map[string]interface{}{
-		"method": "SUBSCRIBE",
-		"params": streams,
-		"id":     time.Now().Unix(),
-	}
+	for start := 0; start < len(streams); start += maxStreamsPerMessage {
+		end := start + maxStreamsPerMessage
+		if end > len(streams) {
+			end = len(streams)
+		}
+		batch := streams[start:end]
 
-	if err := w.Conn.WriteJSON(msg); err != nil {
-		return fmt.Errorf("failed to subscribe: %w", err)
-	}
+		if err := w.sendLocked("SUBSCRIBE", batch); err != nil {
+			return fmt.Errorf("failed to subscribe: %w", err)
+		}
 
-	// 记录订阅
-	for _, stream := range streams {
-		w.subscriptions[stream] = true
+		// 记录订阅（只记录已发送的批次）
+		for _, stream := range batch {
+			w.subscriptions[stream] = true
+		}
 	}
 
 	log.Printf("Subscribed to %d streams (%s)", len(streams), w.MarketType)
@@ -148,7 +167,7 @@ func (w *WSClient) Subscribe(streams []string) error {
 	return nil
 }
 
-// Unsubscribe 取消订阅
+// Unsubscribe 取消订阅（同样按 maxStreamsPerMessage 分批）
 func (w *WSClient) Unsubscribe(streams []string) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -157,24 +176,37 @@ func (w *WSClient) Unsubscribe(streams []string) error {
 		return fmt.Errorf("websocket not connected")
 	}
 
-	msg := map[string]interface{}{
-		"method": "UNSUBSCRIBE",
-		"params": streams,
-		"id":     time.Now().Unix(),
-	}
+	for start := 0; start < len(streams); start += maxStreamsPerMessage {
+		end := start + maxStreamsPerMessage
+		if end > len(streams) {
+			end = len(streams)
+		}
+		batch := streams[start:end]
 
-	if err := w.Conn.WriteJSON(msg); err != nil {
-		return fmt.Errorf("failed to unsubscribe: %w", err)
-	}
+		if err := w.sendLocked("UNSUBSCRIBE", batch); err != nil {
+			return fmt.Errorf("failed to unsubscribe: %w", err)
+		}
 
-	// 删除订阅记录
-	for _, stream := range streams {
-		delete(w.subscriptions, stream)
+		// 删除订阅记录
+		for _, stream := range batch {
+			delete(w.subscriptions, stream)
+		}
 	}
 
 	return nil
 }
 
+// sendLocked 发送一条订阅类请求，调用方需持有 w.mu
+func (w *WSClient) sendLocked(method string, params []string) error {
+	w.nextRequestID++
+	msg := map[string]interface{}{
+		"method": method,
+		"params": params,
+		"id":     w.nextRequestID,
+	}
+	return w.Conn.WriteJSON(msg)
+}
+
 // SetMessageHandler 设置消息处理器
 func (w *WSClient) SetMessageHandler(handler func(*WSMessage)) {
 	w.mu.Lock()
@@ -207,10 +239,10 @@ func (w *WSClient) readMessages() {
 
 		// 如果需要重连
 		if w.reconnect {
-			log.Printf("Reconnecting WebSocket in 5 seconds... (%s)", w.MarketType)
-			time.Sleep(5 * time.Second)
+			common.DedupLog.Printf("aster-ws", "Reconnecting WebSocket in %v... (%s)", w.reconnectDelay, w.MarketType)
+			time.Sleep(w.reconnectDelay)
 			if err := w.Connect(); err != nil {
-				log.Printf("Failed to reconnect: %v", err)
+				common.DedupLog.Printf("aster-ws", "Failed to reconnect: %v", err)
 			} else {
 				// 重新订阅
 				w.mu.RLock()
@@ -263,11 +295,16 @@ func (w *WSClient) readMessages() {
 				continue
 			}
 
+			if !w.faultPoint.Filter() {
+				continue
+			}
+
 			// 1️⃣ 优先尝试解析 BookTicker（真实bid/ask）
 			var bookTicker WSBookTickerData
 			if err := json.Unmarshal(message, &bookTicker); err == nil && bookTicker.Symbol != "" && bookTicker.BidPrice != "" {
-				// 打印 BTC/ETH/SOL 相关的数据用于调试
-				if bookTicker.Symbol == "BTCUSDT" || bookTicker.Symbol == "ETHUSDT" || bookTicker.Symbol == "SOLUSDT" {
+				// 打印 BTC/ETH/SOL 相关的数据用于调试（按symbol采样，避免刷屏）
+				if (bookTicker.Symbol == "BTCUSDT" || bookTicker.Symbol == "ETHUSDT" || bookTicker.Symbol == "SOLUSDT") &&
+					common.TickerLogSampler.Allow("ASTER_"+string(w.MarketType)+"_"+bookTicker.Symbol) {
 					log.Printf("[Aster WS %s] BookTicker %s: bid=%s, ask=%s, txnTime=%d, eventTime=%d",
 						w.MarketType, bookTicker.Symbol, bookTicker.BidPrice, bookTicker.AskPrice, bookTicker.TxnTime, bookTicker.EventTime)
 				}
@@ -360,6 +397,7 @@ func (w *WSClient) checkPongTimeout() {
 func (w *WSClient) Close() {
 	w.reconnect = false
 	close(w.done)
+	w.faultPoint.Release()
 
 	w.mu.Lock()
 	if w.Conn != nil {
@@ -371,10 +409,10 @@ func (w *WSClient) Close() {
 
 // ConvertWSBookTickerToPrice 将WebSocket BookTicker转换为通用价格（推荐）
 func ConvertWSBookTickerToPrice(ticker *WSBookTickerData, exchange common.Exchange, marketType common.MarketType) *common.Price {
-	bidPrice := parseFloat(ticker.BidPrice)
-	askPrice := parseFloat(ticker.AskPrice)
-	bidQty := parseFloat(ticker.BidQty)
-	askQty := parseFloat(ticker.AskQty)
+	bidPrice := numutil.ParseFloat(ticker.BidPrice)
+	askPrice := numutil.ParseFloat(ticker.AskPrice)
+	bidQty := numutil.ParseFloat(ticker.BidQty)
+	askQty := numutil.ParseFloat(ticker.AskQty)
 
 	// 计算中间价
 	midPrice := (bidPrice + askPrice) / 2
@@ -382,9 +420,9 @@ func ConvertWSBookTickerToPrice(ticker *WSBookTickerData, exchange common.Exchan
 	// 使用交易所时间（优先用TxnTime撮合时间，否则用EventTime事件时间）
 	var exchangeTimestamp time.Time
 	if ticker.TxnTime > 0 {
-		exchangeTimestamp = time.UnixMilli(ticker.TxnTime)
+		exchangeTimestamp = common.UnixMilliUTC(ticker.TxnTime)
 	} else if ticker.EventTime > 0 {
-		exchangeTimestamp = time.UnixMilli(ticker.EventTime)
+		exchangeTimestamp = common.UnixMilliUTC(ticker.EventTime)
 	} else {
 		exchangeTimestamp = time.Now() // fallback
 	}
@@ -408,8 +446,8 @@ func ConvertWSBookTickerToPrice(ticker *WSBookTickerData, exchange common.Exchan
 // ConvertWSMiniTickerToPrice 将WebSocket MiniTicker转换为通用价格（不推荐）
 // 注意：MiniTicker只有last trade price，没有真实的bid/ask，会导致系统误差
 func ConvertWSMiniTickerToPrice(ticker *WSMiniTickerData, exchange common.Exchange, marketType common.MarketType) *common.Price {
-	price := parseFloat(ticker.LastPrice)
-	quoteVolume := parseFloat(ticker.QuoteVolume)
+	price := numutil.ParseFloat(ticker.LastPrice)
+	quoteVolume := numutil.ParseFloat(ticker.QuoteVolume)
 
 	return &common.Price{
 		Symbol:      ticker.Symbol,
@@ -421,8 +459,9 @@ func ConvertWSMiniTickerToPrice(ticker *WSMiniTickerData, exchange common.Exchan
 		BidQty:      0,
 		AskQty:      0,
 		Volume24h:   quoteVolume,
-		Timestamp:   time.UnixMilli(ticker.EventTime), // 使用交易所时间
-		LastUpdated: time.Now(),                       // 本地接收时间
+		VolumeKnown: true,
+		Timestamp:   common.UnixMilliUTC(ticker.EventTime), // 使用交易所时间
+		LastUpdated: time.Now(),                            // 本地接收时间
 		Source:      common.PriceSourceWebSocket,
 	}
 }
@@ -0,0 +1,194 @@
+package aster
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// subscribeRequest 服务端收到的订阅请求
+type subscribeRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+// fakeAsterServer 模拟 Aster 行情 WebSocket：记录每条订阅请求，可主动断开当前连接
+type fakeAsterServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []subscribeRequest
+	conns    []*websocket.Conn
+}
+
+func newFakeAsterServer(t *testing.T) *fakeAsterServer {
+	t.Helper()
+	s := &fakeAsterServer{}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		defer conn.Close()
+		for {
+			var req subscribeRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.requests = append(s.requests, req)
+			s.mu.Unlock()
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeAsterServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// take 等待至少 n 条请求后返回并清空记录
+func (s *fakeAsterServer) take(t *testing.T, n int) []subscribeRequest {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		s.mu.Lock()
+		if len(s.requests) >= n {
+			got := s.requests
+			s.requests = nil
+			s.mu.Unlock()
+			return got
+		}
+		have := len(s.requests)
+		s.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d subscribe requests, want %d", have, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// dropConnections 关闭服务端的全部连接（模拟断线）
+func (s *fakeAsterServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func bookTickerStreams(n int) []string {
+	streams := make([]string, n)
+	for i := range streams {
+		streams[i] = fmt.Sprintf("s%dusdt@bookTicker", i)
+	}
+	return streams
+}
+
+// checkBatches 检查请求按 maxStreamsPerMessage 分批、ID 各不相同，并且合起来恰好覆盖 want
+func checkBatches(t *testing.T, requests []subscribeRequest, want []string) {
+	t.Helper()
+	ids := make(map[int64]bool)
+	got := make(map[string]bool)
+	for _, req := range requests {
+		if req.Method != "SUBSCRIBE" {
+			t.Fatalf("method = %q, want SUBSCRIBE", req.Method)
+		}
+		if len(req.Params) == 0 || len(req.Params) > maxStreamsPerMessage {
+			t.Fatalf("request %d carries %d streams, want 1..%d", req.ID, len(req.Params), maxStreamsPerMessage)
+		}
+		if ids[req.ID] {
+			t.Fatalf("duplicate request id %d", req.ID)
+		}
+		ids[req.ID] = true
+		for _, stream := range req.Params {
+			got[stream] = true
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("subscribed %d distinct streams, want %d", len(got), len(want))
+	}
+	for _, stream := range want {
+		if !got[stream] {
+			t.Fatalf("stream %s not subscribed", stream)
+		}
+	}
+}
+
+func TestSubscribeBatchesAcrossReconnect(t *testing.T) {
+	server := newFakeAsterServer(t)
+	ws := NewWSClient(server.wsURL(), common.MarketTypeSpot)
+	ws.reconnectDelay = 10 * time.Millisecond
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	streams := bookTickerStreams(250)
+	if err := ws.Subscribe(streams); err != nil {
+		t.Fatal(err)
+	}
+	checkBatches(t, server.take(t, 3), streams)
+
+	// 断线重连后的重新订阅同样分批（24小时主动重连走同一路径）
+	server.dropConnections()
+	checkBatches(t, server.take(t, 3), streams)
+}
+
+func TestDiscoveredSymbolGetsSubscribed(t *testing.T) {
+	server := newFakeAsterServer(t)
+	ws := NewWSClient(server.wsURL(), common.MarketTypeSpot)
+	if err := ws.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	listed := []string{"BTCUSDT", "ETHUSDT"}
+	discovery := newSymbolDiscovery("Spot", 0, func() ([]string, error) {
+		return listed, nil
+	})
+	discovery.SetNewSymbolsHandler(func(symbols []string) {
+		streams := make([]string, 0, len(symbols))
+		for _, symbol := range symbols {
+			streams = append(streams, strings.ToLower(symbol)+"@bookTicker")
+		}
+		if err := ws.Subscribe(streams); err != nil {
+			t.Errorf("subscribe: %v", err)
+		}
+	})
+	if err := discovery.Start(); err != nil {
+		t.Fatal(err)
+	}
+	checkBatches(t, server.take(t, 1), []string{"btcusdt@bookTicker", "ethusdt@bookTicker"})
+
+	// 新上线的交易对只订阅新增部分
+	listed = []string{"BTCUSDT", "ETHUSDT", "NEWUSDT"}
+	added, err := discovery.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0] != "NEWUSDT" {
+		t.Fatalf("added = %v, want [NEWUSDT]", added)
+	}
+	checkBatches(t, server.take(t, 1), []string{"newusdt@bookTicker"})
+
+	ws.mu.RLock()
+	subscribed := ws.subscriptions["newusdt@bookTicker"]
+	ws.mu.RUnlock()
+	if !subscribed {
+		t.Fatal("new stream not recorded for resubscription")
+	}
+}
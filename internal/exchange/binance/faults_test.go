@@ -0,0 +1,124 @@
+//go:build faults
+
+package binance
+
+import (
+	"crypto-arbitrage-monitor/internal/faults"
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newStreamingTickerServer 模拟交易所：每个连接持续推送 bookTicker，update id 在所有连接间递增
+func newStreamingTickerServer(t *testing.T) (url string, connections *atomic.Int64) {
+	t.Helper()
+	connections = &atomic.Int64{}
+	var updateID atomic.Int64
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		connections.Add(1)
+		for {
+			frame := fmt.Sprintf(`{"e":"bookTicker","u":%d,"s":"BTCUSDT","b":"100","B":"1","a":"101","A":"1","T":%d,"E":%d}`,
+				updateID.Add(1), time.Now().UnixMilli(), time.Now().UnixMilli())
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+				return
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), connections
+}
+
+// injectFault 以本机地址调用 /debug/faults
+func injectFault(t *testing.T, query string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/debug/faults?"+query, nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	rec := httptest.NewRecorder()
+	faults.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: status %d: %s", query, rec.Code, rec.Body)
+	}
+}
+
+func TestWSClientFaultInjection(t *testing.T) {
+	url, connections := newStreamingTickerServer(t)
+	client := NewWSClient(url, common.MarketTypeFuture)
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var lastID int64
+	duplicates := 0
+	client.SetBookTickerHandler(func(ticker *WSBookTickerData) {
+		mu.Lock()
+		defer mu.Unlock()
+		if seen[ticker.UpdateID] {
+			duplicates++
+		}
+		seen[ticker.UpdateID] = true
+		lastID = ticker.UpdateID
+	})
+	handled := func() (int, int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen), lastID
+	}
+	waitUntil := func(what string, timeout time.Duration, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(timeout)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	waitUntil("first messages", 2*time.Second, func() bool { n, _ := handled(); return n >= 10 })
+
+	// 丢弃接下来的5条：处理的 update id 出现5个缺口，之后继续处理
+	_, before := handled()
+	injectFault(t, "target=binance-ws-FUTURE*&action=drop&n=5")
+	waitUntil("messages after the drop", 2*time.Second, func() bool { _, last := handled(); return last > before+20 })
+	mu.Lock()
+	missing := 0
+	for id := before + 1; id <= lastID; id++ {
+		if !seen[id] {
+			missing++
+		}
+	}
+	mu.Unlock()
+	if missing != 5 {
+		t.Fatalf("%d update ids missing after dropping 5 messages", missing)
+	}
+
+	// 强制断开：走正常重连流程（5秒后），重连后继续收到新的 update id 且没有重复处理
+	injectFault(t, "target=binance-ws-FUTURE*&action=close")
+	waitUntil("reconnect", 10*time.Second, func() bool { return connections.Load() == 2 })
+	_, atReconnect := handled()
+	waitUntil("messages after reconnect", 2*time.Second, func() bool { _, last := handled(); return last > atReconnect+10 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if duplicates != 0 {
+		t.Fatalf("%d update ids handled twice", duplicates)
+	}
+}
@@ -0,0 +1,165 @@
+package binance
+
+import (
+	"bytes"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	lagSampleSize       = 1024                   // 处理耗时滚动窗口大小
+	defaultLagThreshold = 500 * time.Millisecond // 事件时间落后超过该值视为滞后
+	defaultLagSustain   = 5 * time.Second        // 持续滞后超过该时长才告警
+)
+
+// LagStatus 连接的处理延迟状态
+type LagStatus struct {
+	ProcessingP95 time.Duration `json:"processing_p95"` // 单条消息处理耗时的p95
+	LastEventLag  time.Duration `json:"last_event_lag"` // 最近一条消息的事件时间延迟（now - EventTime）
+	Lagging       bool          `json:"lagging"`        // 是否处于持续滞后状态
+	LagEvents     int64         `json:"lag_events"`     // 进入持续滞后状态的次数
+	ShedMessages  int64         `json:"shed_messages"`  // 滞后期间被丢弃的消息数
+}
+
+// LagMonitor 读取循环的处理延迟监控
+// 记录每条消息的处理耗时和事件时间延迟，持续滞后时输出告警
+type LagMonitor struct {
+	mu        sync.Mutex
+	name      string
+	threshold time.Duration
+	sustain   time.Duration
+
+	samples    []time.Duration
+	sampleIdx  int
+	sampleFull bool
+
+	lastEventLag time.Duration
+	lagSince     time.Time
+	lagging      bool
+	lagEvents    int64
+	shedMessages int64
+}
+
+// NewLagMonitor 创建延迟监控器（threshold/sustain <= 0 时使用默认值）
+func NewLagMonitor(name string, threshold, sustain time.Duration) *LagMonitor {
+	if threshold <= 0 {
+		threshold = defaultLagThreshold
+	}
+	if sustain <= 0 {
+		sustain = defaultLagSustain
+	}
+
+	return &LagMonitor{
+		name:      name,
+		threshold: threshold,
+		sustain:   sustain,
+		samples:   make([]time.Duration, lagSampleSize),
+	}
+}
+
+// RecordProcessing 记录单条消息从读取到处理完成的耗时
+func (m *LagMonitor) RecordProcessing(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples[m.sampleIdx] = d
+	m.sampleIdx = (m.sampleIdx + 1) % len(m.samples)
+	if m.sampleIdx == 0 {
+		m.sampleFull = true
+	}
+}
+
+// RecordEventLag 记录事件时间延迟，并更新持续滞后状态
+func (m *LagMonitor) RecordEventLag(lag time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.lastEventLag = lag
+
+	if lag <= m.threshold {
+		if m.lagging {
+			log.Printf("[Binance Lag] %s recovered after %.1fs (event lag %dms)",
+				m.name, now.Sub(m.lagSince).Seconds(), lag.Milliseconds())
+		}
+		m.lagSince = time.Time{}
+		m.lagging = false
+		return
+	}
+
+	if m.lagSince.IsZero() {
+		m.lagSince = now
+		return
+	}
+
+	if !m.lagging && now.Sub(m.lagSince) >= m.sustain {
+		m.lagging = true
+		m.lagEvents++
+		log.Printf("[Binance Lag] %s falling behind: event_lag=%dms threshold=%dms sustained=%.1fs processing_p95=%dus lag_events=%d",
+			m.name, lag.Milliseconds(), m.threshold.Milliseconds(), now.Sub(m.lagSince).Seconds(),
+			m.processingP95().Microseconds(), m.lagEvents)
+	}
+}
+
+// IsLagging 是否处于持续滞后状态
+func (m *LagMonitor) IsLagging() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lagging
+}
+
+// RecordShed 记录一条被丢弃的消息
+func (m *LagMonitor) RecordShed() {
+	m.mu.Lock()
+	m.shedMessages++
+	m.mu.Unlock()
+}
+
+// Status 获取当前延迟状态
+func (m *LagMonitor) Status() LagStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return LagStatus{
+		ProcessingP95: m.processingP95(),
+		LastEventLag:  m.lastEventLag,
+		Lagging:       m.lagging,
+		LagEvents:     m.lagEvents,
+		ShedMessages:  m.shedMessages,
+	}
+}
+
+// processingP95 计算处理耗时的p95（调用者需要持有锁）
+func (m *LagMonitor) processingP95() time.Duration {
+	n := m.sampleIdx
+	if m.sampleFull {
+		n = len(m.samples)
+	}
+	if n == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, n)
+	copy(sorted, m.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(n*95)/100]
+}
+
+// extractSymbol 从原始消息中提取 "s" 字段（不做JSON解析，用于滞后时快速丢弃）
+// 找不到时返回 nil
+func extractSymbol(message []byte) []byte {
+	key := []byte(`"s":"`)
+	idx := bytes.Index(message, key)
+	if idx < 0 {
+		return nil
+	}
+
+	start := idx + len(key)
+	end := bytes.IndexByte(message[start:], '"')
+	if end < 0 {
+		return nil
+	}
+	return message[start : start+end]
+}
@@ -0,0 +1,131 @@
+package binance
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newScriptedTickerServer 模拟 !bookTicker 流：把 frames 中的消息依次推送给客户端
+func newScriptedTickerServer(t *testing.T, frames <-chan string) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for frame := range frames {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// bookTickerFrame 事件时间为 eventTime 的 bookTicker 消息
+func bookTickerFrame(symbol string, eventTime time.Time) string {
+	return fmt.Sprintf(`{"e":"bookTicker","u":1,"s":"%s","b":"100","B":"1","a":"101","A":"1","T":%d,"E":%d}`,
+		symbol, eventTime.UnixMilli(), eventTime.UnixMilli())
+}
+
+func TestWSClientShedsWhileLagging(t *testing.T) {
+	frames := make(chan string, 16)
+	defer close(frames)
+
+	client := NewWSClient(newScriptedTickerServer(t, frames), common.MarketTypeFuture)
+	client.lagMonitor = NewLagMonitor("test", 100*time.Millisecond, 20*time.Millisecond)
+	client.SetShedWatchlist([]string{"BTCUSDT"})
+
+	var mu sync.Mutex
+	handled := make(map[string]int)
+	client.SetBookTickerHandler(func(ticker *WSBookTickerData) {
+		mu.Lock()
+		handled[ticker.Symbol]++
+		mu.Unlock()
+	})
+	handledCount := func(symbol string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled[symbol]
+	}
+	waitUntil := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s (status %+v)", what, client.Status())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// 事件时间落后10秒，持续超过 sustain 后进入滞后状态；进入前的消息照常处理
+	stale := time.Now().Add(-10 * time.Second)
+	frames <- bookTickerFrame("ETHUSDT", stale)
+	waitUntil("first ETHUSDT", func() bool { return handledCount("ETHUSDT") == 1 })
+	time.Sleep(30 * time.Millisecond)
+	frames <- bookTickerFrame("ETHUSDT", stale)
+	waitUntil("lagging", func() bool { return client.Status().Lagging })
+	if got := handledCount("ETHUSDT"); got != 2 {
+		t.Fatalf("ETHUSDT handled %d times before shedding, want 2", got)
+	}
+
+	// 滞后期间只处理关注的symbol，其余的在解析前丢弃并计数
+	frames <- bookTickerFrame("ETHUSDT", stale)
+	frames <- bookTickerFrame("SOLUSDT", stale)
+	frames <- bookTickerFrame("BTCUSDT", stale)
+	waitUntil("BTCUSDT", func() bool { return handledCount("BTCUSDT") == 1 })
+	status := client.Status()
+	if status.ShedMessages != 2 || status.LagEvents != 1 || status.LastEventLag < 9*time.Second {
+		t.Fatalf("status while shedding = %+v, want 2 shed messages and 1 lag event", status)
+	}
+	if eth, sol := handledCount("ETHUSDT"), handledCount("SOLUSDT"); eth != 2 || sol != 0 {
+		t.Fatalf("handled ETHUSDT %d, SOLUSDT %d times, want both shed while lagging", eth, sol)
+	}
+
+	// 追上实时数据后恢复处理所有symbol
+	frames <- bookTickerFrame("BTCUSDT", time.Now())
+	waitUntil("recovery", func() bool { return !client.Status().Lagging })
+	frames <- bookTickerFrame("SOLUSDT", time.Now())
+	waitUntil("SOLUSDT after recovery", func() bool { return handledCount("SOLUSDT") == 1 })
+	if got := client.Status().ShedMessages; got != 2 {
+		t.Fatalf("shed messages after recovery = %d, want 2", got)
+	}
+}
+
+func TestLagMonitorSustain(t *testing.T) {
+	m := NewLagMonitor("test", 100*time.Millisecond, time.Hour)
+	m.RecordEventLag(time.Second)
+	m.RecordEventLag(time.Second)
+	if m.IsLagging() {
+		t.Fatal("lagging before the sustain period elapsed")
+	}
+
+	m = NewLagMonitor("test", 100*time.Millisecond, time.Millisecond)
+	m.RecordEventLag(time.Second)
+	time.Sleep(2 * time.Millisecond)
+	m.RecordEventLag(time.Second)
+	if !m.IsLagging() || m.Status().LagEvents != 1 {
+		t.Fatalf("status = %+v, want lagging after the sustain period", m.Status())
+	}
+	m.RecordEventLag(10 * time.Millisecond)
+	if m.IsLagging() {
+		t.Fatal("still lagging after an event within the threshold")
+	}
+}
@@ -2,18 +2,26 @@ package binance
 
 import (
 	"context"
+	"crypto-arbitrage-monitor/internal/netutil"
+	"crypto-arbitrage-monitor/internal/wsutil"
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	binance_connector "github.com/binance/binance-connector-go"
+	"github.com/binance/binance-connector-go/handlers"
+	"github.com/gorilla/websocket"
 )
 
 // RestBookTickerResponse Binance BookTicker REST API 响应
@@ -25,6 +33,12 @@ type RestBookTickerResponse struct {
 	AskQty   string `json:"askQty"`
 }
 
+// RestFuturesBookTickerResponse Binance 合约 BookTicker REST API 响应（比现货多交易所时间）
+type RestFuturesBookTickerResponse struct {
+	RestBookTickerResponse
+	Time int64 `json:"time"` // 交易所时间（毫秒）
+}
+
 // API Base URLs（按优先级排序）
 var (
 	// 现货 API URLs（优先使用性能更好的 api1-api4）
@@ -45,8 +59,34 @@ var (
 	// 代理配置
 	proxyURL    string
 	proxyConfig sync.Mutex
+
+	// 限制Binance REST同时进行中的请求数（现货和合约共用）
+	restLimiter = common.NewConcurrencyLimiter(2)
+
+	// 是否丢弃币本位（反向）合约，默认丢弃
+	excludeInverse atomic.Bool
 )
 
+func init() {
+	excludeInverse.Store(true)
+}
+
+// SetExcludeInverse 设置是否丢弃币本位合约（如 BTCUSD_PERP）
+// 不丢弃时以独立的 BTCUSD_INVERSE 命名空间入库，不会与USDT交易对配对
+func SetExcludeInverse(exclude bool) {
+	excludeInverse.Store(exclude)
+}
+
+// isExcludedSymbol 判断合约symbol是否应被丢弃
+func isExcludedSymbol(symbol string) bool {
+	return excludeInverse.Load() && common.IsInverseSymbol(symbol)
+}
+
+// SetMaxConcurrentRequests 设置Binance REST最大并发请求数
+func SetMaxConcurrentRequests(limit int) {
+	restLimiter.SetLimit(limit)
+}
+
 // SetProxyURL 设置代理 URL（需要在创建客户端前调用）
 func SetProxyURL(url string) {
 	proxyConfig.Lock()
@@ -76,11 +116,9 @@ func newHTTPClient() *http.Client {
 
 	// 创建 Transport
 	// ！Warning: 超时配置，本地需要调整
+	// 拨号使用共享拨号器：DNS缓存，IPv6不通时快速切换到IPv4，连续失败的主机在URL轮换时跳过
 	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   60 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
+		DialContext: netutil.DialContext,
 
 		TLSClientConfig: &tls.Config{
 			MinVersion: tls.VersionTLS12,
@@ -119,6 +157,15 @@ func newHTTPClient() *http.Client {
 	}
 }
 
+// newWSDialer 创建 WebSocket Dialer（与 REST 使用相同的代理配置）
+func newWSDialer() *websocket.Dialer {
+	proxyConfig.Lock()
+	currentProxyURL := proxyURL
+	proxyConfig.Unlock()
+
+	return wsutil.NewDialer(currentProxyURL)
+}
+
 // parseProxyURL 解析代理 URL
 func parseProxyURL(urlStr string) (*url.URL, error) {
 	return url.Parse(urlStr)
@@ -178,23 +225,74 @@ func FetchFuturesPrices() ([]*common.Price, error) {
 	return client.fetchFuturesPricesWithRetry(3)
 }
 
+// FetchSpotBookTicker 获取单个现货交易对的 BookTicker（用于按需订阅前校验 symbol 并写入冷启动数据）
+// symbol 不存在时返回 ErrBadRequest 分类的错误（-1121 Invalid symbol）
+func FetchSpotBookTicker(symbol string) (*common.Price, error) {
+	client := GetRestClient()
+	client.mu.Lock()
+	currentURL := SpotAPIBaseURLs[client.currentSpotIdx]
+	client.mu.Unlock()
+
+	endpoint := currentURL + "/api/v3/ticker/bookTicker?symbol=" + url.QueryEscape(strings.ToUpper(symbol))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	release := restLimiter.Acquire()
+	defer release()
+
+	httpClient := netutil.NewHTTPClient(10 * time.Second)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch spot bookTicker: %w", common.NewTemporaryError(common.ExchangeBinance, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, common.NewHTTPError(common.ExchangeBinance, resp.StatusCode, resp.Header, body)
+	}
+
+	var ticker RestBookTickerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ticker); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	price := convertRestBookTickerToPrice(ticker, common.MarketTypeSpot)
+	if price == nil {
+		return nil, fmt.Errorf("%s has no bid/ask", ticker.Symbol)
+	}
+	return price, nil
+}
+
 // fetchSpotPricesWithRetry 获取现货价格（带重试）
 func (c *RestClient) fetchSpotPricesWithRetry(maxRetries int) ([]*common.Price, error) {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			log.Printf("[Binance API] Retry attempt %d/%d for SPOT", attempt, maxRetries)
+			common.DedupLog.Printf("binance-rest", "[Binance API] Retry attempt %d/%d for SPOT", attempt, maxRetries)
 			time.Sleep(time.Duration(attempt) * time.Second)
 		}
 
+		// 当前主机已被降级（连续拨号失败）时先换到健康的URL，不必再等一次完整超时
+		if c.currentURLDemoted(&c.currentSpotIdx, SpotAPIBaseURLs) {
+			c.rotateSpotURL()
+		}
+
 		prices, err := c.fetchSpotPrices()
 		if err == nil {
 			return prices, nil
 		}
 
 		lastErr = err
-		log.Printf("[Binance API] Attempt %d/%d failed for SPOT: %v", attempt, maxRetries, err)
+		common.DedupLog.Printf("binance-rest", "[Binance API] Attempt %d/%d failed for SPOT: %v", attempt, maxRetries, err)
+
+		// 限频时继续重试只会加重封禁，参数/认证错误重试没有意义，交给调用者处理
+		if errors.Is(err, common.ErrRateLimited) || common.IsPermanentError(err) {
+			return nil, err
+		}
 
 		// 尝试下一个 URL
 		c.rotateSpotURL()
@@ -209,17 +307,26 @@ func (c *RestClient) fetchFuturesPricesWithRetry(maxRetries int) ([]*common.Pric
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			log.Printf("[Binance API] Retry attempt %d/%d for FUTURE", attempt, maxRetries)
+			common.DedupLog.Printf("binance-rest", "[Binance API] Retry attempt %d/%d for FUTURE", attempt, maxRetries)
 			time.Sleep(time.Duration(attempt) * time.Second)
 		}
 
+		if c.currentURLDemoted(&c.currentFutIdx, FuturesAPIBaseURLs) {
+			c.rotateFuturesURL()
+		}
+
 		prices, err := c.fetchFuturesPrices()
 		if err == nil {
 			return prices, nil
 		}
 
 		lastErr = err
-		log.Printf("[Binance API] Attempt %d/%d failed for FUTURE: %v", attempt, maxRetries, err)
+		common.DedupLog.Printf("binance-rest", "[Binance API] Attempt %d/%d failed for FUTURE: %v", attempt, maxRetries, err)
+
+		// 限频时继续重试只会加重封禁，参数/认证错误重试没有意义，交给调用者处理
+		if errors.Is(err, common.ErrRateLimited) || common.IsPermanentError(err) {
+			return nil, err
+		}
 
 		// 尝试下一个 URL
 		c.rotateFuturesURL()
@@ -228,22 +335,50 @@ func (c *RestClient) fetchFuturesPricesWithRetry(maxRetries int) ([]*common.Pric
 	return nil, fmt.Errorf("all %d attempts failed: %w", maxRetries, lastErr)
 }
 
-// rotateSpotURL 轮换现货 API URL
+// rotateSpotURL 轮换现货 API URL，跳过连续拨号失败被降级的主机（全部降级时按顺序轮换）
 func (c *RestClient) rotateSpotURL() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.currentSpotIdx = (c.currentSpotIdx + 1) % len(c.spotClients)
-	log.Printf("[Binance API] Switched to spot URL: %s", SpotAPIBaseURLs[c.currentSpotIdx])
+	c.currentSpotIdx = nextHealthyURL(SpotAPIBaseURLs, c.currentSpotIdx)
+	common.DedupLog.Printf("binance-rest", "[Binance API] Switched to spot URL: %s", SpotAPIBaseURLs[c.currentSpotIdx])
 }
 
-// rotateFuturesURL 轮换合约 API URL
+// rotateFuturesURL 轮换合约 API URL，跳过连续拨号失败被降级的主机
 func (c *RestClient) rotateFuturesURL() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.currentFutIdx = (c.currentFutIdx + 1) % len(c.futuresClients)
-	log.Printf("[Binance API] Switched to futures URL: %s", FuturesAPIBaseURLs[c.currentFutIdx])
+	c.currentFutIdx = nextHealthyURL(FuturesAPIBaseURLs, c.currentFutIdx)
+	common.DedupLog.Printf("binance-rest", "[Binance API] Switched to futures URL: %s", FuturesAPIBaseURLs[c.currentFutIdx])
+}
+
+// currentURLDemoted 当前使用的URL的主机是否被降级
+func (c *RestClient) currentURLDemoted(idx *int, urls []string) bool {
+	c.mu.Lock()
+	current := urls[*idx]
+	c.mu.Unlock()
+	return urlDemoted(current)
+}
+
+// nextHealthyURL 返回 current 之后第一个主机未被降级的URL下标，全部降级时返回下一个
+func nextHealthyURL(urls []string, current int) int {
+	for step := 1; step <= len(urls); step++ {
+		idx := (current + step) % len(urls)
+		if !urlDemoted(urls[idx]) {
+			return idx
+		}
+	}
+	return (current + 1) % len(urls)
+}
+
+// urlDemoted URL的主机是否因连续拨号失败被降级
+func urlDemoted(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return netutil.Demoted(parsed.Hostname())
 }
 
 // fetchSpotPrices 获取现货价格（单次请求）- 使用 BookTicker API（真实bid/ask）
@@ -263,15 +398,19 @@ func (c *RestClient) fetchSpotPrices() ([]*common.Price, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	httpClient := &http.Client{Timeout: 20 * time.Second}
+	release := restLimiter.Acquire()
+	defer release()
+
+	httpClient := netutil.NewHTTPClient(20 * time.Second)
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch spot bookTickers: %w", err)
+		return nil, fmt.Errorf("failed to fetch spot bookTickers: %w", common.NewTemporaryError(common.ExchangeBinance, err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, common.NewHTTPError(common.ExchangeBinance, resp.StatusCode, resp.Header, body)
 	}
 
 	var bookTickers []RestBookTickerResponse
@@ -295,23 +434,95 @@ func (c *RestClient) fetchSpotPrices() ([]*common.Price, error) {
 	return prices, nil
 }
 
-// fetchFuturesPrices 获取合约价格（单次请求）- 使用 TickerPrice API（轻量级）
+// fetchFuturesPrices 获取合约价格（单次请求）- 优先使用 BookTicker API（真实bid/ask）
+// BookTicker 请求失败时回退到 TickerPrice API（只有最新价）
 func (c *RestClient) fetchFuturesPrices() ([]*common.Price, error) {
 	c.mu.Lock()
 	client := c.futuresClients[c.currentFutIdx]
 	currentURL := FuturesAPIBaseURLs[c.currentFutIdx]
 	c.mu.Unlock()
 
-	log.Printf("[Binance API] Fetching FUTURE prices from %s", currentURL)
+	prices, err := fetchFuturesBookTickers(currentURL)
+	if err == nil {
+		return prices, nil
+	}
+	// 限频时换接口同样会被限制
+	if errors.Is(err, common.ErrRateLimited) {
+		return nil, err
+	}
+	common.DedupLog.Printf("binance-rest", "[Binance API] FUTURE bookTicker failed, falling back to ticker price: %v", err)
+
+	return fetchFuturesTickerPrices(client, currentURL)
+}
+
+// fetchFuturesBookTickers 获取合约 BookTicker（/fapi/v1/ticker/bookTicker，真实bid/ask）
+func fetchFuturesBookTickers(baseURL string) ([]*common.Price, error) {
+	log.Printf("[Binance API] Fetching FUTURE BookTicker from %s", baseURL)
+	startTime := time.Now()
+
+	req, err := http.NewRequest("GET", baseURL+"/fapi/v1/ticker/bookTicker", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	release := restLimiter.Acquire()
+	defer release()
+
+	httpClient := netutil.NewHTTPClient(20 * time.Second)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch futures bookTickers: %w", common.NewTemporaryError(common.ExchangeBinance, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, common.NewHTTPError(common.ExchangeBinance, resp.StatusCode, resp.Header, body)
+	}
+
+	var bookTickers []RestFuturesBookTickerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bookTickers); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	log.Printf("[Binance API] Fetched %d FUTURE bookTickers in %.2fs", len(bookTickers), duration.Seconds())
+
+	prices := make([]*common.Price, 0, len(bookTickers))
+	excluded := 0
+	for _, ticker := range bookTickers {
+		if isExcludedSymbol(ticker.Symbol) {
+			excluded++
+			continue
+		}
+		price := convertRestBookTickerToPrice(ticker.RestBookTickerResponse, common.MarketTypeFuture)
+		if price == nil {
+			continue
+		}
+		// 合约 BookTicker 带有交易所时间
+		if ticker.Time > 0 {
+			price.Timestamp = common.UnixMilliUTC(ticker.Time)
+		}
+		prices = append(prices, price)
+	}
+
+	log.Printf("[Binance API] ✓ Successfully processed %d FUTURE prices with real bid/ask (%d inverse excluded)", len(prices), excluded)
+	return prices, nil
+}
+
+// fetchFuturesTickerPrices 获取合约 TickerPrice（轻量级，只有 symbol 和 price）
+func fetchFuturesTickerPrices(client *binance_connector.Client, baseURL string) ([]*common.Price, error) {
+	log.Printf("[Binance API] Fetching FUTURE prices from %s", baseURL)
 	startTime := time.Now()
 
-	// 使用 SDK 获取 TickerPrice（轻量级，只有 symbol 和 price）
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
+	release := restLimiter.Acquire()
 	tickers, err := client.NewTickerPriceService().Do(ctx)
+	release()
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch futures tickers: %w", err)
+		return nil, fmt.Errorf("failed to fetch futures tickers: %w", classifyConnectorError(err))
 	}
 
 	duration := time.Since(startTime)
@@ -319,25 +530,49 @@ func (c *RestClient) fetchFuturesPrices() ([]*common.Price, error) {
 
 	// 转换为通用 Price 格式
 	prices := make([]*common.Price, 0, len(tickers))
+	excluded := 0
 	for _, ticker := range tickers {
+		if isExcludedSymbol(ticker.Symbol) {
+			excluded++
+			continue
+		}
 		price := convertTickerPriceToPrice(*ticker, common.MarketTypeFuture)
 		if price != nil {
 			prices = append(prices, price)
 		}
 	}
 
-	log.Printf("[Binance API] ✓ Successfully processed %d FUTURE prices", len(prices))
+	log.Printf("[Binance API] ✓ Successfully processed %d FUTURE prices (%d inverse excluded)", len(prices), excluded)
 	return prices, nil
 }
 
+// classifyConnectorError 将 SDK 返回的错误转换为分类错误（SDK 不提供HTTP状态码，按错误码分类）
+func classifyConnectorError(err error) error {
+	var apiErr *handlers.APIError
+	if !errors.As(err, &apiErr) {
+		return common.NewTemporaryError(common.ExchangeBinance, err)
+	}
+
+	kind := common.ClassifyBinanceCode(int(apiErr.Code))
+	if kind == nil {
+		kind = common.ErrTemporary
+	}
+	return &common.APIError{
+		Exchange: common.ExchangeBinance,
+		Kind:     kind,
+		Code:     int(apiErr.Code),
+		Message:  apiErr.Message,
+	}
+}
+
 // convertRestBookTickerToPrice 将 REST BookTicker 响应转换为通用 Price（推荐）
 // BookTicker 包含真实的 bid/ask 价格
 func convertRestBookTickerToPrice(ticker RestBookTickerResponse, marketType common.MarketType) *common.Price {
 	// 转换价格（REST API 返回的都是字符串）
-	bidPrice := parseFloat(ticker.BidPrice)
-	askPrice := parseFloat(ticker.AskPrice)
-	bidQty := parseFloat(ticker.BidQty)
-	askQty := parseFloat(ticker.AskQty)
+	bidPrice := numutil.ParseFloat(ticker.BidPrice)
+	askPrice := numutil.ParseFloat(ticker.AskPrice)
+	bidQty := numutil.ParseFloat(ticker.BidQty)
+	askQty := numutil.ParseFloat(ticker.AskQty)
 
 	// 如果价格为 0，跳过
 	if bidPrice == 0 || askPrice == 0 {
@@ -369,7 +604,7 @@ func convertRestBookTickerToPrice(ticker RestBookTickerResponse, marketType comm
 // 注意：这个API只返回价格，没有bid/ask，数据质量较差，应该由WebSocket更新覆盖
 func convertTickerPriceToPrice(ticker binance_connector.TickerPriceResponse, marketType common.MarketType) *common.Price {
 	// 转换价格（SDK 返回的都是字符串）
-	price := parseFloat(ticker.Price)
+	price := numutil.ParseFloat(ticker.Price)
 
 	// 如果价格为 0，跳过
 	if price == 0 {
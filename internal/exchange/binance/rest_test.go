@@ -0,0 +1,93 @@
+package binance
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newBookTickerServer 只响应 /fapi/v1/ticker/bookTicker 的测试服务器
+func newBookTickerServer(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/ticker/bookTicker" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchFuturesBookTickersParsesRealQuotes(t *testing.T) {
+	srv := newBookTickerServer(t, http.StatusOK, `[
+		{"symbol":"BTCUSDT","bidPrice":"50000.10","bidQty":"3.5","askPrice":"50000.20","askQty":"1.25","time":1700000000123},
+		{"symbol":"ETHUSDT","bidPrice":"0","bidQty":"0","askPrice":"0","askQty":"0","time":1700000000124},
+		{"symbol":"BTCUSD_PERP","bidPrice":"49990","bidQty":"100","askPrice":"49991","askQty":"100","time":1700000000125}
+	]`)
+
+	prices, err := fetchFuturesBookTickers(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 零价格和币本位合约（默认丢弃）都不返回
+	if len(prices) != 1 {
+		t.Fatalf("got %d prices, want 1: %+v", len(prices), prices)
+	}
+
+	p := prices[0]
+	if p.Symbol != "BTCUSDT" || p.Exchange != common.ExchangeBinance || p.MarketType != common.MarketTypeFuture {
+		t.Fatalf("identity = %s %s %s", p.Exchange, p.MarketType, p.Symbol)
+	}
+	if p.BidPrice != 50000.10 || p.AskPrice != 50000.20 || p.BidQty != 3.5 || p.AskQty != 1.25 {
+		t.Fatalf("quote = bid %v@%v ask %v@%v, want real bid/ask from bookTicker", p.BidPrice, p.BidQty, p.AskPrice, p.AskQty)
+	}
+	if p.Price != (p.BidPrice+p.AskPrice)/2 {
+		t.Fatalf("price = %v, want mid", p.Price)
+	}
+	if p.Timestamp.UnixMilli() != 1700000000123 {
+		t.Fatalf("timestamp = %d, want exchange time", p.Timestamp.UnixMilli())
+	}
+	if p.Source != common.PriceSourceREST {
+		t.Fatalf("source = %s, want REST", p.Source)
+	}
+}
+
+func TestFetchFuturesBookTickersKeepsInverseWhenEnabled(t *testing.T) {
+	SetExcludeInverse(false)
+	defer SetExcludeInverse(true)
+
+	srv := newBookTickerServer(t, http.StatusOK,
+		`[{"symbol":"BTCUSD_PERP","bidPrice":"49990","bidQty":"100","askPrice":"49991","askQty":"100","time":1700000000125}]`)
+	prices, err := fetchFuturesBookTickers(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || prices[0].Symbol != "BTCUSD_PERP" {
+		t.Fatalf("prices = %+v, want the inverse contract", prices)
+	}
+}
+
+func TestFetchFuturesBookTickersReportsFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"error status", http.StatusTeapot, `{"code":-1003,"msg":"banned"}`},
+		{"malformed body", http.StatusOK, `{"symbol":"BTCUSDT"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newBookTickerServer(t, tt.status, tt.body)
+			// 出错时 fetchFuturesPrices 回退到 TickerPrice
+			if _, err := fetchFuturesBookTickers(srv.URL); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
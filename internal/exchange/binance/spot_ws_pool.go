@@ -1,9 +1,14 @@
 package binance
 
 import (
+	"crypto-arbitrage-monitor/internal/faults"
+	"crypto-arbitrage-monitor/internal/wsutil"
+	"crypto-arbitrage-monitor/pkg/common"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,10 +18,15 @@ import (
 // SpotWSPool Binance 现货 WebSocket 连接池
 // 解决现货不支持 !bookTicker 全量流的问题
 type SpotWSPool struct {
-	symbols           []string                    // 所有需要订阅的 symbol
-	connections       []*SpotWSConnection         // WebSocket 连接池
-	bookTickerHandler func(*WSBookTickerData)     // BookTicker 处理器
-	symbolsPerConn    int                         // 每个连接订阅的 symbol 数量
+	symbols           []string                     // 所有需要订阅的 symbol
+	connections       []*SpotWSConnection          // WebSocket 连接池
+	bookTickerHandler func(*WSBookTickerData)      // BookTicker 处理器
+	symbolsPerConn    int                          // 每个连接订阅的 symbol 数量
+	maxConnections    int                          // 最大连接数（0表示不限制）
+	reconnectLimiter  *wsutil.ReconnectLimiter     // 池内共享的重连限速器（nil表示不限速）
+	onDemand          map[string]bool              // 按需订阅（AddSymbol）的 symbol，Reload 不会移除
+	registry          *wsutil.SubscriptionRegistry // symbol -> 连接编号，所有订阅/退订都经过登记
+	url               string                       // WebSocket 地址
 	mu                sync.RWMutex
 	done              chan struct{}
 }
@@ -33,6 +43,35 @@ type SpotWSConnection struct {
 	connectedAt       time.Time
 	lastPongTime      time.Time
 	bookTickerHandler func(*WSBookTickerData)
+	reconnectLimiter  *wsutil.ReconnectLimiter
+	onReconnect       func()               // 断线重连成功后调用（连接池对账）
+	faultPoint        *faults.Point        // 故障注入点（仅 -tags faults 构建生效）
+	nextRequestID     int64                // 订阅请求ID，每个连接内单调递增（重连后继续递增）
+	pendingAcks       map[int64]pendingAck // 已发送但未收到确认的订阅请求
+	writeMu           sync.Mutex           // 串行化写操作（运行中追加订阅可能与 PONG 并发）
+}
+
+// subscribeAckTimeout 订阅请求超过该时长未确认视为丢失
+const subscribeAckTimeout = 10 * time.Second
+
+// defaultSpotPoolURL 默认现货 WebSocket 地址
+const defaultSpotPoolURL = "wss://stream.binance.com:9443/ws"
+
+// pendingAck 待确认的订阅/退订请求
+type pendingAck struct {
+	method  string // SUBSCRIBE / UNSUBSCRIBE
+	sentAt  time.Time
+	streams int
+}
+
+// subscribeResponse 订阅请求的响应 {"result":null,"id":1} 或 {"error":{...},"id":1}
+type subscribeResponse struct {
+	Result json.RawMessage `json:"result"`
+	ID     *int64          `json:"id"`
+	Error  *struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"error"`
 }
 
 // NewSpotWSPool 创建现货 WebSocket 连接池
@@ -45,10 +84,23 @@ func NewSpotWSPool(symbols []string, symbolsPerConn int) *SpotWSPool {
 		symbols:        symbols,
 		connections:    make([]*SpotWSConnection, 0),
 		symbolsPerConn: symbolsPerConn,
+		onDemand:       make(map[string]bool),
+		registry:       wsutil.NewSubscriptionRegistry(string(common.ExchangeBinance)),
+		url:            defaultSpotPoolURL,
 		done:           make(chan struct{}),
 	}
 }
 
+// spotMarketType 订阅登记中现货的市场类型
+var spotMarketType = string(common.MarketTypeSpot)
+
+// SetSubscriptionRegistry 使用共享的订阅登记（例如 /api/connections 在连接池启动前就需要引用），需要在 Start 之前调用
+func (p *SpotWSPool) SetSubscriptionRegistry(registry *wsutil.SubscriptionRegistry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.registry = registry
+}
+
 // SetBookTickerHandler 设置 BookTicker 处理器
 func (p *SpotWSPool) SetBookTickerHandler(handler func(*WSBookTickerData)) {
 	p.mu.Lock()
@@ -56,40 +108,306 @@ func (p *SpotWSPool) SetBookTickerHandler(handler func(*WSBookTickerData)) {
 	p.bookTickerHandler = handler
 }
 
+// SetMaxConnections 设置最大连接数，超出时自动增大每个连接订阅的 symbol 数量
+func (p *SpotWSPool) SetMaxConnections(maxConnections int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxConnections = maxConnections
+}
+
+// SetReconnectRate 设置池内重连限速：每秒最多 rate 次重连，最多 burst 个连接可以立即重连（rate <= 0 表示不限速）
+// 需要在 Start 之前调用
+func (p *SpotWSPool) SetReconnectRate(rate float64, burst int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reconnectLimiter = wsutil.NewReconnectLimiter(rate, burst)
+}
+
 // Start 启动连接池
 func (p *SpotWSPool) Start() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// 计算需要的连接数
-	numConnections := (len(p.symbols) + p.symbolsPerConn - 1) / p.symbolsPerConn
+	// 去重，同一个 symbol 只分配到一个连接
+	unique := make([]string, 0, len(p.symbols))
+	seen := make(map[string]bool, len(p.symbols))
+	for _, symbol := range p.symbols {
+		symbol = strings.ToUpper(symbol)
+		if !seen[symbol] {
+			seen[symbol] = true
+			unique = append(unique, symbol)
+		}
+	}
+	if dropped := len(p.symbols) - len(unique); dropped > 0 {
+		log.Printf("[Binance Spot Pool] Dropped %d duplicate symbols", dropped)
+	}
+	p.symbols = unique
+
+	// 计算需要的连接数，超过上限时增大每个连接的 symbol 数量（Binance 限制单IP连接数，超限可能被封禁）
+	adjusted, numConnections := wsutil.PlanConnections(len(p.symbols), p.symbolsPerConn, p.maxConnections)
+	if adjusted != p.symbolsPerConn {
+		log.Printf("[Binance Spot Pool] Connections capped at %d, raising symbols/conn from %d to %d",
+			p.maxConnections, p.symbolsPerConn, adjusted)
+		p.symbolsPerConn = adjusted
+	}
 	log.Printf("[Binance Spot Pool] Starting %d WebSocket connections for %d symbols (%d symbols/conn)",
 		numConnections, len(p.symbols), p.symbolsPerConn)
 
-	// 创建连接
+	// 创建连接，启动失败的连接上的 symbol 不算已订阅（之后的 Reload 会重新追加）
+	allSymbols := p.symbols
+	p.symbols = make([]string, 0, len(allSymbols))
 	for i := 0; i < numConnections; i++ {
 		startIdx := i * p.symbolsPerConn
 		endIdx := startIdx + p.symbolsPerConn
-		if endIdx > len(p.symbols) {
-			endIdx = len(p.symbols)
+		if endIdx > len(allSymbols) {
+			endIdx = len(allSymbols)
 		}
 
-		symbols := p.symbols[startIdx:endIdx]
-		conn := NewSpotWSConnection(i, symbols)
-		conn.SetBookTickerHandler(p.bookTickerHandler)
+		symbols := allSymbols[startIdx:endIdx]
+		conn := p.newConnectionLocked(i, symbols)
 
 		if err := conn.Connect(); err != nil {
 			log.Printf("[Binance Spot Pool] Failed to start connection #%d: %v", i, err)
 			continue
 		}
 
+		for _, symbol := range symbols {
+			p.registry.Claim(spotMarketType, symbol, conn.ID, wsutil.SubscriptionSourceStart)
+		}
 		p.connections = append(p.connections, conn)
+		p.symbols = append(p.symbols, symbols...)
 	}
 
 	log.Printf("[Binance Spot Pool] Successfully started %d/%d connections", len(p.connections), numConnections)
 	return nil
 }
 
+// newConnectionLocked 创建使用连接池配置的连接（调用者需要持有锁）
+func (p *SpotWSPool) newConnectionLocked(id int, symbols []string) *SpotWSConnection {
+	conn := NewSpotWSConnection(id, symbols)
+	conn.URL = p.url
+	conn.SetBookTickerHandler(p.bookTickerHandler)
+	conn.reconnectLimiter = p.reconnectLimiter
+	conn.onReconnect = func() { p.Reconcile() }
+	return conn
+}
+
+// AddSymbol 运行中按需追加订阅一个 symbol，按需订阅的 symbol 之后不会被 Reload 移除
+// symbol 已经订阅时返回 *wsutil.SubscriptionConflictError（包含所在的连接编号）
+func (p *SpotWSPool) AddSymbol(symbol string) error {
+	symbol = strings.ToUpper(symbol)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if connID, exists := p.registry.Lookup(spotMarketType, symbol); exists {
+		return &wsutil.SubscriptionConflictError{
+			Exchange:   p.registry.Exchange(),
+			MarketType: spotMarketType,
+			Symbol:     symbol,
+			ConnID:     connID,
+		}
+	}
+
+	if err := p.addSymbolLocked(symbol, wsutil.SubscriptionSourceOnDemand); err != nil {
+		return err
+	}
+	p.onDemand[symbol] = true
+	return nil
+}
+
+// EnsureSymbol 确保 symbol 已订阅（幂等）：已订阅时不做任何操作，返回是否新增
+// 用于刷新等不关心 symbol 原来是否已订阅的调用方
+func (p *SpotWSPool) EnsureSymbol(symbol string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ensureSymbolLocked(strings.ToUpper(symbol), wsutil.SubscriptionSourceReload)
+}
+
+// ensureSymbolLocked 确保 symbol 已订阅（调用者需要持有锁）
+func (p *SpotWSPool) ensureSymbolLocked(symbol, source string) (bool, error) {
+	if _, exists := p.registry.Lookup(spotMarketType, symbol); exists {
+		return false, nil
+	}
+	if err := p.addSymbolLocked(symbol, source); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reload 将订阅的 symbol 列表更新为 symbols（例如交易对列表刷新后），不重建连接池：
+// 新增的 symbol 追加到现有连接或新连接，移除的 symbol 在所在连接上退订，连接上的 symbol 全部移除后关闭该连接，
+// 其余 symbol 的订阅保持不变。按需订阅（AddSymbol）的 symbol 不会被移除
+// 返回新增和移除的 symbol 数；部分 symbol 追加失败时返回错误，其余变更仍然生效
+func (p *SpotWSPool) Reload(symbols []string) (added, removed int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[strings.ToUpper(symbol)] = true
+	}
+
+	// 先移除，空出的容量可以用于新增的 symbol
+	kept := make([]string, 0, len(p.symbols))
+	removedSymbols := make(map[string]bool)
+	for _, symbol := range p.symbols {
+		if wanted[symbol] || p.onDemand[symbol] {
+			kept = append(kept, symbol)
+			continue
+		}
+		removedSymbols[symbol] = true
+	}
+	if len(removedSymbols) > 0 {
+		p.removeSymbolsLocked(removedSymbols)
+		p.symbols = kept
+		removed = len(removedSymbols)
+	}
+
+	var errs []error
+	for _, symbol := range symbols {
+		ok, err := p.ensureSymbolLocked(strings.ToUpper(symbol), wsutil.SubscriptionSourceReload)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			added++
+		}
+	}
+
+	log.Printf("[Binance Spot Pool] Reloaded symbols: +%d -%d (%d symbols on %d connections)", added, removed, len(p.symbols), len(p.connections))
+	return added, removed, errors.Join(errs...)
+}
+
+// addSymbolLocked 追加订阅一个 symbol（调用者需要持有锁，且 symbol 尚未订阅）
+// 追加到订阅数最少的连接；所有连接都已满且未达到最大连接数时新建一个连接
+// 订阅前先在登记中占用 symbol，订阅失败时释放
+func (p *SpotWSPool) addSymbolLocked(symbol, source string) error {
+	var target *SpotWSConnection
+	for _, conn := range p.connections {
+		if target == nil || conn.symbolCount() < target.symbolCount() {
+			target = conn
+		}
+	}
+
+	canGrow := p.maxConnections <= 0 || len(p.connections) < p.maxConnections
+	if target == nil || (target.symbolCount() >= p.symbolsPerConn && canGrow) {
+		conn := p.newConnectionLocked(p.nextConnectionID(), []string{symbol})
+		if err := p.registry.Claim(spotMarketType, symbol, conn.ID, source); err != nil {
+			return err
+		}
+		if err := conn.Connect(); err != nil {
+			p.registry.Release(spotMarketType, symbol, conn.ID)
+			return fmt.Errorf("failed to start connection for %s: %w", symbol, err)
+		}
+		p.connections = append(p.connections, conn)
+		p.symbols = append(p.symbols, symbol)
+		log.Printf("[Binance Spot Pool] Started connection #%d for %s", conn.ID, symbol)
+		return nil
+	}
+
+	if err := p.registry.Claim(spotMarketType, symbol, target.ID, source); err != nil {
+		return err
+	}
+	if err := target.addSymbol(symbol); err != nil {
+		p.registry.Release(spotMarketType, symbol, target.ID)
+		return err
+	}
+	p.symbols = append(p.symbols, symbol)
+	return nil
+}
+
+// removeSymbolsLocked 在所在连接上退订 symbols 并取消登记，连接上的 symbol 全部移除后关闭该连接（调用者需要持有锁）
+// 只修改连接，p.symbols 由调用者更新
+func (p *SpotWSPool) removeSymbolsLocked(symbols map[string]bool) {
+	connections := make([]*SpotWSConnection, 0, len(p.connections))
+	for _, conn := range p.connections {
+		removed := conn.removeSymbols(symbols)
+		for _, symbol := range removed {
+			p.registry.Release(spotMarketType, symbol, conn.ID)
+		}
+		if len(removed) > 0 && conn.symbolCount() == 0 {
+			conn.Close()
+			p.registry.ReleaseConn(conn.ID)
+			log.Printf("[Binance Spot Pool] Closed connection #%d (no symbols left)", conn.ID)
+			continue
+		}
+		connections = append(connections, conn)
+	}
+	p.connections = connections
+}
+
+// Reconcile 对账：按各连接实际订阅的 symbol 修正登记，并退订同一个 symbol 在多个连接上的重复订阅
+// 断线重连成功后自动执行，返回退订的重复订阅数
+func (p *SpotWSPool) Reconcile() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	actual := make([]wsutil.Subscription, 0, len(p.symbols))
+	for _, conn := range p.connections {
+		conn.mu.RLock()
+		for _, symbol := range conn.Symbols {
+			actual = append(actual, wsutil.Subscription{MarketType: spotMarketType, Symbol: symbol, ConnID: conn.ID})
+		}
+		conn.mu.RUnlock()
+	}
+
+	duplicates := p.registry.Reconcile(actual)
+	for _, dup := range duplicates {
+		for _, conn := range p.connections {
+			if conn.ID == dup.ConnID {
+				conn.removeSymbols(map[string]bool{dup.Symbol: true})
+				log.Printf("[Binance Spot Pool] Removed duplicate %s from connection #%d", dup.Symbol, conn.ID)
+			}
+		}
+	}
+
+	// 重复订阅移除后关闭空连接，并按连接重建 symbol 列表
+	connections := make([]*SpotWSConnection, 0, len(p.connections))
+	symbols := make([]string, 0, len(p.symbols))
+	for _, conn := range p.connections {
+		if len(duplicates) > 0 && conn.symbolCount() == 0 {
+			conn.Close()
+			p.registry.ReleaseConn(conn.ID)
+			log.Printf("[Binance Spot Pool] Closed connection #%d (no symbols left)", conn.ID)
+			continue
+		}
+		conn.mu.RLock()
+		symbols = append(symbols, conn.Symbols...)
+		conn.mu.RUnlock()
+		connections = append(connections, conn)
+	}
+	p.connections = connections
+	p.symbols = symbols
+	return len(duplicates)
+}
+
+// Registry 连接池的订阅登记
+func (p *SpotWSPool) Registry() *wsutil.SubscriptionRegistry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.registry
+}
+
+// SymbolCount 当前订阅的 symbol 数量
+func (p *SpotWSPool) SymbolCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.symbols)
+}
+
+// nextConnectionID 新连接的编号（调用者需要持有锁）
+func (p *SpotWSPool) nextConnectionID() int {
+	id := 0
+	for _, conn := range p.connections {
+		if conn.ID >= id {
+			id = conn.ID + 1
+		}
+	}
+	return id
+}
+
 // Close 关闭所有连接
 func (p *SpotWSPool) Close() {
 	close(p.done)
@@ -99,17 +417,30 @@ func (p *SpotWSPool) Close() {
 
 	for _, conn := range p.connections {
 		conn.Close()
+		p.registry.ReleaseConn(conn.ID)
 	}
 }
 
 // NewSpotWSConnection 创建单个 WebSocket 连接
 func NewSpotWSConnection(id int, symbols []string) *SpotWSConnection {
-	return &SpotWSConnection{
-		ID:        id,
-		URL:       "wss://stream.binance.com:9443/ws",
-		Symbols:   symbols,
-		reconnect: true,
-		done:      make(chan struct{}),
+	c := &SpotWSConnection{
+		ID:          id,
+		URL:         defaultSpotPoolURL,
+		Symbols:     symbols,
+		reconnect:   true,
+		done:        make(chan struct{}),
+		pendingAcks: make(map[int64]pendingAck),
+	}
+	c.faultPoint = faults.NewPoint(fmt.Sprintf("binance-spot-pool#%d", id), c.closeConn)
+	return c
+}
+
+// closeConn 关闭当前底层连接（不关闭 SpotWSConnection，读循环退出后正常重连）
+func (c *SpotWSConnection) closeConn() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Conn != nil {
+		c.Conn.Close()
 	}
 }
 
@@ -122,7 +453,7 @@ func (c *SpotWSConnection) SetBookTickerHandler(handler func(*WSBookTickerData))
 
 // Connect 连接到 WebSocket
 func (c *SpotWSConnection) Connect() error {
-	conn, _, err := websocket.DefaultDialer.Dial(c.URL, nil)
+	conn, _, err := newWSDialer().Dial(c.URL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -144,18 +475,18 @@ func (c *SpotWSConnection) Connect() error {
 		return nil
 	})
 
-	// 订阅 symbol
-	if err := c.subscribe(); err != nil {
-		return fmt.Errorf("failed to subscribe: %w", err)
-	}
-
-	// 启动消息读取
+	// 先启动消息读取再订阅，订阅确认按请求ID匹配发送前登记的记录
 	go c.readMessages()
 
 	// 启动心跳和重连检查
 	go c.keepAlive()
 	go c.check24HourReconnect()
 
+	// 订阅 symbol
+	if err := c.subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+
 	return nil
 }
 
@@ -163,13 +494,67 @@ func (c *SpotWSConnection) Connect() error {
 func (c *SpotWSConnection) subscribe() error {
 	c.mu.RLock()
 	symbols := c.Symbols
-	conn := c.Conn
 	c.mu.RUnlock()
+	return c.subscribeSymbols(symbols)
+}
 
-	if conn == nil {
-		return fmt.Errorf("connection not established")
+// symbolCount 当前连接订阅的 symbol 数量
+func (c *SpotWSConnection) symbolCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.Symbols)
+}
+
+// addSymbol 在已建立的连接上追加订阅一个 symbol（重连时随 Symbols 一起重新订阅）
+func (c *SpotWSConnection) addSymbol(symbol string) error {
+	if err := c.subscribeSymbols([]string{symbol}); err != nil {
+		return err
 	}
 
+	c.mu.Lock()
+	// Symbols 可能是连接池 symbol 列表的子切片，复制后再追加，避免覆盖相邻连接的 symbol
+	c.Symbols = append(c.Symbols[:len(c.Symbols):len(c.Symbols)], symbol)
+	c.mu.Unlock()
+
+	log.Printf("[Binance Spot #%d] Added %s (%d symbols)", c.ID, symbol, c.symbolCount())
+	return nil
+}
+
+// removeSymbols 在该连接上退订 symbols 中的 symbol，返回移除的 symbol
+// 退订请求发送失败不影响移除（断线重连时只会重新订阅剩余的 Symbols）
+func (c *SpotWSConnection) removeSymbols(symbols map[string]bool) []string {
+	c.mu.Lock()
+	remaining := make([]string, 0, len(c.Symbols))
+	removed := make([]string, 0)
+	for _, symbol := range c.Symbols {
+		if symbols[symbol] {
+			removed = append(removed, symbol)
+			continue
+		}
+		remaining = append(remaining, symbol)
+	}
+	// 复制而不是原地过滤：Symbols 可能是连接池 symbol 列表的子切片
+	c.Symbols = remaining
+	c.mu.Unlock()
+
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := c.sendStreamRequest("UNSUBSCRIBE", removed); err != nil {
+		log.Printf("[Binance Spot #%d] Failed to unsubscribe %d symbols: %v", c.ID, len(removed), err)
+	}
+	log.Printf("[Binance Spot #%d] Removed %d symbols (%d symbols left)", c.ID, len(removed), len(remaining))
+	return removed
+}
+
+// subscribeSymbols 发送 bookTicker 订阅请求
+func (c *SpotWSConnection) subscribeSymbols(symbols []string) error {
+	return c.sendStreamRequest("SUBSCRIBE", symbols)
+}
+
+// sendStreamRequest 发送 bookTicker 订阅（SUBSCRIBE）或退订（UNSUBSCRIBE）请求
+// 待确认记录在发送前登记（确认可能在 WriteJSON 返回前就被读循环处理），发送失败时撤销
+func (c *SpotWSConnection) sendStreamRequest(method string, symbols []string) error {
 	// 构建订阅流列表：symbol1@bookTicker, symbol2@bookTicker, ...
 	streams := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
@@ -178,18 +563,35 @@ func (c *SpotWSConnection) subscribe() error {
 		streams = append(streams, stream)
 	}
 
+	c.mu.Lock()
+	conn := c.Conn
+	if conn == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("connection not established")
+	}
+	c.nextRequestID++
+	requestID := c.nextRequestID
+	c.pendingAcks[requestID] = pendingAck{method: method, sentAt: time.Now(), streams: len(streams)}
+	c.mu.Unlock()
+
 	// 发送订阅消息
 	msg := map[string]interface{}{
-		"method": "SUBSCRIBE",
+		"method": method,
 		"params": streams,
-		"id":     c.ID,
+		"id":     requestID,
 	}
 
-	if err := conn.WriteJSON(msg); err != nil {
-		return fmt.Errorf("failed to send subscribe message: %w", err)
+	c.writeMu.Lock()
+	err := conn.WriteJSON(msg)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pendingAcks, requestID)
+		c.mu.Unlock()
+		return fmt.Errorf("failed to send %s message: %w", method, err)
 	}
 
-	log.Printf("[Binance Spot #%d] Subscribed to %d bookTicker streams", c.ID, len(streams))
+	log.Printf("[Binance Spot #%d] Sent %s for %d bookTicker streams (request id %d)", c.ID, method, len(streams), requestID)
 	return nil
 }
 
@@ -206,12 +608,17 @@ func (c *SpotWSConnection) readMessages() {
 		}
 		c.mu.Unlock()
 
-		// 重连
+		// 重连（等待5秒后再按池内限速排队，避免所有连接同时重连）
 		if c.reconnect {
-			log.Printf("[Binance Spot #%d] Reconnecting in 5 seconds...", c.ID)
+			common.DedupLog.Printf("binance-spot-pool", "[Binance Spot #%d] Reconnecting in 5 seconds...", c.ID)
 			time.Sleep(5 * time.Second)
+			if !c.reconnectLimiter.Wait(c.done) {
+				return
+			}
 			if err := c.Connect(); err != nil {
-				log.Printf("[Binance Spot #%d] Failed to reconnect: %v", c.ID, err)
+				common.DedupLog.Printf("binance-spot-pool", "[Binance Spot #%d] Failed to reconnect: %v", c.ID, err)
+			} else if c.onReconnect != nil {
+				c.onReconnect()
 			}
 		}
 	}()
@@ -246,11 +653,17 @@ func (c *SpotWSConnection) readMessages() {
 				conn := c.Conn
 				c.mu.RUnlock()
 				if conn != nil {
+					c.writeMu.Lock()
 					conn.WriteMessage(websocket.PongMessage, message)
+					c.writeMu.Unlock()
 				}
 				continue
 			}
 
+			if !c.faultPoint.Filter() {
+				continue
+			}
+
 			messageCount++
 			c.processMessage(message)
 		}
@@ -288,7 +701,49 @@ func (c *SpotWSConnection) processMessage(message []byte) {
 		}
 	}
 
-	// 忽略订阅确认等其他消息
+	// 订阅确认
+	var resp subscribeResponse
+	if err := json.Unmarshal(message, &resp); err == nil && resp.ID != nil {
+		c.handleSubscribeResponse(&resp)
+		return
+	}
+
+	// 忽略其他消息
+}
+
+// handleSubscribeResponse 处理订阅确认，按请求ID匹配待确认记录
+func (c *SpotWSConnection) handleSubscribeResponse(resp *subscribeResponse) {
+	c.mu.Lock()
+	ack, exists := c.pendingAcks[*resp.ID]
+	delete(c.pendingAcks, *resp.ID)
+	c.mu.Unlock()
+
+	if !exists {
+		log.Printf("[Binance Spot #%d] Received response for unknown request id %d", c.ID, *resp.ID)
+		return
+	}
+
+	if resp.Error != nil {
+		log.Printf("[Binance Spot #%d] ✗ %s request %d rejected (%d streams): code=%d msg=%s",
+			c.ID, ack.method, *resp.ID, ack.streams, resp.Error.Code, resp.Error.Msg)
+		return
+	}
+
+	log.Printf("[Binance Spot #%d] ✓ %s request %d confirmed in %dms", c.ID, ack.method, *resp.ID, time.Since(ack.sentAt).Milliseconds())
+}
+
+// checkPendingAcks 检查超时未确认的订阅请求（视为订阅丢失）
+func (c *SpotWSConnection) checkPendingAcks() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, ack := range c.pendingAcks {
+		if time.Since(ack.sentAt) > subscribeAckTimeout {
+			log.Printf("[Binance Spot #%d] ✗ No ack for %s request %d (%d streams) after %.0fs, subscription may be dropped",
+				c.ID, ack.method, id, ack.streams, time.Since(ack.sentAt).Seconds())
+			delete(c.pendingAcks, id)
+		}
+	}
 }
 
 // keepAlive 心跳检查
@@ -308,6 +763,8 @@ func (c *SpotWSConnection) keepAlive() {
 			if time.Since(lastPong) > 90*time.Second {
 				log.Printf("[Binance Spot #%d] No PONG for %.0fs, connection may be dead", c.ID, time.Since(lastPong).Seconds())
 			}
+
+			c.checkPendingAcks()
 		}
 	}
 }
@@ -343,6 +800,7 @@ func (c *SpotWSConnection) check24HourReconnect() {
 func (c *SpotWSConnection) Close() {
 	c.reconnect = false
 	close(c.done)
+	c.faultPoint.Release()
 
 	c.mu.Lock()
 	if c.Conn != nil {
@@ -0,0 +1,246 @@
+package binance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeStreamServer 模拟 Binance 行情 WebSocket：记录请求ID和订阅/退订的流并立即回复确认
+type fakeStreamServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	ids      []int64
+	requests []streamRequest
+}
+
+// streamRequest 服务端收到的订阅/退订请求
+type streamRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int64    `json:"id"`
+}
+
+func newFakeStreamServer(t *testing.T) *fakeStreamServer {
+	t.Helper()
+	s := &fakeStreamServer{}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var req streamRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.ids = append(s.ids, req.ID)
+			s.requests = append(s.requests, req)
+			s.mu.Unlock()
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"result":null,"id":%d}`, req.ID))); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeStreamServer) requestIDs() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.ids...)
+}
+
+// streamCounts 各个流被订阅（SUBSCRIBE）和退订（UNSUBSCRIBE）的次数
+func (s *fakeStreamServer) streamCounts() (subscribed, unsubscribed map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscribed, unsubscribed = make(map[string]int), make(map[string]int)
+	for _, req := range s.requests {
+		counts := subscribed
+		if req.Method == "UNSUBSCRIBE" {
+			counts = unsubscribed
+		}
+		for _, stream := range req.Params {
+			counts[stream]++
+		}
+	}
+	return subscribed, unsubscribed
+}
+
+func (c *SpotWSConnection) pendingAckCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.pendingAcks)
+}
+
+func TestSpotWSConnectionRequestIDsAndAcks(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	conn := NewSpotWSConnection(0, []string{"BTCUSDT", "ETHUSDT"})
+	conn.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+	if err := conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// 确认立即返回，与发送并发处理
+	const added = 30
+	for i := 0; i < added; i++ {
+		if err := conn.addSymbol(fmt.Sprintf("S%dUSDT", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.removeSymbols(map[string]bool{"BTCUSDT": true})
+
+	const total = added + 2
+	deadline := time.Now().Add(3 * time.Second)
+	for len(server.requestIDs()) < total || conn.pendingAckCount() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("requests=%d pending acks=%d, want %d requests all acked", len(server.requestIDs()), conn.pendingAckCount(), total)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i, id := range server.requestIDs() {
+		if id != int64(i+1) {
+			t.Fatalf("request %d has id %d, want %d (ids %v)", i, id, i+1, server.requestIDs())
+		}
+	}
+}
+
+func TestSpotWSConnectionAckBeforeWriteReturns(t *testing.T) {
+	server := newFakeStreamServer(t)
+
+	conn := NewSpotWSConnection(0, nil)
+	conn.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+	if err := conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// 持有写锁让请求停在发送前，此时处理确认（模拟确认先于 WriteJSON 返回被读循环处理）
+	conn.writeMu.Lock()
+	done := make(chan error, 1)
+	go func() { done <- conn.subscribeSymbols([]string{"BTCUSDT"}) }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn.mu.RLock()
+		id := conn.nextRequestID
+		conn.mu.RUnlock()
+		if id == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			conn.writeMu.Unlock()
+			t.Fatalf("request id = %d, want 2", id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	id := int64(2)
+	conn.handleSubscribeResponse(&subscribeResponse{ID: &id})
+	conn.writeMu.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	conn.mu.RLock()
+	_, pending := conn.pendingAcks[2]
+	conn.mu.RUnlock()
+	if pending {
+		t.Fatal("request 2 still pending after its ack was handled")
+	}
+}
+
+func TestSpotWSConnectionSendFailureDropsPendingAck(t *testing.T) {
+	conn := NewSpotWSConnection(0, nil)
+	if err := conn.subscribeSymbols([]string{"BTCUSDT"}); err == nil {
+		t.Fatal("subscribe without a connection succeeded")
+	}
+	if n := conn.pendingAckCount(); n != 0 {
+		t.Fatalf("pending acks = %d after failed send, want 0", n)
+	}
+}
+
+// poolSubscriptions 连接池登记的 symbol -> 连接编号
+func poolSubscriptions(p *SpotWSPool) map[string]int {
+	result := make(map[string]int)
+	for _, sub := range p.Registry().Snapshot() {
+		result[sub.Symbol] = sub.ConnID
+	}
+	return result
+}
+
+func TestSpotWSPoolReload(t *testing.T) {
+	server := newFakeStreamServer(t)
+	pool := NewSpotWSPool([]string{"AUSDT", "BUSDT", "CUSDT", "DUSDT"}, 2)
+	pool.url = "ws" + strings.TrimPrefix(server.URL, "http")
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// 按需订阅的 symbol 不在列表中，Reload 不会移除；两个连接都已满，新建连接 #2
+	if err := pool.AddSymbol("XUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"AUSDT": 0, "BUSDT": 0, "CUSDT": 1, "DUSDT": 1, "XUSDT": 2}
+	if got := poolSubscriptions(pool); !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscriptions after start = %v, want %v", got, want)
+	}
+
+	// 移除 A、D，保留 B、C 的订阅，新增的 E 追加到有空位的连接 #0
+	added, removed, err := pool.Reload([]string{"busdt", "CUSDT", "EUSDT"})
+	if err != nil || added != 1 || removed != 2 {
+		t.Fatalf("reload = +%d -%d (err %v), want +1 -2", added, removed, err)
+	}
+	want = map[string]int{"BUSDT": 0, "EUSDT": 0, "CUSDT": 1, "XUSDT": 2}
+	if got := poolSubscriptions(pool); !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscriptions after reload = %v, want %v", got, want)
+	}
+	if n := pool.SymbolCount(); n != 4 {
+		t.Fatalf("symbol count = %d, want 4", n)
+	}
+
+	// 连接 #1 上的 symbol 全部移除后关闭该连接
+	if added, removed, err := pool.Reload([]string{"EUSDT"}); err != nil || added != 0 || removed != 2 {
+		t.Fatalf("second reload = +%d -%d (err %v), want +0 -2", added, removed, err)
+	}
+	want = map[string]int{"EUSDT": 0, "XUSDT": 2}
+	if got := poolSubscriptions(pool); !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscriptions after second reload = %v, want %v", got, want)
+	}
+	pool.mu.RLock()
+	connections := len(pool.connections)
+	pool.mu.RUnlock()
+	if connections != 2 {
+		t.Fatalf("%d connections, want 2 (emptied connection closed)", connections)
+	}
+
+	// 未变化的 symbol 只订阅一次，移除的 symbol 各退订一次（连接 #1 关闭前退订 C）
+	wantSubscribed := map[string]int{"ausdt@bookTicker": 1, "busdt@bookTicker": 1, "cusdt@bookTicker": 1, "dusdt@bookTicker": 1, "xusdt@bookTicker": 1, "eusdt@bookTicker": 1}
+	wantUnsubscribed := map[string]int{"ausdt@bookTicker": 1, "dusdt@bookTicker": 1, "busdt@bookTicker": 1, "cusdt@bookTicker": 1}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		subscribed, unsubscribed := server.streamCounts()
+		if reflect.DeepEqual(subscribed, wantSubscribed) && reflect.DeepEqual(unsubscribed, wantUnsubscribed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("streams subscribed %v, unsubscribed %v; want %v and %v", subscribed, unsubscribed, wantSubscribed, wantUnsubscribed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
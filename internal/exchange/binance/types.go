@@ -2,6 +2,7 @@ package binance
 
 import (
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 	"time"
 )
@@ -77,10 +78,10 @@ type SymbolInfo struct {
 
 // ConvertWSBookTickerToPrice 将 WebSocket BookTicker 转换为通用 Price（推荐使用）
 func ConvertWSBookTickerToPrice(ticker *WSBookTickerData, exchange common.Exchange, marketType common.MarketType) *common.Price {
-	bidPrice := parseFloat(ticker.BidPrice)
-	askPrice := parseFloat(ticker.AskPrice)
-	bidQty := parseFloat(ticker.BidQty)
-	askQty := parseFloat(ticker.AskQty)
+	bidPrice := numutil.ParseFloat(ticker.BidPrice)
+	askPrice := numutil.ParseFloat(ticker.AskPrice)
+	bidQty := numutil.ParseFloat(ticker.BidQty)
+	askQty := numutil.ParseFloat(ticker.AskQty)
 
 	// 计算中间价
 	midPrice := (bidPrice + askPrice) / 2
@@ -88,9 +89,9 @@ func ConvertWSBookTickerToPrice(ticker *WSBookTickerData, exchange common.Exchan
 	// 确定交易所时间戳（期货优先用TxnTime撮合时间，否则用EventTime事件时间）
 	var exchangeTimestamp time.Time
 	if ticker.TxnTime > 0 {
-		exchangeTimestamp = time.UnixMilli(ticker.TxnTime)
+		exchangeTimestamp = common.UnixMilliUTC(ticker.TxnTime)
 	} else if ticker.EventTime > 0 {
-		exchangeTimestamp = time.UnixMilli(ticker.EventTime)
+		exchangeTimestamp = common.UnixMilliUTC(ticker.EventTime)
 	} else {
 		exchangeTimestamp = time.Now() // fallback
 	}
@@ -114,8 +115,8 @@ func ConvertWSBookTickerToPrice(ticker *WSBookTickerData, exchange common.Exchan
 // ConvertWSMiniTickerToPrice 将 WebSocket MiniTicker 转换为通用 Price（不推荐，仅用于成交量）
 // 注意：MiniTicker只有last trade price，没有真实的bid/ask，会导致系统误差
 func ConvertWSMiniTickerToPrice(ticker *WSMiniTickerData, exchange common.Exchange, marketType common.MarketType) *common.Price {
-	price := parseFloat(ticker.LastPrice)
-	quoteVolume := parseFloat(ticker.QuoteVolume)
+	price := numutil.ParseFloat(ticker.LastPrice)
+	quoteVolume := numutil.ParseFloat(ticker.QuoteVolume)
 
 	return &common.Price{
 		Symbol:      ticker.Symbol,
@@ -127,8 +128,9 @@ func ConvertWSMiniTickerToPrice(ticker *WSMiniTickerData, exchange common.Exchan
 		BidQty:      0,
 		AskQty:      0,
 		Volume24h:   quoteVolume,
-		Timestamp:   time.UnixMilli(ticker.EventTime), // 使用交易所时间
-		LastUpdated: time.Now(),                       // 本地接收时间
+		VolumeKnown: true,
+		Timestamp:   common.UnixMilliUTC(ticker.EventTime), // 使用交易所时间
+		LastUpdated: time.Now(),                            // 本地接收时间
 		Source:      common.PriceSourceWebSocket,
 	}
 }
@@ -0,0 +1,25 @@
+package binance
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+)
+
+func TestConversionsMarkVolumeKnown(t *testing.T) {
+	// bookTicker 没有成交量字段：成交量未知，不能按 0 成交量过滤
+	book := ConvertWSBookTickerToPrice(&WSBookTickerData{
+		EventTime: 1700000000000, Symbol: "BTCUSDT",
+		BidPrice: "50000", BidQty: "1", AskPrice: "50001", AskQty: "2",
+	}, common.ExchangeBinance, common.MarketTypeFuture)
+	if book == nil || book.VolumeKnown || book.Volume24h != 0 {
+		t.Fatalf("bookTicker price = %+v, want unknown volume", book)
+	}
+
+	// miniTicker 带24h成交额
+	mini := ConvertWSMiniTickerToPrice(&WSMiniTickerData{
+		EventTime: 1700000000000, Symbol: "BTCUSDT", LastPrice: "50000", QuoteVolume: "1250000",
+	}, common.ExchangeBinance, common.MarketTypeFuture)
+	if mini == nil || !mini.VolumeKnown || mini.Volume24h != 1250000 {
+		t.Fatalf("miniTicker price = %+v, want known volume 1250000", mini)
+	}
+}
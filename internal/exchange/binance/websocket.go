@@ -1,11 +1,11 @@
 package binance
 
 import (
+	"crypto-arbitrage-monitor/internal/faults"
 	"crypto-arbitrage-monitor/pkg/common"
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,17 +27,71 @@ type WSClient struct {
 	connectedAt        time.Time
 	lastPongTime       time.Time
 	subscriptionID     int
+	lagMonitor         *LagMonitor
+	shedWatchlist      map[string]bool // 非空时启用丢弃模式：持续滞后期间只处理这些symbol
+	faultPoint         *faults.Point   // 故障注入点（仅 -tags faults 构建生效）
 }
 
 // NewWSClient 创建新的 WebSocket 客户端
 func NewWSClient(url string, marketType common.MarketType) *WSClient {
-	return &WSClient{
+	w := &WSClient{
 		URL:           url,
 		MarketType:    marketType,
 		subscriptions: make(map[string]bool),
 		reconnect:     true,
 		done:          make(chan struct{}),
+		lagMonitor:    NewLagMonitor(string(marketType), 0, 0),
 	}
+	w.faultPoint = faults.NewPoint("binance-ws-"+string(marketType), w.closeConn)
+	return w
+}
+
+// closeConn 关闭当前底层连接（不关闭 WSClient，读循环退出后正常重连）
+func (w *WSClient) closeConn() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Conn != nil {
+		w.Conn.Close()
+	}
+}
+
+// SetShedWatchlist 启用丢弃模式：持续滞后期间，不在列表中的symbol在JSON解析前直接丢弃
+// 传入空列表表示关闭丢弃模式
+func (w *WSClient) SetShedWatchlist(symbols []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(symbols) == 0 {
+		w.shedWatchlist = nil
+		return
+	}
+
+	w.shedWatchlist = make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		w.shedWatchlist[strings.ToUpper(strings.TrimSpace(symbol))] = true
+	}
+}
+
+// Status 获取连接的处理延迟状态
+func (w *WSClient) Status() LagStatus {
+	return w.lagMonitor.Status()
+}
+
+// shouldShed 判断消息是否应在解析前丢弃（仅在丢弃模式开启且持续滞后时）
+func (w *WSClient) shouldShed(message []byte) bool {
+	w.mu.RLock()
+	watchlist := w.shedWatchlist
+	w.mu.RUnlock()
+
+	if watchlist == nil || !w.lagMonitor.IsLagging() {
+		return false
+	}
+
+	symbol := extractSymbol(message)
+	if symbol == nil {
+		return false
+	}
+	return !watchlist[string(symbol)]
 }
 
 // SetBookTickerHandler 设置 BookTicker 处理器（推荐使用）
@@ -52,7 +106,7 @@ func (w *WSClient) SetMiniTickerHandler(handler func([]*WSMiniTickerData)) {
 
 // Connect 连接到 WebSocket
 func (w *WSClient) Connect() error {
-	conn, _, err := websocket.DefaultDialer.Dial(w.URL, nil)
+	conn, _, err := newWSDialer().Dial(w.URL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to %s: %v", w.URL, err)
 	}
@@ -140,7 +194,7 @@ func (w *WSClient) readMessages() {
 			log.Println("[Binance WS] Connection lost, reconnecting in 5 seconds...")
 			time.Sleep(5 * time.Second)
 			if err := w.Connect(); err != nil {
-				log.Printf("[Binance WS] Failed to reconnect: %v", err)
+				common.DedupLog.Printf("binance-ws", "[Binance WS] Failed to reconnect: %v", err)
 			} else {
 				log.Println("[Binance WS] Reconnected successfully")
 				// 重新订阅
@@ -177,6 +231,7 @@ func (w *WSClient) readMessages() {
 			conn.SetReadDeadline(time.Now().Add(120 * time.Second))
 
 			msgType, message, err := conn.ReadMessage()
+			readAt := time.Now()
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("[Binance WS] WebSocket connection closed unexpectedly: %v", err)
@@ -200,12 +255,23 @@ func (w *WSClient) readMessages() {
 				continue
 			}
 
+			if !w.faultPoint.Filter() {
+				continue
+			}
+
 			messageCount++
 			if messageCount%100 == 0 {
 				log.Printf("[Binance WS] Received %d messages so far", messageCount)
 			}
 
+			// 持续滞后时丢弃非关注symbol，优先追上实时数据
+			if w.shouldShed(message) {
+				w.lagMonitor.RecordShed()
+				continue
+			}
+
 			w.processMessage(message)
+			w.lagMonitor.RecordProcessing(time.Since(readAt))
 		}
 	}
 }
@@ -215,12 +281,22 @@ func (w *WSClient) processMessage(message []byte) {
 	// 1️⃣ 先尝试解析 BookTicker（优先处理，因为这是我们想要的）
 	var bookTicker WSBookTickerData
 	if err := json.Unmarshal(message, &bookTicker); err == nil && bookTicker.Symbol != "" && bookTicker.BidPrice != "" {
-		// 打印BTC/ETH/SOL的bookTicker数据用于调试
-		if bookTicker.Symbol == "BTCUSDT" || bookTicker.Symbol == "ETHUSDT" || bookTicker.Symbol == "SOLUSDT" {
+		// 记录事件时间延迟（交易所推送时间 -> 本地处理时间）
+		if bookTicker.EventTime > 0 {
+			w.lagMonitor.RecordEventLag(time.Since(time.UnixMilli(bookTicker.EventTime)))
+		}
+
+		// 打印BTC/ETH/SOL的bookTicker数据用于调试（按symbol采样，避免刷屏）
+		if (bookTicker.Symbol == "BTCUSDT" || bookTicker.Symbol == "ETHUSDT" || bookTicker.Symbol == "SOLUSDT") &&
+			common.TickerLogSampler.Allow("BINANCE_"+string(w.MarketType)+"_"+bookTicker.Symbol) {
 			log.Printf("[Binance WS %s] BookTicker %s: bid=%s, ask=%s, txnTime=%d, eventTime=%d",
 				w.MarketType, bookTicker.Symbol, bookTicker.BidPrice, bookTicker.AskPrice, bookTicker.TxnTime, bookTicker.EventTime)
 		}
 
+		if w.MarketType == common.MarketTypeFuture && isExcludedSymbol(bookTicker.Symbol) {
+			return
+		}
+
 		w.mu.RLock()
 		handler := w.bookTickerHandler
 		w.mu.RUnlock()
@@ -237,6 +313,10 @@ func (w *WSClient) processMessage(message []byte) {
 		// 尝试解析 Combined Stream 中的 BookTicker
 		var bookTickerCombined WSBookTickerData
 		if err := json.Unmarshal(wsMsg.Data, &bookTickerCombined); err == nil && bookTickerCombined.Symbol != "" && bookTickerCombined.BidPrice != "" {
+			if w.MarketType == common.MarketTypeFuture && isExcludedSymbol(bookTickerCombined.Symbol) {
+				return
+			}
+
 			w.mu.RLock()
 			handler := w.bookTickerHandler
 			w.mu.RUnlock()
@@ -358,7 +438,7 @@ func (w *WSClient) check24HourReconnect() {
 				w.Close()
 				time.Sleep(2 * time.Second)
 				if err := w.Connect(); err != nil {
-					log.Printf("[Binance WS] Failed to reconnect: %v", err)
+					common.DedupLog.Printf("binance-ws", "[Binance WS] Failed to reconnect: %v", err)
 				} else {
 					log.Println("[Binance WS] Reconnected successfully")
 					// 重新订阅
@@ -384,6 +464,7 @@ func (w *WSClient) check24HourReconnect() {
 func (w *WSClient) Close() error {
 	w.reconnect = false
 	close(w.done)
+	w.faultPoint.Release()
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -393,15 +474,3 @@ func (w *WSClient) Close() error {
 	}
 	return nil
 }
-
-// parseFloat 解析字符串为 float64
-func parseFloat(s string) float64 {
-	if s == "" {
-		return 0
-	}
-	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
-	if err != nil {
-		return 0
-	}
-	return f
-}
@@ -12,9 +12,10 @@ import (
 
 // APIMarketDetail Lighter API返回的市场详情
 type APIMarketDetail struct {
-	Symbol   string `json:"symbol"`
-	MarketID int    `json:"market_id"`
-	Status   string `json:"status"`
+	Symbol     string `json:"symbol"`
+	MarketID   int    `json:"market_id"`
+	Status     string `json:"status"`
+	QuoteAsset string `json:"quote_asset"` // 报价货币（元数据提供时），为空时按symbol或默认值识别
 }
 
 // APIResponse Lighter API响应
@@ -24,11 +25,48 @@ type APIResponse struct {
 	SpotOrderBookDetails []APIMarketDetail `json:"spot_order_book_details"`
 }
 
+// defaultPerpQuote 元数据没有给出报价货币的永续市场使用的报价货币
+// orderBookDetails 中perp的symbol通常不带报价后缀（例如 "PYTH"），每个市场优先使用元数据中的报价货币
+var defaultPerpQuote = "USDT"
+
+// SetDefaultPerpQuote 设置元数据没有报价货币时永续合约的默认报价货币（USDT / USDC）
+func SetDefaultPerpQuote(quote string) {
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+	if quote == "" {
+		return
+	}
+	defaultPerpQuote = quote
+}
+
+// parseMarketSymbol 根据单个市场的元数据生成带正确报价后缀的symbol，返回 (symbol, 报价货币)
+// 优先级：symbol中的分隔符（"LIT/USDC"、"BTC-USDC"）> 元数据的报价货币 > 永续默认报价货币
+// Spot: "LIT/USDC" -> ("LITUSDC", "USDC")
+// Perp: ("PYTH", "USDC") -> ("PYTHUSDC", "USDC")；("PYTH", "") -> ("PYTH" + defaultPerpQuote, defaultPerpQuote)
+func parseMarketSymbol(rawSymbol, quoteAsset string, isSpot bool) (string, string) {
+	for _, sep := range []string{"/", "-"} {
+		if parts := strings.SplitN(rawSymbol, sep, 2); len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			quote := strings.ToUpper(parts[1])
+			return parts[0] + quote, quote
+		}
+	}
+
+	if quote := strings.ToUpper(strings.TrimSpace(quoteAsset)); quote != "" {
+		if strings.HasSuffix(strings.ToUpper(rawSymbol), quote) && len(rawSymbol) > len(quote) {
+			return rawSymbol, quote
+		}
+		return rawSymbol + quote, quote
+	}
+
+	if isSpot {
+		// 没有斜杠也没有报价货币的现货symbol，交给 ParseSymbol 的后缀识别
+		return rawSymbol, ""
+	}
+	return rawSymbol + defaultPerpQuote, defaultPerpQuote
+}
+
 // FetchMarketsFromAPI 从Lighter官方API获取市场配置
 func FetchMarketsFromAPI(apiURL string) ([]*Market, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	client := getHTTPClient(10 * time.Second)
 
 	resp, err := client.Get(apiURL)
 	if err != nil {
@@ -61,10 +99,13 @@ func FetchMarketsFromAPI(apiURL string) ([]*Market, error) {
 	for _, detail := range apiResp.OrderBookDetails {
 		// 只添加active状态的市场
 		if detail.Status == "active" {
+			// Lighter futures的symbol不带报价后缀，按该市场的报价货币加上（例如 "PYTH" -> "PYTHUSDC"）
+			symbol, quote := parseMarketSymbol(detail.Symbol, detail.QuoteAsset, false)
 			markets = append(markets, &Market{
-				MarketID: detail.MarketID,
-				Symbol:   detail.Symbol + "USDT", // Lighter futures的symbol不带USDT后缀，需要加上（例如 "PYTH" -> "PYTHUSDT"）
-				Type:     "perp",
+				MarketID:   detail.MarketID,
+				Symbol:     symbol,
+				Type:       "perp",
+				QuoteAsset: quote,
 			})
 		}
 	}
@@ -74,11 +115,12 @@ func FetchMarketsFromAPI(apiURL string) ([]*Market, error) {
 		// 只添加active状态的市场
 		if detail.Status == "active" {
 			// Spot市场symbol格式为 "LIT/USDC"，需要将斜杠去掉（例如 "LIT/USDC" -> "LITUSDC"）
-			symbol := strings.ReplaceAll(detail.Symbol, "/", "")
+			symbol, quote := parseMarketSymbol(detail.Symbol, detail.QuoteAsset, true)
 			markets = append(markets, &Market{
-				MarketID: detail.MarketID,
-				Symbol:   symbol,
-				Type:     "spot",
+				MarketID:   detail.MarketID,
+				Symbol:     symbol,
+				Type:       "spot",
+				QuoteAsset: quote,
 			})
 		}
 	}
@@ -0,0 +1,70 @@
+package lighter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseMarketSymbol(t *testing.T) {
+	SetDefaultPerpQuote("USDT")
+
+	tests := []struct {
+		raw, quote string
+		spot       bool
+		wantSymbol string
+		wantQuote  string
+	}{
+		{"LIT/USDC", "", true, "LITUSDC", "USDC"},
+		{"ETH/usdt", "", true, "ETHUSDT", "USDT"},
+		{"BTC-USDC", "", false, "BTCUSDC", "USDC"},
+		{"PYTH", "USDC", false, "PYTHUSDC", "USDC"},
+		{"PYTH", "usdt", false, "PYTHUSDT", "USDT"},
+		{"PYTHUSDC", "USDC", false, "PYTHUSDC", "USDC"}, // 已带后缀时不重复
+		{"PYTH", "", false, "PYTHUSDT", "USDT"},         // 没有元数据时使用默认值
+		{"LITUSDC", "", true, "LITUSDC", ""},            // 交给 ParseSymbol 识别
+	}
+	for _, tt := range tests {
+		symbol, quote := parseMarketSymbol(tt.raw, tt.quote, tt.spot)
+		if symbol != tt.wantSymbol || quote != tt.wantQuote {
+			t.Errorf("parseMarketSymbol(%q, %q, %v) = (%q, %q), want (%q, %q)",
+				tt.raw, tt.quote, tt.spot, symbol, quote, tt.wantSymbol, tt.wantQuote)
+		}
+	}
+}
+
+func TestFetchMarketsFromAPIMixedQuotes(t *testing.T) {
+	SetDefaultPerpQuote("USDT")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,
+			"order_book_details":[
+				{"symbol":"ETH","market_id":0,"status":"active","quote_asset":"USDC"},
+				{"symbol":"BTC","market_id":1,"status":"active","quote_asset":"USDT"},
+				{"symbol":"SOL","market_id":2,"status":"active"},
+				{"symbol":"OLD","market_id":3,"status":"inactive","quote_asset":"USDC"}],
+			"spot_order_book_details":[
+				{"symbol":"LIT/USDC","market_id":2048,"status":"active"}]}`))
+	}))
+	defer server.Close()
+
+	markets, err := FetchMarketsFromAPI(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int][2]string{
+		0:    {"ETHUSDC", "USDC"},
+		1:    {"BTCUSDT", "USDT"},
+		2:    {"SOLUSDT", "USDT"},
+		2048: {"LITUSDC", "USDC"},
+	}
+	if len(markets) != len(want) {
+		t.Fatalf("got %d markets, want %d", len(markets), len(want))
+	}
+	for _, m := range markets {
+		w, ok := want[m.MarketID]
+		if !ok || m.Symbol != w[0] || m.QuoteAsset != w[1] {
+			t.Errorf("market %d = %s/%s, want %v", m.MarketID, m.Symbol, m.QuoteAsset, w)
+		}
+	}
+}
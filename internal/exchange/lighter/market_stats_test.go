@@ -0,0 +1,62 @@
+package lighter
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+)
+
+func TestMarketStatsFundingRateValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		settled string
+		want    float64
+	}{
+		{"current rate", "0.0012", "0.0008", 0.0012},
+		{"negative current rate", "-0.0003", "0.0008", -0.0003},
+		{"falls back to settled rate", "", "0.0008", 0.0008},
+		{"both missing", "", "", 0},
+		{"malformed", "n/a", "", 0},
+	}
+	for _, tt := range tests {
+		stats := &MarketStatsData{CurrentFundingRate: tt.current, FundingRate: tt.settled}
+		if got := stats.FundingRateValue(); got != tt.want {
+			t.Errorf("%s: FundingRateValue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarketStatsMessageCarriesFunding(t *testing.T) {
+	markets := []*Market{
+		{MarketID: 1, Symbol: "BTCUSDC", Type: "perp", QuoteAsset: "USDC"},
+		{MarketID: 2048, Symbol: "LITUSDC", Type: "spot", QuoteAsset: "USDC"},
+	}
+	conn := NewWSPoolConnection(0, markets)
+	var prices []*common.Price
+	conn.priceHandler = func(p *common.Price) { prices = append(prices, p) }
+
+	conn.processMessage([]byte(`{"type":"update/market_stats","channel":"market_stats:1","market_stats":{
+		"market_id":1,"index_price":"60010.5","mark_price":"60000.0","open_interest":"1234.5",
+		"last_trade_price":"60001","current_funding_rate":"0.0001","funding_rate":"0.00005",
+		"daily_quote_token_volume":5000000}}`))
+	conn.processMessage([]byte(`{"type":"update/market_stats","channel":"market_stats:2048","market_stats":{
+		"market_id":2048,"mark_price":"1.5","current_funding_rate":"0.01","daily_quote_token_volume":100}}`))
+
+	if len(prices) != 2 {
+		t.Fatalf("got %d prices, want 2", len(prices))
+	}
+
+	perp := prices[0]
+	if perp.MarketType != common.MarketTypeFuture || !perp.FundingKnown || perp.FundingRate != 0.0001 ||
+		perp.OpenInterest != 1234.5 || perp.MarkPrice != 60000 || perp.IndexPrice != 60010.5 {
+		t.Fatalf("perp price = %+v", perp)
+	}
+	if perp.QuoteCurrency != common.QuoteCurrencyUSDC || perp.Volume24h != 5000000 || !perp.VolumeKnown || !perp.SyntheticSpread {
+		t.Fatalf("perp quote=%s volume=%v known=%v synthetic=%v", perp.QuoteCurrency, perp.Volume24h, perp.VolumeKnown, perp.SyntheticSpread)
+	}
+
+	// 现货没有资金费率
+	if spot := prices[1]; spot.MarketType != common.MarketTypeSpot || spot.FundingKnown || spot.FundingRate != 0 {
+		t.Fatalf("spot price = %+v", spot)
+	}
+}
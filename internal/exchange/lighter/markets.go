@@ -31,11 +31,21 @@ func GetCommonMarkets() []*Market {
 
 // getFallbackMarkets 获取fallback市场配置（仅在API失败时使用）
 func getFallbackMarkets() []*Market {
-	return []*Market{
-		{MarketID: 0, Symbol: "ETHUSDT", Type: "perp"},
-		{MarketID: 1, Symbol: "BTCUSDT", Type: "perp"},
-		{MarketID: 2, Symbol: "SOLUSDT", Type: "perp"},
+	fallback := []struct {
+		marketID int
+		base     string
+	}{
+		{0, "ETH"},
+		{1, "BTC"},
+		{2, "SOL"},
 	}
+
+	markets := make([]*Market, 0, len(fallback))
+	for _, f := range fallback {
+		symbol, quote := parseMarketSymbol(f.base, "", false)
+		markets = append(markets, &Market{MarketID: f.marketID, Symbol: symbol, Type: "perp", QuoteAsset: quote})
+	}
+	return markets
 }
 
 // GetMarketIDs 获取所有市场ID列表
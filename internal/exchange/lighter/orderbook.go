@@ -1,6 +1,7 @@
 package lighter
 
 import (
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"log"
 	"sort"
 	"sync"
@@ -42,8 +43,8 @@ func (ob *LocalOrderBook) InitializeFromSnapshot(bids, asks []PriceLevel, nonce,
 
 	// 初始化买单
 	for _, bid := range bids {
-		price := parseFloat(bid.Price)
-		amount := parseFloat(bid.Size)
+		price := numutil.ParseFloat(bid.Price)
+		amount := numutil.ParseFloat(bid.Size)
 		if price > 0 && amount > 0 {
 			ob.Bids[price] = &Order{
 				Price:  price,
@@ -54,8 +55,8 @@ func (ob *LocalOrderBook) InitializeFromSnapshot(bids, asks []PriceLevel, nonce,
 
 	// 初始化卖单
 	for _, ask := range asks {
-		price := parseFloat(ask.Price)
-		amount := parseFloat(ask.Size)
+		price := numutil.ParseFloat(ask.Price)
+		amount := numutil.ParseFloat(ask.Size)
 		if price > 0 && amount > 0 {
 			ob.Asks[price] = &Order{
 				Price:  price,
@@ -143,8 +144,8 @@ func (ob *LocalOrderBook) ApplyIncrementalUpdate(bids, asks []PriceLevel, beginN
 
 	// 应用买单更新
 	for _, bid := range bids {
-		price := parseFloat(bid.Price)
-		amount := parseFloat(bid.Size)
+		price := numutil.ParseFloat(bid.Price)
+		amount := numutil.ParseFloat(bid.Size)
 
 		if price <= 0 {
 			continue
@@ -164,8 +165,8 @@ func (ob *LocalOrderBook) ApplyIncrementalUpdate(bids, asks []PriceLevel, beginN
 
 	// 应用卖单更新
 	for _, ask := range asks {
-		price := parseFloat(ask.Price)
-		amount := parseFloat(ask.Size)
+		price := numutil.ParseFloat(ask.Price)
+		amount := numutil.ParseFloat(ask.Size)
 
 		if price <= 0 {
 			continue
@@ -301,3 +302,74 @@ func (ob *LocalOrderBook) IsInitialized() bool {
 	defer ob.mu.RUnlock()
 	return ob.initialized
 }
+
+// TopLevels 获取前 n 档买卖盘（买盘价格降序，卖盘价格升序），未初始化时返回 false
+// 持锁期间只复制价位，排序和截取在锁外进行，不阻塞增量更新
+func (ob *LocalOrderBook) TopLevels(n int) (bids, asks []Order, ok bool) {
+	ob.mu.RLock()
+	if !ob.initialized {
+		ob.mu.RUnlock()
+		return nil, nil, false
+	}
+	bids = make([]Order, 0, len(ob.Bids))
+	for _, order := range ob.Bids {
+		bids = append(bids, *order)
+	}
+	asks = make([]Order, 0, len(ob.Asks))
+	for _, order := range ob.Asks {
+		asks = append(asks, *order)
+	}
+	ob.mu.RUnlock()
+
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+	if len(bids) > n {
+		bids = bids[:n]
+	}
+	if len(asks) > n {
+		asks = asks[:n]
+	}
+	return bids, asks, true
+}
+
+// DepthVWAP 按名义金额从最优价逐档成交的成交均价（side 为 bid 或 ask）
+// 订单簿深度不足 notional 时按全部档位计算，没有档位时返回 false
+func (ob *LocalOrderBook) DepthVWAP(side string, notional float64) (float64, bool) {
+	ob.mu.RLock()
+	orderMap := ob.Asks
+	if side == "bid" {
+		orderMap = ob.Bids
+	}
+	levels := make([]Order, 0, len(orderMap))
+	for _, order := range orderMap {
+		levels = append(levels, *order)
+	}
+	ob.mu.RUnlock()
+
+	if len(levels) == 0 || notional <= 0 {
+		return 0, false
+	}
+	if side == "bid" {
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price > levels[j].Price })
+	} else {
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price < levels[j].Price })
+	}
+
+	var filledNotional, filledQty float64
+	for _, level := range levels {
+		remaining := notional - filledNotional
+		if remaining <= 0 {
+			break
+		}
+		qty := level.Amount
+		if level.Price*qty > remaining {
+			qty = remaining / level.Price
+		}
+		filledNotional += level.Price * qty
+		filledQty += qty
+	}
+	if filledQty <= 0 {
+		return 0, false
+	}
+	return filledNotional / filledQty, true
+}
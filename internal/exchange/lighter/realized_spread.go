@@ -0,0 +1,137 @@
+package lighter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	realizedSpreadWindow         = time.Hour        // 滚动窗口
+	realizedSpreadSampleInterval = 10 * time.Second // 每个市场最多每10秒记录一次，窗口内最多360个样本
+	realizedSpreadMinSamples     = 3                // 样本不足时不使用历史价差
+)
+
+// realizedSpreads 各市场 WebSocket 订单簿观测到的真实买一卖一价差（进程内共享，REST估算价格使用）
+var realizedSpreads = newSpreadHistory(realizedSpreadWindow, realizedSpreadSampleInterval)
+
+// MarketSpreadStats 单个市场最近一小时观测到的买一卖一价差
+type MarketSpreadStats struct {
+	MarketID            int     `json:"market_id"`
+	MedianSpreadPercent float64 `json:"median_spread_percent"`
+	Samples             int     `json:"samples"`
+}
+
+// spreadSample 一次价差观测
+type spreadSample struct {
+	at       time.Time
+	fraction float64 // (ask - bid) / mid
+}
+
+// spreadHistory 各市场价差观测的滚动窗口
+type spreadHistory struct {
+	mu       sync.Mutex
+	window   time.Duration
+	interval time.Duration
+	samples  map[int][]spreadSample
+}
+
+// newSpreadHistory 创建价差滚动窗口
+func newSpreadHistory(window, interval time.Duration) *spreadHistory {
+	return &spreadHistory{
+		window:   window,
+		interval: interval,
+		samples:  make(map[int][]spreadSample),
+	}
+}
+
+// record 记录一次真实的双边报价，距离上次记录不足采样间隔时忽略
+func (h *spreadHistory) record(marketID int, bid, ask float64, now time.Time) {
+	if bid <= 0 || ask < bid {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := h.samples[marketID]
+	if n := len(samples); n > 0 && now.Sub(samples[n-1].at) < h.interval {
+		return
+	}
+	samples = append(h.prune(samples, now), spreadSample{at: now, fraction: (ask - bid) / ((ask + bid) / 2)})
+	h.samples[marketID] = samples
+}
+
+// median 市场在窗口内价差（比例）的中位数，样本不足时返回 false
+func (h *spreadHistory) median(marketID int, now time.Time) (float64, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := h.prune(h.samples[marketID], now)
+	h.samples[marketID] = samples
+	if len(samples) < realizedSpreadMinSamples {
+		return 0, false
+	}
+	return medianFraction(samples), true
+}
+
+// stats 所有有样本的市场的价差统计，按市场ID排序
+func (h *spreadHistory) stats(now time.Time) []MarketSpreadStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]MarketSpreadStats, 0, len(h.samples))
+	for marketID, samples := range h.samples {
+		samples = h.prune(samples, now)
+		h.samples[marketID] = samples
+		if len(samples) == 0 {
+			continue
+		}
+		result = append(result, MarketSpreadStats{
+			MarketID:            marketID,
+			MedianSpreadPercent: medianFraction(samples) * 100,
+			Samples:             len(samples),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].MarketID < result[j].MarketID })
+	return result
+}
+
+// prune 去掉窗口外的样本（调用者需要持有锁，样本按时间递增）
+func (h *spreadHistory) prune(samples []spreadSample, now time.Time) []spreadSample {
+	i := 0
+	for i < len(samples) && now.Sub(samples[i].at) > h.window {
+		i++
+	}
+	return samples[i:]
+}
+
+// medianFraction 样本价差的中位数（样本不能为空）
+func medianFraction(samples []spreadSample) float64 {
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.fraction
+	}
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// estimateBookFromLastTrade REST 数据没有订单簿时用最新成交价估算 bid/ask
+// 优先使用该市场最近一小时在 WebSocket 订单簿上观测到的价差中位数（以成交价为中心各取一半），
+// 没有历史时回退到固定价差：±0.01%，价格很小时 ±0.1%
+func estimateBookFromLastTrade(marketID int, lastPrice float64, now time.Time) (bid, ask float64) {
+	if fraction, ok := realizedSpreads.median(marketID, now); ok && fraction > 0 {
+		half := lastPrice * fraction / 2
+		return lastPrice - half, lastPrice + half
+	}
+
+	spread := lastPrice * 0.0001
+	if spread < 0.00001 {
+		spread = lastPrice * 0.001 // 对于非常小的价格，使用 0.1% 价差
+	}
+	return lastPrice - spread, lastPrice + spread
+}
@@ -0,0 +1,109 @@
+package lighter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// useFreshSpreadHistory 替换进程内共享的价差历史，测试结束后恢复
+func useFreshSpreadHistory(t *testing.T) *spreadHistory {
+	t.Helper()
+	previous := realizedSpreads
+	realizedSpreads = newSpreadHistory(realizedSpreadWindow, realizedSpreadSampleInterval)
+	t.Cleanup(func() { realizedSpreads = previous })
+	return realizedSpreads
+}
+
+// seedSpread 以采样间隔为步长写入 n 个相同价差（比例）的样本，最后一个在 now
+func seedSpread(h *spreadHistory, marketID int, fraction float64, n int, now time.Time) {
+	for i := n - 1; i >= 0; i-- {
+		mid := 100.0
+		h.record(marketID, mid*(1-fraction/2), mid*(1+fraction/2), now.Add(-time.Duration(i)*realizedSpreadSampleInterval))
+	}
+}
+
+func TestRESTBookUsesRealizedSpread(t *testing.T) {
+	seedSpread(useFreshSpreadHistory(t), 901, 0.004, 5, time.Now())
+
+	SetDefaultPerpQuote("USDT")
+	useCannedClient(t, `{"code":200,
+		"order_book_details":[
+			{"market_id":901,"symbol":"PYTH","status":"active","last_trade_price":0.5,"daily_quote_token_volume":500}],
+		"spot_order_book_details":[
+			{"market_id":2901,"symbol":"LIT/USDC","status":"active","last_trade_price":2,"daily_quote_token_volume":80}]}`)
+	prices, err := fetchMarketDataOnce("https://lighter.invalid", []int{901, 2901})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("got %d prices, want 2", len(prices))
+	}
+
+	// 有 0.4% 的历史价差：以成交价为中心 ±0.2%，并标记为估算报价
+	perp := prices[0]
+	if math.Abs(perp.BidPrice-0.499) > 1e-12 || math.Abs(perp.AskPrice-0.501) > 1e-12 {
+		t.Fatalf("perp book = %v/%v, want 0.499/0.501 (±0.2%% around 0.5)", perp.BidPrice, perp.AskPrice)
+	}
+	if !perp.SyntheticSpread {
+		t.Fatal("estimated perp book not flagged")
+	}
+
+	// 没有历史的市场回退到固定的 ±0.01%
+	spot := prices[1]
+	if math.Abs(spot.BidPrice-1.9998) > 1e-12 || math.Abs(spot.AskPrice-2.0002) > 1e-12 || !spot.SyntheticSpread {
+		t.Fatalf("spot book = %v/%v synthetic=%v, want 1.9998/2.0002 estimated", spot.BidPrice, spot.AskPrice, spot.SyntheticSpread)
+	}
+}
+
+func TestSpreadHistoryMedianAndWindow(t *testing.T) {
+	h := newSpreadHistory(time.Hour, 10*time.Second)
+	now := time.Now()
+
+	// 样本不足时不使用历史
+	seedSpread(h, 1, 0.002, realizedSpreadMinSamples-1, now.Add(-20*time.Minute))
+	if _, ok := h.median(1, now); ok {
+		t.Fatal("median available below the minimum sample count")
+	}
+
+	// 中位数不受个别极端值影响
+	h.record(1, 99.5, 100.5, now.Add(-10*time.Minute)) // 1%
+	if got, ok := h.median(1, now); !ok || math.Abs(got-0.002) > 1e-9 {
+		t.Fatalf("median = %v (ok=%v), want 0.002", got, ok)
+	}
+	h.record(1, 99.8, 100.2, now.Add(-5*time.Minute)) // 0.4%
+
+	// 采样间隔内的观测和无效报价不记录
+	h.record(1, 99.8, 100.2, now.Add(-5*time.Minute+time.Second))
+	h.record(1, 0, 100, now)
+	h.record(1, 101, 100, now)
+	if stats := h.stats(now); len(stats) != 1 || stats[0].MarketID != 1 || stats[0].Samples != 4 {
+		t.Fatalf("stats = %+v, want 4 samples for market 1", stats)
+	}
+
+	// 超过一小时的样本被丢弃
+	later := now.Add(52 * time.Minute)
+	if got, ok := h.median(1, later); ok {
+		t.Fatalf("median %v still available with samples outside the window", got)
+	}
+	if stats := h.stats(later); len(stats) != 1 || stats[0].Samples != 1 || math.Abs(stats[0].MedianSpreadPercent-0.4) > 1e-9 {
+		t.Fatalf("stats after window = %+v, want one 0.4%% sample", stats)
+	}
+}
+
+func TestWSTwoSidedBookRecordsRealizedSpread(t *testing.T) {
+	h := useFreshSpreadHistory(t)
+	conn, _ := newWarmupConnection()
+
+	conn.processMessage([]byte(bidOnlySnapshot))
+	if stats := h.stats(time.Now()); len(stats) != 0 {
+		t.Fatalf("one-sided book recorded a spread: %+v", stats)
+	}
+
+	conn.processMessage([]byte(fullSnapshot))
+	stats := h.stats(time.Now())
+	want := 0.1 / 100.05 * 100
+	if len(stats) != 1 || stats[0].MarketID != 1 || math.Abs(stats[0].MedianSpreadPercent-want) > 1e-9 {
+		t.Fatalf("stats = %+v, want market 1 at %.4f%%", stats, want)
+	}
+}
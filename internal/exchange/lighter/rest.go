@@ -1,13 +1,15 @@
 package lighter
 
 import (
+	"crypto-arbitrage-monitor/internal/netutil"
 	"crypto-arbitrage-monitor/pkg/common"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -30,6 +32,7 @@ type OrderBookDetailItem struct {
 	DailyPriceLow         float64 `json:"daily_price_low"`
 	DailyPriceHigh        float64 `json:"daily_price_high"`
 	OpenInterest          float64 `json:"open_interest"`
+	QuoteAsset            string  `json:"quote_asset"` // 报价货币（元数据提供时）
 }
 
 // 价格缓存
@@ -39,12 +42,70 @@ var (
 	lastFetchTime  time.Time
 	lastFetchCount int
 	fetchErrorCount int
+
+	// 限制Lighter REST同时进行中的请求数
+	restLimiter = common.NewConcurrencyLimiter(2)
+
+	// 自定义 http.Client（nil 表示使用默认客户端）
+	httpClient   *http.Client
+	httpClientMu sync.RWMutex
 )
 
+// SetHTTPClient 设置Lighter REST使用的 http.Client（例如指向 httptest.Server），传入 nil 恢复默认
+func SetHTTPClient(client *http.Client) {
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+	httpClient = client
+}
+
+// getHTTPClient 获取REST请求使用的 http.Client，未设置时按给定超时创建默认客户端
+func getHTTPClient(defaultTimeout time.Duration) *http.Client {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	if httpClient != nil {
+		return httpClient
+	}
+	return netutil.NewHTTPClient(defaultTimeout)
+}
+
+// SetMaxConcurrentRequests 设置Lighter REST最大并发请求数
+func SetMaxConcurrentRequests(limit int) {
+	restLimiter.SetLimit(limit)
+}
+
+// REST 并发请求数和等待结果超时的取值范围
+const (
+	minParallelRequests = 1
+	maxParallelRequests = 5
+	minRequestTimeout   = 1 * time.Second
+	maxRequestTimeout   = 15 * time.Second // 与单次请求的 http.Client 超时一致，更长没有意义
+)
+
+var (
+	parallelRequests = 3               // FetchMarketData 每轮同时发起的请求数
+	requestTimeout   = 5 * time.Second // 等待这一轮请求结果的最长时间
+)
+
+// SetRESTFanout 设置每轮REST拉取同时发起的请求数（取数据最多的结果）和等待结果的超时
+// 超出范围的值会被限制到 [1, 5] 个请求、[1s, 15s] 超时；需要在开始拉取前调用
+func SetRESTFanout(requests int, timeout time.Duration) {
+	if requests < minParallelRequests || requests > maxParallelRequests {
+		clamped := min(max(requests, minParallelRequests), maxParallelRequests)
+		log.Printf("[Lighter] REST parallel requests %d out of range [%d, %d], using %d", requests, minParallelRequests, maxParallelRequests, clamped)
+		requests = clamped
+	}
+	if timeout < minRequestTimeout || timeout > maxRequestTimeout {
+		clamped := min(max(timeout, minRequestTimeout), maxRequestTimeout)
+		log.Printf("[Lighter] REST request timeout %v out of range [%v, %v], using %v", timeout, minRequestTimeout, maxRequestTimeout, clamped)
+		timeout = clamped
+	}
+	parallelRequests = requests
+	requestTimeout = timeout
+}
+
 // FetchMarketData 从 REST API 获取市场数据（并发多次请求 + 合并结果）
 func FetchMarketData(apiURL string, marketIDs []int) ([]*common.Price, error) {
-	const parallelRequests = 3 // 并发请求数
-	const requestTimeout = 5 * time.Second
+	parallelRequests, requestTimeout := parallelRequests, requestTimeout // 本轮使用的配置
 
 	type result struct {
 		prices []*common.Price
@@ -82,7 +143,7 @@ collectResults:
 				allErrors = append(allErrors, res.err)
 			}
 		case <-timeout:
-			log.Printf("Warning: Some Lighter API requests timed out after %v", requestTimeout)
+			common.DedupLog.Printf("lighter-rest", "Warning: Some Lighter API requests timed out after %v", requestTimeout)
 			break collectResults
 		}
 	}
@@ -116,9 +177,14 @@ collectResults:
 
 	// 所有请求都失败
 	fetchErrorCount++
-	log.Printf("Lighter API: all %d parallel requests failed", parallelRequests)
-	for i, err := range allErrors {
-		log.Printf("  Request %d error: %v", i+1, err)
+	// 所有请求的错误合并为一行，逐条输出时不同请求的错误会被合并器当作同一条日志
+	common.DedupLog.Printf("lighter-rest", "Lighter API: all %d parallel requests failed: %s", parallelRequests, joinRequestErrors(allErrors))
+
+	// 被限频时直接返回，由调用者退避（继续使用缓存会掩盖限频）
+	for _, err := range allErrors {
+		if errors.Is(err, common.ErrRateLimited) {
+			return nil, err
+		}
 	}
 
 	// 使用缓存数据
@@ -138,25 +204,31 @@ collectResults:
 		return cachedPrices, nil
 	}
 
+	if len(allErrors) > 0 {
+		return nil, fmt.Errorf("all %d requests failed and no cache available: %w", parallelRequests, allErrors[0])
+	}
 	return nil, fmt.Errorf("all %d requests failed and no cache available", parallelRequests)
 }
 
 // fetchMarketDataOnce 执行单次 API 请求
 func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
+	client := getHTTPClient(15 * time.Second)
 
 	// 使用 orderBookDetails endpoint
 	url := fmt.Sprintf("%s/api/v1/orderBookDetails", apiURL)
 
+	release := restLimiter.Acquire()
+	defer release()
+
 	resp, err := client.Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch market data: %w", err)
+		return nil, fmt.Errorf("failed to fetch market data: %w", common.NewTemporaryError(common.ExchangeLighter, err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, common.NewHTTPError(common.ExchangeLighter, resp.StatusCode, resp.Header, body)
 	}
 
 	var apiResp OrderBookDetailsResponse
@@ -164,8 +236,14 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	// 响应体中的错误码与HTTP状态码含义一致
 	if apiResp.Code != 200 {
-		return nil, fmt.Errorf("API returned error code: %d", apiResp.Code)
+		return nil, &common.APIError{
+			Exchange: common.ExchangeLighter,
+			Kind:     common.ClassifyHTTPStatus(apiResp.Code),
+			Code:     apiResp.Code,
+			Message:  "API returned error code",
+		}
 	}
 
 	// 创建市场 ID 映射
@@ -191,8 +269,8 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 		}
 		totalMarkets++
 
-		// Futures symbol格式为 "PYTH"，需要加上 USDT 后缀
-		symbol := data.Symbol + "USDT"
+		// Futures symbol格式为 "PYTH"，按该市场的报价货币加上后缀（元数据没有时使用默认报价货币）
+		symbol, quote := parseMarketSymbol(data.Symbol, data.QuoteAsset, false)
 
 		// 处理所有市场，不仅仅是 active 的（可能暂时 inactive 但仍有价值）
 		if data.Status != "active" {
@@ -229,13 +307,8 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 			continue
 		}
 
-		// 使用 last_trade_price 估算 bid/ask（假设 0.01% 价差）
-		spread := lastPrice * 0.0001
-		if spread < 0.00001 {
-			spread = lastPrice * 0.001 // 对于非常小的价格，使用 0.1% 价差
-		}
-		bidPrice := lastPrice - spread
-		askPrice := lastPrice + spread
+		// 使用 last_trade_price 估算 bid/ask（优先使用该市场在WebSocket订单簿上观测到的历史价差）
+		bidPrice, askPrice := estimateBookFromLastTrade(data.MarketID, lastPrice, time.Now())
 
 		// futures市场类型
 		marketType := common.MarketTypeFuture
@@ -251,9 +324,15 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 			BidQty:      0, // REST API 不提供订单簿数量
 			AskQty:      0,
 			Volume24h:   data.DailyQuoteTokenVolume,
+			VolumeKnown: true,
 			Timestamp:   now,                    // REST API没有交易所时间戳
 			LastUpdated: now,                    // 本地接收时间
 			Source:      common.PriceSourceREST, // 标记为REST数据源
+
+			QuoteCurrency: common.QuoteCurrency(quote), // 为空时由 PriceStore 根据symbol识别
+
+			SyntheticSpread: true,              // bid/ask 由 last_trade_price 推算
+			OpenInterest:    data.OpenInterest, // orderBookDetails 不包含资金费率
 		}
 
 		prices = append(prices, price)
@@ -268,14 +347,7 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 		totalMarkets++
 
 		// Spot symbol格式为 "LIT/USDC"，需要将斜杠去掉（例如 "LIT/USDC" -> "LITUSDC"）
-		symbol := data.Symbol
-		// 去掉斜杠
-		for i := 0; i < len(symbol); i++ {
-			if symbol[i] == '/' {
-				symbol = symbol[:i] + symbol[i+1:]
-				break
-			}
-		}
+		symbol, quote := parseMarketSymbol(data.Symbol, data.QuoteAsset, true)
 
 		// 处理所有市场，不仅仅是 active 的（可能暂时 inactive 但仍有价值）
 		if data.Status != "active" {
@@ -312,13 +384,8 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 			continue
 		}
 
-		// 使用 last_trade_price 估算 bid/ask（假设 0.01% 价差）
-		spread := lastPrice * 0.0001
-		if spread < 0.00001 {
-			spread = lastPrice * 0.001 // 对于非常小的价格，使用 0.1% 价差
-		}
-		bidPrice := lastPrice - spread
-		askPrice := lastPrice + spread
+		// 使用 last_trade_price 估算 bid/ask（优先使用该市场在WebSocket订单簿上观测到的历史价差）
+		bidPrice, askPrice := estimateBookFromLastTrade(data.MarketID, lastPrice, time.Now())
 
 		// spot市场类型
 		marketType := common.MarketTypeSpot
@@ -334,9 +401,14 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 			BidQty:      0, // REST API 不提供订单簿数量
 			AskQty:      0,
 			Volume24h:   data.DailyQuoteTokenVolume,
+			VolumeKnown: true,
 			Timestamp:   now,                    // REST API没有交易所时间戳
 			LastUpdated: now,                    // 本地接收时间
 			Source:      common.PriceSourceREST, // 标记为REST数据源
+
+			QuoteCurrency: common.QuoteCurrency(quote), // 为空时由 PriceStore 根据symbol识别
+
+			SyntheticSpread: true, // bid/ask 由 last_trade_price 推算
 		}
 
 		prices = append(prices, price)
@@ -349,11 +421,14 @@ func fetchMarketDataOnce(apiURL string, marketIDs []int) ([]*common.Price, error
 	return prices, nil
 }
 
-// parseFloatStr 解析字符串为 float64
-func parseFloatStr(s string) float64 {
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0
+// joinRequestErrors 将并行请求的错误合并为一行（"request 1: ...; request 2: ..."）
+func joinRequestErrors(errs []error) string {
+	if len(errs) == 0 {
+		return "no responses before timeout"
+	}
+	parts := make([]string, len(errs))
+	for i, err := range errs {
+		parts[i] = fmt.Sprintf("request %d: %v", i+1, err)
 	}
-	return f
+	return strings.Join(parts, "; ")
 }
@@ -0,0 +1,258 @@
+package lighter
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJoinRequestErrorsKeepsEveryError(t *testing.T) {
+	errs := []error{
+		errors.New("connection reset"),
+		fmt.Errorf("status 429: %w", common.ErrRateLimited),
+		errors.New("connection reset"),
+	}
+	got := joinRequestErrors(errs)
+	want := "request 1: connection reset; request 2: status 429: " + common.ErrRateLimited.Error() + "; request 3: connection reset"
+	if got != want {
+		t.Fatalf("joinRequestErrors = %q, want %q", got, want)
+	}
+	if joinRequestErrors(nil) == "" {
+		t.Fatal("empty error list produced an empty line")
+	}
+}
+
+func TestFetchMarketDataRespectsConcurrencyLimit(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	SetMaxConcurrentRequests(1)
+	defer SetMaxConcurrentRequests(2)
+
+	// FetchMarketData 并行发出多个请求，限制器让它们依次进行
+	FetchMarketData(server.URL, []int{1})
+	if p := peak.Load(); p != 1 {
+		t.Fatalf("peak concurrent requests = %d, want 1", p)
+	}
+}
+
+// cannedTransport 对所有请求返回同一段预置JSON，并记录请求路径，不访问网络
+type cannedTransport struct {
+	body  string
+	paths []string
+}
+
+func (c *cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.paths = append(c.paths, req.URL.Path)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+		Request:    req,
+	}, nil
+}
+
+// useCannedClient 注入返回预置JSON的客户端，测试结束后恢复默认客户端
+func useCannedClient(t *testing.T, body string) *cannedTransport {
+	t.Helper()
+	transport := &cannedTransport{body: body}
+	SetHTTPClient(&http.Client{Transport: transport})
+	t.Cleanup(func() { SetHTTPClient(nil) })
+	return transport
+}
+
+func TestFetchMarketDataParsesOrderBookDetails(t *testing.T) {
+	SetDefaultPerpQuote("USDT")
+	transport := useCannedClient(t, `{"code":200,
+		"order_book_details":[
+			{"market_id":901,"symbol":"PYTH","status":"active","last_trade_price":0.5,
+			 "daily_base_token_volume":1000,"daily_quote_token_volume":500,"daily_price_low":0.45,
+			 "daily_price_high":0.55,"open_interest":1234.5,"quote_asset":"USDC"},
+			{"market_id":902,"symbol":"NOTSUBSCRIBED","status":"active","last_trade_price":1}],
+		"spot_order_book_details":[
+			{"market_id":2901,"symbol":"LIT/USDC","status":"active","last_trade_price":2,"daily_quote_token_volume":80}]}`)
+
+	// 基础地址无法解析，只有注入的客户端生效时请求才会成功
+	prices, err := fetchMarketDataOnce("https://lighter.invalid", []int{901, 2901})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.paths) != 1 || transport.paths[0] != "/api/v1/orderBookDetails" {
+		t.Fatalf("requested paths = %v", transport.paths)
+	}
+	if len(prices) != 2 {
+		t.Fatalf("got %d prices, want 2 (unsubscribed market skipped)", len(prices))
+	}
+
+	perp, spot := prices[0], prices[1]
+	if perp.Symbol != "PYTHUSDC" || perp.MarketType != common.MarketTypeFuture || perp.QuoteCurrency != "USDC" ||
+		perp.Price != 0.5 || perp.Volume24h != 500 || perp.OpenInterest != 1234.5 ||
+		!perp.SyntheticSpread || perp.Source != common.PriceSourceREST {
+		t.Fatalf("perp price = %+v", perp)
+	}
+	if perp.BidPrice >= perp.Price || perp.AskPrice <= perp.Price {
+		t.Fatalf("perp book %v/%v does not straddle last trade %v", perp.BidPrice, perp.AskPrice, perp.Price)
+	}
+	if spot.Symbol != "LITUSDC" || spot.MarketType != common.MarketTypeSpot || spot.QuoteCurrency != "USDC" ||
+		spot.Price != 2 || spot.Volume24h != 80 {
+		t.Fatalf("spot price = %+v", spot)
+	}
+}
+
+func TestFetchMarketDataRejectsErrorCode(t *testing.T) {
+	useCannedClient(t, `{"code":429,"order_book_details":[]}`)
+
+	_, err := fetchMarketDataOnce("https://lighter.invalid", []int{1})
+	var apiErr *common.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 429 || apiErr.Kind != common.ClassifyHTTPStatus(429) {
+		t.Fatalf("err = %v, want API error with code 429", err)
+	}
+}
+
+func TestFetchMarketsFromAPIUsesInjectedClient(t *testing.T) {
+	SetDefaultPerpQuote("USDT")
+	useCannedClient(t, `{"code":200,
+		"order_book_details":[{"symbol":"BTC","market_id":1,"status":"active"}],
+		"spot_order_book_details":[{"symbol":"ETH/USDC","market_id":2048,"status":"active"}]}`)
+
+	markets, err := FetchMarketsFromAPI("https://lighter.invalid/api/v1/orderBooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 2 || markets[0].Symbol != "BTCUSDT" || markets[0].Type != "perp" ||
+		markets[1].Symbol != "ETHUSDC" || markets[1].Type != "spot" {
+		t.Fatalf("markets = %+v", markets)
+	}
+}
+
+// useRESTFanout 设置本测试的并发请求数、超时和并发上限，清空价格缓存，结束后恢复默认值
+func useRESTFanout(t *testing.T, requests int, timeout time.Duration) {
+	t.Helper()
+	SetRESTFanout(requests, timeout)
+	SetMaxConcurrentRequests(maxParallelRequests)
+	priceCacheMu.Lock()
+	priceCache = make(map[string]*common.Price)
+	priceCacheMu.Unlock()
+	t.Cleanup(func() {
+		SetRESTFanout(3, 5*time.Second)
+		SetMaxConcurrentRequests(2)
+		priceCacheMu.Lock()
+		priceCache = make(map[string]*common.Price)
+		priceCacheMu.Unlock()
+	})
+}
+
+// newOrderBookDetailsServer 第 n 个请求返回 market_id 为 1..n 的 n 个活跃市场，fail 为 true 时返回 503
+func newOrderBookDetailsServer(t *testing.T, fail *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if fail != nil && fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		markets := make([]string, 0, n)
+		for id := 1; id <= n; id++ {
+			markets = append(markets, fmt.Sprintf(`{"market_id":%d,"symbol":"M%d","status":"active","last_trade_price":1}`, id, id))
+		}
+		fmt.Fprintf(w, `{"code":200,"order_book_details":[%s]}`, strings.Join(markets, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestFetchMarketDataFanout(t *testing.T) {
+	SetDefaultPerpQuote("USDT")
+	marketIDs := []int{1, 2, 3, 4, 5}
+
+	for _, tc := range []struct {
+		requests   int
+		wantPrices int
+	}{
+		{requests: 1, wantPrices: 1},
+		{requests: 5, wantPrices: 5}, // 取数据最多的结果
+	} {
+		t.Run(fmt.Sprintf("N=%d", tc.requests), func(t *testing.T) {
+			useRESTFanout(t, tc.requests, 5*time.Second)
+			server, requests := newOrderBookDetailsServer(t, nil)
+
+			prices, err := FetchMarketData(server.URL, marketIDs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := int(requests.Load()); got != tc.requests {
+				t.Fatalf("%d requests sent, want %d", got, tc.requests)
+			}
+			if len(prices) != tc.wantPrices {
+				t.Fatalf("%d prices returned, want %d", len(prices), tc.wantPrices)
+			}
+		})
+	}
+}
+
+func TestFetchMarketDataFallsBackToCache(t *testing.T) {
+	SetDefaultPerpQuote("USDT")
+	useRESTFanout(t, 3, 5*time.Second)
+	var fail atomic.Bool
+	server, requests := newOrderBookDetailsServer(t, &fail)
+
+	// 第一轮成功，最多的结果（3个市场）写入缓存
+	if prices, err := FetchMarketData(server.URL, []int{1, 2, 3}); err != nil || len(prices) != 3 {
+		t.Fatalf("first fetch = %d prices, err %v", len(prices), err)
+	}
+
+	// 所有请求都失败（非限频）时返回缓存中的价格
+	fail.Store(true)
+	prices, err := FetchMarketData(server.URL, []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("fetch with all requests failing: %v, want cached prices", err)
+	}
+	if got := requests.Load(); got != 6 {
+		t.Fatalf("%d requests sent, want 3 per round", got)
+	}
+	symbols := make(map[string]bool)
+	for _, price := range prices {
+		symbols[price.Symbol] = true
+	}
+	if len(prices) != 3 || !symbols["M1USDT"] || !symbols["M2USDT"] || !symbols["M3USDT"] {
+		t.Fatalf("cached prices = %v, want M1USDT..M3USDT", symbols)
+	}
+
+	// 没有缓存时返回错误
+	useRESTFanout(t, 3, 5*time.Second)
+	if _, err := FetchMarketData(server.URL, []int{1, 2, 3}); err == nil {
+		t.Fatal("fetch with all requests failing and no cache succeeded")
+	}
+}
+
+func TestSetRESTFanoutClamps(t *testing.T) {
+	t.Cleanup(func() { SetRESTFanout(3, 5*time.Second) })
+	SetRESTFanout(0, time.Millisecond)
+	if parallelRequests != minParallelRequests || requestTimeout != minRequestTimeout {
+		t.Fatalf("fanout = %d requests, %v timeout, want the minimums", parallelRequests, requestTimeout)
+	}
+	SetRESTFanout(9, time.Minute)
+	if parallelRequests != maxParallelRequests || requestTimeout != maxRequestTimeout {
+		t.Fatalf("fanout = %d requests, %v timeout, want the maximums", parallelRequests, requestTimeout)
+	}
+}
@@ -1,6 +1,7 @@
 package lighter
 
 import (
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 )
 
@@ -63,11 +64,25 @@ type MarketStatsData struct {
 	DailyPriceChange       float64 `json:"daily_price_change"`
 }
 
+// FundingRateValue 当前资金费率（优先 current_funding_rate，其次 funding_rate），字段缺失时为0
+func (m *MarketStatsData) FundingRateValue() float64 {
+	if rate := numutil.ParseFloat(m.CurrentFundingRate); rate != 0 {
+		return rate
+	}
+	return numutil.ParseFloat(m.FundingRate)
+}
+
+// OpenInterestValue 未平仓量，字段缺失时为0
+func (m *MarketStatsData) OpenInterestValue() float64 {
+	return numutil.ParseFloat(m.OpenInterest)
+}
+
 // Market 信息（从配置或 API 获取）
 type Market struct {
-	MarketID int    `json:"market_id"`
-	Symbol   string `json:"symbol"`
-	Type     string `json:"type"` // "perp" 或 "spot"
+	MarketID   int    `json:"market_id"`
+	Symbol     string `json:"symbol"`
+	Type       string `json:"type"`        // "perp" 或 "spot"
+	QuoteAsset string `json:"quote_asset"` // 报价货币（USDT / USDC），空表示USDT
 }
 
 // Order 订单结构（本地维护）
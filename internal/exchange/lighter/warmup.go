@@ -0,0 +1,70 @@
+package lighter
+
+import (
+	"sync"
+	"time"
+)
+
+// warmupTimeout 市场首次收到数据后等待完整报价（双边订单簿或mark price）的时长
+// 超时前只有部分订单簿时不发送价格，避免用估算的另一侧报价造成价格来回跳动
+const warmupTimeout = 3 * time.Second
+
+// warmupTracker 记录每个市场首次收到数据的时间（每次连接重新计时）
+type warmupTracker struct {
+	mu        sync.Mutex
+	timeout   time.Duration
+	firstSeen map[int]time.Time
+	timers    map[int]*time.Timer // 预热期结束时的回调（没有新消息时也能按时发送部分订单簿价格）
+}
+
+// newWarmupTracker 创建预热跟踪器
+func newWarmupTracker(timeout time.Duration) *warmupTracker {
+	return &warmupTracker{
+		timeout:   timeout,
+		firstSeen: make(map[int]time.Time),
+		timers:    make(map[int]*time.Timer),
+	}
+}
+
+// allowPartial 记录市场首次出现的时间，返回是否已过预热期（允许发送部分订单簿估算的价格）
+// 首次出现时 onTimeout 会在预热期结束时被调用一次，除非在此之前收到完整报价（complete）或重连（reset）
+func (w *warmupTracker) allowPartial(marketID int, now time.Time, onTimeout func()) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	first, exists := w.firstSeen[marketID]
+	if !exists {
+		w.firstSeen[marketID] = now
+		if w.timeout <= 0 {
+			return true
+		}
+		if onTimeout != nil {
+			w.timers[marketID] = time.AfterFunc(w.timeout, onTimeout)
+		}
+		return false
+	}
+	return now.Sub(first) >= w.timeout
+}
+
+// complete 市场已有完整报价，取消等待中的预热回调
+func (w *warmupTracker) complete(marketID int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if timer, exists := w.timers[marketID]; exists {
+		timer.Stop()
+		delete(w.timers, marketID)
+	}
+}
+
+// reset 清空所有市场的预热记录并取消回调（重连后旧连接的数据不再计入预热期）
+func (w *warmupTracker) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, timer := range w.timers {
+		timer.Stop()
+	}
+	w.firstSeen = make(map[int]time.Time)
+	w.timers = make(map[int]*time.Timer)
+}
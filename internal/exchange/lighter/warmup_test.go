@@ -0,0 +1,110 @@
+package lighter
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testWarmup = 50 * time.Millisecond
+
+// priceRecorder 并发安全地记录发送的价格（预热回调在定时器协程中发送）
+type priceRecorder struct {
+	mu     sync.Mutex
+	prices []*common.Price
+}
+
+func (r *priceRecorder) record(p *common.Price) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prices = append(r.prices, p)
+}
+
+func (r *priceRecorder) snapshot() []*common.Price {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*common.Price(nil), r.prices...)
+}
+
+func newWarmupConnection() (*WSPoolConnection, *priceRecorder) {
+	conn := NewWSPoolConnection(0, testMarkets(1))
+	conn.warmup = newWarmupTracker(testWarmup)
+	recorder := &priceRecorder{}
+	conn.priceHandler = recorder.record
+	return conn, recorder
+}
+
+const (
+	bidOnlySnapshot = `{"type":"subscribed/order_book","channel":"order_book:1","order_book":{"bids":[{"price":"100","size":"1"}],"asks":[]}}`
+	fullSnapshot    = `{"type":"subscribed/order_book","channel":"order_book:1","order_book":{"bids":[{"price":"100","size":"1"}],"asks":[{"price":"100.1","size":"1"}]}}`
+)
+
+func TestPartialBookEmittedAtWarmupTimeout(t *testing.T) {
+	conn, recorder := newWarmupConnection()
+
+	conn.processMessage([]byte(bidOnlySnapshot))
+	if n := len(recorder.snapshot()); n != 0 {
+		t.Fatalf("%d prices sent during warmup, want none", n)
+	}
+
+	// 之后没有任何消息，预热期结束时仍发送估算价格
+	waitFor(t, time.Second, "partial price at warmup timeout", func() bool { return len(recorder.snapshot()) == 1 })
+	price := recorder.snapshot()[0]
+	if !price.SyntheticSpread || price.BidPrice != 100 || price.AskPrice <= price.BidPrice {
+		t.Fatalf("partial price = %+v", price)
+	}
+}
+
+func TestFullBookBeforeTimeoutCancelsPartial(t *testing.T) {
+	conn, recorder := newWarmupConnection()
+
+	conn.processMessage([]byte(bidOnlySnapshot))
+	conn.processMessage([]byte(fullSnapshot))
+
+	prices := recorder.snapshot()
+	if len(prices) != 1 || prices[0].SyntheticSpread || prices[0].AskPrice != 100.1 {
+		t.Fatalf("prices = %+v, want one real two-sided price", prices)
+	}
+
+	// 预热回调已取消，不会在完整报价之后再发送估算价格
+	time.Sleep(3 * testWarmup)
+	if n := len(recorder.snapshot()); n != 1 {
+		t.Fatalf("%d prices after warmup timeout, want only the two-sided one", n)
+	}
+}
+
+func TestWarmupRestartsOnReconnect(t *testing.T) {
+	server := newFakeLighterServer(t, nil)
+	conn, recorder := newWarmupConnection()
+	conn.URL = server.wsURL()
+	conn.subscribeDelay = 0
+	conn.reconnect = false // 由测试手动重连
+
+	connect := func() {
+		t.Helper()
+		if err := conn.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		// 等订阅确认（空订单簿快照）处理完，避免覆盖测试注入的快照
+		waitFor(t, time.Second, "subscriptions confirmed", func() bool { return len(pendingChannels(conn)) == 0 })
+	}
+	connect()
+	// reconnect 已为 false，只关闭连接和后台协程（Close 写 reconnect 会与退出中的读循环竞争）
+	defer func() {
+		conn.closeConn()
+		close(conn.done)
+	}()
+
+	conn.processMessage([]byte(bidOnlySnapshot))
+	waitFor(t, time.Second, "partial price at warmup timeout", func() bool { return len(recorder.snapshot()) == 1 })
+
+	// 过了预热期的市场在新连接上重新预热
+	conn.closeConn()
+	connect()
+	conn.processMessage([]byte(bidOnlySnapshot))
+	if n := len(recorder.snapshot()); n != 1 {
+		t.Fatalf("%d prices right after reconnect, want partial book held back", n)
+	}
+	waitFor(t, time.Second, "partial price after reconnect warmup", func() bool { return len(recorder.snapshot()) == 2 })
+}
@@ -1,11 +1,13 @@
 package lighter
 
 import (
+	"crypto-arbitrage-monitor/internal/faults"
+	"crypto-arbitrage-monitor/internal/wsutil"
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"sync"
 	"time"
 
@@ -21,10 +23,14 @@ type WSClient struct {
 	marketStatsData map[int]*MarketStatsData
 	mu              sync.RWMutex
 	messageHandler  func(*common.Price)
-	reconnect       bool
+	reconnect       bool          // Close 后为 false，受 mu 保护
+	reconnectDelay  time.Duration // 断线后重连前的等待时间
+	subscribedAll   bool          // 是否使用 order_book/all 订阅（重连时按相同方式恢复）
 	done            chan struct{}
 	apiURL          string        // API URL for market updates
 	refreshInterval time.Duration // 市场刷新间隔
+	warmup          *warmupTracker
+	faultPoint      *faults.Point // 故障注入点（仅 -tags faults 构建生效）
 }
 
 // NewWSClient 创建新的 WebSocket 客户端
@@ -40,28 +46,32 @@ func NewWSClient(url string, markets []*Market, apiURL string, refreshInterval i
 		orderBookData:   make(map[int]*OrderBookData),
 		marketStatsData: make(map[int]*MarketStatsData),
 		reconnect:       true,
+		reconnectDelay:  5 * time.Second,
 		done:            make(chan struct{}),
 		apiURL:          apiURL,
+		warmup:          newWarmupTracker(warmupTimeout),
 	}
 
 	// 设置刷新间隔（存储在结构体中）
 	client.refreshInterval = time.Duration(refreshInterval) * time.Minute
+	client.faultPoint = faults.NewPoint("lighter-ws", func() {
+		if conn := client.getConn(); conn != nil {
+			conn.Close()
+		}
+	})
 
 	return client
 }
 
 // Connect 连接到 WebSocket
+// 读取、重连和重新订阅统一由 run 协程负责，整个生命周期只启动一次
 func (c *WSClient) Connect() error {
-	conn, _, err := websocket.DefaultDialer.Dial(c.URL, nil)
-	if err != nil {
-		return fmt.Errorf("failed to connect to %s: %v", c.URL, err)
+	if err := c.dial(); err != nil {
+		return err
 	}
 
-	c.Conn = conn
-	log.Printf("WebSocket connected to %s", c.URL)
-
-	// 启动读取协程
-	go c.readMessages()
+	// 启动监督协程（读取 + 断线重连）
+	go c.run()
 
 	// 启动心跳保活
 	go c.keepAlive()
@@ -75,6 +85,85 @@ func (c *WSClient) Connect() error {
 	return nil
 }
 
+// dial 建立底层连接
+func (c *WSClient) dial() error {
+	conn, _, err := wsutil.NewDialer("").Dial(c.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", c.URL, err)
+	}
+
+	c.mu.Lock()
+	c.Conn = conn
+	c.mu.Unlock()
+
+	// 新连接重新计算预热期
+	c.warmup.reset()
+
+	log.Printf("WebSocket connected to %s", c.URL)
+	return nil
+}
+
+// getConn 获取当前连接
+func (c *WSClient) getConn() *websocket.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Conn
+}
+
+// run 监督循环：读取消息直到连接断开，然后重连并恢复订阅
+func (c *WSClient) run() {
+	for {
+		c.readMessages()
+
+		// 断线后持续重试，直到连接成功或客户端关闭
+		for {
+			c.mu.RLock()
+			reconnect := c.reconnect
+			c.mu.RUnlock()
+			if !reconnect {
+				return
+			}
+
+			log.Printf("Reconnecting WebSocket in %v...", c.reconnectDelay)
+			select {
+			case <-c.done:
+				return
+			case <-time.After(c.reconnectDelay):
+			}
+
+			if err := c.dial(); err != nil {
+				common.DedupLog.Printf("lighter-ws", "Failed to reconnect: %v", err)
+				continue
+			}
+
+			if err := c.resubscribe(); err != nil {
+				log.Printf("Failed to resubscribe: %v", err)
+				if conn := c.getConn(); conn != nil {
+					conn.Close()
+				}
+				continue
+			}
+			break
+		}
+	}
+}
+
+// resubscribe 按原订阅方式恢复订阅
+func (c *WSClient) resubscribe() error {
+	c.mu.RLock()
+	subscribedAll := c.subscribedAll
+	marketIDs := make([]int, 0, len(c.markets))
+	for id := range c.markets {
+		marketIDs = append(marketIDs, id)
+	}
+	c.mu.RUnlock()
+
+	if subscribedAll {
+		return c.SubscribeAll()
+	}
+	return c.Subscribe(marketIDs)
+}
+
 // SetMessageHandler 设置消息处理器
 func (c *WSClient) SetMessageHandler(handler func(*common.Price)) {
 	c.messageHandler = handler
@@ -82,7 +171,8 @@ func (c *WSClient) SetMessageHandler(handler func(*common.Price)) {
 
 // Subscribe 订阅市场数据
 func (c *WSClient) Subscribe(marketIDs []int) error {
-	if c.Conn == nil {
+	conn := c.getConn()
+	if conn == nil {
 		return fmt.Errorf("websocket not connected")
 	}
 
@@ -93,7 +183,7 @@ func (c *WSClient) Subscribe(marketIDs []int) error {
 			Type:    "subscribe",
 			Channel: fmt.Sprintf("order_book/%d", marketID),
 		}
-		if err := c.Conn.WriteJSON(orderBookSub); err != nil {
+		if err := conn.WriteJSON(orderBookSub); err != nil {
 			return fmt.Errorf("failed to subscribe to order_book/%d: %v", marketID, err)
 		}
 
@@ -102,7 +192,7 @@ func (c *WSClient) Subscribe(marketIDs []int) error {
 			Type:    "subscribe",
 			Channel: fmt.Sprintf("market_stats/%d", marketID),
 		}
-		if err := c.Conn.WriteJSON(marketStatsSub); err != nil {
+		if err := conn.WriteJSON(marketStatsSub); err != nil {
 			return fmt.Errorf("failed to subscribe to market_stats/%d: %v", marketID, err)
 		}
 	}
@@ -113,7 +203,8 @@ func (c *WSClient) Subscribe(marketIDs []int) error {
 
 // SubscribeAll 订阅所有市场（使用 order_book/all 和 market_stats/all）
 func (c *WSClient) SubscribeAll() error {
-	if c.Conn == nil {
+	conn := c.getConn()
+	if conn == nil {
 		return fmt.Errorf("websocket not connected")
 	}
 
@@ -122,7 +213,7 @@ func (c *WSClient) SubscribeAll() error {
 		Type:    "subscribe",
 		Channel: "order_book/all",
 	}
-	if err := c.Conn.WriteJSON(orderBookSub); err != nil {
+	if err := conn.WriteJSON(orderBookSub); err != nil {
 		return fmt.Errorf("failed to subscribe to order_book/all: %v", err)
 	}
 
@@ -131,46 +222,42 @@ func (c *WSClient) SubscribeAll() error {
 		Type:    "subscribe",
 		Channel: "market_stats/all",
 	}
-	if err := c.Conn.WriteJSON(marketStatsSub); err != nil {
+	if err := conn.WriteJSON(marketStatsSub); err != nil {
 		return fmt.Errorf("failed to subscribe to market_stats/all: %v", err)
 	}
 
+	c.mu.Lock()
+	c.subscribedAll = true
+	c.mu.Unlock()
+
 	log.Printf("Subscribed to order_book/all and market_stats/all")
 	return nil
 }
 
-// readMessages 读取 WebSocket 消息
+// readMessages 读取 WebSocket 消息，连接断开或客户端关闭时返回
 func (c *WSClient) readMessages() {
-	defer func() {
-		if c.reconnect {
-			log.Println("Reconnecting WebSocket in 5 seconds...")
-			time.Sleep(5 * time.Second)
-			if err := c.Connect(); err != nil {
-				log.Printf("Failed to reconnect: %v", err)
-			} else {
-				// 重新订阅
-				marketIDs := make([]int, 0, len(c.markets))
-				for id := range c.markets {
-					marketIDs = append(marketIDs, id)
-				}
-				c.Subscribe(marketIDs)
-			}
-		}
-	}()
+	conn := c.getConn()
+	if conn == nil {
+		return
+	}
 
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			_, message, err := c.Conn.ReadMessage()
+			_, message, err := conn.ReadMessage()
 			if err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("WebSocket error: %v", err)
 				}
+				conn.Close()
 				return
 			}
 
+			if !c.faultPoint.Filter() {
+				continue
+			}
 			c.processMessage(message)
 		}
 	}
@@ -282,12 +369,21 @@ func (c *WSClient) sendCombinedPrice(marketID int) {
 		return
 	}
 
+	// 预热期内只有部分订单簿时等待另一侧或mark price，超时时由回调发送估算价格（不依赖后续消息）
+	if !hasBothSides && !hasMarkPrice {
+		if !c.warmup.allowPartial(marketID, time.Now(), func() { c.sendCombinedPrice(marketID) }) {
+			return
+		}
+	} else {
+		c.warmup.complete(marketID)
+	}
+
 	// 使用 mark_price 作为基准价格，而不是 order book 价格
 	var markPrice float64
 	var bidPrice, askPrice, bidQty, askQty float64
 
 	if hasMarketStats {
-		markPrice = parseFloat(marketStats.MarkPrice)
+		markPrice = numutil.ParseFloat(marketStats.MarkPrice)
 	}
 
 	// 如果没有mark price但有完整order book，使用order book中间价
@@ -316,6 +412,12 @@ func (c *WSClient) sendCombinedPrice(marketID int) {
 		}
 	}
 
+	// 没有双边真实报价时，bid/ask 中至少一侧为估算值
+	synthetic := !hasBothSides
+	if hasBothSides {
+		realizedSpreads.record(marketID, bidPrice, askPrice, time.Now())
+	}
+
 	if !hasBothSides && hasPartialOrderBook {
 		// 只有部分order book数据
 		if len(orderBook.Bids) > 0 {
@@ -371,7 +473,7 @@ func (c *WSClient) sendCombinedPrice(marketID int) {
 	// 获取时间戳
 	var timestamp time.Time
 	if hasOrderBook && orderBook.Timestamp > 0 {
-		timestamp = time.UnixMilli(orderBook.Timestamp)
+		timestamp = common.UnixMilliUTC(orderBook.Timestamp)
 	} else {
 		timestamp = time.Now()
 	}
@@ -387,9 +489,23 @@ func (c *WSClient) sendCombinedPrice(marketID int) {
 		BidQty:      bidQty,
 		AskQty:      askQty,
 		Volume24h:   volume24h,
-		Timestamp:   timestamp,              // 使用交易所时间
-		LastUpdated: time.Now(),             // 本地接收时间
+		VolumeKnown: hasMarketStats,
+		Timestamp:   timestamp,                   // 使用交易所时间
+		LastUpdated: time.Now(),                  // 本地接收时间
 		Source:      common.PriceSourceWebSocket, // WebSocket数据源
+
+		QuoteCurrency: common.QuoteCurrency(market.QuoteAsset), // 为空时由 PriceStore 根据symbol识别
+
+		SyntheticSpread: synthetic,
+	}
+
+	// 永续合约附带 market_stats 中的资金费率、未平仓量和标记/指数价格
+	if hasMarketStats && marketType == common.MarketTypeFuture {
+		price.FundingRate = marketStats.FundingRateValue()
+		price.FundingKnown = true
+		price.OpenInterest = marketStats.OpenInterestValue()
+		price.MarkPrice = numutil.ParseFloat(marketStats.MarkPrice)
+		price.IndexPrice = numutil.ParseFloat(marketStats.IndexPrice)
 	}
 
 	c.messageHandler(price)
@@ -405,10 +521,10 @@ func (c *WSClient) keepAlive() {
 		case <-c.done:
 			return
 		case <-ticker.C:
-			if c.Conn != nil {
-				if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			// 发送失败只记录日志，重连由 run 协程负责
+			if conn := c.getConn(); conn != nil {
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 					log.Printf("Failed to send ping: %v", err)
-					return
 				}
 			}
 		}
@@ -417,24 +533,19 @@ func (c *WSClient) keepAlive() {
 
 // Close 关闭连接
 func (c *WSClient) Close() error {
+	c.mu.Lock()
 	c.reconnect = false
+	c.mu.Unlock()
 	close(c.done)
+	c.faultPoint.Release()
+	c.warmup.reset()
 
-	if c.Conn != nil {
-		return c.Conn.Close()
+	if conn := c.getConn(); conn != nil {
+		return conn.Close()
 	}
 	return nil
 }
 
-// parseFloat 解析字符串为 float64
-func parseFloat(s string) float64 {
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0
-	}
-	return f
-}
-
 // refreshMarkets 定期刷新市场列表
 func (c *WSClient) refreshMarkets() {
 	ticker := time.NewTicker(c.refreshInterval)
@@ -453,7 +564,7 @@ func (c *WSClient) refreshMarkets() {
 // updateMarkets 更新市场列表
 func (c *WSClient) updateMarkets() {
 	log.Println("Refreshing Lighter markets from API...")
-	
+
 	newMarkets, err := FetchMarketsFromAPI(c.apiURL)
 	if err != nil {
 		log.Printf("Failed to refresh markets: %v", err)
@@ -511,8 +622,8 @@ func (c *WSClient) getBestBid(bids []PriceLevel) (float64, float64, bool) {
 	found := false
 
 	for _, bid := range bids {
-		price := parseFloat(bid.Price)
-		size := parseFloat(bid.Size)
+		price := numutil.ParseFloat(bid.Price)
+		size := numutil.ParseFloat(bid.Size)
 
 		if price == 0 || size == 0 {
 			continue
@@ -547,8 +658,8 @@ func (c *WSClient) getBestAsk(asks []PriceLevel) (float64, float64, bool) {
 	found := false
 
 	for _, ask := range asks {
-		price := parseFloat(ask.Price)
-		size := parseFloat(ask.Size)
+		price := numutil.ParseFloat(ask.Price)
+		size := numutil.ParseFloat(ask.Size)
 
 		if price == 0 || size == 0 {
 			continue
@@ -0,0 +1,46 @@
+package lighter
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWSClientReconnectsWithoutLeakingGoroutines(t *testing.T) {
+	server := newFakeLighterServer(t, nil)
+	client := NewWSClient(server.wsURL(), testMarkets(1, 2), "", 0)
+	client.reconnectDelay = 10 * time.Millisecond
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Subscribe([]int{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, "initial subscription", func() bool { return server.attemptsFor("order_book/2") == 1 })
+
+	var baseline int
+	for i := 1; i <= 5; i++ {
+		// 模拟断线：关闭客户端当前连接，监督循环应重连并恢复订阅
+		client.getConn().Close()
+		want := i + 1
+		waitFor(t, 2*time.Second, "resubscription after reconnect", func() bool {
+			return server.attemptsFor("order_book/1") >= want && server.attemptsFor("market_stats/2") >= want
+		})
+
+		// 每次重连只恢复一次订阅（没有叠加的读取协程重复订阅）
+		time.Sleep(30 * time.Millisecond)
+		for _, channel := range []string{"order_book/1", "order_book/2", "market_stats/1", "market_stats/2"} {
+			if n := server.attemptsFor(channel); n != want {
+				t.Fatalf("reconnect %d: %s subscribed %d times, want %d", i, channel, n, want)
+			}
+		}
+
+		n := runtime.NumGoroutine()
+		if i == 1 {
+			baseline = n
+		} else if n > baseline+2 {
+			t.Fatalf("reconnect %d: %d goroutines, started from %d", i, n, baseline)
+		}
+	}
+}
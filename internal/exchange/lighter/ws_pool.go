@@ -1,8 +1,12 @@
 package lighter
 
 import (
+	"crypto-arbitrage-monitor/internal/faults"
+	"crypto-arbitrage-monitor/internal/wsutil"
 	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -14,29 +18,74 @@ import (
 // WSPool Lighter WebSocket 连接池
 // 解决 order_book/all 不支持的问题，使用分片订阅模式
 type WSPool struct {
-	markets           []*Market                   // 所有需要订阅的市场
-	connections       []*WSPoolConnection         // WebSocket 连接池
-	priceHandler      func(*common.Price)         // 价格处理器
-	marketsPerConn    int                         // 每个连接订阅的市场数量
-	mu                sync.RWMutex
-	done              chan struct{}
+	markets          []*Market                    // 所有需要订阅的市场
+	connections      []*WSPoolConnection          // WebSocket 连接池
+	priceHandler     func(*common.Price)          // 价格处理器
+	marketsPerConn   int                          // 每个连接订阅的市场数量
+	maxConnections   int                          // 最大连接数（0表示不限制）
+	subscribeDelay   time.Duration                // 相邻订阅消息之间的间隔
+	startStagger     time.Duration                // 相邻连接启动之间的间隔
+	reconnectLimiter *wsutil.ReconnectLimiter     // 池内共享的重连限速器（nil表示不限速）
+	onDemand         map[int]bool                 // 按需订阅（AddMarket）的市场，Reload 不会移除
+	lastConnID       int                          // 下一个新连接的编号
+	url              string                       // WebSocket 地址
+	registry         *wsutil.SubscriptionRegistry // 市场类型+symbol -> 连接编号，所有订阅/退订都经过登记
+	mu               sync.RWMutex
+	done             chan struct{}
+}
+
+const (
+	defaultSubscribeDelay = 50 * time.Millisecond                      // 默认订阅消息间隔
+	defaultStartStagger   = 500 * time.Millisecond                     // 默认连接启动间隔
+	defaultConfirmTimeout = 10 * time.Second                           // 默认等待订阅确认的超时
+	maxSubscribeRetries   = 3                                          // 未确认频道的最大重发次数
+	defaultPoolURL        = "wss://mainnet.zklighter.elliot.ai/stream" // 默认 WebSocket 地址
+)
+
+// depthVWAPNotional 计算深度加权bid/ask的名义金额（USDT），0表示不计算（默认）
+var depthVWAPNotional float64
+
+// SetDepthVWAPNotional 设置深度加权bid/ask的名义金额：本地订单簿可用时按该金额逐档计算成交均价，
+// 写入 Price.DepthBidPrice / DepthAskPrice。0表示不计算
+func SetDepthVWAPNotional(notional float64) {
+	if notional < 0 {
+		notional = 0
+	}
+	depthVWAPNotional = notional
+}
+
+// PoolStats 连接池统计信息
+type PoolStats struct {
+	Connections          int                 `json:"connections"`
+	Markets              int                 `json:"markets"`
+	UnsubscribedChannels []string            `json:"unsubscribed_channels"` // 重试后仍未确认的频道
+	HistoricalSpreads    []MarketSpreadStats `json:"historical_spreads"`    // 各市场最近一小时的买一卖一价差（REST估算bid/ask使用）
 }
 
 // WSPoolConnection 单个 WebSocket 连接
 type WSPoolConnection struct {
-	ID                int
-	URL               string
-	Conn              *websocket.Conn
-	Markets           []*Market
-	orderBookData     map[int]*OrderBookData     // 快照数据（兼容旧逻辑）
-	marketStatsData   map[int]*MarketStatsData
-	localOrderBooks   map[int]*LocalOrderBook    // 本地维护的订单簿（增量更新）
-	mu                sync.RWMutex
-	reconnect         bool
-	done              chan struct{}
-	connectedAt       time.Time
-	lastPongTime      time.Time
-	priceHandler      func(*common.Price)
+	ID               int
+	URL              string
+	Conn             *websocket.Conn
+	Markets          []*Market
+	orderBookData    map[int]*OrderBookData // 快照数据（兼容旧逻辑）
+	marketStatsData  map[int]*MarketStatsData
+	localOrderBooks  map[int]*LocalOrderBook // 本地维护的订单簿（增量更新）
+	mu               sync.RWMutex
+	reconnect        bool
+	done             chan struct{}
+	connectedAt      time.Time
+	lastPongTime     time.Time
+	priceHandler     func(*common.Price)
+	writeMu          sync.Mutex // 串行化写操作（gorilla/websocket 不支持并发写）
+	subscribeDelay   time.Duration
+	confirmTimeout   time.Duration // 等待订阅确认的超时，超时后重发未确认的频道
+	reconnectLimiter *wsutil.ReconnectLimiter
+	onReconnect      func()          // 断线重连成功后调用（连接池对账）
+	faultPoint       *faults.Point   // 故障注入点（仅 -tags faults 构建生效）
+	pendingSubs      map[string]int  // 已发送但未确认的频道 -> 已发送次数
+	unsubscribed     map[string]bool // 重试后仍未确认的频道
+	warmup           *warmupTracker  // 各市场首次收到数据的时间（部分订单簿预热）
 }
 
 // NewWSPool 创建 Lighter WebSocket 连接池
@@ -49,10 +98,86 @@ func NewWSPool(markets []*Market, marketsPerConn int) *WSPool {
 		markets:        markets,
 		connections:    make([]*WSPoolConnection, 0),
 		marketsPerConn: marketsPerConn,
+		subscribeDelay: defaultSubscribeDelay,
+		startStagger:   defaultStartStagger,
+		onDemand:       make(map[int]bool),
+		url:            defaultPoolURL,
+		registry:       wsutil.NewSubscriptionRegistry(string(common.ExchangeLighter)),
 		done:           make(chan struct{}),
 	}
 }
 
+// SetSubscriptionRegistry 使用共享的订阅登记（例如 /api/connections 在连接池启动前就需要引用），需要在 Start 之前调用
+func (p *WSPool) SetSubscriptionRegistry(registry *wsutil.SubscriptionRegistry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.registry = registry
+}
+
+// registryKey 市场在订阅登记中的市场类型和symbol
+func registryKey(market *Market) (marketType, symbol string) {
+	if market.Type == "perp" {
+		return string(common.MarketTypeFuture), market.Symbol
+	}
+	return string(common.MarketTypeSpot), market.Symbol
+}
+
+// SetSubscribePacing 设置订阅节奏：相邻订阅消息间隔、相邻连接启动间隔
+func (p *WSPool) SetSubscribePacing(subscribeDelay, startStagger time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribeDelay = subscribeDelay
+	p.startStagger = startStagger
+}
+
+// SetReconnectRate 设置池内重连限速：每秒最多 rate 次重连，最多 burst 个连接可以立即重连（rate <= 0 表示不限速）
+// 需要在 Start 之前调用
+func (p *WSPool) SetReconnectRate(rate float64, burst int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reconnectLimiter = wsutil.NewReconnectLimiter(rate, burst)
+}
+
+// GetStats 获取连接池统计信息
+func (p *WSPool) GetStats() PoolStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := PoolStats{
+		Connections:          len(p.connections),
+		Markets:              len(p.markets),
+		UnsubscribedChannels: make([]string, 0),
+		HistoricalSpreads:    realizedSpreads.stats(time.Now()),
+	}
+	for _, conn := range p.connections {
+		stats.UnsubscribedChannels = append(stats.UnsubscribedChannels, conn.getUnsubscribedChannels()...)
+	}
+	return stats
+}
+
+// OrderBookDepth 获取某个市场本地订单簿的前 levels 档（symbol 为 Lighter 原始symbol）
+// 市场未订阅或订单簿尚未初始化时返回 false
+func (p *WSPool) OrderBookDepth(symbol string, marketType common.MarketType, levels int) ([]Order, []Order, bool) {
+	marketKind := "spot"
+	if marketType == common.MarketTypeFuture {
+		marketKind = "perp"
+	}
+
+	p.mu.RLock()
+	var orderBook *LocalOrderBook
+	for _, conn := range p.connections {
+		if orderBook = conn.findOrderBook(symbol, marketKind); orderBook != nil {
+			break
+		}
+	}
+	p.mu.RUnlock()
+
+	if orderBook == nil {
+		return nil, nil, false
+	}
+	return orderBook.TopLevels(levels)
+}
+
 // SetPriceHandler 设置价格处理器
 func (p *WSPool) SetPriceHandler(handler func(*common.Price)) {
 	p.mu.Lock()
@@ -60,40 +185,347 @@ func (p *WSPool) SetPriceHandler(handler func(*common.Price)) {
 	p.priceHandler = handler
 }
 
+// SetMaxConnections 设置最大连接数，超出时自动增大每个连接订阅的市场数量
+func (p *WSPool) SetMaxConnections(maxConnections int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxConnections = maxConnections
+}
+
 // Start 启动连接池
+// 错开启动和逐条发送订阅期间不持有连接池的锁，GetStats、AddMarket 等调用不会被阻塞
 func (p *WSPool) Start() error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 
-	// 计算需要的连接数
-	numConnections := (len(p.markets) + p.marketsPerConn - 1) / p.marketsPerConn
+	// 去重，同一个市场只分配到一个连接
+	unique := make([]*Market, 0, len(p.markets))
+	seen := make(map[int]bool, len(p.markets))
+	for _, market := range p.markets {
+		if !seen[market.MarketID] {
+			seen[market.MarketID] = true
+			unique = append(unique, market)
+		}
+	}
+	if dropped := len(p.markets) - len(unique); dropped > 0 {
+		log.Printf("[Lighter Pool] Dropped %d duplicate markets", dropped)
+	}
+
+	// 计算需要的连接数，超过上限时增大每个连接的市场数量，避免触发交易所连接数限制
+	adjusted, numConnections := wsutil.PlanConnections(len(unique), p.marketsPerConn, p.maxConnections)
+	if adjusted != p.marketsPerConn {
+		log.Printf("[Lighter Pool] Connections capped at %d, raising markets/conn from %d to %d",
+			p.maxConnections, p.marketsPerConn, adjusted)
+		p.marketsPerConn = adjusted
+	}
 	log.Printf("[Lighter Pool] Starting %d WebSocket connections for %d markets (%d markets/conn)",
-		numConnections, len(p.markets), p.marketsPerConn)
+		numConnections, len(unique), p.marketsPerConn)
 
-	// 创建连接
+	// 启动失败的连接上的市场不算已订阅（之后的 Reload 会重新追加）
+	marketsPerConn, startStagger := p.marketsPerConn, p.startStagger
+	p.markets = make([]*Market, 0, len(unique))
+	p.mu.Unlock()
+
+	// 创建连接（错开启动，避免所有连接同时发送大量订阅消息）
+	started := 0
 	for i := 0; i < numConnections; i++ {
-		startIdx := i * p.marketsPerConn
-		endIdx := startIdx + p.marketsPerConn
-		if endIdx > len(p.markets) {
-			endIdx = len(p.markets)
+		if i > 0 && startStagger > 0 {
+			select {
+			case <-p.done:
+				return nil
+			case <-time.After(startStagger):
+			}
 		}
 
-		markets := p.markets[startIdx:endIdx]
-		conn := NewWSPoolConnection(i, markets)
-		conn.SetPriceHandler(p.priceHandler)
+		startIdx := i * marketsPerConn
+		endIdx := startIdx + marketsPerConn
+		if endIdx > len(unique) {
+			endIdx = len(unique)
+		}
+		if p.startConnection(unique[startIdx:endIdx]) {
+			started++
+		}
+	}
 
-		if err := conn.Connect(); err != nil {
-			log.Printf("[Lighter Pool] Failed to start connection #%d: %v", i, err)
+	log.Printf("[Lighter Pool] Successfully started %d/%d connections", started, numConnections)
+	return nil
+}
+
+// startConnection Start 中启动一个连接：持锁登记市场，连接和订阅时释放锁，成功后加入连接池
+// 启动期间已被 AddMarket 等订阅的市场跳过；返回连接是否启动成功
+func (p *WSPool) startConnection(markets []*Market) bool {
+	p.mu.Lock()
+	id := p.nextConnectionID()
+	claimed := make([]*Market, 0, len(markets))
+	for _, market := range markets {
+		marketType, symbol := registryKey(market)
+		if err := p.registry.Claim(marketType, symbol, id, wsutil.SubscriptionSourceStart); err != nil {
+			log.Printf("[Lighter Pool] Skipping %s on connection #%d: %v", market.Symbol, id, err)
 			continue
 		}
+		claimed = append(claimed, market)
+	}
+	if len(claimed) == 0 {
+		p.mu.Unlock()
+		return false
+	}
+	conn := p.newConnectionLocked(id, claimed)
+	p.mu.Unlock()
+
+	err := conn.Connect()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
+	closed := false
+	select {
+	case <-p.done:
+		closed = true
+	default:
+	}
+	if err != nil || closed {
+		if err != nil {
+			log.Printf("[Lighter Pool] Failed to start connection #%d: %v", conn.ID, err)
+		} else {
+			conn.Close()
+		}
+		p.registry.ReleaseConn(conn.ID)
+		return false
+	}
+
+	p.connections = append(p.connections, conn)
+	p.markets = append(p.markets, claimed...)
+	return true
+}
+
+// newConnectionLocked 创建使用连接池配置的连接（调用者需要持有锁）
+func (p *WSPool) newConnectionLocked(id int, markets []*Market) *WSPoolConnection {
+	conn := NewWSPoolConnection(id, markets)
+	conn.URL = p.url
+	conn.SetPriceHandler(p.priceHandler)
+	conn.subscribeDelay = p.subscribeDelay
+	conn.reconnectLimiter = p.reconnectLimiter
+	conn.onReconnect = func() { p.Reconcile() }
+	return conn
+}
+
+// AddMarket 运行中按需追加订阅一个市场，按需订阅的市场之后不会被 Reload 移除
+// 市场已经订阅时返回 *wsutil.SubscriptionConflictError（包含所在的连接编号）
+func (p *WSPool) AddMarket(market *Market) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	marketType, symbol := registryKey(market)
+	if connID, exists := p.registry.Lookup(marketType, symbol); exists {
+		return &wsutil.SubscriptionConflictError{
+			Exchange:   p.registry.Exchange(),
+			MarketType: marketType,
+			Symbol:     symbol,
+			ConnID:     connID,
+		}
+	}
+
+	if err := p.addMarketLocked(market, wsutil.SubscriptionSourceOnDemand); err != nil {
+		return err
+	}
+	p.onDemand[market.MarketID] = true
+	return nil
+}
+
+// EnsureMarket 确保市场已订阅（幂等）：已订阅时不做任何操作，返回是否新增
+// 用于刷新等不关心市场原来是否已订阅的调用方
+func (p *WSPool) EnsureMarket(market *Market) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ensureMarketLocked(market, wsutil.SubscriptionSourceReload)
+}
+
+// ensureMarketLocked 确保市场已订阅（调用者需要持有锁）
+func (p *WSPool) ensureMarketLocked(market *Market, source string) (bool, error) {
+	marketType, symbol := registryKey(market)
+	if _, exists := p.registry.Lookup(marketType, symbol); exists {
+		return false, nil
+	}
+	if err := p.addMarketLocked(market, source); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reload 将订阅的市场列表更新为 markets（例如市场列表刷新后），不重建连接池：
+// 新增的市场追加到现有连接或新连接，移除的市场在所在连接上退订，连接上的市场全部移除后关闭该连接，
+// 其余市场的订阅和本地订单簿保持不变。按需订阅（AddMarket）的市场不会被移除
+// 返回新增和移除的市场数；部分市场追加失败时返回错误，其余变更仍然生效
+func (p *WSPool) Reload(markets []*Market) (added, removed int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wanted := make(map[int]*Market, len(markets))
+	for _, market := range markets {
+		wanted[market.MarketID] = market
+	}
+
+	// 先移除，空出的容量可以用于新增的市场
+	kept := make([]*Market, 0, len(p.markets))
+	removedIDs := make(map[int]bool)
+	for _, market := range p.markets {
+		if wanted[market.MarketID] != nil || p.onDemand[market.MarketID] {
+			kept = append(kept, market)
+			continue
+		}
+		removedIDs[market.MarketID] = true
+	}
+	if len(removedIDs) > 0 {
+		p.removeMarketsLocked(removedIDs)
+		p.markets = kept
+		removed = len(removedIDs)
+	}
+
+	var errs []error
+	for _, market := range markets {
+		ok, err := p.ensureMarketLocked(market, wsutil.SubscriptionSourceReload)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			added++
+		}
+	}
+
+	log.Printf("[Lighter Pool] Reloaded markets: +%d -%d (%d markets on %d connections)", added, removed, len(p.markets), len(p.connections))
+	return added, removed, errors.Join(errs...)
+}
+
+// addMarketLocked 追加订阅一个市场（调用者需要持有锁，且市场尚未订阅）
+// 追加到订阅数最少的连接；所有连接都已满且未达到最大连接数时新建一个连接
+// 订阅前先在登记中占用市场，订阅失败时释放
+func (p *WSPool) addMarketLocked(market *Market, source string) error {
+	var target *WSPoolConnection
+	for _, conn := range p.connections {
+		if target == nil || conn.marketCount() < target.marketCount() {
+			target = conn
+		}
+	}
+
+	marketType, symbol := registryKey(market)
+	canGrow := p.maxConnections <= 0 || len(p.connections) < p.maxConnections
+	if target == nil || (target.marketCount() >= p.marketsPerConn && canGrow) {
+		conn := p.newConnectionLocked(p.nextConnectionID(), []*Market{market})
+		if err := p.registry.Claim(marketType, symbol, conn.ID, source); err != nil {
+			return err
+		}
+		if err := conn.Connect(); err != nil {
+			p.registry.Release(marketType, symbol, conn.ID)
+			return fmt.Errorf("failed to start connection for market %d: %w", market.MarketID, err)
+		}
 		p.connections = append(p.connections, conn)
+		p.markets = append(p.markets, market)
+		log.Printf("[Lighter Pool] Started connection #%d for %s (market %d)", conn.ID, market.Symbol, market.MarketID)
+		return nil
 	}
 
-	log.Printf("[Lighter Pool] Successfully started %d/%d connections", len(p.connections), numConnections)
+	if err := p.registry.Claim(marketType, symbol, target.ID, source); err != nil {
+		return err
+	}
+	if err := target.addMarket(market); err != nil {
+		p.registry.Release(marketType, symbol, target.ID)
+		return err
+	}
+	p.markets = append(p.markets, market)
 	return nil
 }
 
+// removeMarketsLocked 在所在连接上退订 ids 中的市场并取消登记，连接上的市场全部移除后关闭该连接（调用者需要持有锁）
+// 只修改连接，p.markets 由调用者更新
+func (p *WSPool) removeMarketsLocked(ids map[int]bool) {
+	connections := make([]*WSPoolConnection, 0, len(p.connections))
+	for _, conn := range p.connections {
+		removed := conn.removeMarkets(ids)
+		for _, market := range removed {
+			marketType, symbol := registryKey(market)
+			p.registry.Release(marketType, symbol, conn.ID)
+		}
+		if len(removed) > 0 && conn.marketCount() == 0 {
+			conn.Close()
+			p.registry.ReleaseConn(conn.ID)
+			log.Printf("[Lighter Pool] Closed connection #%d (no markets left)", conn.ID)
+			continue
+		}
+		connections = append(connections, conn)
+	}
+	p.connections = connections
+}
+
+// Reconcile 对账：按各连接实际订阅的市场修正登记，并退订同一个市场在多个连接上的重复订阅
+// 断线重连成功后自动执行，返回退订的重复订阅数
+func (p *WSPool) Reconcile() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	actual := make([]wsutil.Subscription, 0, len(p.markets))
+	for _, conn := range p.connections {
+		conn.mu.RLock()
+		for _, market := range conn.Markets {
+			marketType, symbol := registryKey(market)
+			actual = append(actual, wsutil.Subscription{MarketType: marketType, Symbol: symbol, ConnID: conn.ID})
+		}
+		conn.mu.RUnlock()
+	}
+
+	duplicates := p.registry.Reconcile(actual)
+	for _, dup := range duplicates {
+		for _, conn := range p.connections {
+			if conn.ID != dup.ConnID {
+				continue
+			}
+			ids := make(map[int]bool)
+			conn.mu.RLock()
+			for _, market := range conn.Markets {
+				if marketType, symbol := registryKey(market); marketType == dup.MarketType && symbol == dup.Symbol {
+					ids[market.MarketID] = true
+				}
+			}
+			conn.mu.RUnlock()
+			conn.removeMarkets(ids)
+			log.Printf("[Lighter Pool] Removed duplicate %s %s from connection #%d", dup.MarketType, dup.Symbol, conn.ID)
+		}
+	}
+
+	// 重复订阅移除后关闭空连接，并按连接重建市场列表
+	connections := make([]*WSPoolConnection, 0, len(p.connections))
+	markets := make([]*Market, 0, len(p.markets))
+	for _, conn := range p.connections {
+		if len(duplicates) > 0 && conn.marketCount() == 0 {
+			conn.Close()
+			p.registry.ReleaseConn(conn.ID)
+			log.Printf("[Lighter Pool] Closed connection #%d (no markets left)", conn.ID)
+			continue
+		}
+		conn.mu.RLock()
+		markets = append(markets, conn.Markets...)
+		conn.mu.RUnlock()
+		connections = append(connections, conn)
+	}
+	p.connections = connections
+	p.markets = markets
+	return len(duplicates)
+}
+
+// Registry 连接池的订阅登记
+func (p *WSPool) Registry() *wsutil.SubscriptionRegistry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.registry
+}
+
+// nextConnectionID 新连接的编号（调用者需要持有锁）
+// 编号不复用：Start 期间正在连接的连接还不在 p.connections 中
+func (p *WSPool) nextConnectionID() int {
+	id := p.lastConnID
+	p.lastConnID++
+	return id
+}
+
 // Close 关闭所有连接
 func (p *WSPool) Close() error {
 	close(p.done)
@@ -103,6 +535,7 @@ func (p *WSPool) Close() error {
 
 	for _, conn := range p.connections {
 		conn.Close()
+		p.registry.ReleaseConn(conn.ID)
 	}
 	return nil
 }
@@ -115,15 +548,31 @@ func NewWSPoolConnection(id int, markets []*Market) *WSPoolConnection {
 		localOrderBooks[market.MarketID] = NewLocalOrderBook(market.MarketID, market.Symbol)
 	}
 
-	return &WSPoolConnection{
+	c := &WSPoolConnection{
 		ID:              id,
-		URL:             "wss://mainnet.zklighter.elliot.ai/stream",
+		URL:             defaultPoolURL,
 		Markets:         markets,
 		orderBookData:   make(map[int]*OrderBookData),
 		marketStatsData: make(map[int]*MarketStatsData),
 		localOrderBooks: localOrderBooks,
 		reconnect:       true,
 		done:            make(chan struct{}),
+		subscribeDelay:  defaultSubscribeDelay,
+		confirmTimeout:  defaultConfirmTimeout,
+		pendingSubs:     make(map[string]int),
+		unsubscribed:    make(map[string]bool),
+		warmup:          newWarmupTracker(warmupTimeout),
+	}
+	c.faultPoint = faults.NewPoint(fmt.Sprintf("lighter-pool#%d", id), c.closeConn)
+	return c
+}
+
+// closeConn 关闭当前底层连接（不关闭 WSPoolConnection，读循环退出后正常重连）
+func (c *WSPoolConnection) closeConn() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Conn != nil {
+		c.Conn.Close()
 	}
 }
 
@@ -136,7 +585,7 @@ func (c *WSPoolConnection) SetPriceHandler(handler func(*common.Price)) {
 
 // Connect 连接到 WebSocket
 func (c *WSPoolConnection) Connect() error {
-	conn, _, err := websocket.DefaultDialer.Dial(c.URL, nil)
+	conn, _, err := wsutil.NewDialer("").Dial(c.URL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -148,6 +597,9 @@ func (c *WSPoolConnection) Connect() error {
 	c.lastPongTime = now
 	c.mu.Unlock()
 
+	// 新连接重新计算预热期
+	c.warmup.reset()
+
 	log.Printf("[Lighter Pool #%d] Connected, subscribing to %d markets", c.ID, len(c.Markets))
 
 	// 设置 Pong 处理器
@@ -158,56 +610,222 @@ func (c *WSPoolConnection) Connect() error {
 		return nil
 	})
 
-	// 订阅市场
-	if err := c.subscribe(); err != nil {
-		return fmt.Errorf("failed to subscribe: %w", err)
-	}
-
-	// 启动消息读取
+	// 先启动消息读取再订阅：逐条发送订阅期间服务端返回的快照和确认需要及时读取
 	go c.readMessages()
 
 	// 启动心跳检查
 	go c.keepAlive()
 
+	// 订阅市场
+	if err := c.subscribe(); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+
 	return nil
 }
 
 // subscribe 订阅市场
+// 按 subscribeDelay 间隔逐条发送，发送完成后后台等待确认并重发未确认的频道
 func (c *WSPoolConnection) subscribe() error {
-	c.mu.RLock()
+	c.mu.Lock()
 	markets := c.Markets
 	conn := c.Conn
-	c.mu.RUnlock()
+	c.pendingSubs = make(map[string]int)
+	c.mu.Unlock()
 
 	if conn == nil {
 		return fmt.Errorf("connection not established")
 	}
 
 	// 订阅每个市场的 order_book 和 market_stats
+	channels := make([]string, 0, len(markets)*2)
 	for _, market := range markets {
-		// 订阅 order book: order_book/{market_id}
-		orderBookSub := SubscribeMessage{
-			Type:    "subscribe",
-			Channel: fmt.Sprintf("order_book/%d", market.MarketID),
-		}
-		if err := conn.WriteJSON(orderBookSub); err != nil {
-			log.Printf("[Lighter Pool #%d] Failed to subscribe to order_book/%d: %v", c.ID, market.MarketID, err)
+		channels = append(channels,
+			fmt.Sprintf("order_book/%d", market.MarketID),
+			fmt.Sprintf("market_stats/%d", market.MarketID))
+	}
+
+	sent := c.sendSubscriptions(conn, channels)
+
+	log.Printf("[Lighter Pool #%d] Subscribed to %d markets (%d/%d channels sent)", c.ID, len(markets), sent, len(channels))
+
+	go c.verifySubscriptions(conn)
+	return nil
+}
+
+// marketCount 当前连接订阅的市场数量
+func (c *WSPoolConnection) marketCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.Markets)
+}
+
+// addMarket 在已建立的连接上追加订阅一个市场（重连时随 Markets 一起重新订阅）
+func (c *WSPoolConnection) addMarket(market *Market) error {
+	c.mu.Lock()
+	conn := c.Conn
+	if conn == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("connection #%d not established", c.ID)
+	}
+	// Markets 可能是连接池市场列表的子切片，复制后再追加，避免覆盖相邻连接的市场
+	c.Markets = append(c.Markets[:len(c.Markets):len(c.Markets)], market)
+	c.localOrderBooks[market.MarketID] = NewLocalOrderBook(market.MarketID, market.Symbol)
+	c.mu.Unlock()
+
+	channels := []string{
+		fmt.Sprintf("order_book/%d", market.MarketID),
+		fmt.Sprintf("market_stats/%d", market.MarketID),
+	}
+	// 发送失败说明连接已断开，重连后会随 Markets 一起订阅
+	sent := c.sendSubscriptions(conn, channels)
+
+	log.Printf("[Lighter Pool #%d] Added %s (market %d, %d/%d channels sent)", c.ID, market.Symbol, market.MarketID, sent, len(channels))
+	go c.verifySubscriptions(conn)
+	return nil
+}
+
+// removeMarkets 在该连接上退订 ids 中的市场并清除其本地数据，返回移除的市场
+// 退订消息发送失败不影响移除（断线重连时只会重新订阅剩余的 Markets）
+func (c *WSPoolConnection) removeMarkets(ids map[int]bool) []*Market {
+	c.mu.Lock()
+	conn := c.Conn
+	markets := make([]*Market, 0, len(c.Markets))
+	removed := make([]*Market, 0)
+	channels := make([]string, 0)
+	for _, market := range c.Markets {
+		if !ids[market.MarketID] {
+			markets = append(markets, market)
 			continue
 		}
+		removed = append(removed, market)
+		for _, channel := range []string{
+			fmt.Sprintf("order_book/%d", market.MarketID),
+			fmt.Sprintf("market_stats/%d", market.MarketID),
+		} {
+			channels = append(channels, channel)
+			delete(c.pendingSubs, channel)
+			delete(c.unsubscribed, channel)
+		}
+		delete(c.localOrderBooks, market.MarketID)
+		delete(c.orderBookData, market.MarketID)
+		delete(c.marketStatsData, market.MarketID)
+	}
+	c.Markets = markets
+	c.mu.Unlock()
 
-		// 订阅 market stats: market_stats/{market_id}
-		marketStatsSub := SubscribeMessage{
-			Type:    "subscribe",
-			Channel: fmt.Sprintf("market_stats/%d", market.MarketID),
+	if len(channels) == 0 || conn == nil {
+		return removed
+	}
+	for _, channel := range channels {
+		c.writeMu.Lock()
+		err := conn.WriteJSON(SubscribeMessage{Type: "unsubscribe", Channel: channel})
+		c.writeMu.Unlock()
+		if err != nil {
+			log.Printf("[Lighter Pool #%d] Failed to unsubscribe from %s: %v", c.ID, channel, err)
+			break
 		}
-		if err := conn.WriteJSON(marketStatsSub); err != nil {
-			log.Printf("[Lighter Pool #%d] Failed to subscribe to market_stats/%d: %v", c.ID, market.MarketID, err)
+	}
+	log.Printf("[Lighter Pool #%d] Removed %d markets (%d markets left)", c.ID, len(removed), c.marketCount())
+	return removed
+}
+
+// findOrderBook 查找该连接上某个市场的本地订单簿，不在该连接上时返回nil
+func (c *WSPoolConnection) findOrderBook(symbol, marketKind string) *LocalOrderBook {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, market := range c.Markets {
+		if market.Symbol == symbol && market.Type == marketKind {
+			return c.localOrderBooks[market.MarketID]
+		}
+	}
+	return nil
+}
+
+// sendSubscriptions 按节奏发送订阅消息，返回成功发送的数量
+func (c *WSPoolConnection) sendSubscriptions(conn *websocket.Conn, channels []string) int {
+	sent := 0
+	for i, channel := range channels {
+		if i > 0 && c.subscribeDelay > 0 {
+			select {
+			case <-c.done:
+				return sent
+			case <-time.After(c.subscribeDelay):
+			}
+		}
+
+		c.mu.Lock()
+		c.pendingSubs[channel]++
+		c.mu.Unlock()
+
+		c.writeMu.Lock()
+		err := conn.WriteJSON(SubscribeMessage{Type: "subscribe", Channel: channel})
+		c.writeMu.Unlock()
+		if err != nil {
+			log.Printf("[Lighter Pool #%d] Failed to subscribe to %s: %v", c.ID, channel, err)
 			continue
 		}
+		sent++
 	}
+	return sent
+}
 
-	log.Printf("[Lighter Pool #%d] Subscribed to %d markets (order_book + market_stats)", c.ID, len(markets))
-	return nil
+// verifySubscriptions 等待订阅确认，超时未确认的频道重发，超过重试次数后标记为未订阅
+func (c *WSPoolConnection) verifySubscriptions(conn *websocket.Conn) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(c.confirmTimeout):
+		}
+
+		c.mu.Lock()
+		// 连接已被替换（重连），由新连接负责确认
+		if c.Conn != conn {
+			c.mu.Unlock()
+			return
+		}
+
+		retry := make([]string, 0)
+		for channel, attempts := range c.pendingSubs {
+			if attempts > maxSubscribeRetries {
+				c.unsubscribed[channel] = true
+				delete(c.pendingSubs, channel)
+				log.Printf("[Lighter Pool #%d] ✗ No confirmation for %s after %d attempts, marking as unsubscribed", c.ID, channel, attempts)
+				continue
+			}
+			retry = append(retry, channel)
+		}
+		c.mu.Unlock()
+
+		if len(retry) == 0 {
+			return
+		}
+
+		log.Printf("[Lighter Pool #%d] Re-sending %d unconfirmed subscriptions", c.ID, len(retry))
+		c.sendSubscriptions(conn, retry)
+	}
+}
+
+// confirmSubscription 收到订阅确认后移除待确认记录
+func (c *WSPoolConnection) confirmSubscription(channel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pendingSubs, channel)
+	delete(c.unsubscribed, channel)
+}
+
+// getUnsubscribedChannels 获取重试后仍未确认的频道
+func (c *WSPoolConnection) getUnsubscribedChannels() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	channels := make([]string, 0, len(c.unsubscribed))
+	for channel := range c.unsubscribed {
+		channels = append(channels, channel)
+	}
+	return channels
 }
 
 // readMessages 读取消息
@@ -223,12 +841,17 @@ func (c *WSPoolConnection) readMessages() {
 		}
 		c.mu.Unlock()
 
-		// 重连
+		// 重连（等待5秒后再按池内限速排队，避免所有连接同时重连）
 		if c.reconnect {
-			log.Printf("[Lighter Pool #%d] Reconnecting in 5 seconds...", c.ID)
+			common.DedupLog.Printf("lighter-pool", "[Lighter Pool #%d] Reconnecting in 5 seconds...", c.ID)
 			time.Sleep(5 * time.Second)
+			if !c.reconnectLimiter.Wait(c.done) {
+				return
+			}
 			if err := c.Connect(); err != nil {
-				log.Printf("[Lighter Pool #%d] Failed to reconnect: %v", c.ID, err)
+				common.DedupLog.Printf("lighter-pool", "[Lighter Pool #%d] Failed to reconnect: %v", c.ID, err)
+			} else if c.onReconnect != nil {
+				c.onReconnect()
 			}
 		}
 	}()
@@ -263,11 +886,17 @@ func (c *WSPoolConnection) readMessages() {
 				conn := c.Conn
 				c.mu.RUnlock()
 				if conn != nil {
+					c.writeMu.Lock()
 					conn.WriteMessage(websocket.PongMessage, message)
+					c.writeMu.Unlock()
 				}
 				continue
 			}
 
+			if !c.faultPoint.Filter() {
+				continue
+			}
+
 			messageCount++
 			c.processMessage(message)
 		}
@@ -312,6 +941,7 @@ func (c *WSPoolConnection) processMessage(message []byte) {
 			log.Printf("[Lighter Pool #%d] Failed to unmarshal market_stats snapshot: %v", c.ID, err)
 			return
 		}
+		c.confirmSubscription(fmt.Sprintf("market_stats/%d", statsSnapshot.MarketStats.MarketID))
 		c.handleMarketStatsUpdate(&statsSnapshot)
 
 	case "update/market_stats":
@@ -344,6 +974,8 @@ func (c *WSPoolConnection) handleOrderBookSnapshot(snapshot *OrderBookUpdate) {
 		}
 	}
 
+	c.confirmSubscription(fmt.Sprintf("order_book/%d", marketID))
+
 	c.mu.Lock()
 	c.orderBookData[marketID] = &snapshot.OrderBook
 
@@ -386,7 +1018,8 @@ func (c *WSPoolConnection) handleOrderBookIncrementalUpdate(update *OrderBookUpd
 	c.mu.RUnlock()
 
 	if !exists {
-		log.Printf("[Lighter Pool #%d] Local order book not found for market %d", c.ID, marketID)
+		// 市场刚被 Reload 移除时，退订生效前仍可能收到更新
+		common.DedupLog.Printf("lighter-pool", "[Lighter Pool #%d] Local order book not found for market %d", c.ID, marketID)
 		return
 	}
 
@@ -481,6 +1114,7 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 	marketStats, hasMarketStats := c.marketStatsData[marketID]
 
 	var bidPrice, askPrice, bidQty, askQty float64
+	var depthBidPrice, depthAskPrice float64
 	var markPrice float64
 	hasBothSides := false
 
@@ -497,12 +1131,20 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 			if hasBid && hasAsk {
 				hasBothSides = true
 				markPrice = (bidPrice + askPrice) / 2
+
+				// 深度加权价格只在本地订单簿两侧都可用时计算，快照和估算价格不提供
+				if depthVWAPNotional > 0 {
+					depthBidPrice, _ = localOB.DepthVWAP("bid", depthVWAPNotional)
+					depthAskPrice, _ = localOB.DepthVWAP("ask", depthVWAPNotional)
+				}
 			}
 		}
 	}
 
 	// 2. 如果本地订单簿没有数据，回退到快照数据（兼容性）
-	if !hasBothSides {
+	if hasBothSides {
+		c.warmup.complete(marketID)
+	} else {
 		orderBook, hasOrderBook := c.orderBookData[marketID]
 		hasPartialOrderBook := hasOrderBook && (len(orderBook.Bids) > 0 || len(orderBook.Asks) > 0)
 		hasMarkPrice := hasMarketStats && marketStats.MarkPrice != "" && marketStats.MarkPrice != "0"
@@ -513,6 +1155,15 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 
 		hasBothSides = hasOrderBook && len(orderBook.Bids) > 0 && len(orderBook.Asks) > 0
 
+		// 预热期内只有部分订单簿时等待另一侧或mark price，超时时由回调发送估算价格（不依赖后续消息）
+		if !hasBothSides && !hasMarkPrice {
+			if !c.warmup.allowPartial(marketID, time.Now(), func() { c.sendCombinedPrice(marketID) }) {
+				return
+			}
+		} else {
+			c.warmup.complete(marketID)
+		}
+
 		if hasBothSides {
 			var hasBid, hasAsk bool
 			bidPrice, bidQty, hasBid = c.getBestBid(orderBook.Bids)
@@ -551,7 +1202,7 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 		} else if !hasBothSides && hasMarkPrice {
 			// 只有mark price
 			if hasMarketStats {
-				markPrice = parseFloat(marketStats.MarkPrice)
+				markPrice = numutil.ParseFloat(marketStats.MarkPrice)
 			}
 			spread := markPrice * 0.0001
 			bidPrice = markPrice - spread
@@ -561,6 +1212,12 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 		}
 	}
 
+	// 没有双边真实报价时，bid/ask 中至少一侧为估算值
+	synthetic := !hasBothSides
+	if hasBothSides {
+		realizedSpreads.record(marketID, bidPrice, askPrice, time.Now())
+	}
+
 	// 解析交易量
 	var volume24h float64
 	if hasMarketStats {
@@ -576,7 +1233,7 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 	// 获取时间戳（尝试从快照数据获取，否则使用当前时间）
 	var timestamp time.Time
 	if orderBookData, exists := c.orderBookData[marketID]; exists && orderBookData.Timestamp > 0 {
-		timestamp = time.UnixMilli(orderBookData.Timestamp)
+		timestamp = common.UnixMilliUTC(orderBookData.Timestamp)
 	} else {
 		timestamp = time.Now()
 	}
@@ -592,9 +1249,26 @@ func (c *WSPoolConnection) sendCombinedPrice(marketID int) {
 		BidQty:      bidQty,
 		AskQty:      askQty,
 		Volume24h:   volume24h,
+		VolumeKnown: hasMarketStats,
 		Timestamp:   timestamp,
 		LastUpdated: time.Now(),
 		Source:      common.PriceSourceWebSocket,
+
+		QuoteCurrency: common.QuoteCurrency(market.QuoteAsset), // 为空时由 PriceStore 根据symbol识别
+
+		SyntheticSpread: synthetic,
+
+		DepthBidPrice: depthBidPrice,
+		DepthAskPrice: depthAskPrice,
+	}
+
+	// 永续合约附带 market_stats 中的资金费率、未平仓量和标记/指数价格
+	if hasMarketStats && marketType == common.MarketTypeFuture {
+		price.FundingRate = marketStats.FundingRateValue()
+		price.FundingKnown = true
+		price.OpenInterest = marketStats.OpenInterestValue()
+		price.MarkPrice = numutil.ParseFloat(marketStats.MarkPrice)
+		price.IndexPrice = numutil.ParseFloat(marketStats.IndexPrice)
 	}
 
 	c.priceHandler(price)
@@ -616,7 +1290,10 @@ func (c *WSPoolConnection) keepAlive() {
 			c.mu.RUnlock()
 
 			if conn != nil {
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.writeMu.Lock()
+				err := conn.WriteMessage(websocket.PingMessage, nil)
+				c.writeMu.Unlock()
+				if err != nil {
 					log.Printf("[Lighter Pool #%d] Failed to send ping: %v", c.ID, err)
 					return
 				}
@@ -633,6 +1310,8 @@ func (c *WSPoolConnection) keepAlive() {
 func (c *WSPoolConnection) Close() {
 	c.reconnect = false
 	close(c.done)
+	c.faultPoint.Release()
+	c.warmup.reset()
 
 	c.mu.Lock()
 	if c.Conn != nil {
@@ -652,8 +1331,8 @@ func (c *WSPoolConnection) getBestBid(bids []PriceLevel) (float64, float64, bool
 	found := false
 
 	for _, bid := range bids {
-		price := parseFloat(bid.Price)
-		size := parseFloat(bid.Size)
+		price := numutil.ParseFloat(bid.Price)
+		size := numutil.ParseFloat(bid.Size)
 
 		if price == 0 || size == 0 {
 			continue
@@ -688,8 +1367,8 @@ func (c *WSPoolConnection) getBestAsk(asks []PriceLevel) (float64, float64, bool
 	found := false
 
 	for _, ask := range asks {
-		price := parseFloat(ask.Price)
-		size := parseFloat(ask.Size)
+		price := numutil.ParseFloat(ask.Price)
+		size := numutil.ParseFloat(ask.Size)
 
 		if price == 0 || size == 0 {
 			continue
@@ -0,0 +1,343 @@
+package lighter
+
+import (
+	"crypto-arbitrage-monitor/internal/wsutil"
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeLighterServer 模拟 Lighter 行情 WebSocket：记录每个频道的订阅次数，ack 返回 true 时回复订阅确认
+type fakeLighterServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	attempts map[string]int
+	ack      func(channel string, attempt int) bool
+}
+
+func newFakeLighterServer(t *testing.T, ack func(channel string, attempt int) bool) *fakeLighterServer {
+	t.Helper()
+	s := &fakeLighterServer{attempts: make(map[string]int), ack: ack}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg SubscribeMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Type != "subscribe" {
+				continue
+			}
+			s.mu.Lock()
+			s.attempts[msg.Channel]++
+			attempt := s.attempts[msg.Channel]
+			s.mu.Unlock()
+			if s.ack != nil && !s.ack(msg.Channel, attempt) {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, subscribedReply(msg.Channel)); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// subscribedReply 订阅确认（不带订单簿和统计数据）
+func subscribedReply(channel string) []byte {
+	kind, id, _ := strings.Cut(channel, "/")
+	if kind == "order_book" {
+		return []byte(fmt.Sprintf(`{"type":"subscribed/order_book","channel":"order_book:%s","order_book":{"bids":[],"asks":[]}}`, id))
+	}
+	return []byte(fmt.Sprintf(`{"type":"subscribed/market_stats","channel":"market_stats:%s","market_stats":{"market_id":%s}}`, id, id))
+}
+
+func (s *fakeLighterServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func (s *fakeLighterServer) attemptsFor(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[channel]
+}
+
+func testMarkets(ids ...int) []*Market {
+	markets := make([]*Market, 0, len(ids))
+	for _, id := range ids {
+		markets = append(markets, &Market{MarketID: id, Symbol: fmt.Sprintf("M%d", id), Type: "perp"})
+	}
+	return markets
+}
+
+func pendingChannels(c *WSPoolConnection) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	channels := make([]string, 0, len(c.pendingSubs))
+	for channel := range c.pendingSubs {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// waitFor 轮询直到 cond 成立或超时
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWSPoolConnectionRetriesUnconfirmed(t *testing.T) {
+	// order_book/1 第二次才确认，order_book/2 始终不确认
+	server := newFakeLighterServer(t, func(channel string, attempt int) bool {
+		switch channel {
+		case "order_book/1":
+			return attempt >= 2
+		case "order_book/2":
+			return false
+		}
+		return true
+	})
+
+	conn := NewWSPoolConnection(0, testMarkets(1, 2))
+	conn.URL = server.wsURL()
+	conn.subscribeDelay = 0
+	conn.confirmTimeout = 50 * time.Millisecond
+	conn.SetPriceHandler(func(*common.Price) {})
+	if err := conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	waitFor(t, 3*time.Second, "order_book/2 marked unsubscribed", func() bool {
+		unsubscribed := conn.getUnsubscribedChannels()
+		return len(unsubscribed) == 1 && unsubscribed[0] == "order_book/2" && len(pendingChannels(conn)) == 0
+	})
+
+	if got := server.attemptsFor("order_book/1"); got != 2 {
+		t.Errorf("order_book/1 sent %d times, want 2", got)
+	}
+	if got := server.attemptsFor("order_book/2"); got != maxSubscribeRetries+1 {
+		t.Errorf("order_book/2 sent %d times, want %d", got, maxSubscribeRetries+1)
+	}
+	for _, channel := range []string{"market_stats/1", "market_stats/2"} {
+		if got := server.attemptsFor(channel); got != 1 {
+			t.Errorf("%s sent %d times, want 1", channel, got)
+		}
+	}
+}
+
+func TestWSPoolConnectionReadsWhileSubscribing(t *testing.T) {
+	server := newFakeLighterServer(t, nil)
+
+	conn := NewWSPoolConnection(0, testMarkets(1, 2))
+	conn.URL = server.wsURL()
+	conn.subscribeDelay = 100 * time.Millisecond
+	conn.SetPriceHandler(func(*common.Price) {})
+	if err := conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Connect 在发送完最后一条订阅后返回，之前的确认已经在发送间隔中被读取
+	if pending := pendingChannels(conn); len(pending) > 1 {
+		t.Fatalf("pending after subscribe = %v, want at most the last channel", pending)
+	}
+}
+
+func TestWSPoolStartDoesNotHoldLock(t *testing.T) {
+	server := newFakeLighterServer(t, nil)
+
+	pool := NewWSPool(testMarkets(1, 2, 3, 4), 2)
+	pool.url = server.wsURL()
+	pool.SetPriceHandler(func(*common.Price) {})
+	pool.SetSubscribePacing(100*time.Millisecond, 300*time.Millisecond)
+	defer pool.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- pool.Start() }()
+
+	// 第一个连接发送订阅、错开启动期间读取统计不被阻塞
+	for i := 0; i < 5; i++ {
+		time.Sleep(100 * time.Millisecond)
+		begin := time.Now()
+		pool.GetStats()
+		if elapsed := time.Since(begin); elapsed > 50*time.Millisecond {
+			t.Fatalf("GetStats blocked for %v during Start", elapsed)
+		}
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return")
+	}
+
+	stats := pool.GetStats()
+	if stats.Connections != 2 || stats.Markets != 4 {
+		t.Fatalf("stats = %+v, want 2 connections, 4 markets", stats)
+	}
+	if got := len(pool.Registry().Snapshot()); got != 4 {
+		t.Fatalf("registry has %d subscriptions, want 4", got)
+	}
+}
+
+func TestWSPoolStartRespectsMaxConnections(t *testing.T) {
+	server := newFakeLighterServer(t, nil)
+
+	ids := make([]int, 25)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	pool := NewWSPool(testMarkets(ids...), 2)
+	pool.url = server.wsURL()
+	pool.SetPriceHandler(func(*common.Price) {})
+	pool.SetSubscribePacing(0, 0)
+	pool.SetMaxConnections(4)
+	defer pool.Close()
+
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 25 个市场按每连接2个需要13个连接，上限4时每连接7个
+	stats := pool.GetStats()
+	if stats.Connections != 4 || stats.Markets != 25 {
+		t.Fatalf("stats = %+v, want 4 connections for 25 markets", stats)
+	}
+	for _, conn := range pool.connections {
+		if n := conn.marketCount(); n > 7 {
+			t.Fatalf("connection #%d has %d markets, want at most 7", conn.ID, n)
+		}
+	}
+}
+
+// marketHolders 实际订阅了该市场的连接
+func marketHolders(pool *WSPool, marketID int) []int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	holders := make([]int, 0)
+	for _, conn := range pool.connections {
+		conn.mu.RLock()
+		for _, market := range conn.Markets {
+			if market.MarketID == marketID {
+				holders = append(holders, conn.ID)
+			}
+		}
+		conn.mu.RUnlock()
+	}
+	return holders
+}
+
+func TestWSPoolRefreshRacingManualSubscribe(t *testing.T) {
+	for round := 0; round < 5; round++ {
+		server := newFakeLighterServer(t, nil)
+		pool := NewWSPool(testMarkets(1, 2), 2)
+		pool.url = server.wsURL()
+		pool.SetPriceHandler(func(*common.Price) {})
+		pool.SetSubscribePacing(0, 0)
+		if err := pool.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		// 市场列表刷新和 /api/subscribe 同时追加市场 3
+		var wg sync.WaitGroup
+		var reloadErr, addErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _, reloadErr = pool.Reload(testMarkets(1, 2, 3))
+		}()
+		go func() {
+			defer wg.Done()
+			addErr = pool.AddMarket(testMarkets(3)[0])
+		}()
+		wg.Wait()
+
+		if reloadErr != nil {
+			t.Fatalf("round %d: reload: %v", round, reloadErr)
+		}
+		var conflict *wsutil.SubscriptionConflictError
+		if addErr != nil && !errors.As(addErr, &conflict) {
+			t.Fatalf("round %d: manual subscribe: %v", round, addErr)
+		}
+
+		if holders := marketHolders(pool, 3); len(holders) != 1 {
+			t.Fatalf("round %d: market 3 subscribed on connections %v, want exactly one", round, holders)
+		}
+		connID, ok := pool.Registry().Lookup(string(common.MarketTypeFuture), "M3")
+		if !ok || connID != marketHolders(pool, 3)[0] {
+			t.Fatalf("round %d: registry has market 3 on #%d (ok=%v), connection holds it on %v", round, connID, ok, marketHolders(pool, 3))
+		}
+		waitFor(t, time.Second, "market 3 subscription", func() bool { return server.attemptsFor("order_book/3") >= 1 })
+		if n := server.attemptsFor("order_book/3"); n != 1 {
+			t.Fatalf("round %d: order_book/3 subscribed %d times, want 1", round, n)
+		}
+		if stats := pool.GetStats(); stats.Markets != 3 {
+			t.Fatalf("round %d: pool tracks %d markets, want 3", round, stats.Markets)
+		}
+		pool.Close()
+	}
+}
+
+func TestWSPoolReconcileRemovesDuplicates(t *testing.T) {
+	server := newFakeLighterServer(t, nil)
+	pool := NewWSPool(testMarkets(1, 2), 1)
+	pool.url = server.wsURL()
+	pool.SetPriceHandler(func(*common.Price) {})
+	pool.SetSubscribePacing(0, 0)
+	defer pool.Close()
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟重连后出现的重复订阅：市场 1 绕过登记又订阅在另一个连接上
+	owner, _ := pool.Registry().Lookup(string(common.MarketTypeFuture), "M1")
+	var other *WSPoolConnection
+	for _, conn := range pool.connections {
+		if conn.ID != owner {
+			other = conn
+		}
+	}
+	if err := other.addMarket(testMarkets(1)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if holders := marketHolders(pool, 1); len(holders) != 2 {
+		t.Fatalf("setup: market 1 on %v, want two connections", holders)
+	}
+
+	if removed := pool.Reconcile(); removed != 1 {
+		t.Fatalf("Reconcile removed %d duplicates, want 1", removed)
+	}
+	if holders := marketHolders(pool, 1); len(holders) != 1 || holders[0] != owner {
+		t.Fatalf("market 1 on %v after reconcile, want only the registered conn #%d", holders, owner)
+	}
+	if holders := marketHolders(pool, 2); len(holders) != 1 {
+		t.Fatalf("market 2 on %v after reconcile, want one connection", holders)
+	}
+	if removed := pool.Reconcile(); removed != 0 {
+		t.Fatalf("second Reconcile removed %d, want 0", removed)
+	}
+}
@@ -0,0 +1,219 @@
+package failover
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Role 实例当前角色
+type Role string
+
+const (
+	RoleLeader  Role = "leader"  // 负责对外通知（套利机会输出、webhook）
+	RoleStandby Role = "standby" // 继续采集数据，但不对外发送通知
+)
+
+// Beat 心跳内容（由 Transport 在两个实例之间交换）
+type Beat struct {
+	InstanceID string `json:"instance_id"`
+	Priority   int    `json:"priority"`
+	Role       Role   `json:"role"`
+	Seq        uint64 `json:"seq"` // 每次心跳递增，对端 Seq 变化才算收到新心跳（不依赖两台主机的时钟）
+}
+
+// outranks 判断 a 是否优先于 b 成为 leader：优先级高者优先，相同时 InstanceID 较小者优先
+func outranks(a, b *Beat) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.InstanceID < b.InstanceID
+}
+
+// Transport 心跳传输：发布本实例的心跳并读取对端最近的心跳
+// 对端从未出现过时返回 nil, nil；读取失败返回错误（都视为本轮未收到心跳）
+type Transport interface {
+	Exchange(self Beat) (*Beat, error)
+}
+
+// PeerHealth 对端状态（/api/health 返回）
+type PeerHealth struct {
+	InstanceID string    `json:"instance_id,omitempty"`
+	Priority   int       `json:"priority"`
+	Role       Role      `json:"role,omitempty"`
+	Healthy    bool      `json:"healthy"`
+	LastSeen   time.Time `json:"last_seen"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// Status 本实例的选主状态
+type Status struct {
+	InstanceID  string     `json:"instance_id"`
+	Priority    int        `json:"priority"`
+	Role        Role       `json:"role"`
+	RoleSince   time.Time  `json:"role_since"`
+	Timeout     string     `json:"timeout"`
+	Transitions int        `json:"transitions"`
+	Peer        PeerHealth `json:"peer"`
+}
+
+// Elector 双实例主备选举状态机
+//
+// 启动时为 standby；收到对端心跳后优先级高者为 leader，另一方为 standby（重连后低优先级实例降级）；
+// 对端心跳超过 timeout 未更新时 standby 提升为 leader。启动后 timeout 内一直没有对端心跳也提升为 leader
+type Elector struct {
+	mu sync.RWMutex
+
+	self      Beat
+	transport Transport
+	timeout   time.Duration
+
+	started     time.Time
+	roleSince   time.Time
+	transitions int
+
+	peer     *Beat
+	peerSeen time.Time // 最近一次收到新心跳（对端 Seq 变化）的时间
+	peerErr  string
+}
+
+// NewElector 创建选举器（初始为 standby）
+func NewElector(instanceID string, priority int, transport Transport, timeout time.Duration, now time.Time) *Elector {
+	return &Elector{
+		self: Beat{
+			InstanceID: instanceID,
+			Priority:   priority,
+			Role:       RoleStandby,
+		},
+		transport: transport,
+		timeout:   timeout,
+		started:   now,
+		roleSince: now,
+	}
+}
+
+// Role 当前角色
+func (e *Elector) Role() Role {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.self.Role
+}
+
+// IsLeader 当前是否为 leader
+func (e *Elector) IsLeader() bool {
+	return e.Role() == RoleLeader
+}
+
+// Beat 本实例当前的心跳（HTTP 心跳接口返回给对端）
+func (e *Elector) Beat() Beat {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.self
+}
+
+// Status 本实例和对端的状态
+func (e *Elector) Status(now time.Time) Status {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	status := Status{
+		InstanceID:  e.self.InstanceID,
+		Priority:    e.self.Priority,
+		Role:        e.self.Role,
+		RoleSince:   e.roleSince,
+		Timeout:     e.timeout.String(),
+		Transitions: e.transitions,
+		Peer: PeerHealth{
+			Healthy:   e.peerHealthy(now),
+			LastSeen:  e.peerSeen,
+			LastError: e.peerErr,
+		},
+	}
+	if e.peer != nil {
+		status.Peer.InstanceID = e.peer.InstanceID
+		status.Peer.Priority = e.peer.Priority
+		status.Peer.Role = e.peer.Role
+	}
+	return status
+}
+
+// Step 交换一次心跳并更新角色，返回更新后的角色
+func (e *Elector) Step(now time.Time) Role {
+	e.mu.Lock()
+	e.self.Seq++
+	self := e.self
+	e.mu.Unlock()
+
+	// 网络/磁盘IO不持有锁
+	peer, err := e.transport.Exchange(self)
+	return e.observe(now, peer, err)
+}
+
+// observe 根据本轮心跳结果更新状态机
+func (e *Elector) observe(now time.Time, peer *Beat, err error) Role {
+	e.mu.Lock()
+
+	e.peerErr = ""
+	if err != nil {
+		e.peerErr = err.Error()
+	} else if peer != nil {
+		if e.peer == nil || peer.Seq != e.peer.Seq || peer.InstanceID != e.peer.InstanceID {
+			e.peerSeen = now
+		}
+		e.peer = peer
+	}
+
+	from := e.self.Role
+	to, reason := e.decide(now)
+	if to != from {
+		e.self.Role = to
+		e.roleSince = now
+		e.transitions++
+	}
+	e.mu.Unlock()
+
+	if to != from {
+		log.Printf("[Failover] %s -> %s: %s", from, to, reason)
+	}
+	return to
+}
+
+// decide 计算应处的角色和原因（调用者需要持有锁）
+func (e *Elector) decide(now time.Time) (Role, string) {
+	if e.peerHealthy(now) {
+		if outranks(&e.self, e.peer) {
+			return RoleLeader, "peer " + e.peer.InstanceID + " is reachable and has lower priority"
+		}
+		return RoleStandby, "peer " + e.peer.InstanceID + " is reachable and has higher priority"
+	}
+
+	if e.peerSeen.IsZero() {
+		// 启动宽限期：等待对端心跳，避免两个实例同时启动时都发送通知
+		if now.Sub(e.started) < e.timeout {
+			return e.self.Role, ""
+		}
+		return RoleLeader, "no peer heartbeat within " + e.timeout.String() + " after start"
+	}
+	return RoleLeader, "peer " + e.peer.InstanceID + " missed heartbeats for " + now.Sub(e.peerSeen).Truncate(time.Second).String()
+}
+
+// peerHealthy 对端是否在 timeout 内发送过新心跳（调用者需要持有锁）
+func (e *Elector) peerHealthy(now time.Time) bool {
+	return e.peer != nil && !e.peerSeen.IsZero() && now.Sub(e.peerSeen) <= e.timeout
+}
+
+// Run 按 interval 定期交换心跳，直到 stopChan 关闭
+func (e *Elector) Run(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	e.Step(time.Now().UTC())
+	for {
+		select {
+		case <-stopChan:
+			return
+		case now := <-ticker.C:
+			e.Step(now)
+		}
+	}
+}
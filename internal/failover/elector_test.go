@@ -0,0 +1,137 @@
+package failover
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryLink 内存中的共享心跳，down 的实例既不发布心跳也读不到对端（模拟网络分区或进程停止）
+type memoryLink struct {
+	mu    sync.Mutex
+	beats map[string]Beat
+	down  map[string]bool
+}
+
+func newMemoryLink() *memoryLink {
+	return &memoryLink{beats: make(map[string]Beat), down: make(map[string]bool)}
+}
+
+func (l *memoryLink) setDown(instanceID string, down bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.down[instanceID] = down
+}
+
+// transport 实例 instanceID 使用的 Transport
+func (l *memoryLink) transport(instanceID string) Transport {
+	return transportFunc(func(self Beat) (*Beat, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.down[instanceID] {
+			return nil, errors.New("network unreachable")
+		}
+		l.beats[instanceID] = self
+		for id, beat := range l.beats {
+			if id != instanceID {
+				peer := beat
+				return &peer, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+type transportFunc func(self Beat) (*Beat, error)
+
+func (f transportFunc) Exchange(self Beat) (*Beat, error) { return f(self) }
+
+func TestElectorSingleInstance(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	e := NewElector("a", 10, newMemoryLink().transport("a"), 3*time.Second, start)
+
+	// 启动宽限期内保持 standby，超过 timeout 仍没有对端时提升为 leader
+	if role := e.Step(start.Add(time.Second)); role != RoleStandby {
+		t.Fatalf("role within the start grace period = %s, want standby", role)
+	}
+	if role := e.Step(start.Add(3 * time.Second)); role != RoleLeader {
+		t.Fatalf("role after the grace period = %s, want leader", role)
+	}
+	status := e.Status(start.Add(3 * time.Second))
+	if status.Transitions != 1 || !status.RoleSince.Equal(start.Add(3*time.Second)) || status.Peer.Healthy {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestElectorCompetingInstances(t *testing.T) {
+	link := newMemoryLink()
+	start := time.Unix(1700000000, 0)
+	timeout := 3 * time.Second
+	primary := NewElector("primary", 10, link.transport("primary"), timeout, start)
+	backup := NewElector("backup", 5, link.transport("backup"), timeout, start)
+
+	now := start
+	step := func() {
+		now = now.Add(time.Second)
+		primary.Step(now)
+		backup.Step(now)
+	}
+	expect := func(what string, primaryRole, backupRole Role) {
+		t.Helper()
+		if got := primary.Role(); got != primaryRole {
+			t.Fatalf("%s: primary is %s, want %s", what, got, primaryRole)
+		}
+		if got := backup.Role(); got != backupRole {
+			t.Fatalf("%s: backup is %s, want %s", what, got, backupRole)
+		}
+	}
+
+	// 同时启动：互相看到心跳后优先级高的成为 leader，只有一个 leader
+	step()
+	step()
+	expect("after start", RoleLeader, RoleStandby)
+
+	// primary 失联：backup 在 timeout 内保持 standby，超过后接管
+	link.setDown("primary", true)
+	for i := 0; i < 3; i++ {
+		step()
+		expect("primary missing within timeout", RoleLeader, RoleStandby)
+	}
+	step()
+	if backup.Role() != RoleLeader {
+		t.Fatalf("backup is %s after primary missed heartbeats past the timeout, want leader", backup.Role())
+	}
+	if status := backup.Status(now); status.Peer.Healthy || status.Peer.InstanceID != "primary" {
+		t.Fatalf("backup sees peer %+v, want primary unhealthy", status.Peer)
+	}
+
+	// primary 恢复：重新看到对端后 backup 让出 leader
+	link.setDown("primary", false)
+	step()
+	step()
+	expect("after primary recovered", RoleLeader, RoleStandby)
+	if got := backup.Status(now).Transitions; got != 2 {
+		t.Fatalf("backup transitions = %d, want 2", got)
+	}
+
+	// 对端心跳 Seq 不变（进程卡住但文件还在）不算存活
+	if role := backup.observe(now.Add(timeout+time.Second), &Beat{InstanceID: "primary", Priority: 10, Seq: primary.Beat().Seq}, nil); role != RoleLeader {
+		t.Fatalf("backup is %s when the primary heartbeat stopped advancing, want leader", role)
+	}
+}
+
+func TestElectorEqualPriorityTiebreak(t *testing.T) {
+	link := newMemoryLink()
+	start := time.Unix(1700000000, 0)
+	b := NewElector("b", 1, link.transport("b"), 3*time.Second, start)
+	a := NewElector("a", 1, link.transport("a"), 3*time.Second, start)
+
+	for now := start.Add(time.Second); now.Before(start.Add(3 * time.Second)); now = now.Add(time.Second) {
+		b.Step(now)
+		a.Step(now)
+	}
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("roles a=%s b=%s, want the smaller instance id to lead", a.Role(), b.Role())
+	}
+}
@@ -0,0 +1,117 @@
+package failover
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HeartbeatPath 对端 Web 服务提供本实例心跳的路径
+const HeartbeatPath = "/api/failover/heartbeat"
+
+// HTTPTransport 通过对端的 Web 服务读取心跳（两个实例互相轮询，本实例的心跳由自己的 Web 服务提供）
+type HTTPTransport struct {
+	url    string
+	client *http.Client
+	token  string // 对端启用了 API 令牌时使用的 Bearer 令牌
+}
+
+// NewHTTPTransport 创建 HTTP 心跳传输，peerURL 为对端 Web 服务地址（例如 http://10.0.0.2:8080）
+func NewHTTPTransport(peerURL string, timeout time.Duration) *HTTPTransport {
+	return &HTTPTransport{
+		url:    strings.TrimRight(peerURL, "/") + HeartbeatPath,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// SetAuthToken 设置读取对端心跳时使用的 Bearer 令牌（对端设置了 API_TOKEN 时需要）
+func (t *HTTPTransport) SetAuthToken(token string) {
+	t.token = token
+}
+
+// Exchange 读取对端心跳
+func (t *HTTPTransport) Exchange(self Beat) (*Beat, error) {
+	req, err := http.NewRequest(http.MethodGet, t.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer heartbeat request: %w", err)
+	}
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch peer heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// 对端未启用主备模式时返回404
+		return nil, fmt.Errorf("peer heartbeat returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data *Beat `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode peer heartbeat: %w", err)
+	}
+	if body.Data == nil || body.Data.InstanceID == "" {
+		return nil, fmt.Errorf("peer heartbeat has no instance id")
+	}
+	if body.Data.InstanceID == self.InstanceID {
+		return nil, fmt.Errorf("peer URL points to this instance (%s)", self.InstanceID)
+	}
+	return body.Data, nil
+}
+
+// FileTransport 通过共享磁盘上的目录交换心跳：每个实例写入 {dir}/{instance_id}.json，读取其他实例的文件
+type FileTransport struct {
+	dir string
+}
+
+// NewFileTransport 创建共享目录心跳传输
+func NewFileTransport(dir string) *FileTransport {
+	return &FileTransport{dir: dir}
+}
+
+// Exchange 写入本实例心跳并读取对端心跳（目录中有多个其他实例时取文件名排序的第一个）
+func (t *FileTransport) Exchange(self Beat) (*Beat, error) {
+	data, err := json.Marshal(self)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode heartbeat: %w", err)
+	}
+
+	// 原子写入，对端不会读到写了一半的文件
+	path := filepath.Join(t.dir, self.InstanceID+".json")
+	if err := common.WriteFileAtomic(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write heartbeat file: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(t.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list heartbeat files: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if file == path {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read peer heartbeat: %w", err)
+		}
+		var peer Beat
+		if err := json.Unmarshal(data, &peer); err != nil {
+			return nil, fmt.Errorf("failed to decode peer heartbeat %s: %w", filepath.Base(file), err)
+		}
+		return &peer, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,238 @@
+//go:build faults
+
+package faults
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Enabled 是否编译了故障注入（go build -tags faults）
+const Enabled = true
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Point)
+)
+
+// Point 单个 WebSocket 连接的故障注入点
+type Point struct {
+	name      string
+	closeConn func()
+
+	mu       sync.Mutex
+	dropNext int           // 丢弃接下来的N条消息
+	delay    time.Duration // 每条消息处理前的延迟
+	dropped  int64
+	delayed  int64
+	closes   int64
+}
+
+// PointState 注入点的当前状态（/debug/faults 返回）
+type PointState struct {
+	Name     string `json:"name"`
+	DropNext int    `json:"drop_next"`
+	DelayMs  int64  `json:"delay_ms"`
+	Dropped  int64  `json:"dropped"`
+	Delayed  int64  `json:"delayed"`
+	Closes   int64  `json:"closes"`
+}
+
+// NewPoint 注册注入点，closeConn 关闭当前底层连接（走正常的断线重连流程）
+// 名称重复时追加序号
+func NewPoint(name string, closeConn func()) *Point {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	unique := name
+	for i := 2; registry[unique] != nil; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	p := &Point{name: unique, closeConn: closeConn}
+	registry[unique] = p
+	return p
+}
+
+// Release 注销注入点（连接关闭时调用）
+func (p *Point) Release() {
+	if p == nil {
+		return
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if registry[p.name] == p {
+		delete(registry, p.name)
+	}
+}
+
+// Filter 每条消息处理前调用：按设置延迟，返回 false 表示丢弃该消息
+func (p *Point) Filter() bool {
+	if p == nil {
+		return true
+	}
+
+	p.mu.Lock()
+	if p.dropNext > 0 {
+		p.dropNext--
+		p.dropped++
+		p.mu.Unlock()
+		return false
+	}
+	delay := p.delay
+	if delay > 0 {
+		p.delayed++
+	}
+	p.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return true
+}
+
+// state 当前状态
+func (p *Point) state() PointState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PointState{
+		Name:     p.name,
+		DropNext: p.dropNext,
+		DelayMs:  p.delay.Milliseconds(),
+		Dropped:  p.dropped,
+		Delayed:  p.delayed,
+		Closes:   p.closes,
+	}
+}
+
+// apply 执行一次故障操作
+func (p *Point) apply(action string, n int, delay time.Duration) {
+	p.mu.Lock()
+	switch action {
+	case "close":
+		p.closes++
+	case "drop":
+		p.dropNext += n
+	case "delay":
+		p.delay = delay
+	case "clear":
+		p.dropNext = 0
+		p.delay = 0
+	}
+	p.mu.Unlock()
+
+	// 在锁外关闭连接：关闭会触发读循环退出并重连
+	if action == "close" && p.closeConn != nil {
+		p.closeConn()
+	}
+}
+
+// match 按名称通配（path.Match 语法，如 lighter-pool#*）查找注入点，按名称排序
+func match(pattern string) ([]*Point, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	points := make([]*Point, 0)
+	for name, p := range registry {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			points = append(points, p)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].name < points[j].name })
+	return points, nil
+}
+
+// Handler /debug/faults 接口（只接受本机请求）
+//
+// GET  /debug/faults?target=*                         列出注入点状态
+// POST /debug/faults?target=NAME&action=close         强制断开连接（触发重连）
+// POST /debug/faults?target=NAME&action=drop&n=10     丢弃接下来的N条消息
+// POST /debug/faults?target=NAME&action=delay&ms=200  每条消息延迟处理（ms=0取消）
+// POST /debug/faults?target=NAME&action=clear         取消丢弃和延迟
+//
+// target 支持通配符（如 binance-spot-pool#*），默认匹配所有注入点
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "Fault injection is only available from localhost", http.StatusForbidden)
+			return
+		}
+
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			target = "*"
+		}
+		points, err := match(target)
+		if err != nil {
+			http.Error(w, "Invalid target pattern: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if len(points) == 0 {
+				http.Error(w, "No fault points match "+target, http.StatusNotFound)
+				return
+			}
+			action := r.URL.Query().Get("action")
+			n, delay := 0, time.Duration(0)
+			switch action {
+			case "close", "clear":
+			case "drop":
+				n, err = strconv.Atoi(r.URL.Query().Get("n"))
+				if err != nil || n <= 0 {
+					http.Error(w, "Invalid n: must be a positive integer", http.StatusBadRequest)
+					return
+				}
+			case "delay":
+				ms, err := strconv.Atoi(r.URL.Query().Get("ms"))
+				if err != nil || ms < 0 {
+					http.Error(w, "Invalid ms: must be a non-negative integer", http.StatusBadRequest)
+					return
+				}
+				delay = time.Duration(ms) * time.Millisecond
+			default:
+				http.Error(w, "Invalid action: expected close, drop, delay or clear", http.StatusBadRequest)
+				return
+			}
+			for _, p := range points {
+				p.apply(action, n, delay)
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		states := make([]PointState, 0, len(points))
+		for _, p := range points {
+			states = append(states, p.state())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"count":   len(states),
+			"data":    states,
+		})
+	}
+}
+
+// isLoopback 请求是否来自本机
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,104 @@
+//go:build faults
+
+package faults
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// control 以本机地址调用 /debug/faults
+func control(t *testing.T, method, query string) (int, []PointState) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/debug/faults?"+query, nil)
+	req.RemoteAddr = "127.0.0.1:50000"
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var resp struct {
+		Data []PointState `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, resp.Data
+}
+
+func TestFaultPointActions(t *testing.T) {
+	closes := 0
+	pool0 := NewPoint("test-pool#0", func() { closes++ })
+	pool1 := NewPoint("test-pool#1", nil)
+	defer pool0.Release()
+	defer pool1.Release()
+
+	if _, states := control(t, http.MethodPost, "target=test-pool%230&action=drop&n=2"); len(states) != 1 || states[0].DropNext != 2 {
+		t.Fatalf("drop states = %+v", states)
+	}
+	if pool0.Filter() || pool0.Filter() || !pool0.Filter() {
+		t.Fatal("want exactly the next 2 messages dropped")
+	}
+	if !pool1.Filter() {
+		t.Fatal("drop applied to a point outside the target")
+	}
+
+	// 通配符匹配池内所有连接
+	if _, states := control(t, http.MethodPost, "target=test-pool%23*&action=delay&ms=20"); len(states) != 2 {
+		t.Fatalf("delay matched %d points, want 2", len(states))
+	}
+	start := time.Now()
+	pool1.Filter()
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("delayed message processed after %v", elapsed)
+	}
+
+	control(t, http.MethodPost, "target=test-pool%230&action=close")
+	if closes != 1 {
+		t.Fatalf("close called the connection closer %d times", closes)
+	}
+	_, states := control(t, http.MethodPost, "target=test-pool%23*&action=clear")
+	for _, state := range states {
+		if state.DropNext != 0 || state.DelayMs != 0 {
+			t.Fatalf("state after clear = %+v", state)
+		}
+	}
+	if states[0].Dropped != 2 || states[0].Closes != 1 || states[1].Delayed != 1 {
+		t.Fatalf("counters = %+v", states)
+	}
+
+	// 注销后不再匹配
+	pool1.Release()
+	if code, _ := control(t, http.MethodPost, "target=test-pool%231&action=close"); code != http.StatusNotFound {
+		t.Fatalf("released point status %d, want 404", code)
+	}
+}
+
+func TestFaultHandlerRejects(t *testing.T) {
+	p := NewPoint("test-reject", nil)
+	defer p.Release()
+
+	req := httptest.NewRequest(http.MethodPost, "/debug/faults?target=test-reject&action=close", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("remote request status %d, want 403", rec.Code)
+	}
+
+	for _, query := range []string{"target=test-reject&action=drop&n=0", "target=test-reject&action=explode", "target=%5B"} {
+		if code, _ := control(t, http.MethodPost, query); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, code)
+		}
+	}
+
+	// 重名的注入点追加序号
+	dup := NewPoint("test-reject", nil)
+	defer dup.Release()
+	if _, states := control(t, http.MethodGet, "target=test-reject*"); len(states) != 2 || states[1].Name != "test-reject-2" {
+		t.Fatalf("points = %+v", states)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !faults
+
+// Package faults WebSocket 连接的故障注入（强制断线、丢弃消息、延迟消息），用于端到端验证重连和重新订阅逻辑
+//
+// 只有使用 -tags faults 编译时才生效，并通过本机的 /debug/faults 接口控制；
+// 正常构建中所有钩子都是空操作（NewPoint 返回 nil，Filter 恒为 true）
+package faults
+
+import "net/http"
+
+// Enabled 是否编译了故障注入（go build -tags faults）
+const Enabled = false
+
+// Point 单个 WebSocket 连接的故障注入点（正常构建中为空操作）
+type Point struct{}
+
+// NewPoint 正常构建中不注册注入点
+func NewPoint(name string, closeConn func()) *Point { return nil }
+
+// Release 空操作
+func (p *Point) Release() {}
+
+// Filter 正常构建中不丢弃任何消息
+func (p *Point) Filter() bool { return true }
+
+// Handler 正常构建中没有 /debug/faults 接口
+func Handler() http.HandlerFunc { return nil }
@@ -0,0 +1,194 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// FileWriter 可恢复的日志文件写入器
+// 文件被外部轮转（logrotate 移走）或删除后自动重新打开原路径，超过大小上限时自行轮转为 path.1, path.2, ...
+type FileWriter struct {
+	path       string
+	maxSize    int64 // 单个文件的最大字节数，0表示不限制
+	maxBackups int   // 保留的备份文件数，0表示轮转时直接删除旧文件
+
+	mu   sync.Mutex
+	file *os.File
+	info os.FileInfo // 打开时的文件信息，用于判断路径是否已指向其他文件
+	size int64
+}
+
+// NewFileWriter 以追加方式打开日志文件
+func NewFileWriter(path string, maxSize int64, maxBackups int) (*FileWriter, error) {
+	if maxBackups < 0 {
+		maxBackups = 0
+	}
+	w := &FileWriter{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write 写入日志，写入后会超过大小上限时先轮转
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "[Logging] Failed to rotate %s: %v\n", w.path, err)
+		}
+	}
+	if w.file == nil {
+		return 0, fmt.Errorf("log file %s is not open", w.path)
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Size 当前日志文件的大小（字节）
+func (w *FileWriter) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+// Reopen 关闭当前文件并重新打开路径（文件不存在时重新创建）
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reopen()
+}
+
+// Close 关闭日志文件
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// RunReopenWatcher 定期检查日志文件是否被删除或移走，收到 SIGUSR1 时立即重新打开，直到 stopChan 关闭
+func (w *FileWriter) RunReopenWatcher(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sigChan := make(chan os.Signal, 1)
+	if len(reopenSignals) > 0 {
+		signal.Notify(sigChan, reopenSignals...)
+		defer signal.Stop(sigChan)
+	}
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-sigChan:
+			if err := w.Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "[Logging] Failed to reopen %s: %v\n", w.path, err)
+			}
+		case <-ticker.C:
+			if err := w.reopenIfMoved(); err != nil {
+				fmt.Fprintf(os.Stderr, "[Logging] Failed to reopen %s: %v\n", w.path, err)
+			}
+		}
+	}
+}
+
+// reopenIfMoved 路径已不存在或指向其他文件（inode变化）时重新打开
+func (w *FileWriter) reopenIfMoved() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current, err := os.Stat(w.path)
+	if err == nil && w.info != nil && os.SameFile(current, w.info) {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return w.reopen()
+}
+
+// open 以追加方式打开日志文件并记录文件信息（调用者需要持有锁或在初始化时调用）
+func (w *FileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = file
+	w.info = info
+	w.size = info.Size()
+	return nil
+}
+
+// reopen 关闭当前文件后重新打开（调用者需要持有锁）
+func (w *FileWriter) reopen() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return w.open()
+}
+
+// rotate 将当前文件依次重命名为 path.1 ... path.N（超出 maxBackups 的删除），然后打开新文件（调用者需要持有锁）
+// 重命名失败时仍会重新打开原路径继续写入，避免日志中断
+func (w *FileWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+
+	err := w.shiftBackups()
+	if openErr := w.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+// shiftBackups 移动备份文件并将当前文件重命名为 path.1（maxBackups 为0时直接删除当前文件）
+func (w *FileWriter) shiftBackups() error {
+	if w.maxBackups == 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	os.Remove(w.backupPath(w.maxBackups))
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(w.backupPath(i), w.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, w.backupPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// backupPath 第 n 个备份文件的路径（1 为最新）
+func (w *FileWriter) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", w.path, n)
+}
@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readFile 读取文件内容，文件不存在时返回空串
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeLine(t *testing.T, w *FileWriter, line string) {
+	t.Helper()
+	if _, err := w.Write([]byte(line + "\n")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileWriterAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arbitrage.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewFileWriter(path, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if w.Size() != 4 {
+		t.Fatalf("initial size = %d, want 4", w.Size())
+	}
+	writeLine(t, w, "new")
+	if got := readFile(t, path); got != "old\nnew\n" {
+		t.Fatalf("content = %q", got)
+	}
+	if w.Size() != 8 {
+		t.Fatalf("size = %d, want 8", w.Size())
+	}
+}
+
+func TestFileWriterReopensAfterExternalDeletion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arbitrage.log")
+	w, err := NewFileWriter(path, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	writeLine(t, w, "before")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.reopenIfMoved(); err != nil {
+		t.Fatal(err)
+	}
+	writeLine(t, w, "after")
+
+	if got := readFile(t, path); got != "after\n" {
+		t.Fatalf("recreated file content = %q, want %q", got, "after\n")
+	}
+	if w.Size() != int64(len("after\n")) {
+		t.Fatalf("size = %d, want %d", w.Size(), len("after\n"))
+	}
+}
+
+func TestFileWriterReopensAfterExternalRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "arbitrage.log")
+	moved := filepath.Join(dir, "arbitrage.log-20261017")
+	w, err := NewFileWriter(path, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	writeLine(t, w, "before")
+
+	// logrotate 移走文件后在原路径创建新文件
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.reopenIfMoved(); err != nil {
+		t.Fatal(err)
+	}
+	writeLine(t, w, "after")
+
+	if got := readFile(t, moved); got != "before\n" {
+		t.Fatalf("rotated file content = %q", got)
+	}
+	if got := readFile(t, path); got != "after\n" {
+		t.Fatalf("new file content = %q", got)
+	}
+
+	// 路径未变化时不重新打开
+	if err := w.reopenIfMoved(); err != nil {
+		t.Fatal(err)
+	}
+	writeLine(t, w, "again")
+	if got := readFile(t, path); got != "after\nagain\n" {
+		t.Fatalf("content after no-op check = %q", got)
+	}
+}
+
+func TestFileWriterRotatesAtMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arbitrage.log")
+	line := strings.Repeat("x", 9) // 加换行共10字节
+	w, err := NewFileWriter(path, 25, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 每个文件最多容纳两行（20字节），第三行触发轮转
+	for i := 0; i < 2; i++ {
+		writeLine(t, w, line)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("rotated below the threshold: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		writeLine(t, w, line)
+	}
+	// 共8行：path.2 丢弃了最早的两行，path.2/path.1/path 各两行
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		if got := readFile(t, p); got != line+"\n"+line+"\n" {
+			t.Fatalf("%s content = %q", filepath.Base(p), got)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("kept more than maxBackups backups: %v", err)
+	}
+	if w.Size() != 20 {
+		t.Fatalf("size = %d, want 20", w.Size())
+	}
+}
+
+func TestFileWriterRotatesWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arbitrage.log")
+	w, err := NewFileWriter(path, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	writeLine(t, w, "first")
+	writeLine(t, w, "second")
+	if got := readFile(t, path); got != "second\n" {
+		t.Fatalf("content = %q, want %q", got, "second\n")
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("backup kept with maxBackups=0: %v", err)
+	}
+}
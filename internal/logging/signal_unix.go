@@ -0,0 +1,11 @@
+//go:build !windows
+
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+// reopenSignals 触发立即重新打开日志文件的信号（logrotate 的 postrotate 可发送 SIGUSR1）
+var reopenSignals = []os.Signal{syscall.SIGUSR1}
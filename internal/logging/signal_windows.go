@@ -0,0 +1,8 @@
+//go:build windows
+
+package logging
+
+import "os"
+
+// reopenSignals Windows 没有 SIGUSR1，只依赖定期检查
+var reopenSignals []os.Signal
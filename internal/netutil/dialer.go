@@ -0,0 +1,454 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 地址族
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// Config 交易所端点拨号配置
+type Config struct {
+	ForceIPv4      bool          // 只使用IPv4地址（IPv6路由不通的机器）
+	DNSCacheTTL    time.Duration // DNS解析结果缓存时长的上限（按记录TTL缓存，TTL未知时使用该值），0表示不缓存
+	DNSStaleFor    time.Duration // 解析失败时过期的缓存最多还能继续使用多久（DNS短暂故障），0表示不使用过期缓存
+	FallbackDelay  time.Duration // 第一个地址未在该时间内连上时并行尝试下一个地址（happy eyeballs）
+	AttemptTimeout time.Duration // 单个地址的连接超时
+	DemoteAfter    int           // 连续N次拨号失败后暂时降级该主机（URL轮换时跳过），0表示不降级
+	DemoteFor      time.Duration // 降级时长
+}
+
+// DefaultConfig 默认拨号配置
+func DefaultConfig() Config {
+	return Config{
+		DNSCacheTTL:    60 * time.Second,
+		DNSStaleFor:    5 * time.Minute,
+		FallbackDelay:  300 * time.Millisecond,
+		AttemptTimeout: 5 * time.Second,
+		DemoteAfter:    3,
+		DemoteFor:      2 * time.Minute,
+	}
+}
+
+// Resolver DNS解析接口（*net.Resolver 实现了该接口）
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// TTLResolver 能同时返回记录TTL的解析器，ttl < 0 表示TTL未知
+type TTLResolver interface {
+	LookupIPAddrTTL(ctx context.Context, host string) (addrs []net.IPAddr, ttl time.Duration, err error)
+}
+
+// HostStats 单个主机的拨号统计
+type HostStats struct {
+	Host                string     `json:"host"`
+	Dials               int64      `json:"dials"`
+	Failures            int64      `json:"failures"`             // 全部地址都没连上的次数
+	AddressFailures     int64      `json:"address_failures"`     // 单个地址连接失败的次数（被其他地址成功补救的也计入）
+	ConsecutiveFailures int        `json:"consecutive_failures"` // 连续失败次数，成功后清零
+	PreferredFamily     string     `json:"preferred_family,omitempty"`
+	Addresses           []string   `json:"addresses,omitempty"`      // 缓存的解析结果
+	DNSExpiresAt        *time.Time `json:"dns_expires_at,omitempty"` // 缓存按记录TTL过期的时间
+	LookupFailures      int64      `json:"lookup_failures"`          // DNS解析失败的次数
+	StaleLookups        int64      `json:"stale_lookups"`            // 解析失败时使用过期缓存的次数
+	DemotedUntil        *time.Time `json:"demoted_until,omitempty"`
+}
+
+// dnsEntry 缓存的解析结果
+type dnsEntry struct {
+	addrs     []net.IP
+	expiresAt time.Time
+}
+
+// hostState 主机的拨号状态
+type hostState struct {
+	dials           int64
+	failures        int64
+	addressFailures int64
+	lookupFailures  int64
+	staleLookups    int64
+	consecutive     int
+	preferredFamily string
+	demotedUntil    time.Time
+}
+
+// Dialer 交易所端点拨号器：缓存DNS结果，按偏好的地址族交错尝试各地址（happy eyeballs），
+// 记录每个主机的失败次数，连续失败的主机暂时降级
+type Dialer struct {
+	resolver Resolver
+	dial     func(ctx context.Context, network, address string) (net.Conn, error)
+
+	mu    sync.Mutex
+	cfg   Config
+	cache map[string]*dnsEntry
+	hosts map[string]*hostState
+}
+
+// NewDialer 创建拨号器，resolver 为nil时使用系统解析器（另外查询名称服务器获取记录TTL）
+func NewDialer(cfg Config, resolver Resolver) *Dialer {
+	if resolver == nil {
+		resolver = newSystemResolver()
+	}
+	d := &Dialer{
+		resolver: resolver,
+		cfg:      cfg,
+		cache:    make(map[string]*dnsEntry),
+		hosts:    make(map[string]*hostState),
+	}
+	netDialer := &net.Dialer{KeepAlive: 30 * time.Second}
+	d.dial = netDialer.DialContext
+	return d
+}
+
+// SetConfig 更新拨号配置，清空DNS缓存
+func (d *Dialer) SetConfig(cfg Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cfg = cfg
+	d.cache = make(map[string]*dnsEntry)
+}
+
+// DialContext 连接 address（host:port），可作为 http.Transport / websocket.Dialer 的 DialContext
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	cfg := d.cfg
+	d.mu.Unlock()
+
+	if ip := net.ParseIP(host); ip != nil {
+		return d.dial(ctx, network, address)
+	}
+
+	ips, err := d.lookup(ctx, host, cfg)
+	if err != nil {
+		d.recordFailure(host, 0, cfg)
+		return nil, err
+	}
+	ips = d.orderAddrs(host, ips, cfg)
+	if len(ips) == 0 {
+		d.recordFailure(host, 0, cfg)
+		return nil, fmt.Errorf("no usable address for %s (force IPv4: %v)", host, cfg.ForceIPv4)
+	}
+
+	conn, winner, failed, err := d.race(ctx, network, ips, port, cfg)
+	if err != nil {
+		d.recordFailure(host, failed, cfg)
+		return nil, fmt.Errorf("dial %s: %w", host, err)
+	}
+	d.recordSuccess(host, addrFamily(winner), failed)
+	return conn, nil
+}
+
+// lookup 解析主机地址，缓存未过期时直接使用
+// 缓存时长取记录TTL（不超过 DNSCacheTTL）；解析失败时在 DNSStaleFor 内继续使用过期的缓存（DNS短暂故障）
+func (d *Dialer) lookup(ctx context.Context, host string, cfg Config) ([]net.IP, error) {
+	now := time.Now()
+	d.mu.Lock()
+	entry := d.cache[host]
+	d.mu.Unlock()
+	if entry != nil && now.Before(entry.expiresAt) {
+		return entry.addrs, nil
+	}
+
+	addrs, ttl, err := d.resolve(ctx, host)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("no addresses for %s", host)
+	}
+	if err != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		state := d.hostLocked(host)
+		state.lookupFailures++
+		if entry != nil && now.Before(entry.expiresAt.Add(cfg.DNSStaleFor)) {
+			state.staleLookups++
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	if cfg.DNSCacheTTL > 0 {
+		if ttl < 0 || ttl > cfg.DNSCacheTTL {
+			ttl = cfg.DNSCacheTTL
+		}
+		d.mu.Lock()
+		d.cache[host] = &dnsEntry{addrs: ips, expiresAt: now.Add(ttl)}
+		d.mu.Unlock()
+	}
+	return ips, nil
+}
+
+// resolve 解析主机地址，解析器不支持TTL时返回 ttl = -1
+func (d *Dialer) resolve(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	if r, ok := d.resolver.(TTLResolver); ok {
+		return r.LookupIPAddrTTL(ctx, host)
+	}
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	return addrs, -1, err
+}
+
+// orderAddrs 按偏好的地址族排序并交错两个地址族（偏好族的第一个地址在前），ForceIPv4 时去掉IPv6地址
+// 没有偏好时保持解析器的顺序决定首选地址族
+func (d *Dialer) orderAddrs(host string, ips []net.IP, cfg Config) []net.IP {
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else if !cfg.ForceIPv4 {
+			v6 = append(v6, ip)
+		}
+	}
+	if len(v4) == 0 || len(v6) == 0 {
+		return append(v4, v6...)
+	}
+
+	d.mu.Lock()
+	preferred := ""
+	if state := d.hosts[host]; state != nil {
+		preferred = state.preferredFamily
+	}
+	d.mu.Unlock()
+
+	first, second := v4, v6
+	if preferred == FamilyIPv6 || (preferred == "" && ips[0].To4() == nil) {
+		first, second = v6, v4
+	}
+	ordered := make([]net.IP, 0, len(ips))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			ordered = append(ordered, first[i])
+		}
+		if i < len(second) {
+			ordered = append(ordered, second[i])
+		}
+	}
+	return ordered
+}
+
+// dialResult 单个地址的连接结果
+type dialResult struct {
+	conn net.Conn
+	ip   net.IP
+	err  error
+}
+
+// race 依次启动各地址的连接：上一个地址失败或超过 FallbackDelay 仍未连上时启动下一个，返回第一个成功的连接
+// failed 为失败的地址数
+func (d *Dialer) race(ctx context.Context, network string, ips []net.IP, port string, cfg Config) (conn net.Conn, winner net.IP, failed int, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan dialResult, len(ips))
+	start := func(ip net.IP) {
+		go func() {
+			attemptCtx := ctx
+			if cfg.AttemptTimeout > 0 {
+				var attemptCancel context.CancelFunc
+				attemptCtx, attemptCancel = context.WithTimeout(ctx, cfg.AttemptTimeout)
+				defer attemptCancel()
+			}
+			c, err := d.dial(attemptCtx, network, net.JoinHostPort(ip.String(), port))
+			results <- dialResult{conn: c, ip: ip, err: err}
+		}()
+	}
+
+	next, pending := 0, 0
+	var errs []error
+	for {
+		if next < len(ips) && pending == 0 {
+			start(ips[next])
+			next++
+			pending++
+		}
+
+		var fallback <-chan time.Time
+		var timer *time.Timer
+		if next < len(ips) && cfg.FallbackDelay > 0 {
+			timer = time.NewTimer(cfg.FallbackDelay)
+			fallback = timer.C
+		}
+
+		var res dialResult
+		var received bool
+		select {
+		case res = <-results:
+			received = true
+		case <-fallback:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		switch {
+		case received:
+			pending--
+			if res.err == nil {
+				// 关闭其余仍在进行中的连接
+				go drainResults(results, pending)
+				return res.conn, res.ip, failed, nil
+			}
+			failed++
+			errs = append(errs, fmt.Errorf("%s: %w", res.ip, res.err))
+			if next >= len(ips) && pending == 0 {
+				return nil, nil, failed, errors.Join(errs...)
+			}
+		case ctx.Err() != nil:
+			go drainResults(results, pending)
+			return nil, nil, failed, ctx.Err()
+		default:
+			// 超过 FallbackDelay 仍未连上，并行尝试下一个地址
+			start(ips[next])
+			next++
+			pending++
+		}
+	}
+}
+
+// drainResults 关闭落选的连接
+func drainResults(results <-chan dialResult, pending int) {
+	for i := 0; i < pending; i++ {
+		if res := <-results; res.conn != nil {
+			res.conn.Close()
+		}
+	}
+}
+
+// addrFamily 地址所属的地址族
+func addrFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return FamilyIPv4
+	}
+	return FamilyIPv6
+}
+
+// hostLocked 主机的拨号状态（调用者需要持有锁）
+func (d *Dialer) hostLocked(host string) *hostState {
+	state := d.hosts[host]
+	if state == nil {
+		state = &hostState{}
+		d.hosts[host] = state
+	}
+	return state
+}
+
+// recordSuccess 记录拨号成功，成功连上的地址族成为该主机的首选
+func (d *Dialer) recordSuccess(host, family string, failedAddrs int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state := d.hostLocked(host)
+	state.dials++
+	state.addressFailures += int64(failedAddrs)
+	state.consecutive = 0
+	state.demotedUntil = time.Time{}
+	state.preferredFamily = family
+}
+
+// recordFailure 记录拨号失败，连续失败达到 DemoteAfter 次时降级该主机
+func (d *Dialer) recordFailure(host string, failedAddrs int, cfg Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state := d.hostLocked(host)
+	state.dials++
+	state.failures++
+	state.addressFailures += int64(failedAddrs)
+	state.consecutive++
+	if cfg.DemoteAfter > 0 && state.consecutive >= cfg.DemoteAfter {
+		state.demotedUntil = time.Now().Add(cfg.DemoteFor)
+	}
+}
+
+// Demoted 主机是否因连续拨号失败处于降级期
+func (d *Dialer) Demoted(host string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state := d.hosts[host]
+	return state != nil && time.Now().Before(state.demotedUntil)
+}
+
+// Stats 各主机的拨号统计（按主机名排序）
+func (d *Dialer) Stats() []HostStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]HostStats, 0, len(d.hosts))
+	for host, state := range d.hosts {
+		s := HostStats{
+			Host:                host,
+			Dials:               state.dials,
+			Failures:            state.failures,
+			AddressFailures:     state.addressFailures,
+			ConsecutiveFailures: state.consecutive,
+			PreferredFamily:     state.preferredFamily,
+			LookupFailures:      state.lookupFailures,
+			StaleLookups:        state.staleLookups,
+		}
+		if entry := d.cache[host]; entry != nil {
+			for _, ip := range entry.addrs {
+				s.Addresses = append(s.Addresses, ip.String())
+			}
+			expiresAt := entry.expiresAt.UTC()
+			s.DNSExpiresAt = &expiresAt
+		}
+		if now.Before(state.demotedUntil) {
+			until := state.demotedUntil.UTC()
+			s.DemotedUntil = &until
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Host < stats[j].Host })
+	return stats
+}
+
+// 所有交易所REST/WebSocket客户端共享的拨号器
+var defaultDialer = NewDialer(DefaultConfig(), nil)
+
+// Configure 设置共享拨号器的配置
+func Configure(cfg Config) {
+	defaultDialer.SetConfig(cfg)
+}
+
+// DialContext 使用共享拨号器连接
+func DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return defaultDialer.DialContext(ctx, network, address)
+}
+
+// Demoted 主机是否在共享拨号器中处于降级期
+func Demoted(host string) bool {
+	return defaultDialer.Demoted(host)
+}
+
+// Stats 共享拨号器的各主机统计
+func Stats() []HostStats {
+	return defaultDialer.Stats()
+}
+
+// NewTransport 创建使用共享拨号器的 http.Transport（其余参数与 http.DefaultTransport 相同）
+func NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = DialContext
+	return transport
+}
+
+// NewHTTPClient 创建使用共享拨号器的 http.Client
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: NewTransport()}
+}
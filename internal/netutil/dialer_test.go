@@ -0,0 +1,218 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubResolver 返回固定地址和TTL的解析器，记录调用次数
+type stubResolver struct {
+	mu    sync.Mutex
+	addrs []net.IPAddr
+	ttl   time.Duration
+	err   error
+	calls int
+}
+
+func (r *stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, _, err := r.LookupIPAddrTTL(ctx, host)
+	return addrs, err
+}
+
+func (r *stubResolver) LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	return r.addrs, r.ttl, r.err
+}
+
+func (r *stubResolver) set(ttl time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ttl, r.err = ttl, err
+}
+
+func (r *stubResolver) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+var (
+	blackholedV6 = net.ParseIP("2001:db8::1")
+	workingV4    = net.ParseIP("192.0.2.1")
+)
+
+// newStubDialer IPv6地址的连接一直挂起直到超时（路由黑洞），IPv4地址立即连上
+func newStubDialer(cfg Config, resolver *stubResolver) (*Dialer, func() []string) {
+	d := NewDialer(cfg, resolver)
+	var mu sync.Mutex
+	var attempts []string
+	d.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, _ := net.SplitHostPort(address)
+		mu.Lock()
+		attempts = append(attempts, host)
+		mu.Unlock()
+		if net.ParseIP(host).To4() == nil {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	return d, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), attempts...)
+	}
+}
+
+func dualStackResolver() *stubResolver {
+	// 解析器把IPv6地址排在前面，和出问题的VPS一致
+	return &stubResolver{addrs: []net.IPAddr{{IP: blackholedV6}, {IP: workingV4}}, ttl: time.Minute}
+}
+
+func TestDialFailsOverFromBlackholedIPv6(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FallbackDelay = 20 * time.Millisecond
+	cfg.AttemptTimeout = 5 * time.Second
+	d, attempts := newStubDialer(cfg, dualStackResolver())
+
+	start := time.Now()
+	conn, err := d.DialContext(context.Background(), "tcp", "api1.example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	// IPv6 没有等到 AttemptTimeout，FallbackDelay 后就并行尝试了 IPv4
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("failover took %v", elapsed)
+	}
+	if got := attempts(); len(got) != 2 || got[0] != blackholedV6.String() || got[1] != workingV4.String() {
+		t.Fatalf("attempts = %v, want v6 then v4", got)
+	}
+
+	stats := d.Stats()
+	if len(stats) != 1 || stats[0].PreferredFamily != FamilyIPv4 || stats[0].Failures != 0 {
+		t.Fatalf("stats = %+v, want ipv4 preferred without failures", stats)
+	}
+
+	// 之后的连接直接从 IPv4 开始，不再等待黑洞地址
+	start = time.Now()
+	conn, err = d.DialContext(context.Background(), "tcp", "api1.example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if elapsed := time.Since(start); elapsed >= cfg.FallbackDelay {
+		t.Fatalf("second dial took %v, want immediate IPv4", elapsed)
+	}
+	if got := attempts(); len(got) != 3 || got[2] != workingV4.String() {
+		t.Fatalf("attempts = %v, want IPv4 first on the second dial", got)
+	}
+}
+
+func TestDialForceIPv4SkipsIPv6(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ForceIPv4 = true
+	d, attempts := newStubDialer(cfg, dualStackResolver())
+
+	conn, err := d.DialContext(context.Background(), "tcp", "api1.example.com:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if got := attempts(); len(got) != 1 || got[0] != workingV4.String() {
+		t.Fatalf("attempts = %v, want only IPv4", got)
+	}
+}
+
+func TestLookupRespectsRecordTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		recordTTL time.Duration
+		cacheCap  time.Duration
+		wantTTL   time.Duration
+	}{
+		{"record TTL below cap", 40 * time.Millisecond, time.Minute, 40 * time.Millisecond},
+		{"record TTL capped", time.Hour, 40 * time.Millisecond, 40 * time.Millisecond},
+		{"unknown TTL uses cap", -1, 40 * time.Millisecond, 40 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.DNSCacheTTL = tt.cacheCap
+			resolver := dualStackResolver()
+			resolver.set(tt.recordTTL, nil)
+			d := NewDialer(cfg, resolver)
+
+			if _, err := d.lookup(context.Background(), "api1.example.com", cfg); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := d.lookup(context.Background(), "api1.example.com", cfg); err != nil {
+				t.Fatal(err)
+			}
+			if n := resolver.callCount(); n != 1 {
+				t.Fatalf("resolver called %d times within TTL, want 1", n)
+			}
+
+			stats := d.Stats()
+			if len(stats) != 0 {
+				t.Fatalf("lookup-only stats = %+v", stats)
+			}
+			d.mu.Lock()
+			ttl := time.Until(d.cache["api1.example.com"].expiresAt)
+			d.mu.Unlock()
+			if ttl > tt.wantTTL || ttl < tt.wantTTL-20*time.Millisecond {
+				t.Fatalf("cache expires in %v, want about %v", ttl, tt.wantTTL)
+			}
+
+			time.Sleep(tt.wantTTL + 10*time.Millisecond)
+			if _, err := d.lookup(context.Background(), "api1.example.com", cfg); err != nil {
+				t.Fatal(err)
+			}
+			if n := resolver.callCount(); n != 2 {
+				t.Fatalf("resolver called %d times after TTL, want 2", n)
+			}
+		})
+	}
+}
+
+func TestLookupStaleReuseIsBounded(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DNSStaleFor = 60 * time.Millisecond
+	resolver := dualStackResolver()
+	resolver.set(10*time.Millisecond, nil)
+	d := NewDialer(cfg, resolver)
+
+	if _, err := d.lookup(context.Background(), "api1.example.com", cfg); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// DNS故障：过期的缓存在 DNSStaleFor 内继续使用，并计入统计
+	resolver.set(0, errors.New("server misbehaving"))
+	ips, err := d.lookup(context.Background(), "api1.example.com", cfg)
+	if err != nil || len(ips) != 2 {
+		t.Fatalf("stale lookup = %v, %v; want cached addresses", ips, err)
+	}
+	stats := d.Stats()
+	if len(stats) != 1 || stats[0].StaleLookups != 1 || stats[0].LookupFailures != 1 {
+		t.Fatalf("stats = %+v, want one stale lookup", stats)
+	}
+
+	// 超过 DNSStaleFor 后不再使用过期缓存
+	time.Sleep(cfg.DNSStaleFor)
+	if _, err := d.lookup(context.Background(), "api1.example.com", cfg); err == nil {
+		t.Fatal("stale entry reused beyond DNSStaleFor")
+	}
+	stats = d.Stats()
+	if stats[0].StaleLookups != 1 || stats[0].LookupFailures != 2 {
+		t.Fatalf("stats = %+v, want the second failure not served from cache", stats)
+	}
+}
@@ -0,0 +1,236 @@
+package netutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// DNS记录类型
+const (
+	dnsTypeA     = 1
+	dnsTypeCNAME = 5
+	dnsTypeAAAA  = 28
+)
+
+// dnsQueryTimeout 单次TTL查询的超时
+const dnsQueryTimeout = 2 * time.Second
+
+// systemResolver 地址仍由系统解析器解析（遵循 /etc/hosts 等系统配置），
+// 另外直接向 /etc/resolv.conf 中的名称服务器查询A/AAAA记录以获得TTL（系统解析器不返回TTL）
+type systemResolver struct {
+	resolver *net.Resolver
+	servers  []string // host:port，为空时TTL未知
+}
+
+// newSystemResolver 创建系统解析器，名称服务器读取失败时只是TTL未知
+func newSystemResolver() *systemResolver {
+	return &systemResolver{
+		resolver: net.DefaultResolver,
+		servers:  readNameservers("/etc/resolv.conf"),
+	}
+}
+
+// LookupIPAddr 只解析地址
+func (r *systemResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return r.resolver.LookupIPAddr(ctx, host)
+}
+
+// LookupIPAddrTTL 解析地址并查询记录TTL，TTL查询失败时返回 -1（由调用方使用配置的缓存时长）
+func (r *systemResolver) LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	addrs, err := r.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, -1, err
+	}
+	ttl, err := r.queryTTL(ctx, host)
+	if err != nil {
+		return addrs, -1, nil
+	}
+	return addrs, ttl, nil
+}
+
+// queryTTL A和AAAA记录（包括CNAME链）中最小的TTL
+func (r *systemResolver) queryTTL(ctx context.Context, host string) (time.Duration, error) {
+	if len(r.servers) == 0 {
+		return 0, errors.New("no nameservers")
+	}
+	minTTL := time.Duration(-1)
+	for _, qtype := range []uint16{dnsTypeA, dnsTypeAAAA} {
+		ttl, err := r.queryTypeTTL(ctx, host, qtype)
+		if err != nil {
+			continue
+		}
+		if minTTL < 0 || ttl < minTTL {
+			minTTL = ttl
+		}
+	}
+	if minTTL < 0 {
+		return 0, fmt.Errorf("no TTL for %s", host)
+	}
+	return minTTL, nil
+}
+
+// queryTypeTTL 依次向各名称服务器查询一种记录类型，返回第一个有效应答中的最小TTL
+func (r *systemResolver) queryTypeTTL(ctx context.Context, host string, qtype uint16) (time.Duration, error) {
+	var lastErr error
+	for _, server := range r.servers {
+		ttl, err := exchangeTTL(ctx, server, host, qtype)
+		if err == nil {
+			return ttl, nil
+		}
+		lastErr = err
+	}
+	return 0, lastErr
+}
+
+// exchangeTTL 通过UDP发送一次查询并解析应答中的TTL
+func exchangeTTL(ctx context.Context, server, host string, qtype uint16) (time.Duration, error) {
+	id := uint16(rand.Intn(1 << 16))
+	query, err := buildDNSQuery(id, host, qtype)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 1232)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	return parseDNSAnswerTTL(buf[:n], id, qtype)
+}
+
+// buildDNSQuery 构造单个问题的递归查询报文
+func buildDNSQuery(id uint16, host string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, 12, 12+len(host)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], 0x0100) // RD
+	binary.BigEndian.PutUint16(msg[4:], 1)      // QDCOUNT
+
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("invalid host %q", host)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, 1) // IN
+	return msg, nil
+}
+
+// parseDNSAnswerTTL 解析应答报文中 qtype 和 CNAME 记录的最小TTL
+func parseDNSAnswerTTL(msg []byte, id uint16, qtype uint16) (time.Duration, error) {
+	if len(msg) < 12 {
+		return 0, errors.New("short DNS response")
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return 0, errors.New("DNS response id mismatch")
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x0200 != 0 {
+		return 0, errors.New("truncated DNS response")
+	}
+	if rcode := flags & 0x000f; rcode != 0 {
+		return 0, fmt.Errorf("DNS rcode %d", rcode)
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := 12
+	var err error
+	for i := 0; i < qdcount; i++ {
+		if off, err = skipDNSName(msg, off); err != nil {
+			return 0, err
+		}
+		off += 4
+	}
+
+	minTTL := int64(-1)
+	for i := 0; i < ancount; i++ {
+		if off, err = skipDNSName(msg, off); err != nil {
+			return 0, err
+		}
+		if off+10 > len(msg) {
+			return 0, errors.New("short DNS record")
+		}
+		rrType := binary.BigEndian.Uint16(msg[off:])
+		ttl := int64(binary.BigEndian.Uint32(msg[off+4:]))
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10 + rdlen
+		if off > len(msg) {
+			return 0, errors.New("short DNS record data")
+		}
+		if rrType != qtype && rrType != dnsTypeCNAME {
+			continue
+		}
+		if minTTL < 0 || ttl < minTTL {
+			minTTL = ttl
+		}
+	}
+	if minTTL < 0 {
+		return 0, errors.New("no matching DNS records")
+	}
+	return time.Duration(minTTL) * time.Second, nil
+}
+
+// skipDNSName 跳过报文中的域名（标签序列或压缩指针），返回其后的偏移
+func skipDNSName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, errors.New("short DNS name")
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			return off + 1, nil
+		case l&0xc0 == 0xc0:
+			return off + 2, nil
+		default:
+			off += 1 + l
+		}
+	}
+}
+
+// readNameservers 读取 resolv.conf 中的名称服务器（host:53）
+func readNameservers(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			if ip := net.ParseIP(fields[1]); ip != nil {
+				servers = append(servers, net.JoinHostPort(ip.String(), "53"))
+			}
+		}
+	}
+	return servers
+}
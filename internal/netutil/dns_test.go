@@ -0,0 +1,97 @@
+package netutil
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// dnsAnswer 构造对 query 的应答：一条 CNAME（ttl 300）加一条 qtype 记录（recordTTL）
+func dnsAnswer(query []byte, qtype uint16, recordTTL uint32) []byte {
+	msg := append([]byte(nil), query...)
+	binary.BigEndian.PutUint16(msg[2:], 0x8180) // QR RD RA
+	binary.BigEndian.PutUint16(msg[6:], 2)      // ANCOUNT
+
+	cname := []byte{4, 'e', 'd', 'g', 'e', 0xc0, 0x0c}
+	msg = append(msg, 0xc0, 0x0c) // 指向问题中的域名
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypeCNAME)
+	msg = binary.BigEndian.AppendUint16(msg, 1)
+	msg = binary.BigEndian.AppendUint32(msg, 300)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(cname)))
+	msg = append(msg, cname...)
+
+	rdata := []byte{192, 0, 2, 1}
+	if qtype == dnsTypeAAAA {
+		rdata = net.ParseIP("2001:db8::1")
+	}
+	msg = append(msg, 0xc0, 0x0c)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, 1)
+	msg = binary.BigEndian.AppendUint32(msg, recordTTL)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(rdata)))
+	return append(msg, rdata...)
+}
+
+func TestParseDNSAnswerTTL(t *testing.T) {
+	query, err := buildDNSQuery(0x1234, "api1.example.com", dnsTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ttl, err := parseDNSAnswerTTL(dnsAnswer(query, dnsTypeA, 45), 0x1234, dnsTypeA)
+	if err != nil || ttl != 45*time.Second {
+		t.Fatalf("ttl = %v, %v; want 45s (minimum over the CNAME chain)", ttl, err)
+	}
+
+	if _, err := parseDNSAnswerTTL(dnsAnswer(query, dnsTypeA, 45), 0x9999, dnsTypeA); err == nil {
+		t.Fatal("mismatched id accepted")
+	}
+	if _, err := parseDNSAnswerTTL(dnsAnswer(query, dnsTypeA, 45)[:40], 0x1234, dnsTypeA); err == nil {
+		t.Fatal("truncated message accepted")
+	}
+	nxdomain := append([]byte(nil), query...)
+	binary.BigEndian.PutUint16(nxdomain[2:], 0x8183)
+	if _, err := parseDNSAnswerTTL(nxdomain, 0x1234, dnsTypeA); err == nil {
+		t.Fatal("NXDOMAIN accepted")
+	}
+	if _, err := buildDNSQuery(1, "bad..host", dnsTypeA); err == nil {
+		t.Fatal("empty label accepted")
+	}
+}
+
+func TestSystemResolverQueriesRecordTTL(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp listen: %v", err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			qtype := binary.BigEndian.Uint16(query[n-4:])
+			recordTTL := uint32(120)
+			if qtype == dnsTypeAAAA {
+				recordTTL = 30
+			}
+			pc.WriteTo(dnsAnswer(query, qtype, recordTTL), addr)
+		}
+	}()
+
+	r := &systemResolver{resolver: net.DefaultResolver, servers: []string{pc.LocalAddr().String()}}
+	ttl, err := r.queryTTL(context.Background(), "api1.example.com")
+	if err != nil || ttl != 30*time.Second {
+		t.Fatalf("ttl = %v, %v; want the smaller AAAA TTL of 30s", ttl, err)
+	}
+
+	r.servers = nil
+	if _, err := r.queryTTL(context.Background(), "api1.example.com"); err == nil {
+		t.Fatal("TTL reported without nameservers")
+	}
+}
@@ -0,0 +1,57 @@
+package opportunitysink
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileRecord NDJSON 文件中的一行
+type fileRecord struct {
+	Time time.Time `json:"time"`
+	*pricestore.ArbitrageOpportunity
+}
+
+// FileSink 将新确认的机会以 NDJSON（每行一个JSON对象）追加到文件
+type FileSink struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewFileSink 创建 NDJSON 文件输出
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Name 输出目标名称
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Publish 追加本轮新确认的机会（每次打开文件追加，外部轮转或删除文件后自动重新创建）
+func (s *FileSink) Publish(batch *Batch) error {
+	if len(batch.New) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, opp := range batch.New {
+		if err := encoder.Encode(fileRecord{Time: batch.Time, ArbitrageOpportunity: opp}); err != nil {
+			return fmt.Errorf("failed to write %s: %w", s.path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package opportunitysink
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Source 套利机会来源（*pricestore.PriceStore）
+type Source interface {
+	GetArbitrageOpportunities() []*pricestore.ArbitrageOpportunity
+}
+
+// Batch 一轮评估的结果
+type Batch struct {
+	Time      time.Time
+	Confirmed []*pricestore.ArbitrageOpportunity // 当前所有已确认的机会
+	New       []*pricestore.ArbitrageOpportunity // 本轮新确认的机会（上一轮不在已确认列表中）
+}
+
+// Sink 套利机会输出目标
+type Sink interface {
+	Name() string
+	Publish(batch *Batch) error
+}
+
+// Fanout 每轮评估一次套利机会，分发给所有启用的输出目标
+type Fanout struct {
+	source Source
+	sinks  []Sink
+	seen   map[string]bool // 上一轮已确认的机会
+	gate   func() bool     // 返回false时只评估不分发（主备模式下的 standby），为nil时总是分发
+}
+
+// NewFanout 创建分发器
+func NewFanout(source Source, sinks ...Sink) *Fanout {
+	return &Fanout{
+		source: source,
+		sinks:  sinks,
+		seen:   make(map[string]bool),
+	}
+}
+
+// SetGate 设置分发条件（需要在 Run 之前调用）
+// 不满足时仍然每轮评估，保持"新确认"的判断连续，切换为 leader 后不会把已有机会当作新机会重复发送
+func (f *Fanout) SetGate(gate func() bool) {
+	f.gate = gate
+}
+
+// Sinks 返回启用的输出目标
+func (f *Fanout) Sinks() []Sink {
+	return f.sinks
+}
+
+// Evaluate 评估一次当前已确认的机会（不分发）
+func (f *Fanout) Evaluate(now time.Time) *Batch {
+	batch := &Batch{Time: now.UTC()}
+	current := make(map[string]bool)
+
+	for _, opp := range f.source.GetArbitrageOpportunities() {
+		if !opp.IsConfirmed {
+			continue
+		}
+		key := opportunityKey(opp)
+		current[key] = true
+		batch.Confirmed = append(batch.Confirmed, opp)
+		if !f.seen[key] {
+			batch.New = append(batch.New, opp)
+		}
+	}
+
+	f.seen = current
+	return batch
+}
+
+// Dispatch 评估一次并分发给所有输出目标（分发条件不满足时不分发），返回各目标的错误
+// 各目标并发发送，慢的目标（例如 webhook 超时）不会推迟其他目标，单个目标失败不影响其他目标
+func (f *Fanout) Dispatch(now time.Time) map[string]error {
+	batch := f.Evaluate(now)
+	if f.gate != nil && !f.gate() {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	for _, sink := range f.sinks {
+		wg.Add(1)
+		go func(sink Sink) {
+			defer wg.Done()
+			if err := sink.Publish(batch); err != nil {
+				mu.Lock()
+				errs[sink.Name()] = err
+				mu.Unlock()
+			}
+		}(sink)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Run 按 interval 定期分发，直到 stopChan 关闭
+func (f *Fanout) Run(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case now := <-ticker.C:
+			for name, err := range f.Dispatch(now) {
+				log.Printf("[OpportunitySink] %s failed: %v", name, err)
+			}
+		}
+	}
+}
+
+// opportunityKey 机会的唯一键（与存储中的机会跟踪器一致）
+func opportunityKey(opp *pricestore.ArbitrageOpportunity) string {
+	return fmt.Sprintf("%s_%s_%s_%s", opp.Symbol, opp.Type, opp.BuyFrom, opp.SellTo)
+}
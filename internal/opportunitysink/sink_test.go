@@ -0,0 +1,149 @@
+package opportunitysink
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSource 返回固定的套利机会
+type fakeSource struct {
+	mu            sync.Mutex
+	opportunities []*pricestore.ArbitrageOpportunity
+}
+
+func (s *fakeSource) GetArbitrageOpportunities() []*pricestore.ArbitrageOpportunity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*pricestore.ArbitrageOpportunity(nil), s.opportunities...)
+}
+
+func (s *fakeSource) set(opportunities ...*pricestore.ArbitrageOpportunity) {
+	s.mu.Lock()
+	s.opportunities = opportunities
+	s.mu.Unlock()
+}
+
+func confirmedOpportunity(symbol string) *pricestore.ArbitrageOpportunity {
+	return &pricestore.ArbitrageOpportunity{
+		Type: "major_coin_spread", Symbol: symbol, SpreadPercent: 0.2,
+		BuyFrom: "BINANCE FUTURE", SellTo: "LIGHTER FUTURE", IsConfirmed: true,
+	}
+}
+
+// recordingSink 记录收到的批次；release 非nil时每次发送阻塞到 release 关闭，err 非nil时发送失败
+type recordingSink struct {
+	name     string
+	release  chan struct{}
+	err      error
+	received chan *Batch
+}
+
+func newRecordingSink(name string) *recordingSink {
+	return &recordingSink{name: name, received: make(chan *Batch, 8)}
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Publish(batch *Batch) error {
+	s.received <- batch
+	if s.release != nil {
+		<-s.release
+	}
+	return s.err
+}
+
+func (s *recordingSink) next(t *testing.T) *Batch {
+	t.Helper()
+	select {
+	case batch := <-s.received:
+		return batch
+	case <-time.After(time.Second):
+		t.Fatalf("%s received nothing", s.name)
+		return nil
+	}
+}
+
+func batchSymbols(batch []*pricestore.ArbitrageOpportunity) []string {
+	symbols := make([]string, 0, len(batch))
+	for _, opp := range batch {
+		symbols = append(symbols, opp.Symbol)
+	}
+	return symbols
+}
+
+func TestFanoutSlowAndFailingSinks(t *testing.T) {
+	source := &fakeSource{}
+	source.set(confirmedOpportunity("BTC"), &pricestore.ArbitrageOpportunity{Symbol: "ETH"})
+
+	slow := newRecordingSink("slow")
+	slow.release = make(chan struct{})
+	failing := newRecordingSink("failing")
+	failing.err = errors.New("webhook returned 500")
+	fanout := NewFanout(source, slow, failing)
+
+	done := make(chan map[string]error, 1)
+	go func() { done <- fanout.Dispatch(time.Now()) }()
+
+	// 慢的目标还没返回时，失败的目标已经收到同一批次
+	failed := failing.next(t)
+	delayed := slow.next(t)
+	if failed != delayed {
+		t.Fatal("sinks received different batches")
+	}
+	if got := batchSymbols(failed.New); len(got) != 1 || got[0] != "BTC" {
+		t.Fatalf("new = %v, want only the confirmed BTC", got)
+	}
+	select {
+	case <-done:
+		t.Fatal("Dispatch returned before the slow sink finished")
+	default:
+	}
+
+	close(slow.release)
+	errs := <-done
+	if len(errs) != 1 || errs["failing"] == nil {
+		t.Fatalf("errors = %v, want only the failing sink", errs)
+	}
+
+	// 下一轮两个目标都继续收到，已发送过的机会不再算新确认
+	source.set(confirmedOpportunity("BTC"), confirmedOpportunity("SOL"))
+	errs = fanout.Dispatch(time.Now())
+	if errs["failing"] == nil || errs["slow"] != nil {
+		t.Fatalf("second round errors = %v", errs)
+	}
+	for _, sink := range []*recordingSink{slow, failing} {
+		batch := sink.next(t)
+		if got := batchSymbols(batch.New); len(got) != 1 || got[0] != "SOL" {
+			t.Fatalf("%s second round new = %v, want [SOL]", sink.name, got)
+		}
+		if len(batch.Confirmed) != 2 {
+			t.Fatalf("%s second round confirmed = %v", sink.name, batchSymbols(batch.Confirmed))
+		}
+	}
+}
+
+func TestFanoutGate(t *testing.T) {
+	source := &fakeSource{}
+	source.set(confirmedOpportunity("BTC"))
+	sink := newRecordingSink("sink")
+	fanout := NewFanout(source, sink)
+
+	leader := false
+	fanout.SetGate(func() bool { return leader })
+	fanout.Dispatch(time.Now())
+	select {
+	case <-sink.received:
+		t.Fatal("standby dispatched to a sink")
+	default:
+	}
+
+	// 切换为 leader 后，standby 期间已确认的机会不当作新机会
+	leader = true
+	fanout.Dispatch(time.Now())
+	if batch := sink.next(t); len(batch.New) != 0 || len(batch.Confirmed) != 1 {
+		t.Fatalf("after promotion new = %v, confirmed = %v", batchSymbols(batch.New), batchSymbols(batch.Confirmed))
+	}
+}
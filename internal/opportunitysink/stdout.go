@@ -0,0 +1,62 @@
+package opportunitysink
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// TableSink 定期以表格形式输出所有已确认的机会（默认写到标准输出）
+type TableSink struct {
+	out io.Writer
+	loc *time.Location // 显示时间的时区，nil表示使用时间本身的时区
+}
+
+// NewTableSink 创建表格输出，out 为nil时使用标准输出
+func NewTableSink(out io.Writer) *TableSink {
+	if out == nil {
+		out = os.Stdout
+	}
+	return &TableSink{out: out}
+}
+
+// SetLocation 设置表头时间的显示时区
+func (s *TableSink) SetLocation(loc *time.Location) {
+	s.loc = loc
+}
+
+// Name 输出目标名称
+func (s *TableSink) Name() string {
+	return "stdout"
+}
+
+// Publish 输出当前已确认的机会，新确认的机会以 * 标记
+func (s *TableSink) Publish(batch *Batch) error {
+	isNew := make(map[string]bool, len(batch.New))
+	for _, opp := range batch.New {
+		isNew[opportunityKey(opp)] = true
+	}
+
+	at := batch.Time
+	if s.loc != nil {
+		at = at.In(s.loc)
+	}
+	tw := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "=== %d confirmed opportunities @ %s ===\n", len(batch.Confirmed), at.Format("15:04:05 MST"))
+	if len(batch.Confirmed) == 0 {
+		return tw.Flush()
+	}
+
+	fmt.Fprintln(tw, "\tSYMBOL\tSPREAD%\tBUY\tSELL\tDURATION\tCONFIDENCE\tMODE")
+	for _, opp := range batch.Confirmed {
+		marker := ""
+		if isNew[opportunityKey(opp)] {
+			marker = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.3f\t%s\t%s\t%.0fs\t%.2f\t%s\n",
+			marker, opp.Symbol, opp.SpreadPercent, opp.BuyFrom, opp.SellTo, opp.Duration, opp.Confidence, opp.ExecutionMode)
+	}
+	return tw.Flush()
+}
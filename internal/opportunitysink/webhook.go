@@ -0,0 +1,65 @@
+package opportunitysink
+
+import (
+	"bytes"
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookPayload webhook 请求体
+type webhookPayload struct {
+	Time          time.Time                          `json:"time"`
+	Count         int                                `json:"count"`
+	Opportunities []*pricestore.ArbitrageOpportunity `json:"opportunities"`
+}
+
+// WebhookSink 将新确认的机会以JSON POST 到 webhook 地址
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink 创建 webhook 输出
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 输出目标名称
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+// Publish 发送本轮新确认的机会（没有新机会时不发送），非2xx响应视为失败
+func (s *WebhookSink) Publish(batch *Batch) error {
+	if len(batch.New) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Time:          batch.Time,
+		Count:         len(batch.New),
+		Opportunities: batch.New,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
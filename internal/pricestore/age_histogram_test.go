@@ -0,0 +1,48 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetAgeHistogram(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+
+	add := func(exchange common.Exchange, marketType common.MarketType, ages ...time.Duration) {
+		for i, age := range ages {
+			ts := now.Add(-age)
+			price := projectionQuote(exchange, 100, 100.1, ts, ts)
+			price.Symbol = fmt.Sprintf("S%d%sUSDT", i, marketType)
+			price.MarketType = marketType
+			if !ps.UpdatePrice(price) {
+				t.Fatalf("update %s %s age %v rejected", exchange, marketType, age)
+			}
+		}
+	}
+	add(common.ExchangeBinance, common.MarketTypeFuture, 100*time.Millisecond, 500*time.Millisecond, 2*time.Second, 45*time.Second)
+	add(common.ExchangeBinance, common.MarketTypeSpot, 3*time.Second, 10*time.Second, 29*time.Second)
+	add(common.ExchangeLighter, common.MarketTypeFuture, 2*time.Minute, 10*time.Minute)
+
+	got := ps.GetAgeHistogram()
+	want := map[string][]int{
+		"BINANCE_FUTURE": {2, 1, 0, 1},
+		"BINANCE_SPOT":   {0, 1, 2, 0},
+		"LIGHTER_FUTURE": {0, 0, 0, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("histogram = %v, want %v", got, want)
+	}
+	for key, counts := range got {
+		if len(counts) != len(AgeHistogramBuckets) {
+			t.Fatalf("%s has %d buckets, want %d", key, len(counts), len(AgeHistogramBuckets))
+		}
+	}
+
+	if empty := NewPriceStore().GetAgeHistogram(); len(empty) != 0 {
+		t.Fatalf("empty store histogram = %v", empty)
+	}
+}
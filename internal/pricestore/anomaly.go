@@ -0,0 +1,311 @@
+package pricestore
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	maxAnomalyAnnotations = 200    // 最多保留的异常标注数
+	anomalyMinSamples     = 30     // 窗口内样本数达到该值后才开始判断
+	anomalyMinScale       = 0.01   // 离散度下限（百分点），价差长期不变（MAD为0）时避免除以0
+	madToSigma            = 1.4826 // MAD 换算为正态分布标准差的系数
+)
+
+// AnomalyConfig 价差异常标注配置
+type AnomalyConfig struct {
+	ZThreshold float64       // 价差相对滚动中位数的稳健z分数达到该值时标注，0表示不启用
+	Window     time.Duration // 滚动统计窗口
+	Cooldown   time.Duration // 同一交易对两次标注的最小间隔
+}
+
+// DefaultAnomalyConfig 默认价差异常标注配置（默认不启用，由 RunAnomalyAnnotator 所在的存储启用）
+func DefaultAnomalyConfig() *AnomalyConfig {
+	return &AnomalyConfig{
+		ZThreshold: 0,
+		Window:     time.Hour,
+		Cooldown:   10 * time.Minute,
+	}
+}
+
+// AnomalyAnnotation 价差异常标注：某交易对的最优价差明显高于其滚动窗口内的常态
+type AnomalyAnnotation struct {
+	Symbol         string    `json:"symbol"`
+	BuyFrom        string    `json:"buy_from"` // 最优方向的买入场所 EXCHANGE_MARKETTYPE
+	SellTo         string    `json:"sell_to"`  // 最优方向的卖出场所 EXCHANGE_MARKETTYPE
+	SpreadPercent  float64   `json:"spread_percent"`
+	Median         float64   `json:"median"`          // 窗口内价差中位数（百分比）
+	MAD            float64   `json:"mad"`             // 窗口内价差的中位数绝对偏差（百分点）
+	ZScore         float64   `json:"z_score"`         // (价差-中位数) / (1.4826*MAD)
+	MedianMultiple float64   `json:"median_multiple"` // 价差是中位数的倍数，中位数不为正时为0
+	Samples        int       `json:"samples"`         // 窗口内样本数
+	Window         string    `json:"window"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// anomalyAnnotator 各交易对的滚动价差统计和最近的标注（有自己的锁，不占用 ps.mu）
+type anomalyAnnotator struct {
+	mu          sync.Mutex
+	cfg         *AnomalyConfig
+	pairs       map[string]*anomalyPair
+	annotations []*AnomalyAnnotation
+}
+
+// anomalyPair 单个交易对（symbol + 两个场所，不区分方向）的滚动窗口
+type anomalyPair struct {
+	window        rollingWindow
+	lastAnnotated time.Time
+}
+
+// SetAnomalyConfig 设置价差异常标注配置（nil表示恢复默认），窗口或阈值变化时清空已有统计
+func (ps *PriceStore) SetAnomalyConfig(cfg *AnomalyConfig) {
+	if cfg == nil {
+		cfg = DefaultAnomalyConfig()
+	}
+
+	ps.anomaly.mu.Lock()
+	defer ps.anomaly.mu.Unlock()
+	ps.anomaly.cfg = cfg
+	ps.anomaly.pairs = make(map[string]*anomalyPair)
+}
+
+// RunAnomalyAnnotator 按 interval 采样各交易对的最优价差并标注异常，直到 stopChan 关闭
+// interval 同时是滚动窗口的采样间隔
+func (ps *PriceStore) RunAnomalyAnnotator(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			ps.annotateSpreads(ps.calcSnapshot().allSpreads(now), now)
+		}
+	}
+}
+
+// GetAnomalies 获取最近的价差异常标注（最新的在前）
+func (ps *PriceStore) GetAnomalies() []*AnomalyAnnotation {
+	ps.anomaly.mu.Lock()
+	defer ps.anomaly.mu.Unlock()
+
+	result := make([]*AnomalyAnnotation, 0, len(ps.anomaly.annotations))
+	for i := len(ps.anomaly.annotations) - 1; i >= 0; i-- {
+		result = append(result, ps.anomaly.annotations[i])
+	}
+	return result
+}
+
+// annotateSpreads 用一轮价差更新各交易对的滚动窗口，返回本轮新增的标注
+// 每个交易对取两个方向中较大的价差作为本轮样本；样本先与窗口（不含自身）比较，再加入窗口
+func (ps *PriceStore) annotateSpreads(spreads []*Spread, now time.Time) []*AnomalyAnnotation {
+	a := ps.anomaly
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cfg := a.cfg
+	if cfg == nil || cfg.ZThreshold <= 0 {
+		return nil
+	}
+
+	best := make(map[string]*Spread)
+	for _, spread := range spreads {
+		key := anomalyPairKey(spread)
+		if current, exists := best[key]; !exists || spread.SpreadPercent > current.SpreadPercent {
+			best[key] = spread
+		}
+	}
+
+	cutoff := now.Add(-cfg.Window)
+	added := make([]*AnomalyAnnotation, 0)
+	for key, spread := range best {
+		pair := a.pairs[key]
+		if pair == nil {
+			pair = &anomalyPair{}
+			a.pairs[key] = pair
+		}
+		pair.window.evict(cutoff)
+
+		if annotation := pair.check(spread, cfg, now); annotation != nil {
+			pair.lastAnnotated = now
+			added = append(added, annotation)
+			log.Printf("[Anomaly] symbol=%s buy=%s sell=%s spread=%.4f%% median=%.4f%% mad=%.4f z=%.1f multiple=%.1fx samples=%d window=%s",
+				annotation.Symbol, annotation.BuyFrom, annotation.SellTo, annotation.SpreadPercent,
+				annotation.Median, annotation.MAD, annotation.ZScore, annotation.MedianMultiple,
+				annotation.Samples, annotation.Window)
+		}
+		pair.window.add(now, spread.SpreadPercent)
+	}
+
+	// 本轮没有价差的交易对只清理过期样本，窗口为空后移除
+	for key, pair := range a.pairs {
+		if _, seen := best[key]; seen {
+			continue
+		}
+		pair.window.evict(cutoff)
+		if pair.window.len() == 0 {
+			delete(a.pairs, key)
+		}
+	}
+
+	a.annotations = append(a.annotations, added...)
+	if len(a.annotations) > maxAnomalyAnnotations {
+		a.annotations = a.annotations[len(a.annotations)-maxAnomalyAnnotations:]
+	}
+	return added
+}
+
+// check 判断价差是否相对窗口常态异常，未达到样本数、阈值或仍在冷却期内时返回nil
+func (p *anomalyPair) check(spread *Spread, cfg *AnomalyConfig, now time.Time) *AnomalyAnnotation {
+	samples := p.window.len()
+	if samples < anomalyMinSamples {
+		return nil
+	}
+	if !p.lastAnnotated.IsZero() && now.Sub(p.lastAnnotated) < cfg.Cooldown {
+		return nil
+	}
+
+	median := p.window.median()
+	mad := p.window.mad()
+	scale := math.Max(madToSigma*mad, anomalyMinScale)
+	z := (spread.SpreadPercent - median) / scale
+	if z < cfg.ZThreshold {
+		return nil
+	}
+
+	multiple := 0.0
+	if median > 0 {
+		multiple = spread.SpreadPercent / median
+	}
+	return &AnomalyAnnotation{
+		Symbol:         spread.Symbol,
+		BuyFrom:        string(spread.BuyExchange) + "_" + string(spread.BuyMarketType),
+		SellTo:         string(spread.SellExchange) + "_" + string(spread.SellMarketType),
+		SpreadPercent:  spread.SpreadPercent,
+		Median:         median,
+		MAD:            mad,
+		ZScore:         z,
+		MedianMultiple: multiple,
+		Samples:        samples,
+		Window:         cfg.Window.String(),
+		DetectedAt:     now,
+	}
+}
+
+// anomalyPairKey 交易对键：symbol + 两个场所（按字典序，不区分方向）
+func anomalyPairKey(spread *Spread) string {
+	legA := string(spread.BuyExchange) + "_" + string(spread.BuyMarketType)
+	legB := string(spread.SellExchange) + "_" + string(spread.SellMarketType)
+	if legA > legB {
+		legA, legB = legB, legA
+	}
+	return fmt.Sprintf("%s|%s|%s", spread.Symbol, legA, legB)
+}
+
+// rollingWindow 按时间滚动的样本窗口，增量维护有序副本：
+// 加入和移除样本为二分查找定位，中位数 O(1)，MAD O(log n)，不需要每轮重新扫描整个窗口
+type rollingWindow struct {
+	samples []windowSample // 按加入时间排列，head 之前的已移出窗口
+	head    int
+	sorted  []float64
+}
+
+// windowSample 窗口中的单个样本
+type windowSample struct {
+	at    time.Time
+	value float64
+}
+
+// len 窗口内样本数
+func (w *rollingWindow) len() int {
+	return len(w.sorted)
+}
+
+// add 加入样本
+func (w *rollingWindow) add(at time.Time, value float64) {
+	w.samples = append(w.samples, windowSample{at: at, value: value})
+	i := sort.SearchFloat64s(w.sorted, value)
+	w.sorted = append(w.sorted, 0)
+	copy(w.sorted[i+1:], w.sorted[i:])
+	w.sorted[i] = value
+}
+
+// evict 移除早于 cutoff 的样本
+func (w *rollingWindow) evict(cutoff time.Time) {
+	for w.head < len(w.samples) && w.samples[w.head].at.Before(cutoff) {
+		value := w.samples[w.head].value
+		i := sort.SearchFloat64s(w.sorted, value)
+		w.sorted = append(w.sorted[:i], w.sorted[i+1:]...)
+		w.head++
+	}
+	// 已移出的样本超过一半时压缩，避免底层数组无限增长
+	if w.head > 0 && w.head*2 >= len(w.samples) {
+		w.samples = append(w.samples[:0], w.samples[w.head:]...)
+		w.head = 0
+	}
+}
+
+// median 窗口中位数（窗口不能为空）
+func (w *rollingWindow) median() float64 {
+	n := len(w.sorted)
+	if n%2 == 1 {
+		return w.sorted[n/2]
+	}
+	return (w.sorted[n/2-1] + w.sorted[n/2]) / 2
+}
+
+// mad 中位数绝对偏差（窗口不能为空）
+// 中位数左侧样本的偏差从中位数向外递增，右侧同理，两个有序序列中选第k小即可，不需要排序全部偏差
+func (w *rollingWindow) mad() float64 {
+	m := w.median()
+	n := len(w.sorted)
+	split := sort.SearchFloat64s(w.sorted, m)
+	left := func(i int) float64 { return m - w.sorted[split-1-i] }
+	right := func(j int) float64 { return w.sorted[split+j] - m }
+	nl, nr := split, n-split
+
+	if n%2 == 1 {
+		return kthOfTwoSorted(left, nl, right, nr, n/2)
+	}
+	return (kthOfTwoSorted(left, nl, right, nr, n/2-1) + kthOfTwoSorted(left, nl, right, nr, n/2)) / 2
+}
+
+// kthOfTwoSorted 两个升序序列合并后的第k小（从0开始）
+// 二分确定从 a 中取 i 个、从 b 中取 k+1-i 个，使两边取到的最大值不超过对方未取的最小值
+func kthOfTwoSorted(a func(int) float64, na int, b func(int) float64, nb int, k int) float64 {
+	lo := k + 1 - nb
+	if lo < 0 {
+		lo = 0
+	}
+	hi := k + 1
+	if hi > na {
+		hi = na
+	}
+	for lo < hi {
+		i := (lo + hi) / 2
+		j := k + 1 - i
+		// a 取得太少：a[i] 小于 b 中已取的最大值
+		if j > 0 && a(i) < b(j-1) {
+			lo = i + 1
+		} else {
+			hi = i
+		}
+	}
+
+	i := lo
+	j := k + 1 - i
+	result := math.Inf(-1)
+	if i > 0 {
+		result = a(i - 1)
+	}
+	if j > 0 && b(j-1) > result {
+		result = b(j - 1)
+	}
+	return result
+}
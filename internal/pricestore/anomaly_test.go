@@ -0,0 +1,83 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+func anomalySpread(buy, sell common.Exchange, percent float64) *Spread {
+	return &Spread{
+		Symbol:      "BTCUSDT",
+		BuyExchange: buy, BuyMarketType: common.MarketTypeFuture,
+		SellExchange: sell, SellMarketType: common.MarketTypeFuture,
+		SpreadPercent: percent,
+	}
+}
+
+// newAnomalyStore 按分钟采样 anomalyMinSamples 轮 0.09%~0.11% 的常态价差，返回下一轮的时间
+func newAnomalyStore(t *testing.T) (*PriceStore, time.Time) {
+	t.Helper()
+	ps := NewPriceStore()
+	ps.SetAnomalyConfig(&AnomalyConfig{ZThreshold: 6, Window: time.Hour, Cooldown: 10 * time.Minute})
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < anomalyMinSamples; i++ {
+		normal := 0.09 + float64(i%3)*0.01
+		// 反方向的负价差与正方向属于同一交易对，每轮只取较大的一个
+		spreads := []*Spread{
+			anomalySpread(common.ExchangeBinance, common.ExchangeLighter, normal),
+			anomalySpread(common.ExchangeLighter, common.ExchangeBinance, -normal-0.02),
+		}
+		if added := ps.annotateSpreads(spreads, now); len(added) != 0 {
+			t.Fatalf("annotation while filling the window: %+v", added[0])
+		}
+		now = now.Add(time.Minute)
+	}
+	return ps, now
+}
+
+func TestAnomalyNoSpike(t *testing.T) {
+	ps, now := newAnomalyStore(t)
+	for _, percent := range []float64{0.11, 0.12, 0.08} {
+		spread := anomalySpread(common.ExchangeBinance, common.ExchangeLighter, percent)
+		if added := ps.annotateSpreads([]*Spread{spread}, now); len(added) != 0 {
+			t.Fatalf("%.2f%% annotated as an anomaly: %+v", percent, added[0])
+		}
+		now = now.Add(time.Minute)
+	}
+	if got := ps.GetAnomalies(); len(got) != 0 {
+		t.Fatalf("%d anomalies recorded, want none", len(got))
+	}
+}
+
+func TestAnomalySpike(t *testing.T) {
+	ps, now := newAnomalyStore(t)
+
+	spike := anomalySpread(common.ExchangeLighter, common.ExchangeBinance, 0.5)
+	added := ps.annotateSpreads([]*Spread{spike}, now)
+	if len(added) != 1 {
+		t.Fatalf("%d annotations for a 0.5%% spike, want 1", len(added))
+	}
+	a := added[0]
+	if a.BuyFrom != "LIGHTER_FUTURE" || a.SellTo != "BINANCE_FUTURE" || a.Samples != anomalyMinSamples ||
+		math.Abs(a.Median-0.10) > 1e-9 || math.Abs(a.MAD-0.01) > 1e-9 || math.Abs(a.MedianMultiple-5) > 1e-9 {
+		t.Fatalf("annotation = %+v", a)
+	}
+	if wantZ := (0.5 - 0.10) / (madToSigma * 0.01); math.Abs(a.ZScore-wantZ) > 1e-6 {
+		t.Fatalf("z = %v, want %v", a.ZScore, wantZ)
+	}
+
+	// 冷却期内同一交易对不再标注，冷却期过后再次标注
+	now = now.Add(time.Minute)
+	if added := ps.annotateSpreads([]*Spread{spike}, now); len(added) != 0 {
+		t.Fatal("annotated again within the cooldown")
+	}
+	now = now.Add(10 * time.Minute)
+	if added := ps.annotateSpreads([]*Spread{spike}, now); len(added) != 1 {
+		t.Fatalf("%d annotations after the cooldown, want 1", len(added))
+	}
+	if got := ps.GetAnomalies(); len(got) != 2 || !got[0].DetectedAt.Equal(now) {
+		t.Fatalf("anomalies = %d, want 2 with the newest first", len(got))
+	}
+}
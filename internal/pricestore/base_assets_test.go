@@ -0,0 +1,47 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUniqueBaseAssetsCollapseQuotePairs(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+
+	// BTC 有 USDT、FDUSD、USDC 三个报价货币和一个币本位合约，ETH 只有 USDT
+	for _, quote := range []struct {
+		exchange common.Exchange
+		symbol   string
+	}{
+		{common.ExchangeBinance, "BTCUSDT"},
+		{common.ExchangeAster, "BTCUSDT"},
+		{common.ExchangeBinance, "BTCFDUSD"},
+		{common.ExchangeLighter, "BTCUSDC"},
+		{common.ExchangeBinance, "BTCUSD_PERP"},
+		{common.ExchangeBinance, "ETHUSDT"},
+	} {
+		price := venueQuote(quote.exchange, quote.symbol, 100, 100.01, now)
+		price.QuoteCurrency = ""
+		price.IsNormalized = false
+		if !ps.UpdatePrice(price) {
+			t.Fatalf("%s %s rejected", quote.exchange, quote.symbol)
+		}
+	}
+
+	if got, want := ps.GetUniqueBaseAssets(), []string{"BTC", "ETH"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unique base assets = %v, want %v", got, want)
+	}
+	stats := ps.GetStats()
+	if stats.UniqueBaseAssets != 2 || stats.TotalSymbols <= stats.UniqueBaseAssets {
+		t.Fatalf("stats: %d symbols, %d unique base assets, want the quote pairs counted once", stats.TotalSymbols, stats.UniqueBaseAssets)
+	}
+}
+
+func TestUniqueBaseAssetsEmpty(t *testing.T) {
+	if got := NewPriceStore().GetUniqueBaseAssets(); got == nil || len(got) != 0 {
+		t.Fatalf("unique base assets = %#v, want an empty list", got)
+	}
+}
@@ -0,0 +1,205 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// blacklistRegexPrefix 正则规则前缀，例如 "re:^.*[35][LS]USDT$"
+const blacklistRegexPrefix = "re:"
+
+// blacklistRule 编译后的黑名单规则（加载/添加时编译一次，不在每次更新时编译）
+// 支持三种形式：
+// - 精确匹配: "USDCUSDT"
+// - 通配符:   "*DOWNUSDT"、"BTC?USDT"（* 匹配任意字符，? 匹配单个字符，注意通配符可能误伤正常币种，例如 *UPUSDT 会匹配 JUPUSDT）
+// - 正则:     "re:^.*[35][LS]USDT$"
+type blacklistRule struct {
+	pattern string
+	exact   string
+	re      *regexp.Regexp
+}
+
+// BlacklistEntry 黑名单规则及其命中次数
+type BlacklistEntry struct {
+	Pattern string `json:"pattern"`
+	Hits    int64  `json:"hits"` // 在入库时被拒绝的价格数
+}
+
+// compileBlacklistRule 编译黑名单规则
+func compileBlacklistRule(pattern string) (*blacklistRule, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+
+	if strings.HasPrefix(pattern, blacklistRegexPrefix) {
+		re, err := regexp.Compile("(?i)" + strings.TrimPrefix(pattern, blacklistRegexPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		return &blacklistRule{pattern: pattern, re: re}, nil
+	}
+
+	pattern = strings.ToUpper(pattern)
+	if !strings.ContainsAny(pattern, "*?") {
+		return &blacklistRule{pattern: pattern, exact: pattern}, nil
+	}
+
+	// 通配符转换为正则
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, ch := range pattern {
+		switch ch {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("$")
+	return &blacklistRule{pattern: pattern, re: regexp.MustCompile(sb.String())}, nil
+}
+
+// match 判断symbol是否命中规则（symbol 需为大写）
+func (r *blacklistRule) match(symbol string) bool {
+	if r.re != nil {
+		return r.re.MatchString(symbol)
+	}
+	return r.exact == symbol
+}
+
+// LoadBlacklist 加载symbol黑名单，并设置持久化路径
+// 文件存在时以文件内容为准（通过 /api/blacklist 修改后写回文件），否则使用 defaults
+func (ps *PriceStore) LoadBlacklist(path string, defaults []string) error {
+	patterns := defaults
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read blacklist file: %w", err)
+	}
+	if err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &patterns); err != nil {
+			return fmt.Errorf("failed to parse blacklist file: %w", err)
+		}
+	}
+
+	rules := make([]*blacklistRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, err := compileBlacklistRule(pattern)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.blacklistFile = path
+	ps.blacklist = rules
+	return nil
+}
+
+// GetBlacklist 获取所有黑名单规则及命中次数
+func (ps *PriceStore) GetBlacklist() []BlacklistEntry {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	entries := make([]BlacklistEntry, 0, len(ps.blacklist))
+	for _, rule := range ps.blacklist {
+		entries = append(entries, BlacklistEntry{
+			Pattern: rule.pattern,
+			Hits:    ps.blacklistHits[rule.pattern],
+		})
+	}
+	return entries
+}
+
+// AddBlacklistPattern 添加黑名单规则并持久化，立即对后续更新生效
+// 先写文件再修改内存，写入失败时黑名单不变；已在存储中的价格由 CalculateSpreads 过滤
+func (ps *PriceStore) AddBlacklistPattern(pattern string) (string, error) {
+	rule, err := compileBlacklistRule(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for _, existing := range ps.blacklist {
+		if existing.pattern == rule.pattern {
+			return rule.pattern, nil
+		}
+	}
+
+	rules := append(append([]*blacklistRule(nil), ps.blacklist...), rule)
+	if err := ps.saveBlacklist(rules); err != nil {
+		return "", err
+	}
+	ps.blacklist = rules
+	return rule.pattern, nil
+}
+
+// RemoveBlacklistPattern 删除黑名单规则并持久化，返回规则是否存在（先写文件再修改内存）
+func (ps *PriceStore) RemoveBlacklistPattern(pattern string) (bool, error) {
+	rule, err := compileBlacklistRule(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for i, existing := range ps.blacklist {
+		if existing.pattern == rule.pattern {
+			rules := append(append([]*blacklistRule(nil), ps.blacklist[:i]...), ps.blacklist[i+1:]...)
+			if err := ps.saveBlacklist(rules); err != nil {
+				return false, err
+			}
+			ps.blacklist = rules
+			delete(ps.blacklistHits, rule.pattern)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// saveBlacklist 将 rules 写入黑名单文件（调用者需要持有 configMu）
+// 未设置文件路径时只保存在内存中
+func (ps *PriceStore) saveBlacklist(rules []*blacklistRule) error {
+	if ps.blacklistFile == "" {
+		return nil
+	}
+
+	patterns := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		patterns = append(patterns, rule.pattern)
+	}
+
+	data, err := json.MarshalIndent(patterns, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode blacklist: %w", err)
+	}
+
+	if err := common.WriteFileAtomic(ps.blacklistFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write blacklist file: %w", err)
+	}
+	return nil
+}
+
+// matchBlacklist 返回symbol命中的第一条规则，未命中返回空字符串（调用者需要持有锁）
+func (ps *PriceStore) matchBlacklist(symbol string) string {
+	return matchBlacklistRules(ps.blacklist, symbol)
+}
@@ -0,0 +1,115 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlacklistRuleMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		symbol  string
+		want    bool
+	}{
+		{"USDCUSDT", "USDCUSDT", true},
+		{"usdcusdt", "USDCUSDT", true},
+		{"USDCUSDT", "USDCUSDTX", false},
+		{"*DOWNUSDT", "ETHDOWNUSDT", true},
+		{"*DOWNUSDT", "ETHUSDT", false},
+		{"BTC?USDT", "BTCXUSDT", true},
+		{"BTC?USDT", "BTCUSDT", false},
+		{"BTC*USDT", "BTCUSDT", true},
+		{"BTC.USDT", "BTCXUSDT", false}, // 通配符中的 . 按字面匹配
+		{"*UPUSDT", "JUPUSDT", true},    // 通配符会误伤，默认规则因此使用按币种列出的正则
+		{"re:^.*[35][LS]USDT$", "BTC3LUSDT", true},
+		{"re:^.*[35][LS]USDT$", "BTCUSDT", false},
+		{"re:^btc", "BTCUSDT", true}, // 正则不区分大小写
+		{config.DefaultLeveragedTokenBlacklist, "BTCUPUSDT", true},
+		{config.DefaultLeveragedTokenBlacklist, "ETHDOWNUSDT", true},
+		{config.DefaultLeveragedTokenBlacklist, "1INCHUPUSDT", true},
+		{config.DefaultLeveragedTokenBlacklist, "JUPUSDT", false},
+		{config.DefaultLeveragedTokenBlacklist, "SUPERUSDT", false},
+		{config.DefaultLeveragedTokenBlacklist, "SETUPUSDT", false},
+		{config.DefaultLeveragedTokenBlacklist, "BTCUPUSDC", false},
+	}
+
+	for _, tt := range tests {
+		rule, err := compileBlacklistRule(tt.pattern)
+		if err != nil {
+			t.Fatalf("compile %q: %v", tt.pattern, err)
+		}
+		if got := rule.match(tt.symbol); got != tt.want {
+			t.Errorf("%q match %q = %v, want %v", tt.pattern, tt.symbol, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "  ", "re:("} {
+		if _, err := compileBlacklistRule(bad); err == nil {
+			t.Errorf("compile %q: want error", bad)
+		}
+	}
+}
+
+func TestBlacklistAddRemovePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.json")
+	ps := NewPriceStore()
+	if err := ps.LoadBlacklist(path, []string{"USDCUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern, err := ps.AddBlacklistPattern("btc*usdt")
+	if err != nil || pattern != "BTC*USDT" {
+		t.Fatalf("add = %q, %v", pattern, err)
+	}
+	if _, err := ps.AddBlacklistPattern("BTC*USDT"); err != nil || len(ps.GetBlacklist()) != 2 {
+		t.Fatalf("duplicate add: err=%v rules=%v", err, ps.GetBlacklist())
+	}
+
+	reloaded := NewPriceStore()
+	if err := reloaded.LoadBlacklist(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.GetBlacklist(); len(got) != 2 || got[1].Pattern != "BTC*USDT" {
+		t.Fatalf("reloaded = %+v", got)
+	}
+
+	if removed, err := ps.RemoveBlacklistPattern("BTC*USDT"); err != nil || !removed {
+		t.Fatalf("remove = %v, %v", removed, err)
+	}
+	if removed, err := ps.RemoveBlacklistPattern("BTC*USDT"); err != nil || removed {
+		t.Fatalf("second remove = %v, %v", removed, err)
+	}
+	if err := reloaded.LoadBlacklist(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.GetBlacklist(); len(got) != 1 {
+		t.Fatalf("reloaded after remove = %+v", got)
+	}
+}
+
+func TestBlacklistSaveFailureKeepsRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blacklist.json")
+	ps := NewPriceStore()
+	if err := ps.LoadBlacklist(path, []string{"USDCUSDT", "FDUSDUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 黑名单文件路径指向已删除的目录，写入失败
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ps.AddBlacklistPattern("ETHUSDT"); err == nil {
+		t.Fatal("add succeeded although the file could not be written")
+	}
+	if _, err := ps.RemoveBlacklistPattern("USDCUSDT"); err == nil {
+		t.Fatal("remove succeeded although the file could not be written")
+	}
+	got := ps.GetBlacklist()
+	if len(got) != 2 || got[0].Pattern != "USDCUSDT" || got[1].Pattern != "FDUSDUSDT" {
+		t.Fatalf("rules changed after failed save: %+v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"strings"
+)
+
+// priceSnapshot 价差/套利机会/自定义策略计算所需数据的副本
+// 在读锁内复制后，耗时的计算（两两组合、字符串格式化、调试输出）在副本上进行，不阻塞 UpdatePrice
+// 已入库的 Price 不会被原地修改（UpdatePrice 在自己的副本上处理完后整体替换），所以只复制索引和指针
+type priceSnapshot struct {
+	bySymbol map[string]map[string]*common.Price // 与 ps.bySymbol 结构相同（key: 标准symbol -> exchange_marketType）
+
+	blacklist            []*blacklistRule
+	minExchangeCount     int
+	minOpportunityVolume float64
+	allowedPairings      map[string]bool // SetAllowedPairings 整体替换，不会原地修改
+	priceMode            PriceMode
+	projection           *LatencyProjection // SetLatencyProjection 整体替换，不会原地修改
+	confidence           *ConfidenceWeights // SetConfidenceWeights 整体替换，不会原地修改
+	venueCaps            map[common.Exchange]VenueCapability
+	symbolNormalizer     *SymbolNormalizer // 自带锁
+
+	// 比值策略及各腿的价格（按 getRatioLegPrice 的优先顺序在锁内选好）
+	ratioStrategies []*RatioStrategy
+	ratioLegs       map[string]*common.Price
+}
+
+// calcSnapshot 在读锁内复制计算所需的数据，返回后不再持有锁
+func (ps *PriceStore) calcSnapshot() *priceSnapshot {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.calcSnapshotLocked(true)
+}
+
+// calcSnapshotLocked 复制计算所需的数据（调用者需要持有锁）
+// withPrices 为 false 时不复制价格索引，用于调用者自带报价的计算（例如按时间点的历史价差）
+func (ps *PriceStore) calcSnapshotLocked(withPrices bool) *priceSnapshot {
+	snap := &priceSnapshot{
+		blacklist:            append([]*blacklistRule(nil), ps.blacklist...),
+		minExchangeCount:     ps.minExchangeCount,
+		minOpportunityVolume: ps.minOpportunityVolume,
+		allowedPairings:      ps.allowedPairings,
+		priceMode:            ps.priceMode,
+		projection:           ps.latencyProjection,
+		confidence:           ps.confidence,
+		venueCaps:            make(map[common.Exchange]VenueCapability, len(ps.venueCaps)),
+		symbolNormalizer:     ps.symbolNormalizer,
+		ratioStrategies:      append([]*RatioStrategy(nil), ps.ratioStrategies...),
+		ratioLegs:            make(map[string]*common.Price),
+	}
+	for exchange, caps := range ps.venueCaps {
+		snap.venueCaps[exchange] = caps
+	}
+
+	if !withPrices {
+		return snap
+	}
+
+	snap.bySymbol = make(map[string]map[string]*common.Price, len(ps.bySymbol))
+	for symbol, priceMap := range ps.bySymbol {
+		copied := make(map[string]*common.Price, len(priceMap))
+		for key, price := range priceMap {
+			copied[key] = price
+		}
+		snap.bySymbol[symbol] = copied
+	}
+	for _, rs := range ps.ratioStrategies {
+		snap.ratioLegs[rs.BaseSymbol] = ps.getRatioLegPrice(rs.BaseSymbol)
+		snap.ratioLegs[rs.QuoteSymbol] = ps.getRatioLegPrice(rs.QuoteSymbol)
+	}
+	return snap
+}
+
+// matchBlacklist 返回symbol命中的第一条黑名单规则，未命中返回空字符串
+func (snap *priceSnapshot) matchBlacklist(symbol string) string {
+	return matchBlacklistRules(snap.blacklist, symbol)
+}
+
+// matchBlacklistRules 返回symbol命中的第一条规则，未命中返回空字符串
+func matchBlacklistRules(rules []*blacklistRule, symbol string) string {
+	if len(rules) == 0 {
+		return ""
+	}
+
+	symbol = strings.ToUpper(symbol)
+	for _, rule := range rules {
+		if rule.match(symbol) {
+			return rule.pattern
+		}
+	}
+	return ""
+}
+
+// belowMinOpportunityVolume 该腿已知的24小时成交量是否低于套利机会的最小成交量
+// 成交量未知（VolumeKnown=false，Volume24h 为0）的腿不过滤，与 /api/spreads 的 min_volume 一致
+func (snap *priceSnapshot) belowMinOpportunityVolume(price *common.Price) bool {
+	return snap.minOpportunityVolume > 0 && price.VolumeKnown && price.Volume24h < snap.minOpportunityVolume
+}
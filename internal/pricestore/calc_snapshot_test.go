@@ -0,0 +1,153 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// calcExchanges 每个symbol报价的场所
+var calcExchanges = []common.Exchange{common.ExchangeBinance, common.ExchangeLighter, common.ExchangeAster}
+
+// seedCalcStore 每个symbol在所有场所各一条活跃报价，场所之间有价差
+func seedCalcStore(ps *PriceStore, symbols []string) {
+	now := time.Now()
+	for _, symbol := range symbols {
+		for i, exchange := range calcExchanges {
+			price := snapshotQuote(exchange, symbol, 1, now)
+			price.BidPrice += float64(i) * 0.2
+			price.AskPrice += float64(i) * 0.2
+			ps.UpdatePrice(price)
+		}
+	}
+}
+
+// runSpreadReaders 并发循环计算价差直到 stop 关闭
+func runSpreadReaders(ps *PriceStore, readers int, stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				ps.CalculateSpreads()
+			}
+		}()
+	}
+	return &wg
+}
+
+func TestCalcSnapshotIsolatedFromLaterUpdates(t *testing.T) {
+	ps := NewPriceStore()
+	seedCalcStore(ps, []string{"BTCUSDT"})
+	snap := ps.calcSnapshot()
+
+	// 快照之后的写入不影响快照
+	now := time.Now().Add(time.Second)
+	moved := projectionQuote(common.ExchangeBinance, 200, 200.1, now, now)
+	ps.UpdatePrice(moved)
+	ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, "ETHUSDT", 1, now))
+
+	key := ps.makeSymbolKey(common.ExchangeBinance, common.MarketTypeFuture)
+	if got := snap.bySymbol["BTCUSDT"][key]; got == nil || got.BidPrice != 100 {
+		t.Fatalf("snapshot price = %+v, want the pre-update bid 100", got)
+	}
+	if _, exists := snap.bySymbol["ETHUSDT"]; exists {
+		t.Fatal("symbol added after the snapshot appeared in it")
+	}
+	if len(snap.bySymbol["BTCUSDT"]) != len(calcExchanges) {
+		t.Fatalf("snapshot has %d BTCUSDT prices, want %d", len(snap.bySymbol["BTCUSDT"]), len(calcExchanges))
+	}
+	if ps.GetPrice(common.ExchangeBinance, common.MarketTypeFuture, "BTCUSDT").BidPrice != 200 {
+		t.Fatal("store did not apply the update")
+	}
+}
+
+func TestSpreadReadersDoNotStarveUpdates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	ps := NewPriceStore()
+	symbols := snapshotSymbols(1500)
+	seedCalcStore(ps, symbols)
+
+	// 一次完整价差计算的耗时：旧实现在整个计算期间持有读锁，写入至少要等这么久
+	start := time.Now()
+	if len(ps.CalculateSpreads()) == 0 {
+		t.Fatal("no spreads calculated")
+	}
+	fullCalc := time.Since(start)
+
+	stop := make(chan struct{})
+	wg := runSpreadReaders(ps, 4, stop)
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+	time.Sleep(fullCalc) // 读者已进入计算循环
+
+	base := time.Now()
+	waits := make([]time.Duration, 0, 200)
+	for n := 2; n < 202; n++ {
+		begin := time.Now()
+		ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, symbols[n%len(symbols)], n, base))
+		waits = append(waits, time.Since(begin))
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+
+	// 锁只覆盖复制索引，写入的典型等待远小于一次完整计算（取中位数，排除GC和调度造成的个别长尾）
+	median := waits[len(waits)/2]
+	if median >= fullCalc/10 {
+		t.Fatalf("median UpdatePrice wait %v behind spread readers, full calculation takes %v", median, fullCalc)
+	}
+	t.Logf("full calculation %v, UpdatePrice wait median %v max %v", fullCalc, median, waits[len(waits)-1])
+}
+
+// BenchmarkCalcSnapshot 价差计算中持有存储锁的部分（复制索引）
+func BenchmarkCalcSnapshot(b *testing.B) {
+	ps := NewPriceStore()
+	seedCalcStore(ps, snapshotSymbols(1500))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps.calcSnapshot()
+	}
+}
+
+// BenchmarkCalculateSpreads 完整的价差计算（对比 BenchmarkCalcSnapshot 可知锁外完成的比例）
+func BenchmarkCalculateSpreads(b *testing.B) {
+	ps := NewPriceStore()
+	seedCalcStore(ps, snapshotSymbols(1500))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps.CalculateSpreads()
+	}
+}
+
+// BenchmarkUpdatePriceUnderSpreadReads 4个并发价差读者下的写入耗时
+func BenchmarkUpdatePriceUnderSpreadReads(b *testing.B) {
+	ps := NewPriceStore()
+	symbols := snapshotSymbols(1500)
+	seedCalcStore(ps, symbols)
+
+	stop := make(chan struct{})
+	wg := runSpreadReaders(ps, 4, stop)
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	base := time.Now()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, symbols[i%len(symbols)], i+2, base))
+	}
+}
@@ -0,0 +1,77 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"time"
+)
+
+// ConfidenceWeights 置信度评分参数
+type ConfidenceWeights struct {
+	FreshAge         time.Duration // 不衰减的数据年龄（低于该值视为完全新鲜）
+	AgeHalfLife      time.Duration // 超过 FreshAge 后每经过该时长得分减半
+	RESTPenalty      float64       // REST数据源的扣分比例（0-1）
+	ThinBookNotional float64       // 盘口金额低于该值（USDT）视为薄盘口
+	ThinBookPenalty  float64       // 薄盘口（或无盘口数量）的扣分比例（0-1）
+	AgeGapHalfLife   time.Duration // 两腿数据年龄差每增加该时长得分减半
+}
+
+// DefaultConfidenceWeights 默认置信度评分参数
+func DefaultConfidenceWeights() *ConfidenceWeights {
+	return &ConfidenceWeights{
+		FreshAge:         1 * time.Second,
+		AgeHalfLife:      5 * time.Second,
+		RESTPenalty:      0.3,
+		ThinBookNotional: 1000,
+		ThinBookPenalty:  0.2,
+		AgeGapHalfLife:   5 * time.Second,
+	}
+}
+
+// SetConfidenceWeights 设置置信度评分参数
+func (ps *PriceStore) SetConfidenceWeights(w *ConfidenceWeights) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.confidence = w
+}
+
+// ScoreConfidence 计算买卖两腿报价的置信度（0-1）
+// 两腿均为WebSocket数据、年龄<1s且盘口充足时为1.0；随年龄衰减，
+// REST数据、薄盘口、两腿年龄差都会降低得分
+func ScoreConfidence(buy, sell *common.Price, now time.Time, w *ConfidenceWeights) float64 {
+	buyAge := now.Sub(buy.LastUpdated)
+	sellAge := now.Sub(sell.LastUpdated)
+
+	score := legConfidence(buy, buyAge, buy.AskPrice*buy.AskQty, w) *
+		legConfidence(sell, sellAge, sell.BidPrice*sell.BidQty, w)
+
+	// 两腿年龄差越大，越可能不是同一时刻的价格
+	gap := buyAge - sellAge
+	if gap < 0 {
+		gap = -gap
+	}
+	score *= halfLifeDecay(gap, w.AgeGapHalfLife)
+
+	return math.Max(0, math.Min(1, score))
+}
+
+// legConfidence 单腿置信度
+func legConfidence(price *common.Price, age time.Duration, bookNotional float64, w *ConfidenceWeights) float64 {
+	score := halfLifeDecay(age-w.FreshAge, w.AgeHalfLife)
+
+	if price.Source == common.PriceSourceREST {
+		score *= 1 - w.RESTPenalty
+	}
+	if bookNotional < w.ThinBookNotional {
+		score *= 1 - w.ThinBookPenalty
+	}
+	return score
+}
+
+// halfLifeDecay 按半衰期衰减（d <= 0 时为1）
+func halfLifeDecay(d, halfLife time.Duration) float64 {
+	if d <= 0 || halfLife <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(d)/float64(halfLife))
+}
@@ -0,0 +1,76 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScoreConfidence(t *testing.T) {
+	now := time.Now()
+	w := DefaultConfidenceWeights()
+
+	// leg 买卖价100，数量 qty（默认盘口金额 100*100 = 10000 USDT，高于薄盘口阈值）
+	leg := func(age time.Duration, source common.PriceSource, qty float64) *common.Price {
+		return &common.Price{
+			BidPrice: 100, AskPrice: 100, BidQty: qty, AskQty: qty,
+			Source: source, LastUpdated: now.Add(-age),
+		}
+	}
+	ws, rest := common.PriceSourceWebSocket, common.PriceSourceREST
+
+	tests := []struct {
+		name string
+		buy  *common.Price
+		sell *common.Price
+		want float64
+	}{
+		{"fresh websocket with depth", leg(500*time.Millisecond, ws, 100), leg(500*time.Millisecond, ws, 100), 1},
+		{"one half-life past fresh age", leg(6*time.Second, ws, 100), leg(6*time.Second, ws, 100), 0.25},
+		{"age gap of one half-life", leg(0, ws, 100), leg(5*time.Second, ws, 100), math.Pow(0.5, 4.0/5) * 0.5},
+		{"rest leg", leg(0, rest, 100), leg(0, ws, 100), 0.7},
+		{"both rest", leg(0, rest, 100), leg(0, rest, 100), 0.49},
+		{"thin buy book", leg(0, ws, 5), leg(0, ws, 100), 0.8},
+		{"unknown book size", leg(0, ws, 0), leg(0, ws, 0), 0.64},
+		{"rest and thin on the same leg", leg(0, rest, 5), leg(0, ws, 100), 0.7 * 0.8},
+		{"future timestamps count as fresh", leg(-time.Second, ws, 100), leg(-time.Second, ws, 100), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScoreConfidence(tt.buy, tt.sell, now, w)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreConfidenceBoundsAndMonotonic(t *testing.T) {
+	now := time.Now()
+	w := DefaultConfidenceWeights()
+	price := func(age time.Duration) *common.Price {
+		return &common.Price{BidPrice: 100, AskPrice: 100, BidQty: 100, AskQty: 100,
+			Source: common.PriceSourceWebSocket, LastUpdated: now.Add(-age)}
+	}
+
+	prev := 2.0
+	for age := time.Duration(0); age <= time.Minute; age += 2 * time.Second {
+		score := ScoreConfidence(price(age), price(age), now, w)
+		if score < 0 || score > 1 {
+			t.Fatalf("age %v: score %v outside [0,1]", age, score)
+		}
+		if score > prev {
+			t.Fatalf("age %v: score %v increased from %v", age, score, prev)
+		}
+		prev = score
+	}
+
+	// 半衰期为0时不衰减
+	flat := *w
+	flat.AgeHalfLife = 0
+	flat.AgeGapHalfLife = 0
+	if got := ScoreConfidence(price(time.Hour), price(0), now, &flat); got != 1 {
+		t.Fatalf("score without decay = %v, want 1", got)
+	}
+}
@@ -0,0 +1,346 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigBundleVersion 配置包的格式版本，导入时必须一致
+const ConfigBundleVersion = 1
+
+// 配置包的各部分
+const (
+	BundleSectionThresholds        = "thresholds"
+	BundleSectionBlacklist         = "blacklist"
+	BundleSectionSymbolMappings    = "symbol_mappings"
+	BundleSectionRatioStrategies   = "ratio_strategies"
+	BundleSectionVenueCapabilities = "venue_capabilities"
+)
+
+// ConfigBundle 运行时可修改的全部配置（阈值、黑名单、symbol映射、比值策略、交易所能力），用于在多台机器之间迁移配置
+// 导入时缺少（或为 null）的部分保持不变，要清空某部分需要显式传空值（{} 或 []）
+// Environment 为启动时的环境变量配置，只用于对照，导入时不应用（需要修改 .env 后重启）
+type ConfigBundle struct {
+	Version           int                                 `json:"version"`
+	ExportedAt        time.Time                           `json:"exported_at"`
+	Thresholds        map[string]float64                  `json:"thresholds"`
+	Blacklist         []string                            `json:"blacklist"`
+	SymbolMappings    map[string]string                   `json:"symbol_mappings"`
+	RatioStrategies   []RatioStrategy                     `json:"ratio_strategies"`
+	VenueCapabilities map[common.Exchange]VenueCapability `json:"venue_capabilities"`
+	Environment       map[string]interface{}              `json:"environment,omitempty"`
+}
+
+// BundleSectionDiff 导入时单个部分的变化（按key：symbol、规则、策略名或交易所）
+type BundleSectionDiff struct {
+	Omitted  bool     `json:"omitted,omitempty"` // 配置包中缺少该部分，保持不变
+	Changed  bool     `json:"changed"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+// ExportConfigBundle 导出当前的运行时配置
+func (ps *PriceStore) ExportConfigBundle() *ConfigBundle {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.exportConfigBundleLocked()
+}
+
+// exportConfigBundleLocked 导出当前的运行时配置（调用者需要持有锁），各部分总是非nil
+func (ps *PriceStore) exportConfigBundleLocked() *ConfigBundle {
+	bundle := &ConfigBundle{
+		Version:           ConfigBundleVersion,
+		ExportedAt:        time.Now().UTC(),
+		Thresholds:        make(map[string]float64, len(ps.thresholdOverrides)),
+		Blacklist:         make([]string, 0, len(ps.blacklist)),
+		SymbolMappings:    ps.symbolNormalizer.Mappings(),
+		RatioStrategies:   make([]RatioStrategy, 0, len(ps.ratioStrategies)),
+		VenueCapabilities: make(map[common.Exchange]VenueCapability, len(ps.venueCaps)),
+	}
+	for symbol, value := range ps.thresholdOverrides {
+		bundle.Thresholds[symbol] = value
+	}
+	for _, rule := range ps.blacklist {
+		bundle.Blacklist = append(bundle.Blacklist, rule.pattern)
+	}
+	for _, rs := range ps.ratioStrategies {
+		bundle.RatioStrategies = append(bundle.RatioStrategies, *rs)
+	}
+	for exchange, capability := range ps.venueCaps {
+		bundle.VenueCapabilities[exchange] = capability
+	}
+	return bundle
+}
+
+// preparedBundle 校验并标准化后的配置包
+type preparedBundle struct {
+	thresholds map[string]float64
+	blacklist  []*blacklistRule
+	mappings   map[string]string
+	strategies []*RatioStrategy
+	venueCaps  map[common.Exchange]VenueCapability
+}
+
+// prepareConfigBundle 校验配置包：格式版本、各部分的格式，以及部分之间的引用
+// - 比值策略的两腿、阈值配置的symbol、symbol映射的目标不能被黑名单过滤
+// - 比值策略名称不能与symbol映射冲突，交易所能力只能配置已知的交易所
+func prepareConfigBundle(bundle *ConfigBundle) (*preparedBundle, error) {
+	if bundle.Version != ConfigBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d, expected %d", bundle.Version, ConfigBundleVersion)
+	}
+
+	prepared := &preparedBundle{
+		thresholds: make(map[string]float64, len(bundle.Thresholds)),
+		venueCaps:  make(map[common.Exchange]VenueCapability, len(bundle.VenueCapabilities)),
+	}
+
+	for _, pattern := range bundle.Blacklist {
+		rule, err := compileBlacklistRule(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", BundleSectionBlacklist, err)
+		}
+		prepared.blacklist = append(prepared.blacklist, rule)
+	}
+	blacklisted := func(symbol string) string {
+		return matchBlacklistRules(prepared.blacklist, symbol)
+	}
+
+	for symbol, value := range bundle.Thresholds {
+		normalized := NormalizeThresholdSymbol(symbol)
+		if normalized == "" {
+			return nil, fmt.Errorf("%s: invalid symbol %q", BundleSectionThresholds, symbol)
+		}
+		if value <= 0 {
+			return nil, fmt.Errorf("%s: threshold for %s must be positive, got %v", BundleSectionThresholds, normalized, value)
+		}
+		if rule := blacklisted(normalized); rule != "" {
+			return nil, fmt.Errorf("%s: %s is blacklisted by %q", BundleSectionThresholds, normalized, rule)
+		}
+		prepared.thresholds[normalized] = value
+	}
+
+	mappings, err := normalizeSymbolMappings(bundle.SymbolMappings)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", BundleSectionSymbolMappings, err)
+	}
+	for from, to := range mappings {
+		if rule := blacklisted(to); rule != "" {
+			return nil, fmt.Errorf("%s: %s maps to %s, which is blacklisted by %q", BundleSectionSymbolMappings, from, to, rule)
+		}
+	}
+	prepared.mappings = mappings
+
+	names := make(map[string]bool, len(bundle.RatioStrategies))
+	for _, strategy := range bundle.RatioStrategies {
+		rs := strategy
+		if err := rs.normalize(); err != nil {
+			return nil, fmt.Errorf("%s: invalid strategy %q: %w", BundleSectionRatioStrategies, strategy.Name, err)
+		}
+		if names[rs.Name] {
+			return nil, fmt.Errorf("%s: duplicate strategy %q", BundleSectionRatioStrategies, rs.Name)
+		}
+		names[rs.Name] = true
+		for _, leg := range []string{rs.BaseSymbol, rs.QuoteSymbol} {
+			if rule := blacklisted(leg); rule != "" {
+				return nil, fmt.Errorf("%s: %s leg %s is blacklisted by %q", BundleSectionRatioStrategies, rs.Name, leg, rule)
+			}
+		}
+		if _, mapped := mappings[NormalizeThresholdSymbol(rs.Name)]; mapped {
+			return nil, fmt.Errorf("%s: strategy name %s is also a symbol mapping source", BundleSectionRatioStrategies, rs.Name)
+		}
+		prepared.strategies = append(prepared.strategies, &rs)
+	}
+
+	for exchange, capability := range bundle.VenueCapabilities {
+		normalized := common.Exchange(strings.ToUpper(string(exchange)))
+		if !common.IsValidExchange(normalized) {
+			return nil, fmt.Errorf("%s: unknown exchange %q", BundleSectionVenueCapabilities, exchange)
+		}
+		if capability.TransferMinutes < 0 {
+			return nil, fmt.Errorf("%s: %s transfer_minutes must not be negative", BundleSectionVenueCapabilities, normalized)
+		}
+		prepared.venueCaps[normalized] = capability
+	}
+	return prepared, nil
+}
+
+// ApplyConfigBundle 校验并应用配置包，返回各部分的变化
+// 配置包中缺少的部分使用当前配置（保持不变，同样参与引用校验）；
+// 在读锁内校验并计算变化，释放锁后写各配置文件（不阻塞价格更新和读取），全部写入成功后才在同一次写锁内替换内存中的配置；
+// 任一文件写入失败时恢复已写入的文件，内存中的配置保持不变；dryRun 为 true 时只计算变化不应用
+func (ps *PriceStore) ApplyConfigBundle(bundle *ConfigBundle, dryRun bool) (map[string]*BundleSectionDiff, error) {
+	// configMu 保证校验到替换期间没有其他配置修改
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+
+	ps.mu.RLock()
+	merged, omitted := ps.mergeConfigBundleLocked(bundle)
+	prepared, err := prepareConfigBundle(merged)
+	var diff map[string]*BundleSectionDiff
+	if err == nil {
+		diff = ps.diffConfigBundleLocked(prepared)
+	}
+	ps.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	for section := range omitted {
+		diff[section].Omitted = true
+	}
+	if dryRun {
+		return diff, nil
+	}
+
+	// 持有 configMu 时当前配置不会被替换，可以不加 mu 读取用于恢复文件
+	saves := []struct {
+		section string
+		save    func() error
+		restore func() error
+	}{
+		{BundleSectionThresholds,
+			func() error { return ps.saveThresholdOverrides(prepared.thresholds) },
+			func() error { return ps.saveThresholdOverrides(ps.thresholdOverrides) }},
+		{BundleSectionBlacklist,
+			func() error { return ps.saveBlacklist(prepared.blacklist) },
+			func() error { return ps.saveBlacklist(ps.blacklist) }},
+		{BundleSectionSymbolMappings,
+			func() error { return ps.saveSymbolMappings(prepared.mappings) },
+			func() error { return ps.saveSymbolMappings(ps.symbolNormalizer.Mappings()) }},
+		{BundleSectionRatioStrategies,
+			func() error { return ps.saveRatioStrategies(prepared.strategies) },
+			func() error { return ps.saveRatioStrategies(ps.ratioStrategies) }},
+		{BundleSectionVenueCapabilities,
+			func() error { return ps.saveVenueCapabilities(prepared.venueCaps) },
+			func() error { return ps.saveVenueCapabilities(ps.venueCaps) }},
+	}
+	for i, s := range saves {
+		if !diff[s.section].Changed {
+			continue
+		}
+		if err := s.save(); err != nil {
+			for _, written := range saves[:i] {
+				if !diff[written.section].Changed {
+					continue
+				}
+				if restoreErr := written.restore(); restoreErr != nil {
+					log.Printf("[Config] Failed to restore %s after bundle import failed: %v", written.section, restoreErr)
+				}
+			}
+			return diff, fmt.Errorf("%s not persisted, configuration unchanged: %w", s.section, err)
+		}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.thresholdOverrides = prepared.thresholds
+	ps.blacklist = prepared.blacklist
+	for _, pattern := range diff[BundleSectionBlacklist].Removed {
+		delete(ps.blacklistHits, pattern)
+	}
+	ps.ratioStrategies = prepared.strategies
+	ps.venueCaps = prepared.venueCaps
+	if diff[BundleSectionSymbolMappings].Changed {
+		ps.symbolNormalizer.SetMappings(prepared.mappings)
+		ps.rebuildSymbolIndex()
+	}
+	return diff, nil
+}
+
+// mergeConfigBundleLocked 用当前配置补齐配置包中缺少的部分，返回补齐后的副本和缺少的部分（调用者需要持有读锁）
+func (ps *PriceStore) mergeConfigBundleLocked(bundle *ConfigBundle) (*ConfigBundle, map[string]bool) {
+	merged := *bundle
+	current := ps.exportConfigBundleLocked()
+	omitted := make(map[string]bool)
+
+	if merged.Thresholds == nil {
+		merged.Thresholds = current.Thresholds
+		omitted[BundleSectionThresholds] = true
+	}
+	if merged.Blacklist == nil {
+		merged.Blacklist = current.Blacklist
+		omitted[BundleSectionBlacklist] = true
+	}
+	if merged.SymbolMappings == nil {
+		merged.SymbolMappings = current.SymbolMappings
+		omitted[BundleSectionSymbolMappings] = true
+	}
+	if merged.RatioStrategies == nil {
+		merged.RatioStrategies = current.RatioStrategies
+		omitted[BundleSectionRatioStrategies] = true
+	}
+	if merged.VenueCapabilities == nil {
+		merged.VenueCapabilities = current.VenueCapabilities
+		omitted[BundleSectionVenueCapabilities] = true
+	}
+	return &merged, omitted
+}
+
+// diffConfigBundleLocked 比较配置包与当前配置（调用者需要持有锁）
+func (ps *PriceStore) diffConfigBundleLocked(prepared *preparedBundle) map[string]*BundleSectionDiff {
+	currentBlacklist := make(map[string]bool, len(ps.blacklist))
+	for _, rule := range ps.blacklist {
+		currentBlacklist[rule.pattern] = true
+	}
+	newBlacklist := make(map[string]bool, len(prepared.blacklist))
+	for _, rule := range prepared.blacklist {
+		newBlacklist[rule.pattern] = true
+	}
+
+	currentStrategies := make(map[string]RatioStrategy, len(ps.ratioStrategies))
+	for _, rs := range ps.ratioStrategies {
+		currentStrategies[rs.Name] = *rs
+	}
+	newStrategies := make(map[string]RatioStrategy, len(prepared.strategies))
+	for _, rs := range prepared.strategies {
+		newStrategies[rs.Name] = *rs
+	}
+
+	currentVenues := make(map[string]VenueCapability, len(ps.venueCaps))
+	for exchange, capability := range ps.venueCaps {
+		currentVenues[string(exchange)] = capability
+	}
+	newVenues := make(map[string]VenueCapability, len(prepared.venueCaps))
+	for exchange, capability := range prepared.venueCaps {
+		newVenues[string(exchange)] = capability
+	}
+
+	return map[string]*BundleSectionDiff{
+		BundleSectionThresholds:        diffSection(ps.thresholdOverrides, prepared.thresholds),
+		BundleSectionBlacklist:         diffSection(currentBlacklist, newBlacklist),
+		BundleSectionSymbolMappings:    diffSection(ps.symbolNormalizer.Mappings(), prepared.mappings),
+		BundleSectionRatioStrategies:   diffSection(currentStrategies, newStrategies),
+		BundleSectionVenueCapabilities: diffSection(currentVenues, newVenues),
+	}
+}
+
+// diffSection 按key比较两份配置
+func diffSection[V any](current, next map[string]V) *BundleSectionDiff {
+	diff := &BundleSectionDiff{}
+	for key, value := range next {
+		old, exists := current[key]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, key)
+		case !reflect.DeepEqual(old, value):
+			diff.Modified = append(diff.Modified, key)
+		}
+	}
+	for key := range current {
+		if _, exists := next[key]; !exists {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	diff.Changed = len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Modified) > 0
+	return diff
+}
@@ -0,0 +1,239 @@
+package pricestore
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newBundleTestStore 创建配置写入临时目录的存储
+func newBundleTestStore(t *testing.T) (*PriceStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ps := NewPriceStore()
+	if err := ps.LoadThresholdOverrides(filepath.Join(dir, "thresholds.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.LoadBlacklist(filepath.Join(dir, "blacklist.json"), []string{"USDCUSDT", "*DOWNUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.LoadSymbolMappings(filepath.Join(dir, "mappings.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.LoadRatioStrategies(filepath.Join(dir, "strategies.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.LoadVenueCapabilities(filepath.Join(dir, "venues.json")); err != nil {
+		t.Fatal(err)
+	}
+	return ps, dir
+}
+
+// roundTrip 模拟导出后经过JSON传输
+func roundTrip(t *testing.T, bundle *ConfigBundle) *ConfigBundle {
+	t.Helper()
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ConfigBundle
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return &decoded
+}
+
+// assertOnlyChanged 只有 sections 中的部分发生变化
+func assertOnlyChanged(t *testing.T, diff map[string]*BundleSectionDiff, sections ...string) {
+	t.Helper()
+	want := make(map[string]bool)
+	for _, section := range sections {
+		want[section] = true
+	}
+	for section, d := range diff {
+		if d.Changed != want[section] {
+			t.Errorf("%s: changed = %v, want %v (%+v)", section, d.Changed, want[section], d)
+		}
+	}
+}
+
+func TestConfigBundleRoundTripUnchanged(t *testing.T) {
+	ps, _ := newBundleTestStore(t)
+
+	diff, err := ps.ApplyConfigBundle(roundTrip(t, ps.ExportConfigBundle()), false)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	assertOnlyChanged(t, diff)
+}
+
+func TestConfigBundleChangeOneSection(t *testing.T) {
+	ps, dir := newBundleTestStore(t)
+
+	bundle := roundTrip(t, ps.ExportConfigBundle())
+	bundle.Thresholds["btc"] = 0.25
+
+	// dry run 只报告变化
+	diff, err := ps.ApplyConfigBundle(bundle, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	assertOnlyChanged(t, diff, BundleSectionThresholds)
+	if len(ps.GetThresholdOverrides()) != 0 {
+		t.Fatal("dry run modified thresholds")
+	}
+
+	diff, err = ps.ApplyConfigBundle(bundle, false)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	assertOnlyChanged(t, diff, BundleSectionThresholds)
+	if got := diff[BundleSectionThresholds].Added; len(got) != 1 || got[0] != "BTCUSDT" {
+		t.Errorf("thresholds added = %v, want [BTCUSDT]", got)
+	}
+	if got := ps.GetThresholdOverrides()["BTCUSDT"]; got != 0.25 {
+		t.Errorf("BTCUSDT threshold = %v, want 0.25", got)
+	}
+
+	// 只写回发生变化的部分
+	if _, err := os.Stat(filepath.Join(dir, "thresholds.json")); err != nil {
+		t.Errorf("thresholds not persisted: %v", err)
+	}
+	for _, name := range []string{"blacklist.json", "mappings.json", "strategies.json", "venues.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s written although unchanged (err=%v)", name, err)
+		}
+	}
+
+	// 导出的结果与导入的一致
+	exported := ps.ExportConfigBundle()
+	diff, err = ps.ApplyConfigBundle(roundTrip(t, exported), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOnlyChanged(t, diff)
+}
+
+func TestConfigBundleWriteFailureLeavesConfigUnchanged(t *testing.T) {
+	ps, dir := newBundleTestStore(t)
+	if err := ps.SetThresholdOverride("ETH", 0.2); err != nil {
+		t.Fatal(err)
+	}
+	thresholdsFile := filepath.Join(dir, "thresholds.json")
+	before, err := os.ReadFile(thresholdsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategiesBefore := len(ps.GetRatioStrategies())
+
+	// 比值策略文件所在目录不存在，阈值文件先写入成功
+	ps.ratioStrategiesFile = filepath.Join(dir, "missing", "strategies.json")
+	bundle := roundTrip(t, ps.ExportConfigBundle())
+	bundle.Thresholds["BTC"] = 0.25
+	bundle.RatioStrategies = append(bundle.RatioStrategies, RatioStrategy{
+		Name: "AAVE-UNI", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 12.3,
+	})
+
+	if _, err := ps.ApplyConfigBundle(bundle, false); err == nil || !strings.Contains(err.Error(), BundleSectionRatioStrategies) {
+		t.Fatalf("apply error = %v, want a %s write failure", err, BundleSectionRatioStrategies)
+	}
+
+	// 内存中的配置不变，已写入的阈值文件恢复原内容
+	if got := ps.GetThresholdOverrides(); len(got) != 1 || got["ETHUSDT"] != 0.2 {
+		t.Errorf("thresholds = %v, want only ETHUSDT 0.2", got)
+	}
+	if got := len(ps.GetRatioStrategies()); got != strategiesBefore {
+		t.Errorf("%d strategies after failed import, want %d", got, strategiesBefore)
+	}
+	after, err := os.ReadFile(thresholdsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var onDisk map[string]float64
+	if err := json.Unmarshal(after, &onDisk); err != nil {
+		t.Fatal(err)
+	}
+	if len(onDisk) != 1 || onDisk["ETHUSDT"] != 0.2 {
+		t.Errorf("thresholds file = %s, want restored %s", after, before)
+	}
+}
+
+func TestConfigBundleMissingSectionsUnchanged(t *testing.T) {
+	ps, _ := newBundleTestStore(t)
+	before := ps.ExportConfigBundle()
+
+	var partial ConfigBundle
+	if err := json.Unmarshal([]byte(`{"version":1,"thresholds":{"ETHUSDT":0.3}}`), &partial); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := ps.ApplyConfigBundle(&partial, false)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	assertOnlyChanged(t, diff, BundleSectionThresholds)
+	for _, section := range []string{BundleSectionBlacklist, BundleSectionSymbolMappings, BundleSectionRatioStrategies, BundleSectionVenueCapabilities} {
+		if !diff[section].Omitted {
+			t.Errorf("%s not reported as omitted", section)
+		}
+	}
+
+	after := ps.ExportConfigBundle()
+	if len(after.Blacklist) != len(before.Blacklist) || len(after.RatioStrategies) != len(before.RatioStrategies) ||
+		len(after.VenueCapabilities) != len(before.VenueCapabilities) {
+		t.Fatalf("omitted sections changed: before %+v, after %+v", before, after)
+	}
+
+	// 显式传空值才清空
+	var clear ConfigBundle
+	if err := json.Unmarshal([]byte(`{"version":1,"blacklist":[]}`), &clear); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = ps.ApplyConfigBundle(&clear, false)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	assertOnlyChanged(t, diff, BundleSectionBlacklist)
+	if got := len(ps.ExportConfigBundle().Blacklist); got != 0 {
+		t.Errorf("blacklist has %d rules after explicit clear", got)
+	}
+}
+
+func TestConfigBundleRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(b *ConfigBundle)
+		wantErr string
+	}{
+		{"version", func(b *ConfigBundle) { b.Version = 2 }, "unsupported bundle version"},
+		{"blacklisted threshold", func(b *ConfigBundle) { b.Thresholds["USDCUSDT"] = 0.2 }, "blacklisted"},
+		{"blacklisted ratio leg", func(b *ConfigBundle) { b.Blacklist = append(b.Blacklist, "STGUSDT") }, "leg STGUSDT is blacklisted"},
+		{"duplicate strategy", func(b *ConfigBundle) { b.RatioStrategies = append(b.RatioStrategies, b.RatioStrategies[0]) }, "duplicate strategy"},
+		{"unknown exchange", func(b *ConfigBundle) { b.VenueCapabilities["NOPE"] = VenueCapability{} }, "unknown exchange"},
+		{"negative transfer", func(b *ConfigBundle) {
+			b.VenueCapabilities["BINANCE"] = VenueCapability{TransferMinutes: -1}
+		}, "transfer_minutes"},
+		{"invalid blacklist pattern", func(b *ConfigBundle) { b.Blacklist = append(b.Blacklist, "re:(") }, BundleSectionBlacklist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, _ := newBundleTestStore(t)
+			before := ps.ExportConfigBundle()
+
+			bundle := roundTrip(t, before)
+			tt.mutate(bundle)
+			if _, err := ps.ApplyConfigBundle(bundle, false); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+
+			// 校验失败时不应用任何部分
+			diff, err := ps.ApplyConfigBundle(roundTrip(t, before), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertOnlyChanged(t, diff)
+		})
+	}
+}
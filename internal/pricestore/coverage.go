@@ -0,0 +1,101 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"sort"
+	"time"
+)
+
+// coverageActiveWindow 报价在该时长内更新过才算场所覆盖了该symbol（与价差计算的活跃窗口一致）
+const coverageActiveWindow = 60 * time.Second
+
+// CoverageGap 在部分交易所有活跃报价、在其他交易所缺失或过期的symbol（潜在的上币套利关注对象）
+type CoverageGap struct {
+	Symbol    string            `json:"symbol"`     // 标准symbol
+	Volume24h float64           `json:"volume_24h"` // 活跃报价中最大的24小时成交量
+	PresentOn []common.Exchange `json:"present_on"` // 有活跃报价的交易所
+	MissingOn []common.Exchange `json:"missing_on"` // 完全没有该symbol报价的交易所
+	StaleOn   []common.Exchange `json:"stale_on"`   // 只有过期报价的交易所
+	UpdatedAt time.Time         `json:"updated_at"` // 活跃报价中最近的更新时间
+}
+
+// GetCoverageGaps 返回覆盖缺口：至少一个交易所有活跃报价且24小时成交量不低于 minVolume，
+// 但在其他交易所缺失或只有过期报价的symbol，按成交量降序排列
+// 参与比较的交易所为当前有任意活跃报价的交易所（整体断线的交易所不会让所有symbol都变成缺口）
+func (ps *PriceStore) GetCoverageGaps(minVolume float64) []*CoverageGap {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	now := time.Now()
+
+	// 当前在线的交易所
+	online := make([]common.Exchange, 0, len(ps.byExchange))
+	for exchange, exchangeMap := range ps.byExchange {
+		for _, price := range exchangeMap {
+			if now.Sub(price.LastUpdated) <= coverageActiveWindow {
+				online = append(online, exchange)
+				break
+			}
+		}
+	}
+	sort.Slice(online, func(i, j int) bool { return online[i] < online[j] })
+
+	gaps := make([]*CoverageGap, 0)
+	if len(online) < 2 {
+		return gaps
+	}
+
+	for symbol, priceMap := range ps.bySymbol {
+		if ps.matchBlacklist(symbol) != "" {
+			continue
+		}
+
+		gap := &CoverageGap{
+			Symbol:    symbol,
+			PresentOn: make([]common.Exchange, 0),
+			MissingOn: make([]common.Exchange, 0),
+			StaleOn:   make([]common.Exchange, 0),
+		}
+		active := make(map[common.Exchange]bool)
+		seen := make(map[common.Exchange]bool)
+		for _, price := range priceMap {
+			seen[price.Exchange] = true
+			if now.Sub(price.LastUpdated) > coverageActiveWindow {
+				continue
+			}
+			active[price.Exchange] = true
+			if price.Volume24h > gap.Volume24h {
+				gap.Volume24h = price.Volume24h
+			}
+			if price.LastUpdated.After(gap.UpdatedAt) {
+				gap.UpdatedAt = price.LastUpdated
+			}
+		}
+		if len(active) == 0 || gap.Volume24h < minVolume {
+			continue
+		}
+
+		for _, exchange := range online {
+			switch {
+			case active[exchange]:
+				gap.PresentOn = append(gap.PresentOn, exchange)
+			case seen[exchange]:
+				gap.StaleOn = append(gap.StaleOn, exchange)
+			default:
+				gap.MissingOn = append(gap.MissingOn, exchange)
+			}
+		}
+		if len(gap.MissingOn) == 0 && len(gap.StaleOn) == 0 {
+			continue
+		}
+		gaps = append(gaps, gap)
+	}
+
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].Volume24h != gaps[j].Volume24h {
+			return gaps[i].Volume24h > gaps[j].Volume24h
+		}
+		return gaps[i].Symbol < gaps[j].Symbol
+	})
+	return gaps
+}
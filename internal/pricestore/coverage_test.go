@@ -0,0 +1,76 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCoverageGaps(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	seed := func(exchange common.Exchange, symbol string, volume float64) {
+		price := venueQuote(exchange, symbol, 100, 100, now)
+		price.Volume24h = volume
+		ps.UpdatePrice(price)
+	}
+	seed(common.ExchangeBinance, "BTCUSDT", 5e9)
+	seed(common.ExchangeLighter, "BTCUSDT", 1e9)
+	seed(common.ExchangeAster, "BTCUSDT", 1e8)
+	seed(common.ExchangeBinance, "ETHUSDT", 2e9)
+	seed(common.ExchangeLighter, "ETHUSDT", 1e9)
+	seed(common.ExchangeBinance, "SOLUSDT", 1e9)
+	seed(common.ExchangeAster, "SOLUSDT", 3e9)
+	seed(common.ExchangeBinance, "DUSTUSDT", 1e3)
+	seed(common.ExchangeBybit, "BTCUSDT", 1e9)
+
+	// Lighter 的 ETH 报价过期；Bybit 所有报价都过期，整体视为离线不参与比较
+	ps.mu.Lock()
+	for exchange, exchangeMap := range ps.byExchange {
+		for _, price := range exchangeMap {
+			if exchange == common.ExchangeBybit || (exchange == common.ExchangeLighter && price.Symbol == "ETHUSDT") {
+				price.LastUpdated = now.Add(-2 * coverageActiveWindow)
+			}
+		}
+	}
+	ps.mu.Unlock()
+
+	gaps := ps.GetCoverageGaps(1e6)
+	type gapView struct {
+		Symbol  string
+		Volume  float64
+		Present []common.Exchange
+		Missing []common.Exchange
+		Stale   []common.Exchange
+	}
+	got := make([]gapView, 0, len(gaps))
+	for _, gap := range gaps {
+		got = append(got, gapView{gap.Symbol, gap.Volume24h, gap.PresentOn, gap.MissingOn, gap.StaleOn})
+		if !gap.UpdatedAt.Equal(now) {
+			t.Fatalf("%s updated at %v, want the latest active quote %v", gap.Symbol, gap.UpdatedAt, now)
+		}
+	}
+	binance, lighter, aster := common.ExchangeBinance, common.ExchangeLighter, common.ExchangeAster
+	none := []common.Exchange{}
+	// BTC 在三个在线交易所都有活跃报价，DUST 成交量不足，都不是缺口；按成交量降序
+	want := []gapView{
+		{"SOLUSDT", 3e9, []common.Exchange{aster, binance}, []common.Exchange{lighter}, none},
+		{"ETHUSDT", 2e9, []common.Exchange{binance}, []common.Exchange{aster}, []common.Exchange{lighter}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("gaps =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if gaps := ps.GetCoverageGaps(0); len(gaps) != 3 || gaps[2].Symbol != "DUSTUSDT" {
+		t.Fatalf("without a volume floor got %d gaps, want DUSTUSDT last of 3", len(gaps))
+	}
+}
+
+func TestCoverageGapsNeedTwoOnlineExchanges(t *testing.T) {
+	ps := NewPriceStore()
+	ps.UpdatePrice(venueQuote(common.ExchangeBinance, "BTCUSDT", 100, 100, time.Now()))
+	if gaps := ps.GetCoverageGaps(0); len(gaps) != 0 {
+		t.Fatalf("gaps = %+v with a single online exchange, want none", gaps)
+	}
+}
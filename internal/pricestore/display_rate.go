@@ -0,0 +1,101 @@
+package pricestore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// displayRateMaxAge 显示货币参考汇率使用的报价最大年龄，超过时视为过期
+const displayRateMaxAge = 60 * time.Second
+
+// 显示货币（?quote=），存储中的价格和名义金额都以USDT计价
+const (
+	DisplayCurrencyUSDT = "USDT"
+	DisplayCurrencyEUR  = "EUR"
+	DisplayCurrencyBTC  = "BTC"
+)
+
+// DisplayRate 显示货币的参考汇率：1单位显示货币 = USDTPerUnit USDT
+type DisplayRate struct {
+	Currency    string    `json:"currency"`
+	USDTPerUnit float64   `json:"usdt_per_unit"`
+	Source      string    `json:"source"` // 例如 EURUSDT_MID、BTCUSDT_INDEX
+	Venues      int       `json:"venues"` // 参与计算的场所数（取中位数）
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ParseDisplayCurrency 解析显示货币（不区分大小写），不支持时返回错误
+func ParseDisplayCurrency(s string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(s))
+	switch currency {
+	case DisplayCurrencyUSDT, DisplayCurrencyEUR, DisplayCurrencyBTC:
+		return currency, nil
+	}
+	return "", fmt.Errorf("invalid quote %q: expected USDT, EUR or BTC", s)
+}
+
+// GetDisplayRate 显示货币当前的参考汇率，由已跟踪场所的报价推出（不单独保存）：
+// - EUR: 各场所 EURUSDT 中间价的中位数
+// - BTC: 各合约场所 BTCUSDT 指数价格的中位数，没有指数价格时使用中间价
+// 没有报价或报价都已过期时返回错误
+func (ps *PriceStore) GetDisplayRate(currency string, now time.Time) (*DisplayRate, error) {
+	if currency == DisplayCurrencyUSDT {
+		return &DisplayRate{Currency: currency, USDTPerUnit: 1, Source: "IDENTITY", UpdatedAt: now.UTC()}, nil
+	}
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	pair := currency + "USDT"
+	prices := ps.bySymbol[ps.symbolNormalizer.Normalize(pair)]
+
+	// 指数价格优先（BTC），其次中间价
+	for _, useIndex := range []bool{currency == DisplayCurrencyBTC, false} {
+		values := make([]float64, 0, len(prices))
+		var newest time.Time
+		stale := false
+		for _, price := range prices {
+			value := price.IndexPrice
+			if !useIndex {
+				value = 0
+				if price.BidPrice > 0 && price.AskPrice > 0 {
+					value = (price.BidPrice + price.AskPrice) / 2
+				}
+			}
+			if value <= 0 {
+				continue
+			}
+			if now.Sub(price.LastUpdated) > displayRateMaxAge {
+				stale = true
+				continue
+			}
+			values = append(values, value)
+			if price.LastUpdated.After(newest) {
+				newest = price.LastUpdated
+			}
+		}
+		if len(values) == 0 {
+			if useIndex {
+				continue
+			}
+			if stale {
+				return nil, fmt.Errorf("%s reference rate is stale (no %s quote within %v)", currency, pair, displayRateMaxAge)
+			}
+			return nil, fmt.Errorf("%s reference rate unavailable (no %s quote)", currency, pair)
+		}
+
+		source := pair + "_MID"
+		if useIndex {
+			source = pair + "_INDEX"
+		}
+		return &DisplayRate{
+			Currency:    currency,
+			USDTPerUnit: medianOf(values),
+			Source:      source,
+			Venues:      len(values),
+			UpdatedAt:   newest,
+		}, nil
+	}
+	return nil, fmt.Errorf("%s reference rate unavailable (no %s quote)", currency, pair)
+}
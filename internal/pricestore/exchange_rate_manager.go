@@ -61,12 +61,20 @@ func (erm *ExchangeRateManager) initDefaultRates() {
 			ToCurrency:    common.QuoteCurrencyUSDT,
 			Rate:          1.0,
 			Source:        "DEFAULT",
-			LastUpdated:   time.Now(),
+			LastUpdated:   time.Now().UTC(),
 			IsDefaultRate: true,
 		}
 	}
 }
 
+// isExchangeRatePair 是否为币安现货的汇率交易对 (USDCUSDT, USDEUSDT, FDUSDUSDT)
+func isExchangeRatePair(price *common.Price) bool {
+	if price.Exchange != common.ExchangeBinance || price.MarketType != common.MarketTypeSpot {
+		return false
+	}
+	return price.Symbol == "USDCUSDT" || price.Symbol == "USDEUSDT" || price.Symbol == "FDUSDUSDT"
+}
+
 // UpdateFromBinance 从Binance价格更新汇率
 // 在PriceStore更新价格后调用
 func (erm *ExchangeRateManager) UpdateFromBinance() {
@@ -130,7 +138,7 @@ func (erm *ExchangeRateManager) GetRate(from common.QuoteCurrency) *ExchangeRate
 			ToCurrency:    common.QuoteCurrencyUSDT,
 			Rate:          1.0,
 			Source:        "IDENTITY",
-			LastUpdated:   time.Now(),
+			LastUpdated:   time.Now().UTC(),
 			IsDefaultRate: false,
 		}
 	}
@@ -147,7 +155,7 @@ func (erm *ExchangeRateManager) GetRate(from common.QuoteCurrency) *ExchangeRate
 		ToCurrency:    common.QuoteCurrencyUSDT,
 		Rate:          1.0,
 		Source:        "FALLBACK",
-		LastUpdated:   time.Now(),
+		LastUpdated:   time.Now().UTC(),
 		IsDefaultRate: true,
 	}
 }
@@ -0,0 +1,187 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// 套利机会的执行方式
+const (
+	ExecutionHedgePerps       = "hedge-both-perps"  // 两腿都是永续合约，两边同时开仓对冲，无需转账
+	ExecutionHedgeSpotPerp    = "hedge-spot-perp"   // 一腿现货一腿永续（不同交易所），两边同时成交对冲，无需转账
+	ExecutionTransferRequired = "transfer-required" // 需要在交易所之间转移资产
+	ExecutionSameVenue        = "same-venue"        // 同一交易所内（例如现货-合约），内部划转即可
+	ExecutionNotExecutable    = "not-executable"    // 交易所能力不支持（无法提币/充币或不支持合约）
+)
+
+// IsValidExecutionMode 检查执行方式参数是否合法
+func IsValidExecutionMode(mode string) bool {
+	switch mode {
+	case ExecutionHedgePerps, ExecutionHedgeSpotPerp, ExecutionTransferRequired, ExecutionSameVenue, ExecutionNotExecutable:
+		return true
+	}
+	return false
+}
+
+// VenueCapability 交易所的执行能力
+type VenueCapability struct {
+	SpotWithdrawal  bool `json:"spot_withdrawal"`  // 支持现货提币
+	SpotDeposit     bool `json:"spot_deposit"`     // 支持现货充币
+	Perpetuals      bool `json:"perpetuals"`       // 支持永续合约（可用于对冲）
+	TransferMinutes int  `json:"transfer_minutes"` // 典型的充提到账时间（分钟）
+}
+
+// DefaultVenueCapabilities 默认的交易所能力配置
+func DefaultVenueCapabilities() map[common.Exchange]VenueCapability {
+	return map[common.Exchange]VenueCapability{
+		common.ExchangeBinance: {SpotWithdrawal: true, SpotDeposit: true, Perpetuals: true, TransferMinutes: 10},
+		common.ExchangeAster:   {SpotWithdrawal: true, SpotDeposit: true, Perpetuals: true, TransferMinutes: 60},
+		common.ExchangeLighter: {SpotWithdrawal: true, SpotDeposit: true, Perpetuals: true, TransferMinutes: 30},
+	}
+}
+
+// SetVenueCapabilities 设置交易所能力配置（下一次计算套利机会时生效）
+func (ps *PriceStore) SetVenueCapabilities(caps map[common.Exchange]VenueCapability) {
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.venueCaps = caps
+}
+
+// LoadVenueCapabilities 从文件加载交易所能力配置，文件不存在时使用默认配置
+// 文件格式: {"BINANCE": {"spot_withdrawal": true, ...}, ...}，未列出的交易所使用默认值
+func (ps *PriceStore) LoadVenueCapabilities(path string) error {
+	caps := DefaultVenueCapabilities()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read venue capabilities file: %w", err)
+	}
+	if err == nil && len(data) > 0 {
+		var fileCaps map[string]VenueCapability
+		if err := json.Unmarshal(data, &fileCaps); err != nil {
+			return fmt.Errorf("failed to parse venue capabilities file: %w", err)
+		}
+		for exchange, capability := range fileCaps {
+			caps[common.Exchange(strings.ToUpper(exchange))] = capability
+		}
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.venueCapsFile = path
+	ps.venueCaps = caps
+	return nil
+}
+
+// saveVenueCapabilities 将 caps 写入交易所能力配置文件（调用者需要持有 configMu），文件修改后 RunVenueCapabilityReloader 会重新加载相同内容
+// 未设置文件路径时只保存在内存中
+func (ps *PriceStore) saveVenueCapabilities(caps map[common.Exchange]VenueCapability) error {
+	if ps.venueCapsFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(caps, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode venue capabilities: %w", err)
+	}
+
+	if err := common.WriteFileAtomic(ps.venueCapsFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write venue capabilities file: %w", err)
+	}
+	return nil
+}
+
+// RunVenueCapabilityReloader 定期检查能力配置文件，修改后自动重新加载，直到 stopChan 关闭
+func (ps *PriceStore) RunVenueCapabilityReloader(path string, interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastModTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastModTime = info.ModTime()
+	}
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(lastModTime) {
+				continue
+			}
+			lastModTime = info.ModTime()
+
+			if err := ps.LoadVenueCapabilities(path); err != nil {
+				log.Printf("[Venues] Failed to reload %s: %v", path, err)
+				continue
+			}
+			log.Printf("[Venues] Reloaded venue capabilities from %s", path)
+		}
+	}
+}
+
+// deriveExecutionMode 根据两腿的交易所和市场类型推导执行方式及预计转账时间
+// 规则（按腿判断，合约仓位不能提币，只有两腿都是现货时才需要转账）：
+// 1. 同一交易所: same-venue
+// 2. 两腿都是合约: 两边都支持永续时 hedge-both-perps
+// 3. 一腿现货一腿合约: 合约腿所在交易所支持永续时 hedge-spot-perp（卖出现货腿使用卖出交易所已有的现货库存）
+// 4. 两腿都是现货: 买入交易所可提币且卖出交易所可充币时 transfer-required
+// 其余情况 not-executable
+func (snap *priceSnapshot) deriveExecutionMode(buyExchange common.Exchange, buyMarket common.MarketType,
+	sellExchange common.Exchange, sellMarket common.MarketType) (string, int) {
+	if buyExchange == sellExchange {
+		return ExecutionSameVenue, 0
+	}
+
+	buyCap, buyKnown := snap.venueCaps[buyExchange]
+	sellCap, sellKnown := snap.venueCaps[sellExchange]
+	if !buyKnown || !sellKnown {
+		return ExecutionNotExecutable, 0
+	}
+
+	buyPerp := buyMarket == common.MarketTypeFuture
+	sellPerp := sellMarket == common.MarketTypeFuture
+	// 合约腿所在交易所必须支持永续
+	if (buyPerp && !buyCap.Perpetuals) || (sellPerp && !sellCap.Perpetuals) {
+		return ExecutionNotExecutable, 0
+	}
+
+	switch {
+	case buyPerp && sellPerp:
+		return ExecutionHedgePerps, 0
+	case buyPerp || sellPerp:
+		return ExecutionHedgeSpotPerp, 0
+	case buyCap.SpotWithdrawal && sellCap.SpotDeposit:
+		minutes := buyCap.TransferMinutes
+		if sellCap.TransferMinutes > minutes {
+			minutes = sellCap.TransferMinutes
+		}
+		return ExecutionTransferRequired, minutes
+	}
+	return ExecutionNotExecutable, 0
+}
+
+// FilterOpportunitiesByExecution 按执行方式过滤套利机会（mode 为空时不过滤）
+func FilterOpportunitiesByExecution(opportunities []*ArbitrageOpportunity, mode string) []*ArbitrageOpportunity {
+	if mode == "" {
+		return opportunities
+	}
+
+	filtered := make([]*ArbitrageOpportunity, 0, len(opportunities))
+	for _, opp := range opportunities {
+		if opp.ExecutionMode == mode {
+			filtered = append(filtered, opp)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,54 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+)
+
+func TestDeriveExecutionMode(t *testing.T) {
+	const (
+		spot = common.MarketTypeSpot
+		perp = common.MarketTypeFuture
+	)
+	// NOWITHDRAW 现货不能提币，PERPLESS 只有现货（可充提）
+	snap := &priceSnapshot{venueCaps: map[common.Exchange]VenueCapability{
+		common.ExchangeBinance: {SpotWithdrawal: true, SpotDeposit: true, Perpetuals: true, TransferMinutes: 10},
+		common.ExchangeLighter: {SpotWithdrawal: true, SpotDeposit: true, Perpetuals: true, TransferMinutes: 30},
+		"NOWITHDRAW":           {SpotWithdrawal: false, SpotDeposit: true, Perpetuals: true, TransferMinutes: 5},
+		"PERPLESS":             {SpotWithdrawal: true, SpotDeposit: true, Perpetuals: false, TransferMinutes: 20},
+	}}
+
+	tests := []struct {
+		name         string
+		buyExchange  common.Exchange
+		buyMarket    common.MarketType
+		sellExchange common.Exchange
+		sellMarket   common.MarketType
+		wantMode     string
+		wantMinutes  int
+	}{
+		{"same venue spot-perp", common.ExchangeBinance, spot, common.ExchangeBinance, perp, ExecutionSameVenue, 0},
+		{"perp-perp", common.ExchangeBinance, perp, common.ExchangeLighter, perp, ExecutionHedgePerps, 0},
+		{"perp-perp without perps", common.ExchangeBinance, perp, "PERPLESS", perp, ExecutionNotExecutable, 0},
+		{"buy spot sell perp", common.ExchangeBinance, spot, common.ExchangeLighter, perp, ExecutionHedgeSpotPerp, 0},
+		{"buy perp sell spot", common.ExchangeLighter, perp, common.ExchangeBinance, spot, ExecutionHedgeSpotPerp, 0},
+		{"buy perp needs no withdrawal", "NOWITHDRAW", perp, common.ExchangeBinance, spot, ExecutionHedgeSpotPerp, 0},
+		{"spot leg on perp-less venue", "PERPLESS", spot, common.ExchangeBinance, perp, ExecutionHedgeSpotPerp, 0},
+		{"perp leg on perp-less venue", common.ExchangeBinance, spot, "PERPLESS", perp, ExecutionNotExecutable, 0},
+		{"spot-spot transfer uses slower venue", common.ExchangeBinance, spot, common.ExchangeLighter, spot, ExecutionTransferRequired, 30},
+		{"spot-spot without withdrawal", "NOWITHDRAW", spot, common.ExchangeBinance, spot, ExecutionNotExecutable, 0},
+		{"spot-spot into deposit-only venue", common.ExchangeBinance, spot, "NOWITHDRAW", spot, ExecutionTransferRequired, 10},
+		{"unknown venue", common.ExchangeBinance, perp, common.ExchangeAster, perp, ExecutionNotExecutable, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, minutes := snap.deriveExecutionMode(tt.buyExchange, tt.buyMarket, tt.sellExchange, tt.sellMarket)
+			if mode != tt.wantMode || minutes != tt.wantMinutes {
+				t.Fatalf("mode = %s (%d min), want %s (%d min)", mode, minutes, tt.wantMode, tt.wantMinutes)
+			}
+			if !IsValidExecutionMode(mode) {
+				t.Fatalf("derived mode %q is not accepted by IsValidExecutionMode", mode)
+			}
+		})
+	}
+}
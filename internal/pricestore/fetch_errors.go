@@ -0,0 +1,62 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"time"
+)
+
+// defaultRateLimitBackoff 交易所被限频但未给出 Retry-After 时的退避时长
+const defaultRateLimitBackoff = 60 * time.Second
+
+// fetchEndpoint 限频退避的粒度：交易所的一个REST接口（例如现货和合约的限频互不影响）
+type fetchEndpoint struct {
+	exchange common.Exchange
+	name     string
+}
+
+// String 返回 "交易所/接口"（/api/stats fetch_backoff_until 的key）
+func (e fetchEndpoint) String() string {
+	return string(e.exchange) + "/" + e.name
+}
+
+// RecordFetchError 记录一次REST拉取失败（按交易所和错误分类计数），被限频时设置该接口的退避截止时间
+func (ps *PriceStore) RecordFetchError(exchange common.Exchange, endpoint string, err error) {
+	class := common.ErrorClass(err)
+	if class == "" {
+		return
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	counts, exists := ps.fetchErrors[exchange]
+	if !exists {
+		counts = make(map[string]int64)
+		ps.fetchErrors[exchange] = counts
+	}
+	counts[class]++
+
+	if class == common.ErrorClassRateLimited {
+		wait := common.RetryAfter(err)
+		if wait <= 0 {
+			wait = defaultRateLimitBackoff
+		}
+		key := fetchEndpoint{exchange: exchange, name: endpoint}
+		until := time.Now().Add(wait)
+		if until.After(ps.fetchBackoffUntil[key]) {
+			ps.fetchBackoffUntil[key] = until
+		}
+	}
+}
+
+// FetchBackoff 返回该接口剩余的限频退避时长，0表示可以正常请求
+func (ps *PriceStore) FetchBackoff(exchange common.Exchange, endpoint string) time.Duration {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	remaining := time.Until(ps.fetchBackoffUntil[fetchEndpoint{exchange: exchange, name: endpoint}])
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
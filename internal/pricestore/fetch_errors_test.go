@@ -0,0 +1,62 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFetchBackoffPerEndpoint(t *testing.T) {
+	ps := NewPriceStore()
+	header := http.Header{"Retry-After": []string{"30"}}
+
+	ps.RecordFetchError(common.ExchangeAster, "spot_book_ticker", common.NewHTTPError(common.ExchangeAster, http.StatusForbidden, header, nil))
+
+	if wait := ps.FetchBackoff(common.ExchangeAster, "spot_book_ticker"); wait <= 25*time.Second || wait > 30*time.Second {
+		t.Fatalf("spot backoff = %v, want about 30s", wait)
+	}
+	for _, other := range []struct {
+		exchange common.Exchange
+		endpoint string
+	}{
+		{common.ExchangeAster, "futures_book_ticker"},
+		{common.ExchangeAster, "aster_spot_24hr"},
+		{common.ExchangeBinance, "spot_book_ticker"},
+	} {
+		if wait := ps.FetchBackoff(other.exchange, other.endpoint); wait != 0 {
+			t.Errorf("%s/%s backoff = %v, want 0", other.exchange, other.endpoint, wait)
+		}
+	}
+
+	// 非限频错误只计数，不退避
+	ps.RecordFetchError(common.ExchangeAster, "futures_book_ticker", common.NewHTTPError(common.ExchangeAster, http.StatusBadGateway, nil, nil))
+	ps.RecordFetchError(common.ExchangeAster, "futures_book_ticker", errors.New("decode failed"))
+	if wait := ps.FetchBackoff(common.ExchangeAster, "futures_book_ticker"); wait != 0 {
+		t.Errorf("futures backoff after 502 = %v, want 0", wait)
+	}
+
+	// 较短的 Retry-After 不缩短已有的退避
+	ps.RecordFetchError(common.ExchangeAster, "spot_book_ticker", common.NewHTTPError(common.ExchangeAster, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}}, nil))
+	if wait := ps.FetchBackoff(common.ExchangeAster, "spot_book_ticker"); wait <= 25*time.Second {
+		t.Errorf("spot backoff shortened to %v", wait)
+	}
+
+	stats := ps.GetStats()
+	if _, ok := stats.FetchBackoffUntil["ASTER/spot_book_ticker"]; !ok || len(stats.FetchBackoffUntil) != 1 {
+		t.Errorf("backoff stats = %v, want only ASTER/spot_book_ticker", stats.FetchBackoffUntil)
+	}
+	counts := stats.FetchErrors[common.ExchangeAster]
+	if counts[common.ErrorClassRateLimited] != 2 || counts[common.ErrorClassTemporary] != 1 || counts[common.ErrorClassUnknown] != 1 {
+		t.Errorf("error counts = %v", counts)
+	}
+}
+
+func TestFetchBackoffDefaultWithoutRetryAfter(t *testing.T) {
+	ps := NewPriceStore()
+	ps.RecordFetchError(common.ExchangeBinance, "futures_book_ticker", common.NewHTTPError(common.ExchangeBinance, http.StatusTeapot, nil, nil))
+	if wait := ps.FetchBackoff(common.ExchangeBinance, "futures_book_ticker"); wait <= defaultRateLimitBackoff-5*time.Second || wait > defaultRateLimitBackoff {
+		t.Fatalf("backoff = %v, want about %v", wait, defaultRateLimitBackoff)
+	}
+}
@@ -0,0 +1,61 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FundingInfo 永续合约场所最近一次的资金费率、未平仓量和标记价格
+// 只由带资金费率的报价（FundingKnown，例如 Lighter market_stats）更新，不含资金费率的 REST 报价不会覆盖
+type FundingInfo struct {
+	Symbol       string          `json:"symbol"` // 标准symbol
+	Exchange     common.Exchange `json:"exchange"`
+	FundingRate  float64         `json:"funding_rate"`
+	OpenInterest float64         `json:"open_interest"`
+	MarkPrice    float64         `json:"mark_price,omitempty"` // 交易所提供的标记价格（已换算为USDT），0表示没有
+	LastUpdated  time.Time       `json:"last_updated"`
+}
+
+// recordFundingLocked 记录报价附带的资金费率（调用者需要持有写锁）
+// 与盘口是否被接受无关：market_stats 更新可能沿用订单簿的交易所时间而被新鲜度规则拒绝
+func (ps *PriceStore) recordFundingLocked(standardSymbol string, price *common.Price) {
+	if !price.FundingKnown || price.MarketType != common.MarketTypeFuture {
+		return
+	}
+	ps.funding[fmt.Sprintf("%s_%s", price.Exchange, standardSymbol)] = &FundingInfo{
+		Symbol:       standardSymbol,
+		Exchange:     price.Exchange,
+		FundingRate:  price.FundingRate,
+		OpenInterest: price.OpenInterest,
+		MarkPrice:    price.MarkPrice,
+		LastUpdated:  price.LastUpdated,
+	}
+}
+
+// GetFunding 获取资金费率记录（按symbol、交易所排序），symbol 为空时返回全部
+func (ps *PriceStore) GetFunding(symbol string) []FundingInfo {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	standardSymbol := ""
+	if symbol != "" {
+		standardSymbol = ps.symbolNormalizer.Normalize(NormalizeThresholdSymbol(symbol))
+	}
+
+	result := make([]FundingInfo, 0, len(ps.funding))
+	for _, info := range ps.funding {
+		if standardSymbol != "" && info.Symbol != standardSymbol {
+			continue
+		}
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Symbol != result[j].Symbol {
+			return result[i].Symbol < result[j].Symbol
+		}
+		return result[i].Exchange < result[j].Exchange
+	})
+	return result
+}
@@ -0,0 +1,52 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+func TestFundingSurvivesRESTUpdates(t *testing.T) {
+	ps := NewPriceStore()
+	t0 := time.Now().Add(-2 * time.Minute)
+
+	ws := projectionQuote(common.ExchangeLighter, 99.9, 100.1, t0, t0)
+	ws.FundingRate, ws.FundingKnown, ws.OpenInterest, ws.MarkPrice = 0.0001, true, 500, 100.02
+	if !ps.UpdatePrice(ws) {
+		t.Fatal("websocket quote rejected")
+	}
+
+	// WS 超过60秒未更新后 REST 报价被接受，它不含资金费率
+	now := time.Now()
+	rest := projectionQuote(common.ExchangeLighter, 99.8, 100.0, now, now)
+	rest.Source, rest.OpenInterest = common.PriceSourceREST, 510
+	if !ps.UpdatePrice(rest) {
+		t.Fatal("REST quote rejected")
+	}
+
+	funding := ps.GetFunding("BTC")
+	if len(funding) != 1 {
+		t.Fatalf("funding entries = %d, want 1", len(funding))
+	}
+	if got := funding[0]; got.FundingRate != 0.0001 || got.OpenInterest != 500 || got.MarkPrice != 100.02 || got.Symbol != "BTCUSDT" {
+		t.Fatalf("funding = %+v, want the websocket values", got)
+	}
+
+	// 盘口被新鲜度规则拒绝时资金费率仍然更新
+	fresh := projectionQuote(common.ExchangeLighter, 99.8, 100.0, now, now)
+	fresh.FundingRate, fresh.FundingKnown = 0.0001, true
+	if !ps.UpdatePrice(fresh) {
+		t.Fatal("fresh websocket quote rejected")
+	}
+	stale := projectionQuote(common.ExchangeLighter, 99.8, 100.0, now.Add(-time.Second), now)
+	stale.FundingRate, stale.FundingKnown = -0.0002, true
+	if ps.UpdatePrice(stale) {
+		t.Fatal("older quote accepted")
+	}
+	if got := ps.GetFunding("BTCUSDT")[0].FundingRate; got != -0.0002 {
+		t.Fatalf("funding rate = %v after a rejected quote, want -0.0002", got)
+	}
+	if len(ps.GetFunding("ETH")) != 0 {
+		t.Fatal("unexpected ETH funding")
+	}
+}
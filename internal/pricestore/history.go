@@ -0,0 +1,166 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrHistoryDisabled 未启用价格历史时的时间点查询错误
+var ErrHistoryDisabled = errors.New("price history is disabled")
+
+// HistoryConfig 价格历史记录配置，用于按时间点（as of）查询价格和价差
+type HistoryConfig struct {
+	Retention      time.Duration // 保留时长，同时是时间点查询的最大回溯范围，0表示不记录
+	SampleInterval time.Duration // 同一场所两次采样的最小间隔，间隔内的更新不记录
+}
+
+// DefaultHistoryConfig 默认价格历史配置（保留5分钟，每个场所每秒最多一个样本）
+func DefaultHistoryConfig() *HistoryConfig {
+	return &HistoryConfig{
+		Retention:      5 * time.Minute,
+		SampleInterval: time.Second,
+	}
+}
+
+// SetHistoryConfig 设置价格历史配置（nil表示恢复默认），关闭记录时清空已有历史
+func (ps *PriceStore) SetHistoryConfig(cfg *HistoryConfig) {
+	if cfg == nil {
+		cfg = DefaultHistoryConfig()
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.historyConfig = cfg
+	if cfg.Retention <= 0 {
+		ps.history = make(map[string]map[string][]*common.Price)
+	}
+}
+
+// recordHistory 记录一次价格采样（调用者需要持有写锁）
+// 入库的价格对象之后不会被修改（新价格总是替换旧对象），因此直接保存指针
+func (ps *PriceStore) recordHistory(standardSymbol, symbolKey string, price *common.Price) {
+	cfg := ps.historyConfig
+	if cfg == nil || cfg.Retention <= 0 {
+		return
+	}
+
+	venues := ps.history[standardSymbol]
+	if venues == nil {
+		venues = make(map[string][]*common.Price)
+		ps.history[standardSymbol] = venues
+	}
+
+	samples := venues[symbolKey]
+	if n := len(samples); n > 0 && price.LastUpdated.Sub(samples[n-1].LastUpdated) < cfg.SampleInterval {
+		return
+	}
+	venues[symbolKey] = trimHistory(append(samples, price), price.LastUpdated.Add(-cfg.Retention))
+}
+
+// pruneHistory 清理超出保留时长的样本，包括已停止更新的场所（调用者需要持有写锁）
+func (ps *PriceStore) pruneHistory(now time.Time) {
+	cfg := ps.historyConfig
+	if cfg == nil || cfg.Retention <= 0 {
+		return
+	}
+
+	cutoff := now.Add(-cfg.Retention)
+	for symbol, venues := range ps.history {
+		for key, samples := range venues {
+			samples = trimHistory(samples, cutoff)
+			if len(samples) == 0 {
+				delete(venues, key)
+				continue
+			}
+			venues[key] = samples
+		}
+		if len(venues) == 0 {
+			delete(ps.history, symbol)
+		}
+	}
+}
+
+// trimHistory 丢弃早于 cutoff 的样本（样本按时间升序）
+func trimHistory(samples []*common.Price, cutoff time.Time) []*common.Price {
+	if len(samples) == 0 || !samples[0].LastUpdated.Before(cutoff) {
+		return samples
+	}
+	i := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].LastUpdated.Before(cutoff)
+	})
+	return samples[i:]
+}
+
+// priceAsOf 二分查找 at 时刻（含）之前最近的样本，没有时返回nil
+func priceAsOf(samples []*common.Price, at time.Time) *common.Price {
+	i := sort.Search(len(samples), func(i int) bool {
+		return samples[i].LastUpdated.After(at)
+	})
+	if i == 0 {
+		return nil
+	}
+	return samples[i-1]
+}
+
+// checkAsOf 校验时间点是否在可查询范围内（调用者需要持有锁）
+func (ps *PriceStore) checkAsOf(at, now time.Time) error {
+	cfg := ps.historyConfig
+	if cfg == nil || cfg.Retention <= 0 {
+		return ErrHistoryDisabled
+	}
+	if at.After(now) {
+		return fmt.Errorf("requested time %s is in the future", at.Format(time.RFC3339))
+	}
+	if earliest := now.Add(-cfg.Retention); at.Before(earliest) {
+		return fmt.Errorf("requested time %s predates history retention of %v (earliest %s)",
+			at.Format(time.RFC3339), cfg.Retention, earliest.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetPricesBySymbolAsOf 获取某个时间点各场所的价格（该时刻或之前最近的样本）
+func (ps *PriceStore) GetPricesBySymbolAsOf(symbol string, at time.Time) ([]*common.Price, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if err := ps.checkAsOf(at, time.Now()); err != nil {
+		return nil, err
+	}
+
+	standardSymbol := ps.symbolNormalizer.Normalize(symbol)
+	prices := make([]*common.Price, 0)
+	for _, samples := range ps.history[standardSymbol] {
+		if price := priceAsOf(samples, at); price != nil {
+			prices = append(prices, price)
+		}
+	}
+	return prices, nil
+}
+
+// CalculateSpreadsAsOf 使用某个时间点的历史报价计算价差（与实时价差使用相同的计算逻辑，活跃判断以 at 为准）
+func (ps *PriceStore) CalculateSpreadsAsOf(at time.Time) ([]*Spread, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	if err := ps.checkAsOf(at, time.Now()); err != nil {
+		return nil, err
+	}
+
+	snap := ps.calcSnapshotLocked(false)
+	spreads := make([]*Spread, 0)
+	for symbol, venues := range ps.history {
+		priceMap := make(map[string]*common.Price, len(venues))
+		for key, samples := range venues {
+			if price := priceAsOf(samples, at); price != nil {
+				priceMap[key] = price
+			}
+		}
+		spreads = append(spreads, snap.symbolSpreads(symbol, priceMap, at)...)
+	}
+
+	ps.sortSpreadsByPercent(spreads)
+	return spreads, nil
+}
@@ -0,0 +1,165 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// seedHistory 按时间顺序写入两个场所的报价（相对 now 的偏移）
+// Binance: -40s 100.00/100.02, -20s 101.00/101.02, -5s 102.00/102.02
+// Lighter: -40s 100.50/100.52, -10s 100.00/100.02
+func seedHistory(t *testing.T, ps *PriceStore, now time.Time) {
+	t.Helper()
+	samples := []struct {
+		exchange common.Exchange
+		offset   time.Duration
+		bid      float64
+	}{
+		{common.ExchangeBinance, -40 * time.Second, 100.00},
+		{common.ExchangeLighter, -40 * time.Second, 100.50},
+		{common.ExchangeBinance, -20 * time.Second, 101.00},
+		{common.ExchangeLighter, -10 * time.Second, 100.00},
+		{common.ExchangeBinance, -5 * time.Second, 102.00},
+	}
+	for _, s := range samples {
+		ts := now.Add(s.offset)
+		if !ps.UpdatePrice(projectionQuote(s.exchange, s.bid, s.bid+0.02, ts, ts)) {
+			t.Fatalf("%s sample at %v rejected", s.exchange, s.offset)
+		}
+	}
+}
+
+// venueBids 各场所的 bid
+func venueBids(prices []*common.Price) map[common.Exchange]float64 {
+	bids := make(map[common.Exchange]float64, len(prices))
+	for _, p := range prices {
+		bids[p.Exchange] = p.BidPrice
+	}
+	return bids
+}
+
+func TestPricesAsOfReconstructsSnapshot(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	seedHistory(t, ps, now)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   map[common.Exchange]float64
+	}{
+		{"before any sample", -45 * time.Second, map[common.Exchange]float64{}},
+		{"exactly at first samples", -40 * time.Second, map[common.Exchange]float64{common.ExchangeBinance: 100.00, common.ExchangeLighter: 100.50}},
+		{"between samples", -15 * time.Second, map[common.Exchange]float64{common.ExchangeBinance: 101.00, common.ExchangeLighter: 100.50}},
+		{"after lighter moved", -7 * time.Second, map[common.Exchange]float64{common.ExchangeBinance: 101.00, common.ExchangeLighter: 100.00}},
+		{"latest", 0, map[common.Exchange]float64{common.ExchangeBinance: 102.00, common.ExchangeLighter: 100.00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, err := ps.GetPricesBySymbolAsOf("BTCUSDT", now.Add(tt.offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := venueBids(prices)
+			if len(got) != len(tt.want) {
+				t.Fatalf("bids = %v, want %v", got, tt.want)
+			}
+			for exchange, bid := range tt.want {
+				if got[exchange] != bid {
+					t.Fatalf("bids = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSpreadsAsOfUseHistoricalLegs(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	seedHistory(t, ps, now)
+
+	tests := []struct {
+		name          string
+		offset        time.Duration
+		buyAsk        float64 // Binance ask
+		sellBid       float64 // Lighter bid
+		wantSpreadPct float64
+	}{
+		{"first samples", -30 * time.Second, 100.02, 100.50, (100.50 - 100.02) / 100.02 * 100},
+		{"between samples", -15 * time.Second, 101.02, 100.50, (100.50 - 101.02) / 101.02 * 100},
+		{"after lighter moved", -7 * time.Second, 101.02, 100.00, (100.00 - 101.02) / 101.02 * 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spreads, err := ps.CalculateSpreadsAsOf(now.Add(tt.offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := binanceBuyLighterSell(t, spreads)
+			if s.BuyPrice != tt.buyAsk || s.SellPrice != tt.sellBid || math.Abs(s.SpreadPercent-tt.wantSpreadPct) > 1e-9 {
+				t.Fatalf("spread buy %v sell %v = %.6f%%, want buy %v sell %v = %.6f%%",
+					s.BuyPrice, s.SellPrice, s.SpreadPercent, tt.buyAsk, tt.sellBid, tt.wantSpreadPct)
+			}
+		})
+	}
+
+	// 当前时刻的历史价差与实时价差一致
+	asOfNow, err := ps.CalculateSpreadsAsOf(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := binanceBuyLighterSell(t, ps.CalculateSpreads())
+	if hist := binanceBuyLighterSell(t, asOfNow); hist.SpreadPercent != live.SpreadPercent {
+		t.Fatalf("as-of-now spread %.6f%% != live %.6f%%", hist.SpreadPercent, live.SpreadPercent)
+	}
+}
+
+func TestAsOfRejectsOutOfRange(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetHistoryConfig(&HistoryConfig{Retention: time.Minute, SampleInterval: time.Second})
+	now := time.Now()
+	seedHistory(t, ps, now)
+
+	if _, err := ps.GetPricesBySymbolAsOf("BTCUSDT", now.Add(-2*time.Minute)); err == nil {
+		t.Fatal("query before retention accepted")
+	}
+	if _, err := ps.CalculateSpreadsAsOf(now.Add(time.Hour)); err == nil {
+		t.Fatal("query in the future accepted")
+	}
+
+	ps.SetHistoryConfig(&HistoryConfig{Retention: 0})
+	if _, err := ps.GetPricesBySymbolAsOf("BTCUSDT", now); !errors.Is(err, ErrHistoryDisabled) {
+		t.Fatalf("err = %v, want ErrHistoryDisabled", err)
+	}
+}
+
+func TestHistorySampleIntervalAndTrim(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetHistoryConfig(&HistoryConfig{Retention: 30 * time.Second, SampleInterval: time.Second})
+	now := time.Now()
+	// 间隔内的更新不记录；超出保留时长的样本被丢弃
+	offsets := []time.Duration{-50 * time.Second, -20 * time.Second, -19800 * time.Millisecond, -10 * time.Second}
+	for i, offset := range offsets {
+		ts := now.Add(offset)
+		if !ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 100+float64(i), 100.02+float64(i), ts, ts)) {
+			t.Fatalf("update %d rejected", i)
+		}
+	}
+
+	ps.mu.RLock()
+	var samples []*common.Price
+	for _, venue := range ps.history["BTCUSDT"] {
+		samples = venue
+	}
+	ps.mu.RUnlock()
+	if len(samples) != 2 || samples[0].BidPrice != 101 || samples[1].BidPrice != 103 {
+		bids := make([]float64, len(samples))
+		for i, s := range samples {
+			bids[i] = s.BidPrice
+		}
+		t.Fatalf("sample bids = %v, want [101 103]", bids)
+	}
+}
@@ -0,0 +1,49 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+func TestInversePerpDoesNotPairWithUSDT(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+
+	// BTCUSD_PERP 按张计价，价格与 BTCUSDT 相差很大；若按同一symbol配对会产生虚假的大价差
+	inverse := projectionQuote(common.ExchangeBinance, 110.0, 110.1, now, now)
+	inverse.Symbol = "BTCUSD_PERP"
+	inverse.QuoteCurrency = ""
+	inverse.IsNormalized = false
+	if !ps.UpdatePrice(inverse) {
+		t.Fatal("inverse quote rejected")
+	}
+	if !ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 100.00, 100.01, now, now)) {
+		t.Fatal("lighter quote rejected")
+	}
+	spot := projectionQuote(common.ExchangeBinance, 100.02, 100.03, now, now)
+	spot.MarketType = common.MarketTypeSpot
+	if !ps.UpdatePrice(spot) {
+		t.Fatal("binance spot quote rejected")
+	}
+
+	bySymbol := ps.GetAllPricesBySymbol()
+	// 标准symbol经过 Normalize 去掉下划线（BTCUSDINVERSE）
+	if n := len(bySymbol[ps.symbolNormalizer.Normalize("BTC"+common.InverseSymbolSuffix)]); n != 1 {
+		t.Fatalf("%d quotes under the inverse namespace, want 1", n)
+	}
+	if n := len(bySymbol["BTCUSDT"]); n != 2 {
+		t.Fatalf("%d quotes under BTCUSDT, want only the two USDT-margined ones", n)
+	}
+
+	spreads := ps.CalculateSpreads()
+	if len(spreads) == 0 {
+		t.Fatal("no spreads between the USDT-margined quotes")
+	}
+	for _, s := range spreads {
+		if common.IsInverseSymbol(s.BuyQuote.Symbol) || common.IsInverseSymbol(s.SellQuote.Symbol) {
+			t.Fatalf("inverse quote paired: buy %s %s, sell %s %s",
+				s.BuyExchange, s.BuyQuote.Symbol, s.SellExchange, s.SellQuote.Symbol)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pricestore
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	maxInversionEvents = 100             // 最多保留的反转记录数
+	pairHistoryTTL     = 5 * time.Minute // 交易对方向记录的保留时长
+	inversionMinSpread = 0.01            // 计入方向的最小价差绝对值（百分比）
+)
+
+// InversionEvent 价差反转事件：同一交易对的有利方向发生翻转
+// 所有参与计算的交易对（不论是否达到阈值）都按两腿中间价的带符号价差跟踪，绝对值低于 inversionMinSpread 时不改变记录的方向
+type InversionEvent struct {
+	Symbol         string    `json:"symbol"`
+	Type           string    `json:"type"`
+	PreviousBuy    string    `json:"previous_buy_from"` // 反转前的买入位置
+	PreviousSell   string    `json:"previous_sell_to"`  // 反转前的卖出位置
+	PreviousSpread float64   `json:"previous_spread"`   // 反转前的中间价价差百分比
+	CurrentBuy     string    `json:"buy_from"`          // 反转后的买入位置
+	CurrentSell    string    `json:"sell_to"`           // 反转后的卖出位置
+	CurrentSpread  float64   `json:"spread_percent"`    // 反转后的中间价价差百分比
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// pairObservation 一次计算中观察到的交易对带符号价差（legA < legB，legA 较便宜即 legA 买入、legB 卖出为正）
+type pairObservation struct {
+	symbol  string
+	oppType string
+	legA    string
+	legB    string
+	spread  float64
+}
+
+// newPairObservation 按买卖位置生成交易对观察，统一为 legA < legB 的方向
+func newPairObservation(symbol, oppType, buyFrom, sellTo string, spreadPercent float64) pairObservation {
+	if buyFrom > sellTo {
+		return pairObservation{symbol: symbol, oppType: oppType, legA: sellTo, legB: buyFrom, spread: -spreadPercent}
+	}
+	return pairObservation{symbol: symbol, oppType: oppType, legA: buyFrom, legB: sellTo, spread: spreadPercent}
+}
+
+// trackPairDirection 记录交易对的带符号价差，符号变化（穿越零点）时记录反转事件（调用者需要持有 trackMu）
+// 价差在 ±inversionMinSpread 内时只刷新 LastSeen，避免两个场所报价持平时来回抖动产生大量反转
+func (ps *PriceStore) trackPairDirection(obs pairObservation, now time.Time) {
+	key := fmt.Sprintf("%s_%s_%s_%s", obs.symbol, obs.oppType, obs.legA, obs.legB)
+	significant := math.Abs(obs.spread) >= inversionMinSpread
+
+	tracker, exists := ps.pairHistory[key]
+	if !exists {
+		tracker = &opportunityTracker{FirstSeen: now}
+		ps.pairHistory[key] = tracker
+	}
+	tracker.LastSeen = now
+	if !significant {
+		return
+	}
+
+	previous := tracker.SpreadPercent
+	tracker.PrevSpreadPercent = previous
+	tracker.SpreadPercent = obs.spread
+	if previous*obs.spread >= 0 {
+		return
+	}
+
+	// 符号翻转：之前的方向与当前相反
+	buy, sell := obs.legA, obs.legB
+	if obs.spread < 0 {
+		buy, sell = sell, buy
+	}
+	event := &InversionEvent{
+		Symbol:         obs.symbol,
+		Type:           obs.oppType,
+		PreviousBuy:    sell,
+		PreviousSell:   buy,
+		PreviousSpread: math.Abs(previous),
+		CurrentBuy:     buy,
+		CurrentSell:    sell,
+		CurrentSpread:  math.Abs(obs.spread),
+		DetectedAt:     now,
+	}
+
+	ps.inversions = append(ps.inversions, event)
+	if len(ps.inversions) > maxInversionEvents {
+		ps.inversions = ps.inversions[len(ps.inversions)-maxInversionEvents:]
+	}
+}
+
+// GetInversions 获取最近的价差反转事件（最新的在前）
+func (ps *PriceStore) GetInversions() []*InversionEvent {
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+
+	result := make([]*InversionEvent, 0, len(ps.inversions))
+	for i := len(ps.inversions) - 1; i >= 0; i-- {
+		result = append(result, ps.inversions[i])
+	}
+	return result
+}
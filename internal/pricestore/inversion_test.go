@@ -0,0 +1,71 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestInversionTracksZeroCrossingBelowThreshold(t *testing.T) {
+	ps := NewPriceStore()
+	t0 := time.Now().Add(-time.Second)
+
+	// Binance 报价固定，Lighter 在其上下小幅移动：价差始终低于 BTC 阈值（0.15%），不产生套利机会
+	if !ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 100.00, 100.01, t0, t0)) {
+		t.Fatal("binance quote rejected")
+	}
+	steps := []struct {
+		name       string
+		bid, ask   float64
+		wantEvents int
+	}{
+		{"binance cheaper by 0.03%", 100.03, 100.04, 0},
+		{"inside the dead band", 100.005, 100.015, 0},
+		{"lighter cheaper by 0.04%", 99.96, 99.97, 1},
+		{"back inside the dead band", 100.00, 100.01, 1},
+		{"still lighter cheaper", 99.95, 99.96, 1},
+	}
+	for i, step := range steps {
+		ts := t0.Add(time.Duration(i+1) * 100 * time.Millisecond)
+		if !ps.UpdatePrice(projectionQuote(common.ExchangeLighter, step.bid, step.ask, ts, ts)) {
+			t.Fatalf("%s: lighter quote rejected", step.name)
+		}
+		if opps := ps.GetArbitrageOpportunities(); len(opps) != 0 {
+			t.Fatalf("%s: %d opportunities, want none below threshold", step.name, len(opps))
+		}
+		if got := len(ps.GetInversions()); got != step.wantEvents {
+			t.Fatalf("%s: %d inversions, want %d", step.name, got, step.wantEvents)
+		}
+	}
+
+	event := ps.GetInversions()[0]
+	if event.Symbol != "BTC" || event.PreviousBuy != "BINANCE FUTURE" || event.PreviousSell != "LIGHTER FUTURE" ||
+		event.CurrentBuy != "LIGHTER FUTURE" || event.CurrentSell != "BINANCE FUTURE" {
+		t.Fatalf("event = %+v", event)
+	}
+	if math.Abs(event.PreviousSpread-0.03) > 0.001 || math.Abs(event.CurrentSpread-0.04) > 0.001 {
+		t.Fatalf("spreads = %.4f -> %.4f, want 0.03 -> 0.04", event.PreviousSpread, event.CurrentSpread)
+	}
+}
+
+func TestGetArbitrageOpportunitiesUsesReadLock(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 100.00, 100.01, now, now))
+	ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 100.30, 100.31, now, now))
+
+	// 其他读取者持有读锁时计算和跟踪仍能完成（写锁会在这里阻塞）
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	done := make(chan int, 1)
+	go func() { done <- len(ps.GetArbitrageOpportunities()) }()
+	select {
+	case n := <-done:
+		if n == 0 {
+			t.Fatal("expected an opportunity above threshold")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("GetArbitrageOpportunities blocked behind a concurrent reader")
+	}
+}
@@ -0,0 +1,40 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"time"
+)
+
+// latencyAlpha REST耗时指数移动平均的平滑系数（越大越偏向最近的请求）
+const latencyAlpha = 0.2
+
+// FetchLatency 单个交易所REST全量拉取的耗时统计
+type FetchLatency struct {
+	LastMs      float64   `json:"last_ms"`    // 最近一次耗时（毫秒）
+	AverageMs   float64   `json:"average_ms"` // 指数移动平均耗时（毫秒）
+	MaxMs       float64   `json:"max_ms"`     // 最大耗时（毫秒）
+	Samples     int64     `json:"samples"`    // 成功的拉取次数
+	LastFetched time.Time `json:"last_fetched"`
+}
+
+// RecordFetchLatency 记录一次成功的REST拉取耗时（例如 GetAllBookTickers），更新该交易所的移动平均
+func (ps *PriceStore) RecordFetchLatency(exchange common.Exchange, duration time.Duration) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ms := float64(duration) / float64(time.Millisecond)
+	latency, exists := ps.fetchLatency[exchange]
+	if !exists {
+		latency = &FetchLatency{AverageMs: ms}
+		ps.fetchLatency[exchange] = latency
+	} else {
+		latency.AverageMs += latencyAlpha * (ms - latency.AverageMs)
+	}
+
+	latency.LastMs = ms
+	if ms > latency.MaxMs {
+		latency.MaxMs = ms
+	}
+	latency.Samples++
+	latency.LastFetched = time.Now().UTC()
+}
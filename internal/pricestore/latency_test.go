@@ -0,0 +1,67 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFetchLatencyMovingAverage(t *testing.T) {
+	ps := NewPriceStore()
+
+	// 第一次拉取直接作为平均值，之后按 latencyAlpha 做指数移动平均
+	fetches := []struct {
+		duration time.Duration
+		wantAvg  float64
+	}{
+		{100 * time.Millisecond, 100},
+		{200 * time.Millisecond, 100 + latencyAlpha*(200-100)},
+		{50 * time.Millisecond, 120 + latencyAlpha*(50-120)},
+	}
+	for i, f := range fetches {
+		ps.RecordFetchLatency(common.ExchangeBinance, f.duration)
+		got := ps.GetStats().FetchLatency[common.ExchangeBinance]
+		if math.Abs(got.AverageMs-f.wantAvg) > 1e-9 {
+			t.Fatalf("fetch %d: average = %v ms, want %v ms", i, got.AverageMs, f.wantAvg)
+		}
+		if got.Samples != int64(i+1) {
+			t.Fatalf("fetch %d: samples = %d, want %d", i, got.Samples, i+1)
+		}
+		if got.LastMs != float64(f.duration/time.Millisecond) {
+			t.Fatalf("fetch %d: last = %v ms, want %v", i, got.LastMs, f.duration)
+		}
+	}
+
+	got := ps.GetStats().FetchLatency[common.ExchangeBinance]
+	if got.MaxMs != 200 {
+		t.Fatalf("max = %v ms, want 200", got.MaxMs)
+	}
+	if time.Since(got.LastFetched) > time.Minute {
+		t.Fatalf("last fetched = %v, not updated", got.LastFetched)
+	}
+
+	// 各交易所独立统计
+	ps.RecordFetchLatency(common.ExchangeLighter, 300*time.Millisecond)
+	stats := ps.GetStats().FetchLatency
+	if stats[common.ExchangeLighter].AverageMs != 300 || stats[common.ExchangeLighter].Samples != 1 {
+		t.Fatalf("lighter latency = %+v", stats[common.ExchangeLighter])
+	}
+	if stats[common.ExchangeBinance].Samples != 3 {
+		t.Fatalf("binance latency changed by lighter fetch: %+v", stats[common.ExchangeBinance])
+	}
+	if _, exists := stats[common.ExchangeAster]; exists {
+		t.Fatal("latency reported for an exchange that never fetched")
+	}
+}
+
+func TestFetchLatencyStatsAreCopies(t *testing.T) {
+	ps := NewPriceStore()
+	ps.RecordFetchLatency(common.ExchangeAster, 10*time.Millisecond)
+
+	stats := ps.GetStats()
+	ps.RecordFetchLatency(common.ExchangeAster, 90*time.Millisecond)
+	if stats.FetchLatency[common.ExchangeAster].Samples != 1 || stats.FetchLatency[common.ExchangeAster].LastMs != 10 {
+		t.Fatalf("earlier stats snapshot mutated: %+v", stats.FetchLatency[common.ExchangeAster])
+	}
+}
@@ -0,0 +1,114 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadSymbolMappings 从文件加载symbol映射（JSON对象 {"原symbol": "标准symbol"}），替换现有映射并重建symbol索引
+// 两侧都会先按 NormalizeThresholdSymbol 标准化（XBT 等价于 XBTUSDT），文件不存在时视为空映射
+// 文件内容校验失败时保留原有映射，返回加载的映射数
+func (ps *PriceStore) LoadSymbolMappings(path string) (int, error) {
+	mappings, err := readSymbolMappings(path)
+	if err != nil {
+		return 0, err
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.symbolMappingsFile = path
+	ps.symbolNormalizer.SetMappings(mappings)
+	// 已入库的价格按新映射重新归组，之后的更新也使用新映射
+	ps.rebuildSymbolIndex()
+	return len(mappings), nil
+}
+
+// ReloadSymbolMappings 重新读取上次加载的映射文件
+func (ps *PriceStore) ReloadSymbolMappings() (int, error) {
+	ps.mu.RLock()
+	path := ps.symbolMappingsFile
+	ps.mu.RUnlock()
+
+	if path == "" {
+		return 0, fmt.Errorf("symbol mappings file is not configured")
+	}
+	return ps.LoadSymbolMappings(path)
+}
+
+// GetSymbolMappings 获取当前的symbol映射
+func (ps *PriceStore) GetSymbolMappings() map[string]string {
+	return ps.symbolNormalizer.Mappings()
+}
+
+// readSymbolMappings 读取并校验映射文件
+// 映射不能成链（目标symbol本身又被映射到其他symbol），否则结果取决于映射的应用顺序
+func readSymbolMappings(path string) (map[string]string, error) {
+	mappings := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read symbol mappings file: %w", err)
+	}
+	if err != nil || len(data) == 0 {
+		return mappings, nil
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse symbol mappings file: %w", err)
+	}
+	return normalizeSymbolMappings(raw)
+}
+
+// normalizeSymbolMappings 标准化并校验映射：两侧按 NormalizeThresholdSymbol 标准化，不能冲突或成链
+func normalizeSymbolMappings(raw map[string]string) (map[string]string, error) {
+	mappings := make(map[string]string, len(raw))
+	for original, standard := range raw {
+		// 空symbol标准化后会变成 "USDT"，需要在标准化前拒绝
+		if strings.TrimSpace(original) == "" || strings.TrimSpace(standard) == "" {
+			return nil, fmt.Errorf("invalid symbol mapping %q -> %q", original, standard)
+		}
+		from := NormalizeThresholdSymbol(original)
+		to := NormalizeThresholdSymbol(standard)
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid symbol mapping %q -> %q", original, standard)
+		}
+		if existing, exists := mappings[from]; exists && existing != to {
+			return nil, fmt.Errorf("conflicting symbol mappings for %s: %s and %s", from, existing, to)
+		}
+		if from != to {
+			mappings[from] = to
+		}
+	}
+
+	for from, to := range mappings {
+		if next, exists := mappings[to]; exists {
+			return nil, fmt.Errorf("chained symbol mapping %s -> %s -> %s, map %s to %s directly", from, to, next, from, next)
+		}
+	}
+	return mappings, nil
+}
+
+// saveSymbolMappings 将 mappings 写入symbol映射文件（调用者需要持有 configMu）
+// 未设置文件路径时只保存在内存中
+func (ps *PriceStore) saveSymbolMappings(mappings map[string]string) error {
+	if ps.symbolMappingsFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode symbol mappings: %w", err)
+	}
+
+	if err := common.WriteFileAtomic(ps.symbolMappingsFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write symbol mappings file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"time"
+)
+
+// markReferenceMaxAge 记录的标记/指数价格的有效期
+// bookTicker 等不带标记价格的数据源使用同一市场最近记录的参考价格，超过有效期后不再校验
+const markReferenceMaxAge = 60 * time.Second
+
+// markReference 某个合约最近一次由交易所提供的参考价格（交易所原始symbol和报价货币）
+type markReference struct {
+	price     float64 // 标记价格，没有时为指数价格
+	updatedAt time.Time
+}
+
+// rememberMarkReference 记录报价自带的标记/指数价格（调用者需要持有锁）
+func (ps *PriceStore) rememberMarkReference(price *common.Price) {
+	if price.MarketType != common.MarketTypeFuture {
+		return
+	}
+	reference := price.MarkPrice
+	if reference <= 0 {
+		reference = price.IndexPrice
+	}
+	if reference <= 0 || math.IsNaN(reference) || math.IsInf(reference, 0) {
+		return
+	}
+
+	ps.markRefs[markReferenceKey(price)] = &markReference{price: reference, updatedAt: time.Now()}
+}
+
+// markDeviationPercent 报价中间价相对参考价格的偏离百分比（调用者需要持有锁）
+// 参考价格依次取报价自带的标记价格、指数价格、同一合约最近记录的参考价格；都没有时返回 false
+func (ps *PriceStore) markDeviationPercent(price *common.Price) (float64, bool) {
+	reference := price.MarkPrice
+	if reference <= 0 {
+		reference = price.IndexPrice
+	}
+	if reference <= 0 {
+		ref := ps.markRefs[markReferenceKey(price)]
+		if ref == nil || time.Since(ref.updatedAt) > markReferenceMaxAge {
+			return 0, false
+		}
+		reference = ref.price
+	}
+
+	mid := price.Price
+	if price.BidPrice > 0 && price.AskPrice > 0 {
+		mid = (price.BidPrice + price.AskPrice) / 2
+	}
+	if mid <= 0 {
+		return 0, false
+	}
+	return math.Abs(mid/reference-1) * 100, true
+}
+
+// markReferenceKey 参考价格的key：交易所 + 原始symbol（只记录合约）
+func markReferenceKey(price *common.Price) string {
+	return string(price.Exchange) + "_" + price.Symbol
+}
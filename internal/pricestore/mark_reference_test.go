@@ -0,0 +1,116 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+// markQuote Lighter 合约报价（bid/ask 围绕 mid），mark/index 为0表示不带参考价格
+func markQuote(mid, mark, index float64) *common.Price {
+	now := time.Now()
+	p := projectionQuote(common.ExchangeLighter, mid-0.5, mid+0.5, now, now)
+	p.MarkPrice = mark
+	p.IndexPrice = index
+	return p
+}
+
+func TestMarkDeviationRejectsFatFingerQuotes(t *testing.T) {
+	ps := NewPriceStore() // 默认阈值 5%
+
+	tests := []struct {
+		name    string
+		price   *common.Price
+		applied bool
+	}{
+		{"near own mark", markQuote(50000, 50100, 0), true},
+		{"10% above own mark", markQuote(55000, 50000, 0), false},
+		{"index used without mark", markQuote(45000, 0, 50000), false},
+		{"mark preferred over index", markQuote(50000, 50000, 40000), true},
+		// 不带参考价格的报价（bookTicker）使用同一合约最近记录的标记价格
+		{"remembered mark, 10% below", markQuote(45000, 0, 0), false},
+		{"remembered mark, 0.2% away", markQuote(50100, 0, 0), true},
+	}
+	for _, tt := range tests {
+		if got := ps.UpdatePrice(tt.price); got != tt.applied {
+			t.Fatalf("%s: applied = %v, want %v", tt.name, got, tt.applied)
+		}
+	}
+	if rejected := ps.GetStats().RejectedByExchange[common.ExchangeLighter]; rejected != 3 {
+		t.Fatalf("rejected = %d, want 3", rejected)
+	}
+	if stored := ps.GetPrice(common.ExchangeLighter, common.MarketTypeFuture, "BTCUSDT"); stored.BidPrice != 50099.5 {
+		t.Fatalf("stored bid = %v, want the last accepted quote", stored.BidPrice)
+	}
+}
+
+func TestMarkDeviationThresholdConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold float64
+		mid       float64
+		applied   bool
+	}{
+		{"within custom threshold", 1, 50400, true},
+		{"beyond custom threshold", 1, 50600, false},
+		{"disabled", 0, 80000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewPriceStore()
+			cfg := DefaultValidationConfig()
+			cfg.MaxMarkDeviationPercent = tt.threshold
+			ps.SetValidationConfig(cfg)
+			if got := ps.UpdatePrice(markQuote(tt.mid, 50000, 0)); got != tt.applied {
+				t.Fatalf("applied = %v, want %v", got, tt.applied)
+			}
+		})
+	}
+}
+
+func TestRememberedMarkExpires(t *testing.T) {
+	ps := NewPriceStore()
+	ps.UpdatePrice(markQuote(50000, 50000, 0))
+
+	// 参考价格过期后不再校验不带标记价格的报价
+	ps.mu.Lock()
+	for _, ref := range ps.markRefs {
+		ref.updatedAt = time.Now().Add(-markReferenceMaxAge - time.Second)
+	}
+	ps.mu.Unlock()
+	if !ps.UpdatePrice(markQuote(60000, 0, 0)) {
+		t.Fatal("quote checked against an expired mark reference")
+	}
+
+	// 其他场所的同名合约不共用参考价格
+	ps.UpdatePrice(markQuote(50000, 50000, 0))
+	now := time.Now()
+	other := projectionQuote(common.ExchangeAster, 59999.5, 60000.5, now, now)
+	if !ps.UpdatePrice(other) {
+		t.Fatal("Aster quote checked against Lighter's mark")
+	}
+}
+
+func TestNormalizeScalesMarkAndIndex(t *testing.T) {
+	p := markQuote(50000, 50010, 50020)
+	p.QuoteCurrency = common.QuoteCurrencyUSDC
+	p.NormalizeToUSDT(0.999, "TEST")
+	if p.MarkPrice != 50010*0.999 || p.IndexPrice != 50020*0.999 {
+		t.Fatalf("mark/index = %v/%v, want scaled by the quote rate", p.MarkPrice, p.IndexPrice)
+	}
+}
+
+func TestRejectedQuoteDoesNotRecordMark(t *testing.T) {
+	ps := NewPriceStore()
+	if !ps.UpdatePrice(markQuote(50000, 50000, 0)) {
+		t.Fatal("quote near its own mark rejected")
+	}
+
+	// 离群报价（自带的标记价格也离谱）被拒绝，不能覆盖记录的参考价格
+	if ps.UpdatePrice(markQuote(50000, 100000, 0)) {
+		t.Fatal("quote 50% away from its own mark accepted")
+	}
+	if !ps.UpdatePrice(markQuote(50100, 0, 0)) {
+		t.Fatal("bookTicker quote checked against the mark of a rejected quote")
+	}
+}
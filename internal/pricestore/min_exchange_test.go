@@ -0,0 +1,78 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+// venueQuote 指定symbol和场所的报价
+func venueQuote(exchange common.Exchange, symbol string, bid, ask float64, received time.Time) *common.Price {
+	price := projectionQuote(exchange, bid, ask, received, received)
+	price.Symbol = symbol
+	return price
+}
+
+// spreadBases 价差和套利机会中出现的base asset（去重排序）
+func spreadBases(ps *PriceStore) (spreads, opportunities []string) {
+	collect := func(symbols map[string]bool) []string {
+		result := make([]string, 0, len(symbols))
+		for symbol := range symbols {
+			result = append(result, symbol)
+		}
+		sort.Strings(result)
+		return result
+	}
+	seen := make(map[string]bool)
+	for _, s := range ps.CalculateSpreads() {
+		seen[common.ParseSymbol(s.Symbol).BaseAsset] = true
+	}
+	spreads = collect(seen)
+	seen = make(map[string]bool)
+	for _, o := range ps.GetArbitrageOpportunities() {
+		seen[o.Symbol] = true
+	}
+	return spreads, collect(seen)
+}
+
+func TestMinExchangeCount(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	// 价差约0.3%，高于默认阈值
+	quotes := []*common.Price{
+		venueQuote(common.ExchangeBinance, "SOLUSDT", 100.00, 100.01, now), // 只有一个场所
+		venueQuote(common.ExchangeBinance, "ETHUSDT", 100.00, 100.01, now),
+		venueQuote(common.ExchangeAster, "ETHUSDT", 100.15, 100.16, now.Add(-2*time.Minute)), // 不活跃，不计入场所数
+		venueQuote(common.ExchangeLighter, "ETHUSDT", 100.30, 100.31, now),
+		venueQuote(common.ExchangeBinance, "BTCUSDT", 100.00, 100.01, now),
+		venueQuote(common.ExchangeLighter, "BTCUSDT", 100.30, 100.31, now),
+		venueQuote(common.ExchangeAster, "BTCUSDT", 100.15, 100.16, now),
+	}
+	for _, q := range quotes {
+		if !ps.UpdatePrice(q) {
+			t.Fatalf("%s %s quote rejected", q.Exchange, q.Symbol)
+		}
+	}
+
+	tests := []struct {
+		min  int
+		want []string
+	}{
+		{0, []string{"BTC", "ETH"}}, // 小于2按2处理
+		{2, []string{"BTC", "ETH"}},
+		{3, []string{"BTC"}},
+		{4, []string{}},
+	}
+	for _, tt := range tests {
+		ps.SetMinExchangeCount(tt.min)
+		spreads, opportunities := spreadBases(ps)
+		if !slices.Equal(spreads, tt.want) {
+			t.Errorf("min=%d: spread symbols = %v, want %v", tt.min, spreads, tt.want)
+		}
+		if !slices.Equal(opportunities, tt.want) {
+			t.Errorf("min=%d: opportunity symbols = %v, want %v", tt.min, opportunities, tt.want)
+		}
+	}
+}
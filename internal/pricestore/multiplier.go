@@ -0,0 +1,325 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MultiplierConfig 数量级倍数（1000PEPE 等）检测配置
+type MultiplierConfig struct {
+	AutoApply bool          // 嫌疑稳定超过 StableFor 后自动应用倍数修正
+	StableFor time.Duration // 自动应用前需要持续检测到的时长
+	Tolerance float64       // 价格比与10的幂的最大相对偏差（0.02 表示2%）
+}
+
+// DefaultMultiplierConfig 默认检测配置（只提示，不自动应用）
+func DefaultMultiplierConfig() *MultiplierConfig {
+	return &MultiplierConfig{
+		AutoApply: false,
+		StableFor: 10 * time.Minute,
+		Tolerance: 0.02,
+	}
+}
+
+// MultiplierSuspect 疑似存在数量级倍数差异的场所报价
+type MultiplierSuspect struct {
+	Exchange            common.Exchange   `json:"exchange"`
+	MarketType          common.MarketType `json:"market_type"`
+	Symbol              string            `json:"symbol"`           // 交易所原始symbol
+	CanonicalSymbol     string            `json:"canonical_symbol"` // 当前索引的标准symbol
+	TargetSymbol        string            `json:"target_symbol"`    // 修正后应索引的标准symbol
+	ReferenceExchange   common.Exchange   `json:"reference_exchange"`
+	ReferenceMarketType common.MarketType `json:"reference_market_type"`
+	ReferenceSymbol     string            `json:"reference_symbol"`
+	Ratio               float64           `json:"ratio"`            // 该场所中间价 / 参考场所中间价
+	Multiplier          float64           `json:"multiplier"`       // 推断的倍数（10的幂），价格除以该值、数量乘以该值
+	ProposedMapping     string            `json:"proposed_mapping"` // 例如 "BINANCE FUTURE 1000PEPEUSDT -> PEPEUSDT (/1000)"
+	FirstSeen           time.Time         `json:"first_seen"`
+	LastSeen            time.Time         `json:"last_seen"`
+	StableSeconds       int64             `json:"stable_seconds"` // 连续检测到的时长
+	Applied             bool              `json:"applied"`        // 是否已应用倍数修正
+}
+
+// multiplierRule 已应用的倍数修正规则
+type multiplierRule struct {
+	suspect      MultiplierSuspect
+	Multiplier   float64
+	TargetSymbol string
+}
+
+// multiplierKey 倍数规则/嫌疑的key: EXCHANGE_MARKET_SYMBOL
+func multiplierKey(exchange common.Exchange, marketType common.MarketType, symbol string) string {
+	return fmt.Sprintf("%s_%s_%s", exchange, marketType, symbol)
+}
+
+// SetMultiplierConfig 设置数量级倍数检测配置（nil表示恢复默认）
+func (ps *PriceStore) SetMultiplierConfig(cfg *MultiplierConfig) {
+	if cfg == nil {
+		cfg = DefaultMultiplierConfig()
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.multiplierConfig = cfg
+}
+
+// GetMultiplierSuspects 获取当前的嫌疑列表和已应用的修正规则
+func (ps *PriceStore) GetMultiplierSuspects() []MultiplierSuspect {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := make([]MultiplierSuspect, 0, len(ps.multiplierSuspects)+len(ps.multiplierRules))
+	for _, suspect := range ps.multiplierSuspects {
+		result = append(result, *suspect)
+	}
+	for _, rule := range ps.multiplierRules {
+		result = append(result, rule.suspect)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Applied != result[j].Applied {
+			return result[i].Applied
+		}
+		return multiplierKey(result[i].Exchange, result[i].MarketType, result[i].Symbol) <
+			multiplierKey(result[j].Exchange, result[j].MarketType, result[j].Symbol)
+	})
+	return result
+}
+
+// RunMultiplierDetector 按 interval 检测数量级倍数差异，直到 stopChan 关闭
+func (ps *PriceStore) RunMultiplierDetector(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			ps.detectMultipliers(time.Now().UTC())
+		}
+	}
+}
+
+// detectMultipliers 执行一次检测，更新嫌疑列表，满足条件时自动应用修正
+// 两种情况：
+// 1. 同一标准symbol下，某个场所的价格约为最低价场所的10^k倍（例如错误的symbol映射）
+// 2. 带数量级前缀的symbol（1000PEPEUSDT）与去掉前缀的symbol（PEPEUSDT）的价格比约等于前缀
+func (ps *PriceStore) detectMultipliers(now time.Time) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	cfg := ps.multiplierConfig
+	seen := make(map[string]bool)
+
+	for canonical, priceMap := range ps.bySymbol {
+		active := activeMidPrices(priceMap, now)
+		if len(active) == 0 {
+			continue
+		}
+
+		// 情况1：同一标准symbol下的场所比较，以最低价场所为参考
+		if len(active) >= 2 {
+			ref := lowestMid(active)
+			for _, p := range active {
+				if p == ref {
+					continue
+				}
+				multiplier, ok := powerOfTenMultiplier(midPrice(p)/midPrice(ref), cfg.Tolerance)
+				if !ok {
+					continue
+				}
+				ps.observeMultiplierSuspect(p, ref, canonical, canonical, multiplier, now, seen)
+			}
+		}
+
+		// 情况2：1000PEPEUSDT 与 PEPEUSDT
+		prefix, base, ok := splitMultiplierPrefix(canonical)
+		if !ok {
+			continue
+		}
+		baseActive := activeMidPrices(ps.bySymbol[base], now)
+		if len(baseActive) == 0 {
+			continue
+		}
+		ref := lowestMid(baseActive)
+		for _, p := range active {
+			// 目标symbol下已有同一场所的报价时不能合并
+			if _, exists := ps.bySymbol[base][ps.makeSymbolKey(p.Exchange, p.MarketType)]; exists {
+				continue
+			}
+			ratio := midPrice(p) / midPrice(ref)
+			if math.Abs(ratio/prefix-1) > cfg.Tolerance {
+				continue
+			}
+			ps.observeMultiplierSuspect(p, ref, canonical, base, prefix, now, seen)
+		}
+	}
+
+	// 本次未检测到的嫌疑视为不再持续，重新计时
+	for key := range ps.multiplierSuspects {
+		if !seen[key] {
+			delete(ps.multiplierSuspects, key)
+		}
+	}
+
+	if !cfg.AutoApply {
+		return
+	}
+	for key, suspect := range ps.multiplierSuspects {
+		if now.Sub(suspect.FirstSeen) >= cfg.StableFor {
+			ps.applyMultiplierRule(key, suspect)
+		}
+	}
+}
+
+// observeMultiplierSuspect 记录一次检测结果，首次出现时输出建议（调用者需要持有锁）
+func (ps *PriceStore) observeMultiplierSuspect(p, ref *common.Price, canonical, target string, multiplier float64, now time.Time, seen map[string]bool) {
+	key := multiplierKey(p.Exchange, p.MarketType, p.Symbol)
+	if _, applied := ps.multiplierRules[key]; applied {
+		return
+	}
+	seen[key] = true
+
+	ratio := midPrice(p) / midPrice(ref)
+	suspect, exists := ps.multiplierSuspects[key]
+	if !exists {
+		suspect = &MultiplierSuspect{
+			Exchange:        p.Exchange,
+			MarketType:      p.MarketType,
+			Symbol:          p.Symbol,
+			CanonicalSymbol: canonical,
+			TargetSymbol:    target,
+			Multiplier:      multiplier,
+			ProposedMapping: fmt.Sprintf("%s %s %s -> %s (/%s)", p.Exchange, p.MarketType, p.Symbol, target, strconv.FormatFloat(multiplier, 'f', -1, 64)),
+			FirstSeen:       now,
+		}
+		ps.multiplierSuspects[key] = suspect
+
+		log.Printf("[Multiplier] Suspect %s: price is %.4gx of %s %s %s, suggest %s",
+			key, ratio, ref.Exchange, ref.MarketType, ref.Symbol, suspect.ProposedMapping)
+	}
+
+	suspect.ReferenceExchange = ref.Exchange
+	suspect.ReferenceMarketType = ref.MarketType
+	suspect.ReferenceSymbol = ref.Symbol
+	suspect.Ratio = ratio
+	suspect.LastSeen = now
+	suspect.StableSeconds = int64(now.Sub(suspect.FirstSeen).Seconds())
+}
+
+// applyMultiplierRule 应用倍数修正，并移除该场所已入库的未修正报价（调用者需要持有锁）
+// 下一次更新时按修正后的价格重新入库
+func (ps *PriceStore) applyMultiplierRule(key string, suspect *MultiplierSuspect) {
+	applied := *suspect
+	applied.Applied = true
+	ps.multiplierRules[key] = &multiplierRule{
+		suspect:      applied,
+		Multiplier:   suspect.Multiplier,
+		TargetSymbol: suspect.TargetSymbol,
+	}
+	delete(ps.multiplierSuspects, key)
+
+	if exchangeMap := ps.byExchange[suspect.Exchange]; exchangeMap != nil {
+		delete(exchangeMap, ps.makeExchangeKey(suspect.MarketType, suspect.Symbol))
+	}
+	if symbolMap := ps.bySymbol[suspect.CanonicalSymbol]; symbolMap != nil {
+		delete(symbolMap, ps.makeSymbolKey(suspect.Exchange, suspect.MarketType))
+	}
+
+	log.Printf("[Multiplier] Auto-applied %s after %ds", suspect.ProposedMapping, suspect.StableSeconds)
+}
+
+// multiplierRuleFor 获取价格适用的修正规则（调用者需要持有锁）
+func (ps *PriceStore) multiplierRuleFor(price *common.Price) *multiplierRule {
+	if len(ps.multiplierRules) == 0 {
+		return nil
+	}
+	return ps.multiplierRules[multiplierKey(price.Exchange, price.MarketType, price.Symbol)]
+}
+
+// applyMultiplier 按倍数修正价格和数量，并标记 AppliedMultiplier
+func applyMultiplier(price *common.Price, multiplier float64) {
+	price.Price /= multiplier
+	price.BidPrice /= multiplier
+	price.AskPrice /= multiplier
+	price.DepthBidPrice /= multiplier
+	price.DepthAskPrice /= multiplier
+	price.MarkPrice /= multiplier
+	price.IndexPrice /= multiplier
+	price.BidQty *= multiplier
+	price.AskQty *= multiplier
+	price.AppliedMultiplier = multiplier
+}
+
+// powerOfTenMultiplier 判断价格比是否接近 10^k（k >= 1）
+func powerOfTenMultiplier(ratio, tolerance float64) (float64, bool) {
+	if ratio <= 0 || math.IsInf(ratio, 0) || math.IsNaN(ratio) {
+		return 0, false
+	}
+	k := math.Round(math.Log10(ratio))
+	if k < 1 {
+		return 0, false
+	}
+	multiplier := math.Pow(10, k)
+	if math.Abs(ratio/multiplier-1) > tolerance {
+		return 0, false
+	}
+	return multiplier, true
+}
+
+// splitMultiplierPrefix 拆分数量级前缀，例如 "1000PEPEUSDT" -> 1000, "PEPEUSDT"
+// 前缀必须是 10^k（k >= 1），且去掉前缀后仍有基础资产
+func splitMultiplierPrefix(symbol string) (float64, string, bool) {
+	digits := 0
+	for digits < len(symbol) && symbol[digits] >= '0' && symbol[digits] <= '9' {
+		digits++
+	}
+	if digits < 2 || symbol[0] != '1' || strings.Trim(symbol[1:digits], "0") != "" {
+		return 0, "", false
+	}
+
+	base := symbol[digits:]
+	info := common.ParseSymbol(base)
+	if info.BaseAsset == "" || info.BaseAsset == base {
+		return 0, "", false
+	}
+	return math.Pow(10, float64(digits-1)), base, true
+}
+
+// activeMidPrices 返回60秒内有有效中间价、且未修正过的报价
+func activeMidPrices(priceMap map[string]*common.Price, now time.Time) []*common.Price {
+	active := make([]*common.Price, 0, len(priceMap))
+	for _, p := range priceMap {
+		if p.AppliedMultiplier > 0 || now.Sub(p.LastUpdated) > 60*time.Second || midPrice(p) <= 0 {
+			continue
+		}
+		active = append(active, p)
+	}
+	return active
+}
+
+// lowestMid 返回中间价最低的报价
+func lowestMid(prices []*common.Price) *common.Price {
+	var lowest *common.Price
+	for _, p := range prices {
+		if lowest == nil || midPrice(p) < midPrice(lowest) {
+			lowest = p
+		}
+	}
+	return lowest
+}
+
+// midPrice 中间价（没有bid/ask时使用最新价）
+func midPrice(p *common.Price) float64 {
+	if p.BidPrice > 0 && p.AskPrice > 0 {
+		return (p.BidPrice + p.AskPrice) / 2
+	}
+	return p.Price
+}
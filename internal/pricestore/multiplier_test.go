@@ -0,0 +1,186 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+// pepeQuotes Binance 按 1000PEPEUSDT 报价（1000倍），Lighter 按 PEPEUSDT 报价
+func pepeQuotes(t *testing.T, ps *PriceStore, now time.Time) {
+	t.Helper()
+	if !ps.UpdatePrice(venueQuote(common.ExchangeBinance, "1000PEPEUSDT", 0.01200, 0.01201, now)) {
+		t.Fatal("1000PEPE quote rejected")
+	}
+	if !ps.UpdatePrice(venueQuote(common.ExchangeLighter, "PEPEUSDT", 0.00001202, 0.00001203, now)) {
+		t.Fatal("PEPE quote rejected")
+	}
+}
+
+func TestMultiplierPrefixDetectedAndSuggested(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	pepeQuotes(t, ps, now)
+
+	ps.detectMultipliers(now)
+	suspects := ps.GetMultiplierSuspects()
+	if len(suspects) != 1 {
+		t.Fatalf("suspects = %+v, want one", suspects)
+	}
+	s := suspects[0]
+	if s.Exchange != common.ExchangeBinance || s.Symbol != "1000PEPEUSDT" || s.CanonicalSymbol != "1000PEPEUSDT" ||
+		s.TargetSymbol != "PEPEUSDT" || s.Multiplier != 1000 || s.Applied ||
+		s.ReferenceExchange != common.ExchangeLighter || math.Abs(s.Ratio-998.3) > 1 {
+		t.Fatalf("suspect = %+v", s)
+	}
+
+	// /api/suspects 返回的建议内容
+	data, err := json.Marshal(suspects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload []map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload[0]["proposed_mapping"] != "BINANCE FUTURE 1000PEPEUSDT -> PEPEUSDT (/1000)" ||
+		payload[0]["multiplier"] != 1000.0 || payload[0]["applied"] != false {
+		t.Fatalf("payload = %v", payload[0])
+	}
+
+	// 未开启自动应用时只提示不修正，两个symbol也不会配对
+	ps.detectMultipliers(now.Add(59 * time.Second))
+	if s := ps.GetMultiplierSuspects(); len(s) != 1 || s[0].Applied {
+		t.Fatalf("suspects without auto-apply = %+v", s)
+	}
+	if len(ps.CalculateSpreads()) != 0 {
+		t.Fatal("1000PEPE paired with PEPE without an applied multiplier")
+	}
+}
+
+func TestMultiplierAutoApplyAfterStable(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetMultiplierConfig(&MultiplierConfig{AutoApply: true, StableFor: 20 * time.Second, Tolerance: 0.02})
+	now := time.Now()
+	pepeQuotes(t, ps, now)
+
+	ps.detectMultipliers(now)
+	ps.detectMultipliers(now.Add(10 * time.Second))
+	if s := ps.GetMultiplierSuspects(); len(s) != 1 || s[0].Applied || s[0].StableSeconds != 10 {
+		t.Fatalf("suspects before StableFor = %+v", s)
+	}
+
+	ps.detectMultipliers(now.Add(20 * time.Second))
+	suspects := ps.GetMultiplierSuspects()
+	if len(suspects) != 1 || !suspects[0].Applied {
+		t.Fatalf("suspects after StableFor = %+v, want the applied rule", suspects)
+	}
+	// 未修正的报价已移除，等下一次更新按修正后的价格入库
+	if n := len(ps.GetAllPricesBySymbol()["1000PEPEUSDT"]); n != 0 {
+		t.Fatalf("%d unadjusted 1000PEPE quotes left", n)
+	}
+
+	next := venueQuote(common.ExchangeBinance, "1000PEPEUSDT", 0.01200, 0.01201, time.Now())
+	next.BidQty = 5
+	if !ps.UpdatePrice(next) {
+		t.Fatal("adjusted quote rejected")
+	}
+	var adjusted *common.Price
+	for _, p := range ps.GetAllPricesBySymbol()["PEPEUSDT"] {
+		if p.Exchange == common.ExchangeBinance {
+			adjusted = p
+		}
+	}
+	if adjusted == nil || adjusted.AppliedMultiplier != 1000 || math.Abs(adjusted.BidPrice-0.000012) > 1e-12 ||
+		adjusted.BidQty != 5000 || adjusted.Symbol != "1000PEPEUSDT" {
+		t.Fatalf("adjusted quote = %+v", adjusted)
+	}
+
+	// 价差按修正后的价格计算
+	spreads := ps.CalculateSpreads()
+	if len(spreads) == 0 {
+		t.Fatal("no spread between the adjusted 1000PEPE and PEPE quotes")
+	}
+	for _, s := range spreads {
+		if math.Abs(s.SpreadPercent) > 1 {
+			t.Fatalf("spread %.2f%% between %s and %s, want the adjusted prices", s.SpreadPercent, s.BuyExchange, s.SellExchange)
+		}
+	}
+
+	// 已应用的场所不再作为新的嫌疑出现
+	ps.detectMultipliers(time.Now())
+	if s := ps.GetMultiplierSuspects(); len(s) != 1 || !s[0].Applied {
+		t.Fatalf("suspects after apply = %+v", s)
+	}
+}
+
+func TestMultiplierSameSymbolAndReset(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	// 错误映射：两个场所都按 PEPEUSDT 入库，但 Binance 的价格是1000倍
+	ps.UpdatePrice(venueQuote(common.ExchangeBinance, "PEPEUSDT", 0.01200, 0.01201, now))
+	ps.UpdatePrice(venueQuote(common.ExchangeLighter, "PEPEUSDT", 0.00001202, 0.00001203, now))
+
+	ps.detectMultipliers(now)
+	suspects := ps.GetMultiplierSuspects()
+	if len(suspects) != 1 || suspects[0].Exchange != common.ExchangeBinance ||
+		suspects[0].TargetSymbol != "PEPEUSDT" || suspects[0].Multiplier != 1000 {
+		t.Fatalf("suspects = %+v", suspects)
+	}
+
+	// 比值不再接近10的幂时嫌疑消失
+	ps.UpdatePrice(venueQuote(common.ExchangeBinance, "PEPEUSDT", 0.00001201, 0.00001202, now.Add(time.Millisecond)))
+	ps.detectMultipliers(now.Add(time.Second))
+	if s := ps.GetMultiplierSuspects(); len(s) != 0 {
+		t.Fatalf("suspects after prices converged = %+v", s)
+	}
+}
+
+func TestPowerOfTenMultiplier(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  float64
+		ok    bool
+	}{
+		{1000, 1000, true},
+		{985, 1000, true}, // 1.5% 以内
+		{1025, 0, false},  // 超出 2%
+		{10.1, 10, true},
+		{1.01, 0, false}, // k=0 不算倍数
+		{500, 0, false},
+		{0, 0, false},
+		{math.Inf(1), 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := powerOfTenMultiplier(tt.ratio, 0.02)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("powerOfTenMultiplier(%v) = %v, %v; want %v, %v", tt.ratio, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSplitMultiplierPrefix(t *testing.T) {
+	tests := []struct {
+		symbol string
+		prefix float64
+		base   string
+		ok     bool
+	}{
+		{"1000PEPEUSDT", 1000, "PEPEUSDT", true},
+		{"1000000MOGUSDT", 1000000, "MOGUSDT", true},
+		{"10000SATSUSDT", 10000, "SATSUSDT", true},
+		{"1INCHUSDT", 0, "", false}, // 单个数字不是前缀
+		{"2000XUSDT", 0, "", false},
+		{"1500XUSDT", 0, "", false},
+		{"PEPEUSDT", 0, "", false},
+		{"1000USDT", 0, "", false},
+	}
+	for _, tt := range tests {
+		prefix, base, ok := splitMultiplierPrefix(tt.symbol)
+		if ok != tt.ok || prefix != tt.prefix || base != tt.base {
+			t.Errorf("splitMultiplierPrefix(%q) = %v, %q, %v; want %v, %q, %v", tt.symbol, prefix, base, ok, tt.prefix, tt.base, tt.ok)
+		}
+	}
+}
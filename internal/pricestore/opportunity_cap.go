@@ -0,0 +1,122 @@
+package pricestore
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// DefaultMaxTrackedOpportunities 默认同时跟踪的套利机会上限
+const DefaultMaxTrackedOpportunities = 500
+
+// OpportunityCapStats 套利机会跟踪上限的统计
+type OpportunityCapStats struct {
+	Tracked     int   `json:"tracked"`      // 当前跟踪的套利机会数
+	MaxTracked  int   `json:"max_tracked"`  // 跟踪上限，0表示不限制
+	Evicted     int64 `json:"evicted"`      // 累计因超过上限被丢弃的未确认机会数
+	LastDropped int   `json:"last_dropped"` // 最近一次计算中被丢弃的机会数
+}
+
+// SetMaxTrackedOpportunities 设置同时跟踪的套利机会上限，0表示不限制
+// 行情剧烈波动时大量symbol同时超过阈值，超过上限后价差最小的未确认机会先被丢弃，已确认的机会不会被丢弃
+func (ps *PriceStore) SetMaxTrackedOpportunities(max int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if max < 0 {
+		max = 0
+	}
+	ps.maxTrackedOpportunities = max
+}
+
+// GetOpportunityCapStats 获取套利机会跟踪上限的统计
+func (ps *PriceStore) GetOpportunityCapStats() OpportunityCapStats {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+	return ps.opportunityCapStatsLocked()
+}
+
+// opportunityCapStatsLocked 套利机会跟踪上限的统计（调用者需要持有 mu 读锁和 trackMu）
+func (ps *PriceStore) opportunityCapStatsLocked() OpportunityCapStats {
+	return OpportunityCapStats{
+		Tracked:     len(ps.opportunityHistory),
+		MaxTracked:  ps.maxTrackedOpportunities,
+		Evicted:     ps.opportunityEvictions,
+		LastDropped: ps.lastDroppedOpportunities,
+	}
+}
+
+// evictionCandidate 可被丢弃的未确认套利机会
+type evictionCandidate struct {
+	key           string
+	spreadPercent float64
+}
+
+// evictionHeap 按价差百分比排序的最小堆，堆顶为最弱的机会
+type evictionHeap []evictionCandidate
+
+func (h evictionHeap) Len() int { return len(h) }
+func (h evictionHeap) Less(i, j int) bool {
+	if h[i].spreadPercent != h[j].spreadPercent {
+		return h[i].spreadPercent < h[j].spreadPercent
+	}
+	return h[i].key < h[j].key
+}
+func (h evictionHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *evictionHeap) Push(x interface{}) { *h = append(*h, x.(evictionCandidate)) }
+func (h *evictionHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// enforceOpportunityCapLocked 跟踪的机会超过上限时丢弃价差最小的未确认机会，返回被丢弃的key（调用者需要持有 mu 读锁和 trackMu）
+// 被丢弃的机会删除跟踪器，之后再次出现时重新计算 FirstSeen；只剩已确认的机会时允许超过上限
+func (ps *PriceStore) enforceOpportunityCapLocked() map[string]bool {
+	ps.lastDroppedOpportunities = 0
+	excess := len(ps.opportunityHistory) - ps.maxTrackedOpportunities
+	if ps.maxTrackedOpportunities <= 0 || excess <= 0 {
+		return nil
+	}
+
+	candidates := make(evictionHeap, 0, len(ps.opportunityHistory))
+	for key, tracker := range ps.opportunityHistory {
+		if !tracker.Confirmed {
+			candidates = append(candidates, evictionCandidate{key: key, spreadPercent: tracker.SpreadPercent})
+		}
+	}
+	heap.Init(&candidates)
+
+	dropped := make(map[string]bool, excess)
+	for len(dropped) < excess && candidates.Len() > 0 {
+		weakest := heap.Pop(&candidates).(evictionCandidate)
+		delete(ps.opportunityHistory, weakest.key)
+		dropped[weakest.key] = true
+	}
+	ps.lastDroppedOpportunities = len(dropped)
+	ps.opportunityEvictions += int64(len(dropped))
+	return dropped
+}
+
+// RankOpportunities 按价差百分比从大到小排序套利机会（原地排序）
+// 价差相同时置信度高的在前，再按 symbol 和买卖方向排序，保证结果稳定
+func RankOpportunities(opportunities []*ArbitrageOpportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		a, b := opportunities[i], opportunities[j]
+		if a.SpreadPercent != b.SpreadPercent {
+			return a.SpreadPercent > b.SpreadPercent
+		}
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.BuyFrom != b.BuyFrom {
+			return a.BuyFrom < b.BuyFrom
+		}
+		return a.SellTo < b.SellTo
+	})
+}
@@ -0,0 +1,176 @@
+package pricestore
+
+import (
+	"container/heap"
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const capTestSymbols = 1000
+
+// newCapStore 1000个symbol都单独配置阈值，Binance 买、Lighter 卖，价差随序号递增（C0 最弱，C999 最强）
+// 两边盘口买一等于卖一，价差与两腿的遍历顺序无关
+func newCapStore(t *testing.T) *PriceStore {
+	t.Helper()
+	ps := NewPriceStore()
+	now := time.Now()
+	for i, symbol := range snapshotSymbols(capTestSymbols) {
+		if err := ps.SetThresholdOverride(symbol, 0.1); err != nil {
+			t.Fatal(err)
+		}
+		sell := 100 * (1 + (0.2+float64(i)*0.001)/100)
+		ps.UpdatePrice(venueQuote(common.ExchangeBinance, symbol, 100, 100, now))
+		ps.UpdatePrice(venueQuote(common.ExchangeLighter, symbol, sell, sell, now))
+	}
+	return ps
+}
+
+// capSymbolIndex 解析 snapshotSymbols 生成的symbol序号
+func capSymbolIndex(t *testing.T, symbol string) int {
+	t.Helper()
+	var i int
+	if _, err := fmt.Sscanf(symbol, "C%d", &i); err != nil {
+		t.Fatalf("unexpected symbol %q", symbol)
+	}
+	return i
+}
+
+// returnedIndexes 返回的套利机会对应的symbol序号
+func returnedIndexes(t *testing.T, opportunities []*ArbitrageOpportunity) map[int]*ArbitrageOpportunity {
+	t.Helper()
+	result := make(map[int]*ArbitrageOpportunity, len(opportunities))
+	for _, opp := range opportunities {
+		if !strings.HasPrefix(opp.BuyFrom, string(common.ExchangeBinance)) || !strings.HasPrefix(opp.SellTo, string(common.ExchangeLighter)) {
+			t.Fatalf("%s legs buy %s sell %s", opp.Symbol, opp.BuyFrom, opp.SellTo)
+		}
+		result[capSymbolIndex(t, opp.Symbol)] = opp
+	}
+	return result
+}
+
+// confirmTrackers 把序号在 [from, to) 的symbol的跟踪器标记为已确认
+func confirmTrackers(t *testing.T, ps *PriceStore, from, to int) {
+	t.Helper()
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+	for key, tracker := range ps.opportunityHistory {
+		symbol := key[:strings.Index(key, "_")]
+		if i := capSymbolIndex(t, symbol); i >= from && i < to {
+			tracker.Confirmed = true
+		}
+	}
+}
+
+func TestOpportunityCapDropsWeakestSpreads(t *testing.T) {
+	ps := newCapStore(t)
+	ps.SetMaxTrackedOpportunities(500)
+
+	opportunities := ps.GetArbitrageOpportunities()
+	if len(opportunities) != 500 {
+		t.Fatalf("%d opportunities returned, want 500", len(opportunities))
+	}
+	returned := returnedIndexes(t, opportunities)
+	for i := 500; i < capTestSymbols; i++ {
+		if returned[i] == nil {
+			t.Fatalf("C%dUSDT dropped, want the 500 strongest spreads kept", i)
+		}
+	}
+
+	stats := ps.GetOpportunityCapStats()
+	want := OpportunityCapStats{Tracked: 500, MaxTracked: 500, Evicted: 500, LastDropped: 500}
+	if stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+
+	// 下一轮跟踪器已满，新出现的弱机会继续被丢弃，累计计数增加
+	ps.GetArbitrageOpportunities()
+	stats = ps.GetOpportunityCapStats()
+	if stats.Tracked != 500 || stats.LastDropped != 500 || stats.Evicted != 1000 {
+		t.Fatalf("stats after second pass = %+v", stats)
+	}
+}
+
+func TestOpportunityCapProtectsConfirmed(t *testing.T) {
+	ps := newCapStore(t)
+	ps.SetMaxTrackedOpportunities(0)
+	if got := len(ps.GetArbitrageOpportunities()); got != capTestSymbols {
+		t.Fatalf("%d opportunities without a cap, want %d", got, capTestSymbols)
+	}
+
+	// 最弱的100个已确认，超过上限时丢弃其后最弱的500个未确认机会
+	confirmTrackers(t, ps, 0, 100)
+	ps.SetMaxTrackedOpportunities(500)
+	returned := returnedIndexes(t, ps.GetArbitrageOpportunities())
+	if len(returned) != 500 {
+		t.Fatalf("%d opportunities returned, want 500", len(returned))
+	}
+	for i := 0; i < capTestSymbols; i++ {
+		kept := returned[i] != nil
+		wantKept := i < 100 || i >= 600
+		if kept != wantKept {
+			t.Fatalf("C%dUSDT kept = %v, want %v", i, kept, wantKept)
+		}
+	}
+	if stats := ps.GetOpportunityCapStats(); stats.Tracked != 500 || stats.LastDropped != 500 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestOpportunityCapExceededOnlyByConfirmed(t *testing.T) {
+	ps := newCapStore(t)
+	ps.SetMaxTrackedOpportunities(0)
+	ps.GetArbitrageOpportunities()
+	confirmTrackers(t, ps, 0, capTestSymbols)
+
+	ps.SetMaxTrackedOpportunities(10)
+	if got := len(ps.GetArbitrageOpportunities()); got != capTestSymbols {
+		t.Fatalf("%d opportunities returned, want all %d confirmed kept", got, capTestSymbols)
+	}
+	stats := ps.GetOpportunityCapStats()
+	if stats.Tracked != capTestSymbols || stats.LastDropped != 0 || stats.Evicted != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestOpportunityCapReentryRestartsConfirmation(t *testing.T) {
+	ps := newCapStore(t)
+	ps.SetMaxTrackedOpportunities(500)
+	ps.GetArbitrageOpportunities()
+
+	// 保留的机会首次出现时间提前到确认时长之前，被丢弃的没有跟踪器
+	ps.trackMu.Lock()
+	for _, tracker := range ps.opportunityHistory {
+		tracker.FirstSeen = tracker.FirstSeen.Add(-7 * time.Second)
+	}
+	ps.trackMu.Unlock()
+
+	ps.SetMaxTrackedOpportunities(0)
+	returned := returnedIndexes(t, ps.GetArbitrageOpportunities())
+	if len(returned) != capTestSymbols {
+		t.Fatalf("%d opportunities returned without a cap, want %d", len(returned), capTestSymbols)
+	}
+	for i, opp := range returned {
+		if wasKept := i >= 500; opp.IsConfirmed != wasKept {
+			t.Fatalf("C%dUSDT confirmed = %v (duration %.1fs), want %v", i, opp.IsConfirmed, opp.Duration, wasKept)
+		}
+	}
+}
+
+func TestEvictionHeapOrder(t *testing.T) {
+	h := evictionHeap{
+		{key: "c", spreadPercent: 0.3},
+		{key: "b", spreadPercent: 0.1},
+		{key: "d", spreadPercent: 0.2},
+		{key: "a", spreadPercent: 0.1},
+	}
+	heap.Init(&h)
+	want := []string{"a", "b", "d", "c"}
+	for _, key := range want {
+		if got := heap.Pop(&h).(evictionCandidate).key; got != key {
+			t.Fatalf("popped %q, want %q", got, key)
+		}
+	}
+}
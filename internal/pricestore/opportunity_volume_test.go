@@ -0,0 +1,40 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+func TestMinOpportunityVolume(t *testing.T) {
+	tests := []struct {
+		name         string
+		binanceKnown bool
+		binanceVol   float64
+		lighterVol   float64
+		want         int
+	}{
+		{"bookTicker leg with unknown volume is kept", false, 0, 1e9, 1},
+		{"both legs above minimum", true, 5e6, 1e9, 1},
+		{"known low-volume leg excluded", true, 5e6, 1000, 0},
+		{"known zero volume excluded", true, 0, 1e9, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := NewPriceStore()
+			ps.SetMinOpportunityVolume(1e6)
+			now := time.Now()
+
+			binance := projectionQuote(common.ExchangeBinance, 100.00, 100.01, now, now)
+			binance.Volume24h, binance.VolumeKnown = tt.binanceVol, tt.binanceKnown
+			lighter := projectionQuote(common.ExchangeLighter, 100.30, 100.31, now, now)
+			lighter.Volume24h = tt.lighterVol
+			ps.UpdatePrice(binance)
+			ps.UpdatePrice(lighter)
+
+			if got := len(ps.GetArbitrageOpportunities()); got != tt.want {
+				t.Fatalf("opportunities = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"strings"
+)
+
+// ParseAllowedPairings 解析允许生成套利机会的市场类型组合（买入腿-卖出腿，与 ArbitrageOpportunity.Pairing 相同）
+// 列表为空或包含 all 时返回nil，表示允许全部组合
+func ParseAllowedPairings(values []string) (map[string]bool, error) {
+	var allowed map[string]bool
+	for _, value := range values {
+		pairing := strings.ToLower(strings.TrimSpace(value))
+		if pairing == "" {
+			continue
+		}
+		if !IsValidPairing(pairing) {
+			return nil, fmt.Errorf("invalid market pairing %q: expected %s, %s, %s, %s or %s",
+				value, PairingAll, PairingSpotSpot, PairingSpotFuture, PairingFutureSpot, PairingFutureFuture)
+		}
+		if pairing == PairingAll {
+			return nil, nil
+		}
+		if allowed == nil {
+			allowed = make(map[string]bool)
+		}
+		allowed[pairing] = true
+	}
+	return allowed, nil
+}
+
+// SetAllowedPairings 设置生成套利机会和多交易所价差策略的市场类型组合，nil表示允许全部组合
+// 未允许的组合不参与计算，不影响 /api/spreads 的价差列表
+func (ps *PriceStore) SetAllowedPairings(allowed map[string]bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.allowedPairings = allowed
+}
+
+// pairingAllowed 买入腿-卖出腿的市场类型组合是否允许生成套利机会/价差策略
+func (snap *priceSnapshot) pairingAllowed(buy, sell *common.Price) bool {
+	if snap.allowedPairings == nil {
+		return true
+	}
+	pairing := strings.ToLower(string(buy.MarketType)) + "-" + strings.ToLower(string(sell.MarketType))
+	return snap.allowedPairings[pairing]
+}
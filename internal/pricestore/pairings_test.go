@@ -0,0 +1,140 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseAllowedPairings(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]bool
+		wantErr bool
+	}{
+		{name: "empty allows all", values: nil, want: nil},
+		{name: "all", values: []string{"all"}, want: nil},
+		{name: "all wins over others", values: []string{"future-future", "ALL"}, want: nil},
+		{
+			name:   "spot-future excluded",
+			values: []string{"future-future", " Future-Spot ", "", "spot-spot"},
+			want:   map[string]bool{PairingFutureFuture: true, PairingFutureSpot: true, PairingSpotSpot: true},
+		},
+		{name: "invalid", values: []string{"future-future", "spot_future"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseAllowedPairings(tc.values)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("allowed = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// pairingStore BTCUSDT：Binance 现货 100、Lighter 合约 100.5、Aster 现货 101（盘口买一等于卖一）
+func pairingStore(t *testing.T) *PriceStore {
+	t.Helper()
+	ps := NewPriceStore()
+	if err := ps.SetThresholdOverride("BTCUSDT", 0.1); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, leg := range []struct {
+		exchange   common.Exchange
+		marketType common.MarketType
+		price      float64
+	}{
+		{common.ExchangeBinance, common.MarketTypeSpot, 100},
+		{common.ExchangeLighter, common.MarketTypeFuture, 100.5},
+		{common.ExchangeAster, common.MarketTypeSpot, 101},
+	} {
+		price := venueQuote(leg.exchange, "BTCUSDT", leg.price, leg.price, now)
+		price.MarketType = leg.marketType
+		if !ps.UpdatePrice(price) {
+			t.Fatalf("%s %s rejected", leg.exchange, leg.marketType)
+		}
+	}
+	return ps
+}
+
+// opportunityPairings 套利机会的市场类型组合（去重排序）
+func opportunityPairings(opportunities []*ArbitrageOpportunity) []string {
+	seen := make(map[string]bool)
+	for _, opp := range opportunities {
+		seen[opp.Pairing()] = true
+	}
+	pairings := make([]string, 0, len(seen))
+	for pairing := range seen {
+		pairings = append(pairings, pairing)
+	}
+	sort.Strings(pairings)
+	return pairings
+}
+
+func TestAllowedPairingsExcludeSpotFuture(t *testing.T) {
+	ps := pairingStore(t)
+	all := []string{PairingFutureSpot, PairingSpotFuture, PairingSpotSpot}
+	if got := opportunityPairings(ps.GetArbitrageOpportunities()); !reflect.DeepEqual(got, all) {
+		t.Fatalf("pairings without a filter = %v, want %v", got, all)
+	}
+
+	allowed, err := ParseAllowedPairings([]string{"future-future", "future-spot", "spot-spot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.SetAllowedPairings(allowed)
+	want := []string{PairingFutureSpot, PairingSpotSpot}
+	if got := opportunityPairings(ps.GetArbitrageOpportunities()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("pairings with spot-future excluded = %v, want %v", got, want)
+	}
+
+	// 价差列表不受影响
+	spotFuture := 0
+	for _, spread := range ps.CalculateSpreads() {
+		if spread.BuyMarketType == common.MarketTypeSpot && spread.SellMarketType == common.MarketTypeFuture {
+			spotFuture++
+		}
+	}
+	if spotFuture == 0 {
+		t.Fatal("spot-future spreads dropped from the spread list")
+	}
+}
+
+func TestFilterOpportunitiesByPairing(t *testing.T) {
+	spot, future := common.MarketTypeSpot, common.MarketTypeFuture
+	opportunities := []*ArbitrageOpportunity{
+		{Symbol: "SS", BuyMarketType: spot, SellMarketType: spot},
+		{Symbol: "SF", BuyMarketType: spot, SellMarketType: future},
+		{Symbol: "FS", BuyMarketType: future, SellMarketType: spot},
+		{Symbol: "FF", BuyMarketType: future, SellMarketType: future},
+		{Symbol: "UNKNOWN"}, // 市场类型未知，只在 all 中返回
+	}
+
+	tests := []struct {
+		pairing string
+		want    []string
+	}{
+		{"", []string{"SS", "SF", "FS", "FF", "UNKNOWN"}},
+		{PairingAll, []string{"SS", "SF", "FS", "FF", "UNKNOWN"}},
+		{PairingSpotSpot, []string{"SS"}},
+		{PairingSpotFuture, []string{"SF"}},
+		{PairingFutureSpot, []string{"FS"}},
+		{PairingFutureFuture, []string{"FF"}},
+	}
+	for _, tc := range tests {
+		got := make([]string, 0)
+		for _, opp := range FilterOpportunitiesByPairing(opportunities, tc.pairing) {
+			got = append(got, opp.Symbol)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("FilterOpportunitiesByPairing(%q) = %v, want %v", tc.pairing, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 模拟仓位状态及平仓原因
+const (
+	PaperStatusOpen   = "open"
+	PaperStatusClosed = "closed"
+
+	PaperCloseConverged = "converged" // 价差收敛到退出阈值以下
+	PaperCloseMaxHold   = "max_hold"  // 超过最长持仓时间
+)
+
+// maxClosedPaperPositions 最多保留的已平仓记录数（累计盈亏单独统计，不受此限制）
+const maxClosedPaperPositions = 500
+
+// PaperConfig 模拟交易（paper trading）配置
+type PaperConfig struct {
+	Enabled           bool          // 套利机会确认时是否开模拟仓位
+	Notional          float64       // 每笔开仓金额（USDT，按买入腿价格计算数量）
+	ExitSpreadPercent float64       // 价差收敛到该值（百分比）以下时平仓
+	MaxHold           time.Duration // 最长持仓时间，超过后按当前价格平仓
+	MaxOpen           int           // 最多同时持有的仓位数，达到上限后不再开仓
+}
+
+// DefaultPaperConfig 默认模拟交易配置（默认不开仓，由 RunPaperTrader 所在的存储启用）
+func DefaultPaperConfig() *PaperConfig {
+	return &PaperConfig{
+		Enabled:           false,
+		Notional:          1000,
+		ExitSpreadPercent: 0.05,
+		MaxHold:           30 * time.Minute,
+		MaxOpen:           20,
+	}
+}
+
+// PaperPosition 模拟仓位：确认的套利机会按买入腿Ask买入、卖出腿Bid卖出
+// 平仓时买入腿按Bid卖出、卖出腿按Ask买回，盈亏已扣除开仓和平仓的taker手续费
+type PaperPosition struct {
+	ID     int64  `json:"id"`
+	Key    string `json:"key"`    // 套利机会key: symbol_type_buyFrom_sellTo
+	Symbol string `json:"symbol"` // 标准symbol
+	Type   string `json:"type"`   // 套利机会类型
+
+	BuyExchange    common.Exchange   `json:"buy_exchange"`
+	BuyMarketType  common.MarketType `json:"buy_market_type"`
+	SellExchange   common.Exchange   `json:"sell_exchange"`
+	SellMarketType common.MarketType `json:"sell_market_type"`
+
+	Notional           float64 `json:"notional"`
+	Quantity           float64 `json:"quantity"`
+	EntryBuyPrice      float64 `json:"entry_buy_price"`  // 买入腿开仓价（Ask）
+	EntrySellPrice     float64 `json:"entry_sell_price"` // 卖出腿开仓价（Bid）
+	EntrySpreadPercent float64 `json:"entry_spread_percent"`
+
+	MarkBuyPrice  float64 `json:"mark_buy_price"`  // 买入腿平仓价（Bid）
+	MarkSellPrice float64 `json:"mark_sell_price"` // 卖出腿平仓价（Ask）
+	SpreadPercent float64 `json:"spread_percent"`  // 当前价差（与套利机会使用相同公式）
+	Fees          float64 `json:"fees"`            // 开仓+平仓手续费
+	PnL           float64 `json:"pnl"`             // 持仓中为浮动盈亏，平仓后为已实现盈亏
+
+	Status      string     `json:"status"`
+	CloseReason string     `json:"close_reason,omitempty"`
+	OpenedAt    time.Time  `json:"opened_at"`
+	MarkedAt    time.Time  `json:"marked_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+}
+
+// PaperSymbolSummary 单个symbol的模拟交易累计统计
+type PaperSymbolSummary struct {
+	Symbol        string  `json:"symbol"`
+	Trades        int     `json:"trades"` // 已平仓笔数
+	Wins          int     `json:"wins"`   // 盈利的已平仓笔数
+	RealizedPnL   float64 `json:"realized_pnl"`
+	Fees          float64 `json:"fees"` // 已平仓的手续费合计
+	OpenPositions int     `json:"open_positions"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// SetPaperConfig 设置模拟交易配置（nil表示恢复默认）
+func (ps *PriceStore) SetPaperConfig(cfg *PaperConfig) {
+	if cfg == nil {
+		cfg = DefaultPaperConfig()
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.paperConfig = cfg
+}
+
+// RunPaperTrader 定期计算套利机会（驱动确认和开仓）并按最新价格盯市、平仓，直到 stopChan 关闭
+func (ps *PriceStore) RunPaperTrader(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			ps.GetArbitrageOpportunities()
+			ps.markPaperPositions(time.Now().UTC())
+		}
+	}
+}
+
+// GetPaperPositions 获取持仓中和已平仓的模拟仓位（均为最新的在前）
+func (ps *PriceStore) GetPaperPositions() (open, closed []PaperPosition) {
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+
+	open = make([]PaperPosition, 0, len(ps.paperOpen))
+	for i := len(ps.paperOpen) - 1; i >= 0; i-- {
+		open = append(open, *ps.paperOpen[i])
+	}
+	closed = make([]PaperPosition, 0, len(ps.paperClosed))
+	for i := len(ps.paperClosed) - 1; i >= 0; i-- {
+		closed = append(closed, *ps.paperClosed[i])
+	}
+	return open, closed
+}
+
+// GetPaperSummary 获取按symbol汇总的累计盈亏（按symbol排序）
+func (ps *PriceStore) GetPaperSummary() []PaperSymbolSummary {
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+
+	bySymbol := make(map[string]*PaperSymbolSummary, len(ps.paperTotals))
+	for symbol, total := range ps.paperTotals {
+		summary := *total
+		bySymbol[symbol] = &summary
+	}
+	for _, pos := range ps.paperOpen {
+		summary, exists := bySymbol[pos.Symbol]
+		if !exists {
+			summary = &PaperSymbolSummary{Symbol: pos.Symbol}
+			bySymbol[pos.Symbol] = summary
+		}
+		summary.OpenPositions++
+		summary.UnrealizedPnL += pos.PnL
+	}
+
+	result := make([]PaperSymbolSummary, 0, len(bySymbol))
+	for _, summary := range bySymbol {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// ResetPaper 清空所有模拟仓位和累计统计
+func (ps *PriceStore) ResetPaper() {
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+
+	ps.paperOpen = nil
+	ps.paperClosed = nil
+	ps.paperTotals = make(map[string]*PaperSymbolSummary)
+	log.Printf("[Paper] Positions and PnL reset")
+}
+
+// openPaperPosition 套利机会刚被确认时开模拟仓位（调用者需要持有 mu 读锁和 trackMu）
+// 组合策略（STG-ZRO 等比值策略）没有单一的买卖场所，不开仓；同一机会已有持仓时不重复开仓
+func (ps *PriceStore) openPaperPosition(key string, opp *ArbitrageOpportunity, now time.Time) {
+	cfg := ps.paperConfig
+	if cfg == nil || !cfg.Enabled || cfg.Notional <= 0 {
+		return
+	}
+	if opp.BuyMarketType == "" || opp.SellMarketType == "" {
+		return
+	}
+	for _, pos := range ps.paperOpen {
+		if pos.Key == key {
+			return
+		}
+	}
+	if cfg.MaxOpen > 0 && len(ps.paperOpen) >= cfg.MaxOpen {
+		return
+	}
+
+	standardSymbol := ps.symbolNormalizer.Normalize(NormalizeThresholdSymbol(opp.Symbol))
+	buy := ps.bySymbol[standardSymbol][paperVenueKey(opp.BuyFrom)]
+	sell := ps.bySymbol[standardSymbol][paperVenueKey(opp.SellTo)]
+	if buy == nil || sell == nil {
+		return
+	}
+
+	ask := ratioLegPrice(buy, true)
+	bid := ratioLegPrice(sell, false)
+	if ask <= 0 || bid <= 0 {
+		return
+	}
+
+	ps.paperNextID++
+	pos := &PaperPosition{
+		ID:                 ps.paperNextID,
+		Key:                key,
+		Symbol:             standardSymbol,
+		Type:               opp.Type,
+		BuyExchange:        buy.Exchange,
+		BuyMarketType:      buy.MarketType,
+		SellExchange:       sell.Exchange,
+		SellMarketType:     sell.MarketType,
+		Notional:           cfg.Notional,
+		Quantity:           cfg.Notional / ask,
+		EntryBuyPrice:      ask,
+		EntrySellPrice:     bid,
+		EntrySpreadPercent: opp.SpreadPercent,
+		Status:             PaperStatusOpen,
+		OpenedAt:           now,
+	}
+	ps.markPaperPosition(pos, buy, sell, now)
+	ps.paperOpen = append(ps.paperOpen, pos)
+
+	log.Printf("[Paper] Opened #%d %s: buy %s @ %.6f, sell %s @ %.6f, spread %.3f%%, qty %.6f",
+		pos.ID, pos.Symbol, opp.BuyFrom, ask, opp.SellTo, bid, opp.SpreadPercent, pos.Quantity)
+}
+
+// markPaperPositions 按最新价格盯市，价差收敛或超过最长持仓时间时平仓
+// 报价缺失或过期的仓位保留上一次的盯市价格，超时后按该价格平仓
+func (ps *PriceStore) markPaperPositions(now time.Time) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+
+	cfg := ps.paperConfig
+	if cfg == nil || len(ps.paperOpen) == 0 {
+		return
+	}
+
+	remaining := ps.paperOpen[:0]
+	for _, pos := range ps.paperOpen {
+		symbolMap := ps.bySymbol[pos.Symbol]
+		buy := symbolMap[fmt.Sprintf("%s_%s", pos.BuyExchange, pos.BuyMarketType)]
+		sell := symbolMap[fmt.Sprintf("%s_%s", pos.SellExchange, pos.SellMarketType)]
+
+		fresh := buy != nil && sell != nil &&
+			now.Sub(buy.LastUpdated) <= 60*time.Second && now.Sub(sell.LastUpdated) <= 60*time.Second
+		if fresh {
+			ps.markPaperPosition(pos, buy, sell, now)
+		}
+
+		reason := ""
+		if fresh && pos.SpreadPercent < cfg.ExitSpreadPercent {
+			reason = PaperCloseConverged
+		} else if cfg.MaxHold > 0 && now.Sub(pos.OpenedAt) >= cfg.MaxHold {
+			reason = PaperCloseMaxHold
+		}
+
+		if reason == "" {
+			remaining = append(remaining, pos)
+			continue
+		}
+		ps.closePaperPosition(pos, reason, now)
+	}
+	ps.paperOpen = remaining
+}
+
+// markPaperPosition 按当前报价计算平仓价、价差和扣除手续费后的盈亏（调用者需要持有 mu 读锁和 trackMu）
+func (ps *PriceStore) markPaperPosition(pos *PaperPosition, buy, sell *common.Price, now time.Time) {
+	exitBuyLeg := ratioLegPrice(buy, false)  // 卖出买入腿持仓
+	exitSellLeg := ratioLegPrice(sell, true) // 买回卖出腿持仓
+	if exitBuyLeg <= 0 || exitSellLeg <= 0 {
+		return
+	}
+
+	pos.MarkBuyPrice = exitBuyLeg
+	pos.MarkSellPrice = exitSellLeg
+	pos.MarkedAt = now
+
+	// 当前价差与套利机会使用相同公式：卖出腿Bid vs 买入腿Ask
+	ask := ratioLegPrice(buy, true)
+	bid := ratioLegPrice(sell, false)
+	pos.SpreadPercent = (bid - ask) * 2 / (bid + ask) * 100
+
+	qty := pos.Quantity
+	gross := qty*(exitBuyLeg-pos.EntryBuyPrice) + qty*(pos.EntrySellPrice-exitSellLeg)
+
+	buyFee := ps.takerFees[pos.BuyExchange] / 100
+	sellFee := ps.takerFees[pos.SellExchange] / 100
+	pos.Fees = qty*(pos.EntryBuyPrice+exitBuyLeg)*buyFee + qty*(pos.EntrySellPrice+exitSellLeg)*sellFee
+	pos.PnL = gross - pos.Fees
+}
+
+// closePaperPosition 平仓并计入累计统计（调用者需要持有 trackMu）
+func (ps *PriceStore) closePaperPosition(pos *PaperPosition, reason string, now time.Time) {
+	closedAt := now
+	pos.Status = PaperStatusClosed
+	pos.CloseReason = reason
+	pos.ClosedAt = &closedAt
+
+	total, exists := ps.paperTotals[pos.Symbol]
+	if !exists {
+		total = &PaperSymbolSummary{Symbol: pos.Symbol}
+		ps.paperTotals[pos.Symbol] = total
+	}
+	total.Trades++
+	if pos.PnL > 0 {
+		total.Wins++
+	}
+	total.RealizedPnL += pos.PnL
+	total.Fees += pos.Fees
+
+	ps.paperClosed = append(ps.paperClosed, pos)
+	if len(ps.paperClosed) > maxClosedPaperPositions {
+		ps.paperClosed = ps.paperClosed[len(ps.paperClosed)-maxClosedPaperPositions:]
+	}
+
+	log.Printf("[Paper] Closed #%d %s (%s) after %v: spread %.3f%% -> %.3f%%, PnL %.4f USDT (fees %.4f)",
+		pos.ID, pos.Symbol, reason, now.Sub(pos.OpenedAt).Round(time.Second),
+		pos.EntrySpreadPercent, pos.SpreadPercent, pos.PnL, pos.Fees)
+}
+
+// paperVenueKey 将套利机会的买卖位置（"EXCHANGE MARKET"）转换为 bySymbol 的key（"EXCHANGE_MARKET"）
+func paperVenueKey(leg string) string {
+	return strings.Replace(leg, " ", "_", 1)
+}
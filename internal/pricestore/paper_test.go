@@ -0,0 +1,153 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+// newPaperStore 启用模拟交易的存储，每个symbol都有 Binance 买、Lighter 卖约0.5%的价差
+func newPaperStore(t *testing.T, cfg *PaperConfig, symbols ...string) *PriceStore {
+	t.Helper()
+	ps := NewPriceStore()
+	ps.SetTakerFees(map[common.Exchange]float64{common.ExchangeBinance: 0.1, common.ExchangeLighter: 0})
+	ps.SetPaperConfig(cfg)
+	now := time.Now()
+	for _, symbol := range symbols {
+		updatePaperLeg(ps, symbol, common.ExchangeBinance, 99.99, 100, now)
+		updatePaperLeg(ps, symbol, common.ExchangeLighter, 100.5, 100.51, now)
+	}
+	return ps
+}
+
+func updatePaperLeg(ps *PriceStore, symbol string, exchange common.Exchange, bid, ask float64, ts time.Time) {
+	price := projectionQuote(exchange, bid, ask, ts, ts)
+	price.Symbol = symbol
+	ps.UpdatePrice(price)
+}
+
+func paperConfig() *PaperConfig {
+	return &PaperConfig{Enabled: true, Notional: 1000, ExitSpreadPercent: 0.05, MaxHold: 30 * time.Minute, MaxOpen: 20}
+}
+
+// confirmOpportunities 计算一次套利机会后把首次出现时间提前到确认时长之前，再计算一次触发确认
+func confirmOpportunities(ps *PriceStore) {
+	ps.GetArbitrageOpportunities()
+	ps.trackMu.Lock()
+	for _, tracker := range ps.opportunityHistory {
+		tracker.FirstSeen = tracker.FirstSeen.Add(-7 * time.Second)
+	}
+	ps.trackMu.Unlock()
+	ps.GetArbitrageOpportunities()
+}
+
+func TestPaperOpensOnConfirm(t *testing.T) {
+	ps := newPaperStore(t, paperConfig(), "BTCUSDT")
+
+	ps.GetArbitrageOpportunities()
+	if open, _ := ps.GetPaperPositions(); len(open) != 0 {
+		t.Fatalf("%d positions opened before confirmation", len(open))
+	}
+
+	confirmOpportunities(ps)
+	open, _ := ps.GetPaperPositions()
+	if len(open) != 1 {
+		t.Fatalf("%d open positions after confirmation, want 1", len(open))
+	}
+	pos := open[0]
+	if pos.BuyExchange != common.ExchangeBinance || pos.SellExchange != common.ExchangeLighter {
+		t.Fatalf("position legs buy %s sell %s", pos.BuyExchange, pos.SellExchange)
+	}
+	if pos.EntryBuyPrice != 100 || pos.EntrySellPrice != 100.5 || math.Abs(pos.Quantity-10) > 1e-9 {
+		t.Fatalf("entry buy %v sell %v qty %v, want 100 / 100.5 / 10", pos.EntryBuyPrice, pos.EntrySellPrice, pos.Quantity)
+	}
+
+	// 已确认的机会再次出现不重复开仓
+	ps.GetArbitrageOpportunities()
+	if open, _ := ps.GetPaperPositions(); len(open) != 1 {
+		t.Fatalf("%d open positions after recomputing, want 1", len(open))
+	}
+}
+
+func TestPaperDisabledDoesNotOpen(t *testing.T) {
+	cfg := paperConfig()
+	cfg.Enabled = false
+	ps := newPaperStore(t, cfg, "BTCUSDT")
+	confirmOpportunities(ps)
+	if open, _ := ps.GetPaperPositions(); len(open) != 0 {
+		t.Fatalf("%d positions opened with paper trading disabled", len(open))
+	}
+}
+
+func TestPaperClosesOnConvergence(t *testing.T) {
+	ps := newPaperStore(t, paperConfig(), "BTCUSDT")
+	confirmOpportunities(ps)
+
+	// Lighter 回落，价差收敛到退出阈值以下
+	now := time.Now().Add(time.Second)
+	updatePaperLeg(ps, "BTCUSDT", common.ExchangeLighter, 100.01, 100.02, now)
+	ps.markPaperPositions(now)
+
+	open, closed := ps.GetPaperPositions()
+	if len(open) != 0 || len(closed) != 1 {
+		t.Fatalf("open %d closed %d, want the position closed", len(open), len(closed))
+	}
+	pos := closed[0]
+	if pos.CloseReason != PaperCloseConverged || pos.Status != PaperStatusClosed || pos.ClosedAt == nil {
+		t.Fatalf("closed position = %+v, want converged", pos)
+	}
+
+	// 买入腿按Bid 99.99卖出，卖出腿按Ask 100.02买回；Binance 手续费0.1%
+	gross := 10*(99.99-100) + 10*(100.5-100.02)
+	fees := 10 * (100 + 99.99) * 0.001
+	if math.Abs(pos.PnL-(gross-fees)) > 1e-9 || math.Abs(pos.Fees-fees) > 1e-9 {
+		t.Fatalf("pnl %v fees %v, want %v / %v", pos.PnL, pos.Fees, gross-fees, fees)
+	}
+
+	summary := ps.GetPaperSummary()
+	if len(summary) != 1 || summary[0].Trades != 1 || summary[0].Wins != 1 || math.Abs(summary[0].RealizedPnL-pos.PnL) > 1e-9 {
+		t.Fatalf("summary = %+v", summary)
+	}
+}
+
+func TestPaperClosesAtMaxHold(t *testing.T) {
+	cfg := paperConfig()
+	cfg.MaxHold = time.Minute
+	ps := newPaperStore(t, cfg, "BTCUSDT")
+	confirmOpportunities(ps)
+
+	// 价差仍然很大，持仓时间未到不平仓
+	ps.markPaperPositions(time.Now().Add(30 * time.Second))
+	if open, _ := ps.GetPaperPositions(); len(open) != 1 {
+		t.Fatalf("%d open positions before max hold, want 1", len(open))
+	}
+
+	// 超过最长持仓时间：报价已过期，按上一次的盯市价格平仓
+	ps.markPaperPositions(time.Now().Add(cfg.MaxHold + time.Second))
+	open, closed := ps.GetPaperPositions()
+	if len(open) != 0 || len(closed) != 1 || closed[0].CloseReason != PaperCloseMaxHold {
+		t.Fatalf("open %d closed %+v, want closed at max hold", len(open), closed)
+	}
+	if closed[0].MarkBuyPrice != 99.99 || closed[0].MarkSellPrice != 100.51 {
+		t.Fatalf("closed at buy %v sell %v, want the last marked prices", closed[0].MarkBuyPrice, closed[0].MarkSellPrice)
+	}
+}
+
+func TestPaperMaxOpenBound(t *testing.T) {
+	cfg := paperConfig()
+	cfg.MaxOpen = 2
+	ps := newPaperStore(t, cfg, "BTCUSDT", "ETHUSDT", "SOLUSDT")
+	confirmOpportunities(ps)
+
+	open, _ := ps.GetPaperPositions()
+	if len(open) != 2 {
+		t.Fatalf("%d open positions, want MaxOpen=2", len(open))
+	}
+
+	// 再次计算时达到上限的仓位数不变
+	ps.GetArbitrageOpportunities()
+	if open, _ := ps.GetPaperPositions(); len(open) != 2 {
+		t.Fatalf("%d open positions after recomputing, want 2", len(open))
+	}
+}
@@ -0,0 +1,63 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"strings"
+)
+
+// PriceMode 价差计算使用的买卖价格
+type PriceMode string
+
+const (
+	PriceModeTopOfBook     PriceMode = "top_of_book"    // 买一/卖一（默认）
+	PriceModeDepthWeighted PriceMode = "depth_weighted" // 有深度加权价格的报价使用 DepthBidPrice/DepthAskPrice，其余仍为买一/卖一
+)
+
+// ParsePriceMode 解析价格模式（不区分大小写），空字符串为默认的 top_of_book
+func ParsePriceMode(value string) (PriceMode, error) {
+	switch mode := PriceMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return PriceModeTopOfBook, nil
+	case PriceModeTopOfBook, PriceModeDepthWeighted:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown price mode %q: expected %s or %s", value, PriceModeTopOfBook, PriceModeDepthWeighted)
+	}
+}
+
+// SetPriceMode 设置价差、套利机会和价差策略计算使用的买卖价格
+func (ps *PriceStore) SetPriceMode(mode PriceMode) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.priceMode = mode
+}
+
+// GetPriceMode 获取当前的价格模式
+func (ps *PriceStore) GetPriceMode() PriceMode {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.priceMode
+}
+
+// askPrice 买入腿使用的价格：按价格模式选择卖一或深度加权卖价，没有卖价时退回中间价
+func (snap *priceSnapshot) askPrice(price *common.Price) float64 {
+	if snap.priceMode == PriceModeDepthWeighted && price.DepthAskPrice > 0 {
+		return price.DepthAskPrice
+	}
+	if price.AskPrice == 0 {
+		return price.Price
+	}
+	return price.AskPrice
+}
+
+// bidPrice 卖出腿使用的价格：按价格模式选择买一或深度加权买价，没有买价时退回中间价
+func (snap *priceSnapshot) bidPrice(price *common.Price) float64 {
+	if snap.priceMode == PriceModeDepthWeighted && price.DepthBidPrice > 0 {
+		return price.DepthBidPrice
+	}
+	if price.BidPrice == 0 {
+		return price.Price
+	}
+	return price.BidPrice
+}
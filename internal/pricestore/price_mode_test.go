@@ -0,0 +1,67 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+// binanceToLighterSpread Binance 买入、Lighter 卖出方向的价差
+func binanceToLighterSpread(t *testing.T, ps *PriceStore) *Spread {
+	t.Helper()
+	for _, spread := range ps.CalculateSpreads() {
+		if spread.BuyExchange == common.ExchangeBinance && spread.SellExchange == common.ExchangeLighter {
+			return spread
+		}
+	}
+	t.Fatal("no Binance -> Lighter spread")
+	return nil
+}
+
+func TestPriceModeDepthWeighted(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	// Binance 没有深度加权价格；Lighter 买一 100.5，但按深度加权卖出只能拿到 100.2
+	ps.UpdatePrice(venueQuote(common.ExchangeBinance, "BTCUSDT", 100, 100, now))
+	lighter := venueQuote(common.ExchangeLighter, "BTCUSDT", 100.5, 100.5, now)
+	lighter.DepthBidPrice = 100.2
+	lighter.DepthAskPrice = 100.8
+	ps.UpdatePrice(lighter)
+
+	tests := []struct {
+		mode        PriceMode
+		wantSell    float64
+		wantPercent float64
+	}{
+		{PriceModeTopOfBook, 100.5, 0.5},
+		{PriceModeDepthWeighted, 100.2, 0.2},
+	}
+	for _, tt := range tests {
+		ps.SetPriceMode(tt.mode)
+		spread := binanceToLighterSpread(t, ps)
+		if spread.BuyPrice != 100 || spread.SellPrice != tt.wantSell || math.Abs(spread.SpreadPercent-tt.wantPercent) > 1e-9 {
+			t.Fatalf("%s: buy %v sell %v spread %.4f%%, want buy 100 sell %v spread %.4f%%",
+				tt.mode, spread.BuyPrice, spread.SellPrice, spread.SpreadPercent, tt.wantSell, tt.wantPercent)
+		}
+	}
+}
+
+func TestParsePriceMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    PriceMode
+		wantErr bool
+	}{
+		{"", PriceModeTopOfBook, false},
+		{"top_of_book", PriceModeTopOfBook, false},
+		{" Depth_Weighted ", PriceModeDepthWeighted, false},
+		{"vwap", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriceMode(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParsePriceMode(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
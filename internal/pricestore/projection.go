@@ -0,0 +1,149 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"time"
+)
+
+// LatencyProjection 延迟补偿的价差投影参数
+// 两腿报价的交易所时间相差超过 MinGap 时，按较旧一腿最近的中间价漂移把它的bid/ask投影到较新一腿的时间，
+// 投影量限制在 ±MaxBps 以内；投影只使用存储中已有的报价，原始价差保持不变，投影结果单独返回
+// 只有两腿都带交易所时间时才投影（本地接收时间与交易所时间不是同一个时钟，不能混用）
+type LatencyProjection struct {
+	MinGap           time.Duration // 两腿交易所时间差超过该值才投影
+	MaxBps           float64       // 单次投影的最大幅度（基点）
+	DriftMinSpacing  time.Duration // 计算漂移的两次报价的最小间隔，间隔更短的报价沿用上次的漂移（避免单个tick在几毫秒内的变化被放大）
+	DriftWindow      time.Duration // 计算漂移的两次报价最大间隔，超过时重新开始（避免用很久以前的报价外推）
+	ForOpportunities bool          // 套利机会按投影后的价差判断阈值
+}
+
+// DefaultLatencyProjection 默认投影参数
+func DefaultLatencyProjection() *LatencyProjection {
+	return &LatencyProjection{
+		MinGap:          150 * time.Millisecond,
+		MaxBps:          20,
+		DriftMinSpacing: 250 * time.Millisecond,
+		DriftWindow:     2 * time.Second,
+	}
+}
+
+// SpreadProjection 延迟补偿后的价差（原始价差见 Spread.SpreadPercent / ArbitrageOpportunity.SpreadPercent）
+type SpreadProjection struct {
+	Leg            string  `json:"leg"`               // 被投影的腿：buy 或 sell（交易所时间较旧的一腿）
+	GapMs          int64   `json:"gap_ms"`            // 两腿交易所时间差（毫秒）
+	DriftBpsPerSec float64 `json:"drift_bps_per_sec"` // 被投影腿的中间价漂移（基点/秒）
+	AmountBps      float64 `json:"amount_bps"`        // 实际投影量（基点，已限幅）
+	Capped         bool    `json:"capped,omitempty"`  // 投影量达到 MaxBps 被限幅
+	BuyPrice       float64 `json:"buy_price"`         // 投影后的买入价
+	SellPrice      float64 `json:"sell_price"`        // 投影后的卖出价
+	SpreadPercent  float64 `json:"spread_percent"`    // 投影后的价差百分比
+}
+
+// SetLatencyProjection 设置延迟补偿的价差投影参数，nil表示不投影（同时清空漂移起点）
+func (ps *PriceStore) SetLatencyProjection(cfg *LatencyProjection) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.latencyProjection = cfg
+	if cfg == nil {
+		ps.driftAnchors = make(map[string]driftAnchor)
+	}
+}
+
+// driftAnchor 计算漂移的起点样本（中间价和交易所时间），保存在存储中，不随报价序列化
+type driftAnchor struct {
+	mid float64
+	at  time.Time
+}
+
+// driftAnchorKey 漂移起点的key，与 byExchange 中的报价一一对应
+func driftAnchorKey(exchange common.Exchange, exchangeKey string) string {
+	return string(exchange) + "_" + exchangeKey
+}
+
+// updateDrift 按同一场所的上一次报价和漂移起点更新 next 的中间价漂移（基点/秒，按交易所时间），返回新的起点
+// 漂移从起点样本计算到当前报价：与起点间隔不足 DriftMinSpacing 时沿用上次的漂移、起点不变（例如只有数量变化的盘口更新），
+// 达到间隔时重新计算并以当前报价为新起点，超过 DriftWindow、时间倒退时从当前报价重新开始；
+// 没有交易所时间时不计算漂移，返回零值（没有起点）
+func updateDrift(prev *common.Price, anchor driftAnchor, next *common.Price, cfg *LatencyProjection) driftAnchor {
+	mid := midPrice(next)
+	if next.Timestamp.IsZero() || mid <= 0 {
+		return driftAnchor{}
+	}
+
+	var drift float64
+	if prev != nil {
+		drift = prev.MidDrift
+	} else {
+		anchor = driftAnchor{}
+	}
+	dt := next.Timestamp.Sub(anchor.at)
+
+	switch {
+	case anchor.at.IsZero() || anchor.mid <= 0 || dt < 0 || dt > cfg.DriftWindow:
+		next.MidDrift = 0
+	case dt < cfg.DriftMinSpacing:
+		next.MidDrift = drift
+		return anchor
+	default:
+		next.MidDrift = (mid - anchor.mid) / anchor.mid * 10000 / dt.Seconds()
+	}
+	return driftAnchor{mid: mid, at: next.Timestamp}
+}
+
+// legProjection 一对报价中较旧一腿的投影
+type legProjection struct {
+	older     *common.Price
+	gap       time.Duration
+	amountBps float64
+	capped    bool
+}
+
+// projectOlderLeg 两腿都有交易所时间、时间差超过 MinGap 且较旧一腿有漂移时返回投影，否则返回nil
+func (snap *priceSnapshot) projectOlderLeg(a, b *common.Price) *legProjection {
+	cfg := snap.projection
+	if cfg == nil || a.Timestamp.IsZero() || b.Timestamp.IsZero() {
+		return nil
+	}
+
+	older, gap := a, b.Timestamp.Sub(a.Timestamp)
+	if gap < 0 {
+		older, gap = b, -gap
+	}
+	if gap <= cfg.MinGap || older.MidDrift == 0 {
+		return nil
+	}
+
+	amount := older.MidDrift * gap.Seconds()
+	capped := math.Abs(amount) > cfg.MaxBps
+	if capped {
+		amount = math.Copysign(cfg.MaxBps, amount)
+	}
+	return &legProjection{older: older, gap: gap, amountBps: amount, capped: capped}
+}
+
+// apply 投影价格（price 不是被投影的腿时原样返回）
+func (lp *legProjection) apply(price *common.Price, value float64) float64 {
+	if lp == nil || price != lp.older {
+		return value
+	}
+	return value * (1 + lp.amountBps/10000)
+}
+
+// report 生成投影结果，buy 为该方向的买入腿
+func (lp *legProjection) report(buy *common.Price, buyPrice, sellPrice, spreadPercent float64) *SpreadProjection {
+	leg := "sell"
+	if buy == lp.older {
+		leg = "buy"
+	}
+	return &SpreadProjection{
+		Leg:            leg,
+		GapMs:          lp.gap.Milliseconds(),
+		DriftBpsPerSec: lp.older.MidDrift,
+		AmountBps:      lp.amountBps,
+		Capped:         lp.capped,
+		BuyPrice:       buyPrice,
+		SellPrice:      sellPrice,
+		SpreadPercent:  spreadPercent,
+	}
+}
@@ -0,0 +1,223 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+// projectionQuote BTCUSDT 永续报价，ts 为交易所时间（零值表示数据源没有提供）
+func projectionQuote(exchange common.Exchange, bid, ask float64, ts, received time.Time) *common.Price {
+	return &common.Price{
+		Symbol:        "BTCUSDT",
+		Exchange:      exchange,
+		MarketType:    common.MarketTypeFuture,
+		Price:         (bid + ask) / 2,
+		BidPrice:      bid,
+		AskPrice:      ask,
+		BidQty:        10,
+		AskQty:        10,
+		Volume24h:     1e9,
+		VolumeKnown:   true,
+		Timestamp:     ts,
+		LastUpdated:   received,
+		Source:        common.PriceSourceWebSocket,
+		QuoteCurrency: common.QuoteCurrencyUSDT,
+		IsNormalized:  true,
+	}
+}
+
+// binanceBuyLighterSell 找到买 Binance、卖 Lighter 的价差
+func binanceBuyLighterSell(t *testing.T, spreads []*Spread) *Spread {
+	t.Helper()
+	for _, s := range spreads {
+		if s.BuyExchange == common.ExchangeBinance && s.SellExchange == common.ExchangeLighter {
+			return s
+		}
+	}
+	t.Fatal("BINANCE -> LIGHTER spread not found")
+	return nil
+}
+
+// feedLaggingLighter 脚本化场景：Lighter 在下跌（300ms 内中间价 100 -> 99.85，约 -50bps/s），
+// 之后 Binance 的报价比 Lighter 新 500ms；按 Lighter 的旧报价价差为正，投影后 Lighter 卖价下移，价差不再为正
+func feedLaggingLighter(t *testing.T, ps *PriceStore) {
+	t.Helper()
+	t0 := time.Now().Add(-time.Second)
+	quotes := []*common.Price{
+		projectionQuote(common.ExchangeLighter, 99.99, 100.01, t0, t0),
+		projectionQuote(common.ExchangeLighter, 99.84, 99.86, t0.Add(300*time.Millisecond), t0.Add(300*time.Millisecond)),
+		projectionQuote(common.ExchangeBinance, 99.70, 99.71, t0.Add(800*time.Millisecond), t0.Add(800*time.Millisecond)),
+	}
+	for i, q := range quotes {
+		if !ps.UpdatePrice(q) {
+			t.Fatalf("quote %d rejected", i)
+		}
+	}
+}
+
+func TestLatencyProjectionLaggingLeg(t *testing.T) {
+	ps := NewPriceStore()
+	cfg := DefaultLatencyProjection()
+	cfg.MaxBps = 100
+	ps.SetLatencyProjection(cfg)
+	feedLaggingLighter(t, ps)
+
+	spread := binanceBuyLighterSell(t, ps.CalculateSpreads())
+	if spread.SpreadPercent <= 0 {
+		t.Fatalf("raw spread = %.4f%%, want positive", spread.SpreadPercent)
+	}
+	p := spread.Projection
+	if p == nil {
+		t.Fatal("spread not projected")
+	}
+	if p.Leg != "sell" || p.GapMs != 500 || p.Capped {
+		t.Errorf("projection = %+v, want sell leg, 500ms gap, not capped", *p)
+	}
+	if math.Abs(p.DriftBpsPerSec-(-50)) > 0.5 {
+		t.Errorf("drift = %.2f bps/s, want about -50", p.DriftBpsPerSec)
+	}
+	if math.Abs(p.AmountBps-(-25)) > 0.5 {
+		t.Errorf("amount = %.2f bps, want about -25", p.AmountBps)
+	}
+	if p.SpreadPercent > 0 {
+		t.Errorf("projected spread = %.4f%%, want <= 0", p.SpreadPercent)
+	}
+	if p.BuyPrice != spread.BuyPrice {
+		t.Errorf("fresh buy leg moved: %v -> %v", spread.BuyPrice, p.BuyPrice)
+	}
+}
+
+func TestLatencyProjectionCapped(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetLatencyProjection(DefaultLatencyProjection()) // MaxBps 20
+	feedLaggingLighter(t, ps)
+
+	p := binanceBuyLighterSell(t, ps.CalculateSpreads()).Projection
+	if p == nil || !p.Capped || p.AmountBps != -20 {
+		t.Fatalf("projection = %+v, want capped at -20bps", p)
+	}
+}
+
+func TestLatencyProjectionOpportunityGating(t *testing.T) {
+	for _, forOpportunities := range []bool{false, true} {
+		ps := NewPriceStore()
+		cfg := DefaultLatencyProjection()
+		cfg.ForOpportunities = forOpportunities
+		ps.SetLatencyProjection(cfg)
+		// 原始价差约 0.13%，低于 BTC 默认阈值 0.15%，阈值调低到 0.1%
+		if err := ps.SetThresholdOverride("BTCUSDT", 0.1); err != nil {
+			t.Fatal(err)
+		}
+		feedLaggingLighter(t, ps)
+
+		var found *ArbitrageOpportunity
+		for _, opp := range ps.GetArbitrageOpportunities() {
+			if opp.BuyFrom == "BINANCE FUTURE" && opp.SellTo == "LIGHTER FUTURE" {
+				found = opp
+			}
+		}
+		if forOpportunities && found != nil {
+			t.Errorf("ForOpportunities: opportunity %+v reported although projected spread is negative", found)
+		}
+		if !forOpportunities {
+			if found == nil {
+				t.Fatal("raw-threshold mode: opportunity missing")
+			}
+			if found.Projection == nil || found.Projection.SpreadPercent >= 0 {
+				t.Errorf("raw-threshold mode: projection = %+v, want negative projected spread", found.Projection)
+			}
+		}
+	}
+}
+
+func TestLatencyProjectionDisabled(t *testing.T) {
+	ps := NewPriceStore()
+	feedLaggingLighter(t, ps)
+	for _, s := range ps.CalculateSpreads() {
+		if s.Projection != nil {
+			t.Fatalf("projected while disabled: %+v", s.Projection)
+		}
+	}
+}
+
+func TestLatencyProjectionRequiresExchangeTimestamps(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetLatencyProjection(DefaultLatencyProjection())
+	t0 := time.Now().Add(-time.Second)
+	ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 99.99, 100.01, t0, t0))
+	ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 99.84, 99.86, t0.Add(300*time.Millisecond), t0.Add(300*time.Millisecond)))
+	// Binance 报价没有交易所时间，只有本地接收时间
+	ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 99.70, 99.71, time.Time{}, t0.Add(800*time.Millisecond)))
+
+	if p := binanceBuyLighterSell(t, ps.CalculateSpreads()).Projection; p != nil {
+		t.Fatalf("projected with a leg missing its exchange timestamp: %+v", p)
+	}
+}
+
+func TestUpdateDriftSpacing(t *testing.T) {
+	cfg := DefaultLatencyProjection() // spacing 250ms, window 2s
+	t0 := time.Unix(1700000000, 0)
+	quote := func(mid float64, offset time.Duration) *common.Price {
+		return projectionQuote(common.ExchangeLighter, mid-0.01, mid+0.01, t0.Add(offset), t0.Add(offset))
+	}
+
+	steps := []struct {
+		name      string
+		mid       float64
+		offset    time.Duration
+		wantDrift float64
+	}{
+		{"first quote", 100, 0, 0},
+		{"one tick 5ms later stays 0", 100.01, 5 * time.Millisecond, 0},
+		{"tick inside spacing", 100.02, 100 * time.Millisecond, 0},
+		{"spacing reached: 10bps over 300ms", 100.10, 300 * time.Millisecond, 10.0 / 0.3},
+		{"size-only update keeps drift", 100.10, 310 * time.Millisecond, 10.0 / 0.3},
+		{"flat over a full spacing", 100.10, 600 * time.Millisecond, 0},
+		{"gap beyond window restarts", 101, 5 * time.Second, 0},
+	}
+
+	var prev *common.Price
+	var anchor driftAnchor
+	for _, step := range steps {
+		next := quote(step.mid, step.offset)
+		anchor = updateDrift(prev, anchor, next, cfg)
+		if math.Abs(next.MidDrift-step.wantDrift) > 0.01 {
+			t.Fatalf("%s: drift = %.4f, want %.4f", step.name, next.MidDrift, step.wantDrift)
+		}
+		prev = next
+	}
+
+	// 没有交易所时间的报价不计算漂移
+	noTimestamp := projectionQuote(common.ExchangeLighter, 99, 99.02, time.Time{}, t0.Add(6*time.Second))
+	if anchor := updateDrift(prev, anchor, noTimestamp, cfg); noTimestamp.MidDrift != 0 || !anchor.at.IsZero() {
+		t.Fatalf("drift computed without exchange timestamp: %+v", noTimestamp)
+	}
+}
+
+func TestDriftAnchorsKeptInStore(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetLatencyProjection(DefaultLatencyProjection())
+	feedLaggingLighter(t, ps)
+
+	key := driftAnchorKey(common.ExchangeLighter, ps.makeExchangeKey(common.MarketTypeFuture, "BTCUSDT"))
+	ps.mu.RLock()
+	anchor, exists := ps.driftAnchors[key]
+	anchors := len(ps.driftAnchors)
+	ps.mu.RUnlock()
+	if !exists || anchor.mid != 99.85 || anchors != 2 {
+		t.Fatalf("anchor = %+v (exists %v, %d anchors), want Lighter anchored at 99.85 and one per venue", anchor, exists, anchors)
+	}
+
+	// 报价被清理后起点一起删除，重新出现的报价从头计算漂移
+	if removed := ps.CleanStaleData(time.Millisecond); removed != 2 {
+		t.Fatalf("removed %d prices, want 2", removed)
+	}
+	ps.mu.RLock()
+	anchors = len(ps.driftAnchors)
+	ps.mu.RUnlock()
+	if anchors != 0 {
+		t.Fatalf("%d drift anchors left after cleaning stale prices", anchors)
+	}
+}
@@ -0,0 +1,89 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+// quotedPrice 指定报价货币的永续报价（quote 为空表示数据源没有声明）
+func quotedPrice(exchange common.Exchange, symbol string, quote common.QuoteCurrency, bid, ask float64) *common.Price {
+	now := time.Now()
+	return &common.Price{
+		Symbol:        symbol,
+		Exchange:      exchange,
+		MarketType:    common.MarketTypeFuture,
+		Price:         (bid + ask) / 2,
+		BidPrice:      bid,
+		AskPrice:      ask,
+		BidQty:        10,
+		AskQty:        10,
+		Timestamp:     now,
+		LastUpdated:   now,
+		Source:        common.PriceSourceWebSocket,
+		QuoteCurrency: quote,
+	}
+}
+
+func TestUpdatePriceNormalizesUSDCQuotedPerp(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	ps.UpdatePrice(&common.Price{
+		Symbol: "USDCUSDT", Exchange: common.ExchangeBinance, MarketType: common.MarketTypeSpot,
+		Price: 0.998, BidPrice: 0.9979, AskPrice: 0.998, Timestamp: now, LastUpdated: now,
+	})
+	deadline := time.Now().Add(time.Second)
+	for ps.exchangeRateManager.GetRate(common.QuoteCurrencyUSDC).Rate != 0.998 {
+		if time.Now().After(deadline) {
+			t.Fatal("USDC rate not updated from BINANCE USDCUSDT")
+		}
+		ps.exchangeRateManager.UpdateFromBinance()
+		time.Sleep(time.Millisecond)
+	}
+
+	// Lighter USDC 永续与 Binance USDT 永续落在同一个标准symbol下，USDC 价格按汇率换算
+	ps.UpdatePrice(quotedPrice(common.ExchangeBinance, "ETHUSDT", "", 2000, 2000.5))
+	if !ps.UpdatePrice(quotedPrice(common.ExchangeLighter, "ETHUSDC", common.QuoteCurrencyUSDC, 2004, 2004.5)) {
+		t.Fatal("lighter quote rejected")
+	}
+
+	var lighter, binance *common.Price
+	for _, p := range ps.GetPricesBySymbol("ETHUSDT") {
+		switch p.Exchange {
+		case common.ExchangeLighter:
+			lighter = p
+		case common.ExchangeBinance:
+			binance = p
+		}
+	}
+	if lighter == nil || binance == nil {
+		t.Fatal("ETHUSDC and ETHUSDT not indexed under the same standard symbol")
+	}
+	if lighter.QuoteCurrency != common.QuoteCurrencyUSDC || lighter.OriginalBidPrice != 2004 {
+		t.Fatalf("lighter quote = %s original bid %v", lighter.QuoteCurrency, lighter.OriginalBidPrice)
+	}
+	if math.Abs(lighter.BidPrice-2004*0.998) > 1e-9 {
+		t.Fatalf("normalized bid = %v, want %v", lighter.BidPrice, 2004*0.998)
+	}
+	// 未换算时 Lighter 比 Binance 贵约 0.2%，换算后价差接近 0
+	if gap := (lighter.BidPrice - binance.BidPrice) / binance.BidPrice * 100; math.Abs(gap) > 0.01 {
+		t.Fatalf("normalized gap = %.4f%%, want about 0", gap)
+	}
+}
+
+func TestUpdatePriceKeepsDeclaredQuote(t *testing.T) {
+	ps := NewPriceStore()
+
+	// 声明为USDT报价的 "SUSDE" 不应按后缀识别为 S/USDE
+	if !ps.UpdatePrice(quotedPrice(common.ExchangeLighter, "SUSDE", common.QuoteCurrencyUSDT, 1.19, 1.2)) {
+		t.Fatal("quote rejected")
+	}
+	prices := ps.GetPricesBySymbol("SUSDEUSDT")
+	if len(prices) != 1 {
+		t.Fatalf("SUSDEUSDT prices = %d, want 1 (SUSDT has %d)", len(prices), len(ps.GetPricesBySymbol("SUSDT")))
+	}
+	if p := prices[0]; p.QuoteCurrency != common.QuoteCurrencyUSDT || p.ExchangeRateSource != "IDENTITY" {
+		t.Fatalf("quote = %s via %s, want USDT identity", p.QuoteCurrency, p.ExchangeRateSource)
+	}
+}
@@ -0,0 +1,318 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 比值策略方向
+const (
+	RatioDirectionBuyBase  = "+A-B" // 买入A（A Ask），卖出 B * 系数（B Bid）
+	RatioDirectionSellBase = "-A+B" // 卖出A（A Bid），买入 B * 系数（B Ask）
+)
+
+// defaultRatioThreshold 比值策略的默认套利阈值（千4 = 0.4%）
+const defaultRatioThreshold = 0.4
+
+// RatioStrategy 配对比值策略: A - 系数 * B，例如 STG - 0.08634 * ZRO、AAVE - 12.3 * UNI
+type RatioStrategy struct {
+	Name        string  `json:"name"`                // 策略名称（例如 "STG-ZRO"），同时作为阈值配置的symbol
+	BaseSymbol  string  `json:"base_symbol"`         // A（标准化symbol，例如 STGUSDT）
+	QuoteSymbol string  `json:"quote_symbol"`        // B（标准化symbol，例如 ZROUSDT）
+	Coefficient float64 `json:"coefficient"`         // B 的系数
+	Direction   string  `json:"direction"`           // "+A-B" 或 "-A+B"
+	Threshold   float64 `json:"threshold,omitempty"` // 套利阈值（百分比），未设置时使用默认值0.4（来源 default），可被按symbol配置的阈值覆盖
+}
+
+// DefaultRatioStrategies 默认注册的比值策略
+func DefaultRatioStrategies() []*RatioStrategy {
+	return []*RatioStrategy{
+		{
+			Name:        "STG-ZRO",
+			BaseSymbol:  "STGUSDT",
+			QuoteSymbol: "ZROUSDT",
+			Coefficient: 0.08634,
+			Direction:   RatioDirectionBuyBase,
+		},
+	}
+}
+
+// normalize 校验并标准化策略配置
+func (rs *RatioStrategy) normalize() error {
+	rs.Name = strings.ToUpper(strings.TrimSpace(rs.Name))
+	if rs.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(rs.BaseSymbol) == "" || strings.TrimSpace(rs.QuoteSymbol) == "" {
+		return fmt.Errorf("base_symbol and quote_symbol are required")
+	}
+	rs.BaseSymbol = NormalizeThresholdSymbol(rs.BaseSymbol)
+	rs.QuoteSymbol = NormalizeThresholdSymbol(rs.QuoteSymbol)
+	if rs.BaseSymbol == rs.QuoteSymbol {
+		return fmt.Errorf("base_symbol and quote_symbol must differ")
+	}
+	if rs.Coefficient <= 0 {
+		return fmt.Errorf("coefficient must be positive, got %v", rs.Coefficient)
+	}
+	if rs.Direction == "" {
+		rs.Direction = RatioDirectionBuyBase
+	}
+	if rs.Direction != RatioDirectionBuyBase && rs.Direction != RatioDirectionSellBase {
+		return fmt.Errorf("direction must be %q or %q", RatioDirectionBuyBase, RatioDirectionSellBase)
+	}
+	if rs.Threshold < 0 {
+		return fmt.Errorf("threshold must not be negative, got %v", rs.Threshold)
+	}
+	return nil
+}
+
+// LoadRatioStrategies 加载比值策略，并设置持久化路径
+// 文件存在时以文件内容为准（通过 /api/strategies 修改后写回文件），否则使用默认策略
+func (ps *PriceStore) LoadRatioStrategies(path string) error {
+	strategies := DefaultRatioStrategies()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read strategies file: %w", err)
+	}
+	if err == nil && len(data) > 0 {
+		strategies = nil
+		if err := json.Unmarshal(data, &strategies); err != nil {
+			return fmt.Errorf("failed to parse strategies file: %w", err)
+		}
+		for _, rs := range strategies {
+			if err := rs.normalize(); err != nil {
+				return fmt.Errorf("invalid strategy %q: %w", rs.Name, err)
+			}
+		}
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.ratioStrategiesFile = path
+	ps.ratioStrategies = strategies
+	return nil
+}
+
+// GetRatioStrategies 获取所有已注册的比值策略
+func (ps *PriceStore) GetRatioStrategies() []RatioStrategy {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := make([]RatioStrategy, 0, len(ps.ratioStrategies))
+	for _, rs := range ps.ratioStrategies {
+		result = append(result, *rs)
+	}
+	return result
+}
+
+// RegisterRatioStrategy 注册比值策略并持久化（同名策略会被替换）
+// 先写文件，写入失败时已注册的策略保持不变
+func (ps *PriceStore) RegisterRatioStrategy(rs RatioStrategy) (*RatioStrategy, error) {
+	if err := rs.normalize(); err != nil {
+		return nil, err
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	registered := &rs
+	strategies := make([]*RatioStrategy, 0, len(ps.ratioStrategies)+1)
+	replaced := false
+	for _, existing := range ps.ratioStrategies {
+		if existing.Name == rs.Name {
+			strategies = append(strategies, registered)
+			replaced = true
+			continue
+		}
+		strategies = append(strategies, existing)
+	}
+	if !replaced {
+		strategies = append(strategies, registered)
+	}
+
+	if err := ps.saveRatioStrategies(strategies); err != nil {
+		return nil, err
+	}
+	ps.ratioStrategies = strategies
+	return registered, nil
+}
+
+// RemoveRatioStrategy 删除比值策略并持久化，返回策略是否存在（写入失败时策略保持不变）
+func (ps *PriceStore) RemoveRatioStrategy(name string) (bool, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for i, existing := range ps.ratioStrategies {
+		if existing.Name == name {
+			strategies := make([]*RatioStrategy, 0, len(ps.ratioStrategies)-1)
+			strategies = append(strategies, ps.ratioStrategies[:i]...)
+			strategies = append(strategies, ps.ratioStrategies[i+1:]...)
+			if err := ps.saveRatioStrategies(strategies); err != nil {
+				return true, err
+			}
+			ps.ratioStrategies = strategies
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// saveRatioStrategies 将 strategies 写入比值策略文件（调用者需要持有 configMu）
+// 未设置文件路径时只保存在内存中
+func (ps *PriceStore) saveRatioStrategies(strategies []*RatioStrategy) error {
+	if ps.ratioStrategiesFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(strategies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode strategies: %w", err)
+	}
+
+	if err := common.WriteFileAtomic(ps.ratioStrategiesFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write strategies file: %w", err)
+	}
+	return nil
+}
+
+// isRatioStrategyName 判断阈值配置的symbol是否属于某个比值策略（调用者需要持有锁）
+func (ps *PriceStore) isRatioStrategyName(symbol string) bool {
+	for _, rs := range ps.ratioStrategies {
+		if NormalizeThresholdSymbol(rs.Name) == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// getRatioLegPrice 获取比值策略单腿的价格（优先 Binance SPOT，其次 Aster SPOT）
+// 注意：此函数不获取锁，调用者需要持有锁
+func (ps *PriceStore) getRatioLegPrice(symbol string) *common.Price {
+	price := ps.getBestPrice(symbol, common.ExchangeBinance, common.MarketTypeSpot)
+	if price == nil {
+		price = ps.getBestPrice(symbol, common.ExchangeAster, common.MarketTypeSpot)
+	}
+	return price
+}
+
+// calculateRatioStrategy 计算比值策略 A - 系数 * B（两腿价格在复制快照时已选好）
+// +A-B: 绝对价差 = B Bid * 系数 - A Ask
+// -A+B: 绝对价差 = A Bid - B Ask * 系数
+// 百分比 = 绝对价差 * 2 / (两腿价格之和) * 100
+func (snap *priceSnapshot) calculateRatioStrategy(rs *RatioStrategy) *CustomStrategy {
+	base := common.ParseSymbol(rs.BaseSymbol).BaseAsset
+	quote := common.ParseSymbol(rs.QuoteSymbol).BaseAsset
+	coef := formatCoefficient(rs.Coefficient)
+
+	strategy := &CustomStrategy{
+		Name:         rs.Name + " 价差套利",
+		StrategyType: rs.Direction,
+		Components:   make([]CustomStrategyToken, 0, 2),
+		Status:       "unavailable",
+	}
+
+	buyBase := rs.Direction == RatioDirectionBuyBase
+	if buyBase {
+		strategy.Description = fmt.Sprintf("买入%s卖出%s的价差套利", base, quote)
+		strategy.Formula = fmt.Sprintf("(%s Bid * %s - %s Ask) * 2 / (%s Bid * %s + %s Ask) * 100",
+			quote, coef, base, quote, coef, base)
+	} else {
+		strategy.Description = fmt.Sprintf("卖出%s买入%s的价差套利", base, quote)
+		strategy.Formula = fmt.Sprintf("(%s Bid - %s Ask * %s) * 2 / (%s Bid + %s Ask * %s) * 100",
+			base, quote, coef, base, quote, coef)
+	}
+
+	basePrice := snap.ratioLegs[rs.BaseSymbol]
+	quotePrice := snap.ratioLegs[rs.QuoteSymbol]
+
+	// +A-B 时A用Ask（买入）、B用Bid（卖出），-A+B 时相反
+	baseValue := ratioLegPrice(basePrice, buyBase)
+	quoteValue := ratioLegPrice(quotePrice, !buyBase)
+
+	strategy.Components = append(strategy.Components,
+		newRatioComponent(base, 1.0, basePrice, baseValue),
+		newRatioComponent(quote, -rs.Coefficient, quotePrice, quoteValue),
+	)
+
+	if basePrice != nil && quotePrice != nil && baseValue > 0 && quoteValue > 0 {
+		scaledQuote := quoteValue * rs.Coefficient
+
+		if buyBase {
+			strategy.Value = scaledQuote - baseValue
+		} else {
+			strategy.Value = baseValue - scaledQuote
+		}
+		if (scaledQuote + baseValue) > 0 {
+			strategy.ValuePercent = strategy.Value * 2 / (scaledQuote + baseValue) * 100
+		}
+
+		strategy.Status = "ready"
+
+		// 使用较新的更新时间
+		strategy.LastUpdated = basePrice.LastUpdated
+		if quotePrice.LastUpdated.After(strategy.LastUpdated) {
+			strategy.LastUpdated = quotePrice.LastUpdated
+		}
+	} else if basePrice != nil || quotePrice != nil {
+		strategy.Status = "partial"
+		if basePrice != nil {
+			strategy.LastUpdated = basePrice.LastUpdated
+		} else {
+			strategy.LastUpdated = quotePrice.LastUpdated
+		}
+	}
+
+	return strategy
+}
+
+// ratioLegPrice 取单腿的成交价格（买入用Ask，卖出用Bid，缺失时使用最新价）
+func ratioLegPrice(price *common.Price, buy bool) float64 {
+	if price == nil {
+		return 0
+	}
+	value := price.BidPrice
+	if buy {
+		value = price.AskPrice
+	}
+	if value == 0 {
+		value = price.Price
+	}
+	return value
+}
+
+// newRatioComponent 构造策略组件信息
+func newRatioComponent(asset string, coefficient float64, price *common.Price, value float64) CustomStrategyToken {
+	if price == nil {
+		return CustomStrategyToken{
+			Symbol:      asset,
+			Coefficient: coefficient,
+			Available:   false,
+		}
+	}
+	return CustomStrategyToken{
+		Symbol:      asset,
+		Coefficient: coefficient,
+		Exchange:    price.Exchange,
+		MarketType:  price.MarketType,
+		Price:       value,
+		Available:   true,
+	}
+}
+
+// formatCoefficient 格式化公式中的系数（去掉多余的0）
+func formatCoefficient(coefficient float64) string {
+	return fmt.Sprintf("%g", coefficient)
+}
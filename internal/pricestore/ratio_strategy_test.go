@@ -0,0 +1,131 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// spotQuote Binance 现货报价
+func spotQuote(symbol string, bid, ask float64) *common.Price {
+	now := time.Now()
+	price := venueQuote(common.ExchangeBinance, symbol, bid, ask, now)
+	price.MarketType = common.MarketTypeSpot
+	return price
+}
+
+// findStrategy 按名称查找计算结果
+func findStrategy(t *testing.T, ps *PriceStore, name string) *CustomStrategy {
+	t.Helper()
+	for _, s := range ps.CalculateCustomStrategies() {
+		if s.Name == name+" 价差套利" {
+			return s
+		}
+	}
+	t.Fatalf("strategy %s not calculated", name)
+	return nil
+}
+
+func TestCustomRatioStrategyValue(t *testing.T) {
+	ps := NewPriceStore()
+	ps.UpdatePrice(spotQuote("AAVEUSDT", 1000, 1001))
+	ps.UpdatePrice(spotQuote("UNIUSDT", 82, 82.1))
+
+	tests := []struct {
+		direction   string
+		wantValue   float64
+		wantPercent float64
+	}{
+		// +A-B: UNI Bid * 12.3 - AAVE Ask = 1008.6 - 1001
+		{RatioDirectionBuyBase, 7.6, 7.6 * 2 / (1008.6 + 1001) * 100},
+		// -A+B: AAVE Bid - UNI Ask * 12.3 = 1000 - 1009.83
+		{RatioDirectionSellBase, -9.83, -9.83 * 2 / (1000 + 1009.83) * 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.direction, func(t *testing.T) {
+			if _, err := ps.RegisterRatioStrategy(RatioStrategy{
+				Name: "aave-uni", BaseSymbol: "AAVE", QuoteSymbol: "uniusdt", Coefficient: 12.3, Direction: tt.direction,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			s := findStrategy(t, ps, "AAVE-UNI")
+			if s.Status != "ready" || s.StrategyType != tt.direction {
+				t.Fatalf("status %s type %s", s.Status, s.StrategyType)
+			}
+			if math.Abs(s.Value-tt.wantValue) > 1e-9 || math.Abs(s.ValuePercent-tt.wantPercent) > 1e-9 {
+				t.Fatalf("value %.6f (%.6f%%), want %.6f (%.6f%%)", s.Value, s.ValuePercent, tt.wantValue, tt.wantPercent)
+			}
+			if len(s.Components) != 2 || s.Components[0].Symbol != "AAVE" || s.Components[1].Coefficient != -12.3 {
+				t.Fatalf("components = %+v", s.Components)
+			}
+		})
+	}
+
+	// 默认的 STG-ZRO 注册仍然存在
+	if len(ps.GetRatioStrategies()) != 2 {
+		t.Fatalf("strategies = %+v, want STG-ZRO plus AAVE-UNI", ps.GetRatioStrategies())
+	}
+}
+
+func TestRatioStrategyPartialLeg(t *testing.T) {
+	ps := NewPriceStore()
+	ps.UpdatePrice(spotQuote("AAVEUSDT", 1000, 1001))
+	if _, err := ps.RegisterRatioStrategy(RatioStrategy{
+		Name: "AAVE-UNI", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 12.3,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s := findStrategy(t, ps, "AAVE-UNI")
+	if s.Status != "partial" || s.Value != 0 || s.Components[1].Available {
+		t.Fatalf("strategy with one leg = %+v", s)
+	}
+}
+
+func TestRatioStrategyValidation(t *testing.T) {
+	ps := NewPriceStore()
+	invalid := []RatioStrategy{
+		{BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 1},
+		{Name: "X", QuoteSymbol: "UNIUSDT", Coefficient: 1},
+		{Name: "X", BaseSymbol: "AAVE", QuoteSymbol: "AAVEUSDT", Coefficient: 1},
+		{Name: "X", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 0},
+		{Name: "X", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 1, Direction: "A-B"},
+		{Name: "X", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 1, Threshold: -1},
+	}
+	for _, rs := range invalid {
+		if _, err := ps.RegisterRatioStrategy(rs); err == nil {
+			t.Errorf("RegisterRatioStrategy(%+v) accepted", rs)
+		}
+	}
+	if n := len(ps.GetRatioStrategies()); n != 1 {
+		t.Fatalf("%d strategies after invalid registrations, want only the default", n)
+	}
+}
+
+func TestRatioStrategiesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strategies.json")
+	ps := NewPriceStore()
+	if err := ps.LoadRatioStrategies(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.RegisterRatioStrategy(RatioStrategy{
+		Name: "AAVE-UNI", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 12.3, Threshold: 0.5,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := ps.RemoveRatioStrategy("stg-zro"); err != nil || !removed {
+		t.Fatalf("remove default: removed=%v err=%v", removed, err)
+	}
+
+	reloaded := NewPriceStore()
+	if err := reloaded.LoadRatioStrategies(path); err != nil {
+		t.Fatal(err)
+	}
+	got := reloaded.GetRatioStrategies()
+	want := RatioStrategy{Name: "AAVE-UNI", BaseSymbol: "AAVEUSDT", QuoteSymbol: "UNIUSDT", Coefficient: 12.3,
+		Direction: RatioDirectionBuyBase, Threshold: 0.5}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("reloaded strategies = %+v, want [%+v]", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSeqMonotonicUnderConcurrentUpdates(t *testing.T) {
+	ps := NewPriceStore()
+	symbols := snapshotSymbols(10)
+	exchanges := []common.Exchange{common.ExchangeBinance, common.ExchangeLighter, common.ExchangeAster}
+	const rounds = 50
+
+	// 增量轮询方与写入方并发：每次返回的 seq 不回退，返回的报价都在 (since, seq] 内且按序列号升序
+	stop := make(chan struct{})
+	pollErr := make(chan string, 1)
+	var pollWG sync.WaitGroup
+	pollWG.Add(1)
+	go func() {
+		defer pollWG.Done()
+		var since uint64
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			prices, seq := ps.GetPricesSince(since)
+			if seq < since {
+				pollErr <- "seq went backwards"
+				return
+			}
+			var last uint64
+			for _, price := range prices {
+				if price.Seq <= since || price.Seq > seq || price.Seq <= last {
+					pollErr <- "price seq outside (since, seq] or out of order"
+					return
+				}
+				last = price.Seq
+			}
+			since = seq
+		}
+	}()
+
+	var wg sync.WaitGroup
+	base := time.Now()
+	for _, exchange := range exchanges {
+		wg.Add(1)
+		go func(exchange common.Exchange) {
+			defer wg.Done()
+			for n := 1; n <= rounds; n++ {
+				for _, symbol := range symbols {
+					if !ps.UpdatePrice(snapshotQuote(exchange, symbol, n, base)) {
+						t.Errorf("update %s %s #%d rejected", exchange, symbol, n)
+					}
+				}
+			}
+		}(exchange)
+	}
+	wg.Wait()
+	close(stop)
+	pollWG.Wait()
+	select {
+	case msg := <-pollErr:
+		t.Fatal(msg)
+	default:
+	}
+
+	// 每次接受的更新恰好占用一个序列号
+	want := uint64(len(exchanges) * len(symbols) * rounds)
+	if got := ps.CurrentSeq(); got != want {
+		t.Fatalf("seq = %d after %d accepted updates", got, want)
+	}
+	prices, seq := ps.GetPricesSince(0)
+	if seq != want || len(prices) != len(exchanges)*len(symbols) {
+		t.Fatalf("GetPricesSince(0) = %d prices at seq %d", len(prices), seq)
+	}
+	seen := make(map[uint64]bool)
+	for _, price := range prices {
+		if seen[price.Seq] {
+			t.Fatalf("duplicate seq %d", price.Seq)
+		}
+		seen[price.Seq] = true
+	}
+}
+
+func TestGetPricesSinceFilters(t *testing.T) {
+	ps := NewPriceStore()
+	base := time.Now()
+	ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, "BTCUSDT", 1, base)) // seq 1
+	ps.UpdatePrice(snapshotQuote(common.ExchangeLighter, "BTCUSDT", 1, base)) // seq 2
+	ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, "ETHUSDT", 1, base)) // seq 3
+	ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, "BTCUSDT", 2, base)) // seq 4，覆盖 seq 1
+
+	// 旧数据被拒绝，不占用序列号
+	if ps.UpdatePrice(snapshotQuote(common.ExchangeLighter, "BTCUSDT", 0, base.Add(-time.Minute))) {
+		t.Fatal("older quote accepted")
+	}
+
+	tests := []struct {
+		since    uint64
+		wantSeqs []uint64
+	}{
+		{0, []uint64{2, 3, 4}},
+		{1, []uint64{2, 3, 4}},
+		{2, []uint64{3, 4}},
+		{3, []uint64{4}},
+		{4, nil},
+		{100, nil},
+	}
+	for _, tt := range tests {
+		prices, seq := ps.GetPricesSince(tt.since)
+		if seq != 4 {
+			t.Fatalf("since %d: seq = %d, want 4", tt.since, seq)
+		}
+		if len(prices) != len(tt.wantSeqs) {
+			t.Fatalf("since %d: got %d prices, want seqs %v", tt.since, len(prices), tt.wantSeqs)
+		}
+		for i, price := range prices {
+			if price.Seq != tt.wantSeqs[i] {
+				t.Fatalf("since %d: price %d has seq %d, want %v", tt.since, i, price.Seq, tt.wantSeqs)
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// FillLeg 单边成交模拟结果
+type FillLeg struct {
+	Exchange       common.Exchange   `json:"exchange"`
+	MarketType     common.MarketType `json:"market_type"`
+	Symbol         string            `json:"symbol"`          // 交易所原始symbol
+	AvgPrice       float64           `json:"avg_price"`       // 成交均价（USDT标准化后）
+	FilledQty      float64           `json:"filled_qty"`      // 成交数量
+	FilledNotional float64           `json:"filled_notional"` // 成交金额（USDT）
+	FeePercent     float64           `json:"fee_percent"`     // taker手续费率（百分比）
+	Fee            float64           `json:"fee"`             // 手续费（USDT）
+	Levels         int               `json:"levels"`          // 成交用到的盘口档数
+	DepthKnown     bool              `json:"depth_known"`     // 是否有盘口数量（没有时假设按最优价全部成交）
+}
+
+// FillSimulation 按给定金额在最优买入/卖出场所逐档成交的模拟结果
+type FillSimulation struct {
+	Symbol           string    `json:"symbol"`
+	Notional         float64   `json:"notional"`     // 请求的买入金额（USDT）
+	Buy              *FillLeg  `json:"buy"`          // 买入场所（与卖出场所不同）
+	Sell             *FillLeg  `json:"sell"`         // 卖出相同数量的场所
+	FullyFilled      bool      `json:"fully_filled"` // 盘口数量是否足够成交全部金额
+	GrossProfit      float64   `json:"gross_profit"`
+	TotalFees        float64   `json:"total_fees"`
+	NetProfit        float64   `json:"net_profit"`
+	NetProfitPercent float64   `json:"net_profit_percent"` // 净利润 / 买入金额
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// SetTakerFees 设置各交易所taker手续费率（百分比，例如 0.1 表示千1）
+func (ps *PriceStore) SetTakerFees(fees map[common.Exchange]float64) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.takerFees = make(map[common.Exchange]float64, len(fees))
+	for exchange, fee := range fees {
+		ps.takerFees[exchange] = fee
+	}
+}
+
+// BookLevel 单档盘口（交易所原始报价货币，SimulateFill 内部按报价的汇率换算为USDT）
+type BookLevel struct {
+	Price float64
+	Size  float64
+}
+
+// DepthSource 获取报价所在场所的多档盘口（买盘价格降序，卖盘价格升序），没有本地订单簿时返回 false
+type DepthSource func(price *common.Price) (bids, asks []BookLevel, ok bool)
+
+// SimulateFill 模拟按 notional 金额买入并在另一场所卖出相同数量，计算均价、手续费和净利润
+// depth 不为nil且场所有本地订单簿时逐档成交，否则只按最优档位成交；盘口数量不足时只成交可成交的部分（FullyFilled=false）
+// 最优买入和卖出场所相同时，选择价差最大的另一组不同场所
+func (ps *PriceStore) SimulateFill(symbol string, notional float64, depth DepthSource) (*FillSimulation, error) {
+	if notional <= 0 {
+		return nil, fmt.Errorf("notional must be positive, got %v", notional)
+	}
+
+	standardSymbol := NormalizeThresholdSymbol(symbol)
+
+	// 持锁只复制报价和费率，读取盘口在锁外进行
+	ps.mu.RLock()
+	priceMap := ps.bySymbol[standardSymbol]
+	if len(priceMap) == 0 {
+		ps.mu.RUnlock()
+		return nil, fmt.Errorf("no prices for %s", standardSymbol)
+	}
+	var asks, bids []*common.Price
+	for _, price := range priceMap {
+		// 只考虑60秒内的活跃数据
+		if time.Since(price.LastUpdated) > 60*time.Second {
+			continue
+		}
+		copied := *price
+		if copied.AskPrice > 0 {
+			asks = append(asks, &copied)
+		}
+		if copied.BidPrice > 0 {
+			bids = append(bids, &copied)
+		}
+	}
+	fees := make(map[common.Exchange]float64, len(ps.takerFees))
+	for exchange, fee := range ps.takerFees {
+		fees[exchange] = fee
+	}
+	ps.mu.RUnlock()
+
+	buyPrice, sellPrice := bestFillVenues(asks, bids)
+	if buyPrice == nil || sellPrice == nil {
+		return nil, fmt.Errorf("no active bid/ask on two different venues for %s", standardSymbol)
+	}
+
+	askLevels, askDepth := fillLevels(buyPrice, depth, false)
+	bidLevels, bidDepth := fillLevels(sellPrice, depth, true)
+
+	// 买入：按金额逐档吃卖盘；卖出：卖出相同数量，买盘不足时按可卖数量重新计算买入
+	qty, spent := walkLevelsByNotional(askLevels, notional)
+	fullyFilled := spent >= notional*(1-1e-9)
+	if bidSize := sumLevelSize(bidLevels); bidSize < qty {
+		qty = bidSize
+		fullyFilled = false
+	}
+	buyNotional, buyUsed := walkLevelsByQty(askLevels, qty)
+	sellNotional, sellUsed := walkLevelsByQty(bidLevels, qty)
+
+	buy := newFillLeg(buyPrice, fees[buyPrice.Exchange], qty, buyNotional, buyUsed, askDepth)
+	sell := newFillLeg(sellPrice, fees[sellPrice.Exchange], qty, sellNotional, sellUsed, bidDepth)
+
+	gross := sell.FilledNotional - buy.FilledNotional
+	totalFees := buy.Fee + sell.Fee
+	net := gross - totalFees
+
+	sim := &FillSimulation{
+		Symbol:      standardSymbol,
+		Notional:    notional,
+		Buy:         buy,
+		Sell:        sell,
+		FullyFilled: fullyFilled,
+		GrossProfit: gross,
+		TotalFees:   totalFees,
+		NetProfit:   net,
+		Timestamp:   time.Now().UTC(),
+	}
+	if buy.FilledNotional > 0 {
+		sim.NetProfitPercent = net / buy.FilledNotional * 100
+	}
+	return sim, nil
+}
+
+// bestFillVenues 选择 bid/ask 比值最大的一组不同场所（买入ask，卖出bid），同场所的最优买卖价不能互相成交
+func bestFillVenues(asks, bids []*common.Price) (buy, sell *common.Price) {
+	// 排序保证并列时结果稳定
+	sort.Slice(asks, func(i, j int) bool { return fillVenueKey(asks[i]) < fillVenueKey(asks[j]) })
+	sort.Slice(bids, func(i, j int) bool { return fillVenueKey(bids[i]) < fillVenueKey(bids[j]) })
+
+	bestRatio := 0.0
+	for _, ask := range asks {
+		for _, bid := range bids {
+			if ask.Exchange == bid.Exchange && ask.MarketType == bid.MarketType {
+				continue
+			}
+			if ratio := bid.BidPrice / ask.AskPrice; buy == nil || ratio > bestRatio {
+				buy, sell, bestRatio = ask, bid, ratio
+			}
+		}
+	}
+	return buy, sell
+}
+
+// fillVenueKey 场所排序键
+func fillVenueKey(price *common.Price) string {
+	return string(price.Exchange) + "_" + string(price.MarketType)
+}
+
+// fillLevels 获取一侧盘口（已换算为USDT），没有订单簿时退化为最优档位
+// 返回的 depthKnown 表示是否有盘口数量；最优档位数量未知时按该价格无限量成交
+func fillLevels(price *common.Price, depth DepthSource, bidSide bool) ([]BookLevel, bool) {
+	if depth != nil {
+		if bids, asks, ok := depth(price); ok {
+			levels := asks
+			if bidSide {
+				levels = bids
+			}
+			if len(levels) > 0 {
+				rate := price.ExchangeRate
+				if rate <= 0 {
+					rate = 1
+				}
+				converted := make([]BookLevel, 0, len(levels))
+				for _, level := range levels {
+					if level.Price > 0 && level.Size > 0 {
+						converted = append(converted, BookLevel{Price: level.Price * rate, Size: level.Size})
+					}
+				}
+				if len(converted) > 0 {
+					return converted, true
+				}
+			}
+		}
+	}
+
+	if bidSide {
+		return []BookLevel{{Price: price.BidPrice, Size: topOfBookSize(price.BidQty)}}, price.BidQty > 0
+	}
+	return []BookLevel{{Price: price.AskPrice, Size: topOfBookSize(price.AskQty)}}, price.AskQty > 0
+}
+
+// topOfBookSize 最优档位数量，未知时视为无限
+func topOfBookSize(qty float64) float64 {
+	if qty > 0 {
+		return qty
+	}
+	return math.Inf(1)
+}
+
+// sumLevelSize 各档数量合计
+func sumLevelSize(levels []BookLevel) float64 {
+	total := 0.0
+	for _, level := range levels {
+		total += level.Size
+	}
+	return total
+}
+
+// walkLevelsByNotional 按金额逐档成交，返回成交数量和成交金额
+func walkLevelsByNotional(levels []BookLevel, notional float64) (qty, spent float64) {
+	for _, level := range levels {
+		if spent >= notional {
+			break
+		}
+		take := math.Min(level.Size, (notional-spent)/level.Price)
+		qty += take
+		spent += take * level.Price
+	}
+	return qty, spent
+}
+
+// walkLevelsByQty 按数量逐档成交，返回成交金额和使用的档数（调用方保证盘口数量足够）
+func walkLevelsByQty(levels []BookLevel, qty float64) (notional float64, used int) {
+	remaining := qty
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		take := math.Min(level.Size, remaining)
+		notional += take * level.Price
+		remaining -= take
+		used++
+	}
+	return notional, used
+}
+
+// newFillLeg 构造单边成交结果
+func newFillLeg(price *common.Price, feePercent, qty, filledNotional float64, levels int, depthKnown bool) *FillLeg {
+	leg := &FillLeg{
+		Exchange:       price.Exchange,
+		MarketType:     price.MarketType,
+		Symbol:         price.Symbol,
+		FilledQty:      qty,
+		FilledNotional: filledNotional,
+		FeePercent:     feePercent,
+		Fee:            filledNotional * feePercent / 100,
+		Levels:         levels,
+		DepthKnown:     depthKnown,
+	}
+	if qty > 0 {
+		leg.AvgPrice = filledNotional / qty
+	}
+	return leg
+}
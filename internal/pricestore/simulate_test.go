@@ -0,0 +1,117 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+// lighterBook 只有 Lighter 有本地订单簿的深度源
+func lighterBook(bids, asks []BookLevel) DepthSource {
+	return func(price *common.Price) ([]BookLevel, []BookLevel, bool) {
+		if price.Exchange != common.ExchangeLighter {
+			return nil, nil, false
+		}
+		return bids, asks, true
+	}
+}
+
+func TestSimulateFillWalksBookLevels(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetTakerFees(map[common.Exchange]float64{common.ExchangeBinance: 0.1})
+	now := time.Now()
+	ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 99.9, 100, now, now))
+	ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 101, 101.1, now, now))
+
+	depth := lighterBook(
+		[]BookLevel{{Price: 101, Size: 1}, {Price: 100.5, Size: 2}, {Price: 100, Size: 5}},
+		[]BookLevel{{Price: 101.1, Size: 1}},
+	)
+
+	tests := []struct {
+		name           string
+		notional       float64
+		wantQty        float64
+		wantSell       float64
+		wantSellLevels int
+		wantFull       bool
+	}{
+		{"top level only", 100, 1, 101, 1, true},
+		{"walks two bid levels", 300, 3, 101 + 2*100.5, 2, true},
+		{"bids exhausted", 1000, 8, 101 + 2*100.5 + 5*100, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := ps.SimulateFill("BTC", tt.notional, depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sim.Buy.Exchange != common.ExchangeBinance || sim.Sell.Exchange != common.ExchangeLighter {
+				t.Fatalf("buy %s sell %s, want Binance -> Lighter", sim.Buy.Exchange, sim.Sell.Exchange)
+			}
+			if math.Abs(sim.Sell.FilledQty-tt.wantQty) > 1e-9 || math.Abs(sim.Buy.FilledQty-tt.wantQty) > 1e-9 {
+				t.Fatalf("qty buy=%v sell=%v, want %v", sim.Buy.FilledQty, sim.Sell.FilledQty, tt.wantQty)
+			}
+			if math.Abs(sim.Sell.FilledNotional-tt.wantSell) > 1e-9 || sim.Sell.Levels != tt.wantSellLevels || !sim.Sell.DepthKnown {
+				t.Fatalf("sell = %+v, want notional %v over %d levels", sim.Sell, tt.wantSell, tt.wantSellLevels)
+			}
+			if math.Abs(sim.Sell.AvgPrice-tt.wantSell/tt.wantQty) > 1e-9 {
+				t.Fatalf("sell avg = %v, want %v", sim.Sell.AvgPrice, tt.wantSell/tt.wantQty)
+			}
+			wantBuy := tt.wantQty * 100
+			if math.Abs(sim.Buy.FilledNotional-wantBuy) > 1e-9 || math.Abs(sim.Buy.Fee-wantBuy*0.001) > 1e-9 {
+				t.Fatalf("buy = %+v, want notional %v with 0.1%% fee", sim.Buy, wantBuy)
+			}
+			if sim.FullyFilled != tt.wantFull {
+				t.Fatalf("fully filled = %v, want %v", sim.FullyFilled, tt.wantFull)
+			}
+			if math.Abs(sim.NetProfit-(tt.wantSell-wantBuy-wantBuy*0.001)) > 1e-9 {
+				t.Fatalf("net profit = %v", sim.NetProfit)
+			}
+		})
+	}
+
+	// 没有深度源时退化为最优档位（Lighter 最优买价数量 10）
+	sim, err := ps.SimulateFill("BTC", 300, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sim.Sell.FilledNotional-3*101) > 1e-9 || sim.Sell.Levels != 1 {
+		t.Fatalf("top-of-book sell = %+v, want 3 @ 101", sim.Sell)
+	}
+}
+
+func TestSimulateFillPicksNextBestVenueWhenBestBidAndAskShareVenue(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	// Lighter 同时有最低ask和最高bid，不能自成交
+	ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 100.0, 100.1, now, now))
+	ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 99.8, 100.3, now, now))
+	ps.UpdatePrice(projectionQuote(common.ExchangeAster, 99.7, 100.2, now, now))
+
+	sim, err := ps.SimulateFill("BTC", 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 买 Aster 卖 Lighter（100.0/100.2）优于买 Lighter 卖 Binance（99.8/100.1）
+	if sim.Buy.Exchange != common.ExchangeAster || sim.Sell.Exchange != common.ExchangeLighter {
+		t.Fatalf("buy %s sell %s, want Aster -> Lighter", sim.Buy.Exchange, sim.Sell.Exchange)
+	}
+	if math.Abs(sim.Buy.AvgPrice-100.2) > 1e-9 || math.Abs(sim.Sell.AvgPrice-100.0) > 1e-9 {
+		t.Fatalf("avg prices buy=%v sell=%v", sim.Buy.AvgPrice, sim.Sell.AvgPrice)
+	}
+}
+
+func TestSimulateFillSingleVenue(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	ps.UpdatePrice(projectionQuote(common.ExchangeLighter, 100.0, 100.1, now, now))
+
+	if _, err := ps.SimulateFill("BTC", 100, nil); err == nil {
+		t.Fatal("simulation with a single venue succeeded")
+	}
+	if _, err := ps.SimulateFill("BTC", 0, nil); err == nil {
+		t.Fatal("zero notional accepted")
+	}
+}
@@ -0,0 +1,112 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"log"
+	"sort"
+	"time"
+)
+
+// TickerView 精简的行情视图（只包含UI需要的字段）
+type TickerView struct {
+	Symbol      string             `json:"symbol"` // 交易所原始symbol
+	Exchange    common.Exchange    `json:"exchange"`
+	MarketType  common.MarketType  `json:"market_type"`
+	BidPrice    float64            `json:"bid_price"`
+	AskPrice    float64            `json:"ask_price"`
+	BidQty      float64            `json:"bid_qty"`
+	AskQty      float64            `json:"ask_qty"`
+	Source      common.PriceSource `json:"source"`
+	LastUpdated time.Time          `json:"last_updated"`
+	AgeMs       int64              `json:"age_ms"` // 生成快照时的数据年龄
+}
+
+// TickerSnapshot 不可变的行情快照，生成后不再修改，读取时无需加锁
+type TickerSnapshot struct {
+	GeneratedAt time.Time               `json:"generated_at"`
+	Seq         uint64                  `json:"seq"`       // 生成快照时的存储序列号
+	BySymbol    map[string][]TickerView `json:"by_symbol"` // key: 标准化symbol
+
+	// Prices 同一时刻的完整报价（key 同 BySymbol）；存储中的报价写入后不再修改，这里只共享指针
+	Prices map[string][]*common.Price `json:"-"`
+}
+
+// GetTickerSnapshot 获取最新的行情快照（不获取存储锁）
+// 刷新任务未启动时返回 nil
+func (ps *PriceStore) GetTickerSnapshot() *TickerSnapshot {
+	return ps.snapshot.Load()
+}
+
+// RunSnapshotRefresher 按 interval 刷新行情快照，直到 stopChan 关闭
+// 存储序列号未变化且快照不超过1秒时跳过重建（超过1秒仍重建以刷新 age_ms 和已清理的条目）
+func (ps *PriceStore) RunSnapshotRefresher(interval time.Duration, stopChan <-chan struct{}) {
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[PriceStore] Ticker snapshot refresher started (interval %v)", interval)
+
+	var lastSeq uint64
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			current := ps.snapshot.Load()
+			if current != nil && ps.CurrentSeq() == lastSeq && time.Since(current.GeneratedAt) < time.Second {
+				continue
+			}
+			snap := ps.buildTickerSnapshot()
+			lastSeq = snap.Seq
+			ps.snapshot.Store(snap)
+		}
+	}
+}
+
+// buildTickerSnapshot 在读锁内复制出完整快照，保证所有条目来自同一时刻
+func (ps *PriceStore) buildTickerSnapshot() *TickerSnapshot {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	now := time.Now().UTC()
+	snap := &TickerSnapshot{
+		GeneratedAt: now,
+		Seq:         ps.seq,
+		BySymbol:    make(map[string][]TickerView, len(ps.bySymbol)),
+		Prices:      make(map[string][]*common.Price, len(ps.bySymbol)),
+	}
+
+	for symbol, priceMap := range ps.bySymbol {
+		views := make([]TickerView, 0, len(priceMap))
+		prices := make([]*common.Price, 0, len(priceMap))
+		for _, price := range priceMap {
+			prices = append(prices, price)
+			views = append(views, TickerView{
+				Symbol:      price.Symbol,
+				Exchange:    price.Exchange,
+				MarketType:  price.MarketType,
+				BidPrice:    price.BidPrice,
+				AskPrice:    price.AskPrice,
+				BidQty:      price.BidQty,
+				AskQty:      price.AskQty,
+				Source:      price.Source,
+				LastUpdated: price.LastUpdated,
+				AgeMs:       now.Sub(price.LastUpdated).Milliseconds(),
+			})
+		}
+		// 固定顺序，便于前端比较
+		sort.Slice(views, func(i, j int) bool {
+			if views[i].Exchange != views[j].Exchange {
+				return views[i].Exchange < views[j].Exchange
+			}
+			return views[i].MarketType < views[j].MarketType
+		})
+		snap.BySymbol[symbol] = views
+		snap.Prices[symbol] = prices
+	}
+
+	return snap
+}
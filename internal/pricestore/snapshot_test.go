@@ -0,0 +1,157 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// snapshotQuote 数量和时间戳都编码写入序号 n，读取方可以检查条目是否完整
+func snapshotQuote(exchange common.Exchange, symbol string, n int, base time.Time) *common.Price {
+	ts := base.Add(time.Duration(n) * time.Millisecond)
+	price := projectionQuote(exchange, 100, 100.1, ts, ts)
+	price.Symbol = symbol
+	price.BidQty = float64(n)
+	price.AskQty = float64(n)
+	return price
+}
+
+// hammerUpdates 并发写入直到 stop 关闭
+func hammerUpdates(ps *PriceStore, symbols []string, stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	base := time.Now()
+	for _, exchange := range []common.Exchange{common.ExchangeBinance, common.ExchangeLighter} {
+		wg.Add(1)
+		go func(exchange common.Exchange) {
+			defer wg.Done()
+			for n := 1; ; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, symbol := range symbols {
+					ps.UpdatePrice(snapshotQuote(exchange, symbol, n, base))
+				}
+			}
+		}(exchange)
+	}
+	return &wg
+}
+
+func snapshotSymbols(n int) []string {
+	symbols := make([]string, n)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("C%dUSDT", i)
+	}
+	return symbols
+}
+
+func TestTickerSnapshotConsistentUnderWrites(t *testing.T) {
+	ps := NewPriceStore()
+	stop := make(chan struct{})
+	wg := hammerUpdates(ps, snapshotSymbols(20), stop)
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for ps.CurrentSeq() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var lastSeq uint64
+	for i := 0; i < 200; i++ {
+		snap := ps.buildTickerSnapshot()
+		if snap.Seq < lastSeq {
+			t.Fatalf("snapshot seq went backwards: %d after %d", snap.Seq, lastSeq)
+		}
+		lastSeq = snap.Seq
+
+		for symbol, views := range snap.BySymbol {
+			prices := snap.Prices[symbol]
+			if len(prices) != len(views) {
+				t.Fatalf("%s: %d views but %d prices", symbol, len(views), len(prices))
+			}
+			for _, view := range views {
+				// 同一次写入的 bid/ask 数量必须一起出现
+				if view.BidQty != view.AskQty {
+					t.Fatalf("%s %s: partially updated entry bid_qty=%v ask_qty=%v", symbol, view.Exchange, view.BidQty, view.AskQty)
+				}
+			}
+			for _, price := range prices {
+				if price.Seq > snap.Seq {
+					t.Fatalf("%s %s: price seq %d beyond snapshot seq %d", symbol, price.Exchange, price.Seq, snap.Seq)
+				}
+			}
+		}
+	}
+}
+
+func TestTickerSnapshotRefresherPublishes(t *testing.T) {
+	ps := NewPriceStore()
+	if ps.GetTickerSnapshot() != nil {
+		t.Fatal("snapshot available before the refresher started")
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go ps.RunSnapshotRefresher(5*time.Millisecond, stop)
+
+	ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, "BTCUSDT", 1, time.Now()))
+	deadline := time.Now().Add(time.Second)
+	for {
+		snap := ps.GetTickerSnapshot()
+		if snap != nil && len(snap.BySymbol["BTCUSDT"]) == 1 {
+			if snap.Seq != ps.CurrentSeq() || len(snap.Prices["BTCUSDT"]) != 1 {
+				t.Fatalf("snapshot seq %d prices %d, store seq %d", snap.Seq, len(snap.Prices["BTCUSDT"]), ps.CurrentSeq())
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("refresher did not publish the update")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// benchmarkReadsUnderWrites 在持续写入的同时测量读取开销
+func benchmarkReadsUnderWrites(b *testing.B, read func(ps *PriceStore) int) {
+	ps := NewPriceStore()
+	symbols := snapshotSymbols(500)
+	base := time.Now()
+	for _, symbol := range symbols {
+		ps.UpdatePrice(snapshotQuote(common.ExchangeBinance, symbol, 0, base))
+	}
+	ps.snapshot.Store(ps.buildTickerSnapshot())
+
+	stop := make(chan struct{})
+	wg := hammerUpdates(ps, symbols, stop)
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if read(ps) == 0 {
+			b.Fatal("empty read")
+		}
+	}
+}
+
+// BenchmarkSnapshotReadUnderWrites /api/prices 读快照：不获取存储锁
+func BenchmarkSnapshotReadUnderWrites(b *testing.B) {
+	benchmarkReadsUnderWrites(b, func(ps *PriceStore) int {
+		return len(ps.GetTickerSnapshot().Prices)
+	})
+}
+
+// BenchmarkLockedReadUnderWrites 对比：每次读取都在读锁内复制全部报价
+func BenchmarkLockedReadUnderWrites(b *testing.B) {
+	benchmarkReadsUnderWrites(b, func(ps *PriceStore) int {
+		return len(ps.GetAllPricesBySymbol())
+	})
+}
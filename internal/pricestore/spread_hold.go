@@ -0,0 +1,80 @@
+package pricestore
+
+import (
+	"sync"
+	"time"
+)
+
+// spreadHoldCache 最近一次计算出的各价差（按 symbol + 两腿场所），用于在短暂缺腿时保留上次的值
+// 有自己的锁，不占用 ps.mu
+type spreadHoldCache struct {
+	mu    sync.Mutex
+	grace time.Duration // 0表示不保留
+	last  map[string]*heldSpread
+}
+
+// heldSpread 价差最近一次出现的时间和值
+type heldSpread struct {
+	spread   *Spread
+	lastSeen time.Time
+}
+
+// SetSpreadGrace 设置缺腿价差的保留时长：价差在该时长内重新出现前继续返回上次的值（标记 held），
+// 超过该时长视为真正消失。0表示不保留（默认）
+func (ps *PriceStore) SetSpreadGrace(grace time.Duration) {
+	if grace < 0 {
+		grace = 0
+	}
+
+	ps.spreadHold.mu.Lock()
+	defer ps.spreadHold.mu.Unlock()
+	ps.spreadHold.grace = grace
+	if grace == 0 {
+		ps.spreadHold.last = make(map[string]*heldSpread)
+	}
+}
+
+// HoldMissingSpreads 用 spreads（本轮实时计算的完整结果）刷新缓存，并追加在保留时长内暂时缺失的价差副本
+// 追加的价差 Held 为 true，MissingSince 为最后一次出现的时间；缺失超过保留时长的价差从缓存中移除
+func (ps *PriceStore) HoldMissingSpreads(spreads []*Spread, now time.Time) []*Spread {
+	hold := ps.spreadHold
+	hold.mu.Lock()
+	defer hold.mu.Unlock()
+
+	if hold.grace == 0 {
+		return spreads
+	}
+
+	present := make(map[string]bool, len(spreads))
+	for _, spread := range spreads {
+		key := spreadHoldKey(spread)
+		present[key] = true
+		hold.last[key] = &heldSpread{spread: spread, lastSeen: now}
+	}
+
+	// 输入可能是多个请求共享的快照，追加时不能写入其底层数组
+	spreads = spreads[:len(spreads):len(spreads)]
+	for key, held := range hold.last {
+		if present[key] {
+			continue
+		}
+		if now.Sub(held.lastSeen) > hold.grace {
+			delete(hold.last, key)
+			continue
+		}
+
+		// 返回副本，缓存中的价差保持原样
+		spread := *held.spread
+		lastSeen := held.lastSeen
+		spread.Held = true
+		spread.MissingSince = &lastSeen
+		spreads = append(spreads, &spread)
+	}
+	return spreads
+}
+
+// spreadHoldKey 价差的缓存键：symbol + 买入场所 + 卖出场所
+func spreadHoldKey(spread *Spread) string {
+	return spread.Symbol + "|" + string(spread.BuyExchange) + "_" + string(spread.BuyMarketType) +
+		"|" + string(spread.SellExchange) + "_" + string(spread.SellMarketType)
+}
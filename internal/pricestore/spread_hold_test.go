@@ -0,0 +1,73 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+func holdTestSpread(symbol string, percent float64) *Spread {
+	return &Spread{
+		Symbol:      symbol,
+		BuyExchange: common.ExchangeBinance, BuyMarketType: common.MarketTypeFuture,
+		SellExchange: common.ExchangeLighter, SellMarketType: common.MarketTypeFuture,
+		SpreadPercent: percent,
+	}
+}
+
+func TestHoldMissingSpreads(t *testing.T) {
+	ps := NewPriceStore()
+	ps.SetSpreadGrace(5 * time.Second)
+	start := time.Unix(1700000000, 0)
+
+	btc, eth := holdTestSpread("BTCUSDT", 0.3), holdTestSpread("ETHUSDT", 0.2)
+	if got := ps.HoldMissingSpreads([]*Spread{btc, eth}, start); len(got) != 2 {
+		t.Fatalf("%d spreads on the first pass, want 2", len(got))
+	}
+
+	// ETH 缺一腿：保留时长内返回上次值的副本，标记 held
+	current := []*Spread{holdTestSpread("BTCUSDT", 0.4)}
+	got := ps.HoldMissingSpreads(current, start.Add(5*time.Second))
+	if len(got) != 2 || got[0] != current[0] {
+		t.Fatalf("spreads within the grace period = %d, want the live BTC and the held ETH", len(got))
+	}
+	held := got[1]
+	if held == eth || held.Symbol != "ETHUSDT" || !held.Held || held.SpreadPercent != 0.2 ||
+		held.MissingSince == nil || !held.MissingSince.Equal(start) {
+		t.Fatalf("held spread = %+v", held)
+	}
+	if eth.Held || eth.MissingSince != nil {
+		t.Fatal("held copy modified the cached spread")
+	}
+	if current[0].Held {
+		t.Fatal("live spread marked held")
+	}
+
+	// 超过保留时长后丢弃，之后也不再出现
+	if got := ps.HoldMissingSpreads(current, start.Add(5*time.Second+time.Millisecond)); len(got) != 1 {
+		t.Fatalf("%d spreads after the grace period, want only BTC", len(got))
+	}
+	if got := ps.HoldMissingSpreads(nil, start.Add(6*time.Second)); len(got) != 1 || got[0].Symbol != "BTCUSDT" {
+		t.Fatalf("spreads after ETH expired = %d", len(got))
+	}
+}
+
+func TestHoldMissingSpreadsReappearAndDisabled(t *testing.T) {
+	ps := NewPriceStore()
+	start := time.Unix(1700000000, 0)
+
+	// 默认不保留
+	ps.HoldMissingSpreads([]*Spread{holdTestSpread("BTCUSDT", 0.3)}, start)
+	if got := ps.HoldMissingSpreads(nil, start.Add(time.Second)); len(got) != 0 {
+		t.Fatalf("%d spreads held with grace 0", len(got))
+	}
+
+	// 重新出现时刷新最后出现时间，保留时长从新的时间开始计算
+	ps.SetSpreadGrace(5 * time.Second)
+	ps.HoldMissingSpreads([]*Spread{holdTestSpread("BTCUSDT", 0.3)}, start)
+	ps.HoldMissingSpreads([]*Spread{holdTestSpread("BTCUSDT", 0.5)}, start.Add(4*time.Second))
+	got := ps.HoldMissingSpreads(nil, start.Add(8*time.Second))
+	if len(got) != 1 || got[0].SpreadPercent != 0.5 || !got[0].MissingSince.Equal(start.Add(4*time.Second)) {
+		t.Fatalf("spreads after reappearing = %+v", got)
+	}
+}
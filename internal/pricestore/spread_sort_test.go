@@ -0,0 +1,41 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// agedSpread 两腿报价分别在 buyAge、sellAge 之前更新的价差
+func agedSpread(symbol string, percent float64, now time.Time, buyAge, sellAge time.Duration) *Spread {
+	return &Spread{
+		Symbol:        symbol,
+		SpreadPercent: percent,
+		BuyQuote:      &common.Price{LastUpdated: now.Add(-buyAge)},
+		SellQuote:     &common.Price{LastUpdated: now.Add(-sellAge)},
+	}
+}
+
+func TestSortSpreadsByPercentFreshnessTiebreak(t *testing.T) {
+	now := time.Now()
+	spreads := []*Spread{
+		agedSpread("STALE", 0.5, now, time.Second, 10*time.Second), // 较旧一腿决定年龄
+		{Symbol: "NOQUOTE", SpreadPercent: 0.5},
+		agedSpread("FRESH", 0.5, now, time.Second, 2*time.Second),
+		agedSpread("LOW", 0.2, now, 0, 0),
+		agedSpread("HIGH", 0.8, now, time.Minute, time.Minute),
+		agedSpread("MIDDLE", 0.5, now, 5*time.Second, time.Second),
+	}
+
+	NewPriceStore().sortSpreadsByPercent(spreads)
+	got := make([]string, 0, len(spreads))
+	for _, spread := range spreads {
+		got = append(got, spread.Symbol)
+	}
+	// 价差优先；价差相同时两腿数据较新的在前，缺少报价的视为最旧
+	want := []string{"HIGH", "FRESH", "MIDDLE", "STALE", "NOQUOTE", "LOW"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+}
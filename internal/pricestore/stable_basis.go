@@ -0,0 +1,285 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stableBasisMaxAge 参与计算的报价最大年龄，过期的腿视为缺失
+const stableBasisMaxAge = 60 * time.Second
+
+// StableBasisConfig 稳定币三角一致性检查配置
+type StableBasisConfig struct {
+	Quotes       []common.QuoteCurrency // 检查的稳定币（相对USDT），例如 USDC、FDUSD
+	Venues       []string               // 只检查这些场所（EXCHANGE 或 EXCHANGE_MARKETTYPE），为空表示全部
+	ThresholdBps float64                // 场所隐含汇率偏离共识超过该值（基点）时告警
+}
+
+// DefaultStableBasisConfig 默认配置：检查 USDC 和 FDUSD，偏离20bps告警
+func DefaultStableBasisConfig() *StableBasisConfig {
+	return &StableBasisConfig{
+		Quotes:       []common.QuoteCurrency{common.QuoteCurrencyUSDC, common.QuoteCurrencyFDUSD},
+		ThresholdBps: 20,
+	}
+}
+
+// StableBasis 单个场所某稳定币的隐含汇率和偏离
+// 隐含汇率由同一场所的 XUSDT / XQUOTE 推出（1 QUOTE = ImpliedRate USDT），取所有共同base的中位数
+type StableBasis struct {
+	Venue              string               `json:"venue"` // EXCHANGE_MARKETTYPE
+	Quote              common.QuoteCurrency `json:"quote"`
+	ImpliedRate        float64              `json:"implied_rate"`
+	Bases              int                  `json:"bases"`                           // 参与计算的base数量
+	DirectRate         float64              `json:"direct_rate,omitempty"`           // 该场所直接的 QUOTEUSDT 中间价，未上线时为0
+	ImpliedVsDirectBps float64              `json:"implied_vs_direct_bps,omitempty"` // 隐含汇率相对直接汇率的偏离（基点）
+	ConsensusRate      float64              `json:"consensus_rate"`                  // 所有场所观测值的中位数，观测值不足2个时为0
+	DeviationBps       float64              `json:"deviation_bps"`                   // 隐含汇率相对共识的偏离（基点）
+	Flagged            bool                 `json:"flagged"`                         // 偏离超过阈值
+}
+
+// StableNormalizationCheck 标准化层使用的汇率与共识的比较
+type StableNormalizationCheck struct {
+	Quote         common.QuoteCurrency `json:"quote"`
+	Rate          float64              `json:"rate"` // 汇率管理器当前使用的汇率
+	Source        string               `json:"source"`
+	ConsensusRate float64              `json:"consensus_rate"`
+	DeviationBps  float64              `json:"deviation_bps"`
+	Flagged       bool                 `json:"flagged"`
+}
+
+// StableBasisReport 一轮稳定币三角一致性检查的结果（/api/stable-basis）
+type StableBasisReport struct {
+	Venues        []*StableBasis              `json:"venues"`
+	Normalization []*StableNormalizationCheck `json:"normalization"`
+	ThresholdBps  float64                     `json:"threshold_bps"`
+	UpdatedAt     time.Time                   `json:"updated_at"`
+}
+
+// stableBasisChecker 检查配置和最近一轮结果（有自己的锁，不占用 ps.mu）
+type stableBasisChecker struct {
+	mu     sync.RWMutex
+	cfg    *StableBasisConfig
+	report *StableBasisReport
+}
+
+// SetStableBasisConfig 设置稳定币三角一致性检查配置（nil表示恢复默认）
+func (ps *PriceStore) SetStableBasisConfig(cfg *StableBasisConfig) {
+	if cfg == nil {
+		cfg = DefaultStableBasisConfig()
+	}
+
+	ps.stableBasis.mu.Lock()
+	defer ps.stableBasis.mu.Unlock()
+	ps.stableBasis.cfg = cfg
+}
+
+// RunStableBasisChecker 按 interval 检查各场所的稳定币隐含汇率，直到 stopChan 关闭
+func (ps *PriceStore) RunStableBasisChecker(interval time.Duration, stopChan <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			ps.checkStableBasis(time.Now().UTC())
+		}
+	}
+}
+
+// GetStableBasis 获取最近一轮检查结果，尚未检查时返回nil
+func (ps *PriceStore) GetStableBasis() *StableBasisReport {
+	ps.stableBasis.mu.RLock()
+	defer ps.stableBasis.mu.RUnlock()
+	return ps.stableBasis.report
+}
+
+// checkStableBasis 基于当前报价执行一轮检查，偏离超过阈值的场所和标准化汇率记录告警
+func (ps *PriceStore) checkStableBasis(now time.Time) *StableBasisReport {
+	ps.stableBasis.mu.RLock()
+	cfg := ps.stableBasis.cfg
+	ps.stableBasis.mu.RUnlock()
+
+	ps.mu.RLock()
+	prices := make([]*common.Price, 0)
+	for _, exchangePrices := range ps.byExchange {
+		for _, price := range exchangePrices {
+			prices = append(prices, price)
+		}
+	}
+	ps.mu.RUnlock()
+
+	rates := make(map[common.QuoteCurrency]*ExchangeRate, len(cfg.Quotes))
+	for _, quote := range cfg.Quotes {
+		rates[quote] = ps.exchangeRateManager.GetRate(quote)
+	}
+
+	report := computeStableBasis(prices, rates, cfg, now)
+	for _, basis := range report.Venues {
+		if basis.Flagged {
+			common.DedupLog.Printf("stable-basis", "[StableBasis] %s implied %s rate %.5f deviates %.1fbps from consensus %.5f (%d bases)",
+				basis.Venue, basis.Quote, basis.ImpliedRate, basis.DeviationBps, basis.ConsensusRate, basis.Bases)
+		}
+	}
+	for _, check := range report.Normalization {
+		if check.Flagged {
+			common.DedupLog.Printf("stable-basis", "[StableBasis] %s normalization rate %.5f (%s) deviates %.1fbps from consensus %.5f",
+				check.Quote, check.Rate, check.Source, check.DeviationBps, check.ConsensusRate)
+		}
+	}
+
+	ps.stableBasis.mu.Lock()
+	ps.stableBasis.report = report
+	ps.stableBasis.mu.Unlock()
+	return report
+}
+
+// computeStableBasis 计算各场所的稳定币隐含汇率、共识汇率和偏离
+// 缺少任一腿（没有共同base、报价过期、只有单边盘口）的场所不产生结果，不影响其他场所
+func computeStableBasis(prices []*common.Price, rates map[common.QuoteCurrency]*ExchangeRate, cfg *StableBasisConfig, now time.Time) *StableBasisReport {
+	// 场所 -> 报价货币 -> base -> 原始中间价
+	mids := make(map[string]map[common.QuoteCurrency]map[string]float64)
+	for _, price := range prices {
+		if price.AppliedMultiplier > 0 || now.Sub(price.LastUpdated) > stableBasisMaxAge {
+			continue
+		}
+		venue := string(price.Exchange) + "_" + string(price.MarketType)
+		if !stableBasisVenueEnabled(cfg.Venues, price.Exchange, venue) {
+			continue
+		}
+		info := common.ParseSymbol(price.Symbol)
+		if info.Inverse {
+			continue
+		}
+		mid := rawMid(price, info.QuoteAsset)
+		if mid <= 0 {
+			continue
+		}
+		if mids[venue] == nil {
+			mids[venue] = make(map[common.QuoteCurrency]map[string]float64)
+		}
+		if mids[venue][info.QuoteAsset] == nil {
+			mids[venue][info.QuoteAsset] = make(map[string]float64)
+		}
+		mids[venue][info.QuoteAsset][info.BaseAsset] = mid
+	}
+
+	venues := make([]string, 0, len(mids))
+	for venue := range mids {
+		venues = append(venues, venue)
+	}
+	sort.Strings(venues)
+
+	report := &StableBasisReport{
+		Venues:        make([]*StableBasis, 0),
+		Normalization: make([]*StableNormalizationCheck, 0),
+		ThresholdBps:  cfg.ThresholdBps,
+		UpdatedAt:     now,
+	}
+	for _, quote := range cfg.Quotes {
+		results := make([]*StableBasis, 0)
+		observations := make([]float64, 0)
+		for _, venue := range venues {
+			usdtLegs := mids[venue][common.QuoteCurrencyUSDT]
+			quoteLegs := mids[venue][quote]
+
+			implied := make([]float64, 0)
+			for base, quoteMid := range quoteLegs {
+				if usdtMid, ok := usdtLegs[base]; ok {
+					implied = append(implied, usdtMid/quoteMid)
+				}
+			}
+			if len(implied) == 0 {
+				continue
+			}
+
+			basis := &StableBasis{
+				Venue:       venue,
+				Quote:       quote,
+				ImpliedRate: medianOf(implied),
+				Bases:       len(implied),
+				DirectRate:  usdtLegs[string(quote)], // 例如 USDCUSDT 的 base 为 USDC
+			}
+			observations = append(observations, basis.ImpliedRate)
+			if basis.DirectRate > 0 {
+				basis.ImpliedVsDirectBps = bpsDiff(basis.ImpliedRate, basis.DirectRate)
+				observations = append(observations, basis.DirectRate)
+			}
+			results = append(results, basis)
+		}
+
+		// 共识需要至少两个独立观测值，否则无法判断哪个场所偏离
+		consensus := 0.0
+		if len(observations) >= 2 {
+			consensus = medianOf(observations)
+		}
+		for _, basis := range results {
+			if consensus > 0 {
+				basis.ConsensusRate = consensus
+				basis.DeviationBps = bpsDiff(basis.ImpliedRate, consensus)
+				basis.Flagged = math.Abs(basis.DeviationBps) > cfg.ThresholdBps
+			}
+			report.Venues = append(report.Venues, basis)
+		}
+
+		if rate := rates[quote]; rate != nil && consensus > 0 {
+			check := &StableNormalizationCheck{
+				Quote:         quote,
+				Rate:          rate.Rate,
+				Source:        rate.Source,
+				ConsensusRate: consensus,
+				DeviationBps:  bpsDiff(rate.Rate, consensus),
+			}
+			check.Flagged = math.Abs(check.DeviationBps) > cfg.ThresholdBps
+			report.Normalization = append(report.Normalization, check)
+		}
+	}
+	return report
+}
+
+// rawMid 报价货币计价的原始中间价（非USDT报价入库时已按汇率标准化，使用转换前的价格）
+func rawMid(price *common.Price, quote common.QuoteCurrency) float64 {
+	bid, ask := price.BidPrice, price.AskPrice
+	if quote != common.QuoteCurrencyUSDT {
+		bid, ask = price.OriginalBidPrice, price.OriginalAskPrice
+	}
+	if bid <= 0 || ask <= 0 {
+		return 0
+	}
+	return (bid + ask) / 2
+}
+
+// stableBasisVenueEnabled 场所是否在检查范围内
+func stableBasisVenueEnabled(venues []string, exchange common.Exchange, venue string) bool {
+	if len(venues) == 0 {
+		return true
+	}
+	for _, v := range venues {
+		v = strings.TrimSpace(v)
+		if strings.EqualFold(v, string(exchange)) || strings.EqualFold(v, venue) {
+			return true
+		}
+	}
+	return false
+}
+
+// bpsDiff value 相对 reference 的偏离（基点）
+func bpsDiff(value, reference float64) float64 {
+	return (value/reference - 1) * 10000
+}
+
+// medianOf 中位数（不修改输入，输入不能为空）
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,108 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"testing"
+	"time"
+)
+
+// stableQuote 现货报价，mid 为报价货币计价的原始中间价（非USDT报价同时写入标准化前的价格）
+func stableQuote(exchange common.Exchange, symbol string, mid float64, now time.Time) *common.Price {
+	price := &common.Price{
+		Symbol: symbol, Exchange: exchange, MarketType: common.MarketTypeSpot,
+		BidPrice: mid * 0.9999, AskPrice: mid * 1.0001, LastUpdated: now,
+	}
+	price.OriginalBidPrice, price.OriginalAskPrice = price.BidPrice, price.AskPrice
+	return price
+}
+
+// stableVenues 三个场所的 BTCUSDT/BTCUSDC，Gate 的隐含 USDC 汇率为 gateRate，其余为1
+func stableVenues(gateRate float64, now time.Time) []*common.Price {
+	prices := make([]*common.Price, 0)
+	for exchange, rate := range map[common.Exchange]float64{
+		common.ExchangeBinance: 1,
+		common.ExchangeBybit:   1,
+		common.ExchangeGate:    gateRate,
+	} {
+		prices = append(prices,
+			stableQuote(exchange, "BTCUSDT", 100000, now),
+			stableQuote(exchange, "BTCUSDC", 100000/rate, now))
+	}
+	return prices
+}
+
+func TestStableBasisThreshold(t *testing.T) {
+	now := time.Now()
+	mispriced := bpsDiff(1.005, 1)
+
+	tests := []struct {
+		name         string
+		gateRate     float64
+		thresholdBps float64
+		wantFlagged  bool
+	}{
+		{"50bps mispricing over the default 20bps", 1.005, 20, true},
+		{"deviation equal to the threshold", 1.005, mispriced, false},
+		{"just under the 50bps threshold", 1.0049, 50, false},
+		{"just over the 50bps threshold", 1.0051, 50, true},
+		{"negative deviation over the threshold", 0.9949, 50, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &StableBasisConfig{Quotes: []common.QuoteCurrency{common.QuoteCurrencyUSDC}, ThresholdBps: tt.thresholdBps}
+			report := computeStableBasis(stableVenues(tt.gateRate, now), nil, cfg, now)
+			if len(report.Venues) != 3 {
+				t.Fatalf("%d venues, want 3", len(report.Venues))
+			}
+			for _, basis := range report.Venues {
+				if math.Abs(basis.ConsensusRate-1) > 1e-12 {
+					t.Fatalf("%s consensus = %v, want 1", basis.Venue, basis.ConsensusRate)
+				}
+				if basis.Venue != "GATE_SPOT" {
+					if basis.Flagged {
+						t.Fatalf("%s flagged at %.2fbps", basis.Venue, basis.DeviationBps)
+					}
+					continue
+				}
+				if wantBps := bpsDiff(tt.gateRate, 1); math.Abs(basis.DeviationBps-wantBps) > 1e-6 {
+					t.Fatalf("gate deviation = %.4fbps, want %.4fbps", basis.DeviationBps, wantBps)
+				}
+				if basis.Flagged != tt.wantFlagged {
+					t.Fatalf("gate flagged = %v at %.4fbps with threshold %.4fbps", basis.Flagged, basis.DeviationBps, tt.thresholdBps)
+				}
+			}
+		})
+	}
+}
+
+func TestStableBasisMissingLegsAndNormalization(t *testing.T) {
+	now := time.Now()
+	prices := stableVenues(1.005, now)
+	// Bitget 只有 USDT 腿，Aster 的 USDC 腿过期：都不产生结果
+	prices = append(prices,
+		stableQuote(common.ExchangeBitget, "BTCUSDT", 100000, now),
+		stableQuote(common.ExchangeAster, "BTCUSDT", 100000, now),
+		stableQuote(common.ExchangeAster, "BTCUSDC", 90000, now.Add(-2*stableBasisMaxAge)))
+
+	cfg := &StableBasisConfig{Quotes: []common.QuoteCurrency{common.QuoteCurrencyUSDC}, ThresholdBps: 20}
+	rates := map[common.QuoteCurrency]*ExchangeRate{common.QuoteCurrencyUSDC: {Rate: 1.003, Source: "BINANCE"}}
+	report := computeStableBasis(prices, rates, cfg, now)
+
+	venues := make([]string, 0, len(report.Venues))
+	for _, basis := range report.Venues {
+		venues = append(venues, basis.Venue)
+	}
+	if len(venues) != 3 || venues[0] != "BINANCE_SPOT" || venues[1] != "BYBIT_SPOT" || venues[2] != "GATE_SPOT" {
+		t.Fatalf("venues = %v, want only the venues with both legs", venues)
+	}
+
+	// 标准化层使用的汇率偏离共识30bps，超过阈值告警
+	if len(report.Normalization) != 1 {
+		t.Fatalf("%d normalization checks, want 1", len(report.Normalization))
+	}
+	check := report.Normalization[0]
+	if !check.Flagged || math.Abs(check.DeviationBps-30) > 1e-6 || check.Source != "BINANCE" {
+		t.Fatalf("normalization check = %+v", check)
+	}
+}
@@ -3,8 +3,11 @@ package pricestore
 import (
 	"crypto-arbitrage-monitor/pkg/common"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +15,14 @@ import (
 type PriceStore struct {
 	mu sync.RWMutex
 
+	// trackMu 保护套利机会跟踪状态（机会/交易对历史、反转记录、跟踪上限统计、快速层提升、模拟仓位），
+	// 计算套利机会时只需持有 mu 的读锁；需要同时加锁时先 mu 后 trackMu
+	trackMu sync.Mutex
+
+	// configMu 串行化运行时配置（阈值、黑名单、symbol映射、比值策略、交易所能力）的修改和配置文件路径的设置，
+	// 导入配置包时写文件期间只持有 configMu、不持有 mu；需要同时加锁时先 configMu 后 mu
+	configMu sync.Mutex
+
 	// 索引1: 按交易所维度存储
 	// key: exchange, value: map[marketType_symbol]*Price
 	byExchange map[common.Exchange]map[string]*common.Price
@@ -21,23 +32,176 @@ type PriceStore struct {
 	bySymbol map[string]map[string]*common.Price
 
 	// Symbol标准化映射表
-	// 用于解决不同交易所symbol名称不一致的问题
-	symbolNormalizer *SymbolNormalizer
+	// 用于解决不同交易所symbol名称不一致的问题，映射可从文件加载（symbolMappingsFile）并在运行时重新加载
+	symbolNormalizer   *SymbolNormalizer
+	symbolMappingsFile string
 
-	// 套利机会历史跟踪
+	// 套利机会历史跟踪（trackMu）
 	// key: symbol_type_buyFrom_sellTo, value: tracker
 	opportunityHistory map[string]*opportunityTracker
+
+	// 价差方向跟踪（不区分买卖方向的交易对），用于检测价差反转（trackMu）
+	// key: symbol_type_legA_legB（legA < legB），SpreadPercent 为带符号的价差
+	pairHistory map[string]*opportunityTracker
+	inversions  []*InversionEvent
 	// 汇率管理器 - Quote Normalization Layer
 	exchangeRateManager *ExchangeRateManager
+
+	// 价格合法性校验配置及各交易所被拒绝的次数
+	validation         *ValidationConfig
+	markRefs           map[string]*markReference // 合约最近的标记/指数价格（key: 交易所_原始symbol）
+	funding            map[string]*FundingInfo   // 永续合约最近的资金费率（key: 交易所_标准symbol）
+	rejectedByExchange map[common.Exchange]int64
+
+	// 按symbol配置的套利阈值（优先于分组阈值），及其持久化文件路径
+	thresholdOverrides map[string]float64
+	thresholdsFile     string
+
+	// 数量级倍数（1000PEPE 等）检测配置、当前嫌疑和已应用的修正规则
+	// key: EXCHANGE_MARKET_SYMBOL
+	multiplierConfig   *MultiplierConfig
+	multiplierSuspects map[string]*MultiplierSuspect
+	multiplierRules    map[string]*multiplierRule
+
+	// 存储名称（命名空间），用于区分生产数据和实验数据源，默认 "default"
+	name string
+
+	// 已注册的配对比值策略（A - 系数 * B），及其持久化文件路径
+	ratioStrategies     []*RatioStrategy
+	ratioStrategiesFile string
+
+	// symbol黑名单（精确/通配符/正则），各规则的命中次数，及其持久化文件路径
+	blacklist     []*blacklistRule
+	blacklistHits map[string]int64
+	blacklistFile string
+
+	// 各交易所REST拉取耗时的移动平均
+	fetchLatency map[common.Exchange]*FetchLatency
+
+	// 各交易所REST拉取失败次数（按错误分类）和各接口的限频退避截止时间
+	fetchErrors       map[common.Exchange]map[string]int64
+	fetchBackoffUntil map[fetchEndpoint]time.Time
+
+	// 各交易所taker手续费率（百分比），用于成交模拟
+	takerFees map[common.Exchange]float64
+
+	// 价差/套利机会置信度评分参数
+	confidence *ConfidenceWeights
+
+	// 各交易所的执行能力（充提币/永续），用于推导套利机会的执行方式，及其配置文件路径
+	venueCaps     map[common.Exchange]VenueCapability
+	venueCapsFile string
+
+	// 模拟交易配置、持仓中和已平仓的模拟仓位，及按symbol的累计统计（仓位和统计由 trackMu 保护）
+	paperConfig *PaperConfig
+	paperOpen   []*PaperPosition
+	paperClosed []*PaperPosition
+	paperTotals map[string]*PaperSymbolSummary
+	paperNextID int64
+
+	// 价格历史（按时间升序的采样），用于按时间点查询价格和价差
+	// key: standardSymbol -> exchange_marketType
+	historyConfig *HistoryConfig
+	history       map[string]map[string][]*common.Price
+
+	// 补充数据（成交量等）的分层刷新：快速层关注列表、机会提升到期时间（trackMu）、各数据源最近刷新
+	tierConfig    *RefreshTierConfig
+	tierWatchlist map[string]bool
+	tierPromoted  map[string]time.Time
+	tierRefresh   map[string]*TierRefresh
+
+	// 各 shouldUpdate 规则接受/拒绝的次数，以及开启调试的symbol最近的更新决定
+	updateAccepted [numUpdateRules]uint64
+	updateRejected [numUpdateRules]uint64
+	updateDebug    map[string]*updateDebugRing
+
+	// 计算价差/套利机会要求的最少活跃场所数（交易所+市场类型），默认2
+	minExchangeCount int
+
+	// 套利机会两腿24小时成交量（计价货币）的最小值，0表示不过滤
+	minOpportunityVolume float64
+
+	// 允许生成套利机会/价差策略的市场类型组合，nil表示全部允许
+	allowedPairings map[string]bool
+
+	// 同时跟踪的套利机会上限（0表示不限制），累计和最近一次因超过上限被丢弃的机会数（trackMu）
+	maxTrackedOpportunities  int
+	opportunityEvictions     int64
+	lastDroppedOpportunities int
+
+	// 价差计算使用的买卖价格（买一/卖一或深度加权价格）
+	priceMode PriceMode
+
+	// 延迟补偿的价差投影参数，nil表示不投影
+	latencyProjection *LatencyProjection
+	driftAnchors      map[string]driftAnchor // 各场所报价计算漂移的起点样本（key: 交易所_exchange索引key）
+
+	// 按标准symbol订阅报价更新的 watcher（/ws/watch）
+	watchers map[string]map[*SymbolWatcher]bool
+
+	// 短暂缺腿的价差在保留时长内继续返回上次的值（自带锁）
+	spreadHold *spreadHoldCache
+
+	// 各交易对价差的滚动统计和异常标注（自带锁）
+	anomaly *anomalyAnnotator
+
+	// 稳定币三角一致性检查的配置和最近结果（自带锁）
+	stableBasis *stableBasisChecker
+
+	// 场所对价差汇总使用的价差缓存（自带锁）
+	venuePairs *venuePairCache
+
+	// 只读行情快照，定期重建后原子发布，读取时不需要获取 mu
+	snapshot atomic.Pointer[TickerSnapshot]
+
+	// 全局更新序列号，每次实际写入时递增
+	// 仅在进程生命周期内单调递增，重启后从0开始
+	seq uint64
 }
 
 // NewPriceStore 创建价格存储器
 func NewPriceStore() *PriceStore {
 	ps := &PriceStore{
-		byExchange:         make(map[common.Exchange]map[string]*common.Price),
-		bySymbol:           make(map[string]map[string]*common.Price),
-		symbolNormalizer:   NewSymbolNormalizer(),
-		opportunityHistory: make(map[string]*opportunityTracker),
+		byExchange:              make(map[common.Exchange]map[string]*common.Price),
+		bySymbol:                make(map[string]map[string]*common.Price),
+		symbolNormalizer:        NewSymbolNormalizer(),
+		opportunityHistory:      make(map[string]*opportunityTracker),
+		pairHistory:             make(map[string]*opportunityTracker),
+		validation:              DefaultValidationConfig(),
+		markRefs:                make(map[string]*markReference),
+		driftAnchors:            make(map[string]driftAnchor),
+		funding:                 make(map[string]*FundingInfo),
+		rejectedByExchange:      make(map[common.Exchange]int64),
+		thresholdOverrides:      make(map[string]float64),
+		blacklistHits:           make(map[string]int64),
+		takerFees:               make(map[common.Exchange]float64),
+		fetchLatency:            make(map[common.Exchange]*FetchLatency),
+		fetchErrors:             make(map[common.Exchange]map[string]int64),
+		fetchBackoffUntil:       make(map[fetchEndpoint]time.Time),
+		confidence:              DefaultConfidenceWeights(),
+		minExchangeCount:        2,
+		maxTrackedOpportunities: DefaultMaxTrackedOpportunities,
+		venueCaps:               DefaultVenueCapabilities(),
+		ratioStrategies:         DefaultRatioStrategies(),
+		name:                    DefaultNamespace,
+		multiplierConfig:        DefaultMultiplierConfig(),
+		multiplierSuspects:      make(map[string]*MultiplierSuspect),
+		multiplierRules:         make(map[string]*multiplierRule),
+		paperConfig:             DefaultPaperConfig(),
+		paperTotals:             make(map[string]*PaperSymbolSummary),
+		historyConfig:           DefaultHistoryConfig(),
+		history:                 make(map[string]map[string][]*common.Price),
+		tierConfig:              DefaultRefreshTierConfig(),
+		tierWatchlist:           make(map[string]bool),
+		tierPromoted:            make(map[string]time.Time),
+		tierRefresh:             make(map[string]*TierRefresh),
+		updateDebug:             make(map[string]*updateDebugRing),
+		spreadHold:              &spreadHoldCache{last: make(map[string]*heldSpread)},
+		priceMode:               PriceModeTopOfBook,
+		anomaly:                 &anomalyAnnotator{cfg: DefaultAnomalyConfig(), pairs: make(map[string]*anomalyPair)},
+		stableBasis:             &stableBasisChecker{cfg: DefaultStableBasisConfig()},
+		venuePairs:              &venuePairCache{},
+		watchers:                make(map[string]map[*SymbolWatcher]bool),
 	}
 
 	// 初始化汇率管理器（需要ps作为参数，所以分步初始化）
@@ -46,16 +210,88 @@ func NewPriceStore() *PriceStore {
 	return ps
 }
 
+// DefaultNamespace 默认存储名称，对应不带命名空间前缀的 /api/... 路径
+const DefaultNamespace = "default"
+
+// SetName 设置存储名称（命名空间）
+func (ps *PriceStore) SetName(name string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.name = name
+}
+
+// Name 获取存储名称（命名空间）
+func (ps *PriceStore) Name() string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.name
+}
+
+// SetMinExchangeCount 设置计算价差/套利机会要求的最少活跃场所数（小于2时按2处理）
+// 场所按 交易所+市场类型 计数，例如 BINANCE SPOT 和 BINANCE FUTURE 算两个
+func (ps *PriceStore) SetMinExchangeCount(n int) {
+	if n < 2 {
+		n = 2
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.minExchangeCount = n
+}
+
+// SetMinOpportunityVolume 设置套利机会两腿24小时成交量（计价货币）的最小值，0表示不过滤
+// 与价差阈值独立：价差再大，任一腿成交量不足时也不算机会；成交量未知的腿（bookTicker 等，VolumeKnown=false）
+// 与 /api/spreads 一致不按成交量过滤
+func (ps *PriceStore) SetMinOpportunityVolume(volume float64) {
+	if volume < 0 {
+		volume = 0
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.minOpportunityVolume = volume
+}
+
 // UpdatePrice 更新价格数据（线程安全）
 // 自动判断是否应该更新（防止旧数据覆盖新数据）
+// 存储的是传入价格的副本，调用者之后可以继续修改或复用传入的结构体
 // 返回值：是否实际更新了数据
-func (ps *PriceStore) UpdatePrice(price *common.Price) bool {
+func (ps *PriceStore) UpdatePrice(input *common.Price) bool {
+	// 标准化、倍数修正和序列号都写在存储自己持有的副本上
+	stored := *input
+	price := &stored
+
+	// 存储的时间统一为UTC（已经是UTC的不转换，转换会去掉单调时钟读数）
+	if price.Timestamp.Location() != time.UTC {
+		price.Timestamp = price.Timestamp.UTC()
+	}
+	if price.LastUpdated.Location() != time.UTC {
+		price.LastUpdated = price.LastUpdated.UTC()
+	}
+
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
+	// === 价格合法性校验 ===
+	// 在标准化之前校验原始报价，拒绝NaN、负数、零价格及bid/ask比例异常的数据
+	// 通过校验的合约报价自带的标记/指数价格记录下来，供不带标记价格的数据源（bookTicker）校验偏离
+	if reason := ps.validatePrice(price); reason != "" {
+		ps.recordRejection(price, reason)
+		return false
+	}
+	ps.rememberMarkReference(price)
+
+	// === 黑名单 ===
+	// 杠杆代币、稳定币对等，直接拒绝入库
+	// 币安现货的汇率交易对仍需入库供汇率管理器使用，只在计算价差时过滤
+	if pattern := ps.matchBlacklist(price.Symbol); pattern != "" && !isExchangeRatePair(price) {
+		ps.blacklistHits[pattern]++
+		return false
+	}
+
 	// === Quote Normalization Layer ===
-	// 1. 解析symbol,识别quote currency
-	symbolInfo := common.ParseSymbol(price.Symbol)
+	// 1. 解析symbol,识别quote currency（数据源已声明报价货币时以声明为准）
+	symbolInfo := common.ParseSymbolWithQuote(price.Symbol, price.QuoteCurrency)
 	price.QuoteCurrency = symbolInfo.QuoteAsset
 
 	// 2. 如果不是USDT,进行标准化
@@ -71,20 +307,47 @@ func (ps *PriceStore) UpdatePrice(price *common.Price) bool {
 	// 3. 使用标准化的symbol进行索引
 	standardSymbol := ps.symbolNormalizer.Normalize(symbolInfo.ToStandardSymbol())
 
+	// 数量级倍数修正（1000PEPE 等），之后的价差计算都使用修正后的价格
+	if rule := ps.multiplierRuleFor(price); rule != nil {
+		applyMultiplier(price, rule.Multiplier)
+		standardSymbol = rule.TargetSymbol
+	}
+
+	// 资金费率单独记录，不随不含资金费率的报价（REST）清零
+	ps.recordFundingLocked(standardSymbol, price)
+
 	// 生成各种key
 	exchangeKey := ps.makeExchangeKey(price.MarketType, price.Symbol)
 
-	// 检查是否应该更新（新鲜度判断）
-	if ps.byExchange[price.Exchange] != nil {
-		if existingPrice := ps.byExchange[price.Exchange][exchangeKey]; existingPrice != nil {
-			if !ps.shouldUpdate(existingPrice, price) {
-				return false // 不更新旧数据
-			}
-		}
+	// 检查是否应该更新（新鲜度判断），按规则计数，开启调试的symbol记录每次决定
+	now := time.Now().UTC()
+	existingPrice := ps.byExchange[price.Exchange][exchangeKey]
+	accepted, updateRule := updateDecision(existingPrice, price, now)
+	ps.recordUpdateDecision(standardSymbol, existingPrice, price, accepted, updateRule, now)
+	if !accepted {
+		return false // 不更新旧数据
 	}
 
 	symbolKey := ps.makeSymbolKey(price.Exchange, price.MarketType)
 
+	// 分配序列号（被拒绝的更新不占用序列号）
+	ps.seq++
+	price.Seq = ps.seq
+
+	// 延迟补偿：按该场所之前的报价更新中间价漂移
+	if ps.latencyProjection != nil {
+		anchorKey := driftAnchorKey(price.Exchange, exchangeKey)
+		var anchor driftAnchor
+		if existingPrice != nil {
+			anchor = ps.driftAnchors[anchorKey]
+		}
+		if anchor = updateDrift(existingPrice, anchor, price, ps.latencyProjection); anchor.at.IsZero() {
+			delete(ps.driftAnchors, anchorKey)
+		} else {
+			ps.driftAnchors[anchorKey] = anchor
+		}
+	}
+
 	// 更新exchange索引
 	if ps.byExchange[price.Exchange] == nil {
 		ps.byExchange[price.Exchange] = make(map[string]*common.Price)
@@ -97,57 +360,65 @@ func (ps *PriceStore) UpdatePrice(price *common.Price) bool {
 	}
 	ps.bySymbol[standardSymbol][symbolKey] = price
 
+	// 记录价格历史，供按时间点查询
+	ps.recordHistory(standardSymbol, symbolKey, price)
+
+	// 直接推送给订阅了该symbol的 watcher
+	if len(ps.watchers) > 0 {
+		ps.notifyWatchers(standardSymbol, symbolKey, price)
+	}
+
 	// 4. 如果是币安的汇率交易对，触发汇率更新
-	if price.Exchange == common.ExchangeBinance && price.MarketType == common.MarketTypeSpot {
-		// 检查是否为汇率交易对 (USDCUSDT, USDEUSDT, FDUSDUSDT)
-		if price.Symbol == "USDCUSDT" || price.Symbol == "USDEUSDT" || price.Symbol == "FDUSDUSDT" {
-			// 异步更新汇率，避免持锁时间过长
-			go ps.exchangeRateManager.UpdateFromBinance()
-		}
+	if isExchangeRatePair(price) {
+		// 异步更新汇率，避免持锁时间过长
+		go ps.exchangeRateManager.UpdateFromBinance()
 	}
 
 	return true
 }
 
-// shouldUpdate 判断是否应该更新价格
+// updateDecision 判断是否应该更新价格，并返回做出决定的规则
 // 新策略（修复架构性问题）：
 // 1. WebSocket数据优先级高于REST数据
 // 2. 使用Timestamp（交易所时间）判断数据新鲜度，而不是LastUpdated（本地接收时间）
 // 3. REST数据不覆盖WebSocket数据（除非WebSocket数据过期）
 // 4. 如果现有数据超过60秒未更新，接受任何新数据（REST兜底）
-func (ps *PriceStore) shouldUpdate(existing, new *common.Price) bool {
-	now := time.Now()
+func updateDecision(existing, new *common.Price, now time.Time) (bool, UpdateRule) {
+	// 没有现有数据，直接接受
+	if existing == nil {
+		return true, UpdateRuleFirst
+	}
 
 	// 规则1：如果现有数据超过60秒没更新（LastUpdated），接受任何新数据（WS可能断了，REST兜底）
 	if now.Sub(existing.LastUpdated) > 60*time.Second {
-		return true
+		return true, UpdateRuleExistingStale
 	}
 
 	// 规则2：WebSocket数据优先级高于REST数据
 	// 如果现有数据是WebSocket，新数据是REST，不更新（除非WebSocket数据过期，已被规则1处理）
 	if existing.Source == common.PriceSourceWebSocket && new.Source == common.PriceSourceREST {
-		return false
+		return false, UpdateRuleWSOverREST
 	}
 
 	// 规则3：如果现有数据是REST，新数据是WebSocket，立即更新
 	if existing.Source == common.PriceSourceREST && new.Source == common.PriceSourceWebSocket {
-		return true
+		return true, UpdateRuleRESTToWS
 	}
 
 	// 规则4：同源数据，比较Timestamp（交易所时间）
 	// 注意：对于REST数据，Timestamp可能等于LastUpdated（因为没有交易所时间戳）
 	if new.Timestamp.After(existing.Timestamp) {
-		return true
+		return true, UpdateRuleNewerTimestamp
 	}
 
 	// 规则5：如果Timestamp相同或更旧，但LastUpdated更新，也接受
 	// （处理某些交易所Timestamp精度不够的情况）
 	if new.LastUpdated.After(existing.LastUpdated) {
-		return true
+		return true, UpdateRuleNewerLastUpdated
 	}
 
 	// 否则拒绝（防止旧数据覆盖新数据）
-	return false
+	return false, UpdateRuleNotNewer
 }
 
 // GetPricesByExchange 按交易所获取所有价格
@@ -211,6 +482,47 @@ func (ps *PriceStore) GetAllPrices() []*common.Price {
 	return prices
 }
 
+// GetAllPricesBySymbol 获取所有价格，按标准化symbol分组（与 GetPricesBySymbol 的分组一致）
+func (ps *PriceStore) GetAllPricesBySymbol() map[string][]*common.Price {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	grouped := make(map[string][]*common.Price, len(ps.bySymbol))
+	for symbol, symbolMap := range ps.bySymbol {
+		prices := make([]*common.Price, 0, len(symbolMap))
+		for _, price := range symbolMap {
+			prices = append(prices, price)
+		}
+		grouped[symbol] = prices
+	}
+	return grouped
+}
+
+// GetPricesSince 获取序列号大于since的所有价格（按序列号升序，用于增量轮询）
+// 同时返回读取时的最新序列号，客户端下次轮询时作为 since 传入不会漏掉更新
+func (ps *PriceStore) GetPricesSince(since uint64) ([]*common.Price, uint64) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	prices := make([]*common.Price, 0)
+	for _, exchangeMap := range ps.byExchange {
+		for _, price := range exchangeMap {
+			if price.Seq > since {
+				prices = append(prices, price)
+			}
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Seq < prices[j].Seq })
+	return prices, ps.seq
+}
+
+// CurrentSeq 获取当前最新的序列号
+func (ps *PriceStore) CurrentSeq() uint64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.seq
+}
+
 // GetAllSymbols 获取所有标准化symbol列表
 func (ps *PriceStore) GetAllSymbols() []string {
 	ps.mu.RLock()
@@ -223,6 +535,40 @@ func (ps *PriceStore) GetAllSymbols() []string {
 	return symbols
 }
 
+// GetUniqueBaseAssets 获取所有base asset（按名称排序）
+// 同一base的多个报价货币（BTCUSDT、BTCFDUSD、BTCUSD_INVERSE 等）只计一次
+func (ps *PriceStore) GetUniqueBaseAssets() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	bases := ps.baseAssetsLocked()
+	result := make([]string, 0, len(bases))
+	for base := range bases {
+		result = append(result, base)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// baseAssetsLocked 所有标准symbol的base asset集合（调用者需要持有锁）
+func (ps *PriceStore) baseAssetsLocked() map[string]bool {
+	bases := make(map[string]bool, len(ps.bySymbol))
+	for symbol, priceMap := range ps.bySymbol {
+		info := common.ParseSymbol(symbol)
+		// 币本位合约的标准symbol去掉了下划线（BTCUSDINVERSE），从交易所原始symbol解析base
+		for _, price := range priceMap {
+			if original := common.ParseSymbol(price.Symbol); original.Inverse {
+				info = original
+			}
+			break
+		}
+		if info.BaseAsset != "" {
+			bases[info.BaseAsset] = true
+		}
+	}
+	return bases
+}
+
 // GetAllExchanges 获取所有交易所列表
 func (ps *PriceStore) GetAllExchanges() []common.Exchange {
 	ps.mu.RLock()
@@ -239,12 +585,23 @@ func (ps *PriceStore) GetAllExchanges() []common.Exchange {
 func (ps *PriceStore) GetStats() StoreStats {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
+	return ps.statsLocked(time.Now())
+}
 
+// statsLocked 统计信息（调用者需要持有锁）
+func (ps *PriceStore) statsLocked(now time.Time) StoreStats {
 	stats := StoreStats{
-		TotalPrices:    0,
-		TotalSymbols:   len(ps.bySymbol),
-		TotalExchanges: len(ps.byExchange),
-		ByExchange:     make(map[common.Exchange]int),
+		TotalPrices:        0,
+		TotalSymbols:       len(ps.bySymbol),
+		UniqueBaseAssets:   len(ps.baseAssetsLocked()),
+		TotalExchanges:     len(ps.byExchange),
+		ByExchange:         make(map[common.Exchange]int),
+		RejectedByExchange: make(map[common.Exchange]int64),
+		BlacklistHits:      make(map[string]int64),
+		FetchLatency:       make(map[common.Exchange]FetchLatency),
+		FetchErrors:        make(map[common.Exchange]map[string]int64),
+		FetchBackoffUntil:  make(map[string]time.Time),
+		RefreshTiers:       make(map[string]TierRefresh),
 	}
 
 	for exchange, priceMap := range ps.byExchange {
@@ -253,6 +610,40 @@ func (ps *PriceStore) GetStats() StoreStats {
 		stats.ByExchange[exchange] = count
 	}
 
+	for exchange, count := range ps.rejectedByExchange {
+		stats.RejectedByExchange[exchange] = count
+	}
+
+	for pattern, count := range ps.blacklistHits {
+		stats.BlacklistHits[pattern] = count
+	}
+
+	for exchange, latency := range ps.fetchLatency {
+		stats.FetchLatency[exchange] = *latency
+	}
+
+	for exchange, counts := range ps.fetchErrors {
+		stats.FetchErrors[exchange] = make(map[string]int64, len(counts))
+		for class, count := range counts {
+			stats.FetchErrors[exchange][class] = count
+		}
+	}
+
+	for endpoint, until := range ps.fetchBackoffUntil {
+		if until.After(now) {
+			stats.FetchBackoffUntil[endpoint.String()] = until
+		}
+	}
+
+	for source, refresh := range ps.tierRefresh {
+		stats.RefreshTiers[source] = *refresh
+	}
+	stats.UpdateRules = ps.updateRuleStats()
+	ps.trackMu.Lock()
+	stats.FastTierSymbols = ps.fastTierSymbols(now)
+	stats.Opportunities = ps.opportunityCapStatsLocked()
+	ps.trackMu.Unlock()
+
 	return stats
 }
 
@@ -274,6 +665,42 @@ func (ps *PriceStore) GetActivePrices(within time.Duration) []*common.Price {
 	return prices
 }
 
+// AgeHistogramBuckets 数据年龄直方图的分桶标签，与 GetAgeHistogram 返回的切片下标一一对应
+var AgeHistogramBuckets = []string{"<1s", "1-5s", "5-30s", ">30s"}
+
+// GetAgeHistogram 按 exchange_marketType 统计价格数据年龄分布
+// 返回值: key为 EXCHANGE_MARKET，value为各分桶的数量（见 AgeHistogramBuckets）
+func (ps *PriceStore) GetAgeHistogram() map[string][]int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	now := time.Now()
+	histogram := make(map[string][]int)
+
+	for exchange, exchangeMap := range ps.byExchange {
+		for _, price := range exchangeMap {
+			key := ps.makeSymbolKey(exchange, price.MarketType)
+			if histogram[key] == nil {
+				histogram[key] = make([]int, len(AgeHistogramBuckets))
+			}
+
+			age := now.Sub(price.LastUpdated)
+			switch {
+			case age < time.Second:
+				histogram[key][0]++
+			case age < 5*time.Second:
+				histogram[key][1]++
+			case age < 30*time.Second:
+				histogram[key][2]++
+			default:
+				histogram[key][3]++
+			}
+		}
+	}
+
+	return histogram
+}
+
 // Spread 价差信息
 type Spread struct {
 	Symbol         string            `json:"symbol"`
@@ -286,6 +713,7 @@ type Spread struct {
 	SpreadPercent  float64           `json:"spread_percent"`
 	SpreadAbsolute float64           `json:"spread_absolute"`
 	Volume24h      float64           `json:"volume_24h"`
+	VolumeKnown    bool              `json:"volume_known"` // 两腿都有真实成交量数据，为false时 Volume24h 不可信
 	UpdatedAt      time.Time         `json:"updated_at"`
 
 	// === Quote Normalization 信息 ===
@@ -296,77 +724,118 @@ type Spread struct {
 	SellOriginalPrice float64              `json:"sell_original_price"`
 	SellExchangeRate  float64              `json:"sell_exchange_rate"`
 	EffectiveSpread   float64              `json:"effective_spread"` // 扣除汇率成本后的有效价差
+
+	// 两腿报价的置信度（0-1），综合数据年龄、数据源、盘口厚度和两腿年龄差
+	Confidence float64 `json:"confidence"`
+
+	// 本轮缺少一腿、在保留时长内返回的上次价差（见 HoldMissingSpreads），MissingSince 为最后一次出现的时间
+	Held         bool       `json:"held,omitempty"`
+	MissingSince *time.Time `json:"missing_since,omitempty"`
+
+	// 延迟补偿后的价差（LATENCY_PROJECTION 开启且两腿交易所时间差超过阈值时），nil表示未投影
+	Projection *SpreadProjection `json:"projection,omitempty"`
+
+	// 计算价差时两腿的报价（不输出到 v1 的JSON，/api/v2/spreads 用于返回两腿的原始symbol、数据源和年龄）
+	BuyQuote  *common.Price `json:"-"`
+	SellQuote *common.Price `json:"-"`
 }
 
 // CalculateSpreads 计算所有symbol的价差
 // 返回按价差百分比降序排列的价差列表
 func (ps *PriceStore) CalculateSpreads() []*Spread {
+	return ps.CalculateSpreadsTimed(nil)
+}
+
+// CalculateSpreadsTimed 计算价差，并将各阶段耗时写入 timing（timing 为 nil 时不计时）
+func (ps *PriceStore) CalculateSpreadsTimed(timing *CalcTiming) []*Spread {
+	start := timing.begin()
+	// 只在复制数据时持有读锁，计算在副本上进行
 	ps.mu.RLock()
-	defer ps.mu.RUnlock()
+	start = timing.markLockWait(start)
+	snap := ps.calcSnapshotLocked(true)
+	ps.mu.RUnlock()
+	start = timing.markSnapshot(start)
+
+	spreads := snap.allSpreads(time.Now())
+	start = timing.markCompute(start)
+
+	// 按价差百分比降序排序
+	ps.sortSpreadsByPercent(spreads)
+
+	timing.finish(start, len(spreads))
+	return spreads
+}
+
+// allSpreads 计算副本中所有symbol的价差（未排序）
+func (snap *priceSnapshot) allSpreads(now time.Time) []*Spread {
+	spreads := make([]*Spread, 0)
+	for symbol, priceMap := range snap.bySymbol {
+		spreads = append(spreads, snap.symbolSpreads(symbol, priceMap, now)...)
+	}
+	return spreads
+}
 
+// symbolSpreads 计算单个symbol各场所之间的价差
+// now 用于判断报价是否活跃及置信度评分，实时计算传入当前时间，历史时间点查询传入查询时间
+func (snap *priceSnapshot) symbolSpreads(symbol string, priceMap map[string]*common.Price, now time.Time) []*Spread {
 	spreads := make([]*Spread, 0)
 
-	// 遍历所有symbol
-	for _, priceMap := range ps.bySymbol {
-		// 将map转为slice方便比较
-		prices := make([]*common.Price, 0, len(priceMap))
-		for _, price := range priceMap {
-			// 只考虑60秒内的活跃数据
-			if time.Since(price.LastUpdated) <= 60*time.Second {
-				prices = append(prices, price)
-			}
-		}
+	// 黑名单规则可能在价格入库后才添加，这里再过滤一次
+	if snap.matchBlacklist(symbol) != "" {
+		return spreads
+	}
 
-		// 至少需要2个交易所的数据才能计算价差
-		if len(prices) < 2 {
+	// 将map转为slice方便比较
+	prices := make([]*common.Price, 0, len(priceMap))
+	for _, price := range priceMap {
+		if snap.matchBlacklist(price.Symbol) != "" {
 			continue
 		}
+		// 只考虑60秒内的活跃数据
+		if now.Sub(price.LastUpdated) <= 60*time.Second {
+			prices = append(prices, price)
+		}
+	}
 
-		// 两两比较计算价差
-		for i := 0; i < len(prices); i++ {
-			for j := i + 1; j < len(prices); j++ {
-				p1 := prices[i]
-				p2 := prices[j]
+	// 至少需要 minExchangeCount 个场所的数据才能计算价差
+	if len(prices) < snap.minExchangeCount {
+		return spreads
+	}
 
-				// 跳过相同交易所和市场类型的组合
-				if p1.Exchange == p2.Exchange && p1.MarketType == p2.MarketType {
-					continue
-				}
+	// 两两比较计算价差
+	for i := 0; i < len(prices); i++ {
+		for j := i + 1; j < len(prices); j++ {
+			p1 := prices[i]
+			p2 := prices[j]
 
-				// 计算两个方向的价差
-				// 方向1: 买p1卖p2
-				spread1 := ps.calculateSpread(p1, p2)
-				if spread1 != nil {
-					spreads = append(spreads, spread1)
-				}
+			// 跳过相同交易所和市场类型的组合
+			if p1.Exchange == p2.Exchange && p1.MarketType == p2.MarketType {
+				continue
+			}
 
-				// 方向2: 买p2卖p1
-				spread2 := ps.calculateSpread(p2, p1)
-				if spread2 != nil {
-					spreads = append(spreads, spread2)
-				}
+			// 计算两个方向的价差
+			// 方向1: 买p1卖p2
+			spread1 := snap.calculateSpread(p1, p2, now)
+			if spread1 != nil {
+				spreads = append(spreads, spread1)
+			}
+
+			// 方向2: 买p2卖p1
+			spread2 := snap.calculateSpread(p2, p1, now)
+			if spread2 != nil {
+				spreads = append(spreads, spread2)
 			}
 		}
 	}
 
-	// 按价差百分比降序排序
-	ps.sortSpreadsByPercent(spreads)
-
 	return spreads
 }
 
-// calculateSpread 计算单向价差（买buyPrice卖sellPrice）
-func (ps *PriceStore) calculateSpread(buyPrice, sellPrice *common.Price) *Spread {
-	// 使用ask价格买入，bid价格卖出（已经是标准化后的USDT价格）
-	askPrice := buyPrice.AskPrice
-	if askPrice == 0 {
-		askPrice = buyPrice.Price
-	}
-
-	bidPrice := sellPrice.BidPrice
-	if bidPrice == 0 {
-		bidPrice = sellPrice.Price
-	}
+// calculateSpread 计算单向价差（买buyPrice卖sellPrice），now 用于置信度评分
+func (snap *priceSnapshot) calculateSpread(buyPrice, sellPrice *common.Price, now time.Time) *Spread {
+	// 使用ask价格买入，bid价格卖出（已经是标准化后的USDT价格，按价格模式选择买一/卖一或深度加权价格）
+	askPrice := snap.askPrice(buyPrice)
+	bidPrice := snap.bidPrice(sellPrice)
 
 	if askPrice == 0 || bidPrice == 0 {
 		return nil
@@ -411,7 +880,7 @@ func (ps *PriceStore) calculateSpread(buyPrice, sellPrice *common.Price) *Spread
 		sellOriginalPrice = bidPrice
 	}
 
-	return &Spread{
+	spread := &Spread{
 		Symbol:         buyPrice.Symbol,
 		BuyExchange:    buyPrice.Exchange,
 		BuyMarketType:  buyPrice.MarketType,
@@ -422,7 +891,9 @@ func (ps *PriceStore) calculateSpread(buyPrice, sellPrice *common.Price) *Spread
 		SpreadPercent:  spreadPercent,
 		SpreadAbsolute: spreadAbsolute,
 		Volume24h:      volume,
+		VolumeKnown:    buyPrice.VolumeKnown && sellPrice.VolumeKnown,
 		UpdatedAt:      updatedAt,
+		Confidence:     ScoreConfidence(buyPrice, sellPrice, now, snap.confidence),
 
 		// Quote Normalization 信息
 		BuyQuoteCurrency:  buyPrice.QuoteCurrency,
@@ -432,19 +903,37 @@ func (ps *PriceStore) calculateSpread(buyPrice, sellPrice *common.Price) *Spread
 		SellOriginalPrice: sellOriginalPrice,
 		SellExchangeRate:  sellPrice.ExchangeRate,
 		EffectiveSpread:   effectiveSpread,
+
+		BuyQuote:  buyPrice,
+		SellQuote: sellPrice,
+	}
+
+	// 较旧一腿按漂移投影到较新一腿的时间，原始价差不变
+	if proj := snap.projectOlderLeg(buyPrice, sellPrice); proj != nil {
+		projAsk := proj.apply(buyPrice, askPrice)
+		projBid := proj.apply(sellPrice, bidPrice)
+		spread.Projection = proj.report(buyPrice, projAsk, projBid, ((projBid-projAsk)/projAsk)*100)
 	}
+	return spread
 }
 
-// sortSpreadsByPercent 按价差百分比降序排序
+// sortSpreadsByPercent 按价差百分比降序排序，价差相同时两腿数据更新的（MaxAge 更小）排在前面
 func (ps *PriceStore) sortSpreadsByPercent(spreads []*Spread) {
-	// 简单冒泡排序（数据量不大）
-	for i := 0; i < len(spreads)-1; i++ {
-		for j := 0; j < len(spreads)-i-1; j++ {
-			if spreads[j].SpreadPercent < spreads[j+1].SpreadPercent {
-				spreads[j], spreads[j+1] = spreads[j+1], spreads[j]
-			}
+	now := time.Now()
+	sort.Slice(spreads, func(i, j int) bool {
+		if spreads[i].SpreadPercent != spreads[j].SpreadPercent {
+			return spreads[i].SpreadPercent > spreads[j].SpreadPercent
 		}
+		return spreads[i].MaxAge(now) < spreads[j].MaxAge(now)
+	})
+}
+
+// MaxAge 两腿报价中较旧一腿的数据年龄，缺少报价时视为无限旧
+func (s *Spread) MaxAge(now time.Time) time.Duration {
+	if s.BuyQuote == nil || s.SellQuote == nil {
+		return time.Duration(math.MaxInt64)
 	}
+	return max(now.Sub(s.BuyQuote.LastUpdated), now.Sub(s.SellQuote.LastUpdated))
 }
 
 // CleanStaleData 清理过期数据
@@ -460,6 +949,7 @@ func (ps *PriceStore) CleanStaleData(threshold time.Duration) int {
 		for key, price := range exchangeMap {
 			if now.Sub(price.LastUpdated) > threshold {
 				delete(exchangeMap, key)
+				delete(ps.driftAnchors, driftAnchorKey(exchange, key))
 				removedCount++
 			}
 		}
@@ -472,6 +962,9 @@ func (ps *PriceStore) CleanStaleData(threshold time.Duration) int {
 	// 重建bySymbol索引
 	ps.rebuildSymbolIndex()
 
+	// 清理超出保留时长的价格历史
+	ps.pruneHistory(now)
+
 	return removedCount
 }
 
@@ -481,7 +974,11 @@ func (ps *PriceStore) rebuildSymbolIndex() {
 
 	for exchange, exchangeMap := range ps.byExchange {
 		for _, price := range exchangeMap {
-			standardSymbol := ps.symbolNormalizer.Normalize(price.Symbol)
+			// 与 UpdatePrice 使用相同的标准化（先解析quote再应用映射）
+			standardSymbol := ps.symbolNormalizer.Normalize(common.ParseSymbol(price.Symbol).ToStandardSymbol())
+			if rule := ps.multiplierRuleFor(price); rule != nil {
+				standardSymbol = rule.TargetSymbol
+			}
 			symbolKey := ps.makeSymbolKey(exchange, price.MarketType)
 
 			if ps.bySymbol[standardSymbol] == nil {
@@ -499,6 +996,11 @@ func (ps *PriceStore) makeExchangeKey(marketType common.MarketType, symbol strin
 
 // makeSymbolKey 生成symbol索引的key: exchange_marketType
 func (ps *PriceStore) makeSymbolKey(exchange common.Exchange, marketType common.MarketType) string {
+	return symbolIndexKey(exchange, marketType)
+}
+
+// symbolIndexKey symbol索引的key: exchange_marketType（不需要 PriceStore 的计算使用）
+func symbolIndexKey(exchange common.Exchange, marketType common.MarketType) string {
 	return fmt.Sprintf("%s_%s", exchange, marketType)
 }
 
@@ -508,6 +1010,34 @@ type StoreStats struct {
 	TotalSymbols   int
 	TotalExchanges int
 	ByExchange     map[common.Exchange]int
+
+	// 去重后的base asset数（同一base的多个报价货币只计一次）
+	UniqueBaseAssets int
+
+	// 各交易所因校验失败被拒绝的价格数
+	RejectedByExchange map[common.Exchange]int64
+
+	// 各黑名单规则拒绝的价格数
+	BlacklistHits map[string]int64
+
+	// 各交易所REST拉取耗时
+	FetchLatency map[common.Exchange]FetchLatency
+
+	// 各交易所REST拉取失败次数（按错误分类：rate_limited/temporary/bad_request/auth/unknown）
+	FetchErrors map[common.Exchange]map[string]int64
+
+	// 正在限频退避的接口（"交易所/接口"）及退避截止时间
+	FetchBackoffUntil map[string]time.Time
+
+	// 各数据源分层刷新的最近刷新时间，以及当前快速层symbol
+	RefreshTiers    map[string]TierRefresh
+	FastTierSymbols []string
+
+	// 各价格更新规则接受/拒绝的次数
+	UpdateRules UpdateRuleStats
+
+	// 套利机会跟踪数量及因超过上限被丢弃的次数
+	Opportunities OpportunityCapStats
 }
 
 // SymbolNormalizer 处理不同交易所symbol名称不一致的问题
@@ -564,6 +1094,30 @@ func (sn *SymbolNormalizer) AddMapping(original, standard string) {
 	sn.customMappings[original] = standard
 }
 
+// SetMappings 整体替换自定义symbol映射
+func (sn *SymbolNormalizer) SetMappings(mappings map[string]string) {
+	customMappings := make(map[string]string, len(mappings))
+	for original, standard := range mappings {
+		customMappings[original] = standard
+	}
+
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	sn.customMappings = customMappings
+}
+
+// Mappings 获取所有自定义symbol映射（副本）
+func (sn *SymbolNormalizer) Mappings() map[string]string {
+	sn.mu.RLock()
+	defer sn.mu.RUnlock()
+
+	mappings := make(map[string]string, len(sn.customMappings))
+	for original, standard := range sn.customMappings {
+		mappings[original] = standard
+	}
+	return mappings
+}
+
 // GetMapping 获取symbol的标准化映射
 func (sn *SymbolNormalizer) GetMapping(symbol string) (string, bool) {
 	sn.mu.RLock()
@@ -597,140 +1151,26 @@ type CustomStrategyToken struct {
 
 // CalculateCustomStrategies 计算所有自定义策略
 func (ps *PriceStore) CalculateCustomStrategies() []*CustomStrategy {
-	ps.mu.RLock()
-	defer ps.mu.RUnlock()
+	// 只在复制数据时持有读锁，计算（包括调试输出）在副本上进行
+	snap := ps.calcSnapshot()
 
 	strategies := make([]*CustomStrategy, 0)
 
-	// 策略1: STG - 0.08634 * ZRO
-	stgZroStrategy := ps.calculateSTGZROStrategy()
-	if stgZroStrategy != nil {
-		strategies = append(strategies, stgZroStrategy)
+	// 策略1: 已注册的比值策略（默认 STG - 0.08634 * ZRO）
+	for _, rs := range snap.ratioStrategies {
+		strategies = append(strategies, snap.calculateRatioStrategy(rs))
 	}
 
 	// 策略2: BTC/SOL/ETH 价差监控 (Aster, Binance, Lighter)
-	multiExchangeStrategies := ps.calculateMultiExchangeSpreadStrategies()
+	multiExchangeStrategies := snap.calculateMultiExchangeSpreadStrategies()
 	strategies = append(strategies, multiExchangeStrategies...)
 
 	return strategies
 }
 
-// calculateSTGZROStrategy 计算 STG - 0.08634 * ZRO 策略
-// 策略类型：+A-B (买入A，卖出B)
-// A = STG, B = ZRO * 0.08634
-// 使用 STG Ask（买入价格）和 ZRO Bid（卖出价格）
-// 绝对价差 = ZRO Bid * 0.08634 - STG Ask
-// 百分比 = (ZRO Bid * 0.08634 - STG Ask) * 2 / (ZRO Bid * 0.08634 + STG Ask) * 100
-func (ps *PriceStore) calculateSTGZROStrategy() *CustomStrategy {
-	const coefficient = 0.08634
-
-	strategy := &CustomStrategy{
-		Name:         "STG-ZRO 价差套利",
-		Description:  "买入STG卖出ZRO的价差套利",
-		Formula:      "(ZRO Bid * 0.08634 - STG Ask) * 2 / (ZRO Bid * 0.08634 + STG Ask) * 100",
-		StrategyType: "+A-B",
-		Components:   make([]CustomStrategyToken, 0),
-		Status:       "unavailable",
-	}
-
-	// 获取 STG 价格（优先选择 Binance SPOT）
-	stgPrice := ps.getBestPrice("STGUSDT", common.ExchangeBinance, common.MarketTypeSpot)
-	if stgPrice == nil {
-		stgPrice = ps.getBestPrice("STGUSDT", common.ExchangeAster, common.MarketTypeSpot)
-	}
-
-	// 获取 ZRO 价格（优先选择 Binance SPOT）
-	zroPrice := ps.getBestPrice("ZROUSDT", common.ExchangeBinance, common.MarketTypeSpot)
-	if zroPrice == nil {
-		zroPrice = ps.getBestPrice("ZROUSDT", common.ExchangeAster, common.MarketTypeSpot)
-	}
-
-	// 获取实际使用的价格
-	var stgAsk, zroBid float64
-
-	// 添加 STG 组件（使用 Ask 价格 - 买入价格）
-	if stgPrice != nil {
-		stgAsk = stgPrice.AskPrice
-		if stgAsk == 0 {
-			stgAsk = stgPrice.Price
-		}
-
-		strategy.Components = append(strategy.Components, CustomStrategyToken{
-			Symbol:      "STG",
-			Coefficient: 1.0,
-			Exchange:    stgPrice.Exchange,
-			MarketType:  stgPrice.MarketType,
-			Price:       stgAsk,
-			Available:   true,
-		})
-	} else {
-		strategy.Components = append(strategy.Components, CustomStrategyToken{
-			Symbol:      "STG",
-			Coefficient: 1.0,
-			Available:   false,
-		})
-	}
-
-	// 添加 ZRO 组件（使用 Bid 价格 - 卖出价格）
-	if zroPrice != nil {
-		zroBid = zroPrice.BidPrice
-		if zroBid == 0 {
-			zroBid = zroPrice.Price
-		}
-
-		strategy.Components = append(strategy.Components, CustomStrategyToken{
-			Symbol:      "ZRO",
-			Coefficient: -coefficient,
-			Exchange:    zroPrice.Exchange,
-			MarketType:  zroPrice.MarketType,
-			Price:       zroBid,
-			Available:   true,
-		})
-	} else {
-		strategy.Components = append(strategy.Components, CustomStrategyToken{
-			Symbol:      "ZRO",
-			Coefficient: -coefficient,
-			Available:   false,
-		})
-	}
-
-	// 计算策略值和百分比
-	if stgPrice != nil && zroPrice != nil && stgAsk > 0 && zroBid > 0 {
-		// B Bid = ZRO Bid * coefficient
-		bBid := zroBid * coefficient
-		// A Ask = STG Ask
-		aAsk := stgAsk
-
-		// 绝对价差: B Bid - A Ask = ZRO Bid * 0.08634 - STG Ask
-		strategy.Value = bBid - aAsk
-
-		// 百分比: (B Bid - A Ask) * 2 / (B Bid + A Ask) * 100
-		if (bBid + aAsk) > 0 {
-			strategy.ValuePercent = (bBid - aAsk) * 2 / (bBid + aAsk) * 100
-		}
-
-		strategy.Status = "ready"
-
-		// 使用较新的更新时间
-		strategy.LastUpdated = stgPrice.LastUpdated
-		if zroPrice.LastUpdated.After(strategy.LastUpdated) {
-			strategy.LastUpdated = zroPrice.LastUpdated
-		}
-	} else if stgPrice != nil || zroPrice != nil {
-		strategy.Status = "partial"
-		if stgPrice != nil {
-			strategy.LastUpdated = stgPrice.LastUpdated
-		} else {
-			strategy.LastUpdated = zroPrice.LastUpdated
-		}
-	}
-
-	return strategy
-}
-
 // ArbitrageOpportunity 套利机会
 type ArbitrageOpportunity struct {
-	Type          string          `json:"type"`               // "major_coin_spread", "stg_zro_spread", "large_cap_spread"
+	Type          string          `json:"type"`               // "major_coin_spread", "stg_zro_spread", "ratio_spread", "large_cap_spread"
 	Symbol        string          `json:"symbol"`             // 币种符号
 	Description   string          `json:"description"`        // 描述
 	SpreadPercent float64         `json:"spread_percent"`     // 价差百分比
@@ -740,13 +1180,75 @@ type ArbitrageOpportunity struct {
 	FirstSeen     time.Time       `json:"first_seen"`         // 首次发现时间
 	Duration      float64         `json:"duration"`           // 持续时长（秒）
 	IsConfirmed   bool            `json:"is_confirmed"`       // 是否确认（持续>=6秒）
+
+	// 实际使用的价差阈值及来源
+	Threshold *AppliedThreshold `json:"threshold,omitempty"`
+
+	// 买卖两腿的市场类型（组合策略如STG-ZRO为空）
+	BuyMarketType  common.MarketType `json:"buy_market_type,omitempty"`
+	SellMarketType common.MarketType `json:"sell_market_type,omitempty"`
+
+	// 两腿报价的置信度（0-1），组合策略如STG-ZRO为0
+	Confidence float64 `json:"confidence"`
+
+	// 执行方式（hedge-both-perps / hedge-spot-perp / transfer-required / same-venue / not-executable）及预计转账时间
+	ExecutionMode   string `json:"execution_mode,omitempty"`
+	TransferMinutes int    `json:"transfer_minutes,omitempty"`
+
+	// 延迟补偿后的价差，nil表示未投影；LATENCY_PROJECTION_FOR_OPPORTUNITIES 开启时按投影后的价差判断阈值
+	Projection *SpreadProjection `json:"projection,omitempty"`
+}
+
+// 套利机会的市场类型组合
+const (
+	PairingAll          = "all"
+	PairingSpotSpot     = "spot-spot"
+	PairingSpotFuture   = "spot-future"
+	PairingFutureSpot   = "future-spot"
+	PairingFutureFuture = "future-future"
+)
+
+// IsValidPairing 检查市场类型组合参数是否合法
+func IsValidPairing(pairing string) bool {
+	switch pairing {
+	case PairingAll, PairingSpotSpot, PairingSpotFuture, PairingFutureSpot, PairingFutureFuture:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pairing 返回买入腿-卖出腿的市场类型组合，例如 "spot-future"
+// 市场类型未知时返回空字符串
+func (o *ArbitrageOpportunity) Pairing() string {
+	if o.BuyMarketType == "" || o.SellMarketType == "" {
+		return ""
+	}
+	return strings.ToLower(string(o.BuyMarketType)) + "-" + strings.ToLower(string(o.SellMarketType))
+}
+
+// FilterOpportunitiesByPairing 按市场类型组合过滤套利机会（all 返回全部）
+func FilterOpportunitiesByPairing(opportunities []*ArbitrageOpportunity, pairing string) []*ArbitrageOpportunity {
+	if pairing == "" || pairing == PairingAll {
+		return opportunities
+	}
+
+	filtered := make([]*ArbitrageOpportunity, 0, len(opportunities))
+	for _, opp := range opportunities {
+		if opp.Pairing() == pairing {
+			filtered = append(filtered, opp)
+		}
+	}
+	return filtered
 }
 
 // opportunityTracker 套利机会跟踪器
 type opportunityTracker struct {
-	FirstSeen     time.Time
-	LastSeen      time.Time
-	SpreadPercent float64
+	FirstSeen         time.Time
+	LastSeen          time.Time
+	SpreadPercent     float64
+	PrevSpreadPercent float64 // 上一次观察到的价差
+	Confirmed         bool    // 是否已确认（用于在确认时开模拟仓位）
 }
 
 // GetArbitrageOpportunities 获取当前可套利策略
@@ -755,62 +1257,52 @@ type opportunityTracker struct {
 // 2. STG-ZRO 价差 >= 0.4%（千4）
 // 3. 大市值币种（市值>2B）价差 >= 0.2%（千2）
 func (ps *PriceStore) GetArbitrageOpportunities() []*ArbitrageOpportunity {
+	return ps.GetArbitrageOpportunitiesTimed(nil)
+}
+
+// GetArbitrageOpportunitiesTimed 获取当前可套利策略，并将各阶段耗时写入 timing（timing 为 nil 时不计时）
+// 读锁内只复制数据和确定检查列表，价差计算在副本上进行，最后在读锁和 trackMu 内更新机会跟踪器
+func (ps *PriceStore) GetArbitrageOpportunitiesTimed(timing *CalcTiming) []*ArbitrageOpportunity {
+	start := timing.begin()
 	ps.mu.RLock()
-	defer ps.mu.RUnlock()
+	start = timing.markLockWait(start)
+	snap := ps.calcSnapshotLocked(true)
+	checks := ps.opportunityChecks()
+	ps.mu.RUnlock()
+	start = timing.markSnapshot(start)
 
 	opportunities := make([]*ArbitrageOpportunity, 0)
-
-	// 定义主流币种（BTC, ETH, SOL）
-	majorCoins := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
-
-	// 定义大市值币种（市值>2B，根据2024-2025年数据）
-	largeCapCoins := map[string]bool{
-		"BTCUSDT":   true, // Bitcoin
-		"ETHUSDT":   true, // Ethereum
-		"SOLUSDT":   true, // Solana
-		"BNBUSDT":   true, // BNB
-		"XRPUSDT":   true, // XRP
-		"ADAUSDT":   true, // Cardano
-		"DOGEUSDT":  true, // Dogecoin
-		"TRXUSDT":   true, // TRON
-		"LINKUSDT":  true, // Chainlink
-		"AVAXUSDT":  true, // Avalanche
-		"DOTUSDT":   true, // Polkadot
-		"MATICUSDT": true, // Polygon
-		"UNIUSDT":   true, // Uniswap
-		"LTCUSDT":   true, // Litecoin
-		"ATOMUSDT":  true, // Cosmos
-	}
-
-	// 1. 检查 BTC/ETH/SOL 价差（千1.5 = 0.15%）
-	for _, coin := range majorCoins {
-		opps := ps.findSpreadOpportunities(coin, 0.15, "major_coin_spread")
-		opportunities = append(opportunities, opps...)
-	}
-
-	// 2. 检查 STG-ZRO 策略价差（千4 = 0.4%）
-	stgZroOpp := ps.checkSTGZROOpportunity(0.4)
-	if stgZroOpp != nil {
-		opportunities = append(opportunities, stgZroOpp)
-	}
-
-	// 3. 检查大市值币种价差（千3 = 0.3%）
-	for coin := range largeCapCoins {
-		// 跳过已经在主流币种中检查过的
-		if coin == "BTCUSDT" || coin == "ETHUSDT" || coin == "SOLUSDT" {
+	observations := make([]pairObservation, 0)
+	for _, check := range checks {
+		if check.ratio != nil {
+			if opp := snap.checkRatioOpportunity(check.ratio, check.threshold); opp != nil {
+				opportunities = append(opportunities, opp)
+				observations = append(observations, newPairObservation(opp.Symbol, opp.Type, opp.BuyFrom, opp.SellTo, opp.SpreadPercent))
+			}
 			continue
 		}
-		opps := ps.findSpreadOpportunities(coin, 0.3, "large_cap_spread")
+		opps, observed := snap.findSpreadOpportunities(check.symbol, check.threshold, check.oppType)
 		opportunities = append(opportunities, opps...)
+		observations = append(observations, observed...)
 	}
 
-	// 4. 更新机会的持续时间和确认状态
-	now := time.Now()
+	start = timing.markCompute(start)
+
+	// 跟踪器和反转记录由 trackMu 保护，存储只需读锁（开模拟仓位要读取报价），其他读取不会被阻塞
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+
+	// 4. 更新机会的持续时间和确认状态（首次发现时间等会序列化，使用UTC）
+	now := time.Now().UTC()
 	currentOppKeys := make(map[string]bool)
 
-	for _, opp := range opportunities {
+	keys := make([]string, len(opportunities))
+	for i, opp := range opportunities {
 		// 生成唯一键
 		key := fmt.Sprintf("%s_%s_%s_%s", opp.Symbol, opp.Type, opp.BuyFrom, opp.SellTo)
+		keys[i] = key
 		currentOppKeys[key] = true
 
 		// 检查历史记录
@@ -826,14 +1318,42 @@ func (ps *PriceStore) GetArbitrageOpportunities() []*ArbitrageOpportunity {
 		} else {
 			// 已存在，更新最后出现时间和价差
 			tracker.LastSeen = now
+			tracker.PrevSpreadPercent = tracker.SpreadPercent
 			tracker.SpreadPercent = opp.SpreadPercent
 		}
+	}
+
+	// 超过跟踪上限时丢弃价差最小的未确认机会，不再返回
+	dropped := ps.enforceOpportunityCapLocked()
+	kept := opportunities[:0]
+	for i, opp := range opportunities {
+		key := keys[i]
+		if dropped[key] {
+			continue
+		}
+		kept = append(kept, opp)
+		tracker := ps.opportunityHistory[key]
+
+		// 出现机会的symbol提升到快速刷新层
+		ps.promoteRefreshTier(opp.Symbol, now)
 
 		// 计算持续时长
 		duration := now.Sub(tracker.FirstSeen).Seconds()
 		opp.FirstSeen = tracker.FirstSeen
 		opp.Duration = duration
 		opp.IsConfirmed = duration >= 6.0 // 持续6秒以上确认
+
+		// 刚被确认的机会开模拟仓位
+		if opp.IsConfirmed && !tracker.Confirmed {
+			tracker.Confirmed = true
+			ps.openPaperPosition(key, opp, now)
+		}
+	}
+	opportunities = kept
+
+	// 检测价差反转（同一交易对的带符号价差穿越零点），包括未达到阈值的交易对
+	for _, obs := range observations {
+		ps.trackPairDirection(obs, now)
 	}
 
 	// 5. 清理过期的历史记录（超过10秒未出现）
@@ -842,19 +1362,93 @@ func (ps *PriceStore) GetArbitrageOpportunities() []*ArbitrageOpportunity {
 			delete(ps.opportunityHistory, key)
 		}
 	}
+	for key, tracker := range ps.pairHistory {
+		if now.Sub(tracker.LastSeen) > pairHistoryTTL {
+			delete(ps.pairHistory, key)
+		}
+	}
 
+	timing.finish(start, len(opportunities))
 	return opportunities
 }
 
-// findSpreadOpportunities 查找指定币种的价差套利机会
-func (ps *PriceStore) findSpreadOpportunities(symbol string, minSpreadPercent float64, oppType string) []*ArbitrageOpportunity {
+// opportunityCheck 一次套利机会检查：价差检查（symbol）或比值策略检查（ratio）
+type opportunityCheck struct {
+	symbol    string
+	oppType   string
+	ratio     *RatioStrategy
+	threshold *AppliedThreshold
+}
+
+// opportunityChecks 确定本轮要检查的symbol/比值策略及实际使用的阈值（调用者需要持有锁）
+// 阈值优先使用按symbol配置的值，其次使用分组阈值
+func (ps *PriceStore) opportunityChecks() []opportunityCheck {
+	checks := make([]opportunityCheck, 0)
+
+	// 定义主流币种（BTC, ETH, SOL）
+	majorCoins := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}
+
+	// 定义大市值币种（市值>2B，根据2024-2025年数据）
+	largeCapCoins := map[string]bool{
+		"BTCUSDT":   true, // Bitcoin
+		"ETHUSDT":   true, // Ethereum
+		"SOLUSDT":   true, // Solana
+		"BNBUSDT":   true, // BNB
+		"XRPUSDT":   true, // XRP
+		"ADAUSDT":   true, // Cardano
+		"DOGEUSDT":  true, // Dogecoin
+		"TRXUSDT":   true, // TRON
+		"LINKUSDT":  true, // Chainlink
+		"AVAXUSDT":  true, // Avalanche
+		"DOTUSDT":   true, // Polkadot
+		"MATICUSDT": true, // Polygon
+		"UNIUSDT":   true, // Uniswap
+		"LTCUSDT":   true, // Litecoin
+		"ATOMUSDT":  true, // Cosmos
+	}
+
+	// 1. 检查 BTC/ETH/SOL 价差（千1.5 = 0.15%）
+	for _, coin := range majorCoins {
+		checks = append(checks, opportunityCheck{symbol: coin, oppType: "major_coin_spread", threshold: ps.resolveThreshold(coin, 0.15, 0)})
+	}
+
+	// 2. 检查比值策略价差（默认 STG-ZRO，千4 = 0.4%）
+	for _, rs := range ps.ratioStrategies {
+		checks = append(checks, opportunityCheck{ratio: rs, threshold: ps.resolveThreshold(rs.Name, rs.Threshold, defaultRatioThreshold)})
+	}
+
+	// 3. 检查大市值币种价差（千3 = 0.3%）
+	for coin := range largeCapCoins {
+		// 跳过已经在主流币种中检查过的
+		if coin == "BTCUSDT" || coin == "ETHUSDT" || coin == "SOLUSDT" {
+			continue
+		}
+		checks = append(checks, opportunityCheck{symbol: coin, oppType: "large_cap_spread", threshold: ps.resolveThreshold(coin, 0.3, 0)})
+	}
+
+	// 3.1 检查不属于任何分组、但单独配置了阈值的币种
+	for coin, value := range ps.thresholdOverrides {
+		if largeCapCoins[coin] || ps.isRatioStrategyName(coin) {
+			continue
+		}
+		threshold := &AppliedThreshold{Value: value, Origin: ThresholdOriginOverride}
+		checks = append(checks, opportunityCheck{symbol: coin, oppType: "custom_threshold_spread", threshold: threshold})
+	}
+
+	return checks
+}
+
+// findSpreadOpportunities 查找指定币种的价差套利机会，同时返回所有参与计算的交易对的带符号价差（用于反转检测）
+func (snap *priceSnapshot) findSpreadOpportunities(symbol string, threshold *AppliedThreshold, oppType string) ([]*ArbitrageOpportunity, []pairObservation) {
 	opportunities := make([]*ArbitrageOpportunity, 0)
+	var observations []pairObservation
+	minSpreadPercent := threshold.Value
 
 	// 获取该币种的所有价格
-	standardSymbol := ps.symbolNormalizer.Normalize(symbol)
-	symbolMap, exists := ps.bySymbol[standardSymbol]
+	standardSymbol := snap.symbolNormalizer.Normalize(symbol)
+	symbolMap, exists := snap.bySymbol[standardSymbol]
 	if !exists {
-		return opportunities
+		return opportunities, observations
 	}
 
 	// 转换为价格列表
@@ -865,8 +1459,8 @@ func (ps *PriceStore) findSpreadOpportunities(symbol string, minSpreadPercent fl
 		}
 	}
 
-	if len(prices) < 2 {
-		return opportunities
+	if len(prices) < snap.minExchangeCount {
+		return opportunities, observations
 	}
 
 	// 提取币种名称
@@ -886,31 +1480,58 @@ func (ps *PriceStore) findSpreadOpportunities(symbol string, minSpreadPercent fl
 				continue
 			}
 
-			// 获取买入和卖出价格
-			askPrice := buyPrice.AskPrice
-			if askPrice == 0 {
-				askPrice = buyPrice.Price
+			// 两个方向的市场类型组合都未允许时跳过（OPPORTUNITY_PAIRINGS）
+			forwardAllowed := snap.pairingAllowed(buyPrice, sellPrice)
+			reverseAllowed := snap.pairingAllowed(sellPrice, buyPrice)
+			if !forwardAllowed && !reverseAllowed {
+				continue
 			}
 
-			bidPrice := sellPrice.BidPrice
-			if bidPrice == 0 {
-				bidPrice = sellPrice.Price
+			// 跳过成交量不足的组合（按两腿中较小的成交量，与价差的 Volume24h 一致）
+			if snap.belowMinOpportunityVolume(buyPrice) || snap.belowMinOpportunityVolume(sellPrice) {
+				continue
 			}
 
+			// 获取买入和卖出价格
+			askPrice := snap.askPrice(buyPrice)
+			bidPrice := snap.bidPrice(sellPrice)
+
 			if askPrice == 0 || bidPrice == 0 {
 				continue
 			}
 
 			// 计算价差百分比（使用统一公式）
 			spreadPercent := (bidPrice - askPrice) * 2 / (bidPrice + askPrice) * 100
+			spreadPercentReverse := (askPrice - bidPrice) * 2 / (askPrice + bidPrice) * 100
+
+			// 不论是否达到阈值都记录两腿中间价的带符号价差（与遍历顺序无关），用于检测穿越零点的价差反转
+			buyLeg := fmt.Sprintf("%s %s", buyPrice.Exchange, buyPrice.MarketType)
+			sellLeg := fmt.Sprintf("%s %s", sellPrice.Exchange, sellPrice.MarketType)
+			buyMid := (askPrice + snap.bidPrice(buyPrice)) / 2
+			sellMid := (bidPrice + snap.askPrice(sellPrice)) / 2
+			midGap := (sellMid - buyMid) * 2 / (sellMid + buyMid) * 100
+			observations = append(observations, newPairObservation(coinName, oppType, buyLeg, sellLeg, midGap))
+
+			// 延迟补偿：较旧一腿按漂移投影，开启 ForOpportunities 时按投影后的价差判断阈值
+			var forwardProjection, reverseProjection *SpreadProjection
+			forwardCheck, reverseCheck := spreadPercent, spreadPercentReverse
+			if proj := snap.projectOlderLeg(buyPrice, sellPrice); proj != nil {
+				projAsk := proj.apply(buyPrice, askPrice)
+				projBid := proj.apply(sellPrice, bidPrice)
+				forwardProjection = proj.report(buyPrice, projAsk, projBid, (projBid-projAsk)*2/(projBid+projAsk)*100)
+				reverseProjection = proj.report(sellPrice, projBid, projAsk, (projAsk-projBid)*2/(projAsk+projBid)*100)
+				if snap.projection.ForOpportunities {
+					forwardCheck, reverseCheck = forwardProjection.SpreadPercent, reverseProjection.SpreadPercent
+				}
+			}
 
 			// 检查是否满足最小价差要求
-			if spreadPercent >= minSpreadPercent {
-				buyFrom := fmt.Sprintf("%s %s", buyPrice.Exchange, buyPrice.MarketType)
-				sellTo := fmt.Sprintf("%s %s", sellPrice.Exchange, sellPrice.MarketType)
+			if forwardAllowed && forwardCheck >= minSpreadPercent {
+				buyFrom, sellTo := buyLeg, sellLeg
 
 				// 创建完整的策略详情
-				strategy := ps.calculateSpreadStrategy(buyPrice, sellPrice)
+				strategy := snap.calculateSpreadStrategy(buyPrice, sellPrice)
+				mode, transferMinutes := snap.deriveExecutionMode(buyPrice.Exchange, buyPrice.MarketType, sellPrice.Exchange, sellPrice.MarketType)
 
 				opportunities = append(opportunities, &ArbitrageOpportunity{
 					Type:          oppType,
@@ -920,17 +1541,25 @@ func (ps *PriceStore) findSpreadOpportunities(symbol string, minSpreadPercent fl
 					BuyFrom:       buyFrom,
 					SellTo:        sellTo,
 					Strategy:      strategy, // 填充完整策略详情
+					Threshold:     threshold,
+
+					BuyMarketType:  buyPrice.MarketType,
+					SellMarketType: sellPrice.MarketType,
+					Confidence:     ScoreConfidence(buyPrice, sellPrice, time.Now(), snap.confidence),
+
+					ExecutionMode:   mode,
+					TransferMinutes: transferMinutes,
+					Projection:      forwardProjection,
 				})
 			}
 
 			// 反向检查（使用统一公式）
-			spreadPercentReverse := (askPrice - bidPrice) * 2 / (askPrice + bidPrice) * 100
-			if spreadPercentReverse >= minSpreadPercent {
-				buyFrom := fmt.Sprintf("%s %s", sellPrice.Exchange, sellPrice.MarketType)
-				sellTo := fmt.Sprintf("%s %s", buyPrice.Exchange, buyPrice.MarketType)
+			if reverseAllowed && reverseCheck >= minSpreadPercent {
+				buyFrom, sellTo := sellLeg, buyLeg
 
 				// 创建完整的策略详情（反向）
-				strategy := ps.calculateSpreadStrategy(sellPrice, buyPrice)
+				strategy := snap.calculateSpreadStrategy(sellPrice, buyPrice)
+				mode, transferMinutes := snap.deriveExecutionMode(sellPrice.Exchange, sellPrice.MarketType, buyPrice.Exchange, buyPrice.MarketType)
 
 				opportunities = append(opportunities, &ArbitrageOpportunity{
 					Type:          oppType,
@@ -940,35 +1569,58 @@ func (ps *PriceStore) findSpreadOpportunities(symbol string, minSpreadPercent fl
 					BuyFrom:       buyFrom,
 					SellTo:        sellTo,
 					Strategy:      strategy, // 填充完整策略详情
+					Threshold:     threshold,
+
+					BuyMarketType:  sellPrice.MarketType,
+					SellMarketType: buyPrice.MarketType,
+					Confidence:     ScoreConfidence(sellPrice, buyPrice, time.Now(), snap.confidence),
+
+					ExecutionMode:   mode,
+					TransferMinutes: transferMinutes,
+					Projection:      reverseProjection,
 				})
 			}
 		}
 	}
 
-	return opportunities
+	return opportunities, observations
 }
 
-// checkSTGZROOpportunity 检查 STG-ZRO 策略套利机会
-func (ps *PriceStore) checkSTGZROOpportunity(minSpreadPercent float64) *ArbitrageOpportunity {
-	strategy := ps.calculateSTGZROStrategy()
-	if strategy == nil || strategy.Status != "ready" {
+// checkRatioOpportunity 检查比值策略套利机会
+func (snap *priceSnapshot) checkRatioOpportunity(rs *RatioStrategy, threshold *AppliedThreshold) *ArbitrageOpportunity {
+	strategy := snap.calculateRatioStrategy(rs)
+	if strategy.Status != "ready" {
 		return nil
 	}
 
 	// 检查价差百分比是否满足条件
-	if strategy.ValuePercent >= minSpreadPercent {
-		return &ArbitrageOpportunity{
-			Type:          "stg_zro_spread",
-			Symbol:        "STG-ZRO",
-			Description:   "STG-ZRO 套利策略",
-			SpreadPercent: strategy.ValuePercent,
-			BuyFrom:       "买入STG",
-			SellTo:        "卖出ZRO",
-			Strategy:      strategy,
-		}
+	if strategy.ValuePercent < threshold.Value {
+		return nil
 	}
 
-	return nil
+	base := common.ParseSymbol(rs.BaseSymbol).BaseAsset
+	quote := common.ParseSymbol(rs.QuoteSymbol).BaseAsset
+	buyFrom, sellTo := "买入"+base, "卖出"+quote
+	if rs.Direction == RatioDirectionSellBase {
+		buyFrom, sellTo = "买入"+quote, "卖出"+base
+	}
+
+	// STG-ZRO 保留原有类型，前端按类型展示
+	oppType := "ratio_spread"
+	if rs.Name == "STG-ZRO" {
+		oppType = "stg_zro_spread"
+	}
+
+	return &ArbitrageOpportunity{
+		Type:          oppType,
+		Symbol:        rs.Name,
+		Description:   rs.Name + " 套利策略",
+		SpreadPercent: strategy.ValuePercent,
+		BuyFrom:       buyFrom,
+		SellTo:        sellTo,
+		Strategy:      strategy,
+		Threshold:     threshold,
+	}
 }
 
 // getBestPrice 获取指定symbol的最佳价格（最近更新的活跃价格）
@@ -998,9 +1650,17 @@ func (ps *PriceStore) getBestPrice(symbol string, preferredExchange common.Excha
 	return nil
 }
 
+// quietStdout 为 true 时不输出多交易所价差和拒绝报价样本等调试信息（--quiet，例如在 systemd 下运行）
+var quietStdout atomic.Bool
+
+// SetQuiet 设置是否关闭标准输出的调试信息（不影响写入日志文件的内容）
+func SetQuiet(quiet bool) {
+	quietStdout.Store(quiet)
+}
+
 // calculateMultiExchangeSpreadStrategies 计算多交易所价差策略
 // 监控 BTC, SOL, ETH 在 Aster, Binance, Lighter 之间的价差
-func (ps *PriceStore) calculateMultiExchangeSpreadStrategies() []*CustomStrategy {
+func (snap *priceSnapshot) calculateMultiExchangeSpreadStrategies() []*CustomStrategy {
 	strategies := make([]*CustomStrategy, 0)
 
 	// 定义要监控的币种
@@ -1023,14 +1683,15 @@ func (ps *PriceStore) calculateMultiExchangeSpreadStrategies() []*CustomStrategy
 		// 获取所有交易所的价格
 		prices := make([]*common.Price, 0)
 		for _, ex := range exchanges {
-			price := ps.getPriceInternal(ex.exchange, ex.marketType, symbol)
+			// 通过标准化symbol索引查找，兼容USDC报价的市场（如Lighter永续）
+			price := snap.bySymbol[symbol][symbolIndexKey(ex.exchange, ex.marketType)]
 			if price != nil && time.Since(price.LastUpdated) <= 60*time.Second {
 				prices = append(prices, price)
 			}
 		}
 
 		// 调试日志：显示找到的价格数量
-		if len(prices) > 0 {
+		if len(prices) > 0 && !quietStdout.Load() {
 			fmt.Printf("[MultiExchange] %s: found %d prices\n", symbol, len(prices))
 		}
 
@@ -1049,26 +1710,29 @@ func (ps *PriceStore) calculateMultiExchangeSpreadStrategies() []*CustomStrategy
 				if buyPrice.Exchange == sellPrice.Exchange && buyPrice.MarketType == sellPrice.MarketType {
 					continue
 				}
-
-				// 计算两个方向的价差
-				strategy1 := ps.calculateSpreadStrategy(buyPrice, sellPrice)
-				if strategy1 != nil {
-					strategies = append(strategies, strategy1)
+				// 计算两个方向的价差（只计算允许的市场类型组合）
+				if snap.pairingAllowed(buyPrice, sellPrice) {
+					if strategy1 := snap.calculateSpreadStrategy(buyPrice, sellPrice); strategy1 != nil {
+						strategies = append(strategies, strategy1)
+					}
 				}
 
-				strategy2 := ps.calculateSpreadStrategy(sellPrice, buyPrice)
-				if strategy2 != nil {
-					strategies = append(strategies, strategy2)
+				if snap.pairingAllowed(sellPrice, buyPrice) {
+					if strategy2 := snap.calculateSpreadStrategy(sellPrice, buyPrice); strategy2 != nil {
+						strategies = append(strategies, strategy2)
+					}
 				}
 			}
 		}
 	}
 
 	// 调试日志：显示生成的策略数量
-	if len(strategies) > 0 {
-		fmt.Printf("[MultiExchange] Generated %d spread strategies\n", len(strategies))
-	} else {
-		fmt.Println("[MultiExchange] No spread strategies generated (waiting for price data...)")
+	if !quietStdout.Load() {
+		if len(strategies) > 0 {
+			fmt.Printf("[MultiExchange] Generated %d spread strategies\n", len(strategies))
+		} else {
+			fmt.Println("[MultiExchange] No spread strategies generated (waiting for price data...)")
+		}
 	}
 
 	return strategies
@@ -1076,17 +1740,10 @@ func (ps *PriceStore) calculateMultiExchangeSpreadStrategies() []*CustomStrategy
 
 // calculateSpreadStrategy 计算单向价差策略
 // buyPrice: 买入价格数据，sellPrice: 卖出价格数据
-func (ps *PriceStore) calculateSpreadStrategy(buyPrice, sellPrice *common.Price) *CustomStrategy {
+func (snap *priceSnapshot) calculateSpreadStrategy(buyPrice, sellPrice *common.Price) *CustomStrategy {
 	// 获取实际使用的价格
-	askPrice := buyPrice.AskPrice
-	if askPrice == 0 {
-		askPrice = buyPrice.Price
-	}
-
-	bidPrice := sellPrice.BidPrice
-	if bidPrice == 0 {
-		bidPrice = sellPrice.Price
-	}
+	askPrice := snap.askPrice(buyPrice)
+	bidPrice := snap.bidPrice(sellPrice)
 
 	if askPrice == 0 || bidPrice == 0 {
 		return nil
@@ -0,0 +1,152 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// 阈值来源
+const (
+	ThresholdOriginOverride = "symbol-override" // 按symbol单独配置
+	ThresholdOriginGroup    = "group"           // 币种分组阈值（主流币/大市值）或比值策略配置的阈值
+	ThresholdOriginDefault  = "default"         // 没有任何配置时使用的内置默认值（例如未设置阈值的比值策略）
+)
+
+// AppliedThreshold 套利机会实际使用的价差阈值
+type AppliedThreshold struct {
+	Value  float64 `json:"value"`  // 阈值（百分比）
+	Origin string  `json:"origin"` // 来源: symbol-override / group / default
+}
+
+// NormalizeThresholdSymbol 标准化阈值配置的symbol（"btc" -> "BTCUSDT"）
+func NormalizeThresholdSymbol(symbol string) string {
+	return common.ParseSymbol(strings.TrimSpace(symbol)).ToStandardSymbol()
+}
+
+// LoadThresholdOverrides 从文件加载按symbol配置的阈值，并设置持久化路径
+// 文件不存在时视为空配置
+func (ps *PriceStore) LoadThresholdOverrides(path string) error {
+	overrides := make(map[string]float64)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read thresholds file: %w", err)
+	}
+	if err == nil && len(data) > 0 {
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return fmt.Errorf("failed to parse thresholds file: %w", err)
+		}
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.thresholdsFile = path
+	ps.thresholdOverrides = make(map[string]float64, len(overrides))
+	for symbol, value := range overrides {
+		ps.thresholdOverrides[NormalizeThresholdSymbol(symbol)] = value
+	}
+	return nil
+}
+
+// GetThresholdOverrides 获取所有按symbol配置的阈值
+func (ps *PriceStore) GetThresholdOverrides() map[string]float64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := make(map[string]float64, len(ps.thresholdOverrides))
+	for symbol, value := range ps.thresholdOverrides {
+		result[symbol] = value
+	}
+	return result
+}
+
+// GetThresholdOverride 获取单个symbol的阈值配置
+func (ps *PriceStore) GetThresholdOverride(symbol string) (float64, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	value, exists := ps.thresholdOverrides[NormalizeThresholdSymbol(symbol)]
+	return value, exists
+}
+
+// SetThresholdOverride 设置单个symbol的阈值并持久化，下一次计算套利机会时生效
+// 先写文件，写入失败时内存中的配置保持不变
+func (ps *PriceStore) SetThresholdOverride(symbol string, value float64) error {
+	if value <= 0 {
+		return fmt.Errorf("threshold must be positive, got %v", value)
+	}
+
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	overrides := ps.copyThresholdOverrides()
+	overrides[NormalizeThresholdSymbol(symbol)] = value
+	if err := ps.saveThresholdOverrides(overrides); err != nil {
+		return err
+	}
+	ps.thresholdOverrides = overrides
+	return nil
+}
+
+// DeleteThresholdOverride 删除单个symbol的阈值配置（恢复分组阈值），写入失败时内存中的配置保持不变
+func (ps *PriceStore) DeleteThresholdOverride(symbol string) error {
+	ps.configMu.Lock()
+	defer ps.configMu.Unlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	overrides := ps.copyThresholdOverrides()
+	delete(overrides, NormalizeThresholdSymbol(symbol))
+	if err := ps.saveThresholdOverrides(overrides); err != nil {
+		return err
+	}
+	ps.thresholdOverrides = overrides
+	return nil
+}
+
+// copyThresholdOverrides 复制当前的阈值配置（调用者需要持有锁）
+func (ps *PriceStore) copyThresholdOverrides() map[string]float64 {
+	overrides := make(map[string]float64, len(ps.thresholdOverrides)+1)
+	for symbol, value := range ps.thresholdOverrides {
+		overrides[symbol] = value
+	}
+	return overrides
+}
+
+// saveThresholdOverrides 将 overrides 写入阈值配置文件（调用者需要持有 configMu）
+// 未设置文件路径时只保存在内存中
+func (ps *PriceStore) saveThresholdOverrides(overrides map[string]float64) error {
+	if ps.thresholdsFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode thresholds: %w", err)
+	}
+
+	if err := common.WriteFileAtomic(ps.thresholdsFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write thresholds file: %w", err)
+	}
+	return nil
+}
+
+// resolveThreshold 获取symbol实际使用的阈值（调用者需要持有锁）
+// 优先symbol配置，其次分组阈值（groupThreshold > 0），都没有时使用 defaultThreshold
+func (ps *PriceStore) resolveThreshold(symbol string, groupThreshold, defaultThreshold float64) *AppliedThreshold {
+	if value, exists := ps.thresholdOverrides[NormalizeThresholdSymbol(symbol)]; exists {
+		return &AppliedThreshold{Value: value, Origin: ThresholdOriginOverride}
+	}
+	if groupThreshold > 0 {
+		return &AppliedThreshold{Value: groupThreshold, Origin: ThresholdOriginGroup}
+	}
+	return &AppliedThreshold{Value: defaultThreshold, Origin: ThresholdOriginDefault}
+}
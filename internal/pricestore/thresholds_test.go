@@ -0,0 +1,125 @@
+package pricestore
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveThresholdPrecedence(t *testing.T) {
+	ps := NewPriceStore()
+	if err := ps.SetThresholdOverride("btc", 0.08); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		symbol     string
+		group      float64
+		fallback   float64
+		wantValue  float64
+		wantOrigin string
+	}{
+		{"override wins over group", "BTCUSDT", 0.15, 0, 0.08, ThresholdOriginOverride},
+		{"group without override", "ETHUSDT", 0.15, 0, 0.15, ThresholdOriginGroup},
+		{"default when nothing configured", "STG-ZRO", 0, defaultRatioThreshold, defaultRatioThreshold, ThresholdOriginDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps.mu.RLock()
+			got := ps.resolveThreshold(tt.symbol, tt.group, tt.fallback)
+			ps.mu.RUnlock()
+			if got.Value != tt.wantValue || got.Origin != tt.wantOrigin {
+				t.Fatalf("threshold = %+v, want %v (%s)", got, tt.wantValue, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+func TestRatioStrategyThresholdOrigin(t *testing.T) {
+	ps := NewPriceStore()
+	if _, err := ps.RegisterRatioStrategy(RatioStrategy{Name: "AAVE-UNI", BaseSymbol: "AAVE", QuoteSymbol: "UNI", Coefficient: 12.3, Threshold: 0.6}); err != nil {
+		t.Fatal(err)
+	}
+
+	ps.mu.RLock()
+	checks := ps.opportunityChecks()
+	ps.mu.RUnlock()
+
+	origins := make(map[string]*AppliedThreshold)
+	for _, check := range checks {
+		if check.ratio != nil {
+			origins[check.ratio.Name] = check.threshold
+		}
+	}
+	if got := origins["STG-ZRO"]; got == nil || got.Origin != ThresholdOriginDefault || got.Value != defaultRatioThreshold {
+		t.Fatalf("STG-ZRO threshold = %+v, want default %v", got, defaultRatioThreshold)
+	}
+	if got := origins["AAVE-UNI"]; got == nil || got.Origin != ThresholdOriginGroup || got.Value != 0.6 {
+		t.Fatalf("AAVE-UNI threshold = %+v, want configured 0.6", got)
+	}
+}
+
+func TestThresholdOverridePersistsBeforeApplying(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "thresholds.json")
+	ps := NewPriceStore()
+	if err := ps.LoadThresholdOverrides(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.SetThresholdOverride("BTC", 0.08); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]float64
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved["BTCUSDT"] != 0.08 {
+		t.Fatalf("saved = %v", saved)
+	}
+
+	// 目录被删除后写入失败，内存中的配置保持不变
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.SetThresholdOverride("ETH", 0.2); err == nil {
+		t.Fatal("set succeeded without a writable file")
+	}
+	if _, exists := ps.GetThresholdOverride("ETH"); exists {
+		t.Fatal("failed set changed the in-memory overrides")
+	}
+	if err := ps.DeleteThresholdOverride("BTC"); err == nil {
+		t.Fatal("delete succeeded without a writable file")
+	}
+	if value, exists := ps.GetThresholdOverride("BTC"); !exists || value != 0.08 {
+		t.Fatal("failed delete changed the in-memory overrides")
+	}
+}
+
+func TestRegisterRatioStrategyPersistsBeforeApplying(t *testing.T) {
+	dir := t.TempDir()
+	ps := NewPriceStore()
+	if err := ps.LoadRatioStrategies(filepath.Join(dir, "strategies.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ps.RegisterRatioStrategy(RatioStrategy{Name: "AAVE-UNI", BaseSymbol: "AAVE", QuoteSymbol: "UNI", Coefficient: 12.3}); err == nil {
+		t.Fatal("register succeeded without a writable file")
+	}
+	if removed, err := ps.RemoveRatioStrategy("STG-ZRO"); !removed || err == nil {
+		t.Fatalf("remove = %v, %v; want found with a write error", removed, err)
+	}
+	strategies := ps.GetRatioStrategies()
+	if len(strategies) != 1 || strategies[0].Name != "STG-ZRO" {
+		t.Fatalf("strategies = %+v, want only the default STG-ZRO", strategies)
+	}
+}
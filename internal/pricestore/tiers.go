@@ -0,0 +1,202 @@
+package pricestore
+
+import (
+	"sort"
+	"time"
+)
+
+// 刷新分层：关注列表和正在出现套利机会的symbol走快速层，其余symbol只随全量刷新更新
+const (
+	RefreshTierFast = "fast"
+	RefreshTierFull = "full"
+)
+
+// RefreshTierConfig 成交量等补充数据的分层刷新配置
+type RefreshTierConfig struct {
+	FastInterval time.Duration // 快速层刷新间隔（逐个symbol请求）
+	FullInterval time.Duration // 全量刷新间隔
+	PromoteFor   time.Duration // 出现套利机会的symbol提升到快速层的时长
+	MaxPromoted  int           // 同时提升到快速层的symbol上限（不含关注列表），0表示不限制
+}
+
+// DefaultRefreshTierConfig 默认分层刷新配置（快速层60秒，全量15分钟，机会提升10分钟，最多提升20个symbol）
+func DefaultRefreshTierConfig() *RefreshTierConfig {
+	return &RefreshTierConfig{
+		FastInterval: 60 * time.Second,
+		FullInterval: 15 * time.Minute,
+		PromoteFor:   10 * time.Minute,
+		MaxPromoted:  20,
+	}
+}
+
+// TierRefresh 单个数据源各刷新层的最近刷新情况
+type TierRefresh struct {
+	LastFast    time.Time `json:"last_fast"`
+	LastFull    time.Time `json:"last_full"`
+	FastSymbols int       `json:"fast_symbols"` // 最近一次快速刷新的symbol数
+	FullSymbols int       `json:"full_symbols"` // 最近一次全量刷新的symbol数
+
+	// 最近一轮报价与成交量的对账结果：有报价但没有24hr成交量的symbol数（成交量按未知处理），
+	// 以及有24hr成交量但本轮没有报价的symbol数（通常是上币/下币过程中两个接口不一致）
+	SymbolsMissingVolume int `json:"symbols_missing_volume"`
+	SymbolsMissingQuote  int `json:"symbols_missing_quote"`
+}
+
+// SetRefreshTiers 设置分层刷新配置（nil表示默认）和快速层关注列表（例如 MONITOR_SYMBOLS）
+func (ps *PriceStore) SetRefreshTiers(cfg *RefreshTierConfig, watchlist []string) {
+	if cfg == nil {
+		cfg = DefaultRefreshTierConfig()
+	}
+
+	symbols := make(map[string]bool, len(watchlist))
+	for _, symbol := range watchlist {
+		if symbol = NormalizeThresholdSymbol(symbol); symbol != "" {
+			symbols[symbol] = true
+		}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.tierConfig = cfg
+	ps.tierWatchlist = symbols
+}
+
+// promoteRefreshTier 将出现套利机会的symbol提升到快速层（调用者需要持有 mu 读锁和 trackMu）
+// 快速层每个symbol单独请求，提升的symbol数达到 MaxPromoted 时替换最早到期的提升；
+// 没有比本次更早到期的提升（例如同一轮内已提升满）时不提升，已提升的symbol保持稳定
+func (ps *PriceStore) promoteRefreshTier(symbol string, now time.Time) {
+	cfg := ps.tierConfig
+	if cfg == nil || cfg.PromoteFor <= 0 {
+		return
+	}
+	symbol = NormalizeThresholdSymbol(symbol)
+	if ps.tierWatchlist[symbol] {
+		return
+	}
+
+	until := now.Add(cfg.PromoteFor)
+	if _, promoted := ps.tierPromoted[symbol]; !promoted && cfg.MaxPromoted > 0 {
+		ps.prunePromotedLocked(now)
+		if len(ps.tierPromoted) >= cfg.MaxPromoted {
+			earliest, earliestUntil := "", until
+			for s, u := range ps.tierPromoted {
+				if u.Before(earliestUntil) || (earliest != "" && u.Equal(earliestUntil) && s < earliest) {
+					earliest, earliestUntil = s, u
+				}
+			}
+			if earliest == "" {
+				return
+			}
+			delete(ps.tierPromoted, earliest)
+		}
+	}
+	ps.tierPromoted[symbol] = until
+}
+
+// prunePromotedLocked 清理已过期的提升（调用者需要持有 trackMu）
+func (ps *PriceStore) prunePromotedLocked(now time.Time) {
+	for symbol, until := range ps.tierPromoted {
+		if !now.Before(until) {
+			delete(ps.tierPromoted, symbol)
+		}
+	}
+}
+
+// IsFastTierSymbol 判断symbol（交易所原始symbol或标准symbol）在 now 时刻是否属于快速层
+func (ps *PriceStore) IsFastTierSymbol(symbol string, now time.Time) bool {
+	standardSymbol := NormalizeThresholdSymbol(symbol)
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	if ps.tierWatchlist[standardSymbol] {
+		return true
+	}
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+	until, promoted := ps.tierPromoted[standardSymbol]
+	return promoted && now.Before(until)
+}
+
+// FastTierSymbols 返回 now 时刻属于快速层的标准symbol（关注列表加上仍在提升期内的symbol）
+func (ps *PriceStore) FastTierSymbols(now time.Time) []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+	return ps.fastTierSymbols(now)
+}
+
+// fastTierSymbols 快速层symbol列表（调用者需要持有 mu 读锁和 trackMu）
+func (ps *PriceStore) fastTierSymbols(now time.Time) []string {
+	symbols := make([]string, 0, len(ps.tierWatchlist)+len(ps.tierPromoted))
+	for symbol := range ps.tierWatchlist {
+		symbols = append(symbols, symbol)
+	}
+	for symbol, until := range ps.tierPromoted {
+		if !ps.tierWatchlist[symbol] && now.Before(until) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// DueRefreshTier 返回数据源在 now 时刻应执行的刷新层，没有到期的刷新时返回空字符串
+// 从未全量刷新或全量间隔已到时返回全量层（全量刷新同时覆盖快速层）
+func (ps *PriceStore) DueRefreshTier(source string, now time.Time) string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	cfg := ps.tierConfig
+	if cfg == nil {
+		cfg = DefaultRefreshTierConfig()
+	}
+	refresh := ps.tierRefresh[source]
+	if refresh == nil || now.Sub(refresh.LastFull) >= cfg.FullInterval {
+		return RefreshTierFull
+	}
+
+	lastFast := refresh.LastFast
+	if refresh.LastFull.After(lastFast) {
+		lastFast = refresh.LastFull
+	}
+	if now.Sub(lastFast) >= cfg.FastInterval {
+		return RefreshTierFast
+	}
+	return ""
+}
+
+// RecordVolumeCoverage 记录数据源最近一轮报价与24hr成交量的对账结果（/api/stats refresh_tiers）
+func (ps *PriceStore) RecordVolumeCoverage(source string, missingVolume, missingQuote int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	refresh := ps.tierRefresh[source]
+	if refresh == nil {
+		refresh = &TierRefresh{}
+		ps.tierRefresh[source] = refresh
+	}
+	refresh.SymbolsMissingVolume = missingVolume
+	refresh.SymbolsMissingQuote = missingQuote
+}
+
+// MarkTierRefreshed 记录数据源完成一次刷新，并清理已过期的提升
+func (ps *PriceStore) MarkTierRefreshed(source, tier string, now time.Time, symbols int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	refresh := ps.tierRefresh[source]
+	if refresh == nil {
+		refresh = &TierRefresh{}
+		ps.tierRefresh[source] = refresh
+	}
+	switch tier {
+	case RefreshTierFull:
+		refresh.LastFull = now.UTC()
+		refresh.FullSymbols = symbols
+	case RefreshTierFast:
+		refresh.LastFast = now.UTC()
+		refresh.FastSymbols = symbols
+	}
+	ps.prunePromotedLocked(now)
+}
@@ -0,0 +1,107 @@
+package pricestore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTierTestStore(maxPromoted int, watchlist ...string) *PriceStore {
+	ps := NewPriceStore()
+	ps.SetRefreshTiers(&RefreshTierConfig{
+		FastInterval: time.Minute,
+		FullInterval: 15 * time.Minute,
+		PromoteFor:   10 * time.Minute,
+		MaxPromoted:  maxPromoted,
+	}, watchlist)
+	return ps
+}
+
+func (ps *PriceStore) promoteAt(symbol string, now time.Time) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	ps.trackMu.Lock()
+	defer ps.trackMu.Unlock()
+	ps.promoteRefreshTier(symbol, now)
+}
+
+func TestRefreshTierPromotionExpires(t *testing.T) {
+	ps := newTierTestStore(0, "BTCUSDT")
+	t0 := time.Unix(1700000000, 0)
+
+	ps.promoteAt("ethusdt", t0)
+	if !ps.IsFastTierSymbol("ETHUSDT", t0.Add(9*time.Minute)) {
+		t.Fatal("ETHUSDT not fast while promoted")
+	}
+	if ps.IsFastTierSymbol("ETHUSDT", t0.Add(10*time.Minute)) {
+		t.Fatal("ETHUSDT still fast after PromoteFor")
+	}
+
+	// 再次出现机会时延长提升期
+	ps.promoteAt("ETHUSDT", t0.Add(5*time.Minute))
+	if !ps.IsFastTierSymbol("ETHUSDT", t0.Add(14*time.Minute)) {
+		t.Fatal("re-promotion did not extend")
+	}
+
+	if !ps.IsFastTierSymbol("BTCUSDT", t0.Add(time.Hour)) {
+		t.Fatal("watchlist symbol not fast")
+	}
+	if got := ps.FastTierSymbols(t0.Add(20 * time.Minute)); !reflect.DeepEqual(got, []string{"BTCUSDT"}) {
+		t.Fatalf("fast symbols = %v, want only the watchlist", got)
+	}
+}
+
+func TestRefreshTierPromotionCap(t *testing.T) {
+	ps := newTierTestStore(2, "BTCUSDT")
+	t0 := time.Unix(1700000000, 0)
+
+	ps.promoteAt("BTCUSDT", t0) // 关注列表不占用提升名额
+	ps.promoteAt("AAAUSDT", t0)
+	ps.promoteAt("BBBUSDT", t0)
+	// 同一轮内提升满后不再替换，已提升的保持稳定
+	ps.promoteAt("CCCUSDT", t0)
+	if got := ps.FastTierSymbols(t0); !reflect.DeepEqual(got, []string{"AAAUSDT", "BBBUSDT", "BTCUSDT"}) {
+		t.Fatalf("fast symbols = %v", got)
+	}
+
+	// 之后出现的机会替换最早到期的提升（同时到期时按symbol）
+	ps.promoteAt("DDDUSDT", t0.Add(2*time.Minute))
+	if got := ps.FastTierSymbols(t0.Add(2 * time.Minute)); !reflect.DeepEqual(got, []string{"BBBUSDT", "BTCUSDT", "DDDUSDT"}) {
+		t.Fatalf("fast symbols after eviction = %v", got)
+	}
+
+	// 已过期的提升不占名额
+	ps.promoteAt("EEEUSDT", t0.Add(10*time.Minute+30*time.Second))
+	if got := ps.FastTierSymbols(t0.Add(10*time.Minute + 30*time.Second)); !reflect.DeepEqual(got, []string{"BTCUSDT", "DDDUSDT", "EEEUSDT"}) {
+		t.Fatalf("fast symbols after expiry = %v", got)
+	}
+}
+
+func TestDueRefreshTierCadence(t *testing.T) {
+	ps := newTierTestStore(0)
+	t0 := time.Unix(1700000000, 0)
+	const source = "test_24hr"
+
+	steps := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, RefreshTierFull},
+		{30 * time.Second, ""},
+		{time.Minute, RefreshTierFast},
+		{90 * time.Second, ""},
+		{2 * time.Minute, RefreshTierFast},
+		{15 * time.Minute, RefreshTierFull},
+		{15*time.Minute + 59*time.Second, ""},
+	}
+	for _, step := range steps {
+		now := t0.Add(step.offset)
+		got := ps.DueRefreshTier(source, now)
+		if got != step.want {
+			t.Fatalf("+%v: due = %q, want %q", step.offset, got, step.want)
+		}
+		if got != "" {
+			ps.MarkTierRefreshed(source, got, now, 1)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package pricestore
+
+import "time"
+
+// CalcTiming 计算类方法的分阶段耗时（用于排查接口慢在哪里）
+// 方法接收 nil 时不计时，开销可以忽略
+type CalcTiming struct {
+	LockWait time.Duration // 等待锁的耗时
+	Snapshot time.Duration // 持有读锁复制计算数据的耗时
+	Compute  time.Duration // 遍历价格、计算价差的耗时
+	Post     time.Duration // 排序/机会跟踪等后处理耗时
+	Results  int           // 结果数量
+}
+
+// begin 开始计时（nil 时返回零值，不调用 time.Now）
+func (t *CalcTiming) begin() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// markLockWait 记录等待锁的耗时，返回下一阶段的起点
+func (t *CalcTiming) markLockWait(start time.Time) time.Time {
+	if t == nil {
+		return start
+	}
+	now := time.Now()
+	t.LockWait = now.Sub(start)
+	return now
+}
+
+// markSnapshot 记录复制数据的耗时，返回下一阶段的起点
+func (t *CalcTiming) markSnapshot(start time.Time) time.Time {
+	if t == nil {
+		return start
+	}
+	now := time.Now()
+	t.Snapshot = now.Sub(start)
+	return now
+}
+
+// markCompute 记录计算耗时，返回下一阶段的起点
+func (t *CalcTiming) markCompute(start time.Time) time.Time {
+	if t == nil {
+		return start
+	}
+	now := time.Now()
+	t.Compute = now.Sub(start)
+	return now
+}
+
+// finish 记录后处理耗时和结果数量
+func (t *CalcTiming) finish(start time.Time, results int) {
+	if t == nil {
+		return
+	}
+	t.Post = time.Since(start)
+	t.Results = results
+}
@@ -0,0 +1,81 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+func TestUpdatePriceStoresCopy(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	input := projectionQuote(common.ExchangeBinance, 100, 100.1, now, now)
+	if !ps.UpdatePrice(input) {
+		t.Fatal("update rejected")
+	}
+
+	// 调用者在写入后修改自己的结构体
+	input.BidPrice = 1
+	input.AskPrice = 2
+	input.BidQty = 0
+	input.Symbol = "ETHUSDT"
+
+	stored := ps.GetPrice(common.ExchangeBinance, common.MarketTypeFuture, "BTCUSDT")
+	if stored == nil {
+		t.Fatal("price missing after caller mutated its input")
+	}
+	if stored == input {
+		t.Fatal("store retained the caller's pointer")
+	}
+	if stored.BidPrice != 100 || stored.AskPrice != 100.1 || stored.BidQty != 10 || stored.Symbol != "BTCUSDT" {
+		t.Fatalf("stored price changed with the input: %+v", stored)
+	}
+	for _, p := range ps.GetPricesBySymbol("BTCUSDT") {
+		if p.BidPrice != 100 {
+			t.Fatalf("symbol index changed with the input: %+v", p)
+		}
+	}
+	if len(ps.GetPricesBySymbol("ETHUSDT")) != 0 {
+		t.Fatal("mutated symbol leaked into the symbol index")
+	}
+}
+
+func TestUpdatePriceAllowsReusingInput(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	input := projectionQuote(common.ExchangeBinance, 100, 100.1, now, now)
+	ps.UpdatePrice(input)
+	first := ps.GetPrice(common.ExchangeBinance, common.MarketTypeFuture, "BTCUSDT")
+
+	// 复用同一个结构体写入下一条报价
+	input.BidPrice, input.AskPrice = 101, 101.1
+	input.Timestamp = now.Add(time.Second)
+	input.LastUpdated = now.Add(time.Second)
+	if !ps.UpdatePrice(input) {
+		t.Fatal("reused input rejected")
+	}
+
+	second := ps.GetPrice(common.ExchangeBinance, common.MarketTypeFuture, "BTCUSDT")
+	if second.BidPrice != 101 {
+		t.Fatalf("latest bid = %v, want 101", second.BidPrice)
+	}
+	if first.BidPrice != 100 {
+		t.Fatalf("earlier reader's price changed to %v", first.BidPrice)
+	}
+}
+
+// BenchmarkUpdatePrice 含复制的单条写入开销（同一结构体反复复用）
+func BenchmarkUpdatePrice(b *testing.B) {
+	ps := NewPriceStore()
+	base := time.Now()
+	input := projectionQuote(common.ExchangeBinance, 100, 100.1, base, base)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ts := base.Add(time.Duration(i) * time.Microsecond)
+		input.Timestamp = ts
+		input.LastUpdated = ts
+		ps.UpdatePrice(input)
+	}
+}
@@ -0,0 +1,227 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"time"
+)
+
+// UpdateRule 决定一次价格更新被接受或拒绝的新鲜度规则（见 updateDecision）
+type UpdateRule uint8
+
+const (
+	UpdateRuleFirst            UpdateRule = iota // 没有现有数据，直接接受
+	UpdateRuleExistingStale                      // 规则1：现有数据超过60秒未更新，接受
+	UpdateRuleWSOverREST                         // 规则2：现有数据是WebSocket、新数据是REST，拒绝
+	UpdateRuleRESTToWS                           // 规则3：现有数据是REST、新数据是WebSocket，接受
+	UpdateRuleNewerTimestamp                     // 规则4：交易所时间更新，接受
+	UpdateRuleNewerLastUpdated                   // 规则5：接收时间更新，接受
+	UpdateRuleNotNewer                           // 都不满足：不比现有数据新，拒绝
+	numUpdateRules
+)
+
+// updateRuleNames 规则名称（统计和调试输出使用）
+var updateRuleNames = [numUpdateRules]string{
+	UpdateRuleFirst:            "first_update",
+	UpdateRuleExistingStale:    "existing_stale",
+	UpdateRuleWSOverREST:       "ws_over_rest",
+	UpdateRuleRESTToWS:         "rest_replaced_by_ws",
+	UpdateRuleNewerTimestamp:   "newer_timestamp",
+	UpdateRuleNewerLastUpdated: "newer_last_updated",
+	UpdateRuleNotNewer:         "not_newer",
+}
+
+// String 规则名称
+func (r UpdateRule) String() string {
+	if r < numUpdateRules {
+		return updateRuleNames[r]
+	}
+	return "unknown"
+}
+
+// defaultUpdateDebugSize 每个调试symbol保留的更新记录数
+const defaultUpdateDebugSize = 100
+
+// UpdatePriceSummary 调试记录中的价格摘要
+type UpdatePriceSummary struct {
+	BidPrice    float64            `json:"bid_price"`
+	AskPrice    float64            `json:"ask_price"`
+	Source      common.PriceSource `json:"source"`
+	Timestamp   time.Time          `json:"timestamp"`
+	LastUpdated time.Time          `json:"last_updated"`
+}
+
+// UpdateDecision 一次更新尝试的调试记录
+type UpdateDecision struct {
+	Time       time.Time           `json:"time"`
+	Exchange   common.Exchange     `json:"exchange"`
+	MarketType common.MarketType   `json:"market_type"`
+	Symbol     string              `json:"symbol"` // 交易所原始symbol
+	Accepted   bool                `json:"accepted"`
+	Rule       string              `json:"rule"`
+	Incoming   UpdatePriceSummary  `json:"incoming"`
+	Existing   *UpdatePriceSummary `json:"existing,omitempty"` // 首次更新时为空
+}
+
+// updateDebugRing 单个symbol的更新记录环形缓冲（预分配，记录时不产生额外分配）
+type updateDebugRing struct {
+	entries []updateDebugEntry
+	next    int
+	full    bool
+}
+
+// updateDebugEntry 环形缓冲中的原始记录
+type updateDebugEntry struct {
+	time        time.Time
+	exchange    common.Exchange
+	marketType  common.MarketType
+	symbol      string
+	rule        UpdateRule
+	accepted    bool
+	incoming    UpdatePriceSummary
+	existing    UpdatePriceSummary
+	hasExisting bool
+}
+
+// UpdateRuleStats 各规则接受/拒绝的更新次数
+type UpdateRuleStats struct {
+	AcceptedByRule map[string]uint64 `json:"accepted_by_rule"`
+	RejectedByRule map[string]uint64 `json:"rejected_by_rule"`
+}
+
+// recordUpdateDecision 计数并（对开启调试的symbol）记录一次更新决定（调用者需要持有写锁）
+func (ps *PriceStore) recordUpdateDecision(standardSymbol string, existing, incoming *common.Price, accepted bool, rule UpdateRule, now time.Time) {
+	if accepted {
+		ps.updateAccepted[rule]++
+	} else {
+		ps.updateRejected[rule]++
+	}
+
+	if len(ps.updateDebug) == 0 {
+		return
+	}
+	ring := ps.updateDebug[standardSymbol]
+	if ring == nil {
+		return
+	}
+
+	entry := &ring.entries[ring.next]
+	entry.time = now
+	entry.exchange = incoming.Exchange
+	entry.marketType = incoming.MarketType
+	entry.symbol = incoming.Symbol
+	entry.rule = rule
+	entry.accepted = accepted
+	entry.incoming = summarizeUpdatePrice(incoming)
+	entry.hasExisting = existing != nil
+	if existing != nil {
+		entry.existing = summarizeUpdatePrice(existing)
+	}
+
+	ring.next++
+	if ring.next == len(ring.entries) {
+		ring.next = 0
+		ring.full = true
+	}
+}
+
+// summarizeUpdatePrice 价格摘要
+func summarizeUpdatePrice(price *common.Price) UpdatePriceSummary {
+	return UpdatePriceSummary{
+		BidPrice:    price.BidPrice,
+		AskPrice:    price.AskPrice,
+		Source:      price.Source,
+		Timestamp:   price.Timestamp,
+		LastUpdated: price.LastUpdated,
+	}
+}
+
+// EnableUpdateDebug 开始记录某个symbol最近 size 次更新尝试（size <= 0 时使用默认值100），已开启时清空重新记录
+func (ps *PriceStore) EnableUpdateDebug(symbol string, size int) string {
+	if size <= 0 {
+		size = defaultUpdateDebugSize
+	}
+	standardSymbol := ps.symbolNormalizer.Normalize(NormalizeThresholdSymbol(symbol))
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.updateDebug[standardSymbol] = &updateDebugRing{entries: make([]updateDebugEntry, size)}
+	return standardSymbol
+}
+
+// DisableUpdateDebug 停止记录某个symbol的更新尝试，返回之前是否开启
+func (ps *PriceStore) DisableUpdateDebug(symbol string) bool {
+	standardSymbol := ps.symbolNormalizer.Normalize(NormalizeThresholdSymbol(symbol))
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	_, exists := ps.updateDebug[standardSymbol]
+	delete(ps.updateDebug, standardSymbol)
+	return exists
+}
+
+// GetUpdateDebug 获取某个symbol记录的更新尝试（按时间从旧到新），未开启调试时返回 false
+func (ps *PriceStore) GetUpdateDebug(symbol string) ([]UpdateDecision, bool) {
+	standardSymbol := ps.symbolNormalizer.Normalize(NormalizeThresholdSymbol(symbol))
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	ring := ps.updateDebug[standardSymbol]
+	if ring == nil {
+		return nil, false
+	}
+
+	count, start := ring.next, 0
+	if ring.full {
+		count, start = len(ring.entries), ring.next
+	}
+
+	decisions := make([]UpdateDecision, 0, count)
+	for i := 0; i < count; i++ {
+		entry := &ring.entries[(start+i)%len(ring.entries)]
+		decision := UpdateDecision{
+			Time:       entry.time,
+			Exchange:   entry.exchange,
+			MarketType: entry.marketType,
+			Symbol:     entry.symbol,
+			Accepted:   entry.accepted,
+			Rule:       entry.rule.String(),
+			Incoming:   entry.incoming,
+		}
+		if entry.hasExisting {
+			existing := entry.existing
+			decision.Existing = &existing
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, true
+}
+
+// UpdateDebugSymbols 返回开启了更新调试的symbol
+func (ps *PriceStore) UpdateDebugSymbols() []string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	symbols := make([]string, 0, len(ps.updateDebug))
+	for symbol := range ps.updateDebug {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// updateRuleStats 各规则的计数（调用者需要持有锁）
+func (ps *PriceStore) updateRuleStats() UpdateRuleStats {
+	stats := UpdateRuleStats{
+		AcceptedByRule: make(map[string]uint64),
+		RejectedByRule: make(map[string]uint64),
+	}
+	for rule := UpdateRule(0); rule < numUpdateRules; rule++ {
+		if n := ps.updateAccepted[rule]; n > 0 {
+			stats.AcceptedByRule[rule.String()] = n
+		}
+		if n := ps.updateRejected[rule]; n > 0 {
+			stats.RejectedByRule[rule.String()] = n
+		}
+	}
+	return stats
+}
@@ -0,0 +1,90 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"testing"
+	"time"
+)
+
+func TestUpdateDecisionRules(t *testing.T) {
+	now := time.Now()
+	quote := func(source common.PriceSource, ts, received time.Time) *common.Price {
+		price := projectionQuote(common.ExchangeBinance, 100, 101, ts, received)
+		price.Source = source
+		return price
+	}
+	ws, rest := common.PriceSourceWebSocket, common.PriceSourceREST
+	existingTS, existingReceived := now.Add(-2*time.Second), now.Add(-time.Second)
+
+	tests := []struct {
+		name         string
+		existing     *common.Price
+		incoming     *common.Price
+		wantAccepted bool
+		wantRule     UpdateRule
+	}{
+		{"first update", nil, quote(ws, now, now), true, UpdateRuleFirst},
+		{"existing stale accepts older REST",
+			quote(ws, now.Add(-2*time.Minute), now.Add(-61*time.Second)),
+			quote(rest, now.Add(-3*time.Minute), now), true, UpdateRuleExistingStale},
+		{"REST does not replace fresh WS",
+			quote(ws, existingTS, existingReceived), quote(rest, now, now), false, UpdateRuleWSOverREST},
+		{"WS replaces REST",
+			quote(rest, existingTS, existingReceived), quote(ws, existingTS, existingReceived), true, UpdateRuleRESTToWS},
+		{"accept newer exchange time",
+			quote(ws, existingTS, existingReceived), quote(ws, existingTS.Add(time.Millisecond), existingReceived), true, UpdateRuleNewerTimestamp},
+		{"same exchange time received later",
+			quote(ws, existingTS, existingReceived), quote(ws, existingTS, now), true, UpdateRuleNewerLastUpdated},
+		{"identical quote",
+			quote(ws, existingTS, existingReceived), quote(ws, existingTS, existingReceived), false, UpdateRuleNotNewer},
+		{"sequence regression",
+			quote(ws, existingTS, existingReceived), quote(ws, existingTS.Add(-time.Second), existingReceived.Add(-time.Second)), false, UpdateRuleNotNewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accepted, rule := updateDecision(tt.existing, tt.incoming, now)
+			if accepted != tt.wantAccepted || rule != tt.wantRule {
+				t.Fatalf("updateDecision = %v, %s; want %v, %s", accepted, rule, tt.wantAccepted, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestUpdateDebugRecordsDecisions(t *testing.T) {
+	ps := NewPriceStore()
+	if symbol := ps.EnableUpdateDebug("BTC", 2); symbol != "BTCUSDT" {
+		t.Fatalf("debug enabled for %q, want BTCUSDT", symbol)
+	}
+
+	ts := time.Now()
+	first := projectionQuote(common.ExchangeBinance, 100, 101, ts, ts)
+	ps.UpdatePrice(first)
+	ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 99, 100, ts.Add(-time.Second), ts.Add(-time.Second)))
+	ps.UpdatePrice(projectionQuote(common.ExchangeBinance, 102, 103, ts.Add(time.Second), ts.Add(time.Second)))
+
+	// 环形缓冲只保留最近2次，按时间从旧到新
+	decisions, ok := ps.GetUpdateDebug("BTCUSDT")
+	if !ok || len(decisions) != 2 {
+		t.Fatalf("decisions = %+v, %v; want the last 2", decisions, ok)
+	}
+	regression, newer := decisions[0], decisions[1]
+	if regression.Accepted || regression.Rule != "not_newer" || regression.Incoming.BidPrice != 99 ||
+		regression.Existing == nil || regression.Existing.BidPrice != 100 {
+		t.Fatalf("regression decision = %+v", regression)
+	}
+	if !newer.Accepted || newer.Rule != "newer_timestamp" || newer.Existing == nil || newer.Existing.BidPrice != 100 {
+		t.Fatalf("newer decision = %+v", newer)
+	}
+
+	rules := ps.GetStats().UpdateRules
+	if rules.AcceptedByRule["first_update"] != 1 || rules.AcceptedByRule["newer_timestamp"] != 1 || rules.RejectedByRule["not_newer"] != 1 {
+		t.Fatalf("rule stats = %+v", rules)
+	}
+
+	if !ps.DisableUpdateDebug("BTC") {
+		t.Fatal("DisableUpdateDebug reported debug was off")
+	}
+	if _, ok := ps.GetUpdateDebug("BTCUSDT"); ok {
+		t.Fatal("debug still enabled after disable")
+	}
+}
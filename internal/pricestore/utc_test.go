@@ -0,0 +1,66 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useLocalZone 测试期间把主机时区设为 UTC+8，序列化的时间不能带主机的时区偏移
+func useLocalZone(t *testing.T) {
+	t.Helper()
+	saved := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	t.Cleanup(func() { time.Local = saved })
+}
+
+// assertUTCJSON 时间的JSON编码以 Z 结尾
+func assertUTCJSON(t *testing.T, name string, ts time.Time) {
+	t.Helper()
+	if ts.IsZero() {
+		t.Fatalf("%s is zero", name)
+	}
+	encoded, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(encoded), `Z"`) {
+		t.Fatalf("%s serialized as %s, want UTC", name, encoded)
+	}
+}
+
+func TestTimestampsSerializeAsUTC(t *testing.T) {
+	useLocalZone(t)
+	ps := NewPriceStore()
+	if err := ps.SetThresholdOverride("BTCUSDT", 0.1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 交易所适配器用本地时区构造的时间（time.Now、time.UnixMilli）
+	now := time.Now()
+	binance := venueQuote(common.ExchangeBinance, "BTCUSDT", 100, 100, now)
+	binance.Timestamp = time.UnixMilli(now.UnixMilli())
+	ps.UpdatePrice(binance)
+	ps.UpdatePrice(venueQuote(common.ExchangeLighter, "BTCUSDT", 100.5, 100.5, now))
+
+	for _, price := range ps.GetAllPricesBySymbol()["BTCUSDT"] {
+		assertUTCJSON(t, string(price.Exchange)+" timestamp", price.Timestamp)
+		assertUTCJSON(t, string(price.Exchange)+" last_updated", price.LastUpdated)
+	}
+	spreads := ps.CalculateSpreads()
+	if len(spreads) == 0 {
+		t.Fatal("no spreads")
+	}
+	assertUTCJSON(t, "spread updated_at", spreads[0].UpdatedAt)
+
+	opportunities := ps.GetArbitrageOpportunities()
+	if len(opportunities) == 0 {
+		t.Fatal("no opportunities")
+	}
+	assertUTCJSON(t, "opportunity first_seen", opportunities[0].FirstSeen)
+	assertUTCJSON(t, "web snapshot generated_at", ps.SnapshotForWeb().GeneratedAt)
+	assertUTCJSON(t, "config bundle exported_at", ps.ExportConfigBundle().ExportedAt)
+	assertUTCJSON(t, "venue pairs computed_at", ps.GetVenuePairs(0.1).ComputedAt)
+}
@@ -0,0 +1,141 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"log"
+	"math"
+)
+
+// PriceBounds 单个symbol的绝对价格上下限（0表示不限制）
+type PriceBounds struct {
+	Min float64
+	Max float64
+}
+
+// ValidationConfig 价格合法性校验配置
+type ValidationConfig struct {
+	// 按symbol配置的绝对价格上下限（key为交易所原始symbol，如 BTCUSDT）
+	SymbolBounds map[string]PriceBounds
+
+	// bid/ask 比例校验：ask < bid*MinAskBidRatio 或 ask > bid*MaxAskBidRatio 时拒绝
+	MinAskBidRatio float64
+	MaxAskBidRatio float64
+
+	// 合约中间价偏离标记价格（没有时用指数价格）超过该百分比时拒绝，0表示不校验
+	// 拦截胖手指挂单等远离标记价格的盘口，避免产生虚假价差
+	MaxMarkDeviationPercent float64
+}
+
+// DefaultValidationConfig 默认校验配置
+func DefaultValidationConfig() *ValidationConfig {
+	return &ValidationConfig{
+		SymbolBounds:            make(map[string]PriceBounds),
+		MinAskBidRatio:          0.5,
+		MaxAskBidRatio:          2.0,
+		MaxMarkDeviationPercent: 5,
+	}
+}
+
+// 拒绝原因
+const (
+	rejectUnknownExchange   = "unknown_exchange"
+	rejectUnknownMarketType = "unknown_market_type"
+	rejectNonFinite         = "non_finite"
+	rejectNegative          = "negative"
+	rejectZero              = "zero_price"
+	rejectOneSided          = "one_sided_no_mid"
+	rejectAskBidRatio       = "ask_bid_ratio"
+	rejectBelowMinimum      = "below_min_bound"
+	rejectAboveMaximum      = "above_max_bound"
+	rejectMarkDeviation     = "mark_deviation"
+)
+
+// SetValidationConfig 设置价格校验配置（nil表示恢复默认）
+func (ps *PriceStore) SetValidationConfig(cfg *ValidationConfig) {
+	if cfg == nil {
+		cfg = DefaultValidationConfig()
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.validation = cfg
+}
+
+// validatePrice 校验价格是否合法（调用者需要持有锁）
+// 返回拒绝原因，空字符串表示通过
+func (ps *PriceStore) validatePrice(price *common.Price) string {
+	cfg := ps.validation
+
+	// 规则0：拒绝未定义的交易所/市场类型（拼写错误会在索引中产生无效的条目）
+	if !common.IsValidExchange(price.Exchange) {
+		return rejectUnknownExchange
+	}
+	if !common.IsValidMarketType(price.MarketType) {
+		return rejectUnknownMarketType
+	}
+
+	// 规则1：拒绝 NaN / Inf
+	for _, v := range []float64{price.Price, price.BidPrice, price.AskPrice} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return rejectNonFinite
+		}
+	}
+
+	// 规则2：拒绝负价格
+	if price.Price < 0 || price.BidPrice < 0 || price.AskPrice < 0 {
+		return rejectNegative
+	}
+
+	// 规则3：完全没有价格信息
+	if price.Price == 0 && price.BidPrice == 0 && price.AskPrice == 0 {
+		return rejectZero
+	}
+
+	// 规则4：bid/ask 比例异常（例如 1e-12 的bid）
+	// 只有bid或只有ask时（如Binance合约TickerPrice）不做比例校验
+	if price.BidPrice > 0 && price.AskPrice > 0 {
+		if price.AskPrice < price.BidPrice*cfg.MinAskBidRatio || price.AskPrice > price.BidPrice*cfg.MaxAskBidRatio {
+			return rejectAskBidRatio
+		}
+	} else if (price.BidPrice > 0) != (price.AskPrice > 0) && price.Price == 0 {
+		// 只有单边报价且没有中间价，无法判断合理性
+		return rejectOneSided
+	}
+
+	// 规则5：按symbol配置的绝对上下限
+	if bounds, exists := cfg.SymbolBounds[price.Symbol]; exists {
+		mid := price.Price
+		if mid == 0 {
+			mid = (price.BidPrice + price.AskPrice) / 2
+		}
+		if bounds.Min > 0 && mid < bounds.Min {
+			return rejectBelowMinimum
+		}
+		if bounds.Max > 0 && mid > bounds.Max {
+			return rejectAboveMaximum
+		}
+	}
+
+	// 规则6：合约盘口中间价偏离标记/指数价格过多
+	// bid/ask 由标记价格推算的报价（SyntheticSpread）本身就贴着参考价格，不需要校验
+	if cfg.MaxMarkDeviationPercent > 0 && price.MarketType == common.MarketTypeFuture && !price.SyntheticSpread {
+		if deviation, ok := ps.markDeviationPercent(price); ok && deviation > cfg.MaxMarkDeviationPercent {
+			return rejectMarkDeviation
+		}
+	}
+
+	return ""
+}
+
+// recordRejection 记录被拒绝的价格（调用者需要持有锁）
+// 每个交易所首次拒绝及之后每100次输出一条样本日志（--quiet 时只计数不输出）
+func (ps *PriceStore) recordRejection(price *common.Price, reason string) {
+	ps.rejectedByExchange[price.Exchange]++
+	count := ps.rejectedByExchange[price.Exchange]
+
+	if (count == 1 || count%100 == 0) && !quietStdout.Load() {
+		log.Printf("[PriceStore] Rejected %s %s %s (%s): price=%g bid=%g ask=%g (total rejected for %s: %d)",
+			price.Exchange, price.MarketType, price.Symbol, reason,
+			price.Price, price.BidPrice, price.AskPrice, price.Exchange, count)
+	}
+}
@@ -0,0 +1,135 @@
+package pricestore
+
+import (
+	"bytes"
+	"crypto-arbitrage-monitor/pkg/common"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePriceRejections(t *testing.T) {
+	ps := NewPriceStore()
+	cfg := DefaultValidationConfig()
+	cfg.SymbolBounds["BTCUSDT"] = PriceBounds{Min: 1000, Max: 1000000}
+	ps.SetValidationConfig(cfg)
+
+	// quote 默认是合法的 Binance 合约报价，mutate 改出各种异常
+	quote := func(mutate func(p *common.Price)) *common.Price {
+		now := time.Now()
+		p := projectionQuote(common.ExchangeBinance, 50000, 50001, now, now)
+		mutate(p)
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		price  *common.Price
+		reason string
+	}{
+		{"valid two-sided", quote(func(p *common.Price) {}), ""},
+		{"unknown exchange", quote(func(p *common.Price) { p.Exchange = "NOPE" }), rejectUnknownExchange},
+		{"unknown market type", quote(func(p *common.Price) { p.MarketType = "OPTION" }), rejectUnknownMarketType},
+		{"NaN bid", quote(func(p *common.Price) { p.BidPrice = math.NaN() }), rejectNonFinite},
+		{"Inf price", quote(func(p *common.Price) { p.Price = math.Inf(1) }), rejectNonFinite},
+		{"negative ask", quote(func(p *common.Price) { p.AskPrice = -1 }), rejectNegative},
+		{"all zero", quote(func(p *common.Price) { p.Price, p.BidPrice, p.AskPrice = 0, 0, 0 }), rejectZero},
+		{"bid-only without mid", quote(func(p *common.Price) { p.Price, p.AskPrice = 0, 0 }), rejectOneSided},
+		{"ask-only without mid", quote(func(p *common.Price) { p.Price, p.BidPrice = 0, 0 }), rejectOneSided},
+		{"bid-only with mid", quote(func(p *common.Price) { p.AskPrice = 0 }), ""},
+		{"last price only", quote(func(p *common.Price) { p.BidPrice, p.AskPrice = 0, 0 }), ""},
+		{"tiny bid", quote(func(p *common.Price) { p.BidPrice = 1e-12 }), rejectAskBidRatio},
+		{"ask far above bid", quote(func(p *common.Price) { p.AskPrice = 150000 }), rejectAskBidRatio},
+		{"below symbol bound", quote(func(p *common.Price) { p.Price, p.BidPrice, p.AskPrice = 500, 499, 501 }), rejectBelowMinimum},
+		{"above symbol bound", quote(func(p *common.Price) { p.Price, p.BidPrice, p.AskPrice = 2e6, 2e6, 2e6+1 }), rejectAboveMaximum},
+		{"unbounded symbol", quote(func(p *common.Price) { p.Symbol = "ETHUSDT"; p.Price, p.BidPrice, p.AskPrice = 500, 499, 501 }), ""},
+		{"far from mark price", quote(func(p *common.Price) { p.MarkPrice = 40000 }), rejectMarkDeviation},
+		{"near mark price", quote(func(p *common.Price) { p.MarkPrice = 50500 }), ""},
+		{"synthetic spread skips mark check", quote(func(p *common.Price) { p.MarkPrice = 40000; p.SyntheticSpread = true }), ""},
+		{"spot skips mark check", quote(func(p *common.Price) { p.MarketType = common.MarketTypeSpot; p.MarkPrice = 40000 }), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ps.validatePrice(tt.price); got != tt.reason {
+				t.Fatalf("validatePrice = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRejectedUpdatesAreCounted(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	bidOnly := projectionQuote(common.ExchangeLighter, 100, 0, now, now)
+	bidOnly.Price = 0
+
+	if ps.UpdatePrice(bidOnly) {
+		t.Fatal("one-sided quote without mid accepted")
+	}
+	if ps.CurrentSeq() != 0 {
+		t.Fatalf("rejected update bumped seq to %d", ps.CurrentSeq())
+	}
+	ps.mu.RLock()
+	rejected := ps.rejectedByExchange[common.ExchangeLighter]
+	ps.mu.RUnlock()
+	if rejected != 1 {
+		t.Fatalf("rejected count = %d, want 1", rejected)
+	}
+}
+
+func TestUnknownExchangeLeavesNoPhantomEntry(t *testing.T) {
+	ps := NewPriceStore()
+	now := time.Now()
+	typo := projectionQuote("BINANNCE", 100, 100.01, now, now)
+	if ps.UpdatePrice(typo) {
+		t.Fatal("quote from an unknown exchange accepted")
+	}
+	perp := projectionQuote(common.ExchangeBinance, 100, 100.01, now, now)
+	perp.MarketType = "PERP"
+	if ps.UpdatePrice(perp) {
+		t.Fatal("quote with an unknown market type accepted")
+	}
+
+	if exchanges := ps.GetAllExchanges(); len(exchanges) != 0 {
+		t.Fatalf("exchanges after rejected quotes = %v", exchanges)
+	}
+	if prices := ps.GetAllPricesBySymbol(); len(prices) != 0 {
+		t.Fatalf("symbols after rejected quotes = %v", prices)
+	}
+	ps.mu.RLock()
+	rejected := ps.rejectedByExchange["BINANNCE"]
+	ps.mu.RUnlock()
+	if rejected != 1 {
+		t.Fatalf("rejected count for the typo = %d, want 1", rejected)
+	}
+}
+
+func TestRejectionSamplesSkippedWhenQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	SetQuiet(true)
+	defer SetQuiet(false)
+
+	ps := NewPriceStore()
+	now := time.Now()
+	if ps.UpdatePrice(projectionQuote(common.ExchangeLighter, -1, 100, now, now)) {
+		t.Fatal("negative bid accepted")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("rejection sample logged while quiet: %q", buf.String())
+	}
+	if rejected := ps.GetStats().RejectedByExchange[common.ExchangeLighter]; rejected != 1 {
+		t.Fatalf("rejected = %d, want 1 counted while quiet", rejected)
+	}
+
+	// 未开启 --quiet 时首次拒绝输出样本
+	SetQuiet(false)
+	ps.UpdatePrice(projectionQuote(common.ExchangeAster, -1, 100, now, now))
+	if !strings.Contains(buf.String(), "Rejected ASTER") {
+		t.Fatalf("log = %q, want a rejection sample", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+package pricestore
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	venuePairsCacheTTL  = 2 * time.Second // 价差计算结果的缓存时长，吸收看板轮询
+	venuePairsTopSymbol = 5               // 每个场所对返回的价差最大的symbol数
+)
+
+// VenuePairSymbol 场所对中单个symbol的价差
+type VenuePairSymbol struct {
+	Symbol        string  `json:"symbol"`
+	SpreadPercent float64 `json:"spread_percent"`
+	Volume24h     float64 `json:"volume_24h"`
+}
+
+// VenuePairStats 一个方向的场所对（在 BuyVenue 买入、在 SellVenue 卖出）在所有symbol上的价差汇总
+type VenuePairStats struct {
+	BuyVenue              string            `json:"buy_venue"`  // EXCHANGE_MARKETTYPE
+	SellVenue             string            `json:"sell_venue"` // EXCHANGE_MARKETTYPE
+	Count                 int               `json:"count"`      // 价差超过阈值的symbol数
+	MeanSpreadPercent     float64           `json:"mean_spread_percent"`
+	WeightedSpreadPercent float64           `json:"weighted_spread_percent"` // 按两腿较小的24小时成交量加权，没有成交量数据时等于均值
+	MaxSpreadPercent      float64           `json:"max_spread_percent"`
+	TotalVolume24h        float64           `json:"total_volume_24h"`
+	TopSymbols            []VenuePairSymbol `json:"top_symbols"` // 价差最大的前5个symbol
+}
+
+// VenuePairReport 场所对价差矩阵（/api/venue-pairs）
+type VenuePairReport struct {
+	Venues           []string          `json:"venues"` // 出现在价差中的场所，按名称排序
+	Pairs            []*VenuePairStats `json:"pairs"`  // 只包含有价差超过阈值的场所对，按数量降序
+	MinSpreadPercent float64           `json:"min_spread_percent"`
+	ComputedAt       time.Time         `json:"computed_at"` // 价差的计算时间（结果最多缓存2秒）
+}
+
+// venuePairCache 最近一次计算的价差（自带锁，不占用 ps.mu）
+type venuePairCache struct {
+	mu         sync.Mutex
+	spreads    []*Spread
+	computedAt time.Time
+}
+
+// GetVenuePairs 按场所对汇总当前所有symbol的价差，只统计价差大于 minSpreadPercent 的symbol
+// 价差与 /api/spreads 使用同一计算（同一份存储副本、60秒活跃窗口和黑名单），结果缓存2秒
+func (ps *PriceStore) GetVenuePairs(minSpreadPercent float64) *VenuePairReport {
+	spreads, computedAt := ps.venuePairSpreads(time.Now().UTC())
+	report := aggregateVenuePairs(spreads, minSpreadPercent)
+	report.ComputedAt = computedAt
+	return report
+}
+
+// venuePairSpreads 返回缓存的价差，缓存过期时基于新的存储副本重新计算
+func (ps *PriceStore) venuePairSpreads(now time.Time) ([]*Spread, time.Time) {
+	ps.venuePairs.mu.Lock()
+	defer ps.venuePairs.mu.Unlock()
+
+	if ps.venuePairs.spreads == nil || now.Sub(ps.venuePairs.computedAt) > venuePairsCacheTTL {
+		ps.venuePairs.spreads = ps.calcSnapshot().allSpreads(now)
+		ps.venuePairs.computedAt = now
+	}
+	return ps.venuePairs.spreads, ps.venuePairs.computedAt
+}
+
+// aggregateVenuePairs 按（买入场所, 卖出场所）汇总价差
+// 每个symbol在每个场所只有一个报价，所以同一场所对中每个symbol最多出现一次
+func aggregateVenuePairs(spreads []*Spread, minSpreadPercent float64) *VenuePairReport {
+	report := &VenuePairReport{
+		Venues:           make([]string, 0),
+		Pairs:            make([]*VenuePairStats, 0),
+		MinSpreadPercent: minSpreadPercent,
+	}
+
+	venues := make(map[string]bool)
+	pairs := make(map[string]*VenuePairStats)
+	symbols := make(map[string][]VenuePairSymbol)
+	weightedSum := make(map[string]float64)
+	for _, spread := range spreads {
+		buyVenue := string(spread.BuyExchange) + "_" + string(spread.BuyMarketType)
+		sellVenue := string(spread.SellExchange) + "_" + string(spread.SellMarketType)
+		venues[buyVenue] = true
+		venues[sellVenue] = true
+		if spread.SpreadPercent <= minSpreadPercent {
+			continue
+		}
+
+		key := buyVenue + "->" + sellVenue
+		stats := pairs[key]
+		if stats == nil {
+			stats = &VenuePairStats{BuyVenue: buyVenue, SellVenue: sellVenue, MaxSpreadPercent: spread.SpreadPercent}
+			pairs[key] = stats
+		}
+		stats.Count++
+		stats.MeanSpreadPercent += spread.SpreadPercent
+		if spread.SpreadPercent > stats.MaxSpreadPercent {
+			stats.MaxSpreadPercent = spread.SpreadPercent
+		}
+		if spread.VolumeKnown && spread.Volume24h > 0 {
+			stats.TotalVolume24h += spread.Volume24h
+			weightedSum[key] += spread.SpreadPercent * spread.Volume24h
+		}
+		symbols[key] = append(symbols[key], VenuePairSymbol{
+			Symbol:        spread.Symbol,
+			SpreadPercent: spread.SpreadPercent,
+			Volume24h:     spread.Volume24h,
+		})
+	}
+
+	for key, stats := range pairs {
+		stats.MeanSpreadPercent /= float64(stats.Count)
+		stats.WeightedSpreadPercent = stats.MeanSpreadPercent
+		if stats.TotalVolume24h > 0 {
+			stats.WeightedSpreadPercent = weightedSum[key] / stats.TotalVolume24h
+		}
+
+		top := symbols[key]
+		sort.Slice(top, func(i, j int) bool {
+			if top[i].SpreadPercent != top[j].SpreadPercent {
+				return top[i].SpreadPercent > top[j].SpreadPercent
+			}
+			return top[i].Symbol < top[j].Symbol
+		})
+		if len(top) > venuePairsTopSymbol {
+			top = top[:venuePairsTopSymbol]
+		}
+		stats.TopSymbols = top
+		report.Pairs = append(report.Pairs, stats)
+	}
+	sort.Slice(report.Pairs, func(i, j int) bool {
+		a, b := report.Pairs[i], report.Pairs[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.BuyVenue != b.BuyVenue {
+			return a.BuyVenue < b.BuyVenue
+		}
+		return a.SellVenue < b.SellVenue
+	})
+
+	for venue := range venues {
+		report.Venues = append(report.Venues, venue)
+	}
+	sort.Strings(report.Venues)
+	return report
+}
@@ -0,0 +1,73 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func venuePairSpread(symbol string, buy, sell common.Exchange, sellMarket common.MarketType, percent, volume float64) *Spread {
+	return &Spread{
+		Symbol:      symbol,
+		BuyExchange: buy, BuyMarketType: common.MarketTypeFuture,
+		SellExchange: sell, SellMarketType: sellMarket,
+		SpreadPercent: percent, Volume24h: volume, VolumeKnown: volume > 0,
+	}
+}
+
+func TestAggregateVenuePairs(t *testing.T) {
+	spreads := make([]*Spread, 0)
+	// Binance -> Lighter：C1..C7 价差 0.1%..0.7%，成交量 1..7（百万）
+	for i := 1; i <= 7; i++ {
+		spreads = append(spreads, venuePairSpread(fmt.Sprintf("C%dUSDT", i), common.ExchangeBinance, common.ExchangeLighter,
+			common.MarketTypeFuture, float64(i)/10, float64(i)*1e6))
+	}
+	// Lighter -> Aster：两个symbol没有成交量数据，加权价差等于均值
+	spreads = append(spreads,
+		venuePairSpread("BTCUSDT", common.ExchangeLighter, common.ExchangeAster, common.MarketTypeFuture, 0.3, 0),
+		venuePairSpread("ETHUSDT", common.ExchangeLighter, common.ExchangeAster, common.MarketTypeFuture, 0.5, 0))
+	// Binance -> Aster 现货：不超过阈值，只出现在场所列表中
+	spreads = append(spreads, venuePairSpread("BTCUSDT", common.ExchangeBinance, common.ExchangeAster, common.MarketTypeSpot, 0.15, 1e6))
+
+	report := aggregateVenuePairs(spreads, 0.15)
+	wantVenues := []string{"ASTER_FUTURE", "ASTER_SPOT", "BINANCE_FUTURE", "LIGHTER_FUTURE"}
+	if !reflect.DeepEqual(report.Venues, wantVenues) {
+		t.Fatalf("venues = %v, want %v", report.Venues, wantVenues)
+	}
+	if len(report.Pairs) != 2 {
+		t.Fatalf("%d pairs, want 2 (pairs at or under the threshold excluded)", len(report.Pairs))
+	}
+
+	// 按数量降序：Binance -> Lighter 的 C2..C7 超过阈值
+	bl := report.Pairs[0]
+	if bl.BuyVenue != "BINANCE_FUTURE" || bl.SellVenue != "LIGHTER_FUTURE" || bl.Count != 6 {
+		t.Fatalf("first pair = %s -> %s count %d", bl.BuyVenue, bl.SellVenue, bl.Count)
+	}
+	// 均值 (0.2+...+0.7)/6 = 0.45；加权 Σ(i/10 * i) / Σi (i=2..7) = 13.9/27
+	if math.Abs(bl.MeanSpreadPercent-0.45) > 1e-9 || math.Abs(bl.WeightedSpreadPercent-13.9/27) > 1e-9 ||
+		bl.MaxSpreadPercent != 0.7 || bl.TotalVolume24h != 27e6 {
+		t.Fatalf("stats = mean %v weighted %v max %v volume %v", bl.MeanSpreadPercent, bl.WeightedSpreadPercent, bl.MaxSpreadPercent, bl.TotalVolume24h)
+	}
+	top := make([]string, 0, len(bl.TopSymbols))
+	for _, symbol := range bl.TopSymbols {
+		top = append(top, symbol.Symbol)
+	}
+	if want := []string{"C7USDT", "C6USDT", "C5USDT", "C4USDT", "C3USDT"}; !reflect.DeepEqual(top, want) {
+		t.Fatalf("top symbols = %v, want %v", top, want)
+	}
+
+	la := report.Pairs[1]
+	if la.BuyVenue != "LIGHTER_FUTURE" || la.SellVenue != "ASTER_FUTURE" || la.Count != 2 ||
+		math.Abs(la.MeanSpreadPercent-0.4) > 1e-9 || la.WeightedSpreadPercent != la.MeanSpreadPercent || la.TotalVolume24h != 0 {
+		t.Fatalf("second pair = %+v", la)
+	}
+}
+
+func TestAggregateVenuePairsEmpty(t *testing.T) {
+	report := aggregateVenuePairs(nil, 0.1)
+	if report.Venues == nil || report.Pairs == nil || len(report.Pairs) != 0 {
+		t.Fatalf("empty report = %+v, want empty (non-nil) lists", report)
+	}
+}
@@ -0,0 +1,80 @@
+package pricestore
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"sync"
+)
+
+// SymbolWatcher 单个symbol的报价订阅：UpdatePrice 接受该symbol的报价时直接记录最新值并通知，
+// 不经过价差计算和快照（用于 /ws/watch 这类只关心一对场所的低延迟推送）
+type SymbolWatcher struct {
+	Symbol string // 标准symbol
+
+	mu     sync.Mutex
+	latest map[string]*common.Price // key: exchange_marketType
+	notify chan struct{}            // 容量1，处理前的多次更新合并为一次通知
+}
+
+// C 有新报价时收到通知（通知被合并，收到后用 Leg 读取最新值）
+func (w *SymbolWatcher) C() <-chan struct{} {
+	return w.notify
+}
+
+// Leg 某个场所的最新报价，没有数据时返回nil（返回的报价不能修改）
+func (w *SymbolWatcher) Leg(exchange common.Exchange, marketType common.MarketType) *common.Price {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.latest[symbolIndexKey(exchange, marketType)]
+}
+
+// offer 记录最新报价并通知（调用者持有存储写锁，这里不能阻塞）
+func (w *SymbolWatcher) offer(key string, price *common.Price) {
+	w.mu.Lock()
+	w.latest[key] = price
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// WatchSymbol 订阅symbol的报价更新，已有的报价作为初始值；不再使用时需要调用 Unwatch
+func (ps *PriceStore) WatchSymbol(symbol string) *SymbolWatcher {
+	standardSymbol := ps.symbolNormalizer.Normalize(NormalizeThresholdSymbol(symbol))
+	w := &SymbolWatcher{
+		Symbol: standardSymbol,
+		latest: make(map[string]*common.Price),
+		notify: make(chan struct{}, 1),
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for key, price := range ps.bySymbol[standardSymbol] {
+		w.latest[key] = price
+	}
+	if ps.watchers[standardSymbol] == nil {
+		ps.watchers[standardSymbol] = make(map[*SymbolWatcher]bool)
+	}
+	ps.watchers[standardSymbol][w] = true
+	return w
+}
+
+// Unwatch 取消订阅
+func (ps *PriceStore) Unwatch(w *SymbolWatcher) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	delete(ps.watchers[w.Symbol], w)
+	if len(ps.watchers[w.Symbol]) == 0 {
+		delete(ps.watchers, w.Symbol)
+	}
+}
+
+// notifyWatchers 通知订阅了该symbol的 watcher（调用者需要持有写锁）
+func (ps *PriceStore) notifyWatchers(standardSymbol, symbolKey string, price *common.Price) {
+	for w := range ps.watchers[standardSymbol] {
+		w.offer(symbolKey, price)
+	}
+}
@@ -0,0 +1,42 @@
+package pricestore
+
+import "time"
+
+// StoreSnapshot Web 接口读取的不可变存储快照：价差和统计在生成时计算好，请求处理时无需获取存储锁
+// 多个请求共享同一个快照，调用者不能修改其中的切片、map和价差
+type StoreSnapshot struct {
+	GeneratedAt  time.Time
+	Seq          uint64        // 生成快照时的存储序列号
+	Spreads      []*Spread     // 实时价差，按价差百分比降序
+	Stats        StoreStats    // 与 GetStats 相同
+	ActivePrices int           // 60秒内更新过的报价数
+	BuildTime    time.Duration // 生成快照的耗时（复制 + 计算）
+}
+
+// SnapshotForWeb 生成 Web 接口使用的存储快照
+// 只在复制价格索引和统计时持有读锁，价差在副本上计算，与 CalculateSpreads 的结果一致
+func (ps *PriceStore) SnapshotForWeb() *StoreSnapshot {
+	start := time.Now()
+
+	ps.mu.RLock()
+	calc := ps.calcSnapshotLocked(true)
+	snap := &StoreSnapshot{
+		GeneratedAt: start.UTC(),
+		Seq:         ps.seq,
+		Stats:       ps.statsLocked(start),
+	}
+	for _, exchangeMap := range ps.byExchange {
+		for _, price := range exchangeMap {
+			if start.Sub(price.LastUpdated) <= 60*time.Second {
+				snap.ActivePrices++
+			}
+		}
+	}
+	ps.mu.RUnlock()
+
+	snap.Spreads = calc.allSpreads(start)
+	ps.sortSpreadsByPercent(snap.Spreads)
+
+	snap.BuildTime = time.Since(start)
+	return snap
+}
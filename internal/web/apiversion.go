@@ -0,0 +1,163 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/apiv2"
+	"crypto-arbitrage-monitor/pkg/common"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// API 版本只按路径区分（不看请求头）：
+// - /api/v1/* 为原有的响应结构，无版本前缀的路径是 v1 的别名，行为完全一致
+// - /api/v2/* 使用 pkg/apiv2 中定义的响应类型，目前提供 spreads
+// 有 v2 对应接口的 v1 响应在 envelope 中带 deprecation 字段，指向 v2 路径
+
+// v2Endpoints 已有 v2 版本的接口
+var v2Endpoints = map[string]bool{
+	"spreads": true,
+}
+
+// apiPath 该命名空间某个版本的接口路径，例如 /api/v2/spreads、/api/v2/{namespace}/spreads
+func (s *Server) apiPath(version, endpoint string) string {
+	path := "/api/" + version
+	if name := s.store.Name(); name != pricestore.DefaultNamespace {
+		path += "/" + name
+	}
+	return path + "/" + endpoint
+}
+
+// v1Deprecation v1 响应的 deprecation 字段，接口没有 v2 版本时返回nil
+func (s *Server) v1Deprecation(endpoint string) map[string]interface{} {
+	if !v2Endpoints[endpoint] {
+		return nil
+	}
+	return map[string]interface{}{
+		"message":   "v1 response shape is frozen; new fields are only added to " + apiv2.Version,
+		"successor": s.apiPath(apiv2.Version, endpoint),
+	}
+}
+
+// v1AliasHandler 将 /api/v1/xxx 改写为 /api/xxx 后交给 mux 处理（包括命名空间 /api/v1/{namespace}/xxx）
+func v1AliasHandler(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/api" + strings.TrimPrefix(r.URL.Path, "/api/v1")
+		if strings.HasPrefix(path, "/api/v1/") || strings.HasPrefix(path, "/api/v2/") {
+			http.NotFound(w, r)
+			return
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		mux.ServeHTTP(w, r2)
+	})
+}
+
+// handleSpreadsV2 处理 v2 价差查询请求，查询参数与 /api/v1/spreads 相同（不支持 fields 和 debug）
+func (s *Server) handleSpreadsV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Query().Get("fields") != "" {
+		http.Error(w, "fields is not supported in "+apiv2.Version, http.StatusBadRequest)
+		return
+	}
+
+	q, err := s.querySpreads(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 两腿报价的年龄相对查询时间点（历史查询为 at）
+	now := q.handlerStart
+	if !q.at.IsZero() {
+		now = q.at
+	}
+	data := make([]*apiv2.Spread, 0, len(q.spreads))
+	for _, spread := range q.spreads {
+		data = append(data, toV2Spread(spread, now))
+	}
+	q.timing.Handler = time.Since(q.handlerStart)
+
+	resp := &apiv2.SpreadsResponse{
+		Envelope: apiv2.Envelope{
+			Success:    true,
+			APIVersion: apiv2.Version,
+			Count:      len(data),
+			Total:      q.total,
+			Offset:     q.page.Offset,
+			Limit:      q.page.Limit,
+		},
+		Data: data,
+	}
+	q.quote.v2Envelope(&resp.Envelope)
+	if !q.snapshotAt.IsZero() {
+		resp.SnapshotAgeMs = time.Since(q.snapshotAt).Milliseconds()
+	}
+	s.writeTimedJSON(w, r, "spreads_v2", resp, q.timing)
+}
+
+// toV2Spread 转换为 v2 价差，两腿的原始symbol、数据源和年龄取自计算价差时的报价
+func toV2Spread(spread *pricestore.Spread, now time.Time) *apiv2.Spread {
+	v2 := &apiv2.Spread{
+		Symbol: spread.Symbol,
+		Buy: apiv2.Leg{
+			Exchange:      spread.BuyExchange,
+			MarketType:    spread.BuyMarketType,
+			Price:         spread.BuyPrice,
+			QuoteCurrency: spread.BuyQuoteCurrency,
+			OriginalPrice: spread.BuyOriginalPrice,
+			ExchangeRate:  spread.BuyExchangeRate,
+		},
+		Sell: apiv2.Leg{
+			Exchange:      spread.SellExchange,
+			MarketType:    spread.SellMarketType,
+			Price:         spread.SellPrice,
+			QuoteCurrency: spread.SellQuoteCurrency,
+			OriginalPrice: spread.SellOriginalPrice,
+			ExchangeRate:  spread.SellExchangeRate,
+		},
+		SpreadPercent:   spread.SpreadPercent,
+		SpreadAbsolute:  spread.SpreadAbsolute,
+		EffectiveSpread: spread.EffectiveSpread,
+		Volume24h:       spread.Volume24h,
+		VolumeKnown:     spread.VolumeKnown,
+		Confidence:      spread.Confidence,
+		Sources:         make([]common.PriceSource, 0, 2),
+		UpdatedAt:       spread.UpdatedAt,
+		Held:            spread.Held,
+		MissingSince:    spread.MissingSince,
+	}
+	if p := spread.Projection; p != nil {
+		v2.Projection = &apiv2.Projection{
+			Leg:            p.Leg,
+			GapMs:          p.GapMs,
+			DriftBpsPerSec: p.DriftBpsPerSec,
+			AmountBps:      p.AmountBps,
+			Capped:         p.Capped,
+			BuyPrice:       p.BuyPrice,
+			SellPrice:      p.SellPrice,
+			SpreadPercent:  p.SpreadPercent,
+		}
+	}
+
+	for _, leg := range []struct {
+		v2    *apiv2.Leg
+		price *common.Price
+	}{{&v2.Buy, spread.BuyQuote}, {&v2.Sell, spread.SellQuote}} {
+		if leg.price == nil {
+			continue
+		}
+		leg.v2.Symbol = leg.price.Symbol
+		leg.v2.Source = leg.price.Source
+		leg.v2.AgeMs = now.Sub(leg.price.LastUpdated).Milliseconds()
+		leg.v2.Synthetic = leg.price.SyntheticSpread
+		if len(v2.Sources) == 0 || v2.Sources[0] != leg.price.Source {
+			v2.Sources = append(v2.Sources, leg.price.Source)
+		}
+	}
+	return v2
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto-arbitrage-monitor/pkg/apiv2"
+	"crypto-arbitrage-monitor/pkg/client"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// getBody GET path，返回状态码和响应内容
+func getBody(t *testing.T, s *Server, path string) (int, []byte) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.Bytes()
+}
+
+func TestSpreadsV1AliasMatchesUnversioned(t *testing.T) {
+	s := newOpportunityServer(t)
+
+	for _, query := range []string{"", "?sort=symbol&order=asc&limit=2&offset=1"} {
+		code, unversioned := getBody(t, s, "/api/spreads"+query)
+		v1Code, v1 := getBody(t, s, "/api/v1/spreads"+query)
+		if code != http.StatusOK || v1Code != http.StatusOK {
+			t.Fatalf("query %q: status %d and %d, want 200", query, code, v1Code)
+		}
+		if string(unversioned) != string(v1) {
+			t.Fatalf("query %q: /api/v1/spreads body differs from /api/spreads:\n%s\n%s", query, v1, unversioned)
+		}
+	}
+}
+
+// bodyRecorder 记录客户端收到的最后一个响应内容
+type bodyRecorder struct {
+	body []byte
+}
+
+func (b *bodyRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if b.body, err = io.ReadAll(resp.Body); err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b.body))
+	return resp, nil
+}
+
+func TestSpreadsV2RoundTripsThroughClient(t *testing.T) {
+	s := newOpportunityServer(t)
+	server := httptest.NewServer(s.newMux())
+	defer server.Close()
+
+	recorder := &bodyRecorder{}
+	resp, err := client.New(server.URL, client.WithHTTPClient(&http.Client{Transport: recorder})).Spreads(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := recorder.body
+	if resp.APIVersion != apiv2.Version || !resp.Success || resp.Count != 6 || len(resp.Data) != 6 {
+		t.Fatalf("envelope = %+v with %d spreads, want 6 v2 spreads", resp.Envelope, len(resp.Data))
+	}
+
+	// 客户端解码后重新编码，与服务端的原始响应字段完全相同（v2 类型没有丢失或多出字段）
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got map[string]interface{}
+	if err := json.Unmarshal(raw, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("client round trip changed the response:\n%s\n%s", encoded, raw)
+	}
+	for _, spread := range resp.Data {
+		if spread.Buy.Exchange == "" || spread.Sell.Exchange == "" || spread.Buy.Price == 0 || len(spread.Sources) == 0 {
+			t.Fatalf("spread %s missing leg details: %+v", spread.Symbol, spread)
+		}
+	}
+
+	// v1 兼容模式转换得到相同的价差和两腿价格
+	compat, err := client.New(server.URL, client.WithV1Compat()).Spreads(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compat.APIVersion != "v1" || len(compat.Data) != len(resp.Data) {
+		t.Fatalf("v1 compat: api_version %q with %d spreads", compat.APIVersion, len(compat.Data))
+	}
+	for i, spread := range compat.Data {
+		v2 := resp.Data[i]
+		if spread.Symbol != v2.Symbol || spread.Buy.Exchange != v2.Buy.Exchange || spread.Buy.Price != v2.Buy.Price ||
+			spread.Sell.Exchange != v2.Sell.Exchange || spread.Sell.Price != v2.Sell.Price || spread.SpreadPercent != v2.SpreadPercent {
+			t.Fatalf("v1 compat spread %d = %+v, want the v2 values %+v", i, spread, v2)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package web
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authExemptPaths 设置了 API 令牌时仍然不需要认证的 /api 路径（健康检查及其版本别名）
+var authExemptPaths = map[string]bool{
+	"/api/health":    true,
+	"/api/v1/health": true,
+	"/api/v2/health": true,
+}
+
+// wsTokenProtocol WebSocket 客户端通过 Sec-WebSocket-Protocol: bearer, <token> 传递令牌（浏览器的 WebSocket 不能设置 Authorization 头）
+const wsTokenProtocol = "bearer"
+
+// SetAPIToken 设置 API 令牌，非空时所有 /api/* 和 /ws/* 请求需要认证
+// /api/* 使用 Authorization: Bearer <token>；/ws/* 还可以使用 ?token=<token> 或 Sec-WebSocket-Protocol: bearer, <token>
+// 静态页面和 /api/health（含 /api/v1/health、/api/v2/health）不需要认证，空字符串表示不启用认证
+func (s *Server) SetAPIToken(token string) {
+	s.apiToken = token
+}
+
+// authMiddleware 校验 /api/* 和 /ws/* 请求的令牌，未设置令牌时不做检查
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.apiToken == "" || !requiresAuth(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok && strings.HasPrefix(r.URL.Path, "/ws/") {
+			token, ok = websocketToken(r)
+		}
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requiresAuth 设置了令牌时该路径是否需要认证
+func requiresAuth(path string) bool {
+	if authExemptPaths[path] {
+		return false
+	}
+	return strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/ws/")
+}
+
+// bearerToken 从 Authorization 头中取出 Bearer 令牌
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// websocketToken 从 ?token= 或 Sec-WebSocket-Protocol: bearer, <token> 中取出 WebSocket 连接的令牌
+func websocketToken(r *http.Request) (string, bool) {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token, true
+	}
+
+	var protocols []string
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			protocols = append(protocols, strings.TrimSpace(protocol))
+		}
+	}
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == wsTokenProtocol && protocols[i+1] != "" {
+			return protocols[i+1], true
+		}
+	}
+	return "", false
+}
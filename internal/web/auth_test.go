@@ -0,0 +1,101 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func newAuthTestHandler(token string) http.Handler {
+	s := NewServer(pricestore.NewPriceStore(), "")
+	s.SetAPIToken(token)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return s.authMiddleware(ok)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	const token = "s3cret"
+	tests := []struct {
+		name     string
+		token    string // 服务端令牌，空表示不启用认证
+		path     string
+		header   map[string]string
+		wantCode int
+	}{
+		{"disabled api", "", "/api/spreads", nil, http.StatusOK},
+		{"disabled ws", "", "/ws/watch", nil, http.StatusOK},
+		{"api authorized", token, "/api/spreads", map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+		{"api scheme case-insensitive", token, "/api/spreads", map[string]string{"Authorization": "bearer " + token}, http.StatusOK},
+		{"api missing token", token, "/api/spreads", nil, http.StatusUnauthorized},
+		{"api wrong token", token, "/api/spreads", map[string]string{"Authorization": "Bearer nope"}, http.StatusUnauthorized},
+		{"api query token not accepted", token, "/api/spreads?token=" + token, nil, http.StatusUnauthorized},
+		{"v1 alias", token, "/api/v1/spreads", nil, http.StatusUnauthorized},
+		{"health exempt", token, "/api/health", nil, http.StatusOK},
+		{"v1 health exempt", token, "/api/v1/health", nil, http.StatusOK},
+		{"v2 health exempt", token, "/api/v2/health", nil, http.StatusOK},
+		{"static exempt", token, "/index.html", nil, http.StatusOK},
+		{"ws missing token", token, "/ws/watch?symbol=BTCUSDT", nil, http.StatusUnauthorized},
+		{"ws wrong query token", token, "/ws/watch?token=nope", nil, http.StatusUnauthorized},
+		{"ws query token", token, "/ws/watch?token=" + token, nil, http.StatusOK},
+		{"ws bearer header", token, "/ws/watch", map[string]string{"Authorization": "Bearer " + token}, http.StatusOK},
+		{"ws subprotocol", token, "/ws/watch", map[string]string{"Sec-WebSocket-Protocol": "bearer, " + token}, http.StatusOK},
+		{"ws wrong subprotocol", token, "/ws/watch", map[string]string{"Sec-WebSocket-Protocol": "bearer, nope"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			newAuthTestHandler(tt.token).ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestWatchWebSocketAuth(t *testing.T) {
+	const token = "s3cret"
+	s := NewServer(pricestore.NewPriceStore(), "")
+	s.SetAPIToken(token)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/watch", s.handleWatch)
+	srv := httptest.NewServer(s.authMiddleware(mux))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/watch?symbol=BTCUSDT&buy=BINANCE_SPOT&sell=LIGHTER_FUTURE"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil {
+		t.Fatal("dial without token succeeded")
+	} else if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("dial without token: resp=%v err=%v, want 401", resp, err)
+	}
+
+	dialer := websocket.Dialer{Subprotocols: []string{wsTokenProtocol, token}}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial with subprotocol token: %v", err)
+	}
+	if conn.Subprotocol() != wsTokenProtocol {
+		t.Errorf("negotiated subprotocol = %q, want %q", conn.Subprotocol(), wsTokenProtocol)
+	}
+	conn.Close()
+
+	conn, _, err = websocket.DefaultDialer.Dial(url+"&token="+token, nil)
+	if err != nil {
+		t.Fatalf("dial with query token: %v", err)
+	}
+	conn.Close()
+}
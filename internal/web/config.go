@@ -0,0 +1,31 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleConfig 返回脱敏后的有效配置（启动时加载的全部配置项，包括各交易所开关、阈值和刷新间隔），
+// 以及运行时生效的按symbol阈值和价格活跃窗口，用于排查部署问题
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.effectiveConfig == nil {
+		http.Error(w, "Configuration not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"config":              s.effectiveConfig,
+			"threshold_overrides": s.store.GetThresholdOverrides(),
+			"activity_windows": map[string]float64{
+				"active_price_seconds": allPricesFreshWindow.Seconds(), // 价差计算、/api/stats 和 fresh_only 只使用该窗口内更新过的报价
+			},
+		},
+	})
+}
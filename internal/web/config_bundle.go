@@ -0,0 +1,89 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxConfigBundleSize 导入的配置包大小上限
+const maxConfigBundleSize = 4 << 20
+
+// SetUnredactedConfig 设置未脱敏的有效配置（/api/config/export?include_secrets=true 返回）
+// 只有设置了 API 令牌时才会返回，未设置令牌时无法确认请求者身份
+func (s *Server) SetUnredactedConfig(values map[string]interface{}) {
+	s.unredactedConfig = values
+}
+
+// handleConfigExport 导出运行时可修改的全部配置（阈值、黑名单、symbol映射、比值策略、交易所能力）及启动配置
+// 支持参数:
+// - include_secrets: 为true时启动配置不脱敏，需要服务端设置了 API_TOKEN（请求已通过令牌认证），
+// 没有单独的管理员令牌，任何持有 API_TOKEN 的客户端都可以导出未脱敏的配置
+func (s *Server) handleConfigExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	bundle := s.store.ExportConfigBundle()
+	bundle.Environment = s.effectiveConfig
+	if r.URL.Query().Get("include_secrets") == "true" {
+		if s.apiToken == "" || s.unredactedConfig == nil {
+			http.Error(w, "include_secrets requires API_TOKEN to be configured", http.StatusForbidden)
+			return
+		}
+		bundle.Environment = s.unredactedConfig
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="monitor-config-%s.json"`, bundle.ExportedAt.Format("20060102-150405")))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(bundle)
+}
+
+// handleConfigImport 校验并应用 /api/config/export 导出的配置包，返回各部分的变化
+// 缺少的部分保持不变（返回 omitted）；任何一部分校验失败时不应用任何配置；environment 部分不应用（需要修改 .env 后重启）
+// 支持参数:
+// - dry_run: 为true时只校验并返回变化，不应用
+func (s *Server) handleConfigImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var bundle pricestore.ConfigBundle
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfigBundleSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&bundle); err != nil {
+		http.Error(w, "Invalid config bundle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	diff, err := s.store.ApplyConfigBundle(&bundle, dryRun)
+	if err != nil && diff == nil {
+		http.Error(w, "Invalid config bundle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"success": err == nil,
+		"dry_run": dryRun,
+		"data":    diff,
+	}
+	if bundle.Environment != nil {
+		resp["environment"] = "not applied: edit .env and restart to change startup configuration"
+	}
+	status := http.StatusOK
+	if err != nil {
+		// 写回文件失败，配置未应用
+		resp["error"] = err.Error()
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,80 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConfigExportIncludeSecrets(t *testing.T) {
+	s := NewServer(pricestore.NewPriceStore(), "")
+	s.SetEffectiveConfig(map[string]interface{}{"BinanceAPIKey": "[REDACTED]"})
+	s.SetUnredactedConfig(map[string]interface{}{"BinanceAPIKey": "real-key"})
+
+	export := func(query string) (*httptest.ResponseRecorder, pricestore.ConfigBundle) {
+		rec := httptest.NewRecorder()
+		s.handleConfigExport(rec, httptest.NewRequest(http.MethodGet, "/api/config/export"+query, nil))
+		var bundle pricestore.ConfigBundle
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, bundle
+	}
+
+	rec, bundle := export("")
+	if rec.Code != http.StatusOK || bundle.Environment["BinanceAPIKey"] != "[REDACTED]" {
+		t.Fatalf("default export: code=%d env=%v", rec.Code, bundle.Environment)
+	}
+	if rec, _ := export("?include_secrets=true"); rec.Code != http.StatusForbidden {
+		t.Fatalf("include_secrets without API_TOKEN: code=%d, want 403", rec.Code)
+	}
+
+	s.SetAPIToken("token")
+	rec, bundle = export("?include_secrets=true")
+	if rec.Code != http.StatusOK || bundle.Environment["BinanceAPIKey"] != "real-key" {
+		t.Fatalf("include_secrets with API_TOKEN: code=%d env=%v", rec.Code, bundle.Environment)
+	}
+}
+
+func TestConfigImportPartialBundle(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	if err := store.LoadBlacklist(t.TempDir()+"/blacklist.json", []string{"USDCUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(store, "")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleConfigImport(rec, httptest.NewRequest(http.MethodPost, "/api/config/import", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"version":1,"thresholds":{"BTCUSDT":0.2}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("code=%d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Data map[string]pricestore.BundleSectionDiff `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Data[pricestore.BundleSectionThresholds].Changed || !resp.Data[pricestore.BundleSectionBlacklist].Omitted {
+		t.Fatalf("unexpected diff: %+v", resp.Data)
+	}
+	if got := len(store.GetBlacklist()); got != 1 {
+		t.Fatalf("blacklist has %d rules after partial import, want 1", got)
+	}
+
+	if rec := post(`{"version":1,"bogus":true}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown field: code=%d, want 400", rec.Code)
+	}
+	if rec := post(`{"version":9}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad version: code=%d, want 400", rec.Code)
+	}
+}
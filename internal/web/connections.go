@@ -0,0 +1,76 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/wsutil"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SetSubscriptionRegistry 注册交易所连接池的订阅登记（/api/connections，需要在 Start 之前调用）
+func (s *Server) SetSubscriptionRegistry(registry *wsutil.SubscriptionRegistry) {
+	s.registries = append(s.registries, registry)
+}
+
+// connectionSummary 单个连接上的订阅数
+type connectionSummary struct {
+	ConnID        int `json:"conn_id"`
+	Subscriptions int `json:"subscriptions"`
+}
+
+// handleConnections 返回各交易所连接池的订阅登记（symbol 所在的连接）
+// 支持参数:
+// - exchange: 只返回该交易所（不区分大小写）
+// - symbol: 只返回该 symbol 的订阅（不区分大小写）
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	exchange := strings.ToUpper(strings.TrimSpace(query.Get("exchange")))
+	symbol := strings.ToUpper(strings.TrimSpace(query.Get("symbol")))
+
+	data := make([]map[string]interface{}, 0, len(s.registries))
+	for _, registry := range s.registries {
+		if exchange != "" && registry.Exchange() != exchange {
+			continue
+		}
+
+		subs := registry.Snapshot()
+		perConn := make(map[int]int)
+		for _, sub := range subs {
+			perConn[sub.ConnID]++
+		}
+		connections := make([]connectionSummary, 0, len(perConn))
+		for id, n := range perConn {
+			connections = append(connections, connectionSummary{ConnID: id, Subscriptions: n})
+		}
+		sort.Slice(connections, func(i, j int) bool { return connections[i].ConnID < connections[j].ConnID })
+
+		if symbol != "" {
+			filtered := make([]wsutil.Subscription, 0, 2)
+			for _, sub := range subs {
+				if sub.Symbol == symbol {
+					filtered = append(filtered, sub)
+				}
+			}
+			subs = filtered
+		}
+
+		data = append(data, map[string]interface{}{
+			"exchange":      registry.Exchange(),
+			"connections":   connections,
+			"subscriptions": subs,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(data),
+		"data":    data,
+	})
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"strconv"
+)
+
+const (
+	defaultDepthLevels = 5  // /api/prices/{symbol} 默认返回的盘口档数
+	maxDepthLevels     = 20 // depth_levels 上限
+)
+
+// DepthLevel 单档盘口
+type DepthLevel struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+// DepthProvider 单个交易所的本地订单簿深度（由 main 按交易所注册连接池的适配，只有维护完整订单簿的交易所才注册）
+type DepthProvider interface {
+	// Depth 返回前 levels 档买卖盘（买盘价格降序，卖盘价格升序，symbol 为交易所原始symbol），没有本地订单簿时返回 false
+	Depth(marketType common.MarketType, symbol string, levels int) (bids, asks []DepthLevel, ok bool)
+}
+
+// SetDepthProvider 注册交易所的订单簿深度（/api/prices/{symbol} 的 depth 字段和 /api/simulate 逐档成交，需要在 Start 之前调用）
+func (s *Server) SetDepthProvider(exchange common.Exchange, provider DepthProvider) {
+	if s.depthProviders == nil {
+		s.depthProviders = make(map[common.Exchange]DepthProvider)
+	}
+	s.depthProviders[exchange] = provider
+}
+
+// depthLevelJSON 盘口档位的 JSON 格式，cum_notional 为从最优价到本档的累计名义价值
+type depthLevelJSON struct {
+	Price       float64 `json:"price"`
+	Size        float64 `json:"size"`
+	CumNotional float64 `json:"cum_notional"`
+}
+
+// depthJSON 价格条目中的 depth 对象
+type depthJSON struct {
+	Bids        []depthLevelJSON `json:"bids"`
+	Asks        []depthLevelJSON `json:"asks"`
+	BidNotional float64          `json:"bid_notional"` // 返回的买盘档位的名义价值合计
+	AskNotional float64          `json:"ask_notional"` // 返回的卖盘档位的名义价值合计
+}
+
+// parseDepthLevels 解析 depth_levels 查询参数（默认5，0表示不返回深度，最大20）
+func parseDepthLevels(raw string) (int, error) {
+	if raw == "" {
+		return defaultDepthLevels, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 || n > maxDepthLevels {
+		return 0, fmt.Errorf("invalid depth_levels %q: must be an integer between 0 and %d", raw, maxDepthLevels)
+	}
+	return n, nil
+}
+
+// priceDepth 获取报价所在场所的盘口深度，交易所没有注册深度或订单簿不可用时返回nil
+func (s *Server) priceDepth(price *common.Price, levels int) *depthJSON {
+	provider, exists := s.depthProviders[price.Exchange]
+	if !exists || levels <= 0 {
+		return nil
+	}
+	bids, asks, ok := provider.Depth(price.MarketType, price.Symbol, levels)
+	if !ok {
+		return nil
+	}
+	return buildDepth(bids, asks)
+}
+
+// buildDepth 计算各档的累计名义价值（档位顺序由 DepthProvider 保证）
+func buildDepth(bids, asks []DepthLevel) *depthJSON {
+	depth := &depthJSON{}
+	depth.Bids, depth.BidNotional = cumulateDepth(bids)
+	depth.Asks, depth.AskNotional = cumulateDepth(asks)
+	return depth
+}
+
+// cumulateDepth 逐档累加名义价值，返回各档和合计
+func cumulateDepth(levels []DepthLevel) ([]depthLevelJSON, float64) {
+	result := make([]depthLevelJSON, 0, len(levels))
+	cum := 0.0
+	for _, level := range levels {
+		cum += level.Price * level.Size
+		result = append(result, depthLevelJSON{Price: level.Price, Size: level.Size, CumNotional: cum})
+	}
+	return result, cum
+}
+
+// simulationDepth 为 /api/simulate 提供多档盘口（实现 pricestore.DepthSource，取 maxDepthLevels 档）
+func (s *Server) simulationDepth(price *common.Price) (bids, asks []pricestore.BookLevel, ok bool) {
+	provider, exists := s.depthProviders[price.Exchange]
+	if !exists {
+		return nil, nil, false
+	}
+	depthBids, depthAsks, ok := provider.Depth(price.MarketType, price.Symbol, maxDepthLevels)
+	if !ok {
+		return nil, nil, false
+	}
+	return toBookLevels(depthBids), toBookLevels(depthAsks), true
+}
+
+// toBookLevels 转换为 pricestore 的盘口档位
+func toBookLevels(levels []DepthLevel) []pricestore.BookLevel {
+	result := make([]pricestore.BookLevel, 0, len(levels))
+	for _, level := range levels {
+		result = append(result, pricestore.BookLevel{Price: level.Price, Size: level.Size})
+	}
+	return result
+}
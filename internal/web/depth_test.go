@@ -0,0 +1,111 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeDepth 固定的三档订单簿，记录请求的档数
+type fakeDepth struct {
+	levels []int
+}
+
+func (f *fakeDepth) Depth(marketType common.MarketType, symbol string, levels int) (bids, asks []DepthLevel, ok bool) {
+	f.levels = append(f.levels, levels)
+	bids = []DepthLevel{{Price: 100, Size: 1}, {Price: 99, Size: 2}, {Price: 98, Size: 3}}
+	asks = []DepthLevel{{Price: 101, Size: 0.5}, {Price: 102, Size: 1.5}, {Price: 103, Size: 4}}
+	if levels < len(bids) {
+		bids, asks = bids[:levels], asks[:levels]
+	}
+	return bids, asks, true
+}
+
+func TestPriceDepth(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	now := time.Now()
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeBinance, now))
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeLighter, now))
+	s := NewServer(store, "")
+	provider := &fakeDepth{}
+	s.SetDepthProvider(common.ExchangeLighter, provider)
+
+	get := func(query string) (int, []map[string]json.RawMessage) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/prices/BTCUSDT"+query, nil))
+		if rec.Code != http.StatusOK {
+			return rec.Code, nil
+		}
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, items
+	}
+
+	_, items := get("?depth_levels=2")
+	if len(items) != 2 {
+		t.Fatalf("%d prices, want Binance and Lighter", len(items))
+	}
+	if _, ok := items[0]["depth"]; ok {
+		t.Fatal("depth returned for Binance without a depth provider")
+	}
+
+	// JSON 结构：bids/asks 各档带累计名义价值，合计等于最后一档的累计值
+	var depth map[string]json.RawMessage
+	if err := json.Unmarshal(items[1]["depth"], &depth); err != nil {
+		t.Fatalf("Lighter depth: %v", err)
+	}
+	keys := make([]string, 0, len(depth))
+	for key := range depth {
+		keys = append(keys, key)
+	}
+	for _, key := range []string{"bids", "asks", "bid_notional", "ask_notional"} {
+		if _, ok := depth[key]; !ok {
+			t.Fatalf("depth keys %v, missing %q", keys, key)
+		}
+	}
+	var levels struct {
+		Bids        []map[string]float64 `json:"bids"`
+		Asks        []map[string]float64 `json:"asks"`
+		BidNotional float64              `json:"bid_notional"`
+		AskNotional float64              `json:"ask_notional"`
+	}
+	if err := json.Unmarshal(items[1]["depth"], &levels); err != nil {
+		t.Fatal(err)
+	}
+	wantBids := []map[string]float64{
+		{"price": 100, "size": 1, "cum_notional": 100},
+		{"price": 99, "size": 2, "cum_notional": 298},
+	}
+	wantAsks := []map[string]float64{
+		{"price": 101, "size": 0.5, "cum_notional": 50.5},
+		{"price": 102, "size": 1.5, "cum_notional": 203.5},
+	}
+	if !reflect.DeepEqual(levels.Bids, wantBids) || !reflect.DeepEqual(levels.Asks, wantAsks) {
+		t.Fatalf("depth levels = %+v / %+v", levels.Bids, levels.Asks)
+	}
+	if math.Abs(levels.BidNotional-298) > 1e-9 || math.Abs(levels.AskNotional-203.5) > 1e-9 {
+		t.Fatalf("notional = %v / %v", levels.BidNotional, levels.AskNotional)
+	}
+
+	// 默认5档，0档不返回也不查询订单簿，超过上限为400
+	provider.levels = nil
+	get("")
+	if _, items := get("?depth_levels=0"); items[1]["depth"] != nil {
+		t.Fatal("depth returned with depth_levels=0")
+	}
+	if !reflect.DeepEqual(provider.levels, []int{defaultDepthLevels}) {
+		t.Fatalf("provider asked for %v levels, want only the default", provider.levels)
+	}
+	if code, _ := get("?depth_levels=21"); code != http.StatusBadRequest {
+		t.Fatalf("depth_levels=21 status %d, want 400", code)
+	}
+}
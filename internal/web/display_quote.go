@@ -0,0 +1,150 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/apiv2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// priceMapQuoteFields priceToAPIMap 中按显示货币换算的绝对价格/成交额字段
+// 百分比、数量、资金费率不换算；original_* 保持原始报价货币，depth 对象保持交易所订单簿的报价货币
+var priceMapQuoteFields = []string{
+	"price", "bid_price", "ask_price", "volume_24h",
+	"mark_price", "index_price", "depth_bid_price", "depth_ask_price",
+}
+
+// displayQuote 请求的显示货币（?quote=EUR|BTC|USDT），只在序列化时换算，存储中的数据始终为USDT
+type displayQuote struct {
+	currency string                  // 请求的显示货币，未指定时为空
+	rate     *pricestore.DisplayRate // 换算使用的参考汇率，汇率不可用时为nil（数据保持USDT）
+	warning  string                  // 汇率缺失或过期的原因
+	now      time.Time
+}
+
+// parseDisplayQuote 解析 quote 查询参数并获取当前参考汇率，不支持的货币返回 error
+// 汇率缺失或过期时不返回错误，数据保持USDT并在响应中带 quote_warning
+func (s *Server) parseDisplayQuote(query url.Values) (*displayQuote, error) {
+	q := &displayQuote{now: time.Now()}
+	raw := query.Get("quote")
+	if raw == "" {
+		return q, nil
+	}
+	currency, err := pricestore.ParseDisplayCurrency(raw)
+	if err != nil {
+		return nil, err
+	}
+	q.currency = currency
+	q.rate, err = s.store.GetDisplayRate(currency, q.now)
+	if err != nil {
+		q.warning = err.Error() + "; values are in USDT"
+	}
+	return q, nil
+}
+
+// active 是否需要换算（USDT 不需要）
+func (q *displayQuote) active() bool {
+	return q.rate != nil && q.rate.Currency != pricestore.DisplayCurrencyUSDT
+}
+
+// convert USDT 数值换算为显示货币
+func (q *displayQuote) convert(v float64) float64 {
+	if !q.active() {
+		return v
+	}
+	return v / q.rate.USDTPerUnit
+}
+
+// convertSpreads 返回换算后的价差副本（价差可能来自只读快照，不能原地修改）
+func (q *displayQuote) convertSpreads(spreads []*pricestore.Spread) []*pricestore.Spread {
+	if !q.active() {
+		return spreads
+	}
+	converted := make([]*pricestore.Spread, len(spreads))
+	for i, spread := range spreads {
+		c := *spread
+		c.BuyPrice = q.convert(c.BuyPrice)
+		c.SellPrice = q.convert(c.SellPrice)
+		c.SpreadAbsolute = q.convert(c.SpreadAbsolute)
+		c.Volume24h = q.convert(c.Volume24h)
+		if c.Projection != nil {
+			projection := *c.Projection
+			projection.BuyPrice = q.convert(projection.BuyPrice)
+			projection.SellPrice = q.convert(projection.SellPrice)
+			c.Projection = &projection
+		}
+		converted[i] = &c
+	}
+	return converted
+}
+
+// convertPriceMap 换算 priceToAPIMap 的价格字段（原地修改，map 为每个请求新建）
+func (q *displayQuote) convertPriceMap(item map[string]interface{}) {
+	if !q.active() {
+		return
+	}
+	for _, field := range priceMapQuoteFields {
+		if v, ok := item[field].(float64); ok {
+			item[field] = q.convert(v)
+		}
+	}
+}
+
+// ageMs 参考汇率的年龄
+func (q *displayQuote) ageMs() int64 {
+	return q.now.Sub(q.rate.UpdatedAt).Milliseconds()
+}
+
+// envelope 在 v1 响应中加入 quote（使用的货币、汇率和汇率年龄）或 quote_warning
+func (q *displayQuote) envelope(resp map[string]interface{}) {
+	if q.currency == "" {
+		return
+	}
+	if q.rate == nil {
+		resp["quote"] = map[string]interface{}{"currency": pricestore.DisplayCurrencyUSDT}
+		resp["quote_warning"] = q.warning
+		return
+	}
+	resp["quote"] = map[string]interface{}{
+		"currency":      q.rate.Currency,
+		"usdt_per_unit": q.rate.USDTPerUnit,
+		"source":        q.rate.Source,
+		"rate_age_ms":   q.ageMs(),
+	}
+}
+
+// v2Envelope 在 v2 envelope 中加入 quote 或 quote_warning
+func (q *displayQuote) v2Envelope(env *apiv2.Envelope) {
+	if q.currency == "" {
+		return
+	}
+	if q.rate == nil {
+		env.Quote = &apiv2.Quote{Currency: pricestore.DisplayCurrencyUSDT}
+		env.QuoteWarning = q.warning
+		return
+	}
+	env.Quote = &apiv2.Quote{
+		Currency:    q.rate.Currency,
+		USDTPerUnit: q.rate.USDTPerUnit,
+		Source:      q.rate.Source,
+		RateAgeMs:   q.ageMs(),
+	}
+}
+
+// headers 响应为数组（没有 envelope）时通过响应头返回 quote 信息
+func (q *displayQuote) headers(w http.ResponseWriter) {
+	if q.currency == "" {
+		return
+	}
+	if q.rate == nil {
+		w.Header().Set("X-Quote-Currency", pricestore.DisplayCurrencyUSDT)
+		w.Header().Set("X-Quote-Warning", q.warning)
+		return
+	}
+	w.Header().Set("X-Quote-Currency", q.rate.Currency)
+	w.Header().Set("X-Quote-Rate", strconv.FormatFloat(q.rate.USDTPerUnit, 'f', -1, 64))
+	w.Header().Set("X-Quote-Rate-Source", q.rate.Source)
+	w.Header().Set("X-Quote-Rate-Age-Ms", strconv.FormatInt(q.ageMs(), 10))
+}
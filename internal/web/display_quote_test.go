@@ -0,0 +1,122 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/apiv2"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addEURRate 在 Aster 上加入一个 EURUSDT 报价（中间价 1.08），只有一个场所，不产生价差
+func addEURRate(t *testing.T, s *Server, received time.Time) {
+	t.Helper()
+	price := seqQuote("EURUSDT", common.ExchangeAster, received)
+	price.BidPrice, price.AskPrice, price.Price = 1.0799, 1.0801, 1.08
+	if !s.store.UpdatePrice(price) {
+		t.Fatal("EURUSDT quote rejected")
+	}
+}
+
+// quotedSpreadsResponse /api/spreads 的 quote 相关字段和价差（按交易对索引）
+type quotedSpreadsResponse struct {
+	Quote *struct {
+		Currency    string  `json:"currency"`
+		USDTPerUnit float64 `json:"usdt_per_unit"`
+		Source      string  `json:"source"`
+		RateAgeMs   *int64  `json:"rate_age_ms"`
+	} `json:"quote"`
+	QuoteWarning string               `json:"quote_warning"`
+	Data         []*pricestore.Spread `json:"data"`
+}
+
+func getQuotedSpreads(t *testing.T, s *Server, query string) (*quotedSpreadsResponse, map[string]*pricestore.Spread) {
+	t.Helper()
+	code, body := getBody(t, s, "/api/spreads"+query)
+	if code != http.StatusOK {
+		t.Fatalf("GET /api/spreads%s: status %d: %s", query, code, body)
+	}
+	var resp quotedSpreadsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	byPair := make(map[string]*pricestore.Spread, len(resp.Data))
+	for _, spread := range resp.Data {
+		byPair[spread.Symbol+"_"+string(spread.BuyExchange)+"_"+string(spread.SellExchange)] = spread
+	}
+	return &resp, byPair
+}
+
+func TestDisplayQuoteEUR(t *testing.T) {
+	s := newOpportunityServer(t)
+	addEURRate(t, s, time.Now())
+
+	_, usdt := getQuotedSpreads(t, s, "")
+	resp, eur := getQuotedSpreads(t, s, "?quote=eur")
+	if resp.Quote == nil || resp.Quote.Currency != pricestore.DisplayCurrencyEUR || resp.Quote.USDTPerUnit != 1.08 ||
+		resp.Quote.Source != "EURUSDT_MID" || resp.Quote.RateAgeMs == nil || *resp.Quote.RateAgeMs < 0 || resp.QuoteWarning != "" {
+		t.Fatalf("quote = %+v, warning %q", resp.Quote, resp.QuoteWarning)
+	}
+	if len(eur) != 6 || len(eur) != len(usdt) {
+		t.Fatalf("%d spreads in EUR, %d in USDT, want 6", len(eur), len(usdt))
+	}
+
+	// 绝对价格、价差和成交量除以汇率，百分比不换算
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b)) }
+	for key, want := range usdt {
+		got := eur[key]
+		if got == nil {
+			t.Fatalf("%s missing from the EUR response", key)
+		}
+		if !near(got.BuyPrice, want.BuyPrice/1.08) || !near(got.SellPrice, want.SellPrice/1.08) ||
+			!near(got.SpreadAbsolute, want.SpreadAbsolute/1.08) || !near(got.Volume24h, want.Volume24h/1.08) {
+			t.Fatalf("%s in EUR = buy %v sell %v abs %v vol %v, want the USDT values / 1.08", key, got.BuyPrice, got.SellPrice, got.SpreadAbsolute, got.Volume24h)
+		}
+		if got.SpreadPercent != want.SpreadPercent || got.BuyOriginalPrice != want.BuyOriginalPrice {
+			t.Fatalf("%s percent %v original %v, want unchanged %v %v", key, got.SpreadPercent, got.BuyOriginalPrice, want.SpreadPercent, want.BuyOriginalPrice)
+		}
+	}
+
+	// v2 在 envelope 中返回同样的汇率
+	code, body := getBody(t, s, "/api/v2/spreads?quote=EUR")
+	var v2 apiv2.SpreadsResponse
+	if code != http.StatusOK || json.Unmarshal(body, &v2) != nil {
+		t.Fatalf("v2 status %d: %s", code, body)
+	}
+	if v2.Quote == nil || v2.Quote.Currency != pricestore.DisplayCurrencyEUR || v2.Quote.USDTPerUnit != 1.08 || v2.QuoteWarning != "" {
+		t.Fatalf("v2 quote = %+v, warning %q", v2.Quote, v2.QuoteWarning)
+	}
+}
+
+func TestDisplayQuoteStaleRateFallsBackToUSDT(t *testing.T) {
+	s := newOpportunityServer(t)
+	addEURRate(t, s, time.Now().Add(-2*time.Minute))
+
+	_, usdt := getQuotedSpreads(t, s, "")
+	resp, fallback := getQuotedSpreads(t, s, "?quote=EUR")
+	if resp.Quote == nil || resp.Quote.Currency != pricestore.DisplayCurrencyUSDT || resp.Quote.USDTPerUnit != 0 {
+		t.Fatalf("quote = %+v, want USDT without a rate", resp.Quote)
+	}
+	if !strings.Contains(resp.QuoteWarning, "stale") || !strings.Contains(resp.QuoteWarning, "values are in USDT") {
+		t.Fatalf("quote warning = %q", resp.QuoteWarning)
+	}
+	for key, want := range usdt {
+		if got := fallback[key]; got == nil || got.BuyPrice != want.BuyPrice || got.SellPrice != want.SellPrice || got.Volume24h != want.Volume24h {
+			t.Fatalf("%s = %+v, want the unconverted USDT values", key, got)
+		}
+	}
+
+	// 没有 EURUSDT 报价时同样保持USDT
+	resp, _ = getQuotedSpreads(t, newOpportunityServer(t), "?quote=EUR")
+	if resp.Quote == nil || resp.Quote.Currency != pricestore.DisplayCurrencyUSDT || !strings.Contains(resp.QuoteWarning, "unavailable") {
+		t.Fatalf("quote without a rate = %+v, warning %q", resp.Quote, resp.QuoteWarning)
+	}
+
+	if code, _ := getBody(t, s, "/api/spreads?quote=JPY"); code != http.StatusBadRequest {
+		t.Fatalf("unsupported quote status %d, want 400", code)
+	}
+}
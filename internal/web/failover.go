@@ -0,0 +1,111 @@
+package web
+
+import (
+	"bytes"
+	"crypto-arbitrage-monitor/internal/failover"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetElector 设置主备选举器（需要在 Start 之前调用），standby 时 API 响应带 "role": "standby"
+func (s *Server) SetElector(elector *failover.Elector) {
+	s.elector = elector
+}
+
+// handleHealth 健康检查：实例角色和主备对端状态
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 未启用主备模式时本实例就是唯一发送通知的实例
+	data := map[string]interface{}{
+		"status": "ok",
+		"role":   failover.RoleLeader,
+	}
+	if s.elector != nil {
+		status := s.elector.Status(time.Now())
+		data["role"] = status.Role
+		data["failover"] = status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// handleFailoverHeartbeat 返回本实例的心跳，供对端的 HTTP 心跳传输轮询
+func (s *Server) handleFailoverHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.elector == nil {
+		http.Error(w, "Failover is disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    s.elector.Beat(),
+	})
+}
+
+// roleMiddleware standby 时在 /api/ 的JSON对象响应中加入 "role": "standby"（leader 的响应不做修改）
+func (s *Server) roleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.elector == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		role := s.elector.Role()
+		if role == failover.RoleLeader {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-Monitor-Role", string(role))
+		buf := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		buf.flush(role)
+	})
+}
+
+// bufferedResponseWriter 缓存响应体，写出前加入角色字段
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader 记录状态码（flush 时写出）
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+// Write 缓存响应体
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// flush 写出响应，JSON对象响应加入 role 字段（其他响应原样写出）
+func (b *bufferedResponseWriter) flush(role failover.Role) {
+	body := b.body.Bytes()
+	if strings.HasPrefix(b.Header().Get("Content-Type"), "application/json") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(body, &obj); err == nil {
+			obj["role"], _ = json.Marshal(role)
+			if labeled, err := json.Marshal(obj); err == nil {
+				body = append(labeled, '\n')
+			}
+		}
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	b.ResponseWriter.Write(body)
+}
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleFunding(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	now := time.Now()
+	for _, q := range []struct {
+		symbol   string
+		exchange common.Exchange
+		funding  bool
+		mark     float64
+		at       time.Time
+	}{
+		{"BTCUSDT", common.ExchangeLighter, true, 100.05, now},
+		{"ETHUSDT", common.ExchangeLighter, true, 0, now.Add(-2 * time.Minute)}, // 过期
+		{"BTCUSDT", common.ExchangeBinance, false, 0, now},                      // 不含资金费率
+	} {
+		store.UpdatePrice(&common.Price{
+			Symbol: q.symbol, Exchange: q.exchange, MarketType: common.MarketTypeFuture,
+			Price: 100, BidPrice: 99.9, AskPrice: 100.1, BidQty: 1, AskQty: 1,
+			Timestamp: q.at, LastUpdated: q.at, Source: common.PriceSourceWebSocket,
+			FundingRate: 0.0001, FundingKnown: q.funding, OpenInterest: 10, MarkPrice: q.mark,
+		})
+	}
+	s := NewServer(store, "")
+
+	get := func(query string) []map[string]interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleFunding(rec, httptest.NewRequest(http.MethodGet, "/api/funding"+query, nil))
+		var resp struct {
+			Success bool                     `json:"success"`
+			Data    []map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success {
+			t.Fatalf("%s: code=%d body=%s", query, rec.Code, rec.Body.String())
+		}
+		return resp.Data
+	}
+
+	data := get("")
+	if len(data) != 1 || data[0]["symbol"] != "BTCUSDT" || data[0]["exchange"] != "LIGHTER" || data[0]["mark_price"] != 100.05 {
+		t.Fatalf("active funding = %v", data)
+	}
+	if data := get("?symbol=ETH"); len(data) != 1 {
+		t.Fatalf("ETH funding = %v, want the stale entry when asked by symbol", data)
+	} else if _, ok := data[0]["mark_price"]; ok {
+		t.Fatalf("mark_price reported without an exchange mark price: %v", data[0])
+	}
+	if data := get("?exchange=binance"); len(data) != 0 {
+		t.Fatalf("binance funding = %v, want none", data)
+	}
+}
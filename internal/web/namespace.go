@@ -0,0 +1,224 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// namespacePattern 命名空间名称规则（用作URL路径段）
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// reservedNamespaces 与默认命名空间API路径冲突的名称
+var reservedNamespaces = map[string]bool{
+	pricestore.DefaultNamespace: true,
+	"spreads":                   true,
+	"stats":                     true,
+	"custom-strategies":         true,
+	"strategies":                true,
+	"arbitrage-opportunities":   true,
+	"debug":                     true,
+	"prices":                    true,
+	"exchange-rates":            true,
+	"age-histogram":             true,
+	"thresholds":                true,
+	"inversions":                true,
+	"blacklist":                 true,
+	"simulate":                  true,
+	"tickers":                   true,
+	"snapshot":                  true,
+	"compare":                   true,
+	"suspects":                  true,
+	"paper":                     true,
+	"funding":                   true,
+	"normalizer":                true,
+	"coverage-gaps":             true,
+	"venue-pairs":               true,
+	"subscribe":                 true,
+	"health":                    true,
+	"failover":                  true,
+	"anomalies":                 true,
+	"version":                   true,
+	"config":                    true,
+	"connections":               true,
+	"stable-basis":              true,
+	"v1":                        true,
+	"v2":                        true,
+}
+
+// AddNamespace 添加一个命名空间的存储，其API挂载在 /api/{namespace}/...（需要在 Start 之前调用）
+// 命名空间名称取自 store.Name()
+func (s *Server) AddNamespace(store *pricestore.PriceStore) error {
+	name := store.Name()
+	if !namespacePattern.MatchString(name) {
+		return fmt.Errorf("invalid namespace %q: must match %s", name, namespacePattern.String())
+	}
+	if reservedNamespaces[name] {
+		return fmt.Errorf("namespace %q conflicts with an existing API path", name)
+	}
+	for _, ns := range s.namespaces {
+		if ns.store.Name() == name {
+			return fmt.Errorf("namespace %q already registered", name)
+		}
+	}
+
+	ns := NewServer(store, s.addr)
+	ns.maxOpportunities = s.maxOpportunities
+	s.namespaces = append(s.namespaces, ns)
+	return nil
+}
+
+// namespaceHandler 将 /api/{namespace}/xxx 改写为 /api/xxx 后交给该命名空间的路由处理
+// version 不为空时处理 /api/{version}/{namespace}/xxx，改写为 /api/{version}/xxx
+func (s *Server) namespaceHandler(version string) http.Handler {
+	mux := http.NewServeMux()
+	s.registerAPIRoutes(mux)
+
+	base := "/api"
+	if version != "" {
+		base += "/" + version
+	}
+	prefix := base + "/" + s.store.Name()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = base + strings.TrimPrefix(r.URL.Path, prefix)
+		r2.URL.RawPath = ""
+		mux.ServeHTTP(w, r2)
+	})
+}
+
+// compareVenue 单个命名空间中某个场所（交易所+市场类型）的报价
+type compareVenue struct {
+	Symbol   string             `json:"symbol"` // 交易所原始symbol
+	BidPrice float64            `json:"bid_price"`
+	AskPrice float64            `json:"ask_price"`
+	Source   common.PriceSource `json:"source"`
+	AgeMs    int64              `json:"age_ms"`
+	Seq      uint64             `json:"seq"`
+}
+
+// compareDiff 同一场所在两个命名空间之间的差异（相对默认命名空间）
+type compareDiff struct {
+	Venue          string   `json:"venue"` // EXCHANGE_MARKET
+	Namespace      string   `json:"namespace"`
+	MissingIn      []string `json:"missing_in,omitempty"`       // 缺少该场所报价的命名空间
+	BidDiffPercent float64  `json:"bid_diff_percent,omitempty"` // (namespace - default) / default * 100
+	AskDiffPercent float64  `json:"ask_diff_percent,omitempty"`
+	AgeDiffMs      int64    `json:"age_diff_ms,omitempty"`
+}
+
+// handleCompare 并排返回各命名空间中同一symbol的报价，并列出与默认命名空间的差异
+// 支持参数:
+// - symbol: 必填（例如 BTC 或 BTCUSDT）
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbol := r.URL.Query().Get("symbol")
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	standardSymbol := pricestore.NormalizeThresholdSymbol(symbol)
+
+	now := time.Now()
+	views := map[string]map[string]compareVenue{
+		pricestore.DefaultNamespace: collectCompareVenues(s.store, standardSymbol, now),
+	}
+	for _, ns := range s.namespaces {
+		views[ns.store.Name()] = collectCompareVenues(ns.store, standardSymbol, now)
+	}
+
+	differences := make([]compareDiff, 0)
+	base := views[pricestore.DefaultNamespace]
+	for _, ns := range s.namespaces {
+		name := ns.store.Name()
+		differences = append(differences, diffCompareVenues(base, views[name], name)...)
+	}
+	sort.Slice(differences, func(i, j int) bool {
+		if differences[i].Namespace != differences[j].Namespace {
+			return differences[i].Namespace < differences[j].Namespace
+		}
+		return differences[i].Venue < differences[j].Venue
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"symbol":  standardSymbol,
+		"data": map[string]interface{}{
+			"namespaces":  views,
+			"differences": differences,
+		},
+	})
+}
+
+// collectCompareVenues 按场所整理某个存储中symbol的报价
+func collectCompareVenues(store *pricestore.PriceStore, symbol string, now time.Time) map[string]compareVenue {
+	prices := store.GetPricesBySymbol(symbol)
+	venues := make(map[string]compareVenue, len(prices))
+	for _, price := range prices {
+		venues[fmt.Sprintf("%s_%s", price.Exchange, price.MarketType)] = compareVenue{
+			Symbol:   price.Symbol,
+			BidPrice: price.BidPrice,
+			AskPrice: price.AskPrice,
+			Source:   price.Source,
+			AgeMs:    now.Sub(price.LastUpdated).Milliseconds(),
+			Seq:      price.Seq,
+		}
+	}
+	return venues
+}
+
+// diffCompareVenues 比较默认命名空间和另一个命名空间的场所报价，返回存在差异的场所
+func diffCompareVenues(base, other map[string]compareVenue, namespace string) []compareDiff {
+	diffs := make([]compareDiff, 0)
+
+	for venue, b := range base {
+		o, ok := other[venue]
+		if !ok {
+			diffs = append(diffs, compareDiff{Venue: venue, Namespace: namespace, MissingIn: []string{namespace}})
+			continue
+		}
+
+		diff := compareDiff{
+			Venue:          venue,
+			Namespace:      namespace,
+			BidDiffPercent: percentDiff(b.BidPrice, o.BidPrice),
+			AskDiffPercent: percentDiff(b.AskPrice, o.AskPrice),
+			AgeDiffMs:      o.AgeMs - b.AgeMs,
+		}
+		if diff.BidDiffPercent != 0 || diff.AskDiffPercent != 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for venue := range other {
+		if _, ok := base[venue]; !ok {
+			diffs = append(diffs, compareDiff{Venue: venue, Namespace: namespace, MissingIn: []string{pricestore.DefaultNamespace}})
+		}
+	}
+
+	return diffs
+}
+
+// percentDiff 计算 (other - base) / base * 100，任一价格缺失时返回0
+func percentDiff(base, other float64) float64 {
+	if base <= 0 || other <= 0 {
+		return 0
+	}
+	diff := (other - base) / base * 100
+	if math.Abs(diff) < 1e-9 {
+		return 0
+	}
+	return diff
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newNamespacedServer 默认存储只有 Binance 报价，实验存储 exp 只有 Lighter 报价
+func newNamespacedServer(t *testing.T) *http.ServeMux {
+	t.Helper()
+	now := time.Now()
+	store := pricestore.NewPriceStore()
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeBinance, now))
+
+	exp := pricestore.NewPriceStore()
+	exp.SetName("exp")
+	exp.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeLighter, now))
+
+	s := NewServer(store, "")
+	if err := s.AddNamespace(exp); err != nil {
+		t.Fatal(err)
+	}
+	return s.newMux()
+}
+
+// pricesExchanges 请求价格接口，返回 BTCUSDT 报价的交易所
+func pricesExchanges(t *testing.T, mux *http.ServeMux, path string) []common.Exchange {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d", path, rec.Code)
+	}
+	var resp struct {
+		Success bool                `json:"success"`
+		Data    map[string][]apiRow `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	if !resp.Success {
+		t.Fatalf("GET %s: success=false", path)
+	}
+	exchanges := make([]common.Exchange, 0)
+	for _, row := range resp.Data["BTCUSDT"] {
+		exchanges = append(exchanges, row.Exchange)
+	}
+	return exchanges
+}
+
+func TestNamespacedRoutesServeTheirStore(t *testing.T) {
+	mux := newNamespacedServer(t)
+
+	tests := []struct {
+		path string
+		want common.Exchange
+	}{
+		{"/api/prices", common.ExchangeBinance}, // 默认命名空间路径不变
+		{"/api/v1/prices", common.ExchangeBinance},
+		{"/api/exp/prices", common.ExchangeLighter},
+		{"/api/v1/exp/prices", common.ExchangeLighter},
+	}
+	for _, tt := range tests {
+		got := pricesExchanges(t, mux, tt.path)
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("GET %s: exchanges %v, want [%s]", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompareListsBothNamespaces(t *testing.T) {
+	mux := newNamespacedServer(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/compare?symbol=BTC", nil))
+	var resp struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Namespaces  map[string]map[string]compareVenue `json:"namespaces"`
+			Differences []compareDiff                      `json:"differences"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success || len(resp.Data.Namespaces) != 2 {
+		t.Fatalf("compare = %+v", resp)
+	}
+	if _, ok := resp.Data.Namespaces[pricestore.DefaultNamespace]["BINANCE_FUTURE"]; !ok {
+		t.Fatalf("default view = %+v", resp.Data.Namespaces[pricestore.DefaultNamespace])
+	}
+	if _, ok := resp.Data.Namespaces["exp"]["LIGHTER_FUTURE"]; !ok {
+		t.Fatalf("exp view = %+v", resp.Data.Namespaces["exp"])
+	}
+
+	// 两个场所各自只在一个命名空间中出现
+	missing := make(map[string]string)
+	for _, d := range resp.Data.Differences {
+		if len(d.MissingIn) == 1 {
+			missing[d.Venue] = d.MissingIn[0]
+		}
+	}
+	if missing["BINANCE_FUTURE"] != "exp" || missing["LIGHTER_FUTURE"] != pricestore.DefaultNamespace {
+		t.Fatalf("differences = %+v", resp.Data.Differences)
+	}
+}
+
+func TestAddNamespaceRejectsConflicts(t *testing.T) {
+	s := NewServer(pricestore.NewPriceStore(), "")
+	named := func(name string) *pricestore.PriceStore {
+		store := pricestore.NewPriceStore()
+		store.SetName(name)
+		return store
+	}
+
+	if err := s.AddNamespace(named("exp")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"exp", "prices", pricestore.DefaultNamespace, "v2", "Bad Name", ""} {
+		if err := s.AddNamespace(named(name)); err == nil {
+			t.Errorf("AddNamespace(%q) accepted", name)
+		}
+	}
+}
+
+func TestDiffCompareVenuesPrices(t *testing.T) {
+	base := map[string]compareVenue{"BINANCE_FUTURE": {BidPrice: 100, AskPrice: 100.1}}
+	same := map[string]compareVenue{"BINANCE_FUTURE": {BidPrice: 100, AskPrice: 100.1}}
+	if diffs := diffCompareVenues(base, same, "exp"); len(diffs) != 0 {
+		t.Fatalf("identical views produced differences %+v", diffs)
+	}
+
+	moved := map[string]compareVenue{"BINANCE_FUTURE": {BidPrice: 101, AskPrice: 100.1}}
+	diffs := diffCompareVenues(base, moved, "exp")
+	if len(diffs) != 1 || diffs[0].BidDiffPercent != 1 || diffs[0].AskDiffPercent != 0 {
+		t.Fatalf("differences = %+v, want bid +1%%", diffs)
+	}
+}
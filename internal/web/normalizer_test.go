@@ -0,0 +1,128 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// crossVenueSpread 是否存在 Binance 与 Lighter 之间的价差
+func crossVenueSpread(store *pricestore.PriceStore) bool {
+	for _, spread := range store.CalculateSpreads() {
+		if (spread.BuyExchange == common.ExchangeBinance && spread.SellExchange == common.ExchangeLighter) ||
+			(spread.BuyExchange == common.ExchangeLighter && spread.SellExchange == common.ExchangeBinance) {
+			return true
+		}
+	}
+	return false
+}
+
+func postReload(t *testing.T, mux *http.ServeMux) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/normalizer/reload", nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, resp
+}
+
+func TestNormalizerReloadCrossesMappedSymbols(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "symbol_mappings.json")
+	store := pricestore.NewPriceStore()
+	if _, err := store.LoadSymbolMappings(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一资产在两个场所使用不同的名字
+	now := time.Now()
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeBinance, now))
+	lighter := seqQuote("XBTUSDT", common.ExchangeLighter, now)
+	lighter.BidPrice, lighter.AskPrice = 100.5, 100.6
+	store.UpdatePrice(lighter)
+	mux := NewServer(store, "").newMux()
+
+	if crossVenueSpread(store) || len(store.GetPricesBySymbol("BTCUSDT")) != 1 {
+		t.Fatal("BTCUSDT and XBTUSDT paired before any mapping")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"XBT": "BTC"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, resp := postReload(t, mux)
+	if code != http.StatusOK || resp["success"] != true || resp["count"] != float64(1) {
+		t.Fatalf("reload = %d %v", code, resp)
+	}
+	if data, _ := resp["data"].(map[string]interface{}); data["XBTUSDT"] != "BTCUSDT" {
+		t.Fatalf("reloaded mappings = %v", resp["data"])
+	}
+
+	// 已入库的价格立即按新映射归组
+	if n := len(store.GetPricesBySymbol("BTCUSDT")); n != 2 {
+		t.Fatalf("BTCUSDT has %d prices after reload, want 2", n)
+	}
+	if _, separate := store.GetAllPricesBySymbol()["XBTUSDT"]; separate {
+		t.Fatal("XBTUSDT still indexed separately after reload")
+	}
+	if !crossVenueSpread(store) {
+		t.Fatal("mapped symbols do not cross after reload")
+	}
+
+	// 之后的更新也使用新映射
+	lighter.Timestamp, lighter.LastUpdated = now.Add(time.Second), now.Add(time.Second)
+	store.UpdatePrice(lighter)
+	if n := len(store.GetPricesBySymbol("BTCUSDT")); n != 2 {
+		t.Fatalf("BTCUSDT has %d prices after a new XBT update, want 2", n)
+	}
+}
+
+func TestNormalizerReloadRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "symbol_mappings.json")
+	if err := os.WriteFile(path, []byte(`{"XBT": "BTC"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := pricestore.NewPriceStore()
+	if _, err := store.LoadSymbolMappings(path); err != nil {
+		t.Fatal(err)
+	}
+	mux := NewServer(store, "").newMux()
+
+	for name, content := range map[string]string{
+		"malformed": `{"XBT": `,
+		"chained":   `{"XBT": "BTC", "BTC": "WBTC"}`,
+		"empty":     `{"XBT": ""}`,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if code, _ := postReload(t, mux); code != http.StatusBadRequest {
+			t.Fatalf("%s file: status %d, want 400", name, code)
+		}
+		if got := store.GetSymbolMappings(); len(got) != 1 || got["XBTUSDT"] != "BTCUSDT" {
+			t.Fatalf("%s file replaced the previous mappings: %v", name, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/normalizer/reload", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET: status %d, want 405", rec.Code)
+	}
+}
+
+func TestNormalizerReloadWithoutFile(t *testing.T) {
+	mux := NewServer(pricestore.NewPriceStore(), "").newMux()
+	if code, _ := postReload(t, mux); code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400 when no mappings file is configured", code)
+	}
+}
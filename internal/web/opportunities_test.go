@@ -0,0 +1,177 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newOpportunityServer 三个币种各有一个 Binance -> Lighter 的套利机会，价差 SOL < ETH < BTC
+func newOpportunityServer(t *testing.T) *Server {
+	t.Helper()
+	store := pricestore.NewPriceStore()
+	now := time.Now()
+	for _, q := range []struct {
+		symbol string
+		spread float64
+	}{
+		{"SOLUSDT", 0.002},
+		{"BTCUSDT", 0.005},
+		{"ETHUSDT", 0.003},
+	} {
+		for _, leg := range []struct {
+			exchange common.Exchange
+			bid, ask float64
+		}{
+			{common.ExchangeBinance, 99.99, 100},
+			{common.ExchangeLighter, 100 * (1 + q.spread), 100*(1+q.spread) + 0.01},
+		} {
+			store.UpdatePrice(&common.Price{
+				Symbol: q.symbol, Exchange: leg.exchange, MarketType: common.MarketTypeFuture,
+				Price: (leg.bid + leg.ask) / 2, BidPrice: leg.bid, AskPrice: leg.ask, BidQty: 10, AskQty: 10,
+				Volume24h: 1e9, VolumeKnown: true, Timestamp: now, LastUpdated: now,
+				Source: common.PriceSourceWebSocket, QuoteCurrency: common.QuoteCurrencyUSDT,
+			})
+		}
+	}
+	return NewServer(store, "")
+}
+
+type opportunitiesResponse struct {
+	Success bool                               `json:"success"`
+	Count   int                                `json:"count"`
+	Total   int                                `json:"total"`
+	Offset  int                                `json:"offset"`
+	Limit   int                                `json:"limit"`
+	HasMore bool                               `json:"has_more"`
+	Data    []*pricestore.ArbitrageOpportunity `json:"data"`
+}
+
+func getOpportunities(t *testing.T, s *Server, query string) (int, *opportunitiesResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.handleArbitrageOpportunities(rec, httptest.NewRequest(http.MethodGet, "/api/arbitrage-opportunities"+query, nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var resp opportunitiesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !resp.Success {
+		t.Fatalf("%s: body=%s", query, rec.Body.String())
+	}
+	return rec.Code, &resp
+}
+
+func opportunitySymbols(opps []*pricestore.ArbitrageOpportunity) []string {
+	symbols := make([]string, 0, len(opps))
+	for _, opp := range opps {
+		symbols = append(symbols, opp.Symbol)
+	}
+	return symbols
+}
+
+func TestArbitrageOpportunitiesLimitKeepsTopRanked(t *testing.T) {
+	s := newOpportunityServer(t)
+
+	tests := []struct {
+		name        string
+		maxReturned int
+		query       string
+		want        []string
+		wantMore    bool
+	}{
+		{"no cap", 0, "", []string{"BTC", "ETH", "SOL"}, false},
+		{"limit keeps the widest spreads", 0, "?limit=2", []string{"BTC", "ETH"}, true},
+		{"offset applied after ranking", 0, "?offset=1&limit=1", []string{"ETH"}, true},
+		{"offset past the end", 0, "?offset=5", []string{}, false},
+		{"server cap without limit", 2, "", []string{"BTC", "ETH"}, true},
+		{"limit above the server cap", 1, "?limit=3", []string{"BTC"}, true},
+		{"last page under the cap", 2, "?offset=2", []string{"SOL"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.SetMaxOpportunities(tt.maxReturned)
+			_, resp := getOpportunities(t, s, tt.query)
+			got := opportunitySymbols(resp.Data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("symbols = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("symbols = %v, want %v", got, tt.want)
+				}
+			}
+			if resp.Total != 3 || resp.Count != len(tt.want) || resp.HasMore != tt.wantMore {
+				t.Fatalf("total=%d count=%d has_more=%v, want 3/%d/%v", resp.Total, resp.Count, resp.HasMore, len(tt.want), tt.wantMore)
+			}
+		})
+	}
+
+	if code, _ := getOpportunities(t, s, "?offset=-1"); code != http.StatusBadRequest {
+		t.Fatalf("negative offset: code %d, want 400", code)
+	}
+}
+
+// newPairingServer BTCUSDT 的四个场所各形成一种市场类型组合：
+// Binance 现货 100、Lighter 合约 100.4、Aster 现货 100.8、Bybit 合约 101.2（盘口买一等于卖一）
+func newPairingServer(t *testing.T) *Server {
+	t.Helper()
+	store := pricestore.NewPriceStore()
+	if err := store.SetThresholdOverride("BTCUSDT", 0.1); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, leg := range []struct {
+		exchange   common.Exchange
+		marketType common.MarketType
+		price      float64
+	}{
+		{common.ExchangeBinance, common.MarketTypeSpot, 100},
+		{common.ExchangeLighter, common.MarketTypeFuture, 100.4},
+		{common.ExchangeAster, common.MarketTypeSpot, 100.8},
+		{common.ExchangeBybit, common.MarketTypeFuture, 101.2},
+	} {
+		quote := seqQuote("BTCUSDT", leg.exchange, now)
+		quote.MarketType = leg.marketType
+		quote.Price, quote.BidPrice, quote.AskPrice = leg.price, leg.price, leg.price
+		if !store.UpdatePrice(quote) {
+			t.Fatalf("%s %s rejected", leg.exchange, leg.marketType)
+		}
+	}
+	return NewServer(store, "")
+}
+
+func TestArbitrageOpportunitiesTypeFilter(t *testing.T) {
+	s := newPairingServer(t)
+
+	_, all := getOpportunities(t, s, "?type=all")
+	seen := make(map[string]bool)
+	for _, opp := range all.Data {
+		seen[opp.Pairing()] = true
+	}
+	for _, pairing := range []string{pricestore.PairingSpotSpot, pricestore.PairingSpotFuture, pricestore.PairingFutureSpot, pricestore.PairingFutureFuture} {
+		if !seen[pairing] {
+			t.Fatalf("type=all has no %s opportunity (pairings %v)", pairing, seen)
+		}
+
+		_, resp := getOpportunities(t, s, "?type="+pairing)
+		if len(resp.Data) == 0 || resp.Total != len(resp.Data) {
+			t.Fatalf("type=%s: %d opportunities, total %d", pairing, len(resp.Data), resp.Total)
+		}
+		for _, opp := range resp.Data {
+			if opp.Pairing() != pairing || opp.BuyMarketType == "" || opp.SellMarketType == "" {
+				t.Fatalf("type=%s returned %s -> %s (%s)", pairing, opp.BuyFrom, opp.SellTo, opp.Pairing())
+			}
+		}
+	}
+	if _, unfiltered := getOpportunities(t, s, ""); unfiltered.Total != all.Total {
+		t.Fatalf("no type: %d opportunities, want the same as type=all (%d)", unfiltered.Total, all.Total)
+	}
+
+	if code, _ := getOpportunities(t, s, "?type=spot_future"); code != http.StatusBadRequest {
+		t.Fatalf("invalid type: code %d, want 400", code)
+	}
+}
@@ -0,0 +1,123 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// pageParams 分页参数，Limit 为0表示不限制（不传参数时返回全部，与分页前的行为一致）
+type pageParams struct {
+	Offset int
+	Limit  int
+}
+
+// parsePage 解析 offset/limit 查询参数，负数或非整数返回错误
+func parsePage(query url.Values) (pageParams, error) {
+	var page pageParams
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"offset", &page.Offset}, {"limit", &page.Limit}} {
+		raw := query.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return pageParams{}, fmt.Errorf("invalid %s %q: must be a non-negative integer", p.name, raw)
+		}
+		*p.value = n
+	}
+	return page, nil
+}
+
+// bounds 返回当前页在 total 条结果中的区间 [start, end)，offset 超出范围时返回空区间
+func (p pageParams) bounds(total int) (int, int) {
+	start := p.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if p.Limit > 0 && start+p.Limit < end {
+		end = start + p.Limit
+	}
+	return start, end
+}
+
+// envelope 在响应中加入分页信息（total 为分页前的结果数）
+func (p pageParams) envelope(resp map[string]interface{}, total int) {
+	resp["total"] = total
+	resp["offset"] = p.Offset
+	if p.Limit > 0 {
+		resp["limit"] = p.Limit
+	}
+}
+
+// parseFields 解析 fields 查询参数（逗号分隔的JSON字段名，嵌套字段用 . 分隔），未指定时返回nil表示返回全部字段
+func parseFields(query url.Values) []string {
+	raw := query.Get("fields")
+	if raw == "" {
+		return nil
+	}
+	fields := make([]string, 0)
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// projectMap 只保留指定字段（不存在的字段忽略），a.b 只保留嵌套对象 a 中的字段 b
+func projectMap(item map[string]interface{}, fields []string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		projectPath(projected, item, strings.Split(field, "."))
+	}
+	return projected
+}
+
+// projectPath 将 src 中 path 指向的值复制到 dst 的相同位置，路径中间不是对象时忽略
+func projectPath(dst, src map[string]interface{}, path []string) {
+	value, exists := src[path[0]]
+	if !exists {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	// 已选择整个对象时 child 就是 nested，再复制一次没有影响
+	child, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		child = make(map[string]interface{})
+		dst[path[0]] = child
+	}
+	projectPath(child, nested, path[1:])
+}
+
+// projectItems 将结构体切片按JSON字段名投影为只包含指定字段的map列表
+func projectItems(items interface{}, fields []string) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var full []map[string]interface{}
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+
+	projected := make([]map[string]interface{}, 0, len(full))
+	for _, item := range full {
+		projected = append(projected, projectMap(item, fields))
+	}
+	return projected, nil
+}
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type spreadsPage struct {
+	Count  int                      `json:"count"`
+	Total  int                      `json:"total"`
+	Offset int                      `json:"offset"`
+	Limit  *int                     `json:"limit"`
+	Data   []map[string]interface{} `json:"data"`
+}
+
+func getSpreadsPage(t *testing.T, s *Server, query string) (int, *spreadsPage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/spreads"+query, nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var page spreadsPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, &page
+}
+
+func TestSpreadsPaging(t *testing.T) {
+	s := newOpportunityServer(t) // 3个symbol，每个两个方向共6个价差
+
+	tests := []struct {
+		name   string
+		query  string
+		count  int
+		offset int
+		limit  int // 0表示响应中没有 limit
+	}{
+		{"no params returns everything", "", 6, 0, 0},
+		{"limit=0 means unlimited", "?limit=0", 6, 0, 0},
+		{"first page", "?limit=4", 4, 0, 4},
+		{"last partial page", "?offset=4&limit=4", 2, 4, 4},
+		{"offset past the end", "?offset=100&limit=10", 0, 100, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, page := getSpreadsPage(t, s, tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("status %d", code)
+			}
+			if page.Count != tt.count || len(page.Data) != tt.count || page.Total != 6 || page.Offset != tt.offset {
+				t.Fatalf("count %d (%d rows), total %d, offset %d; want count %d, total 6, offset %d",
+					page.Count, len(page.Data), page.Total, page.Offset, tt.count, tt.offset)
+			}
+			switch {
+			case tt.limit == 0 && page.Limit != nil:
+				t.Fatalf("limit = %d, want omitted", *page.Limit)
+			case tt.limit != 0 && (page.Limit == nil || *page.Limit != tt.limit):
+				t.Fatalf("limit = %v, want %d", page.Limit, tt.limit)
+			}
+		})
+	}
+
+	for _, query := range []string{"?offset=-1", "?limit=abc"} {
+		if code, _ := getSpreadsPage(t, s, query); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, code)
+		}
+	}
+}
+
+func TestSpreadsFieldsUnknownField(t *testing.T) {
+	s := newOpportunityServer(t)
+	_, page := getSpreadsPage(t, s, "?fields=symbol,spread_percent,no_such_field")
+	if len(page.Data) != 6 {
+		t.Fatalf("%d rows, want 6", len(page.Data))
+	}
+	for _, row := range page.Data {
+		if len(row) != 2 || row["symbol"] == nil || row["spread_percent"] == nil {
+			t.Fatalf("row = %v, want only symbol and spread_percent", row)
+		}
+	}
+}
+
+func TestSpreadsFieldsNested(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	store.SetLatencyProjection(pricestore.DefaultLatencyProjection())
+	// Lighter 一腿交易所时间落后且在下跌，价差带延迟投影
+	t0 := time.Now().Add(-time.Second)
+	for _, q := range []struct {
+		exchange common.Exchange
+		bid, ask float64
+		ts       time.Time
+	}{
+		{common.ExchangeLighter, 99.99, 100.01, t0},
+		{common.ExchangeLighter, 99.84, 99.86, t0.Add(300 * time.Millisecond)},
+		{common.ExchangeBinance, 99.70, 99.71, t0.Add(800 * time.Millisecond)},
+	} {
+		price := seqQuote("BTCUSDT", q.exchange, q.ts)
+		price.BidPrice, price.AskPrice, price.Price = q.bid, q.ask, (q.bid+q.ask)/2
+		if !store.UpdatePrice(price) {
+			t.Fatalf("%s quote rejected", q.exchange)
+		}
+	}
+	s := NewServer(store, "")
+
+	_, page := getSpreadsPage(t, s, "?fields=symbol,projection.leg,projection.gap_ms,projection.no_such_field")
+	if len(page.Data) == 0 {
+		t.Fatal("no spreads")
+	}
+	for _, row := range page.Data {
+		projection, ok := row["projection"].(map[string]interface{})
+		if len(row) != 2 || row["symbol"] != "BTCUSDT" || !ok {
+			t.Fatalf("row = %v, want symbol and projection", row)
+		}
+		if len(projection) != 2 || projection["leg"] == nil || projection["gap_ms"] == nil {
+			t.Fatalf("projection = %v, want only leg and gap_ms", projection)
+		}
+	}
+
+	// 选择整个对象时返回全部嵌套字段，嵌套路径不存在的对象不返回
+	_, page = getSpreadsPage(t, s, "?fields=projection,projection.leg,symbol.nested")
+	for _, row := range page.Data {
+		projection, _ := row["projection"].(map[string]interface{})
+		if len(row) != 1 || projection["spread_percent"] == nil || projection["buy_price"] == nil {
+			t.Fatalf("row = %v, want the whole projection only", row)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	allPricesFreshWindow  = 60 * time.Second // fresh_only 的新鲜度窗口（与 /api/stats 的活跃价格一致）
+	allPricesDefaultLimit = 500              // 未指定 limit 时最多返回的symbol数，避免一次返回上千个symbol
+)
+
+// handleAllPrices 返回所有symbol的价格，按标准symbol分组（行情快照可用时不获取存储锁）
+// 支持参数:
+// - exchange: 只返回该交易所的报价（不区分大小写）
+// - min_volume: 24小时成交量下限（成交量未知的报价不过滤）
+// - unknown_volume: 为hide时同时过滤成交量未知的报价
+// - fresh_only: 默认true，只返回60秒内更新过的报价；false时返回存储中的全部报价
+// - offset/limit: 按symbol分页（symbol按字母排序），未指定 limit 时最多返回500个symbol，limit=0 表示不限制
+// - fields: 逗号分隔的字段名，只返回这些字段
+// - quote: USDT|EUR|BTC，价格和成交量按该货币返回（min_volume 仍为USDT）
+// - since_seq: 只返回序列号大于该值的报价（响应中的 seq 作为下次轮询的 since_seq）
+func (s *Server) handleAllPrices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	exchange := common.Exchange(strings.ToUpper(strings.TrimSpace(query.Get("exchange"))))
+	minVolume := parseFloat(query.Get("min_volume"), 0)
+	hideUnknownVolume := query.Get("unknown_volume") == "hide"
+	freshOnly := query.Get("fresh_only") != "false"
+	sinceSeq, err := parseSinceSeq(query.Get("since_seq"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Get("limit") == "" {
+		page.Limit = allPricesDefaultLimit
+	}
+	fields := parseFields(query)
+	quote, err := s.parseDisplayQuote(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	all, seq := s.allPrices()
+	w.Header().Set("X-Store-Seq", strconv.FormatUint(seq, 10))
+
+	now := time.Now()
+	grouped := make(map[string][]*common.Price)
+	for symbol, prices := range all {
+		for _, price := range prices {
+			if price.Seq <= sinceSeq {
+				continue
+			}
+			if exchange != "" && price.Exchange != exchange {
+				continue
+			}
+			if freshOnly && now.Sub(price.LastUpdated) > allPricesFreshWindow {
+				continue
+			}
+			if minVolume > 0 && price.VolumeKnown && price.Volume24h < minVolume {
+				continue
+			}
+			if hideUnknownVolume && !price.VolumeKnown {
+				continue
+			}
+			grouped[symbol] = append(grouped[symbol], price)
+		}
+	}
+
+	symbols := make([]string, 0, len(grouped))
+	for symbol := range grouped {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	total := len(symbols)
+	start, end := page.bounds(total)
+	symbols = symbols[start:end]
+
+	data := make(map[string][]map[string]interface{}, len(symbols))
+	priceCount := 0
+	for _, symbol := range symbols {
+		prices := grouped[symbol]
+		// 按场所排序，与 /api/prices/{symbol} 一致
+		sort.Slice(prices, func(i, j int) bool {
+			if prices[i].Exchange != prices[j].Exchange {
+				return prices[i].Exchange < prices[j].Exchange
+			}
+			return prices[i].MarketType < prices[j].MarketType
+		})
+
+		items := make([]map[string]interface{}, 0, len(prices))
+		for _, price := range prices {
+			item := priceToAPIMap(price)
+			quote.convertPriceMap(item)
+			if fields != nil {
+				item = projectMap(item, fields)
+			}
+			items = append(items, item)
+		}
+		data[symbol] = items
+		priceCount += len(items)
+	}
+
+	resp := map[string]interface{}{
+		"success":     true,
+		"count":       len(data),
+		"price_count": priceCount,
+		"seq":         seq,
+		"data":        data,
+	}
+	page.envelope(resp, total)
+	quote.envelope(resp)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleSnapshot 按序列号增量返回报价，供外部消费者检测漏掉的更新
+// 支持参数:
+// - since_seq: 只返回序列号大于该值的报价（默认0，即全部报价）
+// 响应中的 seq 是读取时的最新序列号，下次轮询时作为 since_seq 传入；
+// 序列号仅在进程生命周期内有效，服务重启后从0开始（seq 小于上次的值说明服务已重启，需要全量重新拉取）
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sinceSeq, err := parseSinceSeq(r.URL.Query().Get("since_seq"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	prices, seq := s.store.GetPricesSince(sinceSeq)
+	data := make([]map[string]interface{}, 0, len(prices))
+	for _, price := range prices {
+		data = append(data, priceToAPIMap(price))
+	}
+
+	w.Header().Set("X-Store-Seq", strconv.FormatUint(seq, 10))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"seq":       seq,
+		"since_seq": sinceSeq,
+		"count":     len(data),
+		"data":      data,
+	})
+}
+
+// parseSinceSeq 解析 since_seq 参数，未指定时为0
+func parseSinceSeq(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("since_seq must be a non-negative integer")
+	}
+	return seq, nil
+}
+
+// allPrices 按标准symbol分组的全部报价及对应的存储序列号
+// 行情快照可用时直接读快照（不获取存储锁，不与写入路径竞争），否则回退到加锁读取
+func (s *Server) allPrices() (map[string][]*common.Price, uint64) {
+	if snap := s.store.GetTickerSnapshot(); snap != nil {
+		return snap.Prices, snap.Seq
+	}
+	seq := s.store.CurrentSeq()
+	return s.store.GetAllPricesBySymbol(), seq
+}
+
+// priceToAPIMap 价格的 JSON 格式（/api/prices 和 /api/prices/{symbol} 共用）
+func priceToAPIMap(price *common.Price) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":             price.Symbol,
+		"exchange":           price.Exchange,
+		"market_type":        price.MarketType,
+		"price":              price.Price,
+		"bid_price":          price.BidPrice,
+		"ask_price":          price.AskPrice,
+		"bid_qty":            price.BidQty,
+		"ask_qty":            price.AskQty,
+		"volume_24h":         price.Volume24h,
+		"volume_known":       price.VolumeKnown,
+		"timestamp":          price.Timestamp,
+		"last_updated":       price.LastUpdated,
+		"source":             price.Source,
+		"seq":                price.Seq,
+		"applied_multiplier": price.AppliedMultiplier,
+		"funding_rate":       price.FundingRate,
+		"open_interest":      price.OpenInterest,
+		"mark_price":         price.MarkPrice,
+		"index_price":        price.IndexPrice,
+		"synthetic_spread":   price.SyntheticSpread,
+		"depth_bid_price":    price.DepthBidPrice,
+		"depth_ask_price":    price.DepthAskPrice,
+	}
+}
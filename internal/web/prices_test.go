@@ -0,0 +1,103 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type snapshotResponse struct {
+	Success  bool     `json:"success"`
+	Seq      uint64   `json:"seq"`
+	SinceSeq uint64   `json:"since_seq"`
+	Count    int      `json:"count"`
+	Data     []apiRow `json:"data"`
+}
+
+type apiRow struct {
+	Symbol   string          `json:"symbol"`
+	Exchange common.Exchange `json:"exchange"`
+	Seq      uint64          `json:"seq"`
+}
+
+func seqQuote(symbol string, exchange common.Exchange, ts time.Time) *common.Price {
+	return &common.Price{
+		Symbol: symbol, Exchange: exchange, MarketType: common.MarketTypeFuture,
+		Price: 100, BidPrice: 99.99, AskPrice: 100.01, BidQty: 10, AskQty: 10,
+		Volume24h: 1e9, VolumeKnown: true, Timestamp: ts, LastUpdated: ts,
+		Source: common.PriceSourceWebSocket, QuoteCurrency: common.QuoteCurrencyUSDT,
+	}
+}
+
+func getSnapshot(t *testing.T, s *Server, query string) (int, *snapshotResponse, http.Header) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.handleSnapshot(rec, httptest.NewRequest(http.MethodGet, "/api/snapshot"+query, nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil, rec.Header()
+	}
+	var resp snapshotResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, &resp, rec.Header()
+}
+
+func TestSnapshotSinceSeq(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	s := NewServer(store, "")
+	now := time.Now()
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeBinance, now))
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeLighter, now))
+
+	_, full, header := getSnapshot(t, s, "")
+	if !full.Success || full.Seq != 2 || full.Count != 2 || header.Get("X-Store-Seq") != "2" {
+		t.Fatalf("full snapshot = %+v (X-Store-Seq %s)", full, header.Get("X-Store-Seq"))
+	}
+
+	// 增量轮询：只返回上次 seq 之后更新过的报价，按序列号升序
+	store.UpdatePrice(seqQuote("ETHUSDT", common.ExchangeBinance, now))
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeBinance, now.Add(time.Millisecond)))
+	_, delta, _ := getSnapshot(t, s, "?since_seq="+strconv.FormatUint(full.Seq, 10))
+	if delta.Seq != 4 || delta.SinceSeq != 2 || delta.Count != 2 {
+		t.Fatalf("delta = %+v, want 2 prices up to seq 4", delta)
+	}
+	if delta.Data[0].Symbol != "ETHUSDT" || delta.Data[0].Seq != 3 || delta.Data[1].Symbol != "BTCUSDT" || delta.Data[1].Seq != 4 {
+		t.Fatalf("delta data = %+v", delta.Data)
+	}
+
+	_, empty, _ := getSnapshot(t, s, "?since_seq=4")
+	if empty.Seq != 4 || empty.Count != 0 || empty.Data == nil {
+		t.Fatalf("caught-up poll = %+v, want empty data", empty)
+	}
+
+	if code, _, _ := getSnapshot(t, s, "?since_seq=-1"); code != http.StatusBadRequest {
+		t.Fatalf("negative since_seq: status %d, want 400", code)
+	}
+}
+
+func TestAllPricesSinceSeq(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	s := NewServer(store, "")
+	now := time.Now()
+	store.UpdatePrice(seqQuote("BTCUSDT", common.ExchangeBinance, now))
+	store.UpdatePrice(seqQuote("ETHUSDT", common.ExchangeBinance, now))
+
+	rec := httptest.NewRecorder()
+	s.handleAllPrices(rec, httptest.NewRequest(http.MethodGet, "/api/prices?since_seq=1", nil))
+	var resp struct {
+		Seq  uint64              `json:"seq"`
+		Data map[string][]apiRow `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Seq != 2 || len(resp.Data) != 1 || len(resp.Data["ETHUSDT"]) != 1 {
+		t.Fatalf("since_seq=1 response = %+v, want only ETHUSDT", resp)
+	}
+}
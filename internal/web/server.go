@@ -1,14 +1,26 @@
 package web
 
 import (
+	"context"
+	"crypto-arbitrage-monitor/internal/failover"
+	"crypto-arbitrage-monitor/internal/faults"
+	"crypto-arbitrage-monitor/internal/netutil"
 	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/internal/wsutil"
+	"crypto-arbitrage-monitor/pkg/apiv2"
+	"crypto-arbitrage-monitor/pkg/common"
+	"crypto-arbitrage-monitor/pkg/common/numutil"
 	"embed"
 	"encoding/json"
+	"fmt"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,30 +29,164 @@ var staticFS embed.FS
 
 // Server Web服务器
 type Server struct {
-	store *pricestore.PriceStore
-	addr  string
+	store   *pricestore.PriceStore
+	addr    string
+	timings *timingRecorder // 接口分阶段耗时统计
+
+	// 其他命名空间的存储（/api/{namespace}/...），按添加顺序
+	namespaces []*Server
+
+	// 当前日志文件大小（字节），为nil时 /api/stats 不返回
+	logSize func() int64
+
+	// 各WebSocket连接的处理延迟状态（/api/stats 的 ws_lag），key 为连接名
+	wsLag map[string]func() interface{}
+
+	// 各交易所的按需WebSocket订阅（POST /api/subscribe），只在默认命名空间提供
+	subscribers map[common.Exchange]Subscriber
+
+	// 各交易所的本地订单簿深度（/api/prices/{symbol} 的 depth），只在默认命名空间提供
+	depthProviders map[common.Exchange]DepthProvider
+
+	// 各交易所连接池的订阅登记（/api/connections），按注册顺序
+	registries []*wsutil.SubscriptionRegistry
+
+	// 脱敏后的有效配置（/api/version 返回），为nil时不返回配置
+	effectiveConfig map[string]interface{}
+
+	// 未脱敏的有效配置（设置了 API 令牌时 /api/config/export?include_secrets=true 返回）
+	unredactedConfig map[string]interface{}
+
+	// 主备选举器（/api/health 返回角色，standby 时API响应带 role），为nil时表示未启用主备模式
+	elector *failover.Elector
+
+	// 只读存储快照（/api/spreads、/api/stats 无锁读取），snapshotInterval 为0时不启用
+	snapshotInterval time.Duration
+	webSnapshot      atomic.Pointer[pricestore.StoreSnapshot]
+
+	// API 令牌（API_TOKEN），非空时 /api/* 需要 Bearer 认证（/api/health 除外）
+	apiToken string
+
+	// /api/arbitrage-opportunities 最多返回的套利机会数（按价差排名），0表示不限制
+	maxOpportunities int
+
+	// 开始监听端口后关闭（自动打开浏览器等待该信号，而不是固定延时）
+	ready chan struct{}
 }
 
 // NewServer 创建新的Web服务器
 func NewServer(store *pricestore.PriceStore, addr string) *Server {
 	return &Server{
-		store: store,
-		addr:  addr,
+		store:            store,
+		addr:             addr,
+		timings:          newTimingRecorder(),
+		ready:            make(chan struct{}),
+		maxOpportunities: DefaultMaxOpportunities,
+	}
+}
+
+// DefaultMaxOpportunities /api/arbitrage-opportunities 默认最多返回的套利机会数
+const DefaultMaxOpportunities = 200
+
+// SetMaxOpportunities 设置 /api/arbitrage-opportunities 最多返回的套利机会数（同时应用于已添加的命名空间），0表示不限制
+// 请求的 limit 参数只能在该上限内进一步减少返回数量
+func (s *Server) SetMaxOpportunities(max int) {
+	if max < 0 {
+		max = 0
+	}
+	s.maxOpportunities = max
+	for _, ns := range s.namespaces {
+		ns.maxOpportunities = max
+	}
+}
+
+// Ready 返回在服务器开始监听端口后关闭的channel（监听失败时不会关闭）
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// SetLogSizeFunc 设置获取当前日志文件大小的函数（/api/stats 返回 log_file_size）
+func (s *Server) SetLogSizeFunc(fn func() int64) {
+	s.logSize = fn
+}
+
+// SetWSLagFunc 注册WebSocket连接的处理延迟状态（/api/stats 返回 ws_lag，需要在 Start 之前调用），fn 返回 nil 时不返回该连接
+func (s *Server) SetWSLagFunc(name string, fn func() interface{}) {
+	if s.wsLag == nil {
+		s.wsLag = make(map[string]func() interface{})
+	}
+	s.wsLag[name] = fn
+}
+
+// Start 启动服务器，ctx 取消时停止快照刷新并关闭服务器（正常关闭时返回nil）
+func (s *Server) Start(ctx context.Context) error {
+	mux := s.newMux()
+
+	// 只读存储快照，请求处理不再获取存储锁
+	if s.snapshotInterval > 0 {
+		go s.runSnapshotRefresher(ctx, s.snapshotInterval)
+		for _, ns := range s.namespaces {
+			go ns.runSnapshotRefresher(ctx, s.snapshotInterval)
+		}
+	}
+
+	log.Printf("[Web Server] Starting on %s", s.addr)
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	close(s.ready)
+	if s.apiToken != "" {
+		log.Printf("[Web Server] API token authentication enabled for /api/* and /ws/*")
+	}
+
+	server := &http.Server{Handler: s.corsMiddleware(s.authMiddleware(s.roleMiddleware(mux)))}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
 	}
+	return nil
 }
 
-// Start 启动服务器
-func (s *Server) Start() error {
+// serverShutdownTimeout 关闭服务器时等待进行中请求完成的最长时间
+const serverShutdownTimeout = 5 * time.Second
+
+// newMux 构建所有路由（默认命名空间、其他命名空间和静态文件）
+func (s *Server) newMux() *http.ServeMux {
 	mux := http.NewServeMux()
 
-	// API endpoints
-	mux.HandleFunc("/api/spreads", s.handleSpreads)
-	mux.HandleFunc("/api/stats", s.handleStats)
-	mux.HandleFunc("/api/custom-strategies", s.handleCustomStrategies)
-	mux.HandleFunc("/api/arbitrage-opportunities", s.handleArbitrageOpportunities)
-	mux.HandleFunc("/api/debug/prices", s.handleDebugPrices)
-	mux.HandleFunc("/api/prices/", s.handlePricesBySymbol)
-	mux.HandleFunc("/api/exchange-rates", s.handleExchangeRates)
+	// API endpoints（默认命名空间，保持原有路径）
+	s.registerAPIRoutes(mux)
+	mux.HandleFunc("/api/compare", s.handleCompare)
+	mux.HandleFunc("/api/subscribe", s.handleSubscribe)
+	mux.HandleFunc("/api/connections", s.handleConnections)
+	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/version", s.handleVersion)
+	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/config/export", s.handleConfigExport)
+	mux.HandleFunc("/api/config/import", s.handleConfigImport)
+	mux.HandleFunc("/ws/watch", s.handleWatch)
+	mux.HandleFunc(failover.HeartbeatPath, s.handleFailoverHeartbeat)
+
+	// 故障注入（仅 -tags faults 构建，只接受本机请求）
+	if faults.Enabled {
+		mux.HandleFunc("/debug/faults", faults.Handler())
+		log.Printf("[Web Server] Fault injection enabled at /debug/faults (localhost only)")
+	}
+
+	// 其他命名空间: /api/{namespace}/spreads 等
+	for _, ns := range s.namespaces {
+		mux.Handle("/api/"+ns.store.Name()+"/", ns.namespaceHandler(""))
+		mux.Handle("/api/"+apiv2.Version+"/"+ns.store.Name()+"/", ns.namespaceHandler(apiv2.Version))
+	}
+
+	// /api/v1/* 与无版本前缀的路径相同（见 apiversion.go）
+	mux.Handle("/api/v1/", v1AliasHandler(mux))
 
 	// Static files - 使用子文件系统来正确访问 static 目录
 	staticDir, err := fs.Sub(staticFS, "static")
@@ -49,16 +195,48 @@ func (s *Server) Start() error {
 	}
 	mux.Handle("/", http.FileServer(http.FS(staticDir)))
 
-	log.Printf("[Web Server] Starting on %s", s.addr)
-	return http.ListenAndServe(s.addr, s.corsMiddleware(mux))
+	return mux
+}
+
+// registerAPIRoutes 注册使用 s.store 的所有API路由
+func (s *Server) registerAPIRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/spreads", s.handleSpreads)
+	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/custom-strategies", s.handleCustomStrategies)
+	mux.HandleFunc("/api/strategies", s.handleStrategies)
+	mux.HandleFunc("/api/arbitrage-opportunities", s.handleArbitrageOpportunities)
+	mux.HandleFunc("/api/debug/prices", s.handleDebugPrices)
+	mux.HandleFunc("/api/debug/updates/", s.handleDebugUpdates)
+	mux.HandleFunc("/api/prices", s.handleAllPrices)
+	mux.HandleFunc("/api/prices/", s.handlePricesBySymbol)
+	mux.HandleFunc("/api/snapshot", s.handleSnapshot)
+	mux.HandleFunc("/api/exchange-rates", s.handleExchangeRates)
+	mux.HandleFunc("/api/age-histogram", s.handleAgeHistogram)
+	mux.HandleFunc("/api/thresholds", s.handleThresholds)
+	mux.HandleFunc("/api/inversions", s.handleInversions)
+	mux.HandleFunc("/api/anomalies", s.handleAnomalies)
+	mux.HandleFunc("/api/stable-basis", s.handleStableBasis)
+	mux.HandleFunc("/api/thresholds/", s.handleThresholdBySymbol)
+	mux.HandleFunc("/api/blacklist", s.handleBlacklist)
+	mux.HandleFunc("/api/normalizer/reload", s.handleNormalizerReload)
+	mux.HandleFunc("/api/simulate", s.handleSimulate)
+	mux.HandleFunc("/api/tickers", s.handleTickers)
+	mux.HandleFunc("/api/suspects", s.handleSuspects)
+	mux.HandleFunc("/api/coverage-gaps", s.handleCoverageGaps)
+	mux.HandleFunc("/api/venue-pairs", s.handleVenuePairs)
+	mux.HandleFunc("/api/paper/positions", s.handlePaperPositions)
+	mux.HandleFunc("/api/paper/summary", s.handlePaperSummary)
+	mux.HandleFunc("/api/paper/reset", s.handlePaperReset)
+	mux.HandleFunc("/api/funding", s.handleFunding)
+	mux.HandleFunc("/api/v2/spreads", s.handleSpreadsV2)
 }
 
 // corsMiddleware 添加CORS支持
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -71,17 +249,71 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 
 // handleSpreads 处理价差查询请求
 // 支持参数:
-// - sort: spread|volume|symbol (默认spread)
+// - sort: spread|volume|symbol|confidence|freshness (默认spread，freshness 按两腿中较旧一腿的数据年龄，desc 时最新的在前)
 // - order: asc|desc (默认desc)
-// - min_volume: 最小volume过滤
+// - min_volume: 最小volume过滤（只过滤两腿成交量都已知的价差）
+// - unknown_volume: 为hide时同时过滤成交量未知的价差
 // - min_spread: 最小价差百分比过滤
+// - min_confidence: 最小置信度过滤（0-1）
+// - at: 使用该时间点的历史报价计算（RFC3339），不能早于价格历史的保留范围
 // - limit: 限制返回数量
+// - quote: USDT|EUR|BTC，价格、绝对价差和成交量按该货币返回（百分比不换算，过滤参数仍为USDT）
+// - offset/limit: 分页，响应中的 total 为分页前的数量
+// - fields: 逗号分隔的字段名，只返回这些字段（嵌套字段用 . 分隔，例如 projection.spread_percent）
+// - debug: 为1时返回 _timing 分阶段耗时
 func (s *Server) handleSpreads(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	q, err := s.querySpreads(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data interface{} = q.spreads
+	if q.fields != nil {
+		projected, err := projectItems(q.spreads, q.fields)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data = projected
+	}
+	q.timing.Handler = time.Since(q.handlerStart)
+
+	// 返回JSON
+	resp := map[string]interface{}{
+		"success":     true,
+		"count":       len(q.spreads),
+		"data":        data,
+		"deprecation": s.v1Deprecation("spreads"),
+	}
+	q.page.envelope(resp, q.total)
+	q.quote.envelope(resp)
+	if !q.snapshotAt.IsZero() {
+		resp["snapshot_age_ms"] = time.Since(q.snapshotAt).Milliseconds()
+	}
+	s.writeTimedJSON(w, r, "spreads", resp, q.timing)
+}
+
+// spreadQuery 价差查询过滤、排序、分页后的结果（/api/v1/spreads 和 /api/v2/spreads 共用）
+type spreadQuery struct {
+	spreads      []*pricestore.Spread // 当前页
+	total        int                  // 分页前的结果数
+	page         pageParams
+	fields       []string  // 字段选择，nil表示返回全部字段
+	quote        *displayQuote
+	at           time.Time // 历史查询的时间点，实时查询为零值
+	snapshotAt   time.Time // 使用只读快照时为快照生成时间
+	timing       *requestTiming
+	handlerStart time.Time
+}
+
+// querySpreads 按 /api/spreads 的查询参数计算价差，参数错误时返回 error
+func (s *Server) querySpreads(r *http.Request) (*spreadQuery, error) {
 	// 解析查询参数
 	query := r.URL.Query()
 	sortBy := query.Get("sort")
@@ -96,16 +328,52 @@ func (s *Server) handleSpreads(w http.ResponseWriter, r *http.Request) {
 
 	minVolume := parseFloat(query.Get("min_volume"), 0)
 	minSpread := parseFloat(query.Get("min_spread"), -999999)
-	limit := parseInt(query.Get("limit"), 0)
+	minConfidence := parseFloat(query.Get("min_confidence"), 0)
+	hideUnknownVolume := query.Get("unknown_volume") == "hide"
+
+	// 分页和字段选择（不传时返回全部结果和全部字段）
+	page, err := parsePage(query)
+	if err != nil {
+		return nil, err
+	}
+	q := &spreadQuery{page: page, fields: parseFields(query), timing: &requestTiming{}}
+	if q.quote, err = s.parseDisplayQuote(query); err != nil {
+		return nil, err
+	}
+
+	// 计算价差（指定 at 时使用该时间点的历史报价）
+	at, historical, err := parseAsOf(query.Get("at"))
+	if err != nil {
+		return nil, err
+	}
 
-	// 计算价差
-	spreads := s.store.CalculateSpreads()
+	var spreads []*pricestore.Spread
+	if historical {
+		spreads, err = s.store.CalculateSpreadsAsOf(at)
+		if err != nil {
+			return nil, err
+		}
+		q.at = at
+	} else if snap := s.storeSnapshot(); snap != nil {
+		// 快照中的价差不能修改，过滤和排序都在新的切片上进行
+		spreads, q.snapshotAt = snap.Spreads, snap.GeneratedAt
+	} else {
+		spreads = s.store.CalculateSpreadsTimed(&q.timing.Store)
+	}
+	q.handlerStart = time.Now()
+	if !historical {
+		// 短暂缺腿的价差在保留时长内返回上次的值（held），避免页面行闪烁
+		spreads = s.store.HoldMissingSpreads(spreads, q.handlerStart)
+	}
 
 	// 过滤
 	filtered := make([]*pricestore.Spread, 0)
 	for _, spread := range spreads {
+		// 成交量未知（bookTicker 等数据源）的价差不按 min_volume 过滤，由页面标记
+		volumeOK := spread.Volume24h >= minVolume || (!spread.VolumeKnown && !hideUnknownVolume)
 		// 过滤掉价差大于100%的无效币对
-		if spread.Volume24h >= minVolume && spread.SpreadPercent >= minSpread && spread.SpreadPercent <= 100.0 {
+		if volumeOK && spread.SpreadPercent >= minSpread && spread.SpreadPercent <= 100.0 &&
+			spread.Confidence >= minConfidence {
 			filtered = append(filtered, spread)
 		}
 	}
@@ -113,18 +381,12 @@ func (s *Server) handleSpreads(w http.ResponseWriter, r *http.Request) {
 	// 排序
 	s.sortSpreads(filtered, sortBy, order)
 
-	// 限制数量
-	if limit > 0 && len(filtered) > limit {
-		filtered = filtered[:limit]
-	}
-
-	// 返回JSON
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"count":   len(filtered),
-		"data":    filtered,
-	})
+	// 分页（排序稳定，相同排序值按交易对排列，翻页时结果不会重复或遗漏）
+	q.total = len(filtered)
+	start, end := page.bounds(q.total)
+	// 过滤和排序使用USDT数值，换算只作用于返回的当前页
+	q.spreads = q.quote.convertSpreads(filtered[start:end])
+	return q, nil
 }
 
 // handleStats 处理统计信息请求
@@ -134,19 +396,59 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats := s.store.GetStats()
-	activePrices := len(s.store.GetActivePrices(60 * time.Second))
+	var stats pricestore.StoreStats
+	var activePrices int
+	snap := s.storeSnapshot()
+	if snap != nil {
+		stats, activePrices = snap.Stats, snap.ActivePrices
+	} else {
+		stats = s.store.GetStats()
+		activePrices = len(s.store.GetActivePrices(60 * time.Second))
+	}
+
+	data := map[string]interface{}{
+		"total_prices":         stats.TotalPrices,
+		"active_prices":        activePrices,
+		"total_symbols":        stats.TotalSymbols,
+		"unique_base_assets":   stats.UniqueBaseAssets,
+		"total_exchanges":      stats.TotalExchanges,
+		"by_exchange":          stats.ByExchange,
+		"rejected_by_exchange": stats.RejectedByExchange,
+		"blacklist_hits":       stats.BlacklistHits,
+		"fetch_latency":        stats.FetchLatency,
+		"fetch_errors":         stats.FetchErrors,
+		"fetch_backoff_until":  stats.FetchBackoffUntil,
+		"refresh_tiers":        stats.RefreshTiers,
+		"update_rules":         stats.UpdateRules,
+		"opportunities":        stats.Opportunities,
+		"dial_hosts":           netutil.Stats(),
+		"fast_tier_symbols":    stats.FastTierSymbols,
+		"timing":               s.timings.snapshot(),
+	}
+	if s.logSize != nil {
+		data["log_file_size"] = s.logSize()
+	}
+	if len(s.wsLag) > 0 {
+		lag := make(map[string]interface{}, len(s.wsLag))
+		for name, fn := range s.wsLag {
+			if status := fn(); status != nil {
+				lag[name] = status
+			}
+		}
+		data["ws_lag"] = lag
+	}
+	if snap != nil {
+		data["store_snapshot"] = map[string]interface{}{
+			"generated_at":  snap.GeneratedAt,
+			"seq":           snap.Seq,
+			"build_time_ms": snap.BuildTime.Milliseconds(),
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data": map[string]interface{}{
-			"total_prices":    stats.TotalPrices,
-			"active_prices":   activePrices,
-			"total_symbols":   stats.TotalSymbols,
-			"total_exchanges": stats.TotalExchanges,
-			"by_exchange":     stats.ByExchange,
-		},
+		"data":    data,
 	})
 }
 
@@ -168,20 +470,91 @@ func (s *Server) handleCustomStrategies(w http.ResponseWriter, r *http.Request)
 }
 
 // handleArbitrageOpportunities 处理套利机会请求
+// 支持参数:
+// - type: all|spot-spot|spot-future|future-spot|future-future
+// - min_confidence: 最小置信度过滤（0-1）
+// - execution: hedge-both-perps|hedge-spot-perp|transfer-required|same-venue|not-executable
+// - confirmed: 为true时只返回已确认（持续6秒以上）的机会
+// - offset/limit: 按价差从大到小排序后分页，limit 不能超过服务端上限（MAX_OPPORTUNITIES_RETURNED，默认200）
+//   未指定 limit 时也只返回前200个，响应中的 total 为分页前的数量，has_more 表示后面还有结果
+// - debug: 为1时返回 _timing 分阶段耗时
 func (s *Server) handleArbitrageOpportunities(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	opportunities := s.store.GetArbitrageOpportunities()
+	// 按市场类型组合过滤: spot-spot, spot-future, future-spot, future-future, all
+	pairing := r.URL.Query().Get("type")
+	if pairing == "" {
+		pairing = pricestore.PairingAll
+	}
+	if !pricestore.IsValidPairing(pairing) {
+		http.Error(w, "Invalid type, expected one of: all, spot-spot, spot-future, future-spot, future-future", http.StatusBadRequest)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"count":   len(opportunities),
-		"data":    opportunities,
-	})
+	execution := r.URL.Query().Get("execution")
+	if execution != "" && !pricestore.IsValidExecutionMode(execution) {
+		http.Error(w, "Invalid execution, expected one of: hedge-both-perps, hedge-spot-perp, transfer-required, same-venue, not-executable", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if page.Limit == 0 || (s.maxOpportunities > 0 && page.Limit > s.maxOpportunities) {
+		page.Limit = s.maxOpportunities
+	}
+	confirmedOnly := r.URL.Query().Get("confirmed") == "true"
+
+	timing := &requestTiming{}
+	opportunities := s.store.GetArbitrageOpportunitiesTimed(&timing.Store)
+	capStats := s.store.GetOpportunityCapStats()
+	handlerStart := time.Now()
+	opportunities = pricestore.FilterOpportunitiesByPairing(opportunities, pairing)
+	opportunities = pricestore.FilterOpportunitiesByExecution(opportunities, execution)
+	if minConfidence := parseFloat(r.URL.Query().Get("min_confidence"), 0); minConfidence > 0 {
+		filtered := make([]*pricestore.ArbitrageOpportunity, 0, len(opportunities))
+		for _, opp := range opportunities {
+			if opp.Confidence >= minConfidence {
+				filtered = append(filtered, opp)
+			}
+		}
+		opportunities = filtered
+	}
+	if confirmedOnly {
+		confirmed := make([]*pricestore.ArbitrageOpportunity, 0, len(opportunities))
+		for _, opp := range opportunities {
+			if opp.IsConfirmed {
+				confirmed = append(confirmed, opp)
+			}
+		}
+		opportunities = confirmed
+	}
+	// 先排序再分页，limit 截断时保留排名最高的机会
+	pricestore.RankOpportunities(opportunities)
+	total := len(opportunities)
+	start, end := page.bounds(total)
+	opportunities = opportunities[start:end]
+	timing.Handler = time.Since(handlerStart)
+
+	resp := map[string]interface{}{
+		"success":  true,
+		"count":    len(opportunities),
+		"data":     opportunities,
+		"has_more": end < total,
+	}
+	page.envelope(resp, total)
+	// 超过跟踪上限时价差最小的未确认机会被丢弃，提示结果不完整
+	if capStats.LastDropped > 0 {
+		resp["truncated"] = true
+		resp["dropped"] = capStats.LastDropped
+		resp["max_tracked"] = capStats.MaxTracked
+	}
+	s.writeTimedJSON(w, r, "arbitrage-opportunities", resp, timing)
 }
 
 // handleExchangeRates 处理汇率查询请求
@@ -215,7 +588,588 @@ func (s *Server) handleExchangeRates(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleAgeHistogram 处理数据年龄分布请求（用于热力图，快速定位滞后的数据源）
+func (s *Server) handleAgeHistogram(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	histogram := s.store.GetAgeHistogram()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(histogram),
+		"buckets": pricestore.AgeHistogramBuckets,
+		"data":    histogram,
+	})
+}
+
+// handleInversions 获取最近的价差反转事件
+func (s *Server) handleInversions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	inversions := s.store.GetInversions()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(inversions),
+		"data":    inversions,
+	})
+}
+
+// handleAnomalies 获取最近的价差异常标注（最新的在前）
+func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	anomalies := s.store.GetAnomalies()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(anomalies),
+		"data":    anomalies,
+	})
+}
+
+// handleStableBasis 获取各场所稳定币隐含汇率相对共识的偏离（稳定币三角一致性检查未运行时 data 为 null）
+func (s *Server) handleStableBasis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := s.store.GetStableBasis()
+	count := 0
+	if report != nil {
+		count = len(report.Venues)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   count,
+		"data":    report,
+	})
+}
+
+// handleThresholds 获取所有按symbol配置的套利阈值
+func (s *Server) handleThresholds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	overrides := s.store.GetThresholdOverrides()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(overrides),
+		"data":    overrides,
+	})
+}
+
+// handleThresholdBySymbol 查询/设置/删除单个symbol的套利阈值
+// GET    /api/thresholds/{symbol}
+// PUT    /api/thresholds/{symbol}  body: {"threshold": 0.08}
+// DELETE /api/thresholds/{symbol}
+func (s *Server) handleThresholdBySymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := r.URL.Path[len("/api/thresholds/"):]
+	if symbol == "" {
+		http.Error(w, "Symbol is required", http.StatusBadRequest)
+		return
+	}
+	symbol = pricestore.NormalizeThresholdSymbol(symbol)
+
+	switch r.Method {
+	case http.MethodGet:
+		value, exists := s.store.GetThresholdOverride(symbol)
+		if !exists {
+			http.Error(w, "No threshold override for "+symbol, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"symbol":    symbol,
+				"threshold": value,
+			},
+		})
+
+	case http.MethodPut:
+		var req struct {
+			Threshold float64 `json:"threshold"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if req.Threshold <= 0 {
+			http.Error(w, "threshold must be positive", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.store.SetThresholdOverride(symbol, req.Threshold); err != nil {
+			log.Printf("[Web Server] Failed to save threshold for %s: %v", symbol, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"symbol":    symbol,
+				"threshold": req.Threshold,
+			},
+		})
+
+	case http.MethodDelete:
+		if err := s.store.DeleteThresholdOverride(symbol); err != nil {
+			log.Printf("[Web Server] Failed to delete threshold for %s: %v", symbol, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleBlacklist 查询/添加/删除symbol黑名单规则
+// GET    /api/blacklist
+// POST   /api/blacklist            body: {"pattern": "BTCUPUSDT"}（通配符 * ?，正则使用 "re:" 前缀）
+// DELETE /api/blacklist?pattern=BTCUPUSDT
+func (s *Server) handleBlacklist(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entries := s.store.GetBlacklist()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"count":   len(entries),
+			"data":    entries,
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Pattern string `json:"pattern"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+
+		pattern, err := s.store.AddBlacklistPattern(req.Pattern)
+		if err != nil {
+			log.Printf("[Web Server] Failed to add blacklist pattern %q: %v", req.Pattern, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"pattern": pattern,
+			},
+		})
+
+	case http.MethodDelete:
+		pattern := r.URL.Query().Get("pattern")
+		if pattern == "" {
+			http.Error(w, "pattern is required", http.StatusBadRequest)
+			return
+		}
+
+		removed, err := s.store.RemoveBlacklistPattern(pattern)
+		if err != nil {
+			log.Printf("[Web Server] Failed to remove blacklist pattern %q: %v", pattern, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !removed {
+			http.Error(w, "No blacklist pattern "+pattern, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleNormalizerReload 重新读取symbol映射文件并重建symbol索引，新映射立即生效
+// POST /api/normalizer/reload（文件校验失败时保留原有映射并返回400）
+func (s *Server) handleNormalizerReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	count, err := s.store.ReloadSymbolMappings()
+	if err != nil {
+		log.Printf("[Web Server] Failed to reload symbol mappings: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[Web Server] Reloaded %d symbol mappings", count)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   count,
+		"data":    s.store.GetSymbolMappings(),
+	})
+}
+
+// handleStrategies 查询/注册/删除配对比值策略（A - 系数 * B）
+// GET    /api/strategies
+// POST   /api/strategies           body: {"name": "AAVE-UNI", "base_symbol": "AAVE", "quote_symbol": "UNI", "coefficient": 12.3, "direction": "+A-B"}
+// DELETE /api/strategies?name=AAVE-UNI
+func (s *Server) handleStrategies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		strategies := s.store.GetRatioStrategies()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"count":   len(strategies),
+			"data":    strategies,
+		})
+
+	case http.MethodPost:
+		var req pricestore.RatioStrategy
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+
+		registered, err := s.store.RegisterRatioStrategy(req)
+		if err != nil {
+			log.Printf("[Web Server] Failed to register strategy %q: %v", req.Name, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    registered,
+		})
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+
+		removed, err := s.store.RemoveRatioStrategy(name)
+		if err != nil {
+			log.Printf("[Web Server] Failed to remove strategy %q: %v", name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !removed {
+			http.Error(w, "No strategy "+name, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSuspects 返回疑似数量级倍数差异（1000PEPE 等）的场所及建议的修正，包括已自动应用的修正
+func (s *Server) handleSuspects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	suspects := s.store.GetMultiplierSuspects()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(suspects),
+		"data":    suspects,
+	})
+}
+
+// defaultCoverageGapMinVolume /api/coverage-gaps 未指定 min_volume 时的24小时成交量下限（与页面默认的成交量过滤一致）
+const defaultCoverageGapMinVolume = 100000
+
+// handleCoverageGaps 返回在部分交易所有活跃报价、在其他交易所缺失或过期的高成交量symbol
+// 支持参数:
+// - min_volume: 24小时成交量下限（默认100000，0表示不过滤）
+func (s *Server) handleCoverageGaps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	minVolume := float64(defaultCoverageGapMinVolume)
+	if raw := r.URL.Query().Get("min_volume"); raw != "" {
+		v, err := numutil.ParseFloatStrict(raw)
+		if err != nil || v < 0 {
+			http.Error(w, "Invalid min_volume", http.StatusBadRequest)
+			return
+		}
+		minVolume = v
+	}
+
+	gaps := s.store.GetCoverageGaps(minVolume)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"count":      len(gaps),
+		"min_volume": minVolume,
+		"data":       gaps,
+	})
+}
+
+// handleVenuePairs 按场所对（买入场所 -> 卖出场所）汇总所有symbol的当前价差，用于决定在哪些场所保留资金
+// 支持参数:
+// - min_spread: 只统计价差百分比大于该值的symbol，默认0（所有正价差）
+func (s *Server) handleVenuePairs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	minSpread := 0.0
+	if raw := r.URL.Query().Get("min_spread"); raw != "" {
+		v, err := numutil.ParseFloatStrict(raw)
+		if err != nil {
+			http.Error(w, "Invalid min_spread", http.StatusBadRequest)
+			return
+		}
+		minSpread = v
+	}
+
+	report := s.store.GetVenuePairs(minSpread)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(report.Pairs),
+		"data":    report,
+	})
+}
+
+// handleFunding 返回永续合约的资金费率和未平仓量（目前由 Lighter market_stats 提供）
+// 资金费率单独存储，不含资金费率的 REST 报价不会使记录清零；mark_price 只在交易所提供标记价格时返回
+// 支持参数:
+// - symbol: 只返回该symbol（例如 BTC 或 BTCUSDT），不指定时只返回60秒内更新过的记录
+// - exchange: 只返回该交易所（例如 LIGHTER）
+func (s *Server) handleFunding(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	symbol := query.Get("symbol")
+	exchange := common.Exchange(strings.ToUpper(query.Get("exchange")))
+
+	now := time.Now()
+	entries := make([]pricestore.FundingInfo, 0)
+	for _, info := range s.store.GetFunding(symbol) {
+		if exchange != "" && info.Exchange != exchange {
+			continue
+		}
+		if symbol == "" && now.Sub(info.LastUpdated) > 60*time.Second {
+			continue
+		}
+		entries = append(entries, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(entries),
+		"data":    entries,
+	})
+}
+
+// handlePaperPositions 返回模拟交易的持仓中和已平仓仓位（最新的在前）
+// 支持参数:
+// - status: open / closed（默认全部）
+func (s *Server) handlePaperPositions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != pricestore.PaperStatusOpen && status != pricestore.PaperStatusClosed {
+		http.Error(w, "status must be 'open' or 'closed'", http.StatusBadRequest)
+		return
+	}
+
+	open, closed := s.store.GetPaperPositions()
+	switch status {
+	case pricestore.PaperStatusOpen:
+		closed = []pricestore.PaperPosition{}
+	case pricestore.PaperStatusClosed:
+		open = []pricestore.PaperPosition{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(open) + len(closed),
+		"data": map[string]interface{}{
+			"open":   open,
+			"closed": closed,
+		},
+	})
+}
+
+// handlePaperSummary 返回模拟交易按symbol汇总的累计盈亏及合计
+func (s *Server) handlePaperSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	symbols := s.store.GetPaperSummary()
+	total := pricestore.PaperSymbolSummary{Symbol: "TOTAL"}
+	for _, summary := range symbols {
+		total.Trades += summary.Trades
+		total.Wins += summary.Wins
+		total.RealizedPnL += summary.RealizedPnL
+		total.Fees += summary.Fees
+		total.OpenPositions += summary.OpenPositions
+		total.UnrealizedPnL += summary.UnrealizedPnL
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(symbols),
+		"data": map[string]interface{}{
+			"symbols": symbols,
+			"total":   total,
+		},
+	})
+}
+
+// handlePaperReset 清空模拟交易的所有仓位和累计盈亏（POST）
+func (s *Server) handlePaperReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.store.ResetPaper()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// handleTickers 从只读快照返回精简行情（不获取存储锁，不与写入路径竞争）
+// 支持参数:
+// - symbol: 只返回该symbol（例如 BTC 或 BTCUSDT）
+func (s *Server) handleTickers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	snap := s.store.GetTickerSnapshot()
+	if snap == nil {
+		http.Error(w, "Ticker snapshot not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	data := snap.BySymbol
+	if symbol := r.URL.Query().Get("symbol"); symbol != "" {
+		symbol = pricestore.NormalizeThresholdSymbol(symbol)
+		data = map[string][]pricestore.TickerView{symbol: snap.BySymbol[symbol]}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      true,
+		"count":        len(data),
+		"seq":          snap.Seq,
+		"generated_at": snap.GeneratedAt,
+		"data":         data,
+	})
+}
+
+// handleSimulate 模拟按给定金额在最优场所买入/卖出的成交结果（有本地订单簿的场所逐档成交）
+// POST /api/simulate  body: {"symbol": "BTCUSDT", "notional": 1000}
+func (s *Server) handleSimulate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Symbol   string  `json:"symbol"`
+		Notional float64 `json:"notional"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if req.Symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+
+	sim, err := s.store.SimulateFill(req.Symbol, req.Notional, s.simulationDepth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    sim,
+	})
+}
+
 // handlePricesBySymbol 处理按币种查询价格的请求
+// 支持参数:
+// - since_seq: 只返回序列号大于该值的价格
+// - at: 返回该时间点（RFC3339）或之前最近的各场所报价
+// - depth_levels: 有本地订单簿的场所附带前N档盘口（depth 字段，默认5，最大20，0表示不返回；指定 at 时不返回）
 func (s *Server) handlePricesBySymbol(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -227,39 +1181,98 @@ func (s *Server) handlePricesBySymbol(w http.ResponseWriter, r *http.Request) {
 	symbol := path[len("/api/prices/"):]
 
 	if symbol == "" {
-		http.Error(w, "Symbol is required", http.StatusBadRequest)
+		s.handleAllPrices(w, r)
 		return
 	}
 
-	// 获取该币种的所有价格
-	prices := s.store.GetPricesBySymbol(symbol)
+	// 增量轮询：只返回序列号大于 since_seq 的价格
+	sinceSeq, _ := strconv.ParseUint(r.URL.Query().Get("since_seq"), 10, 64)
+
+	// 分页和字段选择（响应保持为数组，分页前的总数放在 X-Total-Count 响应头中）
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields := parseFields(r.URL.Query())
+	depthLevels, err := parseDepthLevels(r.URL.Query().Get("depth_levels"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	quote, err := s.parseDisplayQuote(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	quote.headers(w)
+
+	// 当前最新序列号放在响应头中，客户端下次轮询时作为 since_seq 传入
+	// 注意：序列号仅在进程生命周期内有效，服务重启后从0开始
+	w.Header().Set("X-Store-Seq", strconv.FormatUint(s.store.CurrentSeq(), 10))
+
+	// 获取该币种的所有价格（指定 at 时返回该时间点各场所的历史报价）
+	at, historical, err := parseAsOf(r.URL.Query().Get("at"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var prices []*common.Price
+	if historical {
+		prices, err = s.store.GetPricesBySymbolAsOf(symbol, at)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		prices = s.store.GetPricesBySymbol(symbol)
+	}
+	// 盘口深度只有当前值，历史查询不返回
+	if historical {
+		depthLevels = 0
+	}
 
 	if len(prices) == 0 {
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", "0")
 		json.NewEncoder(w).Encode([]interface{}{})
 		return
 	}
 
+	// 按场所排序，分页时顺序稳定
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].Exchange != prices[j].Exchange {
+			return prices[i].Exchange < prices[j].Exchange
+		}
+		return prices[i].MarketType < prices[j].MarketType
+	})
+
 	// 转换为 JSON 友好的格式
 	result := make([]map[string]interface{}, 0, len(prices))
 	for _, price := range prices {
-		result = append(result, map[string]interface{}{
-			"symbol":       price.Symbol,
-			"exchange":     price.Exchange,
-			"market_type":  price.MarketType,
-			"price":        price.Price,
-			"bid_price":    price.BidPrice,
-			"ask_price":    price.AskPrice,
-			"bid_qty":      price.BidQty,
-			"ask_qty":      price.AskQty,
-			"volume_24h":   price.Volume24h,
-			"timestamp":    price.Timestamp,
-			"last_updated": price.LastUpdated,
-			"source":       price.Source,
-		})
+		if price.Seq <= sinceSeq {
+			continue
+		}
+		item := priceToAPIMap(price)
+		quote.convertPriceMap(item)
+		if depth := s.priceDepth(price, depthLevels); depth != nil {
+			item["depth"] = depth
+		}
+		result = append(result, item)
+	}
+
+	total := len(result)
+	start, end := page.bounds(total)
+	result = result[start:end]
+	if fields != nil {
+		for i, item := range result {
+			result[i] = projectMap(item, fields)
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(result)
 }
 
@@ -310,48 +1323,137 @@ func (s *Server) handleDebugPrices(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleDebugUpdates 价格更新调试：某个symbol最近的更新尝试及接受/拒绝它的新鲜度规则
+// GET    /api/debug/updates/                  列出开启记录的symbol
+// GET    /api/debug/updates/{symbol}          返回记录（未开启时404）
+// POST   /api/debug/updates/{symbol}?size=100 开启记录（已开启时清空重新记录）
+// DELETE /api/debug/updates/{symbol}          关闭记录
+func (s *Server) handleDebugUpdates(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.TrimPrefix(r.URL.Path, "/api/debug/updates/")
+	if symbol == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Symbol is required", http.StatusBadRequest)
+			return
+		}
+		symbols := s.store.UpdateDebugSymbols()
+		sort.Strings(symbols)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"count":   len(symbols),
+			"data":    symbols,
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		decisions, enabled := s.store.GetUpdateDebug(symbol)
+		if !enabled {
+			http.Error(w, "Update debugging is not enabled for "+symbol, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"count":   len(decisions),
+			"data":    decisions,
+		})
+
+	case http.MethodPost:
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		standardSymbol := s.store.EnableUpdateDebug(symbol, size)
+		log.Printf("[Web Server] Enabled update debugging for %s", standardSymbol)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"symbol": standardSymbol,
+			},
+		})
+
+	case http.MethodDelete:
+		if !s.store.DisableUpdateDebug(symbol) {
+			http.Error(w, "Update debugging is not enabled for "+symbol, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // sortSpreads 排序价差列表
+// 排序值相同时先按两腿数据年龄（MaxAge，较新的在前），再按交易对（symbol+买卖场所）升序，保证分页时顺序稳定
 func (s *Server) sortSpreads(spreads []*pricestore.Spread, sortBy, order string) {
+	now := time.Now()
 	sort.Slice(spreads, func(i, j int) bool {
-		var less bool
+		var a, b float64
 		switch sortBy {
 		case "volume":
-			less = spreads[i].Volume24h < spreads[j].Volume24h
+			a, b = spreads[i].Volume24h, spreads[j].Volume24h
 		case "symbol":
-			less = spreads[i].Symbol < spreads[j].Symbol
+			// symbol 排序时交易对本身就是排序值
+		case "confidence":
+			a, b = spreads[i].Confidence, spreads[j].Confidence
+		case "freshness":
+			// 年龄取负值，desc 时数据最新的在前
+			a, b = -float64(spreads[i].MaxAge(now)), -float64(spreads[j].MaxAge(now))
 		case "spread":
 			fallthrough
 		default:
-			less = spreads[i].SpreadPercent < spreads[j].SpreadPercent
+			a, b = spreads[i].SpreadPercent, spreads[j].SpreadPercent
 		}
 
+		if a == b {
+			if sortBy != "symbol" && sortBy != "freshness" {
+				if ai, aj := spreads[i].MaxAge(now), spreads[j].MaxAge(now); ai != aj {
+					return ai < aj
+				}
+			}
+			pi, pj := spreadPairID(spreads[i]), spreadPairID(spreads[j])
+			if sortBy == "symbol" && order != "asc" {
+				return pi > pj
+			}
+			return pi < pj
+		}
 		if order == "asc" {
-			return less
+			return a < b
 		}
-		return !less
+		return a > b
 	})
 }
 
-// parseFloat 解析浮点数，失败返回默认值
+// spreadPairID 价差的交易对标识（symbol 及买卖两腿的场所）
+func spreadPairID(spread *pricestore.Spread) string {
+	return fmt.Sprintf("%s|%s_%s|%s_%s", spread.Symbol,
+		spread.BuyExchange, spread.BuyMarketType, spread.SellExchange, spread.SellMarketType)
+}
+
+// parseFloat 解析浮点数查询参数，为空或无效时返回默认值
 func parseFloat(s string, defaultValue float64) float64 {
-	if s == "" {
-		return defaultValue
-	}
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return defaultValue
+	if f, ok := numutil.ParseFloatOK(s); ok {
+		return f
 	}
-	return f
+	return defaultValue
 }
 
-// parseInt 解析整数，失败返回默认值
-func parseInt(s string, defaultValue int) int {
+// parseAsOf 解析时间点查询参数（RFC3339，例如 2024-05-01T12:00:00Z），为空时返回 false
+func parseAsOf(s string) (time.Time, bool, error) {
 	if s == "" {
-		return defaultValue
+		return time.Time{}, false, nil
 	}
-	i, err := strconv.Atoi(s)
+	at, err := time.Parse(time.RFC3339Nano, s)
 	if err != nil {
-		return defaultValue
+		return time.Time{}, false, fmt.Errorf("invalid 'at' %q: expected RFC3339 time such as 2024-05-01T12:00:00Z", s)
 	}
-	return i
+	return at, true, nil
 }
@@ -0,0 +1,47 @@
+package web
+
+import (
+	"context"
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"log"
+	"time"
+)
+
+// SetSnapshotInterval 设置只读存储快照的刷新间隔（需要在 Start 之前调用，命名空间使用相同间隔）
+// 启用后 /api/spreads 和 /api/stats 读取定期生成的快照，不再每个请求都获取存储锁；0表示不启用
+func (s *Server) SetSnapshotInterval(interval time.Duration) {
+	s.snapshotInterval = interval
+}
+
+// storeSnapshot 最新的只读存储快照，未启用或尚未生成时为nil
+func (s *Server) storeSnapshot() *pricestore.StoreSnapshot {
+	return s.webSnapshot.Load()
+}
+
+// runSnapshotRefresher 按 interval 生成只读存储快照并原子替换，ctx 取消时停止
+// 存储序列号未变化且快照不超过1秒时跳过重建（超过1秒仍重建以刷新过期的报价）
+func (s *Server) runSnapshotRefresher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Web Server] Store snapshot refresher started for %s (interval %v)", s.store.Name(), interval)
+
+	s.webSnapshot.Store(s.store.SnapshotForWeb())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[Web Server] Store snapshot refresher stopped for %s", s.store.Name())
+			return
+		case <-ticker.C:
+		}
+		current := s.webSnapshot.Load()
+		if s.store.CurrentSeq() == current.Seq && time.Since(current.GeneratedAt) < time.Second {
+			continue
+		}
+		snap := s.store.SnapshotForWeb()
+		s.webSnapshot.Store(snap)
+		if snap.BuildTime > interval {
+			log.Printf("[Web Server] Store snapshot for %s took %v (interval %v)", s.store.Name(), snap.BuildTime, interval)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package web
+
+import (
+	"context"
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSnapshotServer 每个symbol有 Binance 和 Lighter 两腿，并生成一次只读快照
+func newSnapshotServer(t *testing.T, symbols int) (*Server, *pricestore.PriceStore) {
+	t.Helper()
+	store := pricestore.NewPriceStore()
+	now := time.Now()
+	for i := 0; i < symbols; i++ {
+		symbol := fmt.Sprintf("C%dUSDT", i)
+		store.UpdatePrice(seqQuote(symbol, common.ExchangeBinance, now))
+		lighter := seqQuote(symbol, common.ExchangeLighter, now)
+		lighter.BidPrice, lighter.AskPrice = 100.2, 100.3
+		store.UpdatePrice(lighter)
+	}
+	s := NewServer(store, "")
+	s.webSnapshot.Store(store.SnapshotForWeb())
+	return s, store
+}
+
+func TestSpreadsServedFromSnapshot(t *testing.T) {
+	s, store := newSnapshotServer(t, 10)
+	snap := s.storeSnapshot()
+	if len(snap.Spreads) != len(store.CalculateSpreads()) || snap.Seq != store.CurrentSeq() {
+		t.Fatalf("snapshot has %d spreads at seq %d, live store %d spreads at seq %d",
+			len(snap.Spreads), snap.Seq, len(store.CalculateSpreads()), store.CurrentSeq())
+	}
+
+	// 快照生成之后的写入要等下一次刷新才可见
+	later := seqQuote("NEWUSDT", common.ExchangeBinance, time.Now())
+	store.UpdatePrice(later)
+	later = seqQuote("NEWUSDT", common.ExchangeLighter, time.Now())
+	later.BidPrice, later.AskPrice = 100.2, 100.3
+	store.UpdatePrice(later)
+
+	rec := httptest.NewRecorder()
+	s.handleSpreads(rec, httptest.NewRequest(http.MethodGet, "/api/spreads", nil))
+	var resp struct {
+		Count         int    `json:"count"`
+		SnapshotAgeMs *int64 `json:"snapshot_age_ms"`
+		Data          []struct {
+			Symbol string `json:"symbol"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.SnapshotAgeMs == nil {
+		t.Fatal("response not served from the snapshot (no snapshot_age_ms)")
+	}
+	if resp.Count != len(snap.Spreads) {
+		t.Fatalf("count = %d, want the snapshot's %d", resp.Count, len(snap.Spreads))
+	}
+	for _, row := range resp.Data {
+		if row.Symbol == "NEWUSDT" {
+			t.Fatal("price written after the snapshot was served before a refresh")
+		}
+	}
+
+	s.webSnapshot.Store(store.SnapshotForWeb())
+	rec = httptest.NewRecorder()
+	s.handleSpreads(rec, httptest.NewRequest(http.MethodGet, "/api/spreads", nil))
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != len(snap.Spreads)+2 {
+		t.Fatalf("count after refresh = %d, want %d", resp.Count, len(snap.Spreads)+2)
+	}
+}
+
+func TestIngestionUnblockedBySnapshotReads(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+	s, store := newSnapshotServer(t, 1000)
+
+	// 一次在存储上直接计算价差的耗时：不使用快照时每个请求至少占用存储这么久
+	start := time.Now()
+	store.CalculateSpreads()
+	liveCalc := time.Since(start)
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	var mu sync.Mutex
+	served := 0
+	for i := 0; i < 16; i++ {
+		readers.Add(1)
+		go func(i int) {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				rec := httptest.NewRecorder()
+				if i%2 == 0 {
+					s.handleSpreads(rec, httptest.NewRequest(http.MethodGet, "/api/spreads?limit=50", nil))
+				} else {
+					s.handleStats(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+				}
+				if rec.Code == http.StatusOK {
+					mu.Lock()
+					served++
+					mu.Unlock()
+				}
+			}
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond) // 读者已在循环中
+
+	base := time.Now()
+	waits := make([]time.Duration, 0, 500)
+	for n := 0; n < 500; n++ {
+		price := seqQuote(fmt.Sprintf("C%dUSDT", n%1000), common.ExchangeBinance, base.Add(time.Duration(n+1)*time.Millisecond))
+		begin := time.Now()
+		if !store.UpdatePrice(price) {
+			t.Fatalf("update %d rejected", n)
+		}
+		waits = append(waits, time.Since(begin))
+	}
+	close(stop)
+	readers.Wait()
+
+	if served == 0 {
+		t.Fatal("no reads served while ingesting")
+	}
+	if store.CurrentSeq() != s.storeSnapshot().Seq+500 {
+		t.Fatalf("store seq %d, snapshot seq %d: not all updates were applied", store.CurrentSeq(), s.storeSnapshot().Seq)
+	}
+
+	// 读取不获取存储锁，写入的典型等待远小于一次实时价差计算（取中位数，排除GC和调度造成的个别长尾）
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	median := waits[len(waits)/2]
+	if median >= liveCalc/10 {
+		t.Fatalf("median UpdatePrice wait %v under %d snapshot reads, live spread calculation takes %v", median, served, liveCalc)
+	}
+	t.Logf("%d reads served, UpdatePrice wait median %v max %v, live calculation %v", served, median, waits[len(waits)-1], liveCalc)
+}
+
+func TestSnapshotRefresherStopsOnCancel(t *testing.T) {
+	s, store := newSnapshotServer(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.runSnapshotRefresher(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("snapshot refresher still running after cancel")
+	}
+
+	// 停止后不再刷新快照
+	seq := s.storeSnapshot().Seq
+	store.UpdatePrice(seqQuote("NEWUSDT", common.ExchangeBinance, time.Now()))
+	time.Sleep(20 * time.Millisecond)
+	if got := s.storeSnapshot().Seq; got != seq {
+		t.Fatalf("snapshot refreshed to seq %d after stop, want %d", got, seq)
+	}
+}
+
+func TestStartShutsDownOnCancel(t *testing.T) {
+	s := NewServer(pricestore.NewPriceStore(), "127.0.0.1:0")
+	s.SetSnapshotInterval(time.Millisecond)
+	exp := pricestore.NewPriceStore()
+	exp.SetName("exp")
+	if err := s.AddNamespace(exp); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start(ctx) }()
+	select {
+	case <-s.Ready():
+	case err := <-errCh:
+		t.Fatalf("Start failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for s.storeSnapshot() == nil || s.namespaces[0].storeSnapshot() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot refreshers did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Start returned %v after shutdown, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server still serving after cancel")
+	}
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newFreshnessServer 三个symbol的价差相同（0.5%），两腿报价的年龄分别为 1s、5s、10s
+func newFreshnessServer(t *testing.T) *Server {
+	t.Helper()
+	store := pricestore.NewPriceStore()
+	now := time.Now()
+	for symbol, age := range map[string]time.Duration{
+		"FRESHUSDT": time.Second,
+		"MIDUSDT":   5 * time.Second,
+		"STALEUSDT": 10 * time.Second,
+	} {
+		for exchange, price := range map[common.Exchange]float64{common.ExchangeBinance: 100, common.ExchangeLighter: 100.5} {
+			quote := seqQuote(symbol, exchange, now.Add(-age))
+			quote.Price, quote.BidPrice, quote.AskPrice = price, price, price
+			if !store.UpdatePrice(quote) {
+				t.Fatalf("%s %s rejected", exchange, symbol)
+			}
+		}
+	}
+	return NewServer(store, "")
+}
+
+// spreadSymbols 价差列表中的symbol（按返回顺序）
+func spreadSymbols(t *testing.T, s *Server, query string) []string {
+	t.Helper()
+	code, page := getSpreadsPage(t, s, query)
+	if code != 200 {
+		t.Fatalf("GET /api/spreads%s: status %d", query, code)
+	}
+	symbols := make([]string, 0, len(page.Data))
+	for _, spread := range page.Data {
+		symbols = append(symbols, spread["symbol"].(string))
+	}
+	return symbols
+}
+
+func TestSortSpreadsByFreshness(t *testing.T) {
+	s := newFreshnessServer(t)
+
+	// 只保留正向价差（买 Binance、卖 Lighter），三个价差百分比相同
+	freshFirst := []string{"FRESHUSDT", "MIDUSDT", "STALEUSDT"}
+	staleFirst := []string{"STALEUSDT", "MIDUSDT", "FRESHUSDT"}
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"?min_spread=0.1&sort=freshness", freshFirst},
+		{"?min_spread=0.1&sort=freshness&order=asc", staleFirst},
+		{"?min_spread=0.1", freshFirst},           // 默认按价差排序，价差相同时较新的在前
+		{"?min_spread=0.1&order=asc", freshFirst}, // 并列时的次序不随 order 变化
+	} {
+		if got := spreadSymbols(t, s, tc.query); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: order = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// 服务端设置了 API_TOKEN 时，/api 请求和 /ws 连接带上保存在 localStorage 的令牌；/api 请求收到401时提示输入令牌并重试一次
+(function () {
+    const nativeFetch = window.fetch.bind(window);
+
+    window.fetch = async function (url, options = {}) {
+        const send = () => {
+            const token = localStorage.getItem('apiToken');
+            const headers = new Headers(options.headers || {});
+            if (token) {
+                headers.set('Authorization', 'Bearer ' + token);
+            }
+            return nativeFetch(url, { ...options, headers });
+        };
+
+        const sentToken = localStorage.getItem('apiToken');
+        let response = await send();
+        if (response.status === 401) {
+            // 并发的请求中只有第一个提示输入，其余的直接使用新令牌重试
+            let token = localStorage.getItem('apiToken');
+            if (token === sentToken) {
+                token = prompt('请输入 API 令牌（服务端 API_TOKEN）');
+                if (token) {
+                    localStorage.setItem('apiToken', token.trim());
+                }
+            }
+            if (token && token !== sentToken) {
+                response = await send();
+            }
+        }
+        return response;
+    };
+
+    // /ws/* 连接不能设置 Authorization 头，通过 Sec-WebSocket-Protocol: bearer, <token> 传递令牌
+    const NativeWebSocket = window.WebSocket;
+    window.WebSocket = function (url, protocols) {
+        const token = localStorage.getItem('apiToken');
+        const path = new URL(url, window.location.href).pathname;
+        if (token && protocols === undefined && path.startsWith('/ws/')) {
+            protocols = ['bearer', token];
+        }
+        return protocols === undefined ? new NativeWebSocket(url) : new NativeWebSocket(url, protocols);
+    };
+    window.WebSocket.prototype = NativeWebSocket.prototype;
+    for (const key of ['CONNECTING', 'OPEN', 'CLOSING', 'CLOSED']) {
+        window.WebSocket[key] = NativeWebSocket[key];
+    }
+})();
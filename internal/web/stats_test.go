@@ -0,0 +1,41 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsWSLag(t *testing.T) {
+	s := NewServer(pricestore.NewPriceStore(), "")
+	s.SetWSLagFunc("binance_futures", func() interface{} {
+		return map[string]interface{}{"lagging": true, "shed_messages": 42}
+	})
+	s.SetWSLagFunc("not_started", func() interface{} { return nil })
+
+	rec := httptest.NewRecorder()
+	s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data struct {
+			WSLag map[string]struct {
+				Lagging      bool  `json:"lagging"`
+				ShedMessages int64 `json:"shed_messages"`
+			} `json:"ws_lag"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	lag, ok := resp.Data.WSLag["binance_futures"]
+	if !ok || !lag.Lagging || lag.ShedMessages != 42 {
+		t.Fatalf("ws_lag = %+v", resp.Data.WSLag)
+	}
+	if _, ok := resp.Data.WSLag["not_started"]; ok {
+		t.Fatal("connection without a status reported in ws_lag")
+	}
+}
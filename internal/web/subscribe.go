@@ -0,0 +1,97 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/wsutil"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// SubscribeStatus 按需订阅的结果
+type SubscribeStatus string
+
+const (
+	SubscribeAdded       SubscribeStatus = "added"       // 新增订阅
+	SubscribeExisting    SubscribeStatus = "existing"    // 已经在订阅（或全量流已覆盖）
+	SubscribeUnsupported SubscribeStatus = "unsupported" // 该交易所/市场类型不支持按需订阅
+)
+
+// Subscriber 单个交易所的按需WebSocket订阅（由 main 按交易所注册连接池/客户端的适配）
+type Subscriber interface {
+	// Subscribe 开始跟踪 symbol（交易所原始symbol），symbol 不存在等失败返回交易所的错误
+	// 已在连接池的某个连接上订阅时可以返回 *wsutil.SubscriptionConflictError（响应为 existing）
+	Subscribe(marketType common.MarketType, symbol string) (SubscribeStatus, error)
+}
+
+// SetSubscriber 注册交易所的按需订阅（POST /api/subscribe，需要在 Start 之前调用）
+func (s *Server) SetSubscriber(exchange common.Exchange, sub Subscriber) {
+	if s.subscribers == nil {
+		s.subscribers = make(map[common.Exchange]Subscriber)
+	}
+	s.subscribers[exchange] = sub
+}
+
+// handleSubscribe 按需订阅单个symbol，不需要等待交易对刷新或重启
+// 请求体: {"exchange":"BINANCE","market_type":"SPOT","symbol":"NEWUSDT"}
+// 响应的 status 为 added / existing / unsupported，订阅失败时返回交易所的错误信息
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Exchange   common.Exchange   `json:"exchange"`
+		MarketType common.MarketType `json:"market_type"`
+		Symbol     string            `json:"symbol"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	req.Exchange = common.Exchange(strings.ToUpper(string(req.Exchange)))
+	req.MarketType = common.MarketType(strings.ToUpper(string(req.MarketType)))
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if req.Exchange == "" || req.Symbol == "" {
+		http.Error(w, "exchange and symbol are required", http.StatusBadRequest)
+		return
+	}
+	if req.MarketType != common.MarketTypeSpot && req.MarketType != common.MarketTypeFuture {
+		http.Error(w, "market_type must be SPOT or FUTURE", http.StatusBadRequest)
+		return
+	}
+
+	status := SubscribeUnsupported
+	message := ""
+	if sub, exists := s.subscribers[req.Exchange]; exists {
+		var err error
+		var conflict *wsutil.SubscriptionConflictError
+		if status, err = sub.Subscribe(req.MarketType, req.Symbol); errors.As(err, &conflict) {
+			// 已在某个连接上订阅：不重复订阅，返回 existing 和所在的连接
+			status, message = SubscribeExisting, conflict.Error()
+		} else if err != nil {
+			// 交易所返回的错误原样返回：symbol不存在等请求错误为400，网络/限频等为502
+			code := http.StatusBadGateway
+			if common.IsPermanentError(err) {
+				code = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+	}
+
+	resp := map[string]interface{}{
+		"success":     status != SubscribeUnsupported,
+		"exchange":    req.Exchange,
+		"market_type": req.MarketType,
+		"symbol":      req.Symbol,
+		"status":      status,
+	}
+	if message != "" {
+		resp["message"] = message
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/internal/wsutil"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeSubscriber 记录订阅请求，返回预设的结果
+type fakeSubscriber struct {
+	status SubscribeStatus
+	err    error
+	calls  []string
+}
+
+func (f *fakeSubscriber) Subscribe(marketType common.MarketType, symbol string) (SubscribeStatus, error) {
+	f.calls = append(f.calls, string(marketType)+" "+symbol)
+	return f.status, f.err
+}
+
+func TestHandleSubscribe(t *testing.T) {
+	tests := []struct {
+		name       string
+		sub        *fakeSubscriber
+		body       string
+		wantCode   int
+		wantStatus SubscribeStatus
+		wantError  string
+	}{
+		{
+			name:       "added",
+			sub:        &fakeSubscriber{status: SubscribeAdded},
+			body:       `{"exchange":"binance","market_type":"spot","symbol":" newusdt "}`,
+			wantCode:   http.StatusOK,
+			wantStatus: SubscribeAdded,
+		},
+		{
+			name: "already subscribed on another connection",
+			sub: &fakeSubscriber{err: &wsutil.SubscriptionConflictError{
+				Exchange: "BINANCE", MarketType: "SPOT", Symbol: "NEWUSDT", ConnID: 3}},
+			body:       `{"exchange":"BINANCE","market_type":"SPOT","symbol":"NEWUSDT"}`,
+			wantCode:   http.StatusOK,
+			wantStatus: SubscribeExisting,
+		},
+		{
+			name:       "unknown exchange",
+			sub:        &fakeSubscriber{status: SubscribeAdded},
+			body:       `{"exchange":"NOWHERE","market_type":"SPOT","symbol":"NEWUSDT"}`,
+			wantCode:   http.StatusOK,
+			wantStatus: SubscribeUnsupported,
+		},
+		{
+			name: "symbol rejected by the exchange",
+			sub: &fakeSubscriber{err: &common.APIError{
+				Exchange: common.ExchangeBinance, Kind: common.ErrBadRequest, Message: "Invalid symbol"}},
+			body:      `{"exchange":"BINANCE","market_type":"SPOT","symbol":"NEWUSDT"}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: "Invalid symbol",
+		},
+		{
+			name:      "subscriber network error",
+			sub:       &fakeSubscriber{err: errors.New("connection reset")},
+			body:      `{"exchange":"BINANCE","market_type":"SPOT","symbol":"NEWUSDT"}`,
+			wantCode:  http.StatusBadGateway,
+			wantError: "connection reset",
+		},
+		{
+			name:      "bad market type",
+			sub:       &fakeSubscriber{status: SubscribeAdded},
+			body:      `{"exchange":"BINANCE","market_type":"OPTION","symbol":"NEWUSDT"}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: "market_type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(pricestore.NewPriceStore(), "")
+			s.SetSubscriber(common.ExchangeBinance, tt.sub)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(tt.body))
+			s.newMux().ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantError != "" {
+				if !strings.Contains(rec.Body.String(), tt.wantError) {
+					t.Fatalf("body %q, want the error %q", rec.Body, tt.wantError)
+				}
+				return
+			}
+
+			var resp struct {
+				Success bool            `json:"success"`
+				Symbol  string          `json:"symbol"`
+				Status  SubscribeStatus `json:"status"`
+				Message string          `json:"message"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != tt.wantStatus || resp.Success != (tt.wantStatus != SubscribeUnsupported) || resp.Symbol != "NEWUSDT" {
+				t.Fatalf("response = %+v", resp)
+			}
+			if tt.wantStatus == SubscribeExisting && !strings.Contains(resp.Message, "conn #3") {
+				t.Fatalf("message %q, want the existing connection", resp.Message)
+			}
+			if tt.wantStatus == SubscribeUnsupported {
+				if len(tt.sub.calls) != 0 {
+					t.Fatalf("subscriber called for an unknown exchange: %v", tt.sub.calls)
+				}
+			} else if len(tt.sub.calls) != 1 || tt.sub.calls[0] != "SPOT NEWUSDT" {
+				t.Fatalf("subscriber calls = %v", tt.sub.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const timingWindowSize = 256 // 每个阶段保留的最近样本数
+
+// requestTiming 单次接口请求的分阶段耗时
+type requestTiming struct {
+	Store   pricestore.CalcTiming // store 内部阶段：等锁、复制、计算、后处理
+	Handler time.Duration         // handler 内过滤/排序耗时
+	Encode  time.Duration         // JSON 编码耗时
+}
+
+// toMap 转换为 ?debug=1 时返回的 _timing 对象（单位：微秒）
+// encode_us 为编码响应数据（不含 _timing 本身）的耗时
+func (t *requestTiming) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"lock_wait_us": t.Store.LockWait.Microseconds(),
+		"snapshot_us":  t.Store.Snapshot.Microseconds(),
+		"compute_us":   t.Store.Compute.Microseconds(),
+		"post_us":      t.Store.Post.Microseconds(),
+		"handler_us":   t.Handler.Microseconds(),
+		"encode_us":    t.Encode.Microseconds(),
+		"results":      t.Store.Results,
+	}
+}
+
+// durationWindow 固定大小的耗时滚动窗口
+type durationWindow struct {
+	samples []time.Duration
+	idx     int
+	full    bool
+}
+
+func (w *durationWindow) add(d time.Duration) {
+	w.samples[w.idx] = d
+	w.idx = (w.idx + 1) % len(w.samples)
+	if w.idx == 0 {
+		w.full = true
+	}
+}
+
+// percentiles 计算 p50/p95/p99（单位：微秒）
+func (w *durationWindow) percentiles() map[string]int64 {
+	n := w.idx
+	if w.full {
+		n = len(w.samples)
+	}
+	if n == 0 {
+		return map[string]int64{"p50_us": 0, "p95_us": 0, "p99_us": 0}
+	}
+
+	sorted := make([]time.Duration, n)
+	copy(sorted, w.samples[:n])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return map[string]int64{
+		"p50_us": sorted[(n*50)/100].Microseconds(),
+		"p95_us": sorted[(n*95)/100].Microseconds(),
+		"p99_us": sorted[(n*99)/100].Microseconds(),
+	}
+}
+
+// endpointTiming 单个接口各阶段的滚动窗口
+type endpointTiming struct {
+	requests int64
+	phases   map[string]*durationWindow
+}
+
+// timingRecorder 按接口记录耗时的滚动百分位
+type timingRecorder struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointTiming
+}
+
+func newTimingRecorder() *timingRecorder {
+	return &timingRecorder{
+		endpoints: make(map[string]*endpointTiming),
+	}
+}
+
+// record 记录一次请求的各阶段耗时
+func (r *timingRecorder) record(endpoint string, t *requestTiming) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	et, exists := r.endpoints[endpoint]
+	if !exists {
+		et = &endpointTiming{phases: make(map[string]*durationWindow)}
+		for _, phase := range []string{"lock_wait", "snapshot", "compute", "post", "handler", "encode"} {
+			et.phases[phase] = &durationWindow{samples: make([]time.Duration, timingWindowSize)}
+		}
+		r.endpoints[endpoint] = et
+	}
+
+	et.requests++
+	et.phases["lock_wait"].add(t.Store.LockWait)
+	et.phases["snapshot"].add(t.Store.Snapshot)
+	et.phases["compute"].add(t.Store.Compute)
+	et.phases["post"].add(t.Store.Post)
+	et.phases["handler"].add(t.Handler)
+	et.phases["encode"].add(t.Encode)
+}
+
+// snapshot 获取所有接口的耗时百分位
+func (r *timingRecorder) snapshot() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]interface{}, len(r.endpoints))
+	for endpoint, et := range r.endpoints {
+		phases := make(map[string]interface{}, len(et.phases))
+		for phase, window := range et.phases {
+			phases[phase] = window.percentiles()
+		}
+		result[endpoint] = map[string]interface{}{
+			"requests": et.requests,
+			"phases":   phases,
+		}
+	}
+	return result
+}
+
+// isDebugRequest 是否请求返回 _timing（?debug=1）
+func isDebugRequest(r *http.Request) bool {
+	return r.URL.Query().Get("debug") == "1"
+}
+
+// writeTimedJSON 编码响应并记录该接口的耗时
+// ?debug=1 且响应为map时附加 _timing：编码耗时在编码完成后才知道，先编码响应数据，再带上 _timing 重新编码
+func (s *Server) writeTimedJSON(w http.ResponseWriter, r *http.Request, endpoint string, resp interface{}, t *requestTiming) {
+	encodeStart := time.Now()
+	body, err := json.Marshal(resp)
+	t.Encode = time.Since(encodeStart)
+	if m, ok := resp.(map[string]interface{}); ok && err == nil && isDebugRequest(r) {
+		m["_timing"] = t.toMap()
+		body, err = json.Marshal(m)
+	}
+	if err != nil {
+		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+	s.timings.record(endpoint, t)
+}
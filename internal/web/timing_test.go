@@ -0,0 +1,68 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugTiming(t *testing.T) {
+	s := newOpportunityServer(t)
+	mux := s.newMux()
+	phases := []string{"lock_wait_us", "snapshot_us", "compute_us", "post_us", "handler_us", "encode_us", "results"}
+
+	for _, path := range []string{"/api/spreads", "/api/arbitrage-opportunities"} {
+		t.Run(path, func(t *testing.T) {
+			get := func(query string) map[string]json.RawMessage {
+				t.Helper()
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+query, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s%s: status %d: %s", path, query, rec.Code, rec.Body)
+				}
+				var resp map[string]json.RawMessage
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				return resp
+			}
+
+			if _, ok := get("")["_timing"]; ok {
+				t.Fatal("_timing returned without debug=1")
+			}
+
+			raw, ok := get("?debug=1")["_timing"]
+			if !ok {
+				t.Fatal("_timing missing with debug=1")
+			}
+			var timing map[string]int64
+			if err := json.Unmarshal(raw, &timing); err != nil {
+				t.Fatal(err)
+			}
+			for _, phase := range phases {
+				if _, ok := timing[phase]; !ok {
+					t.Errorf("_timing missing %s: %v", phase, timing)
+				}
+			}
+			if timing["results"] == 0 {
+				t.Errorf("_timing results = 0, want the calculated count")
+			}
+		})
+	}
+
+	// 两个接口的滚动统计都包含复制和编码阶段
+	stats := s.timings.snapshot()
+	for _, endpoint := range []string{"spreads", "arbitrage-opportunities"} {
+		entry, ok := stats[endpoint].(map[string]interface{})
+		if !ok {
+			t.Fatalf("no timing stats for %s", endpoint)
+		}
+		recorded := entry["phases"].(map[string]interface{})
+		for _, phase := range []string{"snapshot", "encode"} {
+			if _, ok := recorded[phase]; !ok {
+				t.Errorf("%s stats missing %s phase", endpoint, phase)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/buildinfo"
+	"encoding/json"
+	"net/http"
+)
+
+// SetEffectiveConfig 设置 /api/version 和 /api/config 返回的有效配置（调用者负责脱敏，见 config.Config.Redacted）
+func (s *Server) SetEffectiveConfig(cfg map[string]interface{}) {
+	s.effectiveConfig = cfg
+}
+
+// handleVersion 返回构建版本、Go版本、启动时间/运行时长和脱敏后的有效配置
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := map[string]interface{}{
+		"success": true,
+		"data":    buildinfo.Get(),
+	}
+	if s.effectiveConfig != nil {
+		resp["config"] = s.effectiveConfig
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newVolumeServer 三个币种各有 Binance 和 Lighter 两腿：
+// BTC 的 Binance 腿来自 bookTicker（成交量未知），ETH 两腿成交量已知且充足，DOGE 两腿成交量已知但很小
+func newVolumeServer(t *testing.T) *Server {
+	t.Helper()
+	store := pricestore.NewPriceStore()
+	now := time.Now()
+	for _, leg := range []struct {
+		symbol   string
+		exchange common.Exchange
+		volume   float64
+		known    bool
+	}{
+		{"BTCUSDT", common.ExchangeBinance, 0, false},
+		{"BTCUSDT", common.ExchangeLighter, 5e6, true},
+		{"ETHUSDT", common.ExchangeBinance, 5e6, true},
+		{"ETHUSDT", common.ExchangeLighter, 5e6, true},
+		{"DOGEUSDT", common.ExchangeBinance, 500, true},
+		{"DOGEUSDT", common.ExchangeLighter, 800, true},
+	} {
+		price := seqQuote(leg.symbol, leg.exchange, now)
+		price.Volume24h, price.VolumeKnown = leg.volume, leg.known
+		store.UpdatePrice(price)
+	}
+	return NewServer(store, "")
+}
+
+type spreadRow struct {
+	Symbol      string  `json:"symbol"`
+	Volume24h   float64 `json:"volume_24h"`
+	VolumeKnown bool    `json:"volume_known"`
+}
+
+// getSpreadRows 请求 /api/spreads，按symbol返回各币种的价差
+func getSpreadRows(t *testing.T, s *Server, query string) map[string][]spreadRow {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.handleSpreads(rec, httptest.NewRequest(http.MethodGet, "/api/spreads"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/spreads%s: status %d", query, rec.Code)
+	}
+	var resp struct {
+		Success bool        `json:"success"`
+		Data    []spreadRow `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Success {
+		t.Fatalf("GET /api/spreads%s: success=false", query)
+	}
+	rows := make(map[string][]spreadRow)
+	for _, row := range resp.Data {
+		rows[row.Symbol] = append(rows[row.Symbol], row)
+	}
+	return rows
+}
+
+func TestSpreadVolumeFilter(t *testing.T) {
+	s := newVolumeServer(t)
+	all := getSpreadRows(t, s, "")
+	if len(all) != 3 {
+		t.Fatalf("unfiltered spreads cover %d symbols, want 3: %v", len(all), all)
+	}
+	// 任一腿成交量未知时价差的成交量也未知
+	for symbol, known := range map[string]bool{"BTCUSDT": false, "ETHUSDT": true, "DOGEUSDT": true} {
+		for _, row := range all[symbol] {
+			if row.VolumeKnown != known {
+				t.Fatalf("%s volume_known = %v, want %v", symbol, row.VolumeKnown, known)
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]bool
+	}{
+		{"threshold", "?min_volume=100000", map[string]bool{"BTCUSDT": true, "ETHUSDT": true}},
+		{"threshold above all known", "?min_volume=1e7", map[string]bool{"BTCUSDT": true}},
+		{"hide unknown", "?min_volume=100000&unknown_volume=hide", map[string]bool{"ETHUSDT": true}},
+		{"low threshold", "?min_volume=100", map[string]bool{"BTCUSDT": true, "ETHUSDT": true, "DOGEUSDT": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := getSpreadRows(t, s, tt.query)
+			if len(rows) != len(tt.want) {
+				t.Fatalf("symbols = %v, want %v", rows, tt.want)
+			}
+			for symbol := range rows {
+				if !tt.want[symbol] {
+					t.Fatalf("unexpected %s in %v, want %v", symbol, rows, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	watchWriteTimeout   = 5 * time.Second // 单帧写超时，客户端读得太慢时断开
+	watchStatusInterval = 5 * time.Second // 缺腿时重复发送状态帧的间隔
+)
+
+// watchUpgrader /ws/watch 的 WebSocket 升级（与 API 一样允许跨域）
+// 通过 Sec-WebSocket-Protocol 传递令牌的客户端要求服务端确认 bearer 子协议
+var watchUpgrader = websocket.Upgrader{
+	CheckOrigin:  func(r *http.Request) bool { return true },
+	Subprotocols: []string{wsTokenProtocol},
+}
+
+// watchLeg 推送帧中的单腿报价
+type watchLeg struct {
+	Venue  string             `json:"venue"` // EXCHANGE_MARKETTYPE
+	Bid    float64            `json:"bid"`
+	Ask    float64            `json:"ask"`
+	AgeMs  int64              `json:"age_ms"` // 发送时的数据年龄
+	Source common.PriceSource `json:"source"`
+}
+
+// watchFrame /ws/watch 推送的帧
+// type 为 spread 时包含两腿报价和价差；为 status 时说明为什么没有价差（例如某一腿没有数据）
+type watchFrame struct {
+	Type          string    `json:"type"`
+	Symbol        string    `json:"symbol"`
+	Buy           *watchLeg `json:"buy,omitempty"`
+	Sell          *watchLeg `json:"sell,omitempty"`
+	SpreadPercent float64   `json:"spread_percent"`
+	LatencyUs     int64     `json:"latency_us,omitempty"` // 触发本帧的报价从接收到发送的耗时（微秒），初始帧没有
+	Status        string    `json:"status,omitempty"`
+	Missing       []string  `json:"missing,omitempty"`
+}
+
+// watchVenue 单腿场所
+type watchVenue struct {
+	exchange   common.Exchange
+	marketType common.MarketType
+}
+
+// String EXCHANGE_MARKETTYPE
+func (v watchVenue) String() string {
+	return string(v.exchange) + "_" + string(v.marketType)
+}
+
+// parseWatchVenue 解析 BINANCE_FUTURE 形式的场所
+func parseWatchVenue(value string) (watchVenue, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	idx := strings.LastIndex(value, "_")
+	if idx <= 0 {
+		return watchVenue{}, fmt.Errorf("invalid venue %q: expected EXCHANGE_MARKETTYPE, e.g. BINANCE_FUTURE", value)
+	}
+	venue := watchVenue{
+		exchange:   common.Exchange(value[:idx]),
+		marketType: common.MarketType(value[idx+1:]),
+	}
+	if venue.marketType != common.MarketTypeSpot && venue.marketType != common.MarketTypeFuture {
+		return watchVenue{}, fmt.Errorf("invalid venue %q: market type must be SPOT or FUTURE", value)
+	}
+	return venue, nil
+}
+
+// handleWatch 单个交易对的低延迟价差推送
+// GET /ws/watch?symbol=ETHUSDT&buy=BINANCE_FUTURE&sell=LIGHTER_FUTURE 升级为 WebSocket，
+// 每次两腿之一有新报价时立即重新计算这一个价差并推送（不经过全量价差计算和快照）
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	symbol := query.Get("symbol")
+	if symbol == "" {
+		http.Error(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	buy, err := parseWatchVenue(query.Get("buy"))
+	if err != nil {
+		http.Error(w, "buy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	sell, err := parseWatchVenue(query.Get("sell"))
+	if err != nil {
+		http.Error(w, "sell: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := watchUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已经返回了错误响应
+		return
+	}
+	defer conn.Close()
+
+	watcher := s.store.WatchSymbol(symbol)
+	defer s.store.Unwatch(watcher)
+
+	// 读取客户端消息只为了处理关闭/控制帧，连接断开时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	statusTicker := time.NewTicker(watchStatusInterval)
+	defer statusTicker.Stop()
+
+	var lastBuySeq, lastSellSeq uint64
+	send := func(force bool) error {
+		buyPrice := watcher.Leg(buy.exchange, buy.marketType)
+		sellPrice := watcher.Leg(sell.exchange, sell.marketType)
+
+		frame := buildWatchFrame(watcher.Symbol, buy, sell, buyPrice, sellPrice, time.Now())
+		if frame.Type == "spread" {
+			// 其他场所的报价也会触发通知，两腿都没有变化时不推送
+			if !force && buyPrice.Seq == lastBuySeq && sellPrice.Seq == lastSellSeq {
+				return nil
+			}
+			lastBuySeq, lastSellSeq = buyPrice.Seq, sellPrice.Seq
+			if force {
+				// 初始帧不是由新报价触发的，没有推送延迟
+				frame.LatencyUs = 0
+			}
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(watchWriteTimeout))
+		return conn.WriteJSON(frame)
+	}
+
+	if err := send(true); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-closed:
+			return
+		case <-watcher.C():
+			if err := send(false); err != nil {
+				log.Printf("[Watch] %s %s->%s write failed: %v", watcher.Symbol, buy, sell, err)
+				return
+			}
+		case <-statusTicker.C:
+			// 缺腿时定期重复状态帧，客户端不会面对长时间的沉默
+			if watcher.Leg(buy.exchange, buy.marketType) == nil || watcher.Leg(sell.exchange, sell.marketType) == nil {
+				if err := send(true); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// buildWatchFrame 计算单个价差帧（公式与 /api/spreads 一致：买入腿的ask、卖出腿的bid），任一腿缺失时返回状态帧
+func buildWatchFrame(symbol string, buy, sell watchVenue, buyPrice, sellPrice *common.Price, now time.Time) *watchFrame {
+	frame := &watchFrame{Symbol: symbol}
+
+	missing := make([]string, 0, 2)
+	if buyPrice == nil || buyPrice.AskPrice <= 0 {
+		missing = append(missing, buy.String())
+	}
+	if sellPrice == nil || sellPrice.BidPrice <= 0 {
+		missing = append(missing, sell.String())
+	}
+	if len(missing) > 0 {
+		frame.Type = "status"
+		frame.Status = "waiting for data"
+		frame.Missing = missing
+		return frame
+	}
+
+	frame.Type = "spread"
+	frame.Buy = newWatchLeg(buy, buyPrice, now)
+	frame.Sell = newWatchLeg(sell, sellPrice, now)
+	frame.SpreadPercent = (sellPrice.BidPrice - buyPrice.AskPrice) / buyPrice.AskPrice * 100
+
+	// 以较新的一腿（序列号较大）作为触发本帧的报价
+	trigger := buyPrice
+	if sellPrice.Seq > buyPrice.Seq {
+		trigger = sellPrice
+	}
+	frame.LatencyUs = now.Sub(trigger.LastUpdated).Microseconds()
+	return frame
+}
+
+// newWatchLeg 单腿报价
+func newWatchLeg(venue watchVenue, price *common.Price, now time.Time) *watchLeg {
+	return &watchLeg{
+		Venue:  venue.String(),
+		Bid:    price.BidPrice,
+		Ask:    price.AskPrice,
+		AgeMs:  now.Sub(price.LastUpdated).Milliseconds(),
+		Source: price.Source,
+	}
+}
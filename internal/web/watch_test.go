@@ -0,0 +1,80 @@
+package web
+
+import (
+	"crypto-arbitrage-monitor/internal/pricestore"
+	"crypto-arbitrage-monitor/pkg/common"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWatchFrames(t *testing.T) {
+	store := pricestore.NewPriceStore()
+	s := NewServer(store, "")
+	srv := httptest.NewServer(s.newMux())
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/watch?symbol=btc&buy=binance_future&sell=LIGHTER_FUTURE"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	readFrame := func() watchFrame {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var frame watchFrame
+		if err := conn.ReadJSON(&frame); err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		return frame
+	}
+
+	// 初始帧：两腿都没有数据
+	frame := readFrame()
+	if frame.Type != "status" || frame.Symbol != "BTCUSDT" || len(frame.Missing) != 2 {
+		t.Fatalf("initial frame = %+v, want status missing both legs", frame)
+	}
+
+	ts := time.Now()
+	update := func(symbol string, exchange common.Exchange, bid, ask float64) {
+		ts = ts.Add(time.Millisecond)
+		price := seqQuote(symbol, exchange, ts)
+		price.BidPrice, price.AskPrice = bid, ask
+		store.UpdatePrice(price)
+	}
+
+	update("BTCUSDT", common.ExchangeBinance, 99.99, 100)
+	frame = readFrame()
+	if frame.Type != "status" || len(frame.Missing) != 1 || frame.Missing[0] != "LIGHTER_FUTURE" {
+		t.Fatalf("frame with only the buy leg = %+v", frame)
+	}
+
+	update("BTCUSDT", common.ExchangeLighter, 100.5, 100.6)
+	frame = readFrame()
+	if frame.Type != "spread" || frame.Buy == nil || frame.Sell == nil ||
+		frame.Buy.Venue != "BINANCE_FUTURE" || frame.Buy.Ask != 100 || frame.Sell.Bid != 100.5 ||
+		math.Abs(frame.SpreadPercent-0.5) > 1e-9 {
+		t.Fatalf("spread frame = %+v", frame)
+	}
+
+	// 其他场所和其他symbol的报价不推送，下一帧来自买入腿的新报价
+	update("BTCUSDT", common.ExchangeBybit, 90, 91)
+	update("ETHUSDT", common.ExchangeBinance, 10, 11)
+	update("BTCUSDT", common.ExchangeBinance, 99.4, 99.5)
+	frame = readFrame()
+	if frame.Type != "spread" || frame.Buy.Ask != 99.5 || frame.Sell.Bid != 100.5 {
+		t.Fatalf("frame after the filtered updates = %+v, want the new Binance quote", frame)
+	}
+
+	update("ETHUSDT", common.ExchangeLighter, 10, 11)
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if err := conn.ReadJSON(&frame); err == nil {
+		t.Fatalf("unexpected frame %+v for another symbol", frame)
+	}
+}
@@ -0,0 +1,70 @@
+package wsutil
+
+import (
+	"crypto-arbitrage-monitor/internal/netutil"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultHandshakeTimeout = 10 * time.Second
+	readBufferSize          = 64 * 1024 // 全量行情流单帧较大，使用较大的读缓冲
+	writeBufferSize         = 4 * 1024  // 只发送订阅/心跳等小消息
+	sessionCacheSize        = 64
+)
+
+var (
+	mu               sync.RWMutex
+	handshakeTimeout = defaultHandshakeTimeout
+
+	// TLS 会话缓存：按服务器名缓存会话票据，连接池批量重连时可以复用会话，避免每次完整握手
+	sessionCache = tls.NewLRUClientSessionCache(sessionCacheSize)
+)
+
+// SetHandshakeTimeout 设置 WebSocket 握手超时（<= 0 时使用默认值10秒）
+func SetHandshakeTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultHandshakeTimeout
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	handshakeTimeout = timeout
+}
+
+// NewDialer 创建共享配置的 WebSocket Dialer，TCP连接使用 netutil 的共享拨号器（DNS缓存、IPv4/IPv6快速切换）
+// proxyURL 为空时使用环境变量中的代理（HTTP_PROXY / HTTPS_PROXY），否则使用指定代理
+func NewDialer(proxyURL string) *websocket.Dialer {
+	mu.RLock()
+	timeout := handshakeTimeout
+	mu.RUnlock()
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		NetDialContext:   netutil.DialContext,
+		HandshakeTimeout: timeout,
+		ReadBufferSize:   readBufferSize,
+		WriteBufferSize:  writeBufferSize,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ClientSessionCache: sessionCache,
+		},
+	}
+
+	if proxyURL != "" {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil {
+			log.Printf("[WS Dialer] Invalid proxy URL %s: %v, using environment proxy", proxyURL, err)
+		} else {
+			dialer.Proxy = http.ProxyURL(parsed)
+		}
+	}
+
+	return dialer
+}
@@ -0,0 +1,168 @@
+package wsutil
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// connectProxy 本地 HTTP CONNECT 代理，记录每个隧道的目标地址
+type connectProxy struct {
+	*httptest.Server
+	mu      sync.Mutex
+	targets []string
+}
+
+func newConnectProxy(t *testing.T) *connectProxy {
+	t.Helper()
+	p := &connectProxy{}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		p.mu.Lock()
+		p.targets = append(p.targets, r.Host)
+		p.mu.Unlock()
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			upstream.Close()
+			http.Error(w, "hijack unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		client, buf, err := hijacker.Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		go func() {
+			defer upstream.Close()
+			io.Copy(upstream, buf)
+		}()
+		go func() {
+			defer client.Close()
+			io.Copy(client, upstream)
+		}()
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *connectProxy) tunnels() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+// newEchoServer WebSocket 回显服务
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(mt, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDialerHonorsProxy(t *testing.T) {
+	echo := newEchoServer(t)
+	proxy := newConnectProxy(t)
+
+	conn, _, err := NewDialer(proxy.URL).Dial("ws"+strings.TrimPrefix(echo.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := conn.ReadMessage()
+	if err != nil || string(msg) != "ping" {
+		t.Fatalf("echo = %q, %v", msg, err)
+	}
+
+	target := strings.TrimPrefix(echo.URL, "http://")
+	if got := proxy.tunnels(); len(got) != 1 || got[0] != target {
+		t.Fatalf("proxy tunnels = %v, want [%s]", got, target)
+	}
+}
+
+func TestDialerWithoutProxyConnectsDirectly(t *testing.T) {
+	echo := newEchoServer(t)
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "")
+
+	conn, _, err := NewDialer("").Dial("ws"+strings.TrimPrefix(echo.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestDialerHandshakeTimeout(t *testing.T) {
+	// 接受TCP连接但从不回复握手
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	SetHandshakeTimeout(100 * time.Millisecond)
+	defer SetHandshakeTimeout(0)
+
+	start := time.Now()
+	if _, _, err := NewDialer("").Dial("ws://"+listener.Addr().String(), nil); err == nil {
+		t.Fatal("handshake with a silent server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("handshake gave up after %v, want about 100ms", elapsed)
+	}
+}
+
+func TestDialerSharesTLSSessionCache(t *testing.T) {
+	a, b := NewDialer(""), NewDialer("")
+	if a.TLSClientConfig.ClientSessionCache == nil || a.TLSClientConfig.ClientSessionCache != b.TLSClientConfig.ClientSessionCache {
+		t.Fatal("dialers do not share the TLS session cache")
+	}
+	if a.HandshakeTimeout != defaultHandshakeTimeout {
+		t.Fatalf("handshake timeout = %v, want default %v", a.HandshakeTimeout, defaultHandshakeTimeout)
+	}
+}
@@ -0,0 +1,19 @@
+package wsutil
+
+// PlanConnections 计算连接池的连接数：按每个连接 perConn 个订阅分配，
+// 超过 maxConnections（0表示不限制）时增大每个连接的订阅数，使连接数不超过上限
+// 返回调整后的每连接订阅数和连接数
+func PlanConnections(total, perConn, maxConnections int) (adjustedPerConn, connections int) {
+	if total <= 0 {
+		return perConn, 0
+	}
+	if perConn <= 0 {
+		perConn = 1
+	}
+	connections = (total + perConn - 1) / perConn
+	if maxConnections > 0 && connections > maxConnections {
+		perConn = (total + maxConnections - 1) / maxConnections
+		connections = (total + perConn - 1) / perConn
+	}
+	return perConn, connections
+}
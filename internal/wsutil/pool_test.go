@@ -0,0 +1,36 @@
+package wsutil
+
+import "testing"
+
+func TestPlanConnections(t *testing.T) {
+	tests := []struct {
+		name            string
+		total, perConn  int
+		maxConnections  int
+		wantPerConn     int
+		wantConnections int
+	}{
+		{"under cap", 100, 60, 10, 60, 2},
+		{"no cap", 1000, 10, 0, 10, 100},
+		{"exactly at cap", 40, 10, 4, 10, 4},
+		{"over cap raises per-conn", 1000, 10, 8, 125, 8},
+		{"uneven split stays within cap", 1001, 10, 8, 126, 8},
+		{"cap larger than items", 3, 1, 2, 2, 2},
+		{"empty", 0, 60, 4, 60, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perConn, connections := PlanConnections(tt.total, tt.perConn, tt.maxConnections)
+			if perConn != tt.wantPerConn || connections != tt.wantConnections {
+				t.Fatalf("PlanConnections(%d, %d, %d) = %d, %d; want %d, %d",
+					tt.total, tt.perConn, tt.maxConnections, perConn, connections, tt.wantPerConn, tt.wantConnections)
+			}
+			if tt.maxConnections > 0 && connections > tt.maxConnections {
+				t.Fatalf("%d connections exceed the cap %d", connections, tt.maxConnections)
+			}
+			if connections*perConn < tt.total {
+				t.Fatalf("%d connections x %d cannot hold %d items", connections, perConn, tt.total)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package wsutil
+
+import (
+	"sync"
+	"time"
+)
+
+// ReconnectLimiter 连接池内共享的重连限速器（令牌桶）
+// 交易所故障时池内所有连接会在同一时刻断开并重连，限速后按每秒最多 rate 次错开重连，
+// 避免瞬间大量握手触发交易所的连接数限制。nil 表示不限速
+type ReconnectLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // 生成一个令牌的间隔（1/rate）
+	burst    float64       // 令牌桶容量，允许同时立即重连的连接数
+	tokens   float64       // 当前令牌数，为负表示已有连接在排队等待
+	last     time.Time     // 上次补充令牌的时间
+}
+
+// NewReconnectLimiter 创建重连限速器：每秒最多 rate 次重连，最多 burst 个连接可以立即重连
+// rate <= 0 时返回 nil（不限速）
+func NewReconnectLimiter(rate float64, burst int) *ReconnectLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &ReconnectLimiter{
+		interval: time.Duration(float64(time.Second) / rate),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Reserve 预约一次重连，返回需要等待的时长（令牌不足时排在已预约的连接之后）
+func (l *ReconnectLimiter) Reserve() time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// Wait 预约一次重连并等待到可以重连，done 关闭时返回 false（连接已关闭，不再重连）
+func (l *ReconnectLimiter) Wait(done <-chan struct{}) bool {
+	delay := l.Reserve()
+	if delay <= 0 {
+		select {
+		case <-done:
+			return false
+		default:
+			return true
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
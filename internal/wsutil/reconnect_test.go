@@ -0,0 +1,83 @@
+package wsutil
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconnectLimiterStaggersConcurrentReconnects(t *testing.T) {
+	const (
+		conns    = 10
+		burst    = 2
+		interval = 20 * time.Millisecond
+	)
+	l := NewReconnectLimiter(float64(time.Second/interval), burst)
+
+	// 所有连接同时断开：前 burst 个立即重连，其余每隔 interval 重连一个
+	start := time.Now()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	offsets := make([]time.Duration, 0, conns)
+	for i := 0; i < conns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !l.Wait(nil) {
+				t.Error("Wait returned false without done")
+			}
+			mu.Lock()
+			offsets = append(offsets, time.Since(start))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	slack := interval / 2
+	for i := 0; i < burst; i++ {
+		if offsets[i] > interval {
+			t.Fatalf("reconnect %d waited %v, want immediate within the burst", i, offsets[i])
+		}
+	}
+	for i := burst; i < conns; i++ {
+		want := time.Duration(i-burst+1) * interval
+		if offsets[i] < want-slack {
+			t.Fatalf("reconnect %d after %v, want no earlier than %v (offsets %v)", i, offsets[i], want, offsets)
+		}
+	}
+}
+
+func TestReconnectLimiterWaitStopsOnDone(t *testing.T) {
+	l := NewReconnectLimiter(1, 1)
+	done := make(chan struct{})
+	if !l.Wait(done) {
+		t.Fatal("first reconnect within the burst not allowed")
+	}
+
+	// 令牌用完后排队等待，连接关闭时立即返回
+	result := make(chan bool, 1)
+	go func() { result <- l.Wait(done) }()
+	close(done)
+	select {
+	case ok := <-result:
+		if ok {
+			t.Fatal("Wait returned true after done closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after done closed")
+	}
+}
+
+func TestReconnectLimiterDisabled(t *testing.T) {
+	l := NewReconnectLimiter(0, 5)
+	if l != nil {
+		t.Fatal("rate 0 returned a limiter")
+	}
+	for i := 0; i < 100; i++ {
+		if delay := l.Reserve(); delay != 0 {
+			t.Fatalf("nil limiter delay = %v", delay)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package wsutil
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 订阅登记的来源
+const (
+	SubscriptionSourceStart     = "start"     // 连接池启动时的初始订阅
+	SubscriptionSourceReload    = "reload"    // 交易对/市场列表刷新（Reload）
+	SubscriptionSourceOnDemand  = "on_demand" // 按需订阅（/api/subscribe）
+	SubscriptionSourceReconcile = "reconcile" // 对账时补登记（连接上实际存在但没有登记的订阅）
+)
+
+// Subscription 一条订阅：市场类型+symbol 所在的连接
+type Subscription struct {
+	MarketType string    `json:"market_type"`
+	Symbol     string    `json:"symbol"`
+	ConnID     int       `json:"conn_id"`
+	Source     string    `json:"source"`
+	Since      time.Time `json:"since"`
+}
+
+// SubscriptionConflictError 市场类型+symbol 已在另一个连接上订阅
+type SubscriptionConflictError struct {
+	Exchange   string
+	MarketType string
+	Symbol     string
+	ConnID     int // 已有订阅所在的连接
+}
+
+func (e *SubscriptionConflictError) Error() string {
+	return fmt.Sprintf("%s %s %s already subscribed on conn #%d", e.Exchange, e.MarketType, e.Symbol, e.ConnID)
+}
+
+// subscriptionKey 订阅登记的key
+type subscriptionKey struct {
+	marketType string
+	symbol     string
+}
+
+// SubscriptionRegistry 单个交易所的订阅登记（市场类型+symbol -> 连接编号）
+// 连接池的所有订阅/退订（启动、Reload、按需订阅、对账）都经过登记，同一个 symbol 不会同时订阅在两个连接上
+type SubscriptionRegistry struct {
+	exchange string
+
+	mu   sync.Mutex
+	subs map[subscriptionKey]*Subscription
+}
+
+// NewSubscriptionRegistry 创建交易所的订阅登记
+func NewSubscriptionRegistry(exchange string) *SubscriptionRegistry {
+	return &SubscriptionRegistry{
+		exchange: exchange,
+		subs:     make(map[subscriptionKey]*Subscription),
+	}
+}
+
+// Exchange 登记所属的交易所
+func (r *SubscriptionRegistry) Exchange() string {
+	return r.exchange
+}
+
+// Claim 登记 symbol 订阅在 connID 上：已在同一连接上登记时不做修改（幂等），
+// 已在其他连接上登记时返回 *SubscriptionConflictError
+func (r *SubscriptionRegistry) Claim(marketType, symbol string, connID int, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := subscriptionKey{marketType, symbol}
+	if sub, exists := r.subs[key]; exists {
+		if sub.ConnID == connID {
+			return nil
+		}
+		return &SubscriptionConflictError{Exchange: r.exchange, MarketType: marketType, Symbol: symbol, ConnID: sub.ConnID}
+	}
+	r.subs[key] = &Subscription{MarketType: marketType, Symbol: symbol, ConnID: connID, Source: source, Since: time.Now().UTC()}
+	return nil
+}
+
+// Lookup 查询 symbol 订阅所在的连接
+func (r *SubscriptionRegistry) Lookup(marketType, symbol string) (connID int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, exists := r.subs[subscriptionKey{marketType, symbol}]
+	if !exists {
+		return 0, false
+	}
+	return sub.ConnID, true
+}
+
+// Release 取消 symbol 在 connID 上的登记，登记在其他连接上时不做修改
+func (r *SubscriptionRegistry) Release(marketType, symbol string, connID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := subscriptionKey{marketType, symbol}
+	if sub, exists := r.subs[key]; exists && sub.ConnID == connID {
+		delete(r.subs, key)
+	}
+}
+
+// ReleaseConn 取消 connID 上的全部登记（连接关闭时）
+func (r *SubscriptionRegistry) ReleaseConn(connID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, sub := range r.subs {
+		if sub.ConnID == connID {
+			delete(r.subs, key)
+		}
+	}
+}
+
+// Reconcile 按连接上实际订阅的 symbol（actual 中的 MarketType/Symbol/ConnID）修正登记，返回需要退订的重复订阅：
+// - 同一个 symbol 出现在多个连接上时保留登记的连接（没有登记时保留编号最小的连接），其余为重复
+// - 实际存在但没有登记的订阅补登记，登记了但已不在任何连接上的订阅删除
+func (r *SubscriptionRegistry) Reconcile(actual []Subscription) []Subscription {
+	holders := make(map[subscriptionKey][]int)
+	for _, sub := range actual {
+		key := subscriptionKey{sub.MarketType, sub.Symbol}
+		holders[key] = append(holders[key], sub.ConnID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.subs {
+		if len(holders[key]) == 0 {
+			delete(r.subs, key)
+		}
+	}
+
+	var duplicates []Subscription
+	for key, connIDs := range holders {
+		sort.Ints(connIDs)
+		keep := connIDs[0]
+		if sub, exists := r.subs[key]; exists && slices.Contains(connIDs, sub.ConnID) {
+			keep = sub.ConnID
+		} else {
+			r.subs[key] = &Subscription{MarketType: key.marketType, Symbol: key.symbol, ConnID: keep, Source: SubscriptionSourceReconcile, Since: time.Now().UTC()}
+		}
+		for _, id := range connIDs {
+			if id != keep {
+				duplicates = append(duplicates, Subscription{MarketType: key.marketType, Symbol: key.symbol, ConnID: id})
+			}
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Symbol != duplicates[j].Symbol {
+			return duplicates[i].Symbol < duplicates[j].Symbol
+		}
+		return duplicates[i].ConnID < duplicates[j].ConnID
+	})
+	return duplicates
+}
+
+// Snapshot 当前全部登记（按市场类型、symbol排序）
+func (r *SubscriptionRegistry) Snapshot() []Subscription {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subs := make([]Subscription, 0, len(r.subs))
+	for _, sub := range r.subs {
+		subs = append(subs, *sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].MarketType != subs[j].MarketType {
+			return subs[i].MarketType < subs[j].MarketType
+		}
+		return subs[i].Symbol < subs[j].Symbol
+	})
+	return subs
+}
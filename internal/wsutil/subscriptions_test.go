@@ -0,0 +1,129 @@
+package wsutil
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSubscriptionRegistryClaim(t *testing.T) {
+	r := NewSubscriptionRegistry("BINANCE")
+
+	if err := r.Claim("SPOT", "BTCUSDT", 3, SubscriptionSourceStart); err != nil {
+		t.Fatal(err)
+	}
+	// 同一连接重复登记是幂等的
+	if err := r.Claim("SPOT", "BTCUSDT", 3, SubscriptionSourceReload); err != nil {
+		t.Fatalf("idempotent claim: %v", err)
+	}
+	if subs := r.Snapshot(); len(subs) != 1 || subs[0].Source != SubscriptionSourceStart {
+		t.Fatalf("snapshot = %+v, want the original start claim", subs)
+	}
+
+	err := r.Claim("SPOT", "BTCUSDT", 5, SubscriptionSourceOnDemand)
+	var conflict *SubscriptionConflictError
+	if !errors.As(err, &conflict) || conflict.ConnID != 3 {
+		t.Fatalf("second subscriber err = %v, want conflict on conn #3", err)
+	}
+	if want := "BINANCE SPOT BTCUSDT already subscribed on conn #3"; err.Error() != want {
+		t.Fatalf("error = %q, want %q", err.Error(), want)
+	}
+
+	// 市场类型不同的同名 symbol 互不冲突
+	if err := r.Claim("FUTURE", "BTCUSDT", 5, SubscriptionSourceStart); err != nil {
+		t.Fatal(err)
+	}
+	if connID, ok := r.Lookup("FUTURE", "BTCUSDT"); !ok || connID != 5 {
+		t.Fatalf("lookup = %d, %v", connID, ok)
+	}
+}
+
+func TestSubscriptionRegistryRelease(t *testing.T) {
+	r := NewSubscriptionRegistry("LIGHTER")
+	r.Claim("FUTURE", "ETH", 1, SubscriptionSourceStart)
+	r.Claim("FUTURE", "SOL", 1, SubscriptionSourceStart)
+	r.Claim("FUTURE", "BTC", 2, SubscriptionSourceStart)
+
+	// 其他连接不能释放不属于自己的登记
+	r.Release("FUTURE", "BTC", 1)
+	if _, ok := r.Lookup("FUTURE", "BTC"); !ok {
+		t.Fatal("release from the wrong connection removed the subscription")
+	}
+	r.Release("FUTURE", "BTC", 2)
+	if _, ok := r.Lookup("FUTURE", "BTC"); ok {
+		t.Fatal("release did not remove the subscription")
+	}
+
+	r.ReleaseConn(1)
+	if subs := r.Snapshot(); len(subs) != 0 {
+		t.Fatalf("snapshot after closing conn #1 = %+v", subs)
+	}
+}
+
+func TestSubscriptionRegistryConcurrentClaims(t *testing.T) {
+	r := NewSubscriptionRegistry("BINANCE")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := make([]int, 0)
+	for connID := 1; connID <= 16; connID++ {
+		wg.Add(1)
+		go func(connID int) {
+			defer wg.Done()
+			if err := r.Claim("SPOT", "BTCUSDT", connID, SubscriptionSourceOnDemand); err == nil {
+				mu.Lock()
+				winners = append(winners, connID)
+				mu.Unlock()
+			}
+		}(connID)
+	}
+	wg.Wait()
+
+	if len(winners) != 1 {
+		t.Fatalf("%d connections claimed the same symbol: %v", len(winners), winners)
+	}
+	if connID, _ := r.Lookup("SPOT", "BTCUSDT"); connID != winners[0] {
+		t.Fatalf("registry owner %d, winner %d", connID, winners[0])
+	}
+}
+
+func TestSubscriptionRegistryReconcile(t *testing.T) {
+	r := NewSubscriptionRegistry("BINANCE")
+	r.Claim("SPOT", "BTCUSDT", 2, SubscriptionSourceStart)
+	r.Claim("SPOT", "GONEUSDT", 1, SubscriptionSourceStart)
+
+	duplicates := r.Reconcile([]Subscription{
+		{MarketType: "SPOT", Symbol: "BTCUSDT", ConnID: 1},
+		{MarketType: "SPOT", Symbol: "BTCUSDT", ConnID: 2}, // 登记的连接保留
+		{MarketType: "SPOT", Symbol: "ETHUSDT", ConnID: 4},
+		{MarketType: "SPOT", Symbol: "ETHUSDT", ConnID: 3}, // 没有登记时保留编号最小的连接
+	})
+
+	want := []Subscription{
+		{MarketType: "SPOT", Symbol: "BTCUSDT", ConnID: 1},
+		{MarketType: "SPOT", Symbol: "ETHUSDT", ConnID: 4},
+	}
+	if !reflect.DeepEqual(duplicates, want) {
+		t.Fatalf("duplicates = %+v, want %+v", duplicates, want)
+	}
+
+	subs := r.Snapshot()
+	if len(subs) != 2 {
+		t.Fatalf("snapshot = %+v, want BTCUSDT and ETHUSDT only", subs)
+	}
+	if subs[0].Symbol != "BTCUSDT" || subs[0].ConnID != 2 || subs[0].Source != SubscriptionSourceStart {
+		t.Fatalf("BTCUSDT = %+v, want the registered conn #2", subs[0])
+	}
+	if subs[1].Symbol != "ETHUSDT" || subs[1].ConnID != 3 || subs[1].Source != SubscriptionSourceReconcile {
+		t.Fatalf("ETHUSDT = %+v, want backfilled on conn #3", subs[1])
+	}
+
+	// 没有重复时对账不退订任何订阅
+	if duplicates := r.Reconcile([]Subscription{
+		{MarketType: "SPOT", Symbol: "BTCUSDT", ConnID: 2},
+		{MarketType: "SPOT", Symbol: "ETHUSDT", ConnID: 3},
+	}); len(duplicates) != 0 {
+		t.Fatalf("duplicates = %+v, want none", duplicates)
+	}
+}
@@ -0,0 +1,90 @@
+// Package apiv2 /api/v2/* 的响应类型
+//
+// v1（/api/v1/* 和无版本前缀的路径）保持各接口原有的JSON结构，不再增加字段；
+// 新的结构化字段（两腿明细、数据源、置信度等）只加在这里定义的 v2 类型中
+package apiv2
+
+import (
+	"crypto-arbitrage-monitor/pkg/common"
+	"time"
+)
+
+// Version v2 响应 envelope 中的 api_version
+const Version = "v2"
+
+// Envelope v2 响应的公共字段
+type Envelope struct {
+	Success       bool   `json:"success"`
+	APIVersion    string `json:"api_version"`
+	Count         int    `json:"count"`                     // 当前页的结果数
+	Total         int    `json:"total"`                     // 分页前的结果数
+	Offset        int    `json:"offset"`                    // 当前页的起始位置
+	Limit         int    `json:"limit,omitempty"`           // 每页数量，0表示不分页
+	SnapshotAgeMs int64  `json:"snapshot_age_ms,omitempty"` // 使用只读快照时快照的年龄
+
+	// 指定 quote 参数时的显示货币；汇率缺失或过期时数据保持USDT，原因放在 QuoteWarning 中
+	Quote        *Quote `json:"quote,omitempty"`
+	QuoteWarning string `json:"quote_warning,omitempty"`
+}
+
+// Quote 响应中绝对价格和成交额使用的显示货币（百分比字段不换算）
+type Quote struct {
+	Currency    string  `json:"currency"`
+	USDTPerUnit float64 `json:"usdt_per_unit,omitempty"` // 1单位显示货币 = USDTPerUnit USDT
+	Source      string  `json:"source,omitempty"`        // 参考汇率来源，例如 EURUSDT_MID、BTCUSDT_INDEX
+	RateAgeMs   int64   `json:"rate_age_ms,omitempty"`   // 参考汇率的年龄
+}
+
+// SpreadsResponse GET /api/v2/spreads
+type SpreadsResponse struct {
+	Envelope
+	Data []*Spread `json:"data"`
+}
+
+// Spread 两个场所之间的价差，买卖两腿的明细分别放在 Buy 和 Sell 中
+type Spread struct {
+	Symbol          string               `json:"symbol"` // 与 v1 相同（买入腿的交易所symbol）
+	Buy             Leg                  `json:"buy"`
+	Sell            Leg                  `json:"sell"`
+	SpreadPercent   float64              `json:"spread_percent"`
+	SpreadAbsolute  float64              `json:"spread_absolute"`
+	EffectiveSpread float64              `json:"effective_spread"` // 扣除汇率成本后的有效价差
+	Volume24h       float64              `json:"volume_24h"`
+	VolumeKnown     bool                 `json:"volume_known"` // 两腿都有真实成交量数据，为false时 Volume24h 不可信
+	Confidence      float64              `json:"confidence"`   // 两腿报价的置信度（0-1）
+	Sources         []common.PriceSource `json:"sources"`      // 两腿的数据源（去重）
+	UpdatedAt       time.Time            `json:"updated_at"`
+
+	// 本轮缺少一腿、在保留时长内返回的上次价差，MissingSince 为最后一次出现的时间
+	Held         bool       `json:"held,omitempty"`
+	MissingSince *time.Time `json:"missing_since,omitempty"`
+
+	// 延迟补偿后的价差（服务端开启 LATENCY_PROJECTION 且两腿交易所时间差超过阈值时），SpreadPercent 仍为原始价差
+	Projection *Projection `json:"projection,omitempty"`
+}
+
+// Projection 较旧一腿按其中间价漂移投影到较新一腿的时间后的价差
+type Projection struct {
+	Leg            string  `json:"leg"`               // 被投影的腿：buy 或 sell
+	GapMs          int64   `json:"gap_ms"`            // 两腿交易所时间差（毫秒）
+	DriftBpsPerSec float64 `json:"drift_bps_per_sec"` // 被投影腿的中间价漂移（基点/秒）
+	AmountBps      float64 `json:"amount_bps"`        // 实际投影量（基点，已限幅）
+	Capped         bool    `json:"capped,omitempty"`  // 投影量被限幅
+	BuyPrice       float64 `json:"buy_price"`
+	SellPrice      float64 `json:"sell_price"`
+	SpreadPercent  float64 `json:"spread_percent"` // 投影后的价差百分比
+}
+
+// Leg 价差的一腿（价格为USDT或 quote 指定的显示货币计价，原始报价货币的价格见 OriginalPrice）
+type Leg struct {
+	Exchange      common.Exchange      `json:"exchange"`
+	MarketType    common.MarketType    `json:"market_type"`
+	Symbol        string               `json:"symbol,omitempty"` // 交易所原始symbol
+	Price         float64              `json:"price"`
+	QuoteCurrency common.QuoteCurrency `json:"quote_currency"`
+	OriginalPrice float64              `json:"original_price"`
+	ExchangeRate  float64              `json:"exchange_rate"`
+	Source        common.PriceSource   `json:"source,omitempty"`    // WebSocket 或 REST
+	AgeMs         int64                `json:"age_ms"`              // 报价年龄（历史查询时相对查询时间点）
+	Synthetic     bool                 `json:"synthetic,omitempty"` // bid/ask 为估算值而不是真实盘口
+}
@@ -0,0 +1,194 @@
+// Package client SeeingStone Web API 的Go客户端
+//
+// 默认请求 /api/v2/*；连接只提供 v1 接口的旧版本服务时使用 WithV1Compat，
+// 客户端请求 /api/v1/* 并把响应转换为 v2 类型（v1 没有的字段为零值）
+package client
+
+import (
+	"context"
+	"crypto-arbitrage-monitor/pkg/apiv2"
+	"crypto-arbitrage-monitor/pkg/common"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client Web API 客户端
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	v1Compat   bool
+	token      string
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client（默认10秒超时）
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithV1Compat 请求 /api/v1/* 并转换为 v2 类型
+func WithV1Compat() Option {
+	return func(c *Client) {
+		c.v1Compat = true
+	}
+}
+
+// WithToken 请求带 Authorization: Bearer <token>（服务端设置了 API_TOKEN 时需要）
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New 创建客户端，baseURL 例如 http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Spreads 查询价差，params 与 /api/spreads 的查询参数相同（sort、min_spread、limit 等）
+func (c *Client) Spreads(ctx context.Context, params url.Values) (*apiv2.SpreadsResponse, error) {
+	if !c.v1Compat {
+		var resp apiv2.SpreadsResponse
+		if err := c.get(ctx, "/api/v2/spreads", params, &resp); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+
+	var resp v1SpreadsResponse
+	if err := c.get(ctx, "/api/v1/spreads", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toV2(), nil
+}
+
+// get 发送GET请求并解析JSON响应，非200状态码返回包含响应内容的错误
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	u := c.baseURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: decode response: %w", path, err)
+	}
+	return nil
+}
+
+// v1SpreadsResponse GET /api/v1/spreads 的响应（只包含转换需要的字段）
+type v1SpreadsResponse struct {
+	Success       bool        `json:"success"`
+	Count         int         `json:"count"`
+	Total         int         `json:"total"`
+	Offset        int         `json:"offset"`
+	Limit         int         `json:"limit"`
+	SnapshotAgeMs int64       `json:"snapshot_age_ms"`
+	Data          []*v1Spread `json:"data"`
+}
+
+// v1Spread v1 的价差结构（两腿字段平铺）
+type v1Spread struct {
+	Symbol            string               `json:"symbol"`
+	BuyExchange       common.Exchange      `json:"buy_exchange"`
+	BuyMarketType     common.MarketType    `json:"buy_market_type"`
+	BuyPrice          float64              `json:"buy_price"`
+	SellExchange      common.Exchange      `json:"sell_exchange"`
+	SellMarketType    common.MarketType    `json:"sell_market_type"`
+	SellPrice         float64              `json:"sell_price"`
+	SpreadPercent     float64              `json:"spread_percent"`
+	SpreadAbsolute    float64              `json:"spread_absolute"`
+	Volume24h         float64              `json:"volume_24h"`
+	VolumeKnown       bool                 `json:"volume_known"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+	BuyQuoteCurrency  common.QuoteCurrency `json:"buy_quote_currency"`
+	BuyOriginalPrice  float64              `json:"buy_original_price"`
+	BuyExchangeRate   float64              `json:"buy_exchange_rate"`
+	SellQuoteCurrency common.QuoteCurrency `json:"sell_quote_currency"`
+	SellOriginalPrice float64              `json:"sell_original_price"`
+	SellExchangeRate  float64              `json:"sell_exchange_rate"`
+	EffectiveSpread   float64              `json:"effective_spread"`
+	Confidence        float64              `json:"confidence"`
+	Held              bool                 `json:"held"`
+	MissingSince      *time.Time           `json:"missing_since"`
+}
+
+// toV2 转换为 v2 响应（两腿的原始symbol、数据源和年龄在 v1 中没有，保持为零值）
+func (r *v1SpreadsResponse) toV2() *apiv2.SpreadsResponse {
+	data := make([]*apiv2.Spread, 0, len(r.Data))
+	for _, s := range r.Data {
+		data = append(data, &apiv2.Spread{
+			Symbol: s.Symbol,
+			Buy: apiv2.Leg{
+				Exchange:      s.BuyExchange,
+				MarketType:    s.BuyMarketType,
+				Price:         s.BuyPrice,
+				QuoteCurrency: s.BuyQuoteCurrency,
+				OriginalPrice: s.BuyOriginalPrice,
+				ExchangeRate:  s.BuyExchangeRate,
+			},
+			Sell: apiv2.Leg{
+				Exchange:      s.SellExchange,
+				MarketType:    s.SellMarketType,
+				Price:         s.SellPrice,
+				QuoteCurrency: s.SellQuoteCurrency,
+				OriginalPrice: s.SellOriginalPrice,
+				ExchangeRate:  s.SellExchangeRate,
+			},
+			SpreadPercent:   s.SpreadPercent,
+			SpreadAbsolute:  s.SpreadAbsolute,
+			EffectiveSpread: s.EffectiveSpread,
+			Volume24h:       s.Volume24h,
+			VolumeKnown:     s.VolumeKnown,
+			Confidence:      s.Confidence,
+			Sources:         []common.PriceSource{},
+			UpdatedAt:       s.UpdatedAt,
+			Held:            s.Held,
+			MissingSince:    s.MissingSince,
+		})
+	}
+	return &apiv2.SpreadsResponse{
+		Envelope: apiv2.Envelope{
+			Success:       r.Success,
+			APIVersion:    "v1",
+			Count:         r.Count,
+			Total:         r.Total,
+			Offset:        r.Offset,
+			Limit:         r.Limit,
+			SnapshotAgeMs: r.SnapshotAgeMs,
+		},
+		Data: data,
+	}
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DedupLogger 重复日志合并器
+// 同一 subsystem + 消息模板在窗口期内只输出第一条，窗口结束时输出一条
+// "(repeated N times in last 60s)" 汇总（带最后一次的完整消息）
+type DedupLogger struct {
+	mu      sync.Mutex
+	window  time.Duration
+	maxKeys int // 最多跟踪的key数量，超出后不再合并，直接输出
+	entries map[string]*dedupEntry
+	output  func(string)
+}
+
+// dedupEntry 单个key在当前窗口内的状态
+type dedupEntry struct {
+	repeated int
+	last     string
+}
+
+// NewDedupLogger 创建重复日志合并器
+func NewDedupLogger(window time.Duration, maxKeys int) *DedupLogger {
+	return &DedupLogger{
+		window:  window,
+		maxKeys: maxKeys,
+		entries: make(map[string]*dedupEntry),
+		output:  func(msg string) { log.Print(msg) },
+	}
+}
+
+// Printf 输出日志，subsystem + format 相同的消息在窗口期内合并
+func (d *DedupLogger) Printf(subsystem, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	key := subsystem + "|" + format
+
+	d.mu.Lock()
+	if entry, exists := d.entries[key]; exists {
+		entry.repeated++
+		entry.last = msg
+		d.mu.Unlock()
+		return
+	}
+
+	if len(d.entries) >= d.maxKeys {
+		d.mu.Unlock()
+		d.output(msg)
+		return
+	}
+
+	d.entries[key] = &dedupEntry{}
+	d.mu.Unlock()
+
+	d.output(msg)
+	time.AfterFunc(d.window, func() { d.flush(key) })
+}
+
+// flush 窗口结束：输出重复次数汇总并清除该key
+func (d *DedupLogger) flush(key string) {
+	d.mu.Lock()
+	entry := d.entries[key]
+	delete(d.entries, key)
+	d.mu.Unlock()
+
+	if entry != nil && entry.repeated > 0 {
+		d.output(fmt.Sprintf("%s (repeated %d times in last %s)", entry.last, entry.repeated, d.window))
+	}
+}
+
+// DedupLog REST重试、WebSocket重连等高频错误日志共用的合并器（60秒窗口）
+var DedupLog = NewDedupLogger(60*time.Second, 1000)
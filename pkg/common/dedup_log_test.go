@@ -0,0 +1,76 @@
+package common
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureDedupLogger 输出写入切片的合并器
+func captureDedupLogger(window time.Duration, maxKeys int) (*DedupLogger, func() []string) {
+	var mu sync.Mutex
+	var lines []string
+	d := NewDedupLogger(window, maxKeys)
+	d.output = func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, msg)
+	}
+	return d, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), lines...)
+	}
+}
+
+func TestDedupLoggerCollapsesRepeats(t *testing.T) {
+	d, lines := captureDedupLogger(30*time.Millisecond, 10)
+	for i := 1; i <= 5; i++ {
+		d.Printf("rest", "attempt %d failed", i)
+	}
+	if got := lines(); len(got) != 1 || got[0] != "attempt 1 failed" {
+		t.Fatalf("lines within window = %q, want only the first", got)
+	}
+
+	// 窗口结束时输出一条带最后一次消息的汇总
+	deadline := time.Now().Add(time.Second)
+	for len(lines()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("no summary after the window")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	got := lines()
+	if len(got) != 2 || !strings.HasPrefix(got[1], "attempt 5 failed (repeated 4 times") {
+		t.Fatalf("lines = %q, want summary of the last message", got)
+	}
+}
+
+func TestDedupLoggerKeepsDistinctMessages(t *testing.T) {
+	d, lines := captureDedupLogger(time.Minute, 10)
+	d.Printf("rest", "timeout after %v", time.Second)
+	d.Printf("rest", "status %d", 429)
+	d.Printf("ws", "timeout after %v", time.Second) // 不同子系统不合并
+
+	got := lines()
+	want := []string{"timeout after 1s", "status 429", "timeout after 1s"}
+	if len(got) != len(want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("lines = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestDedupLoggerBypassesWhenKeysExhausted(t *testing.T) {
+	d, lines := captureDedupLogger(time.Minute, 1)
+	d.Printf("a", "first")
+	d.Printf("b", "second")
+	d.Printf("b", "second")
+	if got := lines(); len(got) != 3 {
+		t.Fatalf("lines = %q, want every message once the key limit is reached", got)
+	}
+}
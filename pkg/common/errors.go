@@ -0,0 +1,182 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 交易所REST错误分类，用 errors.Is 判断
+var (
+	ErrRateLimited = errors.New("rate limited")          // 被限频，需要退避（RetryAfter 给出建议等待时长）
+	ErrTemporary   = errors.New("temporary error")       // 网络错误或服务端5xx，可以重试
+	ErrBadRequest  = errors.New("bad request")           // 参数错误（例如symbol不存在），重试没有意义
+	ErrAuth        = errors.New("authentication failed") // 签名/API Key 错误，重试没有意义
+)
+
+// 错误分类名称（统计和日志使用）
+const (
+	ErrorClassRateLimited = "rate_limited"
+	ErrorClassTemporary   = "temporary"
+	ErrorClassBadRequest  = "bad_request"
+	ErrorClassAuth        = "auth"
+	ErrorClassUnknown     = "unknown"
+)
+
+// APIError 交易所REST接口返回的错误（已分类）
+type APIError struct {
+	Exchange   Exchange
+	Kind       error         // ErrRateLimited / ErrTemporary / ErrBadRequest / ErrAuth
+	StatusCode int           // HTTP状态码（0表示请求未得到HTTP响应）
+	Code       int           // 交易所错误码（没有时为0）
+	Message    string        // 交易所错误信息或响应内容
+	RetryAfter time.Duration // 限频时交易所建议的等待时长（没有时为0）
+}
+
+// Error 实现 error 接口
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s API error (%v)", e.Exchange, e.Kind)
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, ": status=%d", e.StatusCode)
+	}
+	if e.Code != 0 {
+		fmt.Fprintf(&b, ", code=%d", e.Code)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ", msg=%s", e.Message)
+	}
+	if e.RetryAfter > 0 {
+		fmt.Fprintf(&b, ", retry after %v", e.RetryAfter)
+	}
+	return b.String()
+}
+
+// Unwrap 返回错误分类，使 errors.Is(err, ErrRateLimited) 等判断生效
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// NewHTTPError 根据HTTP响应创建分类错误
+// 响应体为 Binance 风格的 {"code":-1003,"msg":"..."} 时按错误码细分，限频时 Retry-After 头解析为建议等待时长
+func NewHTTPError(exchange Exchange, statusCode int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{
+		Exchange:   exchange,
+		Kind:       ClassifyHTTPStatus(statusCode),
+		StatusCode: statusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+
+	var payload struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Code != 0 {
+		apiErr.Code = payload.Code
+		if payload.Msg != "" {
+			apiErr.Message = payload.Msg
+		}
+		if kind := ClassifyBinanceCode(payload.Code); kind != nil {
+			apiErr.Kind = kind
+		}
+	}
+
+	if header != nil && apiErr.Kind == ErrRateLimited {
+		apiErr.RetryAfter = parseRetryAfter(header.Get("Retry-After"))
+	}
+	return apiErr
+}
+
+// NewTemporaryError 将网络错误（超时、连接失败等）包装为可重试的分类错误
+func NewTemporaryError(exchange Exchange, err error) *APIError {
+	return &APIError{
+		Exchange: exchange,
+		Kind:     ErrTemporary,
+		Message:  err.Error(),
+	}
+}
+
+// ClassifyHTTPStatus 按HTTP状态码分类
+// 429/418（Binance IP封禁）和 403（Binance/Aster 的 WAF 限制）为限频，401 为认证错误，408 和 5xx 为临时错误，其他 4xx 为请求错误
+// 签名/API Key 错误由响应体中的错误码区分（见 ClassifyBinanceCode）
+func ClassifyHTTPStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusTeapot || statusCode == http.StatusForbidden:
+		return ErrRateLimited
+	case statusCode == http.StatusUnauthorized:
+		return ErrAuth
+	case statusCode == http.StatusRequestTimeout || statusCode >= 500:
+		return ErrTemporary
+	case statusCode >= 400:
+		return ErrBadRequest
+	}
+	return ErrTemporary
+}
+
+// ClassifyBinanceCode 按 Binance（及兼容的 Aster）错误码分类，未知错误码返回nil（沿用HTTP状态码分类）
+func ClassifyBinanceCode(code int) error {
+	switch code {
+	case -1003, -1015: // 请求过多 / 下单过多
+		return ErrRateLimited
+	case -1000, -1001, -1006, -1007, -1008: // 未知错误 / 断开 / 意外响应 / 超时 / 服务繁忙
+		return ErrTemporary
+	case -1002, -1021, -1022, -2014, -2015: // 未授权 / 时间戳超出窗口 / 签名无效 / API Key 无效
+		return ErrAuth
+	}
+	if code <= -1100 && code > -1200 { // -11xx 参数错误（包括 -1121 无效symbol）
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// ErrorClass 返回错误的分类名称（nil 返回空字符串）
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrRateLimited):
+		return ErrorClassRateLimited
+	case errors.Is(err, ErrTemporary):
+		return ErrorClassTemporary
+	case errors.Is(err, ErrBadRequest):
+		return ErrorClassBadRequest
+	case errors.Is(err, ErrAuth):
+		return ErrorClassAuth
+	}
+	return ErrorClassUnknown
+}
+
+// IsPermanentError 重试没有意义的错误（请求错误或认证错误）
+func IsPermanentError(err error) bool {
+	return errors.Is(err, ErrBadRequest) || errors.Is(err, ErrAuth)
+}
+
+// RetryAfter 返回限频错误建议的等待时长（非限频错误或交易所未给出时为0）
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && errors.Is(apiErr.Kind, ErrRateLimited) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或HTTP日期）
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClassifyHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusTeapot, ErrRateLimited},
+		{http.StatusForbidden, ErrRateLimited}, // Binance/Aster WAF 限制
+		{http.StatusUnauthorized, ErrAuth},
+		{http.StatusRequestTimeout, ErrTemporary},
+		{http.StatusInternalServerError, ErrTemporary},
+		{http.StatusBadGateway, ErrTemporary},
+		{http.StatusServiceUnavailable, ErrTemporary},
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusNotFound, ErrBadRequest},
+		{0, ErrTemporary},
+	}
+	for _, tt := range tests {
+		if got := ClassifyHTTPStatus(tt.status); got != tt.want {
+			t.Errorf("ClassifyHTTPStatus(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		wantKind   error
+		wantClass  string
+		wantCode   int
+		wantRetry  time.Duration
+		permanent  bool
+	}{
+		{"429 with Retry-After", 429, "30", `{"code":-1003,"msg":"Too many requests"}`, ErrRateLimited, ErrorClassRateLimited, -1003, 30 * time.Second, false},
+		{"403 WAF without body", 403, "", "", ErrRateLimited, ErrorClassRateLimited, 0, 0, false},
+		{"403 with Retry-After", 403, "5", "<html>forbidden</html>", ErrRateLimited, ErrorClassRateLimited, 0, 5 * time.Second, false},
+		{"401 invalid key", 401, "", `{"code":-2015,"msg":"Invalid API-key"}`, ErrAuth, ErrorClassAuth, -2015, 0, true},
+		{"400 invalid symbol", 400, "", `{"code":-1121,"msg":"Invalid symbol."}`, ErrBadRequest, ErrorClassBadRequest, -1121, 0, true},
+		{"400 rate limit code overrides status", 400, "10", `{"code":-1003,"msg":"Too many requests"}`, ErrRateLimited, ErrorClassRateLimited, -1003, 10 * time.Second, false},
+		{"503 busy", 503, "", `{"code":-1008,"msg":"Server busy"}`, ErrTemporary, ErrorClassTemporary, -1008, 0, false},
+		{"400 unknown code keeps status", 400, "", `{"code":-9999,"msg":"?"}`, ErrBadRequest, ErrorClassBadRequest, -9999, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			err := NewHTTPError(ExchangeBinance, tt.status, header, []byte(tt.body))
+			wrapped := fmt.Errorf("fetch: %w", err)
+
+			if !errors.Is(wrapped, tt.wantKind) {
+				t.Errorf("kind = %v, want %v", err.Kind, tt.wantKind)
+			}
+			if got := ErrorClass(wrapped); got != tt.wantClass {
+				t.Errorf("class = %q, want %q", got, tt.wantClass)
+			}
+			if err.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", err.Code, tt.wantCode)
+			}
+			if got := RetryAfter(wrapped); got != tt.wantRetry {
+				t.Errorf("retry after = %v, want %v", got, tt.wantRetry)
+			}
+			if got := IsPermanentError(wrapped); got != tt.permanent {
+				t.Errorf("permanent = %v, want %v", got, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestErrorClassUnclassified(t *testing.T) {
+	if got := ErrorClass(nil); got != "" {
+		t.Errorf("ErrorClass(nil) = %q", got)
+	}
+	if got := ErrorClass(errors.New("boom")); got != ErrorClassUnknown {
+		t.Errorf("ErrorClass(plain) = %q, want %q", got, ErrorClassUnknown)
+	}
+	if got := ErrorClass(NewTemporaryError(ExchangeAster, errors.New("timeout"))); got != ErrorClassTemporary {
+		t.Errorf("ErrorClass(network) = %q, want %q", got, ErrorClassTemporary)
+	}
+}
@@ -0,0 +1,46 @@
+package common
+
+import "sync"
+
+// ConcurrencyLimiter 并发请求限制器（信号量）
+// 用于限制同一交易所同时进行中的REST请求数量，避免触发限频
+type ConcurrencyLimiter struct {
+	mu  sync.Mutex
+	sem chan struct{}
+}
+
+// NewConcurrencyLimiter 创建并发限制器（limit <= 0 时按1处理）
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	if limit <= 0 {
+		limit = 1
+	}
+	return &ConcurrencyLimiter{sem: make(chan struct{}, limit)}
+}
+
+// Acquire 获取一个并发名额（阻塞直到可用），返回释放函数
+func (l *ConcurrencyLimiter) Acquire() func() {
+	l.mu.Lock()
+	sem := l.sem
+	l.mu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// SetLimit 修改并发上限（已获取名额的请求不受影响）
+func (l *ConcurrencyLimiter) SetLimit(limit int) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sem = make(chan struct{}, limit)
+}
+
+// InFlight 当前进行中的请求数
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.sem)
+}
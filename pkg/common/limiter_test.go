@@ -0,0 +1,60 @@
+package common
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// maxConcurrent 用 workers 个协程通过限制器执行任务，返回观察到的最大并发数
+func maxConcurrent(l *ConcurrencyLimiter, workers int) int32 {
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release := l.Acquire()
+			defer release()
+
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			inFlight.Add(-1)
+		}()
+	}
+	wg.Wait()
+	return peak.Load()
+}
+
+func TestConcurrencyLimiterBoundsInFlight(t *testing.T) {
+	for _, limit := range []int{1, 2, 5} {
+		l := NewConcurrencyLimiter(limit)
+		if peak := maxConcurrent(l, 20); peak > int32(limit) {
+			t.Fatalf("limit %d: peak concurrency %d", limit, peak)
+		} else if peak != int32(limit) {
+			t.Fatalf("limit %d: peak concurrency %d, limiter did not allow full parallelism", limit, peak)
+		}
+		if n := l.InFlight(); n != 0 {
+			t.Fatalf("limit %d: %d requests still in flight after release", limit, n)
+		}
+	}
+}
+
+func TestConcurrencyLimiterSetLimit(t *testing.T) {
+	l := NewConcurrencyLimiter(0) // <= 0 按1处理
+	if peak := maxConcurrent(l, 5); peak != 1 {
+		t.Fatalf("peak = %d with default limit, want 1", peak)
+	}
+
+	l.SetLimit(3)
+	if peak := maxConcurrent(l, 10); peak != 3 {
+		t.Fatalf("peak = %d after SetLimit(3), want 3", peak)
+	}
+}
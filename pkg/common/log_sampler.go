@@ -0,0 +1,49 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// LogSampler 按key采样日志：同一个key在 interval 内最多输出一次
+// 用于行情热路径上的调试日志，避免高频tick刷屏
+type LogSampler struct {
+	mu       sync.Mutex
+	interval time.Duration
+	lastLog  map[string]time.Time
+}
+
+// NewLogSampler 创建日志采样器（interval <= 0 表示关闭日志）
+func NewLogSampler(interval time.Duration) *LogSampler {
+	return &LogSampler{
+		interval: interval,
+		lastLog:  make(map[string]time.Time),
+	}
+}
+
+// SetInterval 修改采样间隔（<= 0 表示关闭日志）
+func (s *LogSampler) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+}
+
+// Allow 判断该key本次是否应该输出日志
+func (s *LogSampler) Allow(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.interval <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	if last, exists := s.lastLog[key]; exists && now.Sub(last) < s.interval {
+		return false
+	}
+	s.lastLog[key] = now
+	return true
+}
+
+// TickerLogSampler 各交易所 BookTicker 调试日志共用的采样器（默认每个symbol每5秒一次）
+var TickerLogSampler = NewLogSampler(5 * time.Second)
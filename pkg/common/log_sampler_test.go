@@ -0,0 +1,55 @@
+package common
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLogSamplerAtMostOncePerInterval(t *testing.T) {
+	const interval = 40 * time.Millisecond
+	s := NewLogSampler(interval)
+
+	// 多个协程以tick速率争用同一个key
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	start := time.Now()
+	stop := start.Add(6 * interval)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(stop) {
+				if s.Allow("BINANCE_FUTURE_BTCUSDT") {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	maxAllowed := int32(elapsed/interval) + 1
+	if n := allowed.Load(); n < 2 || n > maxAllowed {
+		t.Fatalf("%d lines in %v, want between 2 and %d", n, elapsed, maxAllowed)
+	}
+}
+
+func TestLogSamplerKeysAreIndependent(t *testing.T) {
+	s := NewLogSampler(time.Hour)
+	if !s.Allow("BTCUSDT") || !s.Allow("ETHUSDT") {
+		t.Fatal("first line of each key suppressed")
+	}
+	if s.Allow("BTCUSDT") || s.Allow("ETHUSDT") {
+		t.Fatal("second line within the interval allowed")
+	}
+}
+
+func TestLogSamplerDisabled(t *testing.T) {
+	s := NewLogSampler(time.Hour)
+	s.SetInterval(0)
+	if s.Allow("BTCUSDT") {
+		t.Fatal("line allowed with interval 0")
+	}
+}
@@ -0,0 +1,68 @@
+// Package numutil 交易所数据中数字字符串的解析和价格/数量的显示格式
+// 所有交易所客户端、Web 接口和命令行工具共用，避免各自的 parseFloat 行为不一致
+package numutil
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseFloatStrict 解析数字字符串：去除首尾空白，支持科学计数法（Lighter 对极小价格会返回 1e-7 这种形式）
+// 空字符串、"null"、无法解析的值以及 NaN/Inf 都返回错误
+func ParseFloatStrict(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "null" {
+		return 0, fmt.Errorf("empty number %q", s)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("non-finite number %q", s)
+	}
+	return f, nil
+}
+
+// ParseFloatOK 与 ParseFloatStrict 规则相同，失败时返回 0, false
+func ParseFloatOK(s string) (float64, bool) {
+	f, err := ParseFloatStrict(s)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// ParseFloat 与 ParseFloatStrict 规则相同，失败时返回0（交易所字段缺失或格式异常时按没有数据处理）
+func ParseFloat(s string) float64 {
+	f, _ := ParseFloatOK(s)
+	return f
+}
+
+// FormatPrice 价格显示格式：统一8位小数，确保能看出不同交易所之间的差异；0显示为 "-"
+func FormatPrice(num float64) string {
+	if num == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.8f", num)
+}
+
+// FormatQty 数量显示格式：按数量级选择小数位数（<0.01 为8位，<1 为6位，<100 为4位，其余2位）；0显示为 "-"
+func FormatQty(num float64) string {
+	if num == 0 {
+		return "-"
+	}
+	switch {
+	case num < 0.01:
+		return fmt.Sprintf("%.8f", num)
+	case num < 1:
+		return fmt.Sprintf("%.6f", num)
+	case num < 100:
+		return fmt.Sprintf("%.4f", num)
+	default:
+		return fmt.Sprintf("%.2f", num)
+	}
+}
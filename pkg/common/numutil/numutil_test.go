@@ -0,0 +1,125 @@
+package numutil
+
+import (
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   float64
+		wantOK bool
+	}{
+		{"1.5", 1.5, true},
+		{" 1.5 ", 1.5, true},
+		{"\t42\n", 42, true},
+		{"-0.25", -0.25, true},
+		{"1e-7", 1e-7, true},
+		{"2.5E+3", 2500, true},
+		{"0", 0, true},
+		{"1e308", 1e308, true},
+		{"123456789012345678901234567890", 1.2345678901234568e29, true},
+		{"", 0, false},
+		{"   ", 0, false},
+		{"null", 0, false},
+		{"abc", 0, false},
+		{"1.2.3", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+		{"-Infinity", 0, false},
+		{"1e400", 0, false}, // 溢出
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParseFloatOK(tt.in)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("ParseFloatOK(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+			if got := ParseFloat(tt.in); got != tt.want {
+				t.Fatalf("ParseFloat(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			strict, err := ParseFloatStrict(tt.in)
+			if (err == nil) != tt.wantOK || strict != tt.want {
+				t.Fatalf("ParseFloatStrict(%q) = %v, %v", tt.in, strict, err)
+			}
+			if math.IsNaN(got) || math.IsInf(got, 0) {
+				t.Fatalf("ParseFloat(%q) returned non-finite %v", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "-"},
+		{1, "1.00000000"},
+		{0.00000012, "0.00000012"},
+		{65432.1, "65432.10000000"},
+		{-1.5, "-1.50000000"},
+	}
+	for _, tt := range tests {
+		if got := FormatPrice(tt.in); got != tt.want {
+			t.Errorf("FormatPrice(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatQty(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "-"},
+		{0.005, "0.00500000"},
+		{0.01, "0.010000"},
+		{0.5, "0.500000"},
+		{1, "1.0000"},
+		{99.5, "99.5000"},
+		{100, "100.00"},
+		{1234567.891, "1234567.89"},
+	}
+	for _, tt := range tests {
+		if got := FormatQty(tt.in); got != tt.want {
+			t.Errorf("FormatQty(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestNoStrayParseFloat 交易所数据的数字解析都应经过 numutil（config 解析环境变量除外）
+func TestNoStrayParseFloat(t *testing.T) {
+	root := filepath.Join("..", "..", "..")
+	for _, dir := range []string{"internal", "cmd", "pkg"} {
+		err := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+			if filepath.Base(filepath.Dir(path)) == "numutil" {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			for i, line := range strings.Split(string(data), "\n") {
+				if strings.Contains(line, "strconv.ParseFloat") {
+					t.Errorf("%s:%d: use numutil.ParseFloat instead of strconv.ParseFloat", path, i+1)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -7,6 +7,20 @@ type SymbolInfo struct {
 	OriginalSymbol string        // 原始symbol (如 ETHUSDC, LITUSDT)
 	BaseAsset      string        // 基础资产 (如 ETH, LIT)
 	QuoteAsset     QuoteCurrency // 报价货币 (如 USDC, USDT)
+	Inverse        bool          // 是否为币本位（反向）合约 (如 BTCUSD_PERP)
+}
+
+// InverseSymbolSuffix 币本位合约的标准symbol后缀，与USDT交易对使用不同的命名空间
+const InverseSymbolSuffix = "USD_INVERSE"
+
+// IsInverseSymbol 判断是否为币本位（反向）合约symbol
+// 币本位合约以USD计价、按张结算，例如 BTCUSD_PERP（永续）、BTCUSD_250328（交割）
+func IsInverseSymbol(symbol string) bool {
+	symbol = strings.ToUpper(symbol)
+	if strings.HasSuffix(symbol, "_PERP") {
+		return true
+	}
+	return strings.Index(symbol, "USD_") > 0
 }
 
 // ParseSymbol 解析symbol,提取base asset和quote currency
@@ -14,6 +28,18 @@ type SymbolInfo struct {
 func ParseSymbol(symbol string) *SymbolInfo {
 	symbol = strings.ToUpper(symbol)
 
+	// 币本位合约：BTCUSD_PERP -> base=BTC，标准symbol为 BTCUSD_INVERSE，不会与 BTCUSDT 配对
+	if IsInverseSymbol(symbol) {
+		baseAsset := symbol[:strings.Index(symbol, "_")]
+		baseAsset = strings.TrimSuffix(baseAsset, "USD")
+		return &SymbolInfo{
+			OriginalSymbol: symbol,
+			BaseAsset:      baseAsset,
+			QuoteAsset:     QuoteCurrencyUSDT, // 以USD计价，不做汇率转换
+			Inverse:        true,
+		}
+	}
+
 	// 按长度从长到短尝试匹配quote currencies
 	// 顺序重要: FDUSD(5字符) > USDT/USDC/USDE(4字符)
 	quoteCurrencies := []QuoteCurrency{
@@ -51,8 +77,31 @@ func ParseSymbol(symbol string) *SymbolInfo {
 	}
 }
 
+// ParseSymbolWithQuote 按数据源声明的报价货币解析symbol，quote 为空时等同于 ParseSymbol
+// 数据源从市场元数据得知报价货币时（例如 Lighter 的 USDC 永续）以声明为准，不再按后缀猜测
+func ParseSymbolWithQuote(symbol string, quote QuoteCurrency) *SymbolInfo {
+	info := ParseSymbol(symbol)
+	if quote == "" || info.Inverse {
+		return info
+	}
+
+	// 带声明的报价后缀时去掉后缀，否则整个symbol就是基础资产（例如 "SUSDE" 不应被拆成 S/USDE）
+	quote = QuoteCurrency(strings.ToUpper(string(quote)))
+	upper := strings.ToUpper(symbol)
+	info.BaseAsset = upper
+	if base := strings.TrimSuffix(upper, string(quote)); base != upper && base != "" {
+		info.BaseAsset = base
+	}
+	info.QuoteAsset = quote
+	return info
+}
+
 // ToStandardSymbol 转换为标准symbol (总是使用USDT后缀)
+// 币本位合约使用独立的 BASEUSD_INVERSE 命名空间
 func (si *SymbolInfo) ToStandardSymbol() string {
+	if si.Inverse {
+		return si.BaseAsset + InverseSymbolSuffix
+	}
 	return si.BaseAsset + "USDT"
 }
 
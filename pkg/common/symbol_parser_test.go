@@ -0,0 +1,49 @@
+package common
+
+import "testing"
+
+func TestParseSymbolWithQuote(t *testing.T) {
+	tests := []struct {
+		symbol    string
+		quote     QuoteCurrency
+		wantBase  string
+		wantQuote QuoteCurrency
+		standard  string
+	}{
+		{"ETHUSDC", "", "ETH", QuoteCurrencyUSDC, "ETHUSDT"},
+		{"ETHUSDC", QuoteCurrencyUSDC, "ETH", QuoteCurrencyUSDC, "ETHUSDT"},
+		{"PYTHUSDT", "usdt", "PYTH", QuoteCurrencyUSDT, "PYTHUSDT"},
+		{"SUSDEUSDT", QuoteCurrencyUSDT, "SUSDE", QuoteCurrencyUSDT, "SUSDEUSDT"},
+		{"SUSDE", QuoteCurrencyUSDT, "SUSDE", QuoteCurrencyUSDT, "SUSDEUSDT"}, // 不按后缀拆成 S/USDE
+		{"SUSDE", "", "S", QuoteCurrencyUSDE, "SUSDT"},
+		{"BTCUSD_PERP", QuoteCurrencyUSDC, "BTC", QuoteCurrencyUSDT, "BTC" + InverseSymbolSuffix},
+	}
+	for _, tt := range tests {
+		info := ParseSymbolWithQuote(tt.symbol, tt.quote)
+		if info.BaseAsset != tt.wantBase || info.QuoteAsset != tt.wantQuote || info.ToStandardSymbol() != tt.standard {
+			t.Errorf("ParseSymbolWithQuote(%q, %q) = %s/%s (%s), want %s/%s (%s)",
+				tt.symbol, tt.quote, info.BaseAsset, info.QuoteAsset, info.ToStandardSymbol(),
+				tt.wantBase, tt.wantQuote, tt.standard)
+		}
+	}
+}
+
+func TestIsInverseSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   bool
+	}{
+		{"BTCUSD_PERP", true},
+		{"ethusd_perp", true},
+		{"BTCUSD_250328", true},
+		{"BTCUSDT", false},
+		{"BTCUSDT_250328", false}, // U本位交割合约
+		{"SUSDEUSDT", false},
+		{"USD_X", false},
+	}
+	for _, tt := range tests {
+		if got := IsInverseSymbol(tt.symbol); got != tt.want {
+			t.Errorf("IsInverseSymbol(%q) = %v, want %v", tt.symbol, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"strings"
+	"time"
+)
+
+// UnixMilliUTC 交易所毫秒时间戳转换为UTC时间（time.UnixMilli 返回本地时区，序列化时会带主机的时区偏移）
+func UnixMilliUTC(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+// LoadDisplayLocation 解析显示时区：空或 Local 为主机时区，其余按IANA名称（如 Asia/Shanghai、UTC）加载
+// 存储和API序列化的时间统一为UTC，只有面向人的输出（终端表格、页面）按显示时区转换
+func LoadDisplayLocation(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "Local") {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUnixMilliUTC(t *testing.T) {
+	saved := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	defer func() { time.Local = saved }()
+
+	ms := int64(1700000000123)
+	ts := UnixMilliUTC(ms)
+	if ts.Location() != time.UTC || ts.UnixMilli() != ms {
+		t.Fatalf("UnixMilliUTC(%d) = %v", ms, ts)
+	}
+	encoded, _ := json.Marshal(ts)
+	if string(encoded) != `"2023-11-14T22:13:20.123Z"` {
+		t.Fatalf("serialized as %s, want UTC", encoded)
+	}
+}
+
+func TestLoadDisplayLocation(t *testing.T) {
+	for _, name := range []string{"", "Local", " local "} {
+		if loc, err := LoadDisplayLocation(name); err != nil || loc != time.Local {
+			t.Fatalf("LoadDisplayLocation(%q) = %v, %v, want Local", name, loc, err)
+		}
+	}
+	if loc, err := LoadDisplayLocation("UTC"); err != nil || loc.String() != "UTC" {
+		t.Fatalf("LoadDisplayLocation(UTC) = %v, %v", loc, err)
+	}
+	if _, err := LoadDisplayLocation("Not/AZone"); err == nil {
+		t.Fatal("invalid zone accepted")
+	}
+}
@@ -10,6 +10,21 @@ const (
 	MarketTypeFuture MarketType = "FUTURE"
 )
 
+// ValidMarketTypes 返回所有合法的市场类型
+func ValidMarketTypes() []MarketType {
+	return []MarketType{MarketTypeSpot, MarketTypeFuture}
+}
+
+// IsValidMarketType 判断市场类型是否为已定义的常量
+func IsValidMarketType(m MarketType) bool {
+	for _, valid := range ValidMarketTypes() {
+		if m == valid {
+			return true
+		}
+	}
+	return false
+}
+
 // Exchange 交易所名称
 type Exchange string
 
@@ -23,6 +38,29 @@ const (
 	ExchangeLighter     Exchange = "LIGHTER"
 )
 
+// ValidExchanges 返回所有合法的交易所名称（新增交易所常量时需要同步添加）
+func ValidExchanges() []Exchange {
+	return []Exchange{
+		ExchangeAster,
+		ExchangeBinance,
+		ExchangeBitget,
+		ExchangeBybit,
+		ExchangeGate,
+		ExchangeHyperliquid,
+		ExchangeLighter,
+	}
+}
+
+// IsValidExchange 判断交易所名称是否为已定义的常量
+func IsValidExchange(e Exchange) bool {
+	for _, valid := range ValidExchanges() {
+		if e == valid {
+			return true
+		}
+	}
+	return false
+}
+
 // PriceSource 价格数据来源
 type PriceSource string
 
@@ -53,6 +91,37 @@ type Price struct {
 	ExchangeRate       float64       `json:"exchange_rate"`         // 使用的汇率
 	ExchangeRateSource string        `json:"exchange_rate_source"`  // 汇率来源
 	IsNormalized       bool          `json:"is_normalized"`         // 是否已标准化
+
+	// Volume24h 是否来自真实的成交量数据；bookTicker 等不含成交量的数据源为false，此时 Volume24h 为0表示未知而不是没有成交
+	VolumeKnown bool `json:"volume_known"`
+
+	// 数量级倍数修正（例如 1000PEPEUSDT 按 PEPEUSDT 计价时为1000），0表示未修正
+	AppliedMultiplier float64 `json:"applied_multiplier,omitempty"`
+
+	// bid/ask 是否为估算值（只有单边订单簿或只有mark price时按固定价差推算），而不是真实盘口
+	SyntheticSpread bool `json:"synthetic_spread,omitempty"`
+
+	// 深度加权的bid/ask（按名义金额逐档成交的VWAP），只有维护本地订单簿的数据源提供，0表示没有
+	// 存储的价格模式为 depth_weighted 时价差计算优先使用，薄盘口下比买一/卖一更稳健
+	DepthBidPrice float64 `json:"depth_bid_price,omitempty"`
+	DepthAskPrice float64 `json:"depth_ask_price,omitempty"`
+
+	// 永续合约的资金费率（交易所原始值）和未平仓量，交易所未提供时为0
+	FundingRate  float64 `json:"funding_rate,omitempty"`
+	OpenInterest float64 `json:"open_interest,omitempty"`
+
+	// FundingRate 是否来自交易所数据；REST orderBookDetails 等不含资金费率的数据源为false，此时0表示未知而不是零费率
+	FundingKnown bool `json:"funding_known,omitempty"`
+
+	// 永续合约的标记价格和指数价格（交易所提供时），用于过滤明显偏离的盘口报价，0表示没有
+	MarkPrice  float64 `json:"mark_price,omitempty"`
+	IndexPrice float64 `json:"index_price,omitempty"`
+
+	// 存储序列号：PriceStore 每接受一次更新分配一个全局递增的值（仅在进程生命周期内有效）
+	Seq uint64 `json:"seq"`
+
+	// 中间价的短期漂移（基点/秒）：PriceStore 开启延迟补偿时按交易所时间相隔至少 DriftMinSpacing 的两次报价计算，0表示没有
+	MidDrift float64 `json:"mid_drift_bps_per_sec,omitempty"`
 }
 
 // NormalizeToUSDT 标准化价格到USDT
@@ -73,6 +142,10 @@ func (p *Price) NormalizeToUSDT(rate float64, rateSource string) {
 	p.BidPrice = p.BidPrice * rate
 	p.AskPrice = p.AskPrice * rate
 	p.Price = (p.BidPrice + p.AskPrice) / 2
+	p.DepthBidPrice = p.DepthBidPrice * rate
+	p.DepthAskPrice = p.DepthAskPrice * rate
+	p.MarkPrice = p.MarkPrice * rate
+	p.IndexPrice = p.IndexPrice * rate
 
 	// 记录转换信息
 	p.ExchangeRate = rate
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+// declaredConstants 解析 types.go，返回类型为 typeName 的常量值
+func declaredConstants(t *testing.T, typeName string) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "types.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			ident, ok := vs.Type.(*ast.Ident)
+			if !ok || ident.Name != typeName {
+				continue
+			}
+			for _, v := range vs.Values {
+				if lit, ok := v.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					values = append(values, lit.Value[1:len(lit.Value)-1])
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		t.Fatalf("no %s constants found in types.go", typeName)
+	}
+	return values
+}
+
+func TestEveryDeclaredExchangeIsValid(t *testing.T) {
+	declared := declaredConstants(t, "Exchange")
+	for _, name := range declared {
+		if !IsValidExchange(Exchange(name)) {
+			t.Errorf("Exchange constant %q missing from ValidExchanges", name)
+		}
+	}
+	if len(ValidExchanges()) != len(declared) {
+		t.Errorf("ValidExchanges has %d entries, types.go declares %d", len(ValidExchanges()), len(declared))
+	}
+}
+
+func TestEveryDeclaredMarketTypeIsValid(t *testing.T) {
+	declared := declaredConstants(t, "MarketType")
+	for _, name := range declared {
+		if !IsValidMarketType(MarketType(name)) {
+			t.Errorf("MarketType constant %q missing from ValidMarketTypes", name)
+		}
+	}
+	if len(ValidMarketTypes()) != len(declared) {
+		t.Errorf("ValidMarketTypes has %d entries, types.go declares %d", len(ValidMarketTypes()), len(declared))
+	}
+}
+
+func TestUnknownValuesAreInvalid(t *testing.T) {
+	for _, e := range []Exchange{"", "BINANNCE", "binance", "OKX"} {
+		if IsValidExchange(e) {
+			t.Errorf("IsValidExchange(%q) = true", e)
+		}
+	}
+	for _, m := range []MarketType{"", "PERP", "spot"} {
+		if IsValidMarketType(m) {
+			t.Errorf("IsValidMarketType(%q) = true", m)
+		}
+	}
+}